@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	auditSince    string
+	auditUntil    string
+	auditKind     string
+	auditResource string
+)
+
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the recorded events in a time range",
+		Long: `List the events recorded in state, oldest first, to build an incident
+timeline. --since and --until take an RFC 3339 time or a duration before now,
+such as 2h; --since is inclusive and --until exclusive:
+
+  provctl audit --since 2024-05-01T12:00:00Z --until 2024-05-01T14:00:00Z
+  provctl audit --since 30m --kind Cluster`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showAudit()
+		},
+	}
+
+	cmd.Flags().StringVar(&auditSince, "since", "24h", "start of the range (RFC 3339 time or duration ago)")
+	cmd.Flags().StringVar(&auditUntil, "until", "", "end of the range (RFC 3339 time or duration ago; default now)")
+	cmd.Flags().StringVar(&auditKind, "kind", "", "only show events of this resource kind, such as Cluster or NodePool")
+	cmd.Flags().StringVar(&auditResource, "resource", "", "only show events of the resource with this name")
+
+	return cmd
+}
+
+func showAudit() error {
+	ctx := context.Background()
+	now := time.Now()
+
+	from, err := parseAuditTime(auditSince, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	to, err := parseAuditTime(auditUntil, now)
+	if err != nil {
+		return fmt.Errorf("invalid --until: %w", err)
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	var filters []api.ResourceID
+	if auditKind != "" || auditResource != "" {
+		filters = append(filters, api.ResourceID{Kind: auditKind, Name: auditResource})
+	}
+
+	events, err := sm.Events().GetEventsByTimeRange(ctx, from, to, filters...)
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+
+	writeAudit(os.Stdout, events)
+	return nil
+}
+
+// parseAuditTime parses an RFC 3339 time or a duration before now. An empty
+// value is the zero time, leaving that end of the range open.
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %s is negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration", value)
+	}
+	return t, nil
+}
+
+// writeAudit prints events as a table
+func writeAudit(out io.Writer, events []api.Event) {
+	if len(events) == 0 {
+		fmt.Fprintln(out, "No events in range.")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tRESOURCE\tACTOR\tDETAILS")
+	for _, event := range events {
+		actor := event.Actor
+		if actor == "" {
+			actor = "-"
+		}
+		details := "-"
+		if event.Payload != nil {
+			if payload, err := json.Marshal(event.Payload); err == nil {
+				details = string(payload)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%s\n", event.Timestamp.Local().Format(time.RFC3339),
+			event.Type, event.Resource.Kind, event.Resource.Name, actor, details)
+	}
+	w.Flush()
+}
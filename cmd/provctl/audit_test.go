@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2h", now.Add(-2 * time.Hour), false},
+		{"2024-04-30T08:00:00Z", time.Date(2024, 4, 30, 8, 0, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAuditTime(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAuditTime(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseAuditTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAudit(t *testing.T) {
+	var out bytes.Buffer
+	writeAudit(&out, nil)
+	if !strings.Contains(out.String(), "No events in range") {
+		t.Errorf("writeAudit() = %q, want an empty-range message", out.String())
+	}
+
+	out.Reset()
+	writeAudit(&out, []api.Event{{
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Type:      api.EventUpdated,
+		Resource:  api.ResourceID{Kind: "Cluster", Name: "prod"},
+		Payload:   map[string]interface{}{"version": "1.29"},
+	}})
+	for _, want := range []string{"Updated", "Cluster/prod", `{"version":"1.29"}`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writeAudit() = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
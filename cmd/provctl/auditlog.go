@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/audit"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+var (
+	auditLogPath string
+	auditEvents  bool
+)
+
+// newAuditWriter returns the writer of plan and apply audit records the
+// audit flags ask for, or nil when auditing is off
+func newAuditWriter(events engine.EventStore) audit.Writer {
+	var writers []audit.Writer
+	if auditLogPath != "" {
+		writers = append(writers, audit.NewFileWriter(auditLogPath))
+	}
+	if auditEvents && events != nil {
+		writers = append(writers, audit.NewEventWriter(events))
+	}
+
+	switch len(writers) {
+	case 0:
+		return nil
+	case 1:
+		return writers[0]
+	}
+	return audit.MultiWriter(writers...)
+}
+
+// auditPlan records a generated plan of the configuration or plan file at
+// source
+func auditPlan(ctx context.Context, w audit.Writer, source string, plan engine.Plan) error {
+	if w == nil {
+		return nil
+	}
+
+	record := audit.NewRecord(ctx, audit.KindPlan, plan)
+	record.Actor = currentUser()
+	record.Source = source
+	if err := w.Write(ctx, record); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// auditedApply runs apply between audit records of its start and its
+// outcome, recording plan as what it applies. Nothing is applied unless the
+// start is recorded.
+func auditedApply(ctx context.Context, w audit.Writer, source string, plan engine.Plan, apply func() error) error {
+	if w == nil {
+		return apply()
+	}
+
+	started := audit.NewRecord(ctx, audit.KindApplyStarted, plan)
+	started.Actor = currentUser()
+	started.Source = source
+	if err := w.Write(ctx, started); err != nil {
+		return fmt.Errorf("failed to write audit record, nothing was applied: %w", err)
+	}
+
+	err := apply()
+	if auditErr := w.Write(ctx, started.Finished(err, time.Now())); auditErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to write audit record: %w", auditErr))
+	}
+	return err
+}
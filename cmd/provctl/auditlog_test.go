@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/audit"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestAuditedApply(t *testing.T) {
+	dir := t.TempDir()
+	auditLogPath = filepath.Join(dir, "audit.jsonl")
+	defer func() { auditLogPath = "" }()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	provider := fake.NewProvider("aws")
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.RegisterProvider(provider)
+	auditor := newAuditWriter(sm.Events())
+
+	create := func(name string) engine.Plan {
+		return engine.Plan{Actions: []engine.Action{{
+			Type:       engine.ActionCreate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: name, Name: name},
+			Parameters: map[string]interface{}{"spec": api.ClusterSpec{Provider: "aws", Config: map[string]interface{}{"name": name}}},
+		}}}
+	}
+	run := func(plan engine.Plan) (string, error) {
+		ctx := engine.WithCorrelationID(context.Background(), engine.NewCorrelationID())
+		err := auditedApply(ctx, auditor, "clusters/", plan, func() error { return eng.Apply(ctx, plan) })
+		return engine.CorrelationIDFrom(ctx), err
+	}
+
+	succeeded, err := run(create("web"))
+	if err != nil {
+		t.Fatalf("auditedApply() error = %v", err)
+	}
+	provider.FailOn("CreateCluster", 0, errors.New("quota exceeded"))
+	failed, err := run(create("db"))
+	if err == nil {
+		t.Fatal("auditedApply() error = nil, want the apply failure")
+	}
+
+	f, err := os.Open(auditLogPath)
+	if err != nil {
+		t.Fatalf("opening audit log: %v", err)
+	}
+	defer f.Close()
+	var records []audit.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record audit.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("audit line %q is not a JSON record: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	want := []struct {
+		kind          audit.Kind
+		correlationID string
+		outcome       audit.Outcome
+	}{
+		{audit.KindApplyStarted, succeeded, ""},
+		{audit.KindApplyFinished, succeeded, audit.OutcomeSucceeded},
+		{audit.KindApplyStarted, failed, ""},
+		{audit.KindApplyFinished, failed, audit.OutcomeFailed},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("audit log has %d records, want %d: %+v", len(records), len(want), records)
+	}
+	for i, w := range want {
+		record := records[i]
+		if record.Kind != w.kind || record.CorrelationID != w.correlationID || record.Outcome != w.outcome {
+			t.Errorf("record %d = %s %s %s, want %s %s %s", i,
+				record.Kind, record.CorrelationID, record.Outcome, w.kind, w.correlationID, w.outcome)
+		}
+		if record.Source != "clusters/" || record.Summary.Creates != 1 || record.Time.IsZero() {
+			t.Errorf("record %d = %+v, want the source, plan summary and time", i, record)
+		}
+	}
+	if len(records[0].Actions) != 1 || records[0].Actions[0].Resource.Name != "web" {
+		t.Errorf("start record actions = %+v, want the full plan", records[0].Actions)
+	}
+	if records[3].Error == "" || records[3].DurationSeconds < 0 {
+		t.Errorf("failed apply record = %+v, want its error and duration", records[3])
+	}
+}
+
+func TestAuditedApplyRefusesWithoutAuditRecord(t *testing.T) {
+	// A directory cannot be opened for appending
+	auditLogPath = t.TempDir()
+	defer func() { auditLogPath = "" }()
+
+	applied := false
+	err := auditedApply(context.Background(), newAuditWriter(nil), "clusters/", engine.Plan{}, func() error {
+		applied = true
+		return nil
+	})
+	if err == nil || applied {
+		t.Errorf("auditedApply() error = %v, applied = %v; want an error and nothing applied", err, applied)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+	"github.com/vjranagit/cluster-api/pkg/validation"
+)
+
+var (
+	cloneRegion string
+	cloneSet    []string
+	cloneDryRun bool
+)
+
+func cloneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clone <source-cluster> <new-name>",
+		Short: "Create a new cluster from an existing cluster's spec",
+		Long: `Copy the spec of a cluster in state, apply overrides and create the copy
+under a new name. Overrides use dotted field paths as shown by compare;
+worker pools can be addressed by index or by name:
+
+  provctl clone production staging --region us-east-1
+  provctl clone production staging --set controlPlane.version=1.29 \
+    --set workerPools.general.desiredSize=1 --dry-run`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cloneCluster(cmd.Context(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&cloneRegion, "region", "", "create the copy in another region")
+	cmd.Flags().StringArrayVar(&cloneSet, "set", nil, "override a spec field, e.g. controlPlane.version=1.29 (repeatable)")
+	cmd.Flags().BoolVar(&cloneDryRun, "dry-run", false, "print the resulting spec without creating the cluster")
+
+	return cmd
+}
+
+func cloneCluster(ctx context.Context, source, name string) error {
+	logger := loggerFrom(ctx)
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	var sourceCluster *api.Cluster
+	for _, cluster := range current.Clusters {
+		switch cluster.Metadata.Name {
+		case source:
+			sourceCluster = cluster
+		case name:
+			return fmt.Errorf("cluster %s already exists in state", name)
+		}
+	}
+	if sourceCluster == nil {
+		return fmt.Errorf("cluster %s not found in state", source)
+	}
+
+	spec, err := cloneSpec(sourceCluster.Spec, name, cloneRegion, cloneSet)
+	if err != nil {
+		return err
+	}
+
+	result := validation.NewValidator().Validate(spec)
+	for _, warning := range result.Warnings {
+		logger.Warn("validation warning", "field", warning.Field, "message", warning.Message)
+	}
+	if err := result.Err(); err != nil {
+		return fmt.Errorf("invalid cluster spec: %w", err)
+	}
+
+	if cloneDryRun {
+		return writeSpec(os.Stdout, spec)
+	}
+
+	eng := engine.NewEngine(sm, sm.Events())
+	cloudProvider, err := newProvider(ctx, spec.Provider, spec.Region, eng.Events())
+	if err != nil {
+		return err
+	}
+	eng.RegisterProvider(cloudProvider)
+
+	// The name is a placeholder ID until the provider assigns one
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:       engine.ActionCreate,
+		Resource:   api.ResourceID{Provider: spec.Provider, Kind: "Cluster", ID: name, Name: name},
+		Parameters: map[string]interface{}{"spec": spec},
+	}}}
+	defer invalidateRefreshCache(ctx, sm, plan)
+	if err := eng.Apply(ctx, plan); err != nil {
+		return fmt.Errorf("clone failed: %w", err)
+	}
+
+	logger.Info("cluster cloned successfully",
+		"source", source,
+		"name", name,
+		"provider", spec.Provider,
+		"region", spec.Region,
+	)
+	return nil
+}
+
+// cloneSpec derives the spec for a copy of a cluster named name. Overrides
+// are path=value pairs applied with ClusterSpec.SetPath; they are applied in
+// place, so source must not be used afterwards. A cluster in an existing
+// network cannot be moved to another region, where that network is not.
+func cloneSpec(source api.ClusterSpec, name, region string, overrides []string) (api.ClusterSpec, error) {
+	spec := source
+	if region != "" && region != spec.Region {
+		if network := spec.Network; network.ExistingVPCID != "" || network.ExistingVNetID != "" || len(network.ExistingSubnetIDs) > 0 {
+			return api.ClusterSpec{}, fmt.Errorf("cannot clone into region %s: the cluster uses an existing network in %s", region, spec.Region)
+		}
+		moveRegion(&spec, region)
+	}
+
+	for _, override := range overrides {
+		path, value, ok := strings.Cut(override, "=")
+		if !ok || path == "" {
+			return api.ClusterSpec{}, fmt.Errorf("invalid --set %q: expected path=value", override)
+		}
+		if err := spec.SetPath(path, value); err != nil {
+			return api.ClusterSpec{}, fmt.Errorf("invalid --set %q: %w", override, err)
+		}
+	}
+
+	return spec.WithName(name), nil
+}
+
+// moveRegion switches a spec to another region, renaming availability zones
+// that carry the old region as a prefix (us-west-2a becomes us-east-1a).
+// Zones without the prefix, such as Azure's numbered zones, are kept.
+func moveRegion(spec *api.ClusterSpec, region string) {
+	rezone := func(zone string) string {
+		if suffix, ok := strings.CutPrefix(zone, spec.Region); ok {
+			return region + suffix
+		}
+		return zone
+	}
+
+	zones := make([]string, len(spec.Network.AvailabilityZones))
+	for i, zone := range spec.Network.AvailabilityZones {
+		zones[i] = rezone(zone)
+	}
+	spec.Network.AvailabilityZones = zones
+
+	subnets := make([]api.Subnet, len(spec.Network.Subnets))
+	for i, subnet := range spec.Network.Subnets {
+		subnet.AvailabilityZone = rezone(subnet.AvailabilityZone)
+		subnets[i] = subnet
+	}
+	spec.Network.Subnets = subnets
+
+	spec.Region = region
+}
+
+// writeSpec prints a cluster spec as indented JSON
+func writeSpec(out io.Writer, spec api.ClusterSpec) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(spec)
+}
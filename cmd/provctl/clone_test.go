@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func cloneSource() api.ClusterSpec {
+	return api.ClusterSpec{
+		Provider: "aws",
+		Region:   "us-west-2",
+		Network: api.NetworkSpec{
+			VPCCIDR:           "10.0.0.0/16",
+			AvailabilityZones: []string{"us-west-2a", "us-west-2b"},
+			Subnets:           []api.Subnet{{Name: "private-a", CIDR: "10.0.1.0/24", AvailabilityZone: "us-west-2a"}},
+		},
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.28"},
+		WorkerPools: []api.WorkerPoolSpec{
+			{Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 5, DesiredSize: 3},
+		},
+		Config: map[string]interface{}{"name": "production"},
+	}
+}
+
+func TestCloneSpec(t *testing.T) {
+	source := cloneSource()
+	spec, err := cloneSpec(source, "staging", "us-east-1", []string{
+		"controlPlane.version=1.29",
+		"workerPools.0.desiredSize=1",
+		"tags.Environment=staging",
+	})
+	if err != nil {
+		t.Fatalf("cloneSpec() error = %v", err)
+	}
+
+	if spec.Config["name"] != "staging" {
+		t.Errorf("name = %v, want staging", spec.Config["name"])
+	}
+	if source.Config["name"] != "production" {
+		t.Errorf("source name changed to %v", source.Config["name"])
+	}
+	if spec.Region != "us-east-1" {
+		t.Errorf("Region = %s, want us-east-1", spec.Region)
+	}
+	if want := []string{"us-east-1a", "us-east-1b"}; !reflect.DeepEqual(spec.Network.AvailabilityZones, want) {
+		t.Errorf("AvailabilityZones = %v, want %v", spec.Network.AvailabilityZones, want)
+	}
+	if zone := spec.Network.Subnets[0].AvailabilityZone; zone != "us-east-1a" {
+		t.Errorf("subnet zone = %s, want us-east-1a", zone)
+	}
+	if spec.ControlPlane.Version != "1.29" {
+		t.Errorf("Version = %s, want 1.29", spec.ControlPlane.Version)
+	}
+	if spec.WorkerPools[0].DesiredSize != 1 {
+		t.Errorf("DesiredSize = %d, want 1", spec.WorkerPools[0].DesiredSize)
+	}
+	if spec.Tags["Environment"] != "staging" {
+		t.Errorf("Tags = %v, want Environment=staging", spec.Tags)
+	}
+}
+
+func TestCloneSpec_KeepsUnprefixedZones(t *testing.T) {
+	source := cloneSource()
+	source.Provider = "azure"
+	source.Region = "eastus"
+	source.Network.AvailabilityZones = []string{"1", "2"}
+
+	spec, err := cloneSpec(source, "staging", "westeurope", nil)
+	if err != nil {
+		t.Fatalf("cloneSpec() error = %v", err)
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(spec.Network.AvailabilityZones, want) {
+		t.Errorf("AvailabilityZones = %v, want %v", spec.Network.AvailabilityZones, want)
+	}
+}
+
+func TestCloneSpec_ExistingNetwork(t *testing.T) {
+	source := cloneSource()
+	source.Network = api.NetworkSpec{ExistingVPCID: "vpc-123", ExistingSubnetIDs: []string{"subnet-a"}}
+
+	if _, err := cloneSpec(source, "staging", "us-east-1", nil); err == nil {
+		t.Error("cloneSpec() into another region succeeded, want an error for an existing network")
+	}
+	spec, err := cloneSpec(source, "staging", "us-west-2", nil)
+	if err != nil {
+		t.Fatalf("cloneSpec() error = %v", err)
+	}
+	if spec.Network.ExistingVPCID != "vpc-123" {
+		t.Errorf("ExistingVPCID = %s, want the source's vpc-123", spec.Network.ExistingVPCID)
+	}
+}
+
+func TestCloneSpec_InvalidOverride(t *testing.T) {
+	for _, override := range []string{"controlPlane.version", "=1.29", "controlPlane.release=1.29"} {
+		if _, err := cloneSpec(cloneSource(), "staging", "", []string{override}); err == nil {
+			t.Errorf("cloneSpec(--set %q) succeeded, want error", override)
+		}
+	}
+}
+
+func TestWriteSpec(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSpec(&buf, cloneSource()); err != nil {
+		t.Fatalf("writeSpec() error = %v", err)
+	}
+
+	var decoded api.ClusterSpec
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not a JSON spec: %v\n%s", err, buf.String())
+	}
+	if decoded.ControlPlane.Version != "1.28" {
+		t.Errorf("decoded Version = %s, want 1.28", decoded.ControlPlane.Version)
+	}
+}
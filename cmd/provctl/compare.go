@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	compareFiles  []string
+	compareIgnore []string
+	compareOutput string
+)
+
+func compareCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compare <cluster-a> <cluster-b>",
+		Short: "Show the spec differences between two clusters",
+		Long: `Compare the specs of two clusters field by field, for example to catch a
+staging cluster running a newer Kubernetes version than production.
+
+Clusters are read from state by default. With one --file both are read from
+that configuration; with two, cluster-a is read from the first file and
+cluster-b from the second:
+
+  provctl compare staging production
+  provctl compare staging production --file staging.hcl --file production.hcl
+  provctl compare staging production --ignore region --ignore tags -o json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return compareClusters(cmd.Context(), args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&compareFiles, "file", nil, "read clusters from an HCL file or directory instead of state (at most twice)")
+	cmd.Flags().StringArrayVar(&compareIgnore, "ignore", nil, "ignore differences under a field path, e.g. region or workerPools.gpu (repeatable)")
+	cmd.Flags().StringVarP(&compareOutput, "output", "o", "text", "output format (text or json)")
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+// SpecDifference is one field whose value differs between two clusters
+type SpecDifference struct {
+	Path string      `json:"path"`
+	A    interface{} `json:"a"`
+	B    interface{} `json:"b"`
+}
+
+// Comparison is the result of comparing two cluster specs
+type Comparison struct {
+	A           string           `json:"a"`
+	B           string           `json:"b"`
+	Differences []SpecDifference `json:"differences"`
+}
+
+func compareClusters(ctx context.Context, nameA, nameB string) error {
+	if compareOutput != "text" && compareOutput != "json" {
+		return fmt.Errorf("invalid --output %q: want text or json", compareOutput)
+	}
+	if len(compareFiles) > 2 {
+		return fmt.Errorf("--file may be given at most twice")
+	}
+
+	var specA, specB api.ClusterSpec
+	var err error
+	if len(compareFiles) == 0 {
+		specA, specB, err = clusterSpecsFromState(ctx, nameA, nameB)
+	} else {
+		fileA, fileB := compareFiles[0], compareFiles[len(compareFiles)-1]
+		if specA, err = clusterSpecFromFile(fileA, nameA); err == nil {
+			specB, err = clusterSpecFromFile(fileB, nameB)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	comparison := compareSpecs(nameA, nameB, specA, specB, compareIgnore)
+	if compareOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(comparison)
+	}
+	writeComparison(os.Stdout, comparison)
+	return nil
+}
+
+func clusterSpecsFromState(ctx context.Context, nameA, nameB string) (api.ClusterSpec, api.ClusterSpec, error) {
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return api.ClusterSpec{}, api.ClusterSpec{}, fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return api.ClusterSpec{}, api.ClusterSpec{}, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	specs := make(map[string]api.ClusterSpec, len(current.Clusters))
+	for _, cluster := range current.Clusters {
+		specs[cluster.Metadata.Name] = cluster.Spec
+	}
+	for _, name := range []string{nameA, nameB} {
+		if _, ok := specs[name]; !ok {
+			return api.ClusterSpec{}, api.ClusterSpec{}, fmt.Errorf("cluster %s not found in state", name)
+		}
+	}
+	return specs[nameA], specs[nameB], nil
+}
+
+func clusterSpecFromFile(path, name string) (api.ClusterSpec, error) {
+	file, err := loadConfig(path)
+	if err != nil {
+		return api.ClusterSpec{}, err
+	}
+	for _, block := range file.Clusters {
+		if block.Name == name {
+			return block.Spec, nil
+		}
+	}
+	return api.ClusterSpec{}, fmt.Errorf("cluster %s not found in %s", name, path)
+}
+
+// compareSpecs diffs two cluster specs, dropping differences under the
+// ignored paths. The name providers read from Config always differs between
+// two clusters, so it is never reported.
+func compareSpecs(nameA, nameB string, a, b api.ClusterSpec, ignore []string) Comparison {
+	comparison := Comparison{A: nameA, B: nameB, Differences: []SpecDifference{}}
+
+	for _, change := range a.Diff(b) {
+		if change.Path == "config.name" || ignoredPath(change.Path, ignore) {
+			continue
+		}
+		comparison.Differences = append(comparison.Differences, SpecDifference{
+			Path: change.Path,
+			A:    change.Old,
+			B:    change.New,
+		})
+	}
+	return comparison
+}
+
+// ignoredPath reports whether path is one of the ignored paths or lies
+// beneath one
+func ignoredPath(path string, ignore []string) bool {
+	for _, prefix := range ignore {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeComparison prints a comparison as a table with a column per cluster
+func writeComparison(out io.Writer, comparison Comparison) {
+	if len(comparison.Differences) == 0 {
+		fmt.Fprintf(out, "Clusters %s and %s have identical specs.\n", comparison.A, comparison.B)
+		return
+	}
+
+	fmt.Fprintf(out, "%d difference(s) between %s (A) and %s (B):\n\n", len(comparison.Differences), comparison.A, comparison.B)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "FIELD\tA: %s\tB: %s\n", comparison.A, comparison.B)
+	for _, difference := range comparison.Differences {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", difference.Path, api.FormatValue(difference.A), api.FormatValue(difference.B))
+	}
+	w.Flush()
+}
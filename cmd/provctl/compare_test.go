@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestCompareSpecs(t *testing.T) {
+	spec := func(name, version, region string, pools ...api.WorkerPoolSpec) api.ClusterSpec {
+		return api.ClusterSpec{
+			Provider:     "aws",
+			Region:       region,
+			ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: version},
+			WorkerPools:  pools,
+			Config:       map[string]interface{}{"name": name},
+		}
+	}
+	general := api.WorkerPoolSpec{Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 3}
+	bigger := general
+	bigger.InstanceType = "m5.xlarge"
+	gpu := api.WorkerPoolSpec{Name: "gpu", InstanceType: "p3.2xlarge", MaxSize: 2}
+
+	staging := spec("staging", "1.29", "us-east-1", general)
+	prod := spec("prod", "1.27", "us-west-2", bigger, gpu)
+
+	comparison := compareSpecs("staging", "prod", staging, prod, []string{"region"})
+	want := map[string][2]string{
+		"controlPlane.version":             {"1.29", "1.27"},
+		"workerPools.general.instanceType": {"m5.large", "m5.xlarge"},
+		"workerPools.gpu":                  {"(not set)", "(defined)"},
+	}
+	if len(comparison.Differences) != len(want) {
+		t.Fatalf("compareSpecs() = %+v, want %d differences", comparison.Differences, len(want))
+	}
+	for _, difference := range comparison.Differences {
+		values, ok := want[difference.Path]
+		if !ok {
+			t.Errorf("unexpected difference %s", difference.Path)
+			continue
+		}
+		if got := [2]string{api.FormatValue(difference.A), api.FormatValue(difference.B)}; got != values {
+			t.Errorf("%s = %v, want %v", difference.Path, got, values)
+		}
+	}
+
+	if got := compareSpecs("staging", "copy", staging, staging, nil); len(got.Differences) != 0 {
+		t.Errorf("compareSpecs() of identical specs = %+v, want none", got.Differences)
+	}
+}
+
+func TestWriteComparison(t *testing.T) {
+	var out bytes.Buffer
+	writeComparison(&out, Comparison{A: "staging", B: "prod", Differences: []SpecDifference{
+		{Path: "controlPlane.version", A: "1.29", B: "1.27"},
+	}})
+
+	for _, want := range []string{"A: staging", "B: prod", "controlPlane.version", "1.29", "1.27"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writeComparison() = %q, want it to contain %q", out.String(), want)
+		}
+	}
+
+	out.Reset()
+	writeComparison(&out, Comparison{A: "staging", B: "prod"})
+	if !strings.Contains(out.String(), "identical") {
+		t.Errorf("writeComparison() = %q, want an identical specs message", out.String())
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/config"
+)
+
+var (
+	configRecursive bool
+	configVars      []string
+	configVarFiles  []string
+)
+
+// addConfigFlags registers the flags shared by commands that read HCL
+// configuration
+func addConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&configRecursive, "recursive", false, "include .hcl files in subdirectories of a config directory")
+	cmd.Flags().StringArrayVar(&configVars, "var", nil, "set a variable (name=value, repeatable)")
+	cmd.Flags().StringArrayVar(&configVarFiles, "var-file", nil, "read variable values from a file (repeatable)")
+}
+
+// loadConfig loads an HCL file or directory using the shared config flags
+func loadConfig(path string) (*config.File, error) {
+	vars := make(map[string]string, len(configVars))
+	for _, assignment := range configVars {
+		name, value, found := strings.Cut(assignment, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid --var %q: want name=value", assignment)
+		}
+		vars[name] = value
+	}
+
+	return config.Load(path, config.Options{
+		Recursive: configRecursive,
+		VarFiles:  configVarFiles,
+		Vars:      vars,
+	})
+}
+
+// defaultSettingsFile is the settings file read from the home directory
+// when --config is not given, if it exists
+const defaultSettingsFile = ".provctl.hcl"
+
+// applySettings loads the settings file and sets every global flag that was
+// not given on the command line from it
+func applySettings(cmd *cobra.Command) error {
+	path := cfgFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, defaultSettingsFile)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+	}
+
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	for _, setting := range settingFlags(settings) {
+		if setting.value == "" || flags.Lookup(setting.flag) == nil || flags.Changed(setting.flag) {
+			continue
+		}
+		if err := flags.Set(setting.flag, setting.value); err != nil {
+			return fmt.Errorf("invalid setting for --%s in %s: %w", setting.flag, path, err)
+		}
+	}
+	return nil
+}
+
+// settingFlag is the value a settings file gives a flag
+type settingFlag struct {
+	flag  string
+	value string
+}
+
+// settingFlags maps settings onto the global flags they default
+func settingFlags(settings *config.Settings) []settingFlag {
+	flags := []settingFlag{
+		{"region", settings.Region},
+		{"progress-interval", settings.ProgressInterval},
+		{"network-timeout", settings.NetworkTimeout},
+		{"control-plane-timeout", settings.ControlPlaneTimeout},
+		{"node-pool-timeout", settings.NodePoolTimeout},
+	}
+	if settings.APIRateLimit != 0 {
+		flags = append(flags, settingFlag{"api-rate-limit", strconv.FormatFloat(settings.APIRateLimit, 'g', -1, 64)})
+	}
+	if settings.APIBurst != 0 {
+		flags = append(flags, settingFlag{"api-burst", strconv.Itoa(settings.APIBurst)})
+	}
+	if aws := settings.AWS; aws != nil {
+		flags = append(flags,
+			settingFlag{"aws-profile", aws.Profile},
+			settingFlag{"aws-role-arn", aws.RoleARN},
+			settingFlag{"aws-external-id", aws.ExternalID},
+		)
+	}
+	if azure := settings.Azure; azure != nil {
+		flags = append(flags,
+			settingFlag{"azure-subscription-id", azure.SubscriptionID},
+			settingFlag{"azure-tenant-id", azure.TenantID},
+			settingFlag{"azure-client-id", azure.ClientID},
+		)
+		if azure.ManagedIdentity {
+			flags = append(flags, settingFlag{"azure-managed-identity", "true"})
+		}
+	}
+	return flags
+}
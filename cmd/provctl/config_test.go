@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestApplySettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provctl.hcl")
+	settings := `
+api_rate_limit        = 2.5
+progress_interval     = "1m"
+control_plane_timeout = "45m"
+
+aws {
+  profile  = "ops"
+  role_arn = "arn:aws:iam::123456789012:role/provctl"
+}
+
+azure {
+  managed_identity = true
+}
+`
+	if err := os.WriteFile(path, []byte(settings), 0644); err != nil {
+		t.Fatalf("failed to write settings: %v", err)
+	}
+	cfgFile = path
+	t.Cleanup(func() {
+		cfgFile, awsProfile, awsRoleARN, azureManagedID = "", "", "", false
+		apiRateLimit, progressInterval = 0, engine.DefaultProgressInterval
+		controlPlaneTimeout = engine.DefaultControlPlaneTimeout
+	})
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&awsProfile, "aws-profile", "", "")
+	cmd.Flags().StringVar(&awsRoleARN, "aws-role-arn", "", "")
+	cmd.Flags().BoolVar(&azureManagedID, "azure-managed-identity", false, "")
+	cmd.Flags().Float64Var(&apiRateLimit, "api-rate-limit", 0, "")
+	cmd.Flags().DurationVar(&progressInterval, "progress-interval", engine.DefaultProgressInterval, "")
+	cmd.Flags().DurationVar(&controlPlaneTimeout, "control-plane-timeout", engine.DefaultControlPlaneTimeout, "")
+	if err := cmd.Flags().Parse([]string{"--aws-role-arn", "arn:aws:iam::123456789012:role/ci"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if err := applySettings(cmd); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if awsProfile != "ops" || !azureManagedID {
+		t.Errorf("awsProfile = %q, azureManagedID = %v, want them from the settings file", awsProfile, azureManagedID)
+	}
+	if apiRateLimit != 2.5 || progressInterval != time.Minute {
+		t.Errorf("apiRateLimit = %v, progressInterval = %v, want them from the settings file", apiRateLimit, progressInterval)
+	}
+	if controlPlaneTimeout != 45*time.Minute {
+		t.Errorf("controlPlaneTimeout = %v, want it from the settings file", controlPlaneTimeout)
+	}
+	if awsRoleARN != "arn:aws:iam::123456789012:role/ci" {
+		t.Errorf("awsRoleARN = %q, want the flag to override the settings file", awsRoleARN)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/connectivity"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/format"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var connectivityTimeout time.Duration
+
+func connectivityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connectivity <cluster>",
+		Short: "Check that a cluster's API server can be reached from here",
+		Long: `Resolve the API endpoint recorded for a cluster, connect to it, complete a TLS
+handshake and request /version with the credentials a kubeconfig of the cluster
+would hold. Each step is reported, so an unreachable cluster shows whether DNS,
+the network, TLS or authentication failed, and what is likely needed to reach a
+private endpoint.
+
+It exits non-zero if the API server cannot be reached.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConnectivity(cmd.Context(), os.Stdout, args[0])
+		},
+	}
+
+	cmd.Flags().DurationVar(&connectivityTimeout, "timeout", 10*time.Second, "time allowed for the whole check")
+
+	return cmd
+}
+
+func runConnectivity(ctx context.Context, out io.Writer, name string) error {
+	if connectivityTimeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	var cluster *api.Cluster
+	for _, c := range current.Clusters {
+		if c.Metadata.Name == name {
+			cluster = c
+			break
+		}
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found in state", name)
+	}
+
+	endpoint := cluster.Status.Properties[api.PropertyEndpoint]
+	if endpoint == "" {
+		return fmt.Errorf("cluster %s has no recorded endpoint; run provctl refresh once it is running", name)
+	}
+
+	credentials, credentialsCheck := clusterCredentials(ctx, cluster)
+	result, err := connectivity.Check(ctx, connectivity.Target{Endpoint: endpoint, Credentials: credentials}, connectivityTimeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Checking %s (%s)\n\n", endpoint, name)
+	checks := append([]doctorCheck{credentialsCheck}, connectivityChecks(cluster, result, credentials != nil)...)
+	printChecklist(out, checks, colorEnabled())
+
+	switch {
+	case result.Failed == connectivity.StageAuth:
+		return fmt.Errorf("cluster %s is reachable but rejected the request", name)
+	case !result.Reachable():
+		return fmt.Errorf("cluster %s is unreachable", name)
+	}
+	return nil
+}
+
+// clusterCredentials asks the cluster's provider for the credentials of its
+// kubeconfig. Without them the check still runs, anonymously and without
+// verifying the server's certificate.
+func clusterCredentials(ctx context.Context, cluster *api.Cluster) (*engine.ClusterCredentials, doctorCheck) {
+	check := doctorCheck{Name: "Credentials"}
+
+	cloudProvider, err := newProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region, nil)
+	if err != nil {
+		check.Status = checkWarn
+		check.Detail = fmt.Sprintf("continuing anonymously: %v", err)
+		check.Hint = credentialHint(cluster.Spec.Provider)
+		return nil, check
+	}
+
+	issuer, ok := cloudProvider.(engine.KubeconfigIssuer)
+	if !ok {
+		check.Status = checkWarn
+		check.Detail = fmt.Sprintf("%s cannot issue cluster credentials, continuing anonymously", cluster.Spec.Provider)
+		return nil, check
+	}
+
+	credentials, err := issuer.ClusterCredentials(ctx, cluster)
+	if err != nil {
+		check.Status = checkWarn
+		check.Detail = fmt.Sprintf("continuing anonymously: %v", err)
+		check.Hint = "check that your identity may describe the cluster"
+		return nil, check
+	}
+
+	check.Detail = "issued by " + cluster.Spec.Provider
+	return credentials, check
+}
+
+// connectivityChecks reports each stage of a connectivity check up to the
+// one that failed
+func connectivityChecks(cluster *api.Cluster, result connectivity.Result, authenticated bool) []doctorCheck {
+	stages := []struct {
+		stage connectivity.Stage
+		name  string
+		pass  func() doctorCheck
+	}{
+		{connectivity.StageDNS, "DNS", func() doctorCheck {
+			detail := "resolves to " + strings.Join(result.Addresses, ", ")
+			if result.Private {
+				detail += " (private)"
+			}
+			return doctorCheck{Detail: detail}
+		}},
+		{connectivity.StageTCP, "TCP", func() doctorCheck {
+			return doctorCheck{Detail: "connected to " + result.Address}
+		}},
+		{connectivity.StageTLS, "TLS", func() doctorCheck {
+			if !result.Verified {
+				return doctorCheck{Status: checkWarn, Detail: "handshake completed; certificate not verified without the cluster's CA"}
+			}
+			return doctorCheck{Detail: "certificate verified against the cluster's CA"}
+		}},
+		{connectivity.StageAPI, "API server", func() doctorCheck {
+			return doctorCheck{Detail: fmt.Sprintf("Kubernetes %s answered in %s", result.Version, format.Duration(result.Latency.Round(time.Millisecond)))}
+		}},
+	}
+
+	failed := result.Failed
+	if failed == connectivity.StageAuth {
+		failed = connectivity.StageAPI
+	}
+
+	var checks []doctorCheck
+	for _, s := range stages {
+		if s.stage != failed {
+			check := s.pass()
+			check.Name = s.name
+			checks = append(checks, check)
+			continue
+		}
+
+		checks = append(checks, doctorCheck{
+			Name:   s.name,
+			Status: checkFail,
+			Detail: result.Err.Error(),
+			Hint:   connectivityHint(cluster, result, authenticated),
+		})
+		break
+	}
+	return checks
+}
+
+// connectivityHint suggests what is needed to get past the failed stage of
+// a check
+func connectivityHint(cluster *api.Cluster, result connectivity.Result, authenticated bool) string {
+	switch result.Failed {
+	case connectivity.StageTLS:
+		return "the certificate does not match the cluster; check for a proxy intercepting TLS"
+	case connectivity.StageAuth:
+		if !authenticated {
+			return "the API server rejects anonymous requests; the network path works"
+		}
+		return "the credentials were rejected; check that your identity is granted access to the cluster"
+	case connectivity.StageAPI:
+		return "the API server is reachable but failing; check the cluster's health"
+	}
+
+	network := "the cluster's network"
+	if vpc := cluster.Status.Properties[api.PropertyVPCID]; vpc != "" {
+		network += " (" + vpc + ")"
+	}
+	access := cluster.Spec.Network.EndpointAccess()
+	switch {
+	case access.PrivateAccess && !access.PublicAccess:
+		return "the endpoint is private: run provctl inside " + network + ", or reach it through a bastion host or VPN"
+	case result.Private:
+		return "the endpoint resolves to private addresses: run provctl inside " + network + ", or reach it through a bastion host or VPN"
+	case len(access.AuthorizedCIDRs) > 0:
+		return "public access is limited to " + strings.Join(access.AuthorizedCIDRs, ", ") + "; check that this machine's address is among them"
+	}
+	return "check firewalls and proxies between this machine and the endpoint"
+}
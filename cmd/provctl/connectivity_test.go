@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/connectivity"
+)
+
+func TestConnectivityChecks(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"gitVersion":"v1.30.2"}`))
+	}))
+	defer server.Close()
+
+	result, err := connectivity.Check(context.Background(), connectivity.Target{Endpoint: server.URL}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	failed := printChecklist(&out, connectivityChecks(&api.Cluster{}, result, false), false)
+	if failed != 0 {
+		t.Errorf("printChecklist() failed = %d, want none:\n%s", failed, out.String())
+	}
+	for _, want := range []string{"✓ DNS: resolves to 127.0.0.1", "✓ TCP: connected to 127.0.0.1:", "⚠ TLS: handshake completed; certificate not verified", "✓ API server: Kubernetes v1.30.2 answered in"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("checklist =\n%s\nwant it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestConnectivityChecks_PrivateEndpoint(t *testing.T) {
+	cluster := &api.Cluster{
+		Spec:   api.ClusterSpec{Network: api.NetworkSpec{PrivateCluster: true}},
+		Status: api.ResourceStatus{Properties: map[string]string{api.PropertyVPCID: "vpc-123"}},
+	}
+	result := connectivity.Result{
+		Addresses: []string{"10.0.1.5"},
+		Private:   true,
+		Failed:    connectivity.StageTCP,
+		Err:       context.DeadlineExceeded,
+	}
+
+	checks := connectivityChecks(cluster, result, true)
+	if len(checks) != 2 || checks[0].Status != checkPass || checks[1].Status != checkFail {
+		t.Fatalf("connectivityChecks() = %+v, want DNS passed and TCP failed", checks)
+	}
+	if hint := checks[1].Hint; !strings.Contains(hint, "bastion host or VPN") || !strings.Contains(hint, "vpc-123") {
+		t.Errorf("hint = %q, want a bastion or VPN into the cluster's VPC suggested", hint)
+	}
+
+	cluster.Spec.Network = api.NetworkSpec{}
+	result.Private = false
+	if hint := connectivityChecks(cluster, result, true)[1].Hint; strings.Contains(hint, "bastion") {
+		t.Errorf("hint = %q, want no bastion suggested for a public endpoint", hint)
+	}
+}
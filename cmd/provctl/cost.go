@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/config"
+	"github.com/vjranagit/cluster-api/pkg/cost"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	costOutput            string
+	costCluster           string
+	costIncreaseThreshold float64
+)
+
+func costCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Estimate infrastructure costs",
+	}
+
+	cmd.AddCommand(costEstimateCmd())
+
+	return cmd
+}
+
+func costEstimateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "estimate [config-path]",
+		Short: "Estimate the monthly cost of the clusters in an HCL configuration",
+		Long: `Estimate the hourly and monthly cost of each cluster in a configuration.
+Use --output csv to export the breakdown for a spreadsheet; CSV output covers
+a single cluster, chosen with --cluster when the configuration has several.
+
+Text output warns about clusters whose estimate is more than
+--cost-increase-threshold percent above the one recorded at their last apply.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return estimateCost(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&costOutput, "output", "o", "text", "output format (text or csv)")
+	cmd.Flags().StringVar(&costCluster, "cluster", "", "only estimate the named cluster")
+	addCostThresholdFlag(cmd)
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+func estimateCost(ctx context.Context, configFile string) error {
+	if costOutput != "text" && costOutput != "csv" {
+		return fmt.Errorf("invalid --output %q: want text or csv", costOutput)
+	}
+
+	file, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	if err := writeCostEstimates(ctx, os.Stdout, file, costCluster, costOutput); err != nil {
+		return err
+	}
+	if costOutput != "text" {
+		return nil
+	}
+
+	// Without state nothing has been applied yet; opening it would create it
+	if _, err := os.Stat(statePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	specs := make(map[string]api.ClusterSpec)
+	for _, block := range file.Clusters {
+		if costCluster == "" || block.Name == costCluster {
+			specs[block.Name] = block.Spec
+		}
+	}
+	return warnCostIncreases(ctx, os.Stdout, sm.Events(), specs, costIncreaseThreshold)
+}
+
+// writeCostEstimates estimates the selected clusters and writes them in the
+// given format
+func writeCostEstimates(ctx context.Context, out io.Writer, file *config.File, cluster, format string) error {
+	var blocks []config.ClusterBlock
+	for _, block := range file.Clusters {
+		if cluster == "" || block.Name == cluster {
+			blocks = append(blocks, block)
+		}
+	}
+
+	switch {
+	case len(blocks) == 0 && cluster != "":
+		return fmt.Errorf("cluster %s not found in configuration", cluster)
+	case len(blocks) == 0:
+		return fmt.Errorf("configuration defines no clusters")
+	case len(blocks) > 1 && format == "csv":
+		return fmt.Errorf("configuration defines %d clusters; choose one with --cluster for CSV output", len(blocks))
+	}
+
+	estimator := cost.NewEstimator()
+	for i, block := range blocks {
+		estimate, err := estimator.EstimateCost(ctx, block.Spec)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", block.Name, err)
+		}
+
+		if format == "csv" {
+			_, err = fmt.Fprint(out, cost.FormatEstimateCSV(estimate))
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "Cluster %s (%s/%s)\n\n", block.Name, block.Spec.Provider, block.Spec.Region)
+		fmt.Fprint(out, cost.FormatEstimate(estimate))
+	}
+	return nil
+}
+
+// addCostThresholdFlag registers --cost-increase-threshold for commands that
+// compare estimates with the last apply
+func addCostThresholdFlag(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&costIncreaseThreshold, "cost-increase-threshold", cost.DefaultIncreaseThreshold,
+		"warn when a cluster's estimate is this many percent above its last apply (0 disables)")
+}
+
+// recordAppliedCosts records the estimated cost of every cluster a plan
+// created or updated, for later estimates to be compared with
+func recordAppliedCosts(ctx context.Context, events engine.EventStore, desired engine.State, plan engine.Plan) error {
+	estimator := cost.NewEstimator()
+	for _, action := range plan.Actions {
+		if action.Resource.Kind != "Cluster" || (action.Type != engine.ActionCreate && action.Type != engine.ActionUpdate) {
+			continue
+		}
+		cluster, ok := desired.Clusters[action.Resource.ID]
+		if !ok {
+			continue
+		}
+
+		estimate, err := estimator.EstimateCost(ctx, cluster.Spec)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", cluster.Metadata.Name, err)
+		}
+		event := api.Event{
+			Type:          api.EventCostEstimated,
+			Resource:      action.Resource,
+			Actor:         currentUser(),
+			Payload:       api.EstimatedCost{MonthlyCost: estimate.TotalMonthlyCost, Currency: estimate.Currency},
+			CorrelationID: engine.CorrelationIDFrom(ctx),
+		}
+		if err := events.RecordEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record cost estimate: %w", err)
+		}
+	}
+	return nil
+}
+
+// lastAppliedCost returns the estimated cost recorded at the last apply of
+// the named cluster, or false if none was recorded
+func lastAppliedCost(ctx context.Context, events engine.EventStore, name string) (api.EstimatedCost, bool, error) {
+	history, err := events.GetEvents(ctx, api.ResourceID{Kind: "Cluster", Name: name})
+	if err != nil {
+		return api.EstimatedCost{}, false, err
+	}
+
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Type != api.EventCostEstimated {
+			continue
+		}
+		// Stored payloads are decoded as generic JSON
+		raw, err := json.Marshal(history[i].Payload)
+		if err != nil {
+			return api.EstimatedCost{}, false, err
+		}
+		var estimate api.EstimatedCost
+		if err := json.Unmarshal(raw, &estimate); err != nil {
+			return api.EstimatedCost{}, false, fmt.Errorf("invalid cost estimate of event %s: %w", history[i].ID, err)
+		}
+		return estimate, true, nil
+	}
+	return api.EstimatedCost{}, false, nil
+}
+
+// warnCostIncreases estimates each cluster, keyed by name, and writes a
+// warning for those whose estimate is more than threshold percent above the
+// one recorded at their last apply
+func warnCostIncreases(ctx context.Context, out io.Writer, events engine.EventStore, specs map[string]api.ClusterSpec, threshold float64) error {
+	if threshold <= 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	estimator := cost.NewEstimator()
+	var increases []cost.Increase
+	for _, name := range names {
+		previous, ok, err := lastAppliedCost(ctx, events, name)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", name, err)
+		}
+		if !ok {
+			continue
+		}
+
+		estimate, err := estimator.EstimateCost(ctx, specs[name])
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", name, err)
+		}
+		increase := cost.Increase{
+			Cluster:  name,
+			Previous: previous.MonthlyCost,
+			Current:  estimate.TotalMonthlyCost,
+			Currency: estimate.Currency,
+		}
+		if increase.Exceeds(threshold) {
+			increases = append(increases, increase)
+		}
+	}
+
+	if len(increases) > 0 {
+		fmt.Fprintln(out)
+	}
+	for _, increase := range increases {
+		fmt.Fprintln(out, increase)
+	}
+	return nil
+}
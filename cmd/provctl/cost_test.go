@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/config"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestWriteCostEstimates(t *testing.T) {
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-west-2",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", InstanceType: "t3.medium", MinSize: 1, MaxSize: 3, DesiredSize: 2}},
+	}
+	file := &config.File{Clusters: []config.ClusterBlock{
+		{Name: "prod", Spec: spec},
+		{Name: "staging", Spec: spec},
+	}}
+
+	var out bytes.Buffer
+	if err := writeCostEstimates(context.Background(), &out, file, "", "text"); err != nil {
+		t.Fatalf("writeCostEstimates() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Cluster prod (aws/us-west-2)") || !strings.Contains(out.String(), "Cluster staging") {
+		t.Errorf("text output = %q, want both clusters", out.String())
+	}
+
+	if err := writeCostEstimates(context.Background(), &out, file, "", "csv"); err == nil {
+		t.Error("writeCostEstimates() error = nil, want an error for CSV of several clusters")
+	}
+
+	out.Reset()
+	if err := writeCostEstimates(context.Background(), &out, file, "prod", "csv"); err != nil {
+		t.Fatalf("writeCostEstimates() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasPrefix(lines[0], "resource,") || !strings.HasPrefix(lines[len(lines)-1], "TOTAL,") {
+		t.Errorf("CSV output = %q, want a header and a totals row", out.String())
+	}
+
+	if err := writeCostEstimates(context.Background(), &out, file, "missing", "text"); err == nil {
+		t.Error("writeCostEstimates() error = nil, want an error for an unknown cluster")
+	}
+}
+
+func TestWarnCostIncreases(t *testing.T) {
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	ctx := context.Background()
+	applied := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-west-2",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", InstanceType: "t3.medium", MinSize: 1, MaxSize: 3, DesiredSize: 2}},
+	}
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: applied},
+	}}
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:     engine.ActionCreate,
+		Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-1", Name: "prod"},
+	}}}
+	if err := recordAppliedCosts(ctx, sm.Events(), desired, plan); err != nil {
+		t.Fatalf("recordAppliedCosts() error = %v", err)
+	}
+
+	previous, ok, err := lastAppliedCost(ctx, sm.Events(), "prod")
+	if err != nil || !ok || previous.MonthlyCost <= 0 {
+		t.Fatalf("lastAppliedCost() = %+v, %v, %v, want the recorded estimate", previous, ok, err)
+	}
+
+	blowup := applied
+	blowup.WorkerPools = []api.WorkerPoolSpec{{Name: "general", InstanceType: "m5.4xlarge", MinSize: 10, MaxSize: 20, DesiredSize: 10}}
+
+	tests := []struct {
+		name      string
+		specs     map[string]api.ClusterSpec
+		threshold float64
+		want      string
+	}{
+		{name: "unchanged", specs: map[string]api.ClusterSpec{"prod": applied}, threshold: 50},
+		{name: "blowup", specs: map[string]api.ClusterSpec{"prod": blowup}, threshold: 50, want: "⚠ Estimated cost of prod up"},
+		{name: "check disabled", specs: map[string]api.ClusterSpec{"prod": blowup}, threshold: 0},
+		{name: "never applied", specs: map[string]api.ClusterSpec{"staging": blowup}, threshold: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := warnCostIncreases(ctx, &out, sm.Events(), tt.specs, tt.threshold); err != nil {
+				t.Fatalf("warnCostIncreases() error = %v", err)
+			}
+			if tt.want == "" && out.Len() > 0 {
+				t.Errorf("output = %q, want no warning", out.String())
+			}
+			if tt.want != "" && !strings.Contains(out.String(), tt.want) {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/decommission"
+	"github.com/vjranagit/cluster-api/pkg/drain"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/snapshot"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	decommissionSkip        = make(map[decommission.Step]*bool)
+	decommissionRestart     bool
+	decommissionAutoApprove bool
+	decommissionDrainTime   time.Duration
+)
+
+// decommissionStepDescriptions describe each step in help and the overview
+var decommissionStepDescriptions = map[decommission.Step]string{
+	decommission.StepStateSnapshot:  "snapshot provctl's state",
+	decommission.StepVolumeSnapshot: "snapshot the disks of persistent volumes",
+	decommission.StepDrain:          "cordon every node and evict its pods",
+	decommission.StepDelete:         "delete the cluster",
+}
+
+func decommissionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decommission <cluster>",
+		Short: "Preserve a cluster's state and data, drain it, then delete it",
+		Long: `Retire a cluster in order:
+
+  1. state-snapshot   snapshot provctl's state, kept by retention as pre_delete
+  2. volume-snapshot  snapshot the disks of persistent volumes, where the provider can
+  3. drain            cordon every node and evict its pods, so workloads shut down gracefully
+  4. delete           delete the cluster and remove it from state
+
+Each step can be left out with --skip-<step>. Completed steps are recorded, so
+after a failure running the command again resumes with the step that failed;
+--restart runs every step again.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDecommission(cmd.Context(), os.Stdout, args[0])
+		},
+	}
+
+	for _, step := range decommission.Steps {
+		decommissionSkip[step] = cmd.Flags().Bool("skip-"+string(step), false, "do not "+decommissionStepDescriptions[step])
+	}
+	cmd.Flags().BoolVar(&decommissionRestart, "restart", false, "ignore the progress of earlier runs and perform every step again")
+	cmd.Flags().BoolVar(&decommissionAutoApprove, "auto-approve", false, "skip interactive approval")
+	cmd.Flags().DurationVar(&decommissionDrainTime, "drain-timeout", 10*time.Minute, "time allowed for evicted pods to terminate")
+	cmd.Flags().StringVar(&snapshotDir, "snapshot-dir", "./snapshots", "directory holding state snapshots")
+	cmd.Flags().BoolVar(&disableProtection, "disable-protection", false, "allow deleting a cluster with deletion protection")
+
+	return cmd
+}
+
+func runDecommission(ctx context.Context, out io.Writer, name string) error {
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	var cluster *api.Cluster
+	for _, c := range current.Clusters {
+		if c.Metadata.Name == name {
+			cluster = c
+			break
+		}
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found in state", name)
+	}
+
+	deleting := !*decommissionSkip[decommission.StepDelete]
+	if deleting && !disableProtection {
+		if err := engine.CheckDeletionProtection(deletePlan([]*api.Cluster{cluster}), current); err != nil {
+			return err
+		}
+	}
+
+	workflow := decommission.New(cluster, sm.Events())
+	if decommissionRestart {
+		workflow.Restart(time.Now())
+	}
+	for step, skip := range decommissionSkip {
+		if *skip {
+			workflow.Skip(step)
+		}
+	}
+	completed, err := workflow.Completed(ctx)
+	if err != nil {
+		return err
+	}
+
+	printDecommissionSteps(out, cluster, completed)
+	if len(completed) == len(decommission.Steps) {
+		fmt.Fprintln(out, "\nEvery step was completed by an earlier run.")
+		return nil
+	}
+
+	if !decommissionAutoApprove {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to decommission without --auto-approve: stdin is not a terminal")
+		}
+		fmt.Fprintln(out)
+		if !confirm(os.Stdin, out, fmt.Sprintf("Do you want to decommission cluster %s?", name)) {
+			fmt.Fprintln(out, "Decommission cancelled.")
+			return nil
+		}
+	}
+
+	steps := &decommissionSteps{cluster: cluster, state: sm, current: current, workflow: workflow}
+	workflow.Handle(decommission.StepStateSnapshot, steps.snapshotState)
+	workflow.Handle(decommission.StepVolumeSnapshot, steps.snapshotVolumes)
+	workflow.Handle(decommission.StepDrain, steps.drain)
+	workflow.Handle(decommission.StepDelete, steps.delete)
+
+	fmt.Fprintln(out)
+	colored := colorEnabled()
+	err = workflow.Run(ctx, func(result decommission.StepResult) {
+		mark := color.Wrap(colored, color.Green, "✓")
+		if result.Outcome == decommission.OutcomeSkipped {
+			mark = color.Wrap(colored, color.Yellow, "-")
+		}
+		line := fmt.Sprintf("%s %s: %s", mark, result.Step, result.Outcome)
+		if result.Detail != "" {
+			line += ", " + result.Detail
+		}
+		fmt.Fprintln(out, line)
+	})
+
+	var stepErr *decommission.StepError
+	if errors.As(err, &stepErr) {
+		fmt.Fprintf(out, "%s %s: %v\n", color.Wrap(colored, color.Red, "✗"), stepErr.Step, stepErr.Err)
+		if hint := decommissionHint(stepErr); hint != "" {
+			fmt.Fprintf(out, "    → %s\n", hint)
+		}
+		return fmt.Errorf("decommission of %s stopped at %s; run provctl decommission %s again to resume", name, stepErr.Step, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if deleting {
+		fmt.Fprintf(out, "\nDecommission complete! Cluster %s is deleted.\n", name)
+	} else {
+		fmt.Fprintf(out, "\nCluster %s is ready to delete; run provctl decommission %s again without --skip-delete.\n", name, name)
+	}
+	return nil
+}
+
+// printDecommissionSteps lists what a run will do with each step
+func printDecommissionSteps(out io.Writer, cluster *api.Cluster, completed map[decommission.Step]string) {
+	fmt.Fprintf(out, "Decommissioning %s (%s) - %s/%s:\n", cluster.Metadata.Name, cluster.ID, cluster.Spec.Provider, cluster.Spec.Region)
+	for i, step := range decommission.Steps {
+		plan := "will " + decommissionStepDescriptions[step]
+		switch _, done := completed[step]; {
+		case done:
+			plan = "done by an earlier run"
+		case *decommissionSkip[step]:
+			plan = "skipped"
+		}
+		fmt.Fprintf(out, "  %d. %-16s %s\n", i+1, step, plan)
+	}
+}
+
+// decommissionHint suggests how to get past a failed step
+func decommissionHint(err *decommission.StepError) string {
+	switch {
+	case err.Step == decommission.StepVolumeSnapshot && errors.Is(err, engine.ErrNotSupported):
+		return "snapshot the volumes' disks yourself, then rerun with --skip-volume-snapshot"
+	case err.Step == decommission.StepVolumeSnapshot && errors.Is(err, errNoVolumes):
+		return "if the cluster keeps no data on persistent volumes, rerun with --skip-volume-snapshot"
+	case err.Step == decommission.StepDrain:
+		return "drain the cluster with kubectl drain, or rerun with --skip-drain to delete it as it is"
+	}
+	return ""
+}
+
+// errNoVolumes fails the volume snapshot step when the provider finds no
+// disks, which more likely means they are tagged in a way it does not
+// recognise than that the cluster keeps no data
+var errNoVolumes = errors.New("no persistent volumes found")
+
+// decommissionSteps performs the steps of decommissioning a cluster
+type decommissionSteps struct {
+	cluster  *api.Cluster
+	state    *state.SQLiteStateManager
+	current  engine.State
+	workflow *decommission.Workflow
+	provider engine.CloudProvider
+}
+
+func (s *decommissionSteps) cloudProvider(ctx context.Context) (engine.CloudProvider, error) {
+	if s.provider != nil {
+		return s.provider, nil
+	}
+	provider, err := newProvider(ctx, s.cluster.Spec.Provider, s.cluster.Spec.Region, s.state.Events())
+	if err != nil {
+		return nil, err
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *decommissionSteps) snapshotState(ctx context.Context) (string, error) {
+	manager, err := snapshot.NewManager(snapshotDir, s.state)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+	snap, err := manager.CreateTaggedSnapshot(ctx, "before decommissioning "+s.cluster.Metadata.Name, snapshot.TriggerPreDelete,
+		map[string]string{"cluster": s.cluster.Metadata.Name})
+	if err != nil {
+		return "", err
+	}
+	return "snapshot " + snap.ID, nil
+}
+
+func (s *decommissionSteps) snapshotVolumes(ctx context.Context) (string, error) {
+	provider, err := s.cloudProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	snapshotter, ok := provider.(engine.VolumeSnapshotter)
+	if !ok {
+		return "", fmt.Errorf("%s: snapshotting volumes: %w", s.cluster.Spec.Provider, engine.ErrNotSupported)
+	}
+
+	// Snapshots taken by an attempt that failed part way are not taken again
+	taken, err := s.workflow.Progress(ctx, decommission.StepVolumeSnapshot)
+	if err != nil {
+		return "", err
+	}
+	started, err := snapshotter.SnapshotVolumes(ctx, s.cluster, taken)
+	if len(started) > 0 {
+		if recordErr := s.workflow.RecordProgress(ctx, decommission.StepVolumeSnapshot, started); recordErr != nil {
+			return "", errors.Join(err, recordErr)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(taken)+len(started) == 0 {
+		return "", fmt.Errorf("%w for cluster %s", errNoVolumes, s.cluster.Metadata.Name)
+	}
+	return volumeSnapshotDetail(taken, started), nil
+}
+
+// volumeSnapshotDetail describes the snapshots of the volume snapshot step,
+// those taken by earlier attempts and those just started
+func volumeSnapshotDetail(taken, started map[string]string) string {
+	ids := make([]string, 0, len(taken)+len(started))
+	for _, id := range started {
+		ids = append(ids, id)
+	}
+	for _, id := range taken {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	detail := fmt.Sprintf("%d snapshot(s): %s", len(ids), strings.Join(ids, ", "))
+	if len(taken) > 0 {
+		detail += fmt.Sprintf(" (%d taken by an earlier attempt)", len(taken))
+	}
+	return detail
+}
+
+func (s *decommissionSteps) drain(ctx context.Context) (string, error) {
+	endpoint := s.cluster.Status.Properties[api.PropertyEndpoint]
+	if endpoint == "" {
+		return "", fmt.Errorf("cluster %s has no recorded endpoint", s.cluster.Metadata.Name)
+	}
+	provider, err := s.cloudProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	issuer, ok := provider.(engine.KubeconfigIssuer)
+	if !ok {
+		return "", fmt.Errorf("%s cannot issue cluster credentials: %w", s.cluster.Spec.Provider, engine.ErrNotSupported)
+	}
+	credentials, err := issuer.ClusterCredentials(ctx, s.cluster)
+	if err != nil {
+		return "", err
+	}
+
+	drainer, err := drain.NewDrainer(endpoint, credentials, loggerFrom(ctx))
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, decommissionDrainTime)
+	defer cancel()
+	result, err := drainer.Drain(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cordoned %d node(s), evicted %d pod(s)", result.Nodes, result.Evicted), nil
+}
+
+func (s *decommissionSteps) delete(ctx context.Context) (string, error) {
+	provider, err := s.cloudProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := provider.DeleteCluster(ctx, s.cluster); err != nil {
+		return "", fmt.Errorf("failed to delete cluster: %w", err)
+	}
+
+	forgotten, err := engine.ForgetResource(&s.current, "Cluster", s.cluster.ID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.state.SaveState(ctx, s.current); err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+	return fmt.Sprintf("removed the cluster and %d node pool(s) from state", len(forgotten)-1), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/decommission"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestPrintDecommissionSteps(t *testing.T) {
+	decommissionCmd() // Registers the --skip flags
+	*decommissionSkip[decommission.StepVolumeSnapshot] = true
+	defer func() { *decommissionSkip[decommission.StepVolumeSnapshot] = false }()
+
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws", Region: "us-west-2"}}
+	completed := map[decommission.Step]string{decommission.StepStateSnapshot: "snapshot snapshot-1"}
+
+	var out bytes.Buffer
+	printDecommissionSteps(&out, cluster, completed)
+	for _, want := range []string{
+		"Decommissioning prod (c-1) - aws/us-west-2:",
+		"1. state-snapshot   done by an earlier run",
+		"2. volume-snapshot  skipped",
+		"3. drain            will cordon every node and evict its pods",
+		"4. delete           will delete the cluster",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("printDecommissionSteps() = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestDecommissionHint(t *testing.T) {
+	tests := []struct {
+		err  *decommission.StepError
+		want string
+	}{
+		{&decommission.StepError{Step: decommission.StepVolumeSnapshot, Err: fmt.Errorf("azure: %w", engine.ErrNotSupported)}, "--skip-volume-snapshot"},
+		{&decommission.StepError{Step: decommission.StepVolumeSnapshot, Err: fmt.Errorf("%w for cluster prod", errNoVolumes)}, "--skip-volume-snapshot"},
+		{&decommission.StepError{Step: decommission.StepVolumeSnapshot, Err: fmt.Errorf("throttled")}, ""},
+		{&decommission.StepError{Step: decommission.StepDrain, Err: fmt.Errorf("eviction blocked")}, "--skip-drain"},
+		{&decommission.StepError{Step: decommission.StepDelete, Err: fmt.Errorf("throttled")}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := decommissionHint(tt.err); (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("decommissionHint(%v) = %q, want it to mention %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestVolumeSnapshotDetail(t *testing.T) {
+	started := map[string]string{"vol-2": "snap-2"}
+	if got, want := volumeSnapshotDetail(nil, started), "1 snapshot(s): snap-2"; got != want {
+		t.Errorf("volumeSnapshotDetail() = %q, want %q", got, want)
+	}
+
+	taken := map[string]string{"vol-1": "snap-1"}
+	if got, want := volumeSnapshotDetail(taken, started), "2 snapshot(s): snap-1, snap-2 (1 taken by an earlier attempt)"; got != want {
+		t.Errorf("volumeSnapshotDetail() = %q, want %q", got, want)
+	}
+}
+
+// volumeSnapshotter snapshots volumes, failing after the first snapshot while
+// failing is set, and records the snapshots each call was told were taken
+type volumeSnapshotter struct {
+	*fake.Provider
+	volumes []string
+	failing bool
+	taken   []map[string]string
+}
+
+func (v *volumeSnapshotter) SnapshotVolumes(ctx context.Context, cluster *api.Cluster, taken map[string]string) (map[string]string, error) {
+	v.taken = append(v.taken, taken)
+	started := make(map[string]string)
+	for _, volume := range v.volumes {
+		if _, ok := taken[volume]; ok {
+			continue
+		}
+		if v.failing && len(started) == 1 {
+			return started, errors.New("throttled")
+		}
+		started[volume] = "snap-" + volume
+	}
+	return started, nil
+}
+
+func TestDecommissionSteps_SnapshotVolumes(t *testing.T) {
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+	ctx := context.Background()
+
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}}
+	snapshotter := &volumeSnapshotter{Provider: fake.NewProvider("aws"), volumes: []string{"vol-1", "vol-2"}, failing: true}
+	steps := &decommissionSteps{cluster: cluster, state: sm, workflow: decommission.New(cluster, sm.Events()), provider: snapshotter}
+
+	if _, err := steps.snapshotVolumes(ctx); err == nil {
+		t.Fatal("snapshotVolumes() error = nil, want the snapshot to fail")
+	}
+
+	// The rerun only snapshots the volume the failed attempt did not
+	snapshotter.failing = false
+	steps.workflow = decommission.New(cluster, sm.Events())
+	detail, err := steps.snapshotVolumes(ctx)
+	if err != nil {
+		t.Fatalf("snapshotVolumes() error = %v", err)
+	}
+	if want := map[string]string{"vol-1": "snap-vol-1"}; !reflect.DeepEqual(snapshotter.taken[1], want) {
+		t.Errorf("rerun was told %v were taken, want %v", snapshotter.taken[1], want)
+	}
+	if want := "2 snapshot(s): snap-vol-1, snap-vol-2 (1 taken by an earlier attempt)"; detail != want {
+		t.Errorf("snapshotVolumes() = %q, want %q", detail, want)
+	}
+
+	snapshotter.volumes = nil
+	steps.workflow = decommission.New(cluster, sm.Events())
+	steps.workflow.Restart(time.Now().Add(time.Second))
+	if _, err := steps.snapshotVolumes(ctx); !errors.Is(err, errNoVolumes) {
+		t.Errorf("snapshotVolumes() error = %v, want errNoVolumes without volumes", err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	deleteSelector    string
+	deleteDryRun      bool
+	deleteAutoApprove bool
+)
+
+// deleteSelected deletes every cluster in state whose labels match the
+// selector after a single confirmation, applying the deletes like any other
+// plan. Protected clusters abort the whole deletion unless
+// --disable-protection is set.
+func deleteSelected(ctx context.Context, rawSelector string) error {
+	ctx = engine.WithCorrelationID(ctx, engine.NewCorrelationID())
+
+	selector, err := api.ParseSelector(rawSelector)
+	if err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	matches := selectClusters(current, selector)
+	if len(matches) == 0 {
+		fmt.Printf("No clusters match selector %s.\n", selector)
+		return nil
+	}
+
+	plan := deletePlan(matches)
+	if !disableProtection {
+		if err := engine.CheckDeletionProtection(plan, current); err != nil {
+			return err
+		}
+	}
+	window, err := maintenanceWindow("")
+	if err != nil {
+		return err
+	}
+
+	printDeletions(os.Stdout, selector, matches)
+	if deleteDryRun {
+		return nil
+	}
+	if err := checkMaintenanceWindow(window); err != nil {
+		return err
+	}
+
+	if !deleteAutoApprove {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to delete without --auto-approve: stdin is not a terminal")
+		}
+		fmt.Println()
+		if !confirm(os.Stdin, os.Stdout, fmt.Sprintf("Do you want to destroy these %d cluster(s)?", len(matches))) {
+			fmt.Println("Delete cancelled.")
+			return nil
+		}
+	}
+
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.SetDisableProtection(disableProtection)
+	eng.SetMaintenanceWindow(window)
+	selected := make(map[string]*api.Cluster, len(matches))
+	for _, cluster := range matches {
+		selected[cluster.ID] = cluster
+	}
+	if err := registerProviders(ctx, eng, sm.Events(), selected); err != nil {
+		return err
+	}
+
+	// Even a failed delete may have deleted some clusters
+	defer invalidateRefreshCache(ctx, sm, plan)
+	apply := func() error { return eng.Apply(ctx, plan) }
+	if err := auditedApply(ctx, newAuditWriter(sm.Events()), "selector "+selector.String(), plan, apply); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	fmt.Printf("\nDelete complete! %d cluster(s) destroyed.\n", len(matches))
+	return nil
+}
+
+// selectClusters returns the clusters whose labels match, ordered by name
+func selectClusters(current engine.State, selector api.Selector) []*api.Cluster {
+	var matches []*api.Cluster
+	for _, cluster := range current.Clusters {
+		if selector.Matches(cluster.Metadata.Labels) {
+			matches = append(matches, cluster)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Metadata.Name < matches[j].Metadata.Name
+	})
+	return matches
+}
+
+func deletePlan(clusters []*api.Cluster) engine.Plan {
+	plan := engine.Plan{Actions: []engine.Action{}}
+	for _, cluster := range clusters {
+		plan.Actions = append(plan.Actions, engine.Action{
+			Type: engine.ActionDelete,
+			Resource: api.ResourceID{
+				Provider: cluster.Spec.Provider,
+				Kind:     "Cluster",
+				ID:       cluster.ID,
+				Name:     cluster.Metadata.Name,
+			},
+		})
+	}
+	return plan
+}
+
+func printDeletions(out io.Writer, selector api.Selector, clusters []*api.Cluster) {
+	fmt.Fprintf(out, "%d cluster(s) match selector %s and will be destroyed:\n", len(clusters), selector)
+	for _, cluster := range clusters {
+		fmt.Fprintf(out, "  - %s (%s) - %s/%s\n", cluster.Metadata.Name, cluster.ID, cluster.Spec.Provider, cluster.Spec.Region)
+	}
+}
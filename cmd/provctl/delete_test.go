@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestSelectClusters(t *testing.T) {
+	cluster := func(id, name string, labels map[string]string) *api.Cluster {
+		return &api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: name, Labels: labels},
+			Spec:     api.ClusterSpec{Provider: "aws", Region: "us-west-2"},
+		}
+	}
+	current := engine.State{Clusters: map[string]*api.Cluster{
+		"c-1": cluster("c-1", "test-b", map[string]string{"env": "test", "team": "web"}),
+		"c-2": cluster("c-2", "test-a", map[string]string{"env": "test", "team": "payments"}),
+		"c-3": cluster("c-3", "prod", map[string]string{"env": "prod"}),
+		"c-4": cluster("c-4", "unlabeled", nil),
+	}}
+
+	selector, err := api.ParseSelector("env=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := selectClusters(current, selector)
+	if len(matches) != 2 || matches[0].Metadata.Name != "test-a" || matches[1].Metadata.Name != "test-b" {
+		t.Fatalf("selectClusters() = %v, want test-a and test-b", matches)
+	}
+
+	var out bytes.Buffer
+	printDeletions(&out, selector, matches)
+	if !strings.Contains(out.String(), "2 cluster(s) match selector env=test") || !strings.Contains(out.String(), "test-a (c-2)") {
+		t.Errorf("printDeletions() = %q", out.String())
+	}
+
+	selector, err = api.ParseSelector("env=test,team!=payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matches := selectClusters(current, selector); len(matches) != 1 || matches[0].ID != "c-1" {
+		t.Errorf("selectClusters() = %v, want only c-1", matches)
+	}
+}
+
+func TestDeletePlan_RespectsProtection(t *testing.T) {
+	protected := &api.Cluster{
+		ID:       "c-1",
+		Metadata: api.ResourceMetadata{Name: "shared-test"},
+		Spec:     api.ClusterSpec{DeletionProtection: true},
+	}
+	current := engine.State{Clusters: map[string]*api.Cluster{"c-1": protected}}
+
+	if err := engine.CheckDeletionProtection(deletePlan([]*api.Cluster{protected}), current); err == nil {
+		t.Error("CheckDeletionProtection() = nil, want an error for a protected cluster")
+	}
+}
+
+func TestDeleteSelected_AppliesPlan(t *testing.T) {
+	defer func(path, window string, autoApprove bool) {
+		statePath, applyWindow, deleteAutoApprove = path, window, autoApprove
+	}(statePath, applyWindow, deleteAutoApprove)
+	statePath = filepath.Join(t.TempDir(), "state.db")
+	deleteAutoApprove = true
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+	cluster := func(id, name, env string) *api.Cluster {
+		return &api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: name, Labels: map[string]string{"env": env}},
+			Spec:     api.ClusterSpec{Provider: "events-test"},
+		}
+	}
+	pool := func(id, clusterName string) *api.NodePool {
+		return &api.NodePool{ID: id, Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterName: clusterName}}}
+	}
+	if err := sm.SaveState(ctx, engine.State{
+		Clusters:  map[string]*api.Cluster{"c-1": cluster("c-1", "test", "test"), "c-2": cluster("c-2", "prod", "prod")},
+		NodePools: map[string]*api.NodePool{"p-1": pool("p-1", "test"), "p-2": pool("p-2", "prod")},
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	// Outside the maintenance window nothing is deleted
+	applyWindow = "Mon 00:00-00:01 UTC"
+	if window, _ := maintenanceWindow(""); !window.InWindow(time.Now()) {
+		if err := deleteSelected(ctx, "env=test"); !errors.Is(err, engine.ErrOutsideMaintenanceWindow) {
+			t.Fatalf("deleteSelected() error = %v, want ErrOutsideMaintenanceWindow", err)
+		}
+	}
+	applyWindow = ""
+
+	if err := deleteSelected(ctx, "env=test"); err != nil {
+		t.Fatalf("deleteSelected() error = %v", err)
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if _, ok := current.Clusters["c-1"]; ok || len(current.Clusters) != 1 {
+		t.Errorf("state clusters = %v, want only c-2", current.Clusters)
+	}
+	if _, ok := current.NodePools["p-1"]; ok || len(current.NodePools) != 1 {
+		t.Errorf("state node pools = %v, want only p-2", current.NodePools)
+	}
+
+	events, err := sm.Events().GetEvents(ctx, api.ResourceID{Provider: "events-test", Kind: "Cluster", ID: "c-1", Name: "test"})
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != api.EventDeleted {
+		t.Errorf("events = %v, want one %s event", events, api.EventDeleted)
+	}
+}
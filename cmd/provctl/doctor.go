@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/format"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+// Clouds reject requests signed with a clock this far off; AWS allows five
+// minutes. Smaller skews are reported as warnings.
+const (
+	maxClockSkew  = 5 * time.Minute
+	warnClockSkew = time.Minute
+)
+
+// checkStatus is the outcome of a doctor check
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// doctorCheck is one line of the doctor checklist
+type doctorCheck struct {
+	Name   string
+	Status checkStatus
+	Detail string
+	Hint   string // What to do about a warning or failure
+}
+
+func doctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor [config]",
+		Short: "Diagnose the environment provctl runs in",
+		Long: `Check that the state backend is reachable and its schema current, that the
+credentials of every provider in use are valid, that the snapshot directory is
+writable and that the local clock agrees with each cloud API. Providers are
+taken from the clusters in state and from the configuration, if given.
+
+Run this first when troubleshooting. It exits non-zero if any check fails.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := ""
+			if len(args) == 1 {
+				configPath = args[0]
+			}
+			return runDoctor(cmd.Context(), os.Stdout, configPath)
+		},
+	}
+
+	cmd.Flags().StringVar(&snapshotDir, "snapshot-dir", "./snapshots", "directory holding state snapshots")
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+// runDoctor runs every check, prints the checklist to out and returns an
+// error if any check failed
+func runDoctor(ctx context.Context, out io.Writer, configPath string) error {
+	stateCheck, stored := checkState(ctx, statePath)
+	checks := []doctorCheck{stateCheck}
+
+	// The region of the first cluster seen is used to reach each provider
+	regions := make(map[string]string)
+	addProvider := func(name, region string) {
+		if _, ok := regions[name]; !ok && name != "" {
+			regions[name] = region
+		}
+	}
+	for _, cluster := range stored {
+		addProvider(cluster.Spec.Provider, cluster.Spec.Region)
+	}
+
+	if configPath != "" {
+		check := doctorCheck{Name: "Configuration"}
+		file, err := loadConfig(configPath)
+		if err != nil {
+			check.Status = checkFail
+			check.Detail = err.Error()
+			check.Hint = "run provctl validate for details"
+		} else {
+			check.Detail = fmt.Sprintf("%d cluster(s) in %s", len(file.Clusters), configPath)
+			for _, block := range file.Clusters {
+				addProvider(block.Spec.Provider, block.Spec.Region)
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	checks = append(checks, checkSnapshotDir(snapshotDir))
+
+	if len(regions) == 0 {
+		checks = append(checks, doctorCheck{
+			Name:   "Cloud credentials",
+			Status: checkWarn,
+			Detail: "no cluster in state or configuration names a provider",
+			Hint:   "pass a configuration to check the credentials it needs",
+		})
+	}
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checks = append(checks, checkProvider(ctx, name, regions[name])...)
+	}
+
+	failed := printChecklist(out, checks, colorEnabled())
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// checkState opens the state database read-only and verifies its schema as
+// it is on disk, returning the stored clusters. Other commands migrate an
+// outdated schema when they open it; doctor reports it instead.
+func checkState(ctx context.Context, path string) (doctorCheck, map[string]*api.Cluster) {
+	check := doctorCheck{Name: "State backend"}
+
+	// Opening a missing database would create it
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		check.Status = checkWarn
+		check.Detail = fmt.Sprintf("no state at %s", path)
+		check.Hint = "the first apply creates it; pass --state if it lives elsewhere"
+		return check, nil
+	}
+
+	sm, err := state.OpenSQLiteStateReadOnly(path)
+	if err != nil {
+		check.Status = checkFail
+		check.Detail = err.Error()
+		check.Hint = "check that the file is a provctl state database and that you can read it"
+		return check, nil
+	}
+	defer sm.Close()
+
+	if err := sm.CheckSchema(ctx); err != nil {
+		check.Status = checkFail
+		check.Detail = err.Error()
+		check.Hint = "a missing column is added by the next command that writes state; otherwise restore a known good state with provctl snapshot restore"
+		return check, nil
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		check.Status = checkFail
+		check.Detail = err.Error()
+		check.Hint = "restore a known good state with provctl snapshot restore"
+		return check, nil
+	}
+
+	check.Detail = fmt.Sprintf("%s, schema current, %d cluster(s)", path, len(current.Clusters))
+	return check, current.Clusters
+}
+
+// checkSnapshotDir checks that snapshots can be written to dir. Snapshots
+// create a missing directory, so then its nearest existing parent must be
+// writable.
+func checkSnapshotDir(dir string) doctorCheck {
+	check := doctorCheck{Name: "Snapshot directory", Hint: "fix the permissions or pass --snapshot-dir"}
+
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				check.Status = checkFail
+				check.Detail = fmt.Sprintf("%s is not a directory", existing)
+				return check
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if !errors.Is(err, fs.ErrNotExist) || parent == existing {
+			check.Status = checkFail
+			check.Detail = err.Error()
+			return check
+		}
+		existing = parent
+	}
+
+	probe, err := os.CreateTemp(existing, ".provctl-doctor-*")
+	if err != nil {
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("%s is not writable: %v", existing, err)
+		return check
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	check.Hint = ""
+	check.Detail = dir + " is writable"
+	if existing != dir {
+		check.Detail = dir + " will be created by the first snapshot"
+	}
+	return check
+}
+
+// checkProvider validates a provider's credentials and compares the local
+// clock with its cloud API. The clock is checked even when the credentials
+// are rejected, as a skewed clock is a common cause.
+func checkProvider(ctx context.Context, name, region string) []doctorCheck {
+	credentials := doctorCheck{Name: name + " credentials"}
+
+	cloudProvider, err := engine.NewProvider(ctx, name, providerConfig(region, nil, loggerFrom(ctx)))
+	if err != nil {
+		credentials.Status = checkFail
+		credentials.Detail = err.Error()
+		credentials.Hint = credentialHint(name)
+		return []doctorCheck{credentials}
+	}
+
+	if validator, ok := cloudProvider.(engine.CredentialValidator); !ok {
+		credentials.Status = checkWarn
+		credentials.Detail = "the provider cannot verify its credentials"
+	} else if err := validator.Validate(ctx); err != nil {
+		credentials.Status = checkFail
+		credentials.Detail = err.Error()
+		credentials.Hint = "check network access to the cloud API"
+		if errors.Is(err, engine.ErrInvalidCredentials) {
+			credentials.Hint = credentialHint(name)
+		}
+	} else {
+		credentials.Detail = "valid in " + region
+	}
+	checks := []doctorCheck{credentials}
+
+	if checker, ok := cloudProvider.(engine.ClockChecker); ok {
+		serverTime, err := checker.ServerTime(ctx)
+		if err != nil {
+			checks = append(checks, doctorCheck{
+				Name:   name + " clock",
+				Status: checkWarn,
+				Detail: fmt.Sprintf("could not read the cloud API time: %v", err),
+				Hint:   "check network access to the cloud API",
+			})
+		} else {
+			checks = append(checks, checkClockSkew(name, time.Since(serverTime)))
+		}
+	}
+	return checks
+}
+
+// credentialHint suggests how to fix rejected credentials of a provider
+func credentialHint(provider string) string {
+	switch provider {
+	case "aws":
+		return "refresh your AWS credentials, e.g. with aws sso login, or check --aws-profile and --aws-role-arn"
+	case "azure":
+		return "run az login, or check --azure-subscription-id, --azure-tenant-id, --azure-client-id and AZURE_CLIENT_SECRET"
+	default:
+		return "check the credentials configured for " + provider
+	}
+}
+
+// checkClockSkew grades how far the local clock is ahead of (positive skew)
+// or behind a provider's cloud API
+func checkClockSkew(provider string, skew time.Duration) doctorCheck {
+	check := doctorCheck{Name: provider + " clock"}
+
+	// The Date header only has second precision
+	skew = skew.Round(time.Second)
+	switch {
+	case skew == 0:
+		check.Detail = "in sync with the cloud API"
+		return check
+	case skew > 0:
+		check.Detail = fmt.Sprintf("%s ahead of the cloud API", format.Duration(skew))
+	default:
+		skew = -skew
+		check.Detail = fmt.Sprintf("%s behind the cloud API", format.Duration(skew))
+	}
+
+	switch {
+	case skew >= maxClockSkew:
+		check.Status = checkFail
+		check.Hint = "synchronize the system clock, e.g. by enabling NTP; the cloud rejects requests signed with it"
+	case skew >= warnClockSkew:
+		check.Status = checkWarn
+		check.Hint = "synchronize the system clock, e.g. by enabling NTP"
+	}
+	return check
+}
+
+// printChecklist writes one line per check with its hint below and a
+// summary, returning the number of failed checks
+func printChecklist(out io.Writer, checks []doctorCheck, colored bool) int {
+	var passed, warned, failed int
+	for _, check := range checks {
+		var mark string
+		switch check.Status {
+		case checkPass:
+			passed++
+			mark = color.Wrap(colored, color.Green, "✓")
+		case checkWarn:
+			warned++
+			mark = color.Wrap(colored, color.Yellow, "⚠")
+		case checkFail:
+			failed++
+			mark = color.Wrap(colored, color.Red, "✗")
+		}
+
+		fmt.Fprintf(out, "%s %s: %s\n", mark, check.Name, check.Detail)
+		if check.Hint != "" && check.Status != checkPass {
+			fmt.Fprintf(out, "    → %s\n", check.Hint)
+		}
+	}
+
+	fmt.Fprintf(out, "\n%d passed, %d warning(s), %d failed\n", passed, warned, failed)
+	return failed
+}
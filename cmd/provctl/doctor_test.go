@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestCheckState(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.db")
+	if check, _ := checkState(ctx, missing); check.Status != checkWarn {
+		t.Errorf("checkState(missing) = %+v, want a warning", check)
+	}
+	if _, err := os.Stat(missing); err == nil {
+		t.Error("checkState() created the missing state database")
+	}
+
+	path := filepath.Join(dir, "state.db")
+	sm, err := state.NewSQLiteStateManager(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	cluster := &api.Cluster{ID: "c-1", Spec: api.ClusterSpec{Provider: "aws", Region: "us-east-1"}}
+	if err := sm.SaveState(ctx, engine.State{Clusters: map[string]*api.Cluster{cluster.ID: cluster}}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	sm.Close()
+
+	check, clusters := checkState(ctx, path)
+	if check.Status != checkPass || len(clusters) != 1 {
+		t.Errorf("checkState() = %+v with %d clusters, want a pass with 1", check, len(clusters))
+	}
+
+	// An outdated schema is reported, not migrated behind the user's back
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	if _, err := db.Exec("ALTER TABLE events DROP COLUMN correlation_id"); err != nil {
+		t.Fatalf("dropping column: %v", err)
+	}
+	db.Close()
+	for i := 0; i < 2; i++ {
+		if check, _ := checkState(ctx, path); check.Status != checkFail {
+			t.Errorf("checkState(outdated) run %d = %+v, want a failure", i+1, check)
+		}
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if check, _ := checkState(ctx, garbage); check.Status != checkFail || check.Hint == "" {
+		t.Errorf("checkState(garbage) = %+v, want a failure with a hint", check)
+	}
+}
+
+func TestCheckSnapshotDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want checkStatus
+	}{
+		{name: "existing", dir: dir, want: checkPass},
+		{name: "missing", dir: filepath.Join(dir, "snapshots", "prod"), want: checkPass},
+		{name: "file", dir: file, want: checkFail},
+		{name: "under a file", dir: filepath.Join(file, "snapshots"), want: checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if check := checkSnapshotDir(tt.dir); check.Status != tt.want {
+				t.Errorf("checkSnapshotDir() = %+v, want status %d", check, tt.want)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("checkSnapshotDir() left files behind: %v", entries)
+	}
+}
+
+func TestCheckClockSkew(t *testing.T) {
+	tests := []struct {
+		skew       time.Duration
+		want       checkStatus
+		wantDetail string
+	}{
+		{skew: 300 * time.Millisecond, want: checkPass, wantDetail: "in sync"},
+		{skew: 20 * time.Second, want: checkPass, wantDetail: "20s ahead"},
+		{skew: -90 * time.Second, want: checkWarn, wantDetail: "1m30s behind"},
+		{skew: 6 * time.Minute, want: checkFail, wantDetail: "6m ahead"},
+		{skew: -10 * time.Minute, want: checkFail, wantDetail: "10m behind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.skew.String(), func(t *testing.T) {
+			check := checkClockSkew("aws", tt.skew)
+			if check.Status != tt.want || !strings.Contains(check.Detail, tt.wantDetail) {
+				t.Errorf("checkClockSkew(%s) = %+v, want status %d and %q", tt.skew, check, tt.want, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestPrintChecklist(t *testing.T) {
+	var out bytes.Buffer
+	failed := printChecklist(&out, []doctorCheck{
+		{Name: "State backend", Detail: "ok", Hint: "unused"},
+		{Name: "Snapshot directory", Status: checkWarn, Detail: "slow", Hint: "look closer"},
+		{Name: "aws credentials", Status: checkFail, Detail: "expired", Hint: "log in"},
+	}, false)
+
+	if failed != 1 {
+		t.Errorf("printChecklist() = %d failed, want 1", failed)
+	}
+	want := `✓ State backend: ok
+⚠ Snapshot directory: slow
+    → look closer
+✗ aws credentials: expired
+    → log in
+
+1 passed, 1 warning(s), 1 failed
+`
+	if out.String() != want {
+		t.Errorf("printChecklist() output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
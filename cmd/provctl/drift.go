@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vjranagit/cluster-api/pkg/drift"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	driftSeverityThreshold string
+	driftSettle            time.Duration
+)
+
+func driftCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect configuration drift",
+	}
+
+	cmd.AddCommand(driftDetectCmd())
+
+	return cmd
+}
+
+func driftDetectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "detect [config-path]",
+		Short: "Compare an HCL configuration against actual cloud state",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return detectDrift(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&driftSeverityThreshold, "severity-threshold", string(drift.SeverityLow),
+		"only report drift at or above this severity (low, medium, high, critical)")
+	cmd.Flags().DurationVar(&driftSettle, "settle", 0,
+		"skip clusters and node pools changed within this long, while cloud resources may still be settling")
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+func detectDrift(ctx context.Context, configFile string) error {
+	threshold, err := drift.ParseSeverity(driftSeverityThreshold)
+	if err != nil {
+		return err
+	}
+
+	file, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	desired := file.DesiredState(stored)
+	// Stored node pools record when they last changed, for --settle
+	desired.NodePools = stored.NodePools
+
+	eng := engine.NewEngine(sm, nil)
+	if err := registerProviders(ctx, eng, sm.Events(), desired.Clusters); err != nil {
+		return err
+	}
+
+	detector := drift.NewDriftDetector(eng, loggerFrom(ctx), drift.WithIgnoreChangesWithin(driftSettle))
+	report, err := detector.DetectDrift(ctx, desired)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(drift.FormatReportColor(report.Filter(threshold), colorEnabled()))
+	return nil
+}
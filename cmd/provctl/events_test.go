@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestExportEvents(t *testing.T) {
+	ctx := context.Background()
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, name := range []string{"prod", "staging", "dev"} {
+		event := api.Event{Timestamp: base.Add(time.Duration(i) * time.Hour), Type: api.EventCreated,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", Name: name}}
+		if err := sm.Events().RecordEvent(ctx, event); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := exportEvents(ctx, sm.Events(), base.Add(time.Hour), &out, true); err != nil {
+		t.Fatalf("exportEvents() error = %v", err)
+	}
+
+	zr, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("export is not gzip: %v", err)
+	}
+	var names []string
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		var event api.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q is not a JSON event: %v", scanner.Text(), err)
+		}
+		names = append(names, event.Resource.Name)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+
+	if len(names) != 2 || names[0] != "staging" || names[1] != "dev" {
+		t.Errorf("exported %v, want [staging dev]", names)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/planner"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func graphCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "graph [config-path]",
+		Short: "Print the resource dependency graph in Graphviz DOT format",
+		Long: `Print the clusters, networks and node pools of a configuration, or of
+stored state when no configuration is given, with their dependencies as a
+Graphviz DOT graph:
+
+  provctl graph cluster.hcl | dot -Tpng -o cluster.png`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFile := ""
+			if len(args) == 1 {
+				configFile = args[0]
+			}
+			return printGraph(cmd.Context(), configFile)
+		},
+	}
+
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+func printGraph(ctx context.Context, configFile string) error {
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	graphed := stored
+	if configFile != "" {
+		file, err := loadConfig(configFile)
+		if err != nil {
+			return err
+		}
+		graphed = file.DesiredState(stored)
+	}
+
+	fmt.Fprint(os.Stdout, planner.FormatDOT(planner.BuildDependencyGraph(graphed)))
+	return nil
+}
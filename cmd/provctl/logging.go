@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+var (
+	logFormat string
+	logLevel  string
+)
+
+// loggerKey is the context key of the command's logger
+type loggerKey struct{}
+
+// newLogger creates the CLI logger. An empty format selects text on a
+// terminal and JSON otherwise, so interactive runs are readable and piped or
+// CI runs stay machine-parseable.
+func newLogger(w io.Writer, format, level string, terminal bool) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid --log-level %q: want debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	if format == "" {
+		format = "json"
+		if terminal {
+			format = "text"
+		}
+	}
+
+	// Lines logged with a context carry the correlation ID of its operation
+	switch strings.ToLower(format) {
+	case "json":
+		return slog.New(engine.NewCorrelationHandler(slog.NewJSONHandler(w, opts))), nil
+	case "text":
+		return slog.New(engine.NewCorrelationHandler(slog.NewTextHandler(w, opts))), nil
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q: want json or text", format)
+	}
+}
+
+// withLogger returns a context carrying logger
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger carried by ctx, or the default logger
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		level    string
+		terminal bool
+		wantJSON bool
+		wantErr  bool
+	}{
+		{name: "terminal defaults to text", level: "info", terminal: true},
+		{name: "pipe defaults to json", level: "info", wantJSON: true},
+		{name: "explicit json on terminal", format: "json", level: "info", terminal: true, wantJSON: true},
+		{name: "explicit text", format: "text", level: "debug"},
+		{name: "invalid format", format: "xml", level: "info", wantErr: true},
+		{name: "invalid level", level: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, err := newLogger(&out, tt.format, tt.level, tt.terminal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newLogger() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			logger.Info("hello", "cluster", "prod")
+			if got := strings.HasPrefix(out.String(), "{"); got != tt.wantJSON {
+				t.Errorf("newLogger() wrote %q, want JSON %v", out.String(), tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestNewLogger_Level(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "text", "warn", false)
+	if err != nil {
+		t.Fatalf("newLogger() error = %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(out.String(), "hidden") || !strings.Contains(out.String(), "shown") {
+		t.Errorf("newLogger() at warn wrote %q", out.String())
+	}
+
+	ctx := withLogger(context.Background(), logger)
+	if loggerFrom(ctx) != logger {
+		t.Error("loggerFrom() did not return the context's logger")
+	}
+	if loggerFrom(context.Background()) == nil {
+		t.Error("loggerFrom() = nil, want the default logger")
+	}
+}
@@ -4,44 +4,119 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vjranagit/cluster-api/pkg/api"
 	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
 	"github.com/vjranagit/cluster-api/pkg/providers/aws"
 	"github.com/vjranagit/cluster-api/pkg/providers/azure"
 	"github.com/vjranagit/cluster-api/pkg/state"
+	"github.com/vjranagit/cluster-api/pkg/validation"
 )
 
 var (
-	cfgFile     string
-	provider    string
-	region      string
-	statePath   string
-	logger      *slog.Logger
+	cfgFile           string
+	provider          string
+	region            string
+	statePath         string
+	disableProtection bool
+	forceUpgrade      bool
+	applyAutoApprove  bool
+	applyPlanFile     string
+	applyWindow       string
+	showCost          bool
+	applyDiff         bool
+	awsProfile        string
+	awsRoleARN        string
+	awsExternalID     string
+	azureSubscription string
+	azureTenantID     string
+	azureClientID     string
+	azureManagedID    bool
+	noColor           bool
+	lockTimeout       time.Duration
+	apiRateLimit      float64
+	apiBurst          int
+	progressInterval  time.Duration
 )
 
-func main() {
-	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+// Time allowed for each phase of provisioning
+var (
+	networkTimeout      time.Duration
+	controlPlaneTimeout time.Duration
+	nodePoolTimeout     time.Duration
+)
 
+func main() {
 	rootCmd := &cobra.Command{
 		Use:   "provctl",
 		Short: "Multi-cloud Kubernetes cluster provisioning tool",
 		Long: `provctl is a declarative infrastructure provisioner for Kubernetes clusters
 across AWS, Azure, and other cloud providers.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			logger, err := newLogger(os.Stderr, logFormat, logLevel, isTerminal(os.Stderr))
+			if err != nil {
+				return err
+			}
+			// Components falling back to the default logger share the configuration
+			slog.SetDefault(logger)
+			cmd.SetContext(withLogger(cmd.Context(), logger))
+			return applySettings(cmd)
+		},
 	}
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.provctl.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "provider settings file; flags given on the command line override it (default $HOME/.provctl.hcl)")
 	rootCmd.PersistentFlags().StringVar(&statePath, "state", "./state.db", "path to state database")
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 0, "how long to wait for a state lock held by another run (default fail at once)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "log format, json or text (default text on a terminal, json otherwise)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also disabled by NO_COLOR or when stdout is not a terminal)")
+	rootCmd.PersistentFlags().StringVar(&awsProfile, "aws-profile", "", "AWS shared config profile")
+	rootCmd.PersistentFlags().StringVar(&awsRoleARN, "aws-role-arn", "", "AWS role to assume for provisioning")
+	rootCmd.PersistentFlags().StringVar(&awsExternalID, "aws-external-id", "", "external ID for assuming --aws-role-arn")
+	rootCmd.PersistentFlags().StringVar(&azureSubscription, "azure-subscription-id", os.Getenv("AZURE_SUBSCRIPTION_ID"), "Azure subscription ID")
+	rootCmd.PersistentFlags().StringVar(&azureTenantID, "azure-tenant-id", "", "Azure tenant of the service principal")
+	rootCmd.PersistentFlags().StringVar(&azureClientID, "azure-client-id", "",
+		"Azure service principal client ID (secret read from AZURE_CLIENT_SECRET), or user-assigned identity with --azure-managed-identity")
+	rootCmd.PersistentFlags().BoolVar(&azureManagedID, "azure-managed-identity", false, "authenticate to Azure with the host's managed identity")
+	rootCmd.PersistentFlags().StringVar(&auditLogPath, "audit-log", os.Getenv("PROVCTL_AUDIT_LOG"), "append a JSON Lines audit record of every plan and apply to this file")
+	rootCmd.PersistentFlags().BoolVar(&auditEvents, "audit-events", false, "also record plan and apply audit records in the event store")
+	rootCmd.PersistentFlags().Float64Var(&apiRateLimit, "api-rate-limit", 0, "cloud API calls per second per provider, shared by all operations (default per provider; negative for no limit)")
+	rootCmd.PersistentFlags().IntVar(&apiBurst, "api-burst", 0, "cloud API calls per provider allowed at once before --api-rate-limit paces them (default per provider)")
+	rootCmd.PersistentFlags().DurationVar(&progressInterval, "progress-interval", engine.DefaultProgressInterval, "how often to log the progress of long-running cloud operations")
+	rootCmd.PersistentFlags().DurationVar(&networkTimeout, "network-timeout", engine.DefaultNetworkTimeout, "time allowed for creating a cluster's network")
+	rootCmd.PersistentFlags().DurationVar(&controlPlaneTimeout, "control-plane-timeout", engine.DefaultControlPlaneTimeout, "time allowed for creating a cluster's control plane")
+	rootCmd.PersistentFlags().DurationVar(&nodePoolTimeout, "node-pool-timeout", engine.DefaultNodePoolTimeout, "time allowed for creating a node pool")
 
 	rootCmd.AddCommand(createCmd())
+	rootCmd.AddCommand(cloneCmd())
+	rootCmd.AddCommand(planCmd())
 	rootCmd.AddCommand(applyCmd())
+	rootCmd.AddCommand(validateCmd())
 	rootCmd.AddCommand(deleteCmd())
+	rootCmd.AddCommand(decommissionCmd())
 	rootCmd.AddCommand(listCmd())
+	rootCmd.AddCommand(outputCmd())
+	rootCmd.AddCommand(watchCmd())
+	rootCmd.AddCommand(statusCmd())
+	rootCmd.AddCommand(refreshCmd())
+	rootCmd.AddCommand(driftCmd())
+	rootCmd.AddCommand(costCmd())
+	rootCmd.AddCommand(recommendCmd())
+	rootCmd.AddCommand(auditCmd())
+	rootCmd.AddCommand(eventsCmd())
+	rootCmd.AddCommand(graphCmd())
+	rootCmd.AddCommand(compareCmd())
+	rootCmd.AddCommand(snapshotCmd())
+	rootCmd.AddCommand(stateCmd())
+	rootCmd.AddCommand(forceUnlockCmd())
+	rootCmd.AddCommand(doctorCmd())
+	rootCmd.AddCommand(connectivityCmd())
 	rootCmd.AddCommand(versionCmd())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -57,7 +132,7 @@ func createCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clusterName := args[0]
-			return createCluster(clusterName)
+			return createCluster(cmd.Context(), clusterName)
 		},
 	}
 
@@ -68,27 +143,88 @@ func createCmd() *cobra.Command {
 }
 
 func applyCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "apply [config-file]",
-		Short: "Apply configuration from HCL file",
-		Args:  cobra.ExactArgs(1),
+	cmd := &cobra.Command{
+		Use:   "apply [config-path]",
+		Short: "Apply configuration from an HCL file or directory",
+		Long: `Plan and apply the changes an HCL configuration requires.
+
+With --plan-file, apply a plan saved by plan --out instead, exactly as it was
+reviewed. A saved plan records its progress, so applying it again after an
+interrupted apply skips the actions that already completed.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if applyPlanFile != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("a configuration path cannot be combined with --plan-file")
+				}
+				return nil
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if applyPlanFile != "" {
+				return applySavedPlan(cmd.Context(), applyPlanFile)
+			}
 			configFile := args[0]
-			return applyConfig(configFile)
+			return applyConfig(cmd.Context(), configFile)
 		},
 	}
+
+	cmd.Flags().BoolVar(&applyAutoApprove, "auto-approve", false, "skip interactive approval (required when stdin is not a terminal)")
+	cmd.Flags().BoolVar(&disableProtection, "disable-protection", false, "allow deleting clusters with deletion protection")
+	cmd.Flags().BoolVar(&forceUpgrade, "force-upgrade", false, "upgrade clusters despite blocking upgrade preflight issues")
+	cmd.Flags().BoolVar(&showCost, "cost", false, "annotate each action with its estimated monthly cost change")
+	cmd.Flags().BoolVar(&applyDiff, "diff", false, "show the old and new value of every field an update changes")
+	cmd.Flags().StringVar(&overrideGuardrails, "override-guardrails", "", "apply despite guardrail violations, giving the reason recorded in the audit log")
+	cmd.Flags().StringVar(&applyPlanFile, "plan-file", "", "apply a plan saved by plan --out, resuming it if an earlier apply was interrupted")
+	cmd.Flags().StringVar(&applyWindow, "maintenance-window", "",
+		`only apply within this window, e.g. "Mon-Fri 22:00-02:00 Europe/Berlin" (overrides the configuration's maintenance_window)`)
+	addCostThresholdFlag(cmd)
+	addTargetFlag(cmd)
+	addStrictFlag(cmd)
+	addConfigFlags(cmd)
+
+	return cmd
 }
 
 func deleteCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "delete [cluster-name]",
-		Short: "Delete a cluster",
-		Args:  cobra.ExactArgs(1),
+		Short: "Delete a cluster, or every cluster matching a label selector",
+		Long: `Delete a cluster by name, or with --selector every cluster in state whose
+labels match, for example to tear down test environments:
+
+  provctl delete --selector env=test,team!=payments
+
+All matching clusters are listed and confirmed once before any is deleted.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if deleteSelector != "" {
+				if len(args) != 0 {
+					return fmt.Errorf("a cluster name cannot be combined with --selector")
+				}
+				return nil
+			}
+			if deleteDryRun || deleteAutoApprove || applyWindow != "" {
+				return fmt.Errorf("--dry-run, --auto-approve and --maintenance-window require --selector")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if deleteSelector != "" {
+				return deleteSelected(cmd.Context(), deleteSelector)
+			}
 			clusterName := args[0]
-			return deleteCluster(clusterName)
+			return deleteCluster(cmd.Context(), clusterName)
 		},
 	}
+
+	cmd.Flags().BoolVar(&disableProtection, "disable-protection", false, "allow deleting clusters with deletion protection")
+	cmd.Flags().StringVarP(&deleteSelector, "selector", "l", "", "delete all clusters whose labels match (key=value,key2!=value2)")
+	cmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "list the clusters --selector matches without deleting them")
+	cmd.Flags().BoolVar(&deleteAutoApprove, "auto-approve", false, "skip interactive approval of a --selector delete")
+	cmd.Flags().StringVar(&applyWindow, "maintenance-window", "",
+		`only delete with --selector within this window, e.g. "Sat 02:00-06:00 UTC"`)
+
+	return cmd
 }
 
 func listCmd() *cobra.Command {
@@ -101,6 +237,19 @@ func listCmd() *cobra.Command {
 	}
 }
 
+func forceUnlockCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "force-unlock",
+		Short: "Release a stuck state lock",
+		Long: `Release the state lock regardless of which process holds it.
+Only use this when the holder is known to have crashed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return forceUnlock()
+		},
+	}
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -112,37 +261,28 @@ func versionCmd() *cobra.Command {
 	}
 }
 
-func createCluster(name string) error {
-	ctx := context.Background()
+func createCluster(ctx context.Context, name string) error {
+	logger := loggerFrom(ctx)
 
 	// Initialize state manager
-	sm, err := state.NewSQLiteStateManager(statePath)
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create state manager: %w", err)
 	}
 	defer sm.Close()
 
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
 	// Initialize engine
 	eng := engine.NewEngine(sm, nil)
 
-	// Register providers
-	switch provider {
-	case "aws":
-		awsProvider, err := aws.NewProvider(ctx, region, logger)
-		if err != nil {
-			return fmt.Errorf("failed to create AWS provider: %w", err)
-		}
-		eng.RegisterProvider(awsProvider)
-	case "azure":
-		// Azure requires subscription ID - would come from config
-		azureProvider, err := azure.NewProvider(ctx, "subscription-id", region, logger)
-		if err != nil {
-			return fmt.Errorf("failed to create Azure provider: %w", err)
-		}
-		eng.RegisterProvider(azureProvider)
-	default:
-		return fmt.Errorf("unsupported provider: %s", provider)
-	}
+	// Register providers; the provider is constructed once the spec is valid
+	eng.RegisterProviderLoader(provider, func(ctx context.Context) (engine.CloudProvider, error) {
+		return newProvider(ctx, provider, region, sm.Events())
+	})
 
 	// Create cluster spec
 	spec := api.ClusterSpec{
@@ -161,8 +301,21 @@ func createCluster(name string) error {
 		},
 	}
 
+	// Validate spec before touching the cloud
+	result := validation.NewValidator().Validate(spec)
+	for _, warning := range result.Warnings {
+		logger.Warn("validation warning", "field", warning.Field, "message", warning.Message)
+	}
+	if err := result.Err(); err != nil {
+		return fmt.Errorf("invalid cluster spec: %w", err)
+	}
+
+	cloudProvider, err := eng.LoadProvider(ctx, provider)
+	if err != nil {
+		return err
+	}
+
 	// Create cluster
-	cloudProvider := eng.GetProvider(provider)
 	cluster, err := cloudProvider.CreateCluster(ctx, spec)
 	if err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
@@ -177,16 +330,292 @@ func createCluster(name string) error {
 	return nil
 }
 
-func applyConfig(configFile string) error {
-	logger.Info("applying configuration", "file", configFile)
-	// TODO: Parse HCL config and apply
-	return fmt.Errorf("not implemented yet")
+// newProvider constructs the named cloud provider for a region, recording
+// phase transitions in events if set, and verifies its credentials so that
+// bad credentials fail fast
+func newProvider(ctx context.Context, name, region string, events engine.EventStore) (engine.CloudProvider, error) {
+	cloudProvider, err := engine.NewProvider(ctx, name, providerConfig(region, events, loggerFrom(ctx)))
+	if err != nil {
+		return nil, err
+	}
+
+	if validator, ok := cloudProvider.(engine.CredentialValidator); ok {
+		if err := validator.Validate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return cloudProvider, nil
+}
+
+// providerConfig maps the global provider flags onto factory configuration
+func providerConfig(region string, events engine.EventStore, logger *slog.Logger) engine.ProviderConfig {
+	credentials := map[string]string{
+		aws.CredentialProfile:    awsProfile,
+		aws.CredentialRoleARN:    awsRoleARN,
+		aws.CredentialExternalID: awsExternalID,
+		azure.CredentialTenantID: azureTenantID,
+	}
+
+	// The client ID names a user-assigned identity when managed identity is
+	// selected and a service principal otherwise
+	switch {
+	case azureManagedID:
+		credentials[azure.CredentialManagedIdentity] = "true"
+		credentials[azure.CredentialManagedIdentityClientID] = azureClientID
+	case azureClientID != "":
+		credentials[azure.CredentialClientID] = azureClientID
+		credentials[azure.CredentialClientSecret] = os.Getenv("AZURE_CLIENT_SECRET")
+	}
+
+	return engine.ProviderConfig{
+		Region:         region,
+		SubscriptionID: azureSubscription,
+		Credentials:    credentials,
+		Events:         events,
+		RateLimit:      engine.RateLimit{RequestsPerSecond: apiRateLimit, Burst: apiBurst},
+		Logger:         logger,
+
+		ProgressInterval: progressInterval,
+
+		NetworkTimeout:      networkTimeout,
+		ControlPlaneTimeout: controlPlaneTimeout,
+		NodePoolTimeout:     nodePoolTimeout,
+	}
 }
 
-func deleteCluster(name string) error {
-	logger.Info("deleting cluster", "name", name)
-	// TODO: Implement cluster deletion
-	return fmt.Errorf("not implemented yet")
+func applyConfig(ctx context.Context, configFile string) error {
+	// One correlation ID ties the guardrail overrides, events and log lines
+	// of this apply together
+	ctx = engine.WithCorrelationID(ctx, engine.NewCorrelationID())
+	loggerFrom(ctx).InfoContext(ctx, "applying configuration", "file", configFile)
+
+	p, err := newPlanner(applyDiff)
+	if err != nil {
+		return err
+	}
+
+	file, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(os.Stdout, file); err != nil {
+		return err
+	}
+	overridden, err := checkGuardrails(ctx, os.Stdout, file)
+	if err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	desired := file.DesiredState(stored)
+
+	window, err := maintenanceWindow(file.MaintenanceWindow.Schedule)
+	if err != nil {
+		return err
+	}
+
+	eng := engine.NewEngine(sm, nil)
+	eng.SetDisableProtection(disableProtection)
+	eng.SetForceUpgrade(forceUpgrade)
+	eng.SetDefaultTags(file.DefaultTags.Tags)
+	eng.SetMaintenanceWindow(window)
+	if err := registerProviders(ctx, eng, sm.Events(), stored.Clusters); err != nil {
+		return err
+	}
+	if err := registerProviders(ctx, eng, sm.Events(), desired.Clusters); err != nil {
+		return err
+	}
+	p.SetProviders(eng.LoadRegionalProvider)
+
+	plan, err := p.PlanFrom(ctx, desired, planner.NewLiveStateSource(eng))
+	if err != nil {
+		return err
+	}
+	auditor := newAuditWriter(sm.Events())
+	if err := auditPlan(ctx, auditor, configFile, plan); err != nil {
+		return err
+	}
+
+	fmt.Print(p.PrintPlan(plan))
+	if len(plan.Actions) == 0 {
+		fmt.Println("\nNo changes. Infrastructure matches the configuration.")
+		return nil
+	}
+	if err := warnCostIncreases(ctx, os.Stdout, sm.Events(), desiredSpecs(desired), costIncreaseThreshold); err != nil {
+		return err
+	}
+	if err := checkMaintenanceWindow(window); err != nil {
+		return err
+	}
+
+	if approved, err := approveApply(plan); err != nil || !approved {
+		return err
+	}
+
+	if err := recordGuardrailOverrides(ctx, sm.Events(), desired, overridden); err != nil {
+		return err
+	}
+
+	// Even a failed apply may have changed some resources
+	defer invalidateRefreshCache(ctx, sm, plan)
+	apply := func() error { return eng.Apply(ctx, plan) }
+	if err := auditedApply(ctx, auditor, configFile, plan, apply); err != nil {
+		return fmt.Errorf("apply failed: %w", err)
+	}
+	if err := recordAppliedCosts(ctx, sm.Events(), desired, plan); err != nil {
+		loggerFrom(ctx).WarnContext(ctx, "failed to record cost estimates", "error", err)
+	}
+
+	fmt.Printf("\nApply complete! %d action(s) applied.\n", len(plan.Actions))
+	return nil
+}
+
+// maintenanceWindow returns the window apply is restricted to: the one given
+// by --maintenance-window, or else schedule, the configuration's. It is nil
+// when neither sets one.
+func maintenanceWindow(schedule string) (*engine.MaintenanceWindow, error) {
+	if applyWindow != "" {
+		schedule = applyWindow
+	}
+	if schedule == "" {
+		return nil, nil
+	}
+	return engine.ParseMaintenanceWindow(schedule)
+}
+
+// checkMaintenanceWindow fails outside the maintenance window, before
+// approval is asked for a plan the engine would refuse to apply
+func checkMaintenanceWindow(window *engine.MaintenanceWindow) error {
+	if window.InWindow(time.Now()) {
+		return nil
+	}
+	return fmt.Errorf("%w; apply again within the window", engine.ErrOutsideMaintenanceWindow)
+}
+
+// approveApply asks for approval of a plan unless --auto-approve is set
+func approveApply(plan engine.Plan) (bool, error) {
+	if applyAutoApprove {
+		return true, nil
+	}
+	if !isTerminal(os.Stdin) {
+		return false, fmt.Errorf("refusing to apply without --auto-approve: stdin is not a terminal")
+	}
+	fmt.Println()
+	if !confirmApply(os.Stdin, os.Stdout, plan) {
+		fmt.Println("Apply cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
+
+func deleteCluster(ctx context.Context, name string) error {
+	logger := loggerFrom(ctx)
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	var cluster *api.Cluster
+	for _, c := range current.Clusters {
+		if c.Metadata.Name == name {
+			cluster = c
+			break
+		}
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found in state", name)
+	}
+
+	if !disableProtection {
+		plan := engine.Plan{Actions: []engine.Action{{
+			Type:     engine.ActionDelete,
+			Resource: api.ResourceID{Provider: cluster.Spec.Provider, Kind: "Cluster", ID: cluster.ID, Name: name},
+		}}}
+		if err := engine.CheckDeletionProtection(plan, current); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("deleting cluster", "name", name, "id", cluster.ID)
+
+	cloudProvider, err := newProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region, sm.Events())
+	if err != nil {
+		return err
+	}
+	if err := cloudProvider.DeleteCluster(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+
+	delete(current.Clusters, cluster.ID)
+	if err := sm.SaveState(ctx, current); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	logger.Info("cluster deleted successfully", "name", name, "id", cluster.ID)
+	return nil
+}
+
+func forceUnlock() error {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	holder, err := sm.LockInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		fmt.Println("State is not locked")
+		return nil
+	}
+
+	if err := sm.ForceUnlock(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Released state lock held by %s since %s\n", holder.Owner, holder.Since.Format("2006-01-02 15:04:05"))
+	return nil
+}
+
+// lockOptions configures a state manager that takes the state lock to wait
+// for it as long as --lock-timeout, reporting the wait on stderr
+func lockOptions() []state.Option {
+	return []state.Option{
+		state.WithLockTimeout(lockTimeout),
+		state.WithLockWaitNotify(lockWaitNotifier(os.Stderr)),
+	}
+}
+
+// lockWaitNotifier reports a wait for the state lock to out
+func lockWaitNotifier(out io.Writer) state.LockWaitFunc {
+	return func(holder *state.LockError, waited time.Duration) {
+		fmt.Fprintf(out, "Waiting for state lock held by %s (%ds elapsed)...\n", holder.Owner, int(waited.Seconds()))
+	}
 }
 
 func listClusters() error {
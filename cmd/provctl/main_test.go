@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+// factoryEvents is the event store the events-test provider factory was
+// last given
+var factoryEvents engine.EventStore
+
+// regionTestProviders maps each provider the region-test factory constructed
+// to the region it was given
+var regionTestProviders = make(map[engine.CloudProvider]string)
+
+func init() {
+	engine.RegisterProviderFactory("events-test", func(ctx context.Context, cfg engine.ProviderConfig) (engine.CloudProvider, error) {
+		factoryEvents = cfg.Events
+		return fake.NewProvider("events-test"), nil
+	})
+	engine.RegisterProviderFactory("region-test", func(ctx context.Context, cfg engine.ProviderConfig) (engine.CloudProvider, error) {
+		provider := fake.NewProvider("region-test")
+		regionTestProviders[provider] = cfg.Region
+		return provider, nil
+	})
+}
+
+func TestRegisterProviders_Events(t *testing.T) {
+	ctx := context.Background()
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	eng := engine.NewEngine(sm, nil)
+	clusters := map[string]*api.Cluster{
+		"cluster-1": {ID: "cluster-1", Spec: api.ClusterSpec{Provider: "events-test"}},
+	}
+	if err := registerProviders(ctx, eng, sm.Events(), clusters); err != nil {
+		t.Fatalf("registerProviders() error = %v", err)
+	}
+	if _, err := eng.LoadProvider(ctx, "events-test"); err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+
+	if factoryEvents == nil {
+		t.Fatal("provider factory got no event store")
+	}
+	resource := api.ResourceID{Provider: "events-test", Kind: "Cluster", ID: "cluster-1"}
+	if err := factoryEvents.RecordEvent(ctx, api.Event{Type: api.EventPhaseChanged, Resource: resource}); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+	events, err := sm.Events().GetEvents(ctx, resource)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("GetEvents() = %d events, want the one recorded through the provider's store", len(events))
+	}
+}
+
+func TestRegisterProviders_Regions(t *testing.T) {
+	ctx := context.Background()
+	eng := engine.NewEngine(nil, nil)
+
+	stored := map[string]*api.Cluster{
+		"cluster-1": {ID: "cluster-1", Spec: api.ClusterSpec{Provider: "region-test", Region: "us-west-2"}},
+	}
+	desired := map[string]*api.Cluster{
+		"cluster-1": stored["cluster-1"],
+		"cluster-2": {ID: "cluster-2", Spec: api.ClusterSpec{Provider: "region-test", Region: "eu-west-1"}},
+	}
+	if err := registerProviders(ctx, eng, nil, stored); err != nil {
+		t.Fatalf("registerProviders() error = %v", err)
+	}
+	if err := registerProviders(ctx, eng, nil, desired); err != nil {
+		t.Fatalf("registerProviders() error = %v", err)
+	}
+
+	for _, region := range []string{"us-west-2", "eu-west-1"} {
+		provider, err := eng.LoadRegionalProvider(ctx, "region-test", region)
+		if err != nil {
+			t.Fatalf("LoadRegionalProvider(%s) error = %v", region, err)
+		}
+		if got := regionTestProviders[provider]; got != region {
+			t.Errorf("provider for %s was constructed for region %q", region, got)
+		}
+	}
+}
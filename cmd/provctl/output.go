@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/autoscaler"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func outputCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "output <cluster> [key]",
+		Short: "Print attributes of a created cluster",
+		Long: `Print the attributes providers recorded for a cluster, such as its API
+endpoint, OIDC issuer, VPC and security groups. Without a key all attributes
+are printed as a JSON object; with a key only its value is printed, for use in
+scripts:
+
+  provctl output production endpoint
+
+The autoscaler-priorities key prints the cluster-autoscaler priority expander
+ConfigMap generated from the worker pools' priorities:
+
+  provctl output production autoscaler-priorities | kubectl apply -f -`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := ""
+			if len(args) == 2 {
+				key = args[1]
+			}
+			return showOutput(args[0], key)
+		},
+	}
+}
+
+// outputAutoscalerPriorities is the output key of the priority expander
+// ConfigMap, generated from the spec rather than recorded by a provider
+const outputAutoscalerPriorities = "autoscaler-priorities"
+
+func showOutput(name, key string) error {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	for _, cluster := range current.Clusters {
+		if cluster.Metadata.Name == name {
+			return writeOutput(os.Stdout, cluster, key)
+		}
+	}
+	return fmt.Errorf("cluster %s not found in state", name)
+}
+
+// writeOutput prints one property value, or every property as JSON when key
+// is empty
+func writeOutput(out io.Writer, cluster *api.Cluster, key string) error {
+	if key == "" {
+		properties := cluster.Status.Properties
+		if properties == nil {
+			properties = map[string]string{}
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(properties)
+	}
+
+	if key == outputAutoscalerPriorities {
+		_, err := io.WriteString(out, autoscaler.PriorityConfigMap(cluster))
+		return err
+	}
+
+	value, exists := cluster.Status.Properties[key]
+	if !exists {
+		return fmt.Errorf("cluster %s has no output %q", cluster.Metadata.Name, key)
+	}
+	_, err := fmt.Fprintln(out, value)
+	return err
+}
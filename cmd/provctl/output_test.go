@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestWriteOutput(t *testing.T) {
+	cluster := &api.Cluster{
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Status: api.ResourceStatus{Properties: map[string]string{
+			api.PropertyEndpoint: "https://prod.example.com",
+			api.PropertyVPCID:    "vpc-123",
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		cluster *api.Cluster
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "all properties",
+			cluster: cluster,
+			want:    "{\n  \"endpoint\": \"https://prod.example.com\",\n  \"vpc_id\": \"vpc-123\"\n}\n",
+		},
+		{name: "single value", cluster: cluster, key: api.PropertyVPCID, want: "vpc-123\n"},
+		{name: "unknown key", cluster: cluster, key: api.PropertyOIDCIssuer, wantErr: true},
+		{name: "no properties", cluster: &api.Cluster{}, want: "{}\n"},
+		{
+			name:    "autoscaler priorities",
+			cluster: &api.Cluster{ID: "c-1", Spec: api.ClusterSpec{WorkerPools: []api.WorkerPoolSpec{{Name: "general", Priority: 10}}}},
+			key:     "autoscaler-priorities",
+			want: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cluster-autoscaler-priority-expander\n  namespace: kube-system\n" +
+				"data:\n  priorities: |-\n    10:\n      - '^c-1-general$'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeOutput(&out, tt.cluster, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := out.String(); !tt.wantErr && got != tt.want {
+				t.Errorf("writeOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/cost"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	planRefresh  bool
+	planCacheTTL time.Duration
+	planTargets  []string
+	planOut      string
+	planDiff     bool
+)
+
+func planCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan [config-path]",
+		Short: "Show changes required by an HCL configuration",
+		Long: `Compare the desired configuration against actual state and show the
+actions apply would take. By default actual state is refreshed from the cloud
+providers; use --refresh=false to diff against stored state for a fast local plan.
+Refreshed state is cached per provider region for --cache-ttl, so plans run
+back to back do not query the providers again; apply clears the cache.
+
+Clusters whose estimated monthly cost is more than --cost-increase-threshold
+percent above the estimate recorded at their last apply are flagged.
+
+Each update lists the fields it changes, the old value prefixed - and the new
+one +; --diff=false shows only the updated resources.
+
+With --out the plan is saved, to be applied later as it is with
+apply --plan-file.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return planConfig(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&planRefresh, "refresh", true, "query providers for actual state before planning")
+	cmd.Flags().DurationVar(&planCacheTTL, "cache-ttl", planner.DefaultCacheTTL, "reuse refreshed state this recent (0 disables the cache)")
+	cmd.Flags().BoolVar(&disableProtection, "disable-protection", false, "allow plans that delete clusters with deletion protection")
+	cmd.Flags().BoolVar(&showCost, "cost", false, "annotate each action with its estimated monthly cost change")
+	cmd.Flags().BoolVar(&planDiff, "diff", true, "show the old and new value of every field an update changes")
+	cmd.Flags().StringVar(&planOut, "out", "", "save the plan to this file for apply --plan-file")
+	addCostThresholdFlag(cmd)
+	addTargetFlag(cmd)
+	addStrictFlag(cmd)
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+func planConfig(ctx context.Context, configFile string) error {
+	ctx = engine.WithCorrelationID(ctx, engine.NewCorrelationID())
+
+	p, err := newPlanner(planDiff)
+	if err != nil {
+		return err
+	}
+
+	file, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	if err := validateConfig(os.Stdout, file); err != nil {
+		return err
+	}
+	if _, err := checkGuardrails(ctx, os.Stdout, file); err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	desired := file.DesiredState(stored)
+
+	source, err := planStateSource(ctx, p, sm, stored)
+	if err != nil {
+		return err
+	}
+
+	plan, err := p.PlanFrom(ctx, desired, source)
+	if err != nil {
+		return err
+	}
+	// A saved plan gets its ID now, so the audit record names the plan
+	// a later apply --plan-file applies
+	if planOut != "" {
+		plan.ID = uuid.NewString()
+	}
+	if err := auditPlan(ctx, newAuditWriter(sm.Events()), configFile, plan); err != nil {
+		return err
+	}
+
+	fmt.Printf("Planning against %s\n\n", source.Name())
+	fmt.Print(p.PrintPlan(plan))
+	if err := warnCostIncreases(ctx, os.Stdout, sm.Events(), desiredSpecs(desired), costIncreaseThreshold); err != nil {
+		return err
+	}
+
+	if planOut == "" {
+		return nil
+	}
+	fingerprint, err := planner.StateFingerprint(stored)
+	if err != nil {
+		return err
+	}
+	saved := planner.SavedPlan{
+		Plan:              plan,
+		CreatedAt:         time.Now(),
+		DefaultTags:       file.DefaultTags.Tags,
+		MaintenanceWindow: file.MaintenanceWindow.Schedule,
+		StateFingerprint:  fingerprint,
+	}
+	if err := writePlanFile(planOut, saved); err != nil {
+		return err
+	}
+	fmt.Printf("\nSaved the plan to %s. Apply it with:\n  provctl apply --plan-file %s\n", planOut, planOut)
+	return nil
+}
+
+// desiredSpecs returns the specs of the desired clusters keyed by name
+func desiredSpecs(desired engine.State) map[string]api.ClusterSpec {
+	specs := make(map[string]api.ClusterSpec, len(desired.Clusters))
+	for _, cluster := range desired.Clusters {
+		specs[cluster.Metadata.Name] = cluster.Spec
+	}
+	return specs
+}
+
+// planStateSource picks the actual-state source according to --refresh. A
+// refresh sets up providers, which the planner then also uses to estimate
+// provisioning times. Providers are constructed only when used, so a plan
+// served from the refresh cache constructs none for unchanged clusters.
+func planStateSource(ctx context.Context, p *planner.Planner, sm *state.SQLiteStateManager, stored engine.State) (planner.StateSource, error) {
+	if !planRefresh {
+		return planner.NewStoredStateSource(sm), nil
+	}
+
+	eng := engine.NewEngine(sm, nil)
+	if err := registerProviders(ctx, eng, sm.Events(), stored.Clusters); err != nil {
+		return nil, err
+	}
+	p.SetProviders(eng.LoadRegionalProvider)
+	source := planner.NewLiveStateSource(eng)
+	if planCacheTTL > 0 {
+		source.SetCache(sm.RefreshCache(), planCacheTTL)
+	}
+	return source, nil
+}
+
+// invalidateRefreshCache drops the cached refreshes of every provider a plan
+// touched, so that the next plan sees the applied changes
+func invalidateRefreshCache(ctx context.Context, sm *state.SQLiteStateManager, plan engine.Plan) {
+	providers := make(map[string]bool)
+	for _, action := range plan.Actions {
+		if action.Type != engine.ActionNoop {
+			providers[action.Resource.Provider] = true
+		}
+	}
+
+	for provider := range providers {
+		if err := sm.RefreshCache().Invalidate(ctx, provider); err != nil {
+			loggerFrom(ctx).Warn("failed to invalidate refresh cache", "provider", provider, "error", err)
+		}
+	}
+}
+
+// addTargetFlag registers --target for commands that plan changes
+func addTargetFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&planTargets, "target", nil,
+		"limit the plan to a resource and its dependencies (cluster=<name> or nodepool=<cluster>/<pool>, repeatable)")
+}
+
+// newPlanner creates a planner configured from the shared plan flags,
+// showing the field-level changes of updates when diff is set. Plan and
+// apply default --diff differently, so each has its own flag.
+func newPlanner(diff bool) (*planner.Planner, error) {
+	var targets []planner.Target
+	for _, raw := range planTargets {
+		target, err := planner.ParseTarget(raw)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	p := planner.NewPlanner(nil)
+	p.SetDisableProtection(disableProtection)
+	p.SetTargets(targets)
+	p.SetColor(colorEnabled())
+	p.SetDiff(diff)
+	if showCost {
+		p.SetEstimator(cost.NewEstimator())
+	}
+	return p, nil
+}
+
+// colorEnabled reports whether human-readable output on stdout is colored
+func colorEnabled() bool {
+	return !noColor && color.Enabled(os.Stdout)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+// lazyTestCluster is the cluster the lazy-test provider reports
+var lazyTestCluster = &api.Cluster{
+	ID:       "cluster-1",
+	Metadata: api.ResourceMetadata{Name: "prod"},
+	Spec:     api.ClusterSpec{Provider: "lazy-test", Region: "region-1"},
+}
+
+// lazyTestLoads counts constructions of the lazy-test provider, each of
+// which takes lazyTestDelay in place of resolving cloud credentials
+var (
+	lazyTestLoads atomic.Int32
+	lazyTestDelay time.Duration
+)
+
+func init() {
+	engine.RegisterProviderFactory("lazy-test", func(ctx context.Context, cfg engine.ProviderConfig) (engine.CloudProvider, error) {
+		lazyTestLoads.Add(1)
+		time.Sleep(lazyTestDelay)
+		provider := fake.NewProvider("lazy-test")
+		provider.SeedCluster(lazyTestCluster)
+		return provider, nil
+	})
+}
+
+// planWithRefresh runs the state source and planning steps of the plan
+// command with --refresh and a refresh cache
+func planWithRefresh(ctx context.Context, sm *state.SQLiteStateManager) error {
+	planRefresh, planCacheTTL = true, time.Hour
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return err
+	}
+	p := planner.NewPlanner(nil)
+	source, err := planStateSource(ctx, p, sm, stored)
+	if err != nil {
+		return err
+	}
+	_, err = p.PlanFrom(ctx, stored, source)
+	return err
+}
+
+func newLazyTestState(tb testing.TB) *state.SQLiteStateManager {
+	sm, err := state.NewSQLiteStateManager(filepath.Join(tb.TempDir(), "state.db"))
+	if err != nil {
+		tb.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	tb.Cleanup(func() { sm.Close() })
+
+	current := engine.State{Clusters: map[string]*api.Cluster{lazyTestCluster.ID: lazyTestCluster}}
+	if err := sm.SaveState(context.Background(), current); err != nil {
+		tb.Fatalf("SaveState() error = %v", err)
+	}
+	return sm
+}
+
+func TestPlanStateSource_CachedRefreshConstructsNoProvider(t *testing.T) {
+	defer func(refresh bool, ttl time.Duration) { planRefresh, planCacheTTL = refresh, ttl }(planRefresh, planCacheTTL)
+	ctx := context.Background()
+	sm := newLazyTestState(t)
+	lazyTestLoads.Store(0)
+
+	if err := planWithRefresh(ctx, sm); err != nil {
+		t.Fatalf("plan error = %v", err)
+	}
+	if n := lazyTestLoads.Load(); n != 1 {
+		t.Fatalf("first plan constructed the provider %d time(s), want 1 to refresh", n)
+	}
+
+	if err := planWithRefresh(ctx, sm); err != nil {
+		t.Fatalf("plan error = %v", err)
+	}
+	if n := lazyTestLoads.Load(); n != 1 {
+		t.Errorf("plan from the refresh cache constructed the provider, %d construction(s) in all, want 1", n)
+	}
+}
+
+func BenchmarkPlan_CachedRefresh(b *testing.B) {
+	defer func(refresh bool, ttl time.Duration) { planRefresh, planCacheTTL = refresh, ttl }(planRefresh, planCacheTTL)
+	defer func(delay time.Duration) { lazyTestDelay = delay }(lazyTestDelay)
+	ctx := context.Background()
+	sm := newLazyTestState(b)
+
+	// Stands in for SDK configuration loading and credential resolution
+	lazyTestDelay = 10 * time.Millisecond
+	if err := planWithRefresh(ctx, sm); err != nil {
+		b.Fatalf("plan error = %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := planWithRefresh(ctx, sm); err != nil {
+			b.Fatalf("plan error = %v", err)
+		}
+	}
+}
+
+func TestPlanCmd_DiffDefault(t *testing.T) {
+	defer func(plan, apply bool) { planDiff, applyDiff = plan, apply }(planDiff, applyDiff)
+
+	// Registered in the order of the root command
+	plan := planCmd()
+	apply := applyCmd()
+
+	if err := plan.ParseFlags(nil); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if !planDiff {
+		t.Error("plan --diff defaults to false, want true")
+	}
+	if err := apply.ParseFlags(nil); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if applyDiff {
+		t.Error("apply --diff defaults to true, want false")
+	}
+
+	if err := plan.ParseFlags([]string{"--diff=false"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := apply.ParseFlags([]string{"--diff"}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if planDiff || !applyDiff {
+		t.Errorf("plan --diff=false and apply --diff set planDiff = %v, applyDiff = %v", planDiff, applyDiff)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+// writePlanFile saves a plan for apply --plan-file
+func writePlanFile(path string, saved planner.SavedPlan) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	if err := planner.WritePlan(f, saved); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to save plan: %w", err)
+	}
+	return nil
+}
+
+// readPlanFile loads a plan saved by writePlanFile
+func readPlanFile(path string) (planner.SavedPlan, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return planner.SavedPlan{}, fmt.Errorf("failed to open plan: %w", err)
+	}
+	defer f.Close()
+
+	saved, err := planner.ReadPlan(f)
+	if err != nil {
+		return planner.SavedPlan{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return saved, nil
+}
+
+// applySavedPlan applies a plan saved by plan --out, skipping the actions
+// an earlier, interrupted apply of it completed
+func applySavedPlan(ctx context.Context, path string) error {
+	ctx = engine.WithCorrelationID(ctx, engine.NewCorrelationID())
+	loggerFrom(ctx).InfoContext(ctx, "applying saved plan", "file", path)
+
+	saved, err := readPlanFile(path)
+	if err != nil {
+		return err
+	}
+	plan := saved.Plan
+
+	p, err := newPlanner(applyDiff)
+	if err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	stored, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	planned := engine.State{Clusters: plannedClusters(plan)}
+
+	window, err := maintenanceWindow(saved.MaintenanceWindow)
+	if err != nil {
+		return err
+	}
+
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.SetDisableProtection(disableProtection)
+	eng.SetForceUpgrade(forceUpgrade)
+	eng.SetDefaultTags(saved.DefaultTags)
+	eng.SetMaintenanceWindow(window)
+	if err := registerProviders(ctx, eng, sm.Events(), stored.Clusters); err != nil {
+		return err
+	}
+	if err := registerProviders(ctx, eng, sm.Events(), planned.Clusters); err != nil {
+		return err
+	}
+
+	applied, err := eng.AppliedActions(ctx, plan)
+	if err != nil {
+		return err
+	}
+	if err := checkStalePlan(saved, stored, applied); err != nil {
+		return err
+	}
+	remaining := pendingActions(plan, applied)
+
+	fmt.Printf("Applying the plan saved at %s\n\n", saved.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Print(p.PrintPlan(remaining))
+	if len(applied) > 0 {
+		fmt.Printf("\nResuming: %d of %d action(s) were applied by an earlier run and are skipped.\n",
+			len(applied), len(plan.Actions))
+	}
+	if len(remaining.Actions) == 0 {
+		fmt.Println("\nNothing left to apply.")
+		return nil
+	}
+	if err := checkMaintenanceWindow(window); err != nil {
+		return err
+	}
+
+	if approved, err := approveApply(remaining); err != nil || !approved {
+		return err
+	}
+
+	// Even a failed apply may have changed some resources
+	defer invalidateRefreshCache(ctx, sm, remaining)
+	apply := func() error { return eng.Apply(ctx, plan) }
+	if err := auditedApply(ctx, newAuditWriter(sm.Events()), path, remaining, apply); err != nil {
+		return fmt.Errorf("apply failed: %w; apply --plan-file %s again to resume", err, path)
+	}
+	if err := recordAppliedCosts(ctx, sm.Events(), planned, remaining); err != nil {
+		loggerFrom(ctx).WarnContext(ctx, "failed to record cost estimates", "error", err)
+	}
+
+	fmt.Printf("\nApply complete! %d action(s) applied.\n", len(remaining.Actions))
+	return nil
+}
+
+// checkStalePlan refuses a saved plan whose state has changed since it was
+// made: its actions were worked out against clusters that are no longer as
+// they were. A plan an earlier apply partly applied changed state itself, so
+// resuming it is allowed.
+func checkStalePlan(saved planner.SavedPlan, stored engine.State, applied map[int]bool) error {
+	if len(applied) > 0 {
+		return nil
+	}
+	current, err := planner.StateFingerprint(stored)
+	if err != nil {
+		return err
+	}
+	if current != saved.StateFingerprint {
+		return fmt.Errorf("state has changed since the plan was saved at %s; run plan --out again",
+			saved.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// plannedClusters returns the clusters a plan creates or updates, keyed by
+// the ID of their action
+func plannedClusters(plan engine.Plan) map[string]*api.Cluster {
+	clusters := make(map[string]*api.Cluster)
+	for _, action := range plan.Actions {
+		spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+		if !ok || action.Resource.Kind != "Cluster" {
+			continue
+		}
+		clusters[action.Resource.ID] = &api.Cluster{
+			ID:       action.Resource.ID,
+			Metadata: api.ResourceMetadata{Name: action.Resource.Name},
+			Spec:     spec,
+		}
+	}
+	return clusters
+}
+
+// pendingActions returns the plan without the actions already applied
+func pendingActions(plan engine.Plan, applied map[int]bool) engine.Plan {
+	remaining := engine.Plan{ID: plan.ID}
+	for i, action := range plan.Actions {
+		if !applied[i] {
+			remaining.Actions = append(remaining.Actions, action)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+)
+
+func TestPlanFile(t *testing.T) {
+	plan := engine.Plan{Actions: []engine.Action{
+		{
+			Type:       engine.ActionCreate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "prod", Name: "prod"},
+			Parameters: map[string]interface{}{"spec": api.ClusterSpec{Provider: "aws", Region: "us-west-2"}},
+		},
+		{
+			Type:     engine.ActionDelete,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "old"},
+		},
+	}}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := writePlanFile(path, planner.SavedPlan{Plan: plan, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("writePlanFile() error = %v", err)
+	}
+	saved, err := readPlanFile(path)
+	if err != nil {
+		t.Fatalf("readPlanFile() error = %v", err)
+	}
+	if saved.Plan.ID == "" || len(saved.Plan.Actions) != 2 {
+		t.Fatalf("readPlanFile() = %+v, want both actions and an ID", saved.Plan)
+	}
+
+	clusters := plannedClusters(saved.Plan)
+	if len(clusters) != 1 || clusters["prod"].Spec.Region != "us-west-2" {
+		t.Errorf("plannedClusters() = %v, want prod only", clusters)
+	}
+
+	remaining := pendingActions(saved.Plan, map[int]bool{0: true})
+	if remaining.ID != saved.Plan.ID || len(remaining.Actions) != 1 || remaining.Actions[0].Type != engine.ActionDelete {
+		t.Errorf("pendingActions() = %+v, want the delete of the same plan", remaining)
+	}
+
+	if _, err := readPlanFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("readPlanFile() error = nil, want an error for a missing file")
+	}
+}
+
+func TestCheckStalePlan(t *testing.T) {
+	planned := engine.State{Clusters: map[string]*api.Cluster{
+		"c-1": {ID: "c-1", Spec: api.ClusterSpec{Provider: "aws", Region: "us-west-2"}},
+	}}
+	fingerprint, err := planner.StateFingerprint(planned)
+	if err != nil {
+		t.Fatalf("StateFingerprint() error = %v", err)
+	}
+	saved := planner.SavedPlan{CreatedAt: time.Now(), StateFingerprint: fingerprint}
+
+	if err := checkStalePlan(saved, planned, nil); err != nil {
+		t.Errorf("checkStalePlan() of unchanged state error = %v, want nil", err)
+	}
+
+	changed := engine.State{Clusters: map[string]*api.Cluster{
+		"c-1": {ID: "c-1", Spec: api.ClusterSpec{Provider: "aws", Region: "us-east-1"}},
+	}}
+	if err := checkStalePlan(saved, changed, nil); err == nil || !strings.Contains(err.Error(), "state has changed") {
+		t.Errorf("checkStalePlan() of changed state error = %v, want a stale plan error", err)
+	}
+	if err := checkStalePlan(saved, changed, map[int]bool{0: true}); err != nil {
+		t.Errorf("checkStalePlan() resuming a partly applied plan error = %v, want nil", err)
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	t.Cleanup(func() { applyWindow = "" })
+
+	if window, err := maintenanceWindow(""); err != nil || window != nil {
+		t.Errorf("maintenanceWindow() = %+v, %v, want none without a flag or configuration", window, err)
+	}
+
+	window, err := maintenanceWindow("Sat 02:00-06:00")
+	if err != nil {
+		t.Fatalf("maintenanceWindow() error = %v", err)
+	}
+	if len(window.Weekdays) != 1 || window.Weekdays[0] != time.Saturday {
+		t.Errorf("maintenanceWindow() = %+v, want the configured Saturday window", window)
+	}
+
+	applyWindow = "Sun 02:00-06:00"
+	window, err = maintenanceWindow("Sat 02:00-06:00")
+	if err != nil {
+		t.Fatalf("maintenanceWindow() error = %v", err)
+	}
+	if len(window.Weekdays) != 1 || window.Weekdays[0] != time.Sunday {
+		t.Errorf("maintenanceWindow() = %+v, want --maintenance-window to override the configuration", window)
+	}
+
+	applyWindow = "Weekends"
+	if _, err := maintenanceWindow(""); err == nil {
+		t.Error("maintenanceWindow() error = nil, want an invalid --maintenance-window rejected")
+	}
+}
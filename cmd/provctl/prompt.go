@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+)
+
+// confirm asks a yes/no question and reports whether the user typed "yes"
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s\n  Only 'yes' will be accepted to approve.\n\n  Enter a value: ", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	return strings.TrimSpace(answer) == "yes"
+}
+
+// confirmApply asks the user to approve a plan. Plans that delete or
+// replace clusters require typing each such cluster's name; other plans
+// require "yes".
+func confirmApply(in io.Reader, out io.Writer, plan engine.Plan) bool {
+	type destructive struct {
+		action  engine.Action
+		warning string
+	}
+	var actions []destructive
+	for _, action := range planner.ActionsOfType(plan, engine.ActionDelete) {
+		actions = append(actions, destructive{action, fmt.Sprintf("This plan deletes %s %s.",
+			action.Resource.Kind, action.Resource.Name)})
+	}
+	for _, action := range planner.ActionsOfType(plan, engine.ActionUpdate) {
+		if len(planner.ReplacementChanges(action)) > 0 {
+			actions = append(actions, destructive{action, fmt.Sprintf("This plan replaces %s %s, destroying and recreating it.",
+				action.Resource.Kind, action.Resource.Name)})
+		}
+	}
+	if len(actions) == 0 {
+		return confirm(in, out, "Do you want to perform these actions?")
+	}
+
+	reader := bufio.NewReader(in)
+	for _, d := range actions {
+		fmt.Fprintf(out, "%s\n  Type the name of the %s to confirm: ", d.warning, strings.ToLower(d.action.Resource.Kind))
+
+		answer, err := reader.ReadString('\n')
+		if err != nil && answer == "" {
+			return false
+		}
+		if strings.TrimSpace(answer) != d.action.Resource.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+)
+
+func TestConfirmApply(t *testing.T) {
+	createPlan := engine.Plan{Actions: []engine.Action{
+		{Type: engine.ActionCreate, Resource: api.ResourceID{Kind: "Cluster", Name: "staging"}},
+	}}
+	deletePlan := engine.Plan{Actions: []engine.Action{
+		{Type: engine.ActionCreate, Resource: api.ResourceID{Kind: "Cluster", Name: "staging"}},
+		{Type: engine.ActionDelete, Resource: api.ResourceID{Kind: "Cluster", Name: "prod"}},
+		{Type: engine.ActionDelete, Resource: api.ResourceID{Kind: "Cluster", Name: "dev"}},
+	}}
+	replacePlan := engine.Plan{Actions: []engine.Action{
+		{Type: engine.ActionUpdate, Resource: api.ResourceID{Kind: "Cluster", Name: "staging"}},
+		{
+			Type:       engine.ActionUpdate,
+			Resource:   api.ResourceID{Kind: "Cluster", Name: "prod"},
+			Parameters: map[string]interface{}{planner.ParamReplacement: []api.FieldChange{{Path: "network.vpcCidr"}}},
+		},
+	}}
+
+	tests := []struct {
+		name  string
+		plan  engine.Plan
+		input string
+		want  bool
+	}{
+		{name: "yes approves", plan: createPlan, input: "yes\n", want: true},
+		{name: "y is not enough", plan: createPlan, input: "y\n", want: false},
+		{name: "empty input", plan: createPlan, input: "", want: false},
+		{name: "deletes need names", plan: deletePlan, input: "yes\n", want: false},
+		{name: "all names typed", plan: deletePlan, input: "dev\nprod\n", want: true},
+		{name: "wrong order", plan: deletePlan, input: "prod\ndev\n", want: false},
+		{name: "one name missing", plan: deletePlan, input: "dev\n", want: false},
+		{name: "surrounding whitespace", plan: deletePlan, input: "  dev \nprod", want: true},
+		{name: "replacements need names", plan: replacePlan, input: "yes\n", want: false},
+		{name: "replaced name typed", plan: replacePlan, input: "prod\n", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := confirmApply(strings.NewReader(tt.input), &out, tt.plan); got != tt.want {
+				t.Errorf("confirmApply(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/cost"
+	"github.com/vjranagit/cluster-api/pkg/format"
+)
+
+var (
+	recommendProvider string
+	recommendRegion   string
+	recommendVCPU     int
+	recommendMemoryGB float64
+	recommendSpot     bool
+)
+
+// recommendDefaultRegions is the region priced when --region is not given
+var recommendDefaultRegions = map[string]string{
+	"aws":   "us-west-2",
+	"azure": "eastus",
+}
+
+func recommendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recommend",
+		Short: "Recommend the cheapest instance type for a node size",
+		Long: `Pick the cheapest instance type with at least the given vCPUs and memory per
+node, using the same pricing data as cost estimates, and explain the choice.`,
+		Example: "  provctl recommend --vcpu 4 --memory 16 --provider aws --spot",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeRecommendation(cmd.Context(), os.Stdout, cost.NewEstimator(), recommendProvider, recommendRegion,
+				recommendVCPU, recommendMemoryGB, recommendSpot)
+		},
+	}
+
+	cmd.Flags().StringVar(&recommendProvider, "provider", "aws", "cloud provider (aws, azure)")
+	cmd.Flags().StringVar(&recommendRegion, "region", "", "region to price (default us-west-2 on AWS, eastus on Azure)")
+	cmd.Flags().IntVar(&recommendVCPU, "vcpu", 0, "vCPUs required per node")
+	cmd.Flags().Float64Var(&recommendMemoryGB, "memory", 0, "memory required per node, in GB")
+	cmd.Flags().BoolVar(&recommendSpot, "spot", false, "compare spot rather than on-demand prices")
+	cmd.MarkFlagRequired("vcpu")
+	cmd.MarkFlagRequired("memory")
+
+	return cmd
+}
+
+// writeRecommendation recommends an instance type and writes it with the
+// reasoning behind it
+func writeRecommendation(ctx context.Context, out io.Writer, estimator *cost.Estimator, provider, region string, vcpu int, memoryGB float64, spot bool) error {
+	if region == "" {
+		region = recommendDefaultRegions[provider]
+	}
+
+	recommendation, err := estimator.Recommend(ctx, provider, region, vcpu, memoryGB, spot)
+	if err != nil {
+		return err
+	}
+
+	rates := "on-demand"
+	if spot {
+		rates = "spot"
+	}
+	fmt.Fprintf(out, "Recommended instance type: %s (%s/%s)\n", recommendation.InstanceType, provider, region)
+	fmt.Fprintf(out, "  Size: %d vCPU, %g GB\n", recommendation.Price.VCPU, recommendation.Price.MemoryGB)
+	fmt.Fprintf(out, "  Cost: $%.4f/hour, %s/month per node (%s)\n",
+		recommendation.HourlyCost, format.Money(recommendation.MonthlyCost, "USD"), rates)
+	fmt.Fprintf(out, "  Why:  %s\n", recommendation.Reason(vcpu, memoryGB))
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/cost"
+)
+
+func TestWriteRecommendation(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeRecommendation(context.Background(), &out, cost.NewEstimator(), "aws", "", 4, 16, false); err != nil {
+		t.Fatalf("writeRecommendation() error = %v", err)
+	}
+	for _, want := range []string{
+		"Recommended instance type: t3.xlarge (aws/us-west-2)",
+		"Size: 4 vCPU, 16 GB",
+		"Cost: $0.1664/hour, $121.47/month per node (on-demand)",
+		"Why:  cheapest of 4 of 10",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want %q", out.String(), want)
+		}
+	}
+
+	if err := writeRecommendation(context.Background(), &out, cost.NewEstimator(), "gcp", "", 4, 16, false); err == nil {
+		t.Error("writeRecommendation() error = nil, want an error without pricing data")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	refreshDryRun      bool
+	refreshAutoApprove bool
+)
+
+func refreshCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Update stored state to match actual cloud resources",
+		Long: `Query each provider for the clusters and node pools tracked in state and
+update the stored spec and status to match reality. The cloud is never modified.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return refreshState(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&refreshDryRun, "dry-run", false, "show state changes without saving them")
+	cmd.Flags().BoolVar(&refreshAutoApprove, "auto-approve", false, "skip interactive confirmation")
+
+	return cmd
+}
+
+func refreshState(ctx context.Context) error {
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	eng := engine.NewEngine(sm, nil)
+	if err := registerProviders(ctx, eng, sm.Events(), current.Clusters); err != nil {
+		return err
+	}
+
+	result, err := eng.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(formatRefreshResult(result))
+
+	if !result.HasChanges() || refreshDryRun {
+		return nil
+	}
+
+	if !refreshAutoApprove && !confirm(os.Stdin, os.Stdout, "Do you want to update state with these changes?") {
+		fmt.Println("Refresh cancelled.")
+		return nil
+	}
+
+	if err := sm.SaveState(ctx, result.State); err != nil {
+		return fmt.Errorf("failed to save refreshed state: %w", err)
+	}
+
+	fmt.Printf("State updated: %d resource(s) refreshed\n", len(result.Changes))
+	return nil
+}
+
+// registerProviders registers a provider for every provider region
+// referenced by clusters, recording its events in events. Providers are
+// constructed, and their credentials validated, only when first used, so
+// commands that never reach the cloud skip that cost.
+func registerProviders(ctx context.Context, eng *engine.Engine, events engine.EventStore, clusters map[string]*api.Cluster) error {
+	known := make(map[string]bool)
+	for _, name := range engine.ProviderFactories() {
+		known[name] = true
+	}
+
+	for _, cluster := range clusters {
+		name, region := cluster.Spec.Provider, cluster.Spec.Region
+		if eng.HasRegionalProvider(name, region) {
+			continue
+		}
+		if !known[name] {
+			return fmt.Errorf("cluster %s: %w: %q", cluster.Metadata.Name, engine.ErrProviderNotFound, name)
+		}
+
+		eng.RegisterRegionalProviderLoader(name, region, func(ctx context.Context) (engine.CloudProvider, error) {
+			return newProvider(ctx, name, region, events)
+		})
+	}
+
+	return nil
+}
+
+func formatRefreshResult(result *engine.RefreshResult) string {
+	if !result.HasChanges() && len(result.Missing) == 0 {
+		return "✓ State matches cloud reality - nothing to refresh\n"
+	}
+
+	output := ""
+	if result.HasChanges() {
+		output += fmt.Sprintf("Refresh found %d resource(s) with changes:\n\n", len(result.Changes))
+		for _, change := range result.Changes {
+			output += fmt.Sprintf("  ~ %s/%s (%s)\n", change.Resource.Kind, change.Resource.Name, change.Resource.ID)
+			for _, field := range change.Fields {
+				output += fmt.Sprintf("      %s: %v → %v\n", field.Path, field.Old, field.New)
+			}
+		}
+		output += "\n"
+	}
+
+	if len(result.Missing) > 0 {
+		output += "Not found in cloud (left in state):\n"
+		for _, resource := range result.Missing {
+			output += fmt.Sprintf("  ? %s/%s (%s)\n", resource.Kind, resource.Name, resource.ID)
+		}
+		output += "\n"
+	}
+
+	return output
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vjranagit/cluster-api/pkg/format"
+	"github.com/vjranagit/cluster-api/pkg/snapshot"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	snapshotDir   string
+	snapshotDesc  string
+	snapshotTags  []string
+	restoreDryRun bool
+	restoreOnly   []string
+
+	scheduleInterval    time.Duration
+	scheduleKeep        int
+	scheduleMaxAge      time.Duration
+	scheduleKeepTags    []string
+	scheduleKeepReasons []string
+	schedulePruneJobs   int
+)
+
+func snapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Manage state snapshots",
+	}
+
+	cmd.PersistentFlags().StringVar(&snapshotDir, "snapshot-dir", "./snapshots", "directory holding state snapshots")
+
+	cmd.AddCommand(snapshotCreateCmd())
+	cmd.AddCommand(snapshotListCmd())
+	cmd.AddCommand(snapshotRestoreCmd())
+	cmd.AddCommand(snapshotScheduleCmd())
+	cmd.AddCommand(snapshotVerifyCmd())
+
+	return cmd
+}
+
+func snapshotCreateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a snapshot of current state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createSnapshot()
+		},
+	}
+
+	cmd.Flags().StringVar(&snapshotDesc, "description", "", "snapshot description")
+	cmd.Flags().StringArrayVar(&snapshotTags, "tag", nil, "tag the snapshot (key=value, repeatable)")
+
+	return cmd
+}
+
+func snapshotListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listSnapshots()
+		},
+	}
+}
+
+func snapshotRestoreCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore [snapshot-id]",
+		Short: "Restore state from a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return restoreSnapshot(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&restoreDryRun, "dry-run", false, "show changes without restoring")
+	cmd.Flags().StringArrayVar(&restoreOnly, "only", nil, "restore only matching resources (cluster=name or nodepool=name, repeatable)")
+
+	return cmd
+}
+
+func snapshotScheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Take snapshots on an interval until interrupted",
+		Long: `Take a scheduled snapshot every --interval and prune old scheduled snapshots
+with the retention flags after each one. Snapshots taken for other reasons,
+such as snapshot create, are never pruned by the schedule. Runs until
+interrupted:
+
+  provctl snapshot schedule --interval 1h --keep 48`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return scheduleSnapshots(cmd.Context())
+		},
+	}
+
+	cmd.Flags().DurationVar(&scheduleInterval, "interval", time.Hour, "time between snapshots")
+	cmd.Flags().IntVar(&scheduleKeep, "keep", 24, "number of most recent scheduled snapshots to keep (0 keeps all)")
+	cmd.Flags().DurationVar(&scheduleMaxAge, "max-age", 0, "delete scheduled snapshots older than this (0 disables)")
+	cmd.Flags().StringArrayVar(&scheduleKeepTags, "keep-tag", nil, "never delete snapshots with this tag (key=value, repeatable)")
+	cmd.Flags().StringArrayVar(&scheduleKeepReasons, "keep-reason", nil, "never delete snapshots taken for this reason, e.g. pre_upgrade (repeatable)")
+	cmd.Flags().IntVar(&schedulePruneJobs, "prune-concurrency", snapshot.DefaultPruneConcurrency, "number of snapshots to delete at once when pruning")
+
+	return cmd
+}
+
+func snapshotVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify [snapshot-id]",
+		Short: "Check that snapshots are readable and match their checksums",
+		Long: `Load every snapshot, or the one given, recompute its checksum and report
+whether it is intact. Snapshots that cannot be read or unmarshaled are
+reported too. Exits non-zero if any snapshot fails, so it can gate DR drills:
+
+  provctl snapshot verify
+  provctl snapshot verify snapshot-20240501-120000-000000-1a2b3c4d`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifySnapshots(args)
+		},
+	}
+}
+
+func openSnapshotManager() (*snapshot.Manager, *state.SQLiteStateManager, error) {
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create state manager: %w", err)
+	}
+
+	manager, err := snapshot.NewManager(snapshotDir, sm)
+	if err != nil {
+		sm.Close()
+		return nil, nil, fmt.Errorf("failed to create snapshot manager: %w", err)
+	}
+
+	return manager, sm, nil
+}
+
+func createSnapshot() error {
+	tags, err := parseSnapshotTags("--tag", snapshotTags)
+	if err != nil {
+		return err
+	}
+
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	snap, err := manager.CreateTaggedSnapshot(context.Background(), snapshotDesc, snapshot.TriggerManual, tags)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📸 Snapshot created: %s\n", snap.ID)
+	fmt.Printf("Clusters: %d\n", snap.Metadata.ClusterCount)
+	fmt.Printf("Node Pools: %d\n", snap.Metadata.NodePoolCount)
+
+	return nil
+}
+
+func listSnapshots() error {
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	snapshots, err := manager.ListSnapshots()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Snapshots:")
+	fmt.Printf("%-40s  %-19s  %-16s  %-8s  %-5s  %s\n", "ID", "Created", "Trigger", "Clusters", "Pools", "Size")
+	for _, info := range snapshots {
+		fmt.Printf("%-40s  %-19s  %-16s  %-8d  %-5d  %s\n",
+			info.ID,
+			info.CreatedAt.Format("2006-01-02 15:04:05"),
+			info.TriggerReason,
+			info.ClusterCount,
+			info.NodePoolCount,
+			format.Bytes(info.SizeBytes),
+		)
+	}
+
+	return nil
+}
+
+func restoreSnapshot(ctx context.Context, snapshotID string) error {
+	var selectors []snapshot.ResourceSelector
+	for _, raw := range restoreOnly {
+		selector, err := snapshot.ParseResourceSelector(raw)
+		if err != nil {
+			return err
+		}
+		selectors = append(selectors, selector)
+	}
+
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	// A restore overwrites state, so it must not interleave with an apply,
+	// refresh or delete writing it
+	if !restoreDryRun {
+		if err := sm.Lock(ctx); err != nil {
+			return err
+		}
+		defer sm.Unlock(ctx)
+	}
+
+	// Catch interrupts so the restore can finish writing and verifying state
+	// once its backup exists, instead of being killed halfway
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := manager.RestoreSnapshot(ctx, snapshotID, restoreDryRun, selectors...)
+	if result != nil {
+		fmt.Print(snapshot.FormatRestoreResult(result))
+	}
+	return err
+}
+
+func scheduleSnapshots(ctx context.Context) error {
+	if scheduleKeep < 0 || scheduleMaxAge < 0 {
+		return fmt.Errorf("--keep and --max-age cannot be negative")
+	}
+	if schedulePruneJobs < 1 {
+		return fmt.Errorf("--prune-concurrency must be at least 1")
+	}
+
+	keepTags, err := parseSnapshotTags("--keep-tag", scheduleKeepTags)
+	if err != nil {
+		return err
+	}
+	retention := snapshot.RetentionPolicy{MaxAge: scheduleMaxAge, MaxCount: scheduleKeep, KeepTags: keepTags, Concurrency: schedulePruneJobs}
+	for _, name := range scheduleKeepReasons {
+		reason, err := snapshot.ParseTriggerReason(name)
+		if err != nil {
+			return fmt.Errorf("invalid --keep-reason: %w", err)
+		}
+		retention.KeepReasons = append(retention.KeepReasons, reason)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	fmt.Printf("📸 Taking a snapshot every %s (Ctrl-C to stop)\n", scheduleInterval)
+
+	err = snapshot.NewScheduler(manager, scheduleInterval, retention, loggerFrom(ctx)).Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func verifySnapshots(snapshotIDs []string) error {
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		return err
+	}
+	defer sm.Close()
+
+	results, err := manager.VerifySnapshots(snapshotIDs...)
+	if err != nil {
+		return err
+	}
+	if failed := writeVerifyResults(os.Stdout, results); failed > 0 {
+		return fmt.Errorf("%d of %d snapshot(s) failed verification", failed, len(results))
+	}
+	return nil
+}
+
+// writeVerifyResults reports the integrity of each snapshot with a summary,
+// returning how many failed
+func writeVerifyResults(out io.Writer, results []snapshot.VerifyResult) int {
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No snapshots to verify.")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tDETAIL")
+	counts := make(map[snapshot.VerifyStatus]int)
+	for _, result := range results {
+		counts[result.Status]++
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.ID, strings.ToUpper(string(result.Status)), result.Detail)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d snapshot(s): %d ok, %d corrupt, %d unreadable, %d legacy\n", len(results),
+		counts[snapshot.VerifyOK], counts[snapshot.VerifyCorrupt], counts[snapshot.VerifyUnreadable], counts[snapshot.VerifyLegacy])
+	return len(results) - counts[snapshot.VerifyOK]
+}
+
+// parseSnapshotTags parses the key=value tags given with flag
+func parseSnapshotTags(flag string, values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+	for _, tag := range values {
+		key, value, found := strings.Cut(tag, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid %s %q: want key=value", flag, tag)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
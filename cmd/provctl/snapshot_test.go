@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/snapshot"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestWriteVerifyResults(t *testing.T) {
+	results := []snapshot.VerifyResult{
+		{ID: "snapshot-a", Status: snapshot.VerifyOK},
+		{ID: "snapshot-b", Status: snapshot.VerifyCorrupt, Detail: "checksum mismatch: recorded 1f, computed 2e"},
+		{ID: "snapshot-c", Status: snapshot.VerifyUnreadable, Detail: "failed to unmarshal snapshot"},
+		{ID: "snapshot-d", Status: snapshot.VerifyLegacy, Detail: "length checksum"},
+	}
+
+	var out bytes.Buffer
+	if failed := writeVerifyResults(&out, results); failed != 3 {
+		t.Errorf("writeVerifyResults() failed = %d, want 3", failed)
+	}
+	for _, line := range []string{
+		"snapshot-a  OK",
+		"snapshot-b  CORRUPT     checksum mismatch: recorded 1f, computed 2e",
+		"snapshot-c  UNREADABLE  failed to unmarshal snapshot",
+		"snapshot-d  LEGACY      length checksum",
+		"4 snapshot(s): 1 ok, 1 corrupt, 1 unreadable, 1 legacy",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestRestoreSnapshot_HoldsStateLock(t *testing.T) {
+	defer func(path, dir string, dryRun bool) { statePath, snapshotDir, restoreDryRun = path, dir, dryRun }(statePath, snapshotDir, restoreDryRun)
+	statePath = filepath.Join(t.TempDir(), "state.db")
+	snapshotDir = t.TempDir()
+	ctx := context.Background()
+
+	manager, sm, err := openSnapshotManager()
+	if err != nil {
+		t.Fatalf("openSnapshotManager() error = %v", err)
+	}
+	defer sm.Close()
+	snap, err := manager.CreateSnapshot(ctx, "before", snapshot.TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	// Another run, such as an apply, holds the lock
+	if err := sm.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	var lockErr *state.LockError
+	restoreDryRun = false
+	if err := restoreSnapshot(ctx, snap.ID); !errors.As(err, &lockErr) {
+		t.Errorf("restoreSnapshot() error = %v, want a LockError while the state is locked", err)
+	}
+
+	// A dry run writes nothing, so it does not wait for the lock
+	restoreDryRun = true
+	if err := restoreSnapshot(ctx, snap.ID); err != nil {
+		t.Errorf("restoreSnapshot() dry run error = %v", err)
+	}
+
+	if err := sm.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	restoreDryRun = false
+	if err := restoreSnapshot(ctx, snap.ID); err != nil {
+		t.Errorf("restoreSnapshot() error = %v once the lock is released", err)
+	}
+}
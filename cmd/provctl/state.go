@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var (
+	stateRmAutoApprove    bool
+	statePruneAutoApprove bool
+)
+
+func stateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "state",
+		Short: "Inspect and edit stored state",
+	}
+
+	cmd.AddCommand(stateRmCmd())
+	cmd.AddCommand(statePruneOrphansCmd())
+
+	return cmd
+}
+
+func stateRmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm <kind>/<name-or-id>",
+		Short: "Stop tracking a resource without deleting it",
+		Long: `Remove a cluster or node pool from stored state, leaving the cloud
+untouched, e.g. after it was deleted out of band. Removing a cluster also
+removes the node pools recorded as belonging to it:
+
+  provctl state rm cluster/staging
+  provctl state rm nodepool/np-0123`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeFromState(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&stateRmAutoApprove, "auto-approve", false, "skip interactive confirmation (required when stdin is not a terminal)")
+
+	return cmd
+}
+
+// parseResourceAddress parses a <kind>/<name-or-id> address
+func parseResourceAddress(address string) (kind, ref string, err error) {
+	kind, ref, ok := strings.Cut(address, "/")
+	if !ok || ref == "" {
+		return "", "", fmt.Errorf("invalid resource %q: expected cluster/<name> or nodepool/<name>", address)
+	}
+
+	switch strings.ToLower(kind) {
+	case "cluster":
+		return "Cluster", ref, nil
+	case "nodepool":
+		return "NodePool", ref, nil
+	default:
+		return "", "", fmt.Errorf("invalid resource %q: unknown kind %q (want cluster or nodepool)", address, kind)
+	}
+}
+
+func removeFromState(ctx context.Context, address string) error {
+	kind, ref, err := parseResourceAddress(address)
+	if err != nil {
+		return err
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	forgotten, err := engine.ForgetResource(&current, kind, ref)
+	if err != nil {
+		return err
+	}
+
+	printForgotten(os.Stdout, forgotten)
+	if !stateRmAutoApprove {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to edit state without --auto-approve: stdin is not a terminal")
+		}
+		fmt.Println()
+		if !confirm(os.Stdin, os.Stdout, "Do you want to stop tracking these resources?") {
+			fmt.Println("State unchanged.")
+			return nil
+		}
+	}
+
+	if err := sm.SaveState(ctx, current); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	fmt.Printf("\nRemoved %d resource(s) from state. Cloud resources were not modified.\n", len(forgotten))
+	return nil
+}
+
+// printForgotten lists the resources a state removal stops tracking
+func printForgotten(out io.Writer, forgotten []api.ResourceID) {
+	fmt.Fprintf(out, "The following %d resource(s) will be removed from state only:\n", len(forgotten))
+	for _, resource := range forgotten {
+		fmt.Fprintf(out, "  - %s %s (%s)\n", resource.Kind, resource.Name, resource.ID)
+	}
+}
+
+func statePruneOrphansCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune-orphans",
+		Short: "Remove node pools whose cluster is no longer in state",
+		Long: `Remove node pools left in stored state after their cluster was removed.
+Nothing reconciles such pools. Only state is edited: if the pools still
+exist in the cloud, delete them there or import them again.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return pruneOrphans(cmd.Context())
+		},
+	}
+
+	cmd.Flags().BoolVar(&statePruneAutoApprove, "auto-approve", false, "skip interactive confirmation (required when stdin is not a terminal)")
+
+	return cmd
+}
+
+func pruneOrphans(ctx context.Context) error {
+	sm, err := state.NewSQLiteStateManager(statePath, lockOptions()...)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	if err := sm.Lock(ctx); err != nil {
+		return err
+	}
+	defer sm.Unlock(ctx)
+
+	orphans, err := sm.FindOrphanedNodePools(ctx)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned node pools in state.")
+		return nil
+	}
+
+	printOrphans(os.Stdout, orphans)
+	if !statePruneAutoApprove {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("refusing to edit state without --auto-approve: stdin is not a terminal")
+		}
+		fmt.Println()
+		if !confirm(os.Stdin, os.Stdout, "Do you want to remove these node pools from state?") {
+			fmt.Println("State unchanged.")
+			return nil
+		}
+	}
+
+	ids := make([]string, len(orphans))
+	for i, pool := range orphans {
+		ids[i] = pool.ID
+	}
+	removed, err := sm.DeleteOrphanedNodePools(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nRemoved %d node pool(s) from state. Cloud resources were not modified.\n", removed)
+	return nil
+}
+
+// printOrphans lists orphaned node pools with the cluster they belonged to
+func printOrphans(out io.Writer, orphans []*api.NodePool) {
+	fmt.Fprintf(out, "The following %d node pool(s) belong to clusters no longer in state:\n", len(orphans))
+	for _, pool := range orphans {
+		cluster := pool.Status.Properties[api.PropertyClusterID]
+		if name := pool.Status.Properties[api.PropertyClusterName]; name != "" {
+			cluster = name + ", " + cluster
+		}
+		fmt.Fprintf(out, "  - NodePool %s (%s) of cluster %s\n", pool.Metadata.Name, pool.ID, cluster)
+	}
+}
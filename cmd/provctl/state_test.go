@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestParseResourceAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		wantKind string
+		wantRef  string
+		wantErr  bool
+	}{
+		{"cluster/prod", "Cluster", "prod", false},
+		{"NodePool/np-0123", "NodePool", "np-0123", false},
+		{"cluster/", "", "", true},
+		{"prod", "", "", true},
+		{"network/vpc-1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		kind, ref, err := parseResourceAddress(tt.address)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseResourceAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			continue
+		}
+		if kind != tt.wantKind || ref != tt.wantRef {
+			t.Errorf("parseResourceAddress(%q) = %s, %s, want %s, %s", tt.address, kind, ref, tt.wantKind, tt.wantRef)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/drift"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/format"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var statusOutput string
+
+func statusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show every cluster's desired against actual status",
+		Long: `Show, for every cluster in state, the Kubernetes version provctl applied
+against the version the provider reports, the cluster's phase, how many
+drifted fields a drift scan finds and when provctl last reconciled it.
+
+Nothing is changed. Unlike watch, which follows one cluster until it is
+running, status is a single view of all clusters, for example to keep open
+during a change window:
+
+  watch -n 30 provctl status
+  provctl status -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showStatus(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&statusOutput, "output", "o", "text", "output format (text or json)")
+
+	return cmd
+}
+
+// ClusterStatus is one cluster's desired and actual status
+type ClusterStatus struct {
+	Name           string    `json:"name"`
+	ID             string    `json:"id"`
+	Provider       string    `json:"provider"`
+	Region         string    `json:"region"`
+	DesiredVersion string    `json:"desiredVersion"`
+	ActualVersion  string    `json:"actualVersion,omitempty"` // Empty when the provider has no such cluster
+	Phase          api.Phase `json:"phase,omitempty"`
+	Found          bool      `json:"found"`  // The provider reports the cluster
+	Drifts         int       `json:"drifts"` // Drifted fields of the cluster and its node pools
+	LastReconciled time.Time `json:"lastReconciled"`
+	Error          string    `json:"error,omitempty"` // Why the actual status is unknown
+}
+
+// StatusReport combines state, a drift scan and the providers' view of
+// every cluster
+type StatusReport struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Clusters    []ClusterStatus `json:"clusters"`
+}
+
+func showStatus(ctx context.Context) error {
+	if statusOutput != "text" && statusOutput != "json" {
+		return fmt.Errorf("invalid --output %q: want text or json", statusOutput)
+	}
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	eng := engine.NewEngine(sm, nil)
+	if err := registerProviders(ctx, eng, sm.Events(), current.Clusters); err != nil {
+		return err
+	}
+
+	report, err := buildStatusReport(ctx, eng, current, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if statusOutput == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	writeStatusReport(os.Stdout, report)
+	return nil
+}
+
+// buildStatusReport reports every cluster of current, sorted by name. A
+// cluster whose provider cannot be reached is reported with the error
+// rather than failing the report.
+func buildStatusReport(ctx context.Context, eng *engine.Engine, current engine.State, now time.Time) (*StatusReport, error) {
+	scan, err := drift.NewDriftDetector(eng, loggerFrom(ctx)).DetectDrift(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	drifts := make(map[string]int)
+	for _, d := range scan.Drifts {
+		clusterID, _, _ := strings.Cut(d.Resource.ID, "/") // Node pool drift is keyed <cluster>/<pool>
+		drifts[clusterID]++
+	}
+
+	report := &StatusReport{GeneratedAt: now, Clusters: []ClusterStatus{}}
+	for id, cluster := range current.Clusters {
+		status := ClusterStatus{
+			Name:           cluster.Metadata.Name,
+			ID:             id,
+			Provider:       cluster.Spec.Provider,
+			Region:         cluster.Spec.Region,
+			DesiredVersion: cluster.Spec.ControlPlane.Version,
+			Drifts:         drifts[id],
+			LastReconciled: cluster.Metadata.UpdatedAt,
+		}
+
+		provider, err := eng.LoadRegionalProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region)
+		var actual *api.Cluster
+		if err == nil {
+			actual, err = provider.GetCluster(ctx, id)
+		}
+		switch {
+		case err != nil:
+			status.Error = err.Error()
+		case actual != nil:
+			status.Found = true
+			status.ActualVersion = actual.Spec.ControlPlane.Version
+			status.Phase = actual.Status.Phase
+		}
+		report.Clusters = append(report.Clusters, status)
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool { return report.Clusters[i].Name < report.Clusters[j].Name })
+
+	return report, nil
+}
+
+func writeStatusReport(out io.Writer, report *StatusReport) {
+	if len(report.Clusters) == 0 {
+		fmt.Fprintln(out, "No clusters in state.")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPROVIDER\tDESIRED\tACTUAL\tPHASE\tDRIFT\tLAST RECONCILED")
+	drifted, failed := 0, 0
+	for _, c := range report.Clusters {
+		actual, phase := c.ActualVersion, string(c.Phase)
+		switch {
+		case c.Error != "":
+			actual, phase = "?", "Unknown"
+			failed++
+		case !c.Found:
+			actual, phase = "-", "NotFound"
+		}
+		if c.Drifts > 0 {
+			drifted++
+		}
+		reconciled := "-"
+		if !c.LastReconciled.IsZero() {
+			reconciled = fmt.Sprintf("%s (%s ago)", c.LastReconciled.Format("2006-01-02 15:04"),
+				format.Duration(report.GeneratedAt.Sub(c.LastReconciled)))
+		}
+		fmt.Fprintf(w, "%s\t%s/%s\t%s\t%s\t%s\t%d\t%s\n",
+			c.Name, c.Provider, c.Region, c.DesiredVersion, actual, phase, c.Drifts, reconciled)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d cluster(s), %d with drift\n", len(report.Clusters), drifted)
+	if failed > 0 {
+		fmt.Fprintln(out, "\nStatus unknown:")
+		for _, c := range report.Clusters {
+			if c.Error != "" {
+				fmt.Fprintf(out, "  %s: %s\n", c.Name, c.Error)
+			}
+		}
+	}
+}
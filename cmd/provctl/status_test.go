@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+func TestBuildStatusReport(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cluster := func(id, name, provider, version string) *api.Cluster {
+		return &api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: name, UpdatedAt: now.Add(-3 * time.Hour)},
+			Spec: api.ClusterSpec{
+				Provider:     provider,
+				Region:       "region-1",
+				ControlPlane: api.ControlPlaneSpec{Version: version},
+				WorkerPools:  []api.WorkerPoolSpec{{Name: "general", DesiredSize: 3}},
+			},
+		}
+	}
+	prod := cluster("c-prod", "prod", "aws", "1.29")
+	staging := cluster("c-staging", "staging", "aws", "1.29")
+	eu := cluster("c-eu", "eu", "azure", "1.28")
+	current := engine.State{Clusters: map[string]*api.Cluster{prod.ID: prod, staging.ID: staging, eu.ID: eu}}
+
+	// prod runs an older version with a scaled down pool; staging is gone
+	actual := *prod
+	actual.Spec.ControlPlane.Version = "1.28"
+	actual.Spec.WorkerPools = []api.WorkerPoolSpec{{Name: "general", DesiredSize: 2}}
+	actual.Status.Phase = api.PhaseRunning
+	aws := fake.NewProvider("aws")
+	aws.SeedCluster(&actual)
+	azure := fake.NewProvider("azure")
+	azure.FailOn("GetCluster", 0, errors.New("credentials expired"))
+
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(aws)
+	eng.RegisterProvider(azure)
+
+	report, err := buildStatusReport(context.Background(), eng, current, now)
+	if err != nil {
+		t.Fatalf("buildStatusReport() error = %v", err)
+	}
+
+	want := []ClusterStatus{
+		{Name: "eu", ID: "c-eu", Provider: "azure", Region: "region-1", DesiredVersion: "1.28", Error: "credentials expired"},
+		{Name: "prod", ID: "c-prod", Provider: "aws", Region: "region-1", DesiredVersion: "1.29", ActualVersion: "1.28", Phase: api.PhaseRunning, Found: true, Drifts: 2},
+		{Name: "staging", ID: "c-staging", Provider: "aws", Region: "region-1", DesiredVersion: "1.29", Drifts: 1},
+	}
+	if len(report.Clusters) != len(want) {
+		t.Fatalf("buildStatusReport() = %+v, want %d clusters", report.Clusters, len(want))
+	}
+	for i, got := range report.Clusters {
+		want[i].LastReconciled = now.Add(-3 * time.Hour)
+		if got != want[i] {
+			t.Errorf("Clusters[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if n := aws.CallCount("UpdateCluster") + aws.CallCount("CreateCluster"); n != 0 {
+		t.Errorf("status changed clusters %d times, want none", n)
+	}
+
+	var out bytes.Buffer
+	writeStatusReport(&out, report)
+	for _, line := range []string{
+		"prod     aws/region-1    1.29     1.28    Running   2      2024-03-01 09:00 (3h ago)",
+		"staging  aws/region-1    1.29     -       NotFound  1",
+		"eu       azure/region-1  1.28     ?       Unknown   0",
+		"3 cluster(s), 2 with drift",
+		"  eu: credentials expired",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, out.String())
+		}
+	}
+}
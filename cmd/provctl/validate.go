@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/config"
+	"github.com/vjranagit/cluster-api/pkg/cost"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/validation"
+)
+
+var (
+	validateStrict     bool
+	overrideGuardrails string
+)
+
+func validateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [config-path]",
+		Short: "Check an HCL configuration for errors and risky settings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := loadConfig(args[0])
+			if err != nil {
+				return err
+			}
+			if err := validateConfig(os.Stdout, file); err != nil {
+				return err
+			}
+			if _, err := checkGuardrails(cmd.Context(), os.Stdout, file); err != nil {
+				return err
+			}
+			fmt.Println("Configuration is valid.")
+			return nil
+		},
+	}
+
+	addStrictFlag(cmd)
+	addConfigFlags(cmd)
+
+	return cmd
+}
+
+// addStrictFlag registers --strict for commands that validate configuration
+func addStrictFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&validateStrict, "strict", false, "treat validation warnings as errors")
+}
+
+// validateConfig validates every cluster in a configuration, printing
+// warnings to out. It returns the errors of all clusters joined.
+func validateConfig(out io.Writer, file *config.File) error {
+	validator := validation.NewValidator()
+	validator.SetStrict(validateStrict)
+	validator.SetRequiredTags(file.DefaultTags.Required)
+	if err := validator.SetNamingRules(file.Naming); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	var failed []error
+	for _, block := range file.Clusters {
+		result := validator.Validate(block.Spec.WithName(block.Name).WithDefaultTags(file.DefaultTags.Tags))
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(out, "Warning: cluster %s: %s\n", block.Name, warning)
+		}
+		if err := result.Err(); err != nil {
+			failed = append(failed, fmt.Errorf("cluster %s: %w", block.Name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(failed...))
+	}
+	return nil
+}
+
+// guardrailViolation lists the guardrails one cluster exceeds
+type guardrailViolation struct {
+	Cluster string
+	Issues  []validation.Issue
+}
+
+// checkGuardrails checks every cluster against the configuration's
+// guardrails. Violations are errors unless --override-guardrails gives a
+// reason, in which case they are printed as warnings and returned so that the
+// override can be recorded.
+func checkGuardrails(ctx context.Context, out io.Writer, file *config.File) ([]guardrailViolation, error) {
+	guardrails := file.Guardrails
+	if !guardrails.Enabled() {
+		return nil, nil
+	}
+
+	estimator := cost.NewEstimator()
+
+	var violations []guardrailViolation
+	var failed []error
+	for _, block := range file.Clusters {
+		monthlyCost := 0.0
+		if guardrails.MaxMonthlyCost > 0 {
+			estimate, err := estimator.EstimateCost(ctx, block.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("cluster %s: failed to estimate cost for guardrails: %w", block.Name, err)
+			}
+			monthlyCost = estimate.TotalMonthlyCost
+		}
+
+		result := guardrails.Check(block.Spec, monthlyCost)
+		if !result.HasErrors() {
+			continue
+		}
+		violations = append(violations, guardrailViolation{Cluster: block.Name, Issues: result.Errors})
+
+		if overrideGuardrails == "" {
+			failed = append(failed, fmt.Errorf("cluster %s: %w", block.Name, result.Err()))
+			continue
+		}
+		for _, issue := range result.Errors {
+			fmt.Fprintf(out, "Warning: cluster %s: guardrail overridden: %s\n", block.Name, issue)
+		}
+	}
+
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("guardrails exceeded (apply with --override-guardrails <reason> to proceed anyway): %w", errors.Join(failed...))
+	}
+	return violations, nil
+}
+
+// recordGuardrailOverrides records an audit event, with the override reason,
+// for each cluster applied despite exceeding its guardrails
+func recordGuardrailOverrides(ctx context.Context, events engine.EventStore, desired engine.State, violations []guardrailViolation) error {
+	logger := loggerFrom(ctx)
+
+	for _, violation := range violations {
+		resource := api.ResourceID{Kind: "Cluster", Name: violation.Cluster}
+		for id, cluster := range desired.Clusters {
+			if cluster.Metadata.Name == violation.Cluster {
+				resource.ID = id
+				resource.Provider = cluster.Spec.Provider
+				break
+			}
+		}
+
+		override := api.GuardrailOverride{Reason: overrideGuardrails}
+		for _, issue := range violation.Issues {
+			override.Violations = append(override.Violations, issue.String())
+		}
+
+		logger.WarnContext(ctx, "guardrails overridden", "cluster", violation.Cluster, "reason", override.Reason, "violations", override.Violations)
+		event := api.Event{
+			Type:          api.EventGuardrailOverridden,
+			Resource:      resource,
+			Actor:         currentUser(),
+			Payload:       override,
+			CorrelationID: engine.CorrelationIDFrom(ctx),
+		}
+		if err := events.RecordEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record guardrail override: %w", err)
+		}
+	}
+	return nil
+}
+
+// currentUser names the operator for audit events, or is empty if unknown
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/config"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+	"github.com/vjranagit/cluster-api/pkg/validation"
+)
+
+func TestValidateConfig(t *testing.T) {
+	file := &config.File{Clusters: []config.ClusterBlock{{
+		Name: "prod",
+		Spec: api.ClusterSpec{
+			Provider:    "aws",
+			Network:     api.NetworkSpec{AvailabilityZones: []string{"us-west-2a"}},
+			WorkerPools: []api.WorkerPoolSpec{{Name: "general", MinSize: 2, MaxSize: 4}},
+		},
+	}}}
+
+	defer func(strict bool) { validateStrict = strict }(validateStrict)
+
+	validateStrict = false
+	var out bytes.Buffer
+	if err := validateConfig(&out, file); err != nil {
+		t.Fatalf("validateConfig() error = %v, want warnings only", err)
+	}
+	if !strings.Contains(out.String(), "Warning: cluster prod: workerPools.general.minSize") {
+		t.Errorf("validateConfig() output = %q, want a placement warning", out.String())
+	}
+
+	validateStrict = true
+	out.Reset()
+	if err := validateConfig(&out, file); err == nil {
+		t.Error("validateConfig() error = nil, want an error with --strict")
+	}
+}
+
+func guardrailConfig(guardrails validation.Guardrails) *config.File {
+	return &config.File{
+		Guardrails: guardrails,
+		Clusters: []config.ClusterBlock{{
+			Name: "prod",
+			Spec: api.ClusterSpec{
+				Provider:     "aws",
+				Region:       "us-east-1",
+				ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.29"},
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 1000, DesiredSize: 3},
+				},
+			},
+		}},
+	}
+}
+
+func TestCheckGuardrails(t *testing.T) {
+	defer func(reason string) { overrideGuardrails = reason }(overrideGuardrails)
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		guardrails validation.Guardrails
+		wantErr    string
+	}{
+		{name: "no guardrails"},
+		{name: "within limits", guardrails: validation.Guardrails{MaxTotalNodes: 1000, MaxPoolNodes: 1000, MaxMonthlyCost: 1e6}},
+		{name: "pool limit", guardrails: validation.Guardrails{MaxPoolNodes: 100}, wantErr: "workerPools.general.maxSize"},
+		{name: "total limit", guardrails: validation.Guardrails{MaxTotalNodes: 500}, wantErr: "1000 nodes in total"},
+		{name: "cost limit", guardrails: validation.Guardrails{MaxMonthlyCost: 10}, wantErr: "exceeds guardrail of $10.00/month"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrideGuardrails = ""
+			var out bytes.Buffer
+			violations, err := checkGuardrails(ctx, &out, guardrailConfig(tt.guardrails))
+			if tt.wantErr == "" {
+				if err != nil || len(violations) != 0 {
+					t.Fatalf("checkGuardrails() = %v, %v, want no violations", violations, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "cluster prod") {
+				t.Fatalf("checkGuardrails() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+
+			// An override reason turns the violation into a warning
+			overrideGuardrails = "capacity test"
+			out.Reset()
+			violations, err = checkGuardrails(ctx, &out, guardrailConfig(tt.guardrails))
+			if err != nil {
+				t.Fatalf("checkGuardrails() with override error = %v", err)
+			}
+			if len(violations) != 1 || violations[0].Cluster != "prod" {
+				t.Errorf("violations = %+v, want one for prod", violations)
+			}
+			if !strings.Contains(out.String(), "guardrail overridden") {
+				t.Errorf("output = %q, want an override warning", out.String())
+			}
+		})
+	}
+}
+
+func TestRecordGuardrailOverrides(t *testing.T) {
+	defer func(reason string) { overrideGuardrails = reason }(overrideGuardrails)
+	overrideGuardrails = "load test for launch"
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	ctx := context.Background()
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}},
+	}}
+	violations := []guardrailViolation{{
+		Cluster: "prod",
+		Issues:  []validation.Issue{{Field: "workerPools", Message: "too many nodes"}},
+	}}
+
+	if err := recordGuardrailOverrides(ctx, sm.Events(), desired, violations); err != nil {
+		t.Fatalf("recordGuardrailOverrides() error = %v", err)
+	}
+
+	resource := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-1", Name: "prod"}
+	events, err := sm.Events().GetEvents(ctx, resource)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != api.EventGuardrailOverridden {
+		t.Fatalf("events = %+v, want one GuardrailOverridden event", events)
+	}
+
+	want := map[string]interface{}{
+		"reason":     "load test for launch",
+		"violations": []interface{}{"workerPools: too many nodes"},
+	}
+	if !reflect.DeepEqual(events[0].Payload, want) {
+		t.Errorf("payload = %#v, want %#v", events[0].Payload, want)
+	}
+}
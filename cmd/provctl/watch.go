@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/format"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var watchInterval time.Duration
+
+// errClusterFailed is returned when a watched cluster enters the Failed phase
+var errClusterFailed = errors.New("cluster failed")
+
+func watchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch <cluster>",
+		Short: "Follow a cluster's status until it is running",
+		Long: `Poll the cloud provider and show the cluster phase, conditions and node
+pool status until the cluster is Running. Exits non-zero if the cluster enters
+the Failed phase or the watch is interrupted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return watchCluster(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Second, "time between status checks")
+
+	return cmd
+}
+
+func watchCluster(ctx context.Context, name string) error {
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	sm, err := state.NewSQLiteStateManager(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to create state manager: %w", err)
+	}
+	defer sm.Close()
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	var cluster *api.Cluster
+	for _, c := range current.Clusters {
+		if c.Metadata.Name == name {
+			cluster = c
+			break
+		}
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found in state", name)
+	}
+
+	cloudProvider, err := newProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region, nil)
+	if err != nil {
+		return err
+	}
+
+	err = pollCluster(ctx, cloudProvider, cluster.ID, watchInterval, os.Stdout, isTerminal(os.Stdout))
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("watch interrupted")
+	}
+	return err
+}
+
+// pollCluster renders the cluster status every interval until it is Running,
+// returning errClusterFailed if it fails. With clear set each render
+// replaces the previous one, as on a terminal.
+func pollCluster(ctx context.Context, provider engine.CloudProvider, clusterID string, interval time.Duration, out io.Writer, clear bool) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cluster, err := provider.GetCluster(ctx, clusterID)
+		if err != nil {
+			return fmt.Errorf("failed to get cluster: %w", err)
+		}
+		if cluster == nil {
+			return fmt.Errorf("cluster %s not found at provider %s", clusterID, provider.Name())
+		}
+
+		pools, err := provider.ListNodePools(ctx, clusterID)
+		if err != nil {
+			return fmt.Errorf("failed to list node pools: %w", err)
+		}
+
+		if clear {
+			fmt.Fprint(out, "\033[H\033[2J")
+		}
+		fmt.Fprint(out, formatWatchStatus(cluster, pools, time.Now()))
+
+		switch cluster.Status.Phase {
+		case api.PhaseRunning:
+			return nil
+		case api.PhaseFailed:
+			if cluster.Status.Message != "" {
+				return fmt.Errorf("%w: %s", errClusterFailed, cluster.Status.Message)
+			}
+			return errClusterFailed
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// formatWatchStatus renders one snapshot of a cluster's status
+func formatWatchStatus(cluster *api.Cluster, pools []*api.NodePool, now time.Time) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Cluster %s (%s) - %s/%s\n", cluster.Metadata.Name, cluster.ID, cluster.Spec.Provider, cluster.Spec.Region)
+	fmt.Fprintf(&b, "Phase: %s (checked %s)\n", cluster.Status.Phase, now.Format("15:04:05"))
+	if cluster.Status.Message != "" {
+		fmt.Fprintf(&b, "Message: %s\n", cluster.Status.Message)
+	}
+
+	if len(cluster.Status.Conditions) > 0 {
+		b.WriteString("\nConditions:\n")
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tLAST TRANSITION\tREASON")
+		for _, condition := range cluster.Status.Conditions {
+			transition := "-"
+			if !condition.LastTransitionTime.IsZero() {
+				transition = fmt.Sprintf("%s (%s ago)",
+					condition.LastTransitionTime.Format("2006-01-02 15:04:05"),
+					format.Duration(now.Sub(condition.LastTransitionTime)))
+			}
+			reason := condition.Reason
+			if condition.Message != "" {
+				reason = strings.TrimSpace(reason + " " + condition.Message)
+			}
+			fmt.Fprintf(w, "  %s\t%t\t%s\t%s\n", condition.Type, condition.Status, transition, reason)
+		}
+		w.Flush()
+	}
+
+	if len(pools) > 0 {
+		b.WriteString("\nNode pools:\n")
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tPHASE\tDESIRED\tMIN/MAX")
+		for _, pool := range pools {
+			fmt.Fprintf(w, "  %s\t%s\t%d\t%d/%d\n", pool.Metadata.Name, pool.Status.Phase,
+				pool.Spec.DesiredSize, pool.Spec.MinSize, pool.Spec.MaxSize)
+		}
+		w.Flush()
+	}
+
+	return b.String()
+}
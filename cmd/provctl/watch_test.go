@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+// phasedProvider reports the next phase from phases on each GetCluster call
+type phasedProvider struct {
+	*fake.Provider
+	phases []api.Phase
+}
+
+func (p *phasedProvider) GetCluster(ctx context.Context, clusterID string) (*api.Cluster, error) {
+	cluster, err := p.Provider.GetCluster(ctx, clusterID)
+	if err != nil || cluster == nil {
+		return cluster, err
+	}
+	if len(p.phases) > 0 {
+		cluster.Status.Phase = p.phases[0]
+		p.phases = p.phases[1:]
+	}
+	return cluster, nil
+}
+
+func newPhasedProvider(phases ...api.Phase) *phasedProvider {
+	provider := fake.NewProvider("fake")
+	provider.SeedCluster(&api.Cluster{
+		ID:       "c-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Provider: "fake", Region: "local"},
+		Status: api.ResourceStatus{
+			Message: "control plane unavailable",
+			Conditions: []api.Condition{{
+				Type:               "ControlPlaneReady",
+				Reason:             "Creating",
+				LastTransitionTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			}},
+		},
+	})
+	provider.SeedNodePool("c-1", &api.NodePool{
+		ID:       "np-1",
+		Metadata: api.ResourceMetadata{Name: "workers"},
+		Spec:     api.WorkerPoolSpec{Name: "workers", MinSize: 1, MaxSize: 5, DesiredSize: 3},
+		Status:   api.ResourceStatus{Phase: api.PhaseProvisioning},
+	})
+	return &phasedProvider{Provider: provider, phases: phases}
+}
+
+func TestPollCluster(t *testing.T) {
+	t.Run("waits until running", func(t *testing.T) {
+		provider := newPhasedProvider(api.PhaseProvisioning, api.PhaseProvisioning, api.PhaseRunning)
+
+		var out bytes.Buffer
+		if err := pollCluster(context.Background(), provider, "c-1", time.Millisecond, &out, false); err != nil {
+			t.Fatalf("pollCluster() error = %v", err)
+		}
+		if got := provider.CallCount("GetCluster"); got != 3 {
+			t.Errorf("GetCluster called %d times, want 3", got)
+		}
+		for _, want := range []string{"Phase: Running", "ControlPlaneReady", "2024-01-02 03:04:05", "workers", "Provisioning"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("output missing %q:\n%s", want, out.String())
+			}
+		}
+		if strings.Contains(out.String(), "\033[") {
+			t.Errorf("output contains escape codes without clear:\n%q", out.String())
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		provider := newPhasedProvider(api.PhaseProvisioning, api.PhaseFailed)
+
+		var out bytes.Buffer
+		err := pollCluster(context.Background(), provider, "c-1", time.Millisecond, &out, false)
+		if !errors.Is(err, errClusterFailed) {
+			t.Fatalf("pollCluster() error = %v, want %v", err, errClusterFailed)
+		}
+		if !strings.Contains(err.Error(), "control plane unavailable") {
+			t.Errorf("error %q does not include the status message", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		provider := newPhasedProvider()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		var out bytes.Buffer
+		err := pollCluster(ctx, provider, "c-1", time.Millisecond, &out, false)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("pollCluster() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("cluster missing", func(t *testing.T) {
+		provider := newPhasedProvider()
+
+		var out bytes.Buffer
+		if err := pollCluster(context.Background(), provider, "c-missing", time.Millisecond, &out, false); err == nil {
+			t.Fatal("pollCluster() error = nil, want error for missing cluster")
+		}
+	})
+}
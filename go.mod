@@ -5,8 +5,11 @@ go 1.21
 require (
 	github.com/aws/aws-sdk-go-v2 v1.24.0
 	github.com/aws/aws-sdk-go-v2/config v1.26.0
+	github.com/aws/aws-sdk-go-v2/credentials v1.16.11
 	github.com/aws/aws-sdk-go-v2/service/ec2 v1.141.0
 	github.com/aws/aws-sdk-go-v2/service/eks v1.35.0
+	github.com/aws/aws-sdk-go-v2/service/sts v1.26.4
+	github.com/aws/smithy-go v1.20.0
 	github.com/aws/aws-sdk-go-v2/service/iam v1.28.0
 	github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing v1.22.0
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.9.0
@@ -15,7 +18,10 @@ require (
 	github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4 v4.7.0
 	github.com/google/uuid v1.5.0
 	github.com/hashicorp/hcl/v2 v2.19.1
+	github.com/zclconf/go-cty v1.13.0
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/sync v0.5.0
+	golang.org/x/time v0.5.0
 	modernc.org/sqlite v1.28.0
 	go.etcd.io/etcd/client/v3 v3.5.11
 	go.opentelemetry.io/otel v1.21.0
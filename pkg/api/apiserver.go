@@ -0,0 +1,34 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// APIServerFlags returns the kube-apiserver flags a self-managed control
+// plane is bootstrapped with: one --feature-gates flag for all feature gates,
+// then the API server arguments, each sorted so the result is stable
+func (c ControlPlaneSpec) APIServerFlags() []string {
+	var flags []string
+
+	if len(c.FeatureGates) > 0 {
+		gates := make([]string, 0, len(c.FeatureGates))
+		for gate, enabled := range c.FeatureGates {
+			gates = append(gates, gate+"="+strconv.FormatBool(enabled))
+		}
+		sort.Strings(gates)
+		flags = append(flags, "--feature-gates="+strings.Join(gates, ","))
+	}
+
+	names := make([]string, 0, len(c.APIServerArgs))
+	for name := range c.APIServerArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flags = append(flags, "--"+name+"="+c.APIServerArgs[name])
+	}
+
+	return flags
+}
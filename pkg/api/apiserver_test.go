@@ -0,0 +1,28 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestControlPlaneSpec_APIServerFlags(t *testing.T) {
+	if flags := (ControlPlaneSpec{}).APIServerFlags(); len(flags) != 0 {
+		t.Errorf("APIServerFlags() = %v, want none", flags)
+	}
+
+	spec := ControlPlaneSpec{
+		FeatureGates: map[string]bool{"WatchList": true, "AnyVolumeDataSource": false},
+		APIServerArgs: map[string]string{
+			"max-requests-inflight": "800",
+			"audit-log-maxage":      "30",
+		},
+	}
+	want := []string{
+		"--feature-gates=AnyVolumeDataSource=false,WatchList=true",
+		"--audit-log-maxage=30",
+		"--max-requests-inflight=800",
+	}
+	if got := spec.APIServerFlags(); !slices.Equal(got, want) {
+		t.Errorf("APIServerFlags() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldChange describes a single field that differs between two specs
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// String renders the change as "path: old → new"
+func (c FieldChange) String() string {
+	return c.Path + ": " + FormatValue(c.Old) + " → " + FormatValue(c.New)
+}
+
+// FormatValue renders a spec value from a FieldChange for display. Whole
+// structs, such as a worker pool only one side has, are summarized.
+func FormatValue(value interface{}) string {
+	if value == nil {
+		return "(not set)"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Struct:
+		return "(defined)"
+	case reflect.Slice, reflect.Map:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// Equal reports whether two cluster specs are semantically equal
+func (s ClusterSpec) Equal(other ClusterSpec) bool {
+	return len(s.Diff(other)) == 0
+}
+
+// Diff returns the field-level changes needed to go from s to other.
+// Paths use the JSON field names joined with dots (e.g. "controlPlane.version").
+// Slices of named elements such as worker pools are matched by name
+// ("workerPools.gpu.desiredSize"), and all other slices are compared as
+// unordered sets, so reordering availability zones or taints is not a change.
+func (s ClusterSpec) Diff(other ClusterSpec) []FieldChange {
+	var changes []FieldChange
+	diffValues("", reflect.ValueOf(s), reflect.ValueOf(other), false, &changes)
+	return changes
+}
+
+// Equal reports whether two worker pool specs are semantically equal
+func (p WorkerPoolSpec) Equal(other WorkerPoolSpec) bool {
+	return len(p.Diff(other)) == 0
+}
+
+// Diff returns the field-level changes needed to go from p to other
+func (p WorkerPoolSpec) Diff(other WorkerPoolSpec) []FieldChange {
+	var changes []FieldChange
+	diffValues("", reflect.ValueOf(p), reflect.ValueOf(other), false, &changes)
+	return changes
+}
+
+// PoolChange classifies how a worker pool change can be applied
+type PoolChange string
+
+const (
+	PoolChangeNone        PoolChange = "None"
+	PoolChangeInPlace     PoolChange = "InPlace"     // Applied to the running nodes
+	PoolChangeReplacement PoolChange = "Replacement" // Nodes must be replaced
+)
+
+// inPlacePoolFields are the worker pool fields providers can change without
+// replacing nodes: Kubernetes labels and taints, and the scaling bounds
+var inPlacePoolFields = map[string]bool{
+	"labels":      true,
+	"taints":      true,
+	"minSize":     true,
+	"maxSize":     true,
+	"desiredSize": true,
+}
+
+// ClassifyChange reports how going from p to other can be applied. Changes
+// confined to labels, taints and scaling are made in place; any other
+// difference, such as a new instance type or image, requires replacement.
+func (p WorkerPoolSpec) ClassifyChange(other WorkerPoolSpec) PoolChange {
+	changes := p.Diff(other)
+	if len(changes) == 0 {
+		return PoolChangeNone
+	}
+
+	for _, change := range changes {
+		field, _, _ := strings.Cut(change.Path, ".")
+		if !inPlacePoolFields[field] {
+			return PoolChangeReplacement
+		}
+	}
+	return PoolChangeInPlace
+}
+
+// diffValues appends the changes from old to new. Below a sensitive field,
+// changed values are redacted, so a change shows without its values.
+func diffValues(path string, old, new reflect.Value, sensitive bool, changes *[]FieldChange) {
+	switch old.Kind() {
+	case reflect.Struct:
+		t := old.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			diffValues(joinPath(path, name), old.Field(i), new.Field(i), sensitive || isSensitive(field), changes)
+		}
+
+	case reflect.Ptr:
+		switch {
+		case old.IsNil() && new.IsNil():
+		case old.IsNil() || new.IsNil():
+			*changes = append(*changes, fieldChange(path, valueOrNil(old), valueOrNil(new), sensitive))
+		default:
+			diffValues(path, old.Elem(), new.Elem(), sensitive, changes)
+		}
+
+	case reflect.Map:
+		for _, key := range mapKeys(old, new) {
+			oldVal := old.MapIndex(key)
+			newVal := new.MapIndex(key)
+			keyPath := joinPath(path, key.String())
+
+			if !oldVal.IsValid() || !newVal.IsValid() {
+				*changes = append(*changes, fieldChange(keyPath, valueOrNil(oldVal), valueOrNil(newVal), sensitive))
+				continue
+			}
+			diffValues(keyPath, oldVal, newVal, sensitive, changes)
+		}
+
+	case reflect.Slice:
+		if hasNameField(old.Type().Elem()) {
+			diffNamedSlice(path, old, new, sensitive, changes)
+			return
+		}
+		if !sameElements(old, new) {
+			*changes = append(*changes, fieldChange(path, old.Interface(), new.Interface(), sensitive))
+		}
+
+	default:
+		if !reflect.DeepEqual(valueOrNil(old), valueOrNil(new)) {
+			*changes = append(*changes, fieldChange(path, valueOrNil(old), valueOrNil(new), sensitive))
+		}
+	}
+}
+
+// fieldChange returns the change of a field with its values redacted if the
+// field is sensitive, or the sensitive fields of values such as a whole new
+// struct redacted otherwise
+func fieldChange(path string, old, new interface{}, sensitive bool) FieldChange {
+	return FieldChange{Path: path, Old: redactInterface(old, sensitive), New: redactInterface(new, sensitive)}
+}
+
+func redactInterface(value interface{}, sensitive bool) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(value), sensitive).Interface()
+}
+
+// diffNamedSlice matches elements by their Name field and diffs them pairwise
+func diffNamedSlice(path string, old, new reflect.Value, sensitive bool, changes *[]FieldChange) {
+	oldByName := indexByName(old)
+	newByName := indexByName(new)
+
+	names := make([]string, 0, len(oldByName)+len(newByName))
+	for name := range oldByName {
+		names = append(names, name)
+	}
+	for name := range newByName {
+		if _, ok := oldByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldElem, inOld := oldByName[name]
+		newElem, inNew := newByName[name]
+		elemPath := joinPath(path, name)
+
+		switch {
+		case inOld && inNew:
+			diffValues(elemPath, oldElem, newElem, sensitive, changes)
+		case inOld:
+			*changes = append(*changes, fieldChange(elemPath, oldElem.Interface(), nil, sensitive))
+		default:
+			*changes = append(*changes, fieldChange(elemPath, nil, newElem.Interface(), sensitive))
+		}
+	}
+}
+
+func indexByName(v reflect.Value) map[string]reflect.Value {
+	index := make(map[string]reflect.Value, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		index[elem.FieldByName("Name").String()] = elem
+	}
+	return index
+}
+
+// sameElements compares two slices as unordered multisets
+func sameElements(a, b reflect.Value) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	matched := make([]bool, b.Len())
+	for i := 0; i < a.Len(); i++ {
+		found := false
+		for j := 0; j < b.Len(); j++ {
+			if !matched[j] && reflect.DeepEqual(a.Index(i).Interface(), b.Index(j).Interface()) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func mapKeys(a, b reflect.Value) []reflect.Value {
+	seen := make(map[string]bool)
+	var keys []reflect.Value
+	for _, m := range []reflect.Value{a, b} {
+		for _, key := range m.MapKeys() {
+			if !seen[key.String()] {
+				seen[key.String()] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+func hasNameField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := t.FieldByName("Name")
+	return ok && field.Type.Kind() == reflect.String
+}
+
+func jsonName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return "" // unexported
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func valueOrNil(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"testing"
+)
+
+func baseSpec() ClusterSpec {
+	return ClusterSpec{
+		Provider: "aws",
+		Region:   "us-west-2",
+		Network: NetworkSpec{
+			VPCCIDR:           "10.0.0.0/16",
+			AvailabilityZones: []string{"us-west-2a", "us-west-2b", "us-west-2c"},
+			Subnets: []Subnet{
+				{Name: "private-a", CIDR: "10.0.1.0/24", AvailabilityZone: "us-west-2a"},
+				{Name: "private-b", CIDR: "10.0.2.0/24", AvailabilityZone: "us-west-2b"},
+			},
+		},
+		ControlPlane: ControlPlaneSpec{
+			Type:    ControlPlaneManaged,
+			Version: "1.28",
+			Identity: &IdentitySpec{
+				Type:            "oidc",
+				ServiceAccounts: []string{"kube-system/a", "kube-system/b"},
+			},
+		},
+		WorkerPools: []WorkerPoolSpec{
+			{
+				Name:         "general",
+				InstanceType: "t3.medium",
+				MinSize:      1,
+				MaxSize:      5,
+				DesiredSize:  3,
+				Labels:       map[string]string{"workload": "general"},
+			},
+			{
+				Name:         "gpu",
+				InstanceType: "p3.2xlarge",
+				MinSize:      0,
+				MaxSize:      2,
+				Taints: []Taint{
+					{Key: "gpu", Value: "true", Effect: "NoSchedule"},
+					{Key: "dedicated", Value: "ml", Effect: "NoExecute"},
+				},
+			},
+		},
+		Tags: map[string]string{"Environment": "production", "Team": "platform"},
+	}
+}
+
+func TestClusterSpec_Equal(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *ClusterSpec)
+		want   bool
+	}{
+		{
+			name:   "identical",
+			modify: func(s *ClusterSpec) {},
+			want:   true,
+		},
+		{
+			name: "availability zones reordered",
+			modify: func(s *ClusterSpec) {
+				s.Network.AvailabilityZones = []string{"us-west-2c", "us-west-2a", "us-west-2b"}
+			},
+			want: true,
+		},
+		{
+			name: "worker pools reordered",
+			modify: func(s *ClusterSpec) {
+				s.WorkerPools[0], s.WorkerPools[1] = s.WorkerPools[1], s.WorkerPools[0]
+			},
+			want: true,
+		},
+		{
+			name: "taints reordered",
+			modify: func(s *ClusterSpec) {
+				taints := s.WorkerPools[1].Taints
+				s.WorkerPools[1].Taints = []Taint{taints[1], taints[0]}
+			},
+			want: true,
+		},
+		{
+			name: "tags removed",
+			modify: func(s *ClusterSpec) {
+				s.Tags = nil
+			},
+			want: false,
+		},
+		{
+			name: "version changed",
+			modify: func(s *ClusterSpec) {
+				s.ControlPlane.Version = "1.29"
+			},
+			want: false,
+		},
+		{
+			name: "availability zone removed",
+			modify: func(s *ClusterSpec) {
+				s.Network.AvailabilityZones = s.Network.AvailabilityZones[:2]
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := baseSpec()
+			b := baseSpec()
+			tt.modify(&b)
+
+			if got := a.Equal(b); got != tt.want {
+				t.Errorf("Equal() = %v, want %v (diff: %v)", got, tt.want, a.Diff(b))
+			}
+			if got := b.Equal(a); got != tt.want {
+				t.Errorf("Equal() not symmetric: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterSpec_EqualEmptyCollections(t *testing.T) {
+	a := ClusterSpec{Tags: nil, WorkerPools: nil}
+	b := ClusterSpec{Tags: map[string]string{}, WorkerPools: []WorkerPoolSpec{}}
+
+	if !a.Equal(b) {
+		t.Errorf("Equal() should treat nil and empty collections as equal, diff: %v", a.Diff(b))
+	}
+}
+
+func TestClusterSpec_Diff(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *ClusterSpec)
+		want   []FieldChange
+	}{
+		{
+			name: "version change",
+			modify: func(s *ClusterSpec) {
+				s.ControlPlane.Version = "1.29"
+			},
+			want: []FieldChange{
+				{Path: "controlPlane.version", Old: "1.28", New: "1.29"},
+			},
+		},
+		{
+			name: "pool scaled",
+			modify: func(s *ClusterSpec) {
+				s.WorkerPools[0].DesiredSize = 4
+			},
+			want: []FieldChange{
+				{Path: "workerPools.general.desiredSize", Old: 3, New: 4},
+			},
+		},
+		{
+			name: "tag changed and added",
+			modify: func(s *ClusterSpec) {
+				s.Tags["Environment"] = "staging"
+				s.Tags["Owner"] = "alice"
+			},
+			want: []FieldChange{
+				{Path: "tags.Environment", Old: "production", New: "staging"},
+				{Path: "tags.Owner", Old: nil, New: "alice"},
+			},
+		},
+		{
+			name: "label removed",
+			modify: func(s *ClusterSpec) {
+				delete(s.WorkerPools[0].Labels, "workload")
+			},
+			want: []FieldChange{
+				{Path: "workerPools.general.labels.workload", Old: "general", New: nil},
+			},
+		},
+		{
+			name: "subnet cidr changed",
+			modify: func(s *ClusterSpec) {
+				s.Network.Subnets[1].CIDR = "10.0.20.0/24"
+			},
+			want: []FieldChange{
+				{Path: "network.subnets.private-b.cidr", Old: "10.0.2.0/24", New: "10.0.20.0/24"},
+			},
+		},
+		{
+			name: "identity removed",
+			modify: func(s *ClusterSpec) {
+				s.ControlPlane.Identity = nil
+			},
+			want: []FieldChange{
+				{Path: "controlPlane.identity"},
+			},
+		},
+		{
+			name: "spot enabled",
+			modify: func(s *ClusterSpec) {
+				s.WorkerPools[0].Spot = &SpotConfig{Enabled: true}
+			},
+			want: []FieldChange{
+				{Path: "workerPools.general.spot"},
+			},
+		},
+		{
+			name: "service accounts reordered",
+			modify: func(s *ClusterSpec) {
+				s.ControlPlane.Identity.ServiceAccounts = []string{"kube-system/b", "kube-system/a"}
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := baseSpec()
+			b := baseSpec()
+			tt.modify(&b)
+
+			got := a.Diff(b)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Diff() got %d changes, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for i, change := range got {
+				if change.Path != tt.want[i].Path {
+					t.Errorf("Diff()[%d].Path = %s, want %s", i, change.Path, tt.want[i].Path)
+				}
+				if tt.want[i].Old != nil && change.Old != tt.want[i].Old {
+					t.Errorf("Diff()[%d].Old = %v, want %v", i, change.Old, tt.want[i].Old)
+				}
+				if tt.want[i].New != nil && change.New != tt.want[i].New {
+					t.Errorf("Diff()[%d].New = %v, want %v", i, change.New, tt.want[i].New)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterSpec_DiffPoolAddedRemoved(t *testing.T) {
+	a := baseSpec()
+	b := baseSpec()
+	b.WorkerPools = append(b.WorkerPools[:1], WorkerPoolSpec{Name: "spot", InstanceType: "c5.xlarge"})
+
+	changes := a.Diff(b)
+	if len(changes) != 2 {
+		t.Fatalf("Diff() got %d changes, want 2: %v", len(changes), changes)
+	}
+
+	// Names are sorted, so "gpu" (removed) precedes "spot" (added)
+	if changes[0].Path != "workerPools.gpu" || changes[0].Old == nil || changes[0].New != nil {
+		t.Errorf("Diff()[0] = %+v, want removal of workerPools.gpu", changes[0])
+	}
+	if changes[1].Path != "workerPools.spot" || changes[1].Old != nil || changes[1].New == nil {
+		t.Errorf("Diff()[1] = %+v, want addition of workerPools.spot", changes[1])
+	}
+}
+
+func TestClusterSpec_DiffTaintChanged(t *testing.T) {
+	a := baseSpec()
+	b := baseSpec()
+	b.WorkerPools[1].Taints[0].Effect = "PreferNoSchedule"
+
+	changes := a.Diff(b)
+	if len(changes) != 1 || changes[0].Path != "workerPools.gpu.taints" {
+		t.Errorf("Diff() = %v, want single change to workerPools.gpu.taints", changes)
+	}
+}
+
+func TestClusterSpec_DiffConfig(t *testing.T) {
+	a := ClusterSpec{Config: map[string]interface{}{"name": "prod", "replicas": 3}}
+	b := ClusterSpec{Config: map[string]interface{}{"name": "prod", "replicas": 5}}
+
+	changes := a.Diff(b)
+	if len(changes) != 1 || changes[0].Path != "config.replicas" {
+		t.Errorf("Diff() = %v, want single change to config.replicas", changes)
+	}
+}
+
+func TestWorkerPoolSpec_Diff(t *testing.T) {
+	a := WorkerPoolSpec{Name: "general", InstanceType: "t3.medium", MaxSize: 5}
+	b := WorkerPoolSpec{Name: "general", InstanceType: "t3.large", MaxSize: 5}
+
+	if a.Equal(b) {
+		t.Error("Equal() should detect instance type change")
+	}
+
+	changes := a.Diff(b)
+	if len(changes) != 1 || changes[0].Path != "instanceType" {
+		t.Errorf("Diff() = %v, want single change to instanceType", changes)
+	}
+}
+
+func TestWorkerPoolSpec_ClassifyChange(t *testing.T) {
+	base := WorkerPoolSpec{
+		Name:         "general",
+		InstanceType: "t3.medium",
+		MinSize:      1,
+		MaxSize:      5,
+		Labels:       map[string]string{"team": "web"},
+		Taints:       []Taint{{Key: "dedicated", Value: "web", Effect: "NoSchedule"}},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*WorkerPoolSpec)
+		want   PoolChange
+	}{
+		{name: "unchanged", modify: func(*WorkerPoolSpec) {}, want: PoolChangeNone},
+		{
+			name:   "label added",
+			modify: func(s *WorkerPoolSpec) { s.Labels = map[string]string{"team": "web", "env": "prod"} },
+			want:   PoolChangeInPlace,
+		},
+		{
+			name:   "taints removed",
+			modify: func(s *WorkerPoolSpec) { s.Taints = nil },
+			want:   PoolChangeInPlace,
+		},
+		{
+			name:   "scaled",
+			modify: func(s *WorkerPoolSpec) { s.MaxSize = 10 },
+			want:   PoolChangeInPlace,
+		},
+		{
+			name:   "instance type",
+			modify: func(s *WorkerPoolSpec) { s.InstanceType = "t3.large" },
+			want:   PoolChangeReplacement,
+		},
+		{
+			name: "label and image",
+			modify: func(s *WorkerPoolSpec) {
+				s.Labels = map[string]string{"team": "api"}
+				s.ImageID = "ami-123"
+			},
+			want: PoolChangeReplacement,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := base
+			tt.modify(&desired)
+			if got := base.ClassifyChange(desired); got != tt.want {
+				t.Errorf("ClassifyChange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldChange_String(t *testing.T) {
+	tests := []struct {
+		change FieldChange
+		want   string
+	}{
+		{FieldChange{Path: "controlPlane.version", Old: "1.29", New: "1.28"}, "controlPlane.version: 1.29 → 1.28"},
+		{FieldChange{Path: "tags.team", New: "web"}, "tags.team: (not set) → web"},
+		{FieldChange{Path: "workerPools.gpu", Old: WorkerPoolSpec{Name: "gpu"}}, "workerPools.gpu: (defined) → (not set)"},
+		{FieldChange{Path: "network.availabilityZones", Old: []string{"a"}, New: []string{"a", "b"}}, `network.availabilityZones: ["a"] → ["a","b"]`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AnnotationIgnoreChanges holds the comma-separated field paths of a resource
+// that are managed outside provctl, such as a pool size adjusted by the
+// cluster autoscaler. Drift on those fields is not reported.
+const AnnotationIgnoreChanges = "provctl.io/ignore-changes"
+
+// IgnoreRules are field paths in the dotted convention of ClusterSpec.Diff,
+// e.g. "workerPools.gpu.desiredSize". A "*" segment matches any single
+// segment, and a rule also covers every field nested below it, so
+// "workerPools.*.labels" ignores all labels of all pools.
+type IgnoreRules []string
+
+// ParseIgnoreRules checks that every rule is a well-formed dotted path
+func ParseIgnoreRules(paths []string) (IgnoreRules, error) {
+	rules := make(IgnoreRules, 0, len(paths))
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" || strings.Contains(path, ",") || strings.Contains(path, "..") ||
+			strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+			return nil, fmt.Errorf("invalid ignore rule %q: expected a dotted field path", path)
+		}
+		rules = append(rules, path)
+	}
+	return rules, nil
+}
+
+// Matches reports whether any rule covers the field path
+func (r IgnoreRules) Matches(path string) bool {
+	segments := strings.Split(path, ".")
+	for _, rule := range r {
+		if matchSegments(strings.Split(rule, "."), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments reports whether rule equals path or one of its prefixes,
+// with "*" matching any segment
+func matchSegments(rule, path []string) bool {
+	if len(rule) > len(path) {
+		return false
+	}
+	for i, segment := range rule {
+		if segment != "*" && segment != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IgnoreChanges returns the ignore rules stored on the resource
+func (m ResourceMetadata) IgnoreChanges() IgnoreRules {
+	value := m.Annotations[AnnotationIgnoreChanges]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// SetIgnoreChanges stores ignore rules on the resource, removing the
+// annotation when there are none
+func (m *ResourceMetadata) SetIgnoreChanges(rules IgnoreRules) {
+	if len(rules) == 0 {
+		delete(m.Annotations, AnnotationIgnoreChanges)
+		return
+	}
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+	m.Annotations[AnnotationIgnoreChanges] = strings.Join(rules, ",")
+}
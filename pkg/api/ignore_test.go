@@ -0,0 +1,71 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreRules_Matches(t *testing.T) {
+	rules := IgnoreRules{"workerPools.gpu.desiredSize", "workerPools.*.labels", "tags"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "workerPools.gpu.desiredSize", want: true},
+		{path: "workerPools.general.desiredSize", want: false},
+		{path: "workerPools.gpu.maxSize", want: false},
+		{path: "workerPools.general.labels.team", want: true},
+		{path: "workerPools.general.labels", want: true},
+		{path: "workerPools.general", want: false},
+		{path: "tags.Environment", want: true},
+		{path: "tagsExtra", want: false},
+		{path: "controlPlane.version", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rules.Matches(tt.path); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if (IgnoreRules{}).Matches("controlPlane.version") {
+		t.Error("empty rules matched a path")
+	}
+}
+
+func TestParseIgnoreRules(t *testing.T) {
+	rules, err := ParseIgnoreRules([]string{" workerPools.gpu.desiredSize ", "tags"})
+	if err != nil {
+		t.Fatalf("ParseIgnoreRules() error = %v", err)
+	}
+	if want := (IgnoreRules{"workerPools.gpu.desiredSize", "tags"}); !reflect.DeepEqual(rules, want) {
+		t.Errorf("ParseIgnoreRules() = %v, want %v", rules, want)
+	}
+
+	for _, path := range []string{"", "workerPools..desiredSize", ".tags", "tags.", "tags,labels"} {
+		if _, err := ParseIgnoreRules([]string{path}); err == nil {
+			t.Errorf("ParseIgnoreRules(%q) succeeded, want error", path)
+		}
+	}
+}
+
+func TestResourceMetadata_IgnoreChanges(t *testing.T) {
+	var metadata ResourceMetadata
+	if rules := metadata.IgnoreChanges(); rules != nil {
+		t.Errorf("IgnoreChanges() = %v, want none", rules)
+	}
+
+	want := IgnoreRules{"workerPools.gpu.desiredSize", "tags"}
+	metadata.SetIgnoreChanges(want)
+	if got := metadata.IgnoreChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IgnoreChanges() = %v, want %v", got, want)
+	}
+
+	metadata.SetIgnoreChanges(nil)
+	if _, ok := metadata.Annotations[AnnotationIgnoreChanges]; ok {
+		t.Error("SetIgnoreChanges(nil) kept the annotation")
+	}
+}
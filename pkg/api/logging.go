@@ -0,0 +1,66 @@
+package api
+
+// Control-plane log types, named as in the EKS logging configuration
+const (
+	LogTypeAPI               = "api"
+	LogTypeAudit             = "audit"
+	LogTypeAuthenticator     = "authenticator"
+	LogTypeControllerManager = "controllerManager"
+	LogTypeScheduler         = "scheduler"
+	LogTypeClusterAutoscaler = "clusterAutoscaler" // AKS only
+)
+
+// DefaultLogTypes are collected when logging is configured without types
+var DefaultLogTypes = []string{LogTypeAPI, LogTypeAudit}
+
+// Capabilities describe the optional features a provider supports
+type Capabilities struct {
+	LogTypes                 []string // Control-plane log types that can be collected
+	SpotAllocationStrategies []string // Spot allocation strategies scale groups accept
+}
+
+// providerCapabilities holds the capabilities of each built-in provider
+var providerCapabilities = map[string]Capabilities{
+	"aws": {
+		LogTypes:                 []string{LogTypeAPI, LogTypeAudit, LogTypeAuthenticator, LogTypeControllerManager, LogTypeScheduler},
+		SpotAllocationStrategies: []string{SpotLowestPrice, SpotCapacityOptimized, SpotPriceCapacityOptimized},
+	},
+	"azure": {
+		LogTypes: []string{LogTypeAPI, LogTypeAudit, LogTypeAuthenticator, LogTypeControllerManager, LogTypeScheduler,
+			LogTypeClusterAutoscaler},
+		SpotAllocationStrategies: []string{SpotLowestPrice, SpotCapacityOptimized},
+	},
+}
+
+// ProviderCapabilities returns the capabilities of a provider, or false for
+// a provider whose capabilities are not known
+func ProviderCapabilities(provider string) (Capabilities, bool) {
+	capabilities, ok := providerCapabilities[provider]
+	return capabilities, ok
+}
+
+// LogsEnabled reports whether control-plane logs are collected
+func (o *ObservabilitySpec) LogsEnabled() bool {
+	return o != nil && (o.ControlPlaneLogs || o.Logging != nil)
+}
+
+// LogTypes returns the control-plane log types to collect: the configured
+// types, DefaultLogTypes when none are configured, or nil when logs are off
+func (o *ObservabilitySpec) LogTypes() []string {
+	if !o.LogsEnabled() {
+		return nil
+	}
+	if o.Logging != nil && len(o.Logging.Types) > 0 {
+		return o.Logging.Types
+	}
+	return DefaultLogTypes
+}
+
+// LogDestination returns where control-plane logs are sent, or "" for the
+// provider's default
+func (o *ObservabilitySpec) LogDestination() string {
+	if o == nil || o.Logging == nil {
+		return ""
+	}
+	return o.Logging.Destination
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObservabilitySpec_LogTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		obs  *ObservabilitySpec
+		want []string
+	}{
+		{name: "no observability"},
+		{name: "logs off", obs: &ObservabilitySpec{Metrics: true}},
+		{name: "logs on", obs: &ObservabilitySpec{ControlPlaneLogs: true}, want: DefaultLogTypes},
+		{name: "logging block without types", obs: &ObservabilitySpec{Logging: &LoggingSpec{Destination: "clusters"}}, want: DefaultLogTypes},
+		{
+			name: "configured types",
+			obs:  &ObservabilitySpec{Logging: &LoggingSpec{Types: []string{LogTypeScheduler}}},
+			want: []string{LogTypeScheduler},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.obs.LogTypes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LogTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderCapabilities(t *testing.T) {
+	aws, ok := ProviderCapabilities("aws")
+	if !ok || len(aws.LogTypes) != 5 {
+		t.Errorf("ProviderCapabilities(aws) = %+v, %v", aws, ok)
+	}
+	if _, ok := ProviderCapabilities("fake"); ok {
+		t.Error("ProviderCapabilities(fake) reported capabilities for an unknown provider")
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+// EndpointAccess returns the API endpoint access of the network. Without an
+// explicit APIServerAccess block, a private cluster has a private endpoint
+// only and any other cluster a public one.
+func (n NetworkSpec) EndpointAccess() APIServerAccessSpec {
+	if n.APIServerAccess != nil {
+		return *n.APIServerAccess
+	}
+	return APIServerAccessSpec{PublicAccess: !n.PrivateCluster, PrivateAccess: n.PrivateCluster}
+}
+
+// ExistingNetworkID returns the ID of the VPC or VNet the cluster is
+// provisioned into, or "" when provctl creates the network from VPCCIDR
+func (n NetworkSpec) ExistingNetworkID() string {
+	if n.ExistingVPCID != "" {
+		return n.ExistingVPCID
+	}
+	return n.ExistingVNetID
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNetworkSpec_EndpointAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		network NetworkSpec
+		want    APIServerAccessSpec
+	}{
+		{
+			name:    "public by default",
+			network: NetworkSpec{},
+			want:    APIServerAccessSpec{PublicAccess: true},
+		},
+		{
+			name:    "private cluster",
+			network: NetworkSpec{PrivateCluster: true},
+			want:    APIServerAccessSpec{PrivateAccess: true},
+		},
+		{
+			name: "explicit access overrides private cluster",
+			network: NetworkSpec{
+				PrivateCluster: true,
+				APIServerAccess: &APIServerAccessSpec{
+					PublicAccess:    true,
+					PrivateAccess:   true,
+					AuthorizedCIDRs: []string{"203.0.113.0/24"},
+				},
+			},
+			want: APIServerAccessSpec{PublicAccess: true, PrivateAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.network.EndpointAccess(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EndpointAccess() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import "slices"
+
+// AllInstanceTypes returns the instance types the pool may launch: its
+// primary InstanceType followed by any distinct InstanceTypes alternatives.
+func (p WorkerPoolSpec) AllInstanceTypes() []string {
+	types := []string{p.InstanceType}
+	for _, instanceType := range p.InstanceTypes {
+		if instanceType == "" || slices.Contains(types, instanceType) {
+			continue
+		}
+		types = append(types, instanceType)
+	}
+	return types
+}
+
+// NodeGroupName names the cloud group running a pool's nodes: the launch
+// template and Auto Scaling group on AWS and the scale set on Azure
+func NodeGroupName(clusterID, poolName string) string {
+	return clusterID + "-" + poolName
+}
+
+// Diversified reports whether the pool spreads its nodes over more than one
+// instance type
+func (p WorkerPoolSpec) Diversified() bool {
+	return len(p.AllInstanceTypes()) > 1
+}
+
+// Weighted reports whether the pool weighs its instance types. The sizes of
+// a weighted pool count capacity units rather than instances.
+func (p WorkerPoolSpec) Weighted() bool {
+	return len(p.InstanceWeights) > 0
+}
+
+// InstanceWeight returns how many capacity units an instance of a type
+// counts as: its weight in InstanceWeights, or 1, so that a larger instance
+// can stand in for several smaller ones
+func (p WorkerPoolSpec) InstanceWeight(instanceType string) int {
+	if weight := p.InstanceWeights[instanceType]; weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+// ResolvePools returns the worker pools with PoolDefaults and SpotDefaults
+// applied, leaving the spec unchanged. A pool inherits every default it
+// leaves unset, and default labels are merged under the pool's own. set
+// reports whether a pool sets one of the instanceType, minSize, maxSize or
+// desiredSize fields, so that a pool can override a default with zero; when
+// set is nil a zero value counts as unset.
+func (s ClusterSpec) ResolvePools(set func(pool, field string) bool) []WorkerPoolSpec {
+	if (s.PoolDefaults == nil && s.SpotDefaults == nil) || s.WorkerPools == nil {
+		return s.WorkerPools
+	}
+	defaults := s.PoolDefaults
+	if defaults == nil {
+		defaults = &PoolDefaults{}
+	}
+	if set == nil {
+		set = func(string, string) bool { return false }
+	}
+
+	pools := make([]WorkerPoolSpec, len(s.WorkerPools))
+	for i, pool := range s.WorkerPools {
+		if defaults.InstanceType != "" && pool.InstanceType == "" && !set(pool.Name, "instanceType") {
+			pool.InstanceType = defaults.InstanceType
+		}
+		inheritSize(&pool.MinSize, defaults.MinSize, set(pool.Name, "minSize"))
+		inheritSize(&pool.MaxSize, defaults.MaxSize, set(pool.Name, "maxSize"))
+		inheritSize(&pool.DesiredSize, defaults.DesiredSize, set(pool.Name, "desiredSize"))
+
+		if pool.Spot == nil && defaults.Spot != nil {
+			spot := *defaults.Spot
+			pool.Spot = &spot
+		}
+		if pool.WarmPool == nil && defaults.WarmPool != nil {
+			warm := *defaults.WarmPool
+			pool.WarmPool = &warm
+		}
+		if len(defaults.Labels) > 0 {
+			pool.Labels = MergeTags(defaults.Labels, pool.Labels)
+		}
+		if pool.Spot != nil && pool.Spot.AllocationStrategy == "" && s.SpotDefaults != nil {
+			spot := *pool.Spot
+			spot.AllocationStrategy = s.SpotDefaults.AllocationStrategy
+			pool.Spot = &spot
+		}
+		pools[i] = pool
+	}
+	return pools
+}
+
+// inheritSize sets size to the default unless the pool sets it itself
+func inheritSize(size *int, def *int, set bool) {
+	if def != nil && *size == 0 && !set {
+		*size = *def
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkerPoolSpec_AllInstanceTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		pool WorkerPoolSpec
+		want []string
+	}{
+		{
+			name: "single type",
+			pool: WorkerPoolSpec{InstanceType: "m5.large"},
+			want: []string{"m5.large"},
+		},
+		{
+			name: "alternatives follow the primary type",
+			pool: WorkerPoolSpec{InstanceType: "m5.large", InstanceTypes: []string{"m5a.large", "m4.large"}},
+			want: []string{"m5.large", "m5a.large", "m4.large"},
+		},
+		{
+			name: "duplicates and blanks are dropped",
+			pool: WorkerPoolSpec{InstanceType: "m5.large", InstanceTypes: []string{"m5.large", "", "m5a.large", "m5a.large"}},
+			want: []string{"m5.large", "m5a.large"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.pool.AllInstanceTypes()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllInstanceTypes() = %v, want %v", got, tt.want)
+			}
+			if diversified := len(tt.want) > 1; tt.pool.Diversified() != diversified {
+				t.Errorf("Diversified() = %v, want %v", tt.pool.Diversified(), diversified)
+			}
+		})
+	}
+}
+
+func TestClusterSpec_ResolvePools(t *testing.T) {
+	size := func(n int) *int { return &n }
+	defaults := &PoolDefaults{
+		InstanceType: "m5.large",
+		MinSize:      size(1),
+		MaxSize:      size(10),
+		Spot:         &SpotConfig{Enabled: true},
+		Labels:       map[string]string{"team": "platform", "tier": "general"},
+	}
+
+	tests := []struct {
+		name string
+		pool WorkerPoolSpec
+		set  map[string]bool
+		want WorkerPoolSpec
+	}{
+		{
+			name: "inherit",
+			pool: WorkerPoolSpec{Name: "general"},
+			want: WorkerPoolSpec{
+				Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 10,
+				Spot:   &SpotConfig{Enabled: true},
+				Labels: map[string]string{"team": "platform", "tier": "general"},
+			},
+		},
+		{
+			name: "partial override",
+			pool: WorkerPoolSpec{Name: "batch", MaxSize: 30, Labels: map[string]string{"tier": "batch"}},
+			set:  map[string]bool{"minSize": true},
+			want: WorkerPoolSpec{
+				Name: "batch", InstanceType: "m5.large", MinSize: 0, MaxSize: 30,
+				Spot:   &SpotConfig{Enabled: true},
+				Labels: map[string]string{"team": "platform", "tier": "batch"},
+			},
+		},
+		{
+			name: "full override",
+			pool: WorkerPoolSpec{
+				Name: "gpu", InstanceType: "p3.2xlarge", MinSize: 2, MaxSize: 4,
+				Spot:   &SpotConfig{Enabled: false},
+				Labels: map[string]string{"team": "ml", "tier": "gpu"},
+			},
+			want: WorkerPoolSpec{
+				Name: "gpu", InstanceType: "p3.2xlarge", MinSize: 2, MaxSize: 4,
+				Spot:   &SpotConfig{Enabled: false},
+				Labels: map[string]string{"team": "ml", "tier": "gpu"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := ClusterSpec{WorkerPools: []WorkerPoolSpec{tt.pool}, PoolDefaults: defaults}
+			pools := spec.ResolvePools(func(pool, field string) bool { return tt.set[field] })
+			if !reflect.DeepEqual(pools[0], tt.want) {
+				t.Errorf("ResolvePools() = %+v, want %+v", pools[0], tt.want)
+			}
+			if !reflect.DeepEqual(spec.WorkerPools[0], tt.pool) {
+				t.Errorf("ResolvePools() modified the spec's pool: %+v", spec.WorkerPools[0])
+			}
+		})
+	}
+}
+
+func TestClusterSpec_ResolvePoolsWithoutDefaults(t *testing.T) {
+	spec := ClusterSpec{WorkerPools: []WorkerPoolSpec{{Name: "general", InstanceType: "m5.large", MaxSize: 3}}}
+	if pools := spec.ResolvePools(nil); !reflect.DeepEqual(pools, spec.WorkerPools) {
+		t.Errorf("ResolvePools() = %+v, want pools unchanged", pools)
+	}
+}
+
+func TestClusterSpec_ResolvePoolsSpotDefaults(t *testing.T) {
+	spec := ClusterSpec{
+		SpotDefaults: &SpotDefaults{AllocationStrategy: SpotCapacityOptimized},
+		WorkerPools: []WorkerPoolSpec{
+			{Name: "batch", Spot: &SpotConfig{Enabled: true}},
+			{Name: "ml", Spot: &SpotConfig{Enabled: true, AllocationStrategy: SpotLowestPrice}},
+			{Name: "general"},
+		},
+	}
+
+	pools := spec.ResolvePools(nil)
+	if got := pools[0].Spot.AllocationStrategy; got != SpotCapacityOptimized {
+		t.Errorf("batch AllocationStrategy = %q, want the cluster default", got)
+	}
+	if got := pools[1].Spot.AllocationStrategy; got != SpotLowestPrice {
+		t.Errorf("ml AllocationStrategy = %q, want its own", got)
+	}
+	if pools[2].Spot != nil {
+		t.Errorf("general Spot = %+v, want an on-demand pool left alone", pools[2].Spot)
+	}
+	if spec.WorkerPools[0].Spot.AllocationStrategy != "" {
+		t.Error("ResolvePools() modified the spec's spot config")
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+// Property keys providers set in ResourceStatus.Properties once a cluster is
+// created. Downstream tooling reads them with `provctl output`.
+const (
+	PropertyEndpoint         = "endpoint"           // Kubernetes API server URL
+	PropertyOIDCIssuer       = "oidc_issuer"        // OIDC issuer URL for workload identity
+	PropertyVPCID            = "vpc_id"             // VPC or VNet the cluster runs in
+	PropertySecurityGroupIDs = "security_group_ids" // Comma-separated security group IDs
+	PropertyResourceGroup    = "resource_group"     // Azure resource group holding the cluster, also set on its node pools
+)
+
+// PropertyClusterName is set on node pools to the name of the owning
+// cluster, which provider APIs need to address the pool
+const PropertyClusterName = "cluster_name"
+
+// PropertyClusterID is set on node pools to the ID of the owning cluster,
+// linking the pool to it in state
+const PropertyClusterID = "cluster_id"
+
+// SetProperty sets a status property, ignoring empty values
+func (s *ResourceStatus) SetProperty(key, value string) {
+	if value == "" {
+		return
+	}
+	if s.Properties == nil {
+		s.Properties = make(map[string]string)
+	}
+	s.Properties[key] = value
+}
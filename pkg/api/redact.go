@@ -0,0 +1,219 @@
+package api
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// Redacted replaces sensitive values in logs, snapshots and other dumps
+const Redacted = "***"
+
+// Fields tagged sensitive:"true" hold values such as role ARNs or provider
+// configuration that must not appear in logs or human-readable dumps. The
+// tag applies to everything nested in the field.
+
+// Redact returns a deep copy of v with its sensitive fields redacted:
+// strings read Redacted, maps and slices keep their keys and length with
+// each value redacted, and other values are zeroed. Empty strings stay
+// empty, so an unset field still reads as unset. v is not modified.
+func Redact[T any](v T) T {
+	redacted := reflect.New(reflect.TypeOf(&v).Elem()).Elem()
+	redacted.Set(redactValue(reflect.ValueOf(&v).Elem(), false))
+	return redacted.Interface().(T)
+}
+
+// DeepCopy returns a copy of v sharing no pointers, maps or slices with it,
+// so either can be modified without affecting the other. Sensitive fields
+// are copied as they are.
+func DeepCopy[T any](v T) T {
+	copied := reflect.New(reflect.TypeOf(&v).Elem()).Elem()
+	copied.Set(copyValue(reflect.ValueOf(&v).Elem(), false, func(reflect.StructField) bool { return false }))
+	return copied.Interface().(T)
+}
+
+func redactValue(v reflect.Value, sensitive bool) reflect.Value {
+	return copyValue(v, sensitive, isSensitive)
+}
+
+// copyValue deep copies v, redacting it if sensitive and redacting every
+// field within it that redactField reports sensitive
+func copyValue(v reflect.Value, sensitive bool, redactField func(reflect.StructField) bool) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(copyValue(v.Elem(), sensitive, redactField))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(copyValue(v.Elem(), sensitive, redactField))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			copied.Field(i).Set(copyValue(v.Field(i), sensitive || redactField(field), redactField))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), copyValue(iter.Value(), sensitive, redactField))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(copyValue(v.Index(i), sensitive, redactField))
+		}
+		return copied
+	case reflect.String:
+		if sensitive && v.Len() > 0 {
+			return reflect.ValueOf(Redacted).Convert(v.Type())
+		}
+		return v
+	}
+
+	if sensitive {
+		return reflect.Zero(v.Type())
+	}
+	return v
+}
+
+// Unredact returns a copy of redacted, a value Redact produced, with its
+// redacted fields taken from original, such as the current state a redacted
+// snapshot is restored over. Redacted fields original cannot supply, like
+// those of a resource original lacks, are zeroed rather than left as
+// Redacted.
+func Unredact[T any](redacted, original T) T {
+	restored := reflect.New(reflect.TypeOf(&redacted).Elem()).Elem()
+	restored.Set(reflect.ValueOf(&redacted).Elem())
+	unredactValue(restored, reflect.ValueOf(&original).Elem())
+	return restored.Interface().(T)
+}
+
+// unredactValue restores the sensitive fields of dst, which must be
+// settable, from original, which is invalid when there is nothing to
+// restore from. Containers are copied before they are changed, so the
+// value Redact produced is left as it was.
+func unredactValue(dst, original reflect.Value) {
+	if original.IsValid() && original.Type() != dst.Type() {
+		original = reflect.Value{}
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			return
+		}
+		copied := reflect.New(dst.Type().Elem())
+		copied.Elem().Set(dst.Elem())
+		unredactValue(copied.Elem(), indirect(original))
+		dst.Set(copied)
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			var originalField reflect.Value
+			if original.IsValid() {
+				originalField = original.Field(i)
+			}
+			switch {
+			case !isSensitive(field):
+				unredactValue(dst.Field(i), originalField)
+			case !isRedacted(dst.Field(i)):
+				// Unset, or never redacted, as in snapshots taken before
+				// sensitive fields were
+			case originalField.IsValid():
+				dst.Field(i).Set(originalField)
+			default:
+				dst.Field(i).Set(reflect.Zero(field.Type))
+			}
+		}
+	case reflect.Map:
+		if dst.IsNil() {
+			return
+		}
+		copied := reflect.MakeMapWithSize(dst.Type(), dst.Len())
+		iter := dst.MapRange()
+		for iter.Next() {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			var originalElem reflect.Value
+			if original.IsValid() && !original.IsNil() {
+				originalElem = original.MapIndex(iter.Key())
+			}
+			unredactValue(elem, originalElem)
+			copied.SetMapIndex(iter.Key(), elem)
+		}
+		dst.Set(copied)
+	case reflect.Slice:
+		if dst.IsNil() {
+			return
+		}
+		copied := reflect.MakeSlice(dst.Type(), dst.Len(), dst.Len())
+		reflect.Copy(copied, dst)
+		for i := 0; i < copied.Len(); i++ {
+			var originalElem reflect.Value
+			if original.IsValid() && i < original.Len() {
+				originalElem = original.Index(i)
+			}
+			unredactValue(copied.Index(i), originalElem)
+		}
+		dst.Set(copied)
+	}
+}
+
+// indirect returns the value a pointer points to, or an invalid value for a
+// nil or invalid pointer
+func indirect(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.IsNil() {
+		return reflect.Value{}
+	}
+	return v.Elem()
+}
+
+// isRedacted reports whether a sensitive value is set and reads as Redact
+// leaves it
+func isRedacted(v reflect.Value) bool {
+	return !v.IsZero() && reflect.DeepEqual(v.Interface(), redactValue(v, true).Interface())
+}
+
+func isSensitive(field reflect.StructField) bool {
+	return field.Tag.Get("sensitive") == "true"
+}
+
+// loggedClusterSpec is a ClusterSpec without its LogValue method, which
+// would otherwise be called again on the redacted copy
+type loggedClusterSpec ClusterSpec
+
+// LogValue redacts the sensitive fields of a spec logged with slog
+func (s ClusterSpec) LogValue() slog.Value {
+	return slog.AnyValue(loggedClusterSpec(Redact(s)))
+}
+
+// loggedResource is a Resource without its LogValue method
+type loggedResource[T any] Resource[T]
+
+// LogValue redacts the sensitive fields of a resource logged with slog
+func (r Resource[T]) LogValue() slog.Value {
+	return slog.AnyValue(loggedResource[T](Redact(r)))
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRoleARN = "arn:aws:iam::123456789012:role/workloads"
+
+func sensitiveSpec() ClusterSpec {
+	return ClusterSpec{
+		Provider: "aws",
+		ControlPlane: ControlPlaneSpec{
+			Version:  "1.30",
+			Identity: &IdentitySpec{Type: "oidc", RoleARN: testRoleARN},
+			Config:   map[string]interface{}{"token": "abc", "replicas": 3},
+		},
+		Config: map[string]interface{}{"name": "prod"},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	spec := sensitiveSpec()
+	redacted := Redact(spec)
+
+	if got := redacted.ControlPlane.Identity.RoleARN; got != Redacted {
+		t.Errorf("redacted RoleARN = %q, want %q", got, Redacted)
+	}
+	if want := map[string]interface{}{"token": Redacted, "replicas": 0}; !reflect.DeepEqual(redacted.ControlPlane.Config, want) {
+		t.Errorf("redacted control plane config = %v, want %v", redacted.ControlPlane.Config, want)
+	}
+	if redacted.ControlPlane.Version != "1.30" || redacted.Config["name"] != "prod" {
+		t.Errorf("redacted spec = %+v, want fields that are not sensitive kept", redacted)
+	}
+
+	if !reflect.DeepEqual(spec, sensitiveSpec()) {
+		t.Errorf("Redact() modified its argument: %+v", spec)
+	}
+	if got := Redact(IdentitySpec{Type: "managed"}).RoleARN; got != "" {
+		t.Errorf("redacted unset RoleARN = %q, want it left unset", got)
+	}
+}
+
+func TestUnredact(t *testing.T) {
+	current := map[string]*Cluster{"c1": {ID: "c1", Spec: sensitiveSpec()}}
+	redacted := Redact(map[string]*Cluster{
+		"c1": {ID: "c1", Spec: sensitiveSpec()},
+		"c2": {ID: "c2", Spec: sensitiveSpec()},
+	})
+
+	restored := Unredact(redacted, current)
+	if got := restored["c1"].Spec; !reflect.DeepEqual(got, sensitiveSpec()) {
+		t.Errorf("restored spec = %+v, want the original", got)
+	}
+	if got := restored["c2"].Spec.ControlPlane.Identity.RoleARN; got != "" {
+		t.Errorf("restored RoleARN without an original = %q, want it zeroed", got)
+	}
+	if got := redacted["c1"].Spec.ControlPlane.Identity.RoleARN; got != Redacted {
+		t.Errorf("Unredact() modified its argument, RoleARN = %q", got)
+	}
+
+	// Values that were never redacted are kept over the original's
+	old := sensitiveSpec()
+	old.ControlPlane.Identity.RoleARN = "arn:aws:iam::123456789012:role/old"
+	if got := Unredact(old, sensitiveSpec()).ControlPlane.Identity.RoleARN; got != old.ControlPlane.Identity.RoleARN {
+		t.Errorf("restored RoleARN = %q, want the unredacted value kept", got)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	spec := sensitiveSpec()
+	spec.Tags = map[string]string{"team": "platform"}
+	spec.WorkerPools = []WorkerPoolSpec{{Name: "general", Labels: map[string]string{"tier": "web"}}}
+
+	copied := DeepCopy(spec)
+	if !reflect.DeepEqual(copied, spec) {
+		t.Fatalf("DeepCopy() = %+v, want %+v", copied, spec)
+	}
+
+	copied.Tags["team"] = "data"
+	copied.WorkerPools[0].Labels["tier"] = "batch"
+	copied.ControlPlane.Identity.RoleARN = "changed"
+	copied.ControlPlane.Config["token"] = "changed"
+	if spec.Tags["team"] != "platform" || spec.WorkerPools[0].Labels["tier"] != "web" ||
+		spec.ControlPlane.Identity.RoleARN != testRoleARN || spec.ControlPlane.Config["token"] != "abc" {
+		t.Errorf("changing the copy changed the original: %+v", spec)
+	}
+}
+
+func TestClusterSpec_LogValue(t *testing.T) {
+	for name, handler := range map[string]func(*bytes.Buffer) slog.Handler{
+		"text": func(b *bytes.Buffer) slog.Handler { return slog.NewTextHandler(b, nil) },
+		"json": func(b *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(b, nil) },
+	} {
+		var logs bytes.Buffer
+		spec := sensitiveSpec()
+		slog.New(handler(&logs)).Info("applying", "spec", spec, "cluster", &Cluster{ID: "c1", Spec: spec})
+
+		if strings.Contains(logs.String(), testRoleARN) || strings.Contains(logs.String(), "abc") {
+			t.Errorf("%s log = %s, want sensitive values redacted", name, logs.String())
+		}
+		if !strings.Contains(logs.String(), "1.30") {
+			t.Errorf("%s log = %s, want the rest of the spec", name, logs.String())
+		}
+	}
+}
+
+func TestClusterSpec_DiffRedactsSensitiveFields(t *testing.T) {
+	old := sensitiveSpec()
+	new := sensitiveSpec()
+	new.ControlPlane.Identity.RoleARN = "arn:aws:iam::123456789012:role/other"
+
+	changes := old.Diff(new)
+	if len(changes) != 1 || changes[0].Path != "controlPlane.identity.roleArn" {
+		t.Fatalf("Diff() = %v, want the role ARN change", changes)
+	}
+	if got := changes[0].String(); got != "controlPlane.identity.roleArn: *** → ***" {
+		t.Errorf("change = %q, want the values redacted", got)
+	}
+
+	// A whole new struct is redacted too
+	old.ControlPlane.Identity = nil
+	changes = old.Diff(sensitiveSpec())
+	if len(changes) != 1 {
+		t.Fatalf("Diff() = %v, want the identity change", changes)
+	}
+	if identity, ok := changes[0].New.(*IdentitySpec); !ok || identity.RoleARN != Redacted {
+		t.Errorf("Diff() new identity = %+v, want it redacted", changes[0].New)
+	}
+}
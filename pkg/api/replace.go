@@ -0,0 +1,43 @@
+package api
+
+import "strings"
+
+// replacementFields are the cluster spec paths, in the dotted convention of
+// Diff, whose change the cloud cannot apply in place: the cluster is
+// destroyed and created again. Rules under "" hold for every provider.
+// Worker pool changes are rolled out by replacing nodes instead; see
+// WorkerPoolSpec.ClassifyChange.
+var replacementFields = map[string][]string{
+	"": {
+		"provider",
+		"region",
+		"controlPlane.type",
+		"network.vpcCidr",
+		"network.subnets", // Node groups cannot move to other subnets
+		"network.existingVpcId",
+		"network.existingVnetId",
+		"network.existingSubnetIds",
+	},
+	"azure": {
+		"network.privateCluster", // AKS cannot make a running cluster private or public
+	},
+}
+
+// ReplacementChanges returns the changes from s to other that force the
+// cluster to be replaced rather than updated in place, or nil if it can be
+// updated in place. The rules of s's provider apply.
+func (s ClusterSpec) ReplacementChanges(other ClusterSpec) []FieldChange {
+	rules := append(append([]string(nil), replacementFields[""]...), replacementFields[s.Provider]...)
+
+	var forcing []FieldChange
+	for _, change := range s.Diff(other) {
+		segments := strings.Split(change.Path, ".")
+		for _, rule := range rules {
+			if matchSegments(strings.Split(rule, "."), segments) {
+				forcing = append(forcing, change)
+				break
+			}
+		}
+	}
+	return forcing
+}
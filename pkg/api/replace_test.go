@@ -0,0 +1,66 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterSpec_ReplacementChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ClusterSpec)
+		want   []string
+	}{
+		{name: "no change", modify: func(s *ClusterSpec) {}},
+		{
+			name: "in-place changes",
+			modify: func(s *ClusterSpec) {
+				s.ControlPlane.Version = "1.29"
+				s.WorkerPools[0].MaxSize = 10
+				s.Tags["Team"] = "data"
+			},
+		},
+		{
+			name:   "VPC CIDR",
+			modify: func(s *ClusterSpec) { s.Network.VPCCIDR = "10.1.0.0/16" },
+			want:   []string{"network.vpcCidr"},
+		},
+		{
+			name:   "subnet CIDR alongside an in-place change",
+			modify: func(s *ClusterSpec) { s.Network.Subnets[0].CIDR = "10.0.9.0/24"; s.ControlPlane.Version = "1.29" },
+			want:   []string{"network.subnets.private-a.cidr"},
+		},
+		{
+			name:   "region and control plane type",
+			modify: func(s *ClusterSpec) { s.Region = "us-east-1"; s.ControlPlane.Type = ControlPlaneSelfManaged },
+			want:   []string{"region", "controlPlane.type"},
+		},
+		{
+			name:   "private cluster on AWS",
+			modify: func(s *ClusterSpec) { s.Network.PrivateCluster = true },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := baseSpec()
+			tt.modify(&desired)
+
+			var got []string
+			for _, change := range baseSpec().ReplacementChanges(desired) {
+				got = append(got, change.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReplacementChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	azure := baseSpec()
+	azure.Provider = "azure"
+	private := azure
+	private.Network.PrivateCluster = true
+	if got := azure.ReplacementChanges(private); len(got) != 1 || got[0].Path != "network.privateCluster" {
+		t.Errorf("ReplacementChanges() = %v, want network.privateCluster on Azure", got)
+	}
+}
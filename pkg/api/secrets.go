@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SecretRef names a value a cluster's nodes or add-ons need, such as
+// registry credentials or a license key, and where to read it when the
+// cluster is applied. Only the reference is part of the spec, so it can be
+// stored and diffed; the value is never written to configuration or state.
+type SecretRef struct {
+	Name string `json:"name" hcl:"name,label"`
+	Env  string `json:"env,omitempty" hcl:"env,optional"`   // Environment variable holding the value
+	File string `json:"file,omitempty" hcl:"file,optional"` // File holding the value
+	Key  string `json:"key,omitempty" hcl:"key,optional"`   // With File, the entry of a JSON object of values to use
+}
+
+// Secret is a resolved secret value. It is redacted wherever it is
+// formatted, logged or marshaled; Reveal returns the value itself for the
+// provider injecting it.
+type Secret string
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String redacts the value
+func (s Secret) String() string {
+	return Redacted
+}
+
+// GoString redacts the value from %#v
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+// LogValue redacts the value from structured logs
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// MarshalJSON redacts the value, so a secret that ends up in state, an event
+// or a snapshot by mistake does not leak
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// SecretNames returns the names of resolved secrets in order, for logging
+// which secrets a cluster gets without their values
+func SecretNames(secrets map[string]Secret) []string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve reads the secret value from its environment variable or file. A
+// file without a key holds the value alone, less a trailing newline.
+func (r SecretRef) Resolve() (Secret, error) {
+	if r.Env != "" {
+		value, ok := os.LookupEnv(r.Env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", r.Name, r.Env)
+		}
+		return Secret(value), nil
+	}
+	if r.File == "" {
+		return "", fmt.Errorf("secret %s has neither env nor file", r.Name)
+	}
+
+	data, err := os.ReadFile(r.File)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", r.Name, err)
+	}
+	if r.Key == "" {
+		value := strings.TrimRight(string(data), "\r\n")
+		if value == "" {
+			return "", fmt.Errorf("secret %s: file %s is empty", r.Name, r.File)
+		}
+		return Secret(value), nil
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		// The error can quote the file, so it is not included
+		return "", fmt.Errorf("secret %s: file %s is not a JSON object of string values", r.Name, r.File)
+	}
+	value, ok := values[r.Key]
+	if !ok || value == "" {
+		return "", fmt.Errorf("secret %s: file %s has no key %s", r.Name, r.File, r.Key)
+	}
+	return Secret(value), nil
+}
+
+// ResolveSecrets resolves every secret of the spec, keyed by name. All
+// unresolvable secrets are reported together.
+func (s ClusterSpec) ResolveSecrets() (map[string]Secret, error) {
+	if len(s.Secrets) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]Secret, len(s.Secrets))
+	var problems []string
+	for _, ref := range s.Secrets {
+		value, err := ref.Resolve()
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		values[ref.Name] = value
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("cannot resolve secrets: %s", strings.Join(problems, "; "))
+	}
+	return values, nil
+}
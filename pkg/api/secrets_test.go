@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretRef_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	valuesFile := filepath.Join(dir, "secrets.json")
+	if err := os.WriteFile(valuesFile, []byte(`{"license": "key-123"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROVCTL_TEST_TOKEN", "env-token")
+
+	tests := []struct {
+		name    string
+		ref     SecretRef
+		want    string
+		wantErr string
+	}{
+		{name: "env", ref: SecretRef{Name: "token", Env: "PROVCTL_TEST_TOKEN"}, want: "env-token"},
+		{name: "file", ref: SecretRef{Name: "token", File: tokenFile}, want: "file-token"},
+		{name: "file key", ref: SecretRef{Name: "license", File: valuesFile, Key: "license"}, want: "key-123"},
+		{name: "unset env", ref: SecretRef{Name: "token", Env: "PROVCTL_TEST_UNSET"}, wantErr: "PROVCTL_TEST_UNSET is not set"},
+		{name: "missing key", ref: SecretRef{Name: "license", File: valuesFile, Key: "other"}, wantErr: "has no key other"},
+		{name: "not JSON", ref: SecretRef{Name: "license", File: tokenFile, Key: "license"}, wantErr: "not a JSON object"},
+		{name: "no source", ref: SecretRef{Name: "token"}, wantErr: "neither env nor file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.ref.Resolve()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got.Reveal() != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got.Reveal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterSpec_ResolveSecretsReportsAll(t *testing.T) {
+	spec := ClusterSpec{Secrets: []SecretRef{
+		{Name: "a", Env: "PROVCTL_TEST_UNSET_A"},
+		{Name: "b", Env: "PROVCTL_TEST_UNSET_B"},
+	}}
+
+	_, err := spec.ResolveSecrets()
+	if err == nil || !strings.Contains(err.Error(), "PROVCTL_TEST_UNSET_A") || !strings.Contains(err.Error(), "PROVCTL_TEST_UNSET_B") {
+		t.Errorf("ResolveSecrets() error = %v, want both unset variables reported", err)
+	}
+}
+
+func TestSecret_Redacted(t *testing.T) {
+	const value = "hunter2"
+	secrets := map[string]Secret{"password": Secret(value)}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("applying", "secret", secrets["password"], "secrets", secrets)
+	encoded, err := json.Marshal(secrets)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	outputs := map[string]string{
+		"%v":   fmt.Sprintf("%v", secrets),
+		"%s":   fmt.Sprintf("%s", secrets["password"]),
+		"%+v":  fmt.Sprintf("%+v", struct{ S Secret }{secrets["password"]}),
+		"%#v":  fmt.Sprintf("%#v", secrets),
+		"JSON": string(encoded),
+		"log":  logged.String(),
+	}
+	for name, output := range outputs {
+		if strings.Contains(output, value) || !strings.Contains(output, Redacted) {
+			t.Errorf("%s output = %s, want the value redacted", name, output)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector matches resources by their metadata labels, in the style of
+// Kubernetes equality-based label selectors
+type Selector []Requirement
+
+// Requirement is a single condition of a selector
+type Requirement struct {
+	Key      string
+	Operator string // "=", "!=", "exists" or "!exists"
+	Value    string
+}
+
+// Selector requirement operators
+const (
+	SelectorEquals    = "="
+	SelectorNotEquals = "!="
+	SelectorExists    = "exists"
+	SelectorNotExists = "!exists"
+)
+
+// ParseSelector parses a comma-separated list of requirements: key=value
+// (or key==value), key!=value, key for a label that is set and !key for one
+// that is not. All requirements must match.
+func ParseSelector(s string) (Selector, error) {
+	var selector Selector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty requirement", s)
+		}
+
+		var req Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			key, value, _ := strings.Cut(part, "!=")
+			req = Requirement{Key: key, Operator: SelectorNotEquals, Value: value}
+		case strings.Contains(part, "=="):
+			key, value, _ := strings.Cut(part, "==")
+			req = Requirement{Key: key, Operator: SelectorEquals, Value: value}
+		case strings.Contains(part, "="):
+			key, value, _ := strings.Cut(part, "=")
+			req = Requirement{Key: key, Operator: SelectorEquals, Value: value}
+		case strings.HasPrefix(part, "!"):
+			req = Requirement{Key: part[1:], Operator: SelectorNotExists}
+		default:
+			req = Requirement{Key: part, Operator: SelectorExists}
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if req.Key == "" {
+			return nil, fmt.Errorf("invalid selector %q: requirement %q has no key", s, part)
+		}
+		if strings.ContainsAny(req.Key, "=!") || strings.ContainsAny(req.Value, "=!") {
+			return nil, fmt.Errorf("invalid selector %q: malformed requirement %q", s, part)
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// Matches reports whether labels satisfy every requirement. An empty
+// selector matches everything.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether labels satisfy the requirement. A != requirement
+// also matches resources without the label.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, exists := labels[r.Key]
+	switch r.Operator {
+	case SelectorEquals:
+		return exists && value == r.Value
+	case SelectorNotEquals:
+		return !exists || value != r.Value
+	case SelectorExists:
+		return exists
+	case SelectorNotExists:
+		return !exists
+	default:
+		return false
+	}
+}
+
+// String returns the selector in the form accepted by ParseSelector
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, req := range s {
+		switch req.Operator {
+		case SelectorExists:
+			parts[i] = req.Key
+		case SelectorNotExists:
+			parts[i] = "!" + req.Key
+		default:
+			parts[i] = req.Key + req.Operator + req.Value
+		}
+	}
+	return strings.Join(parts, ",")
+}
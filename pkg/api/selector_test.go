@@ -0,0 +1,67 @@
+package api
+
+import "testing"
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "env=test", want: "env=test"},
+		{input: "env==test", want: "env=test"},
+		{input: "env=test, team!=payments", want: "env=test,team!=payments"},
+		{input: "ephemeral,!keep", want: "ephemeral,!keep"},
+		{input: "env=", want: "env="},
+		{input: "", wantErr: true},
+		{input: "env=test,", wantErr: true},
+		{input: "=test", wantErr: true},
+		{input: "env=a=b", wantErr: true},
+		{input: "!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			selector, err := ParseSelector(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && selector.String() != tt.want {
+				t.Errorf("ParseSelector() = %q, want %q", selector.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"env": "test", "team": "web"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"env=test", true},
+		{"env=prod", false},
+		{"env=test,team=web", true},
+		{"env=test,team=api", false},
+		{"team!=api", true},
+		{"owner!=alice", true},
+		{"team!=web", false},
+		{"env", true},
+		{"owner", false},
+		{"!owner", true},
+		{"!env", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseSelector() error = %v", err)
+			}
+			if got := selector.Matches(labels); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", labels, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SetPath sets the field at a dotted path, parsing value according to the
+// field's type. Paths use the same JSON field names as Diff
+// ("controlPlane.version"). Slice elements are addressed by index
+// ("workerPools.0.instanceType") or, for named elements such as worker pools,
+// by name ("workerPools.gpu.desiredSize"). A path ending in a map key sets
+// that key ("tags.env"), string slices take comma-separated values, and nil
+// pointers and maps along the path are allocated.
+func (s *ClusterSpec) SetPath(path, value string) error {
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+	return setValue(reflect.ValueOf(s).Elem(), "", strings.Split(path, "."), value)
+}
+
+func setValue(v reflect.Value, path string, segments []string, value string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), path, segments, value)
+	}
+
+	if len(segments) == 0 {
+		if err := setScalar(v, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+
+	segment := segments[0]
+	next := joinPath(path, segment)
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if jsonName(t.Field(i)) == segment {
+				return setValue(v.Field(i), next, segments[1:], value)
+			}
+		}
+		return fmt.Errorf("%s: unknown field", next)
+
+	case reflect.Slice:
+		index, err := sliceIndex(v, segment)
+		if err != nil {
+			return fmt.Errorf("%s: %w", next, err)
+		}
+		return setValue(v.Index(index), next, segments[1:], value)
+
+	case reflect.Map:
+		if len(segments) > 1 {
+			return fmt.Errorf("%s: cannot set fields below a map entry", next)
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := setScalar(elem, value); err != nil {
+			return fmt.Errorf("%s: %w", next, err)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()), elem)
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s has no fields", next, path)
+}
+
+// sliceIndex resolves a path segment to a slice index, either numerically or
+// by element name for slices of named elements
+func sliceIndex(v reflect.Value, segment string) (int, error) {
+	if index, err := strconv.Atoi(segment); err == nil {
+		if index < 0 || index >= v.Len() {
+			return 0, fmt.Errorf("index out of range (length %d)", v.Len())
+		}
+		return index, nil
+	}
+
+	if hasNameField(v.Type().Elem()) {
+		for i := 0; i < v.Len(); i++ {
+			if v.Index(i).FieldByName("Name").String() == segment {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("no element named %q", segment)
+	}
+	return 0, fmt.Errorf("invalid index %q", segment)
+}
+
+func setScalar(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(value))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("cannot set a list of %s from a string", v.Type().Elem().Kind())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("cannot set a %s from a string", v.Kind())
+	}
+	return nil
+}
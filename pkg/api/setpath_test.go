@@ -0,0 +1,118 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterSpec_SetPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value string
+		want  []FieldChange
+	}{
+		{
+			name:  "nested string",
+			path:  "controlPlane.version",
+			value: "1.29",
+			want:  []FieldChange{{Path: "controlPlane.version", Old: "1.28", New: "1.29"}},
+		},
+		{
+			name:  "pool by index",
+			path:  "workerPools.0.instanceType",
+			value: "m5.large",
+			want:  []FieldChange{{Path: "workerPools.general.instanceType", Old: "t3.medium", New: "m5.large"}},
+		},
+		{
+			name:  "pool by name",
+			path:  "workerPools.gpu.maxSize",
+			value: "4",
+			want:  []FieldChange{{Path: "workerPools.gpu.maxSize", Old: 2, New: 4}},
+		},
+		{
+			name:  "map key",
+			path:  "tags.Environment",
+			value: "staging",
+			want:  []FieldChange{{Path: "tags.Environment", Old: "production", New: "staging"}},
+		},
+		{
+			name:  "nil map allocated",
+			path:  "workerPools.gpu.labels.accelerator",
+			value: "nvidia",
+			want:  []FieldChange{{Path: "workerPools.gpu.labels.accelerator", New: "nvidia"}},
+		},
+		{
+			name:  "bool",
+			path:  "network.natGateway",
+			value: "true",
+			want:  []FieldChange{{Path: "network.natGateway", Old: false, New: true}},
+		},
+		{
+			name:  "string list",
+			path:  "network.availabilityZones",
+			value: "us-west-2a, us-west-2b",
+			want: []FieldChange{{
+				Path: "network.availabilityZones",
+				Old:  []string{"us-west-2a", "us-west-2b", "us-west-2c"},
+				New:  []string{"us-west-2a", "us-west-2b"},
+			}},
+		},
+		{
+			name:  "named string type",
+			path:  "controlPlane.type",
+			value: "self-managed",
+			want:  []FieldChange{{Path: "controlPlane.type", Old: ControlPlaneManaged, New: ControlPlaneSelfManaged}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := baseSpec()
+			if err := spec.SetPath(tt.path, tt.value); err != nil {
+				t.Fatalf("SetPath(%q) error = %v", tt.path, err)
+			}
+
+			got := baseSpec().Diff(spec)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterSpec_SetPathAllocatesPointer(t *testing.T) {
+	spec := baseSpec()
+	if err := spec.SetPath("observability.logVolumeGb", "12.5"); err != nil {
+		t.Fatalf("SetPath() error = %v", err)
+	}
+	if spec.Observability == nil || spec.Observability.LogVolumeGB != 12.5 {
+		t.Errorf("Observability = %+v, want LogVolumeGB 12.5", spec.Observability)
+	}
+}
+
+func TestClusterSpec_SetPathErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value string
+	}{
+		{"unknown field", "controlPlane.release", "1.29"},
+		{"index out of range", "workerPools.5.maxSize", "3"},
+		{"unknown pool", "workerPools.batch.maxSize", "3"},
+		{"bad integer", "workerPools.0.maxSize", "many"},
+		{"struct value", "controlPlane", "1.29"},
+		{"below scalar", "region.name", "eu-west-1"},
+		{"below map entry", "tags.Environment.value", "x"},
+		{"empty path", "", "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := baseSpec()
+			if err := spec.SetPath(tt.path, tt.value); err == nil {
+				t.Errorf("SetPath(%q, %q) succeeded, want error", tt.path, tt.value)
+			}
+		})
+	}
+}
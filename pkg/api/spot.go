@@ -0,0 +1,32 @@
+package api
+
+// Spot allocation strategies, deciding which capacity pools spot instances
+// are launched from. Capacity-optimized pools are interrupted least, at a
+// slight premium over the lowest price.
+const (
+	SpotLowestPrice            = "lowest-price"             // Cheapest pools, most interruptions
+	SpotCapacityOptimized      = "capacity-optimized"       // Deepest pools, fewest interruptions
+	SpotPriceCapacityOptimized = "price-capacity-optimized" // Cheapest of the deepest pools (AWS only)
+)
+
+// SpotAllocationStrategies lists every known spot allocation strategy
+var SpotAllocationStrategies = []string{SpotLowestPrice, SpotCapacityOptimized, SpotPriceCapacityOptimized}
+
+// SpotDefaults are spot settings shared by every spot pool of a cluster, so
+// that its pools draw spot capacity the same way
+type SpotDefaults struct {
+	AllocationStrategy string `json:"allocationStrategy,omitempty" hcl:"allocation_strategy,optional"`
+}
+
+// OnDemandBase returns how many nodes of a spot pool run on on-demand
+// capacity. A pool falling back to on-demand keeps its minimum size there,
+// so spot shortages cannot take it below its minimum, and runs the rest on
+// spot. Auto Scaling groups and scale sets cannot launch on-demand instances
+// in place of spot requests they fail to fulfil, so this base is the
+// fallback they offer.
+func (s WorkerPoolSpec) OnDemandBase() int {
+	if s.Spot == nil || !s.Spot.Enabled || !s.Spot.FallbackToOnDemand {
+		return 0
+	}
+	return s.MinSize
+}
@@ -0,0 +1,53 @@
+package api
+
+// Tags are cloud resource tags (AWS tags, Azure resource tags) used for cost
+// allocation and ownership. They are distinct from Labels, which are
+// Kubernetes node labels applied inside the cluster.
+
+const (
+	TagManagedBy = "provctl.io/managed-by" // Marks resources created by provctl
+	TagCluster   = "provctl.io/cluster"    // Name of the owning cluster
+)
+
+// MandatoryTags returns the tags provctl applies to every cloud resource it creates
+func MandatoryTags(clusterName string) map[string]string {
+	return map[string]string{
+		TagManagedBy: "provctl",
+		TagCluster:   clusterName,
+	}
+}
+
+// MergeTags merges tag sets in order, with later sets overriding earlier ones
+func MergeTags(sets ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, set := range sets {
+		for key, value := range set {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// PoolTags returns the effective cloud tags for a worker pool of this cluster.
+// Pool tags override cluster tags, which override the mandatory tags.
+func (s ClusterSpec) PoolTags(clusterName string, pool WorkerPoolSpec) map[string]string {
+	return MergeTags(MandatoryTags(clusterName), s.Tags, pool.Tags)
+}
+
+// DefaultTags are tags an organization applies to every cluster, declared
+// once instead of in each cluster block
+type DefaultTags struct {
+	Tags     map[string]string `hcl:"tags,optional"`
+	Required []string          `hcl:"required,optional"` // Keys every cluster must end up with
+}
+
+// WithDefaultTags returns the spec with defaults merged beneath its own tags,
+// so an explicit cluster tag overrides a default. Worker pools inherit them
+// through PoolTags.
+func (s ClusterSpec) WithDefaultTags(defaults map[string]string) ClusterSpec {
+	if len(defaults) == 0 {
+		return s
+	}
+	s.Tags = MergeTags(defaults, s.Tags)
+	return s
+}
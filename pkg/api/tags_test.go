@@ -0,0 +1,82 @@
+package api
+
+import "testing"
+
+func TestClusterSpec_PoolTags(t *testing.T) {
+	cluster := ClusterSpec{
+		Tags: map[string]string{
+			"team":        "platform",
+			"environment": "prod",
+			TagManagedBy:  "terraform",
+		},
+	}
+	pool := WorkerPoolSpec{
+		Name: "gpu",
+		Tags: map[string]string{
+			"team":        "ml",
+			"cost-center": "1234",
+		},
+	}
+
+	got := cluster.PoolTags("prod-cluster", pool)
+
+	want := map[string]string{
+		"team":        "ml",           // pool overrides cluster
+		"environment": "prod",         // inherited from cluster
+		"cost-center": "1234",         // pool only
+		TagManagedBy:  "terraform",    // cluster overrides mandatory
+		TagCluster:    "prod-cluster", // mandatory
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("PoolTags() got %d tags, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("PoolTags()[%s] = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestMergeTags_DoesNotMutateInputs(t *testing.T) {
+	base := map[string]string{"a": "1"}
+	override := map[string]string{"a": "2"}
+
+	merged := MergeTags(base, override)
+	merged["b"] = "3"
+
+	if base["a"] != "1" || len(base) != 1 {
+		t.Errorf("MergeTags() mutated base: %v", base)
+	}
+	if merged["a"] != "2" {
+		t.Errorf("MergeTags()[a] = %q, want 2", merged["a"])
+	}
+}
+
+func TestClusterSpec_WithDefaultTags(t *testing.T) {
+	defaults := map[string]string{"cost-center": "42", "owner": "platform", "environment": "dev"}
+	spec := ClusterSpec{Tags: map[string]string{"environment": "prod"}}
+	pool := WorkerPoolSpec{Name: "gpu", Tags: map[string]string{"owner": "ml"}}
+
+	merged := spec.WithDefaultTags(defaults)
+	if spec.Tags["cost-center"] != "" {
+		t.Errorf("WithDefaultTags() mutated the spec: %v", spec.Tags)
+	}
+
+	got := merged.PoolTags("prod-cluster", pool)
+	want := map[string]string{
+		"cost-center": "42",           // default
+		"environment": "prod",         // cluster overrides default
+		"owner":       "ml",           // pool overrides default
+		TagManagedBy:  "provctl",      // mandatory
+		TagCluster:    "prod-cluster", // mandatory
+	}
+	if len(got) != len(want) {
+		t.Errorf("PoolTags() got %d tags, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("PoolTags()[%s] = %q, want %q", key, got[key], value)
+		}
+	}
+}
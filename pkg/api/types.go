@@ -25,6 +25,15 @@ type ResourceMetadata struct {
 	UpdatedAt   time.Time         `json:"updatedAt"`
 }
 
+// Touch records a change to the resource made at now, setting CreatedAt as
+// well for a resource that has none yet
+func (m *ResourceMetadata) Touch(now time.Time) {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+}
+
 // ResourceStatus represents the current state of a resource
 type ResourceStatus struct {
 	Phase      Phase             `json:"phase"`
@@ -66,22 +75,43 @@ const (
 
 // ClusterSpec defines the desired state of a cluster
 type ClusterSpec struct {
-	Provider     string                 `json:"provider" hcl:"provider"`
-	Region       string                 `json:"region" hcl:"region"`
-	Network      NetworkSpec            `json:"network" hcl:"network,block"`
-	ControlPlane ControlPlaneSpec       `json:"controlPlane" hcl:"control_plane,block"`
-	WorkerPools  []WorkerPoolSpec       `json:"workerPools" hcl:"worker_pools,block"`
-	Tags         map[string]string      `json:"tags,omitempty" hcl:"tags,optional"`
-	Config       map[string]interface{} `json:"config,omitempty" hcl:"config,optional"`
+	Provider           string                 `json:"provider" hcl:"provider"`
+	Region             string                 `json:"region" hcl:"region"`
+	Network            NetworkSpec            `json:"network" hcl:"network,block"`
+	ControlPlane       ControlPlaneSpec       `json:"controlPlane" hcl:"control_plane,block"`
+	WorkerPools        []WorkerPoolSpec       `json:"workerPools" hcl:"worker_pools,block"`
+	PoolDefaults       *PoolDefaults          `json:"poolDefaults,omitempty" hcl:"pool_defaults,block"` // Settings every worker pool inherits
+	SpotDefaults       *SpotDefaults          `json:"spotDefaults,omitempty" hcl:"spot_defaults,block"` // Spot settings every spot pool inherits
+	Observability      *ObservabilitySpec     `json:"observability,omitempty" hcl:"observability,block"`
+	DeletionProtection bool                   `json:"deletionProtection,omitempty" hcl:"deletion_protection,optional"` // Refuse deletes unless explicitly overridden
+	Tags               map[string]string      `json:"tags,omitempty" hcl:"tags,optional"`                              // Cloud resource tags inherited by worker pools
+	Secrets            []SecretRef            `json:"secrets,omitempty" hcl:"secret,block"`                            // Resolved and injected at apply time, never stored
+	Config             map[string]interface{} `json:"config,omitempty" hcl:"config,optional"`
 }
 
 // NetworkSpec defines network configuration
 type NetworkSpec struct {
-	VPCCIDR           string   `json:"vpcCidr" hcl:"vpc_cidr"`
+	VPCCIDR           string   `json:"vpcCidr" hcl:"vpc_cidr,optional"`
 	AvailabilityZones []string `json:"availabilityZones" hcl:"availability_zones"`
 	Subnets           []Subnet `json:"subnets,omitempty" hcl:"subnets,block"`
 	NATGateway        bool     `json:"natGateway" hcl:"nat_gateway,optional"`
 	PrivateCluster    bool     `json:"privateCluster" hcl:"private_cluster,optional"`
+	LBProcessedGB     float64  `json:"lbProcessedGb,omitempty" hcl:"lb_processed_gb,optional"` // Expected load balancer traffic per month
+	EgressGB          float64  `json:"egressGb,omitempty" hcl:"egress_gb,optional"`            // Expected data transfer out per month
+
+	// A network created outside provctl, used instead of creating one from VPCCIDR
+	ExistingVPCID     string   `json:"existingVpcId,omitempty" hcl:"existing_vpc_id,optional"`   // AWS
+	ExistingVNetID    string   `json:"existingVnetId,omitempty" hcl:"existing_vnet_id,optional"` // Azure
+	ExistingSubnetIDs []string `json:"existingSubnetIds,omitempty" hcl:"existing_subnet_ids,optional"`
+
+	APIServerAccess *APIServerAccessSpec `json:"apiServerAccess,omitempty" hcl:"api_server_access,block"` // Overrides PrivateCluster for the API endpoint
+}
+
+// APIServerAccessSpec controls how the Kubernetes API endpoint can be reached
+type APIServerAccessSpec struct {
+	PublicAccess    bool     `json:"publicAccess" hcl:"public_access,optional"`
+	PrivateAccess   bool     `json:"privateAccess" hcl:"private_access,optional"`               // Reachable from inside the network
+	AuthorizedCIDRs []string `json:"authorizedCidrs,omitempty" hcl:"authorized_cidrs,optional"` // Limits public access to these ranges
 }
 
 // Subnet defines a subnet configuration
@@ -100,7 +130,11 @@ type ControlPlaneSpec struct {
 	Count        int                    `json:"count,omitempty" hcl:"count,optional"`
 	HA           bool                   `json:"ha" hcl:"ha,optional"`
 	Identity     *IdentitySpec          `json:"identity,omitempty" hcl:"identity,block"`
-	Config       map[string]interface{} `json:"config,omitempty" hcl:"config,optional"`
+	Config       map[string]interface{} `json:"config,omitempty" hcl:"config,optional" sensitive:"true"`
+
+	// kube-apiserver settings of a self-managed control plane
+	FeatureGates  map[string]bool   `json:"featureGates,omitempty" hcl:"feature_gates,optional"`   // Such as {"WatchList": true}
+	APIServerArgs map[string]string `json:"apiServerArgs,omitempty" hcl:"api_server_args,optional"` // Flag names without leading dashes
 }
 
 // ControlPlaneType defines the type of control plane
@@ -115,26 +149,73 @@ const (
 type IdentitySpec struct {
 	Type            string   `json:"type" hcl:"type"`
 	ServiceAccounts []string `json:"serviceAccounts,omitempty" hcl:"service_accounts,optional"`
-	RoleARN         string   `json:"roleArn,omitempty" hcl:"role_arn,optional"`
+	RoleARN         string   `json:"roleArn,omitempty" hcl:"role_arn,optional" sensitive:"true"`
+}
+
+// ObservabilitySpec defines control-plane logging and monitoring add-ons
+type ObservabilitySpec struct {
+	ControlPlaneLogs bool         `json:"controlPlaneLogs" hcl:"control_plane_logs,optional"`
+	Metrics          bool         `json:"metrics" hcl:"metrics,optional"`
+	LogVolumeGB      float64      `json:"logVolumeGb,omitempty" hcl:"log_volume_gb,optional"` // Expected log ingestion per month
+	Logging          *LoggingSpec `json:"logging,omitempty" hcl:"logging,block"`              // Routes control-plane logs; implies ControlPlaneLogs
+}
+
+// LoggingSpec selects which control-plane logs are collected and where they
+// are sent
+type LoggingSpec struct {
+	Destination string   `json:"destination,omitempty" hcl:"destination,optional"` // CloudWatch log group or Log Analytics workspace ID
+	Types       []string `json:"types,omitempty" hcl:"types,optional"`             // Defaults to DefaultLogTypes
 }
 
 // WorkerPoolSpec defines a worker node pool
 type WorkerPoolSpec struct {
-	Name         string                 `json:"name" hcl:"name,label"`
-	InstanceType string                 `json:"instanceType" hcl:"instance_type"`
-	MinSize      int                    `json:"minSize" hcl:"min_size"`
-	MaxSize      int                    `json:"maxSize" hcl:"max_size"`
-	DesiredSize  int                    `json:"desiredSize,omitempty" hcl:"desired_size,optional"`
-	Spot         *SpotConfig            `json:"spot,omitempty" hcl:"spot,block"`
-	Labels       map[string]string      `json:"labels,omitempty" hcl:"labels,optional"`
-	Taints       []Taint                `json:"taints,omitempty" hcl:"taints,block"`
-	Config       map[string]interface{} `json:"config,omitempty" hcl:"config,optional"`
+	Name            string                 `json:"name" hcl:"name,label"`
+	InstanceType    string                 `json:"instanceType" hcl:"instance_type,optional"`
+	InstanceTypes   []string               `json:"instanceTypes,omitempty" hcl:"instance_types,optional"`     // Spot alternatives to InstanceType
+	InstanceWeights map[string]int         `json:"instanceWeights,omitempty" hcl:"instance_weights,optional"` // Capacity units per instance type; see InstanceWeight
+	MinSize         int                    `json:"minSize" hcl:"min_size,optional"`
+	MaxSize         int                    `json:"maxSize" hcl:"max_size,optional"`
+	DesiredSize     int                    `json:"desiredSize,omitempty" hcl:"desired_size,optional"`
+	MaxPods         int                    `json:"maxPods,omitempty" hcl:"max_pods,optional"`  // Kubelet pod limit per node; 0 keeps the provider default
+	Priority        int                    `json:"priority,omitempty" hcl:"priority,optional"` // Cluster-autoscaler priority expander rank; higher scales first
+	Spot            *SpotConfig            `json:"spot,omitempty" hcl:"spot,block"`
+	WarmPool        *WarmPoolConfig        `json:"warmPool,omitempty" hcl:"warm_pool,block"`
+	Labels          map[string]string      `json:"labels,omitempty" hcl:"labels,optional"` // Kubernetes node labels
+	Taints          []Taint                `json:"taints,omitempty" hcl:"taints,block"`
+	Tags            map[string]string      `json:"tags,omitempty" hcl:"tags,optional"` // Cloud resource tags, override cluster tags
+	ImageID         string                 `json:"imageId,omitempty" hcl:"image_id,optional"`
+	UserData        string                 `json:"userData,omitempty" hcl:"user_data,optional"`
+	SSHKeyName      string                 `json:"sshKeyName,omitempty" hcl:"ssh_key_name,optional"`
+	Config          map[string]interface{} `json:"config,omitempty" hcl:"config,optional"`
+}
+
+// PoolDefaults are worker pool settings declared once for a cluster. Sizes
+// are pointers so that a default of zero can be told apart from no default.
+type PoolDefaults struct {
+	InstanceType string            `json:"instanceType,omitempty" hcl:"instance_type,optional"`
+	MinSize      *int              `json:"minSize,omitempty" hcl:"min_size,optional"`
+	MaxSize      *int              `json:"maxSize,omitempty" hcl:"max_size,optional"`
+	DesiredSize  *int              `json:"desiredSize,omitempty" hcl:"desired_size,optional"`
+	Spot         *SpotConfig       `json:"spot,omitempty" hcl:"spot,block"`
+	WarmPool     *WarmPoolConfig   `json:"warmPool,omitempty" hcl:"warm_pool,block"`
+	Labels       map[string]string `json:"labels,omitempty" hcl:"labels,optional"` // Merged under each pool's own labels
 }
 
 // SpotConfig defines spot/preemptible instance configuration
 type SpotConfig struct {
-	Enabled  bool    `json:"enabled" hcl:"enabled"`
-	MaxPrice float64 `json:"maxPrice,omitempty" hcl:"max_price,optional"`
+	Enabled            bool    `json:"enabled" hcl:"enabled"`
+	MaxPrice           float64 `json:"maxPrice,omitempty" hcl:"max_price,optional"`
+	AllocationStrategy string  `json:"allocationStrategy,omitempty" hcl:"allocation_strategy,optional"` // Overrides SpotDefaults
+	FallbackToOnDemand bool    `json:"fallbackToOnDemand,omitempty" hcl:"fallback_to_on_demand,optional"` // Keep the pool's minimum on on-demand capacity; see OnDemandBase
+}
+
+// WarmPoolConfig keeps stopped, pre-initialized instances ready so that a
+// pool scales out quickly. It maps onto AWS Auto Scaling warm pools, which
+// provctl cannot create yet, so validation rejects an enabled one.
+type WarmPoolConfig struct {
+	Enabled             bool `json:"enabled" hcl:"enabled"`
+	MinSize             int  `json:"minSize,omitempty" hcl:"min_size,optional"`                          // Warm instances kept even when the pool is at max size
+	MaxPreparedCapacity int  `json:"maxPreparedCapacity,omitempty" hcl:"max_prepared_capacity,optional"` // Running plus warm instances; defaults to the pool's max size
 }
 
 // Taint represents a Kubernetes taint
@@ -152,24 +233,63 @@ type NodePool = Resource[WorkerPoolSpec]
 
 // Event represents a state change event
 type Event struct {
-	ID        uuid.UUID   `json:"id"`
-	Timestamp time.Time   `json:"timestamp"`
-	Type      EventType   `json:"type"`
-	Resource  ResourceID  `json:"resource"`
-	Actor     string      `json:"actor"`
-	Payload   interface{} `json:"payload"`
+	ID            uuid.UUID   `json:"id"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Type          EventType   `json:"type"`
+	Resource      ResourceID  `json:"resource"`
+	Actor         string      `json:"actor"`
+	Payload       interface{} `json:"payload"`
+	CorrelationID string      `json:"correlationId,omitempty"` // Shared by all events of one apply or reconcile
+	PlanAction    string      `json:"planAction,omitempty"`    // The action of a saved plan the event completed
 }
 
 // EventType defines types of events
 type EventType string
 
 const (
-	EventCreated EventType = "Created"
-	EventUpdated EventType = "Updated"
-	EventDeleted EventType = "Deleted"
-	EventFailed  EventType = "Failed"
+	EventCreated             EventType = "Created"
+	EventUpdated             EventType = "Updated"
+	EventDeleted             EventType = "Deleted"
+	EventFailed              EventType = "Failed"
+	EventPhaseChanged        EventType = "PhaseChanged"
+	EventGuardrailOverridden EventType = "GuardrailOverridden"
+	EventCostEstimated       EventType = "CostEstimated"
+	EventPlanned             EventType = "Planned"
+	EventApplyStarted        EventType = "ApplyStarted"
+	EventApplyFinished       EventType = "ApplyFinished"
+	EventDecommissionStep    EventType = "DecommissionStep"
 )
 
+// PhaseTransition is the payload of an EventPhaseChanged event
+type PhaseTransition struct {
+	From   Phase  `json:"from"`
+	To     Phase  `json:"to"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// GuardrailOverride is the payload of an EventGuardrailOverridden event
+type GuardrailOverride struct {
+	Reason     string   `json:"reason"`
+	Violations []string `json:"violations"`
+}
+
+// EstimatedCost is the payload of an EventCostEstimated event, recording the
+// estimated cost of a cluster as applied
+type EstimatedCost struct {
+	MonthlyCost float64 `json:"monthlyCost"`
+	Currency    string  `json:"currency"`
+}
+
+// DecommissionStep is the payload of an EventDecommissionStep event,
+// recording a completed step of decommissioning a cluster, or the progress
+// of one that stopped part way
+type DecommissionStep struct {
+	Step       string            `json:"step"`
+	Detail     string            `json:"detail,omitempty"`
+	Incomplete bool              `json:"incomplete,omitempty"`
+	Progress   map[string]string `json:"progress,omitempty"` // What the step has done so far, such as snapshots by volume ID
+}
+
 // ResourceID uniquely identifies a resource
 type ResourceID struct {
 	Provider string `json:"provider"`
@@ -177,3 +297,15 @@ type ResourceID struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 }
+
+// WithName returns the spec with the cluster name providers read from Config
+// set to name, leaving the original Config untouched
+func (s ClusterSpec) WithName(name string) ClusterSpec {
+	config := make(map[string]interface{}, len(s.Config)+1)
+	for key, value := range s.Config {
+		config[key] = value
+	}
+	config["name"] = name
+	s.Config = config
+	return s
+}
@@ -0,0 +1,195 @@
+// Package audit records the plans provctl generates and the applies it runs,
+// for compliance review
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/planner"
+)
+
+// Kind says what an audit record records
+type Kind string
+
+const (
+	KindPlan          Kind = "plan"           // A plan was generated
+	KindApplyStarted  Kind = "apply_started"  // An approved plan is about to be applied
+	KindApplyFinished Kind = "apply_finished" // The apply of a plan ended
+)
+
+// Outcome is how an apply ended
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Record is one audit entry. The start and end records of an apply, and the
+// plan record of the plan it applies, share a correlation ID.
+type Record struct {
+	Time          time.Time           `json:"time"`
+	Kind          Kind                `json:"kind"`
+	CorrelationID string              `json:"correlationId,omitempty"`
+	Actor         string              `json:"actor,omitempty"`
+	Source        string              `json:"source,omitempty"` // The configuration, saved plan file or delete selector planned or applied
+	PlanID        string              `json:"planId,omitempty"` // Set for saved plans
+	Summary       planner.PlanSummary `json:"summary"`
+	Actions       []Action            `json:"actions,omitempty"` // The full plan, left out of end records
+
+	// Set on KindApplyFinished records
+	Outcome         Outcome `json:"outcome,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+}
+
+// Action is a plan action as recorded, with sensitive fields redacted
+type Action struct {
+	Type       engine.ActionType      `json:"type"`
+	Resource   api.ResourceID         `json:"resource"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// NewRecord returns a record of plan stamped with the current time and the
+// correlation ID of ctx
+func NewRecord(ctx context.Context, kind Kind, plan engine.Plan) Record {
+	record := Record{
+		Time:          time.Now().UTC(),
+		Kind:          kind,
+		CorrelationID: engine.CorrelationIDFrom(ctx),
+		PlanID:        plan.ID,
+		Summary:       planner.Summarize(plan),
+		Actions:       make([]Action, 0, len(plan.Actions)),
+	}
+	for _, action := range plan.Actions {
+		record.Actions = append(record.Actions, Action{
+			Type:       action.Type,
+			Resource:   action.Resource,
+			Parameters: api.Redact(action.Parameters),
+		})
+	}
+	return record
+}
+
+// Finished returns the end record of the apply r started, at the given
+// time, with the error the apply returned
+func (r Record) Finished(err error, at time.Time) Record {
+	finished := r
+	finished.Time = at.UTC()
+	finished.Kind = KindApplyFinished
+	finished.Actions = nil
+	finished.Outcome = OutcomeSucceeded
+	finished.DurationSeconds = at.Sub(r.Time).Seconds()
+	if err != nil {
+		finished.Outcome = OutcomeFailed
+		finished.Error = err.Error()
+	}
+	return finished
+}
+
+// Writer persists audit records
+type Writer interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// FileWriter appends records to a file as JSON Lines. The file is only ever
+// appended to, and each record is synced to disk before Write returns.
+type FileWriter struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileWriter returns a writer appending to the file at path, which is
+// created on first write if it does not exist
+func NewFileWriter(path string) *FileWriter {
+	return &FileWriter{path: path}
+}
+
+// Write appends the record as one line. Records are written with a single
+// write to a file opened for appending, so records of concurrent runs do
+// not interleave.
+func (w *FileWriter) Write(ctx context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// eventTypes are the event types records are stored as
+var eventTypes = map[Kind]api.EventType{
+	KindPlan:          api.EventPlanned,
+	KindApplyStarted:  api.EventApplyStarted,
+	KindApplyFinished: api.EventApplyFinished,
+}
+
+// EventWriter records audit records in an event store, as events of a
+// "Plan" resource with the record as payload
+type EventWriter struct {
+	events engine.EventStore
+}
+
+// NewEventWriter returns a writer recording to events
+func NewEventWriter(events engine.EventStore) *EventWriter {
+	return &EventWriter{events: events}
+}
+
+// Write records the record as an event
+func (w *EventWriter) Write(ctx context.Context, record Record) error {
+	event := api.Event{
+		Timestamp:     record.Time,
+		Type:          eventTypes[record.Kind],
+		Resource:      api.ResourceID{Kind: "Plan", ID: record.PlanID, Name: record.Source},
+		Actor:         record.Actor,
+		Payload:       record,
+		CorrelationID: record.CorrelationID,
+	}
+	if err := w.events.RecordEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// multiWriter writes each record to every one of its writers
+type multiWriter []Writer
+
+// MultiWriter returns a writer writing each record to all of writers, even
+// if writing to one of them fails
+func MultiWriter(writers ...Writer) Writer {
+	return multiWriter(writers)
+}
+
+func (m multiWriter) Write(ctx context.Context, record Record) error {
+	var errs []error
+	for _, w := range m {
+		if err := w.Write(ctx, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func testPlan() engine.Plan {
+	spec := api.ClusterSpec{
+		Provider: "aws",
+		ControlPlane: api.ControlPlaneSpec{
+			Version:  "1.30",
+			Identity: &api.IdentitySpec{Type: "oidc", RoleARN: "arn:aws:iam::123456789012:role/workloads"},
+		},
+	}
+	return engine.Plan{ID: "plan-1", Actions: []engine.Action{
+		{
+			Type:       engine.ActionCreate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "web", Name: "web"},
+			Parameters: map[string]interface{}{"spec": spec},
+		},
+		{
+			Type:     engine.ActionDelete,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "old"},
+		},
+	}}
+}
+
+func TestNewRecord(t *testing.T) {
+	ctx := engine.WithCorrelationID(context.Background(), "corr-1")
+	record := NewRecord(ctx, KindApplyStarted, testPlan())
+
+	if record.CorrelationID != "corr-1" || record.PlanID != "plan-1" {
+		t.Errorf("NewRecord() = %+v, want the correlation and plan IDs", record)
+	}
+	if record.Summary.Creates != 1 || record.Summary.Deletes != 1 || !record.Summary.Destructive {
+		t.Errorf("NewRecord() summary = %+v, want one create and one delete", record.Summary)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Contains(string(line), "role/workloads") || !strings.Contains(string(line), `"version":"1.30"`) {
+		t.Errorf("record = %s, want the full plan with sensitive fields redacted", line)
+	}
+
+	finished := record.Finished(errors.New("quota exceeded"), record.Time.Add(90*time.Second))
+	if finished.Kind != KindApplyFinished || finished.Outcome != OutcomeFailed || finished.Error != "quota exceeded" {
+		t.Errorf("Finished() = %+v, want a failed end record", finished)
+	}
+	if finished.DurationSeconds != 90 || finished.Actions != nil || finished.CorrelationID != "corr-1" {
+		t.Errorf("Finished() = %+v, want the duration and correlation ID without the plan", finished)
+	}
+	if got := record.Finished(nil, time.Now()).Outcome; got != OutcomeSucceeded {
+		t.Errorf("Finished(nil) outcome = %s, want %s", got, OutcomeSucceeded)
+	}
+}
+
+func TestFileWriter_Appends(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte(`{"kind":"plan"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewFileWriter(path)
+	for _, kind := range []Kind{KindApplyStarted, KindApplyFinished} {
+		if err := w.Write(ctx, NewRecord(ctx, kind, testPlan())); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != `{"kind":"plan"}` {
+		t.Fatalf("audit log = %q, want the existing record kept and two appended", data)
+	}
+	for _, line := range lines[1:] {
+		var record Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("line %q is not a JSON record: %v", line, err)
+		}
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	ctx := engine.WithCorrelationID(context.Background(), "corr-1")
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	record := NewRecord(ctx, KindPlan, testPlan())
+	record.Source = "clusters/"
+	record.Actor = "alice"
+	if err := MultiWriter(NewEventWriter(sm.Events())).Write(ctx, record); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	events, err := sm.Events().GetEvents(ctx, api.ResourceID{Kind: "Plan", ID: "plan-1", Name: "clusters/"})
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Type != api.EventPlanned || events[0].Actor != "alice" || events[0].CorrelationID != "corr-1" {
+		t.Errorf("events = %+v, want one Planned event of the record", events)
+	}
+}
@@ -0,0 +1,80 @@
+// Package autoscaler generates cluster-autoscaler configuration from a
+// cluster's spec
+package autoscaler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// PriorityConfigMapName is the ConfigMap the priority expander of
+// cluster-autoscaler reads. The autoscaler must run with --expander=priority
+// for it to take effect.
+const PriorityConfigMapName = "cluster-autoscaler-priority-expander"
+
+// Priority is the node groups sharing one priority
+type Priority struct {
+	Priority int
+	Pools    []string // Pool names, sorted
+	Patterns []string // Regular expressions matching the pools' node groups
+}
+
+// Priorities returns the priorities of a cluster's worker pools, highest
+// first. The autoscaler scales the node groups of the highest priority that
+// can fit pending pods, choosing among pools of equal priority at random.
+// Pools without a priority rank at 0.
+func Priorities(cluster *api.Cluster) []Priority {
+	byPriority := make(map[int]*Priority)
+	for _, pool := range cluster.Spec.WorkerPools {
+		p, ok := byPriority[pool.Priority]
+		if !ok {
+			p = &Priority{Priority: pool.Priority}
+			byPriority[pool.Priority] = p
+		}
+		p.Pools = append(p.Pools, pool.Name)
+	}
+
+	priorities := make([]Priority, 0, len(byPriority))
+	for _, p := range byPriority {
+		sort.Strings(p.Pools)
+		for _, pool := range p.Pools {
+			p.Patterns = append(p.Patterns, nodeGroupPattern(cluster, pool))
+		}
+		priorities = append(priorities, *p)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i].Priority > priorities[j].Priority })
+	return priorities
+}
+
+// nodeGroupPattern matches the name cluster-autoscaler knows a pool's node
+// group by: the Auto Scaling group on AWS and the scale set on Azure, which
+// the providers name alike. It is anchored, so that pool spot does not match
+// the group of pool spot-a.
+func nodeGroupPattern(cluster *api.Cluster, pool string) string {
+	return "^" + regexp.QuoteMeta(api.NodeGroupName(cluster.ID, pool)) + "$"
+}
+
+// PriorityConfigMap renders the priority expander ConfigMap of a cluster as
+// a manifest for kubectl apply
+func PriorityConfigMap(cluster *api.Cluster) string {
+	var b strings.Builder
+	b.WriteString("apiVersion: v1\n")
+	b.WriteString("kind: ConfigMap\n")
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", PriorityConfigMapName)
+	b.WriteString("  namespace: kube-system\n")
+	b.WriteString("data:\n")
+	b.WriteString("  priorities: |-\n")
+	for _, p := range Priorities(cluster) {
+		fmt.Fprintf(&b, "    %d:\n", p.Priority)
+		for _, pattern := range p.Patterns {
+			// Single-quoted YAML escapes nothing but the quote itself
+			fmt.Fprintf(&b, "      - '%s'\n", strings.ReplaceAll(pattern, "'", "''"))
+		}
+	}
+	return b.String()
+}
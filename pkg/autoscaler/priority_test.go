@@ -0,0 +1,79 @@
+package autoscaler
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestPriorityConfigMap(t *testing.T) {
+	cluster := &api.Cluster{
+		ID: "c-1",
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+			WorkerPools: []api.WorkerPoolSpec{
+				{Name: "on-demand"},
+				{Name: "spot", Priority: 50},
+				{Name: "reserved", Priority: 100},
+				{Name: "spot-arm", Priority: 50},
+			},
+		},
+	}
+
+	want := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cluster-autoscaler-priority-expander
+  namespace: kube-system
+data:
+  priorities: |-
+    100:
+      - '^c-1-reserved$'
+    50:
+      - '^c-1-spot$'
+      - '^c-1-spot-arm$'
+    0:
+      - '^c-1-on-demand$'
+`
+	if got := PriorityConfigMap(cluster); got != want {
+		t.Errorf("PriorityConfigMap() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNodeGroupPattern(t *testing.T) {
+	for _, provider := range []string{"aws", "azure"} {
+		cluster := &api.Cluster{ID: "c-1", Spec: api.ClusterSpec{Provider: provider}}
+		pattern := regexp.MustCompile(nodeGroupPattern(cluster, "spot"))
+		if name := api.NodeGroupName(cluster.ID, "spot"); !pattern.MatchString(name) {
+			t.Errorf("%s: %s does not match the pool's node group %q", provider, pattern, name)
+		}
+		for _, other := range []string{api.NodeGroupName("c-1", "spot-arm"), api.NodeGroupName("c-2", "spot")} {
+			if pattern.MatchString(other) {
+				t.Errorf("%s: %s matches %q", provider, pattern, other)
+			}
+		}
+	}
+
+	// Cluster IDs are matched literally
+	cluster := &api.Cluster{ID: "c.1", Spec: api.ClusterSpec{Provider: "aws"}}
+	if regexp.MustCompile(nodeGroupPattern(cluster, "spot")).MatchString("cx1-spot") {
+		t.Error("nodeGroupPattern() treats the cluster ID as a pattern")
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	cluster := &api.Cluster{ID: "c-1", Spec: api.ClusterSpec{WorkerPools: []api.WorkerPoolSpec{
+		{Name: "b", Priority: 10}, {Name: "a", Priority: 10}, {Name: "c", Priority: 30},
+	}}}
+
+	var got [][]string
+	for _, p := range Priorities(cluster) {
+		got = append(got, p.Pools)
+	}
+	if want := [][]string{{"c"}, {"a", "b"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Priorities() pools = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+// Package color wraps terminal output in ANSI color codes
+package color
+
+import (
+	"io"
+	"os"
+)
+
+// Color is an ANSI foreground color escape sequence
+type Color string
+
+const (
+	Green  Color = "\033[32m" // Additions
+	Yellow Color = "\033[33m" // Modifications
+	Red    Color = "\033[31m" // Deletions
+
+	reset = "\033[0m"
+)
+
+// Enabled reports whether output written to w should be colored: w must be a
+// terminal and the NO_COLOR environment variable (https://no-color.org) must
+// be unset or empty
+func Enabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Wrap returns s in color c when enabled is true, and s unchanged otherwise
+func Wrap(enabled bool, c Color, s string) string {
+	if !enabled || s == "" {
+		return s
+	}
+	return string(c) + s + reset
+}
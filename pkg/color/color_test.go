@@ -0,0 +1,38 @@
+package color
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	if Enabled(&bytes.Buffer{}) {
+		t.Error("Enabled() = true for a buffer, want false")
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if Enabled(f) {
+		t.Error("Enabled() = true for a regular file, want false")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if Enabled(os.Stdout) {
+		t.Error("Enabled() = true with NO_COLOR set, want false")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if got := Wrap(true, Green, "+ create"); got != "\033[32m+ create\033[0m" {
+		t.Errorf("Wrap(true) = %q", got)
+	}
+	if got := Wrap(false, Green, "+ create"); got != "+ create" {
+		t.Errorf("Wrap(false) = %q, want text unchanged", got)
+	}
+}
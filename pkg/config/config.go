@@ -0,0 +1,374 @@
+// Package config loads desired cluster configuration from HCL files
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/validation"
+)
+
+// File is the decoded configuration: the cluster blocks of every loaded file
+// with variables and locals already substituted
+type File struct {
+	Clusters    []ClusterBlock
+	Guardrails  validation.Guardrails  // Limits every cluster must stay within
+	DefaultTags api.DefaultTags        // Tags every cluster gets unless it sets them
+	Naming      validation.NamingRules // Cluster name rules replacing the defaults
+
+	// MaintenanceWindow restricts when apply may change infrastructure
+	MaintenanceWindow MaintenanceWindow
+}
+
+// MaintenanceWindow is the maintenance_window block. Without one, or with
+// an empty schedule, changes may be applied at any time.
+type MaintenanceWindow struct {
+	Schedule string `hcl:"schedule"` // Such as "Mon-Fri 22:00-02:00 Europe/Berlin"; see engine.ParseMaintenanceWindow
+}
+
+// Window parses the schedule, returning nil when there is none
+func (w MaintenanceWindow) Window() (*engine.MaintenanceWindow, error) {
+	if w.Schedule == "" {
+		return nil, nil
+	}
+	return engine.ParseMaintenanceWindow(w.Schedule)
+}
+
+// ClusterBlock declares a single cluster
+type ClusterBlock struct {
+	Name string
+	Spec api.ClusterSpec
+
+	// IgnoreChanges are spec fields managed outside provctl, exempt from drift
+	IgnoreChanges api.IgnoreRules
+
+	// DeclRange is where the block is declared, used to attribute errors
+	DeclRange hcl.Range
+
+	// set records the mergeable attributes the block sets; see mergeStored
+	set map[string]bool
+}
+
+// Options control how configuration is loaded
+type Options struct {
+	Recursive bool              // Include .hcl files in subdirectories of a config directory
+	VarFiles  []string          // Files of name = value assignments, applied in order
+	Vars      map[string]string // Raw values from the command line, applied after VarFiles
+}
+
+// clusterBody separates the attributes of a cluster block that describe the
+// resource rather than its spec
+type clusterBody struct {
+	IgnoreChanges []string `hcl:"ignore_changes,optional"`
+	Spec          hcl.Body `hcl:",remain"`
+}
+
+var fileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "locals"},
+		{Type: "cluster", LabelNames: []string{"name"}},
+		{Type: "guardrails"},
+		{Type: "default_tags"},
+		{Type: "naming"},
+		{Type: "maintenance_window"},
+	},
+}
+
+// LoadFile parses and decodes an HCL configuration file
+func LoadFile(path string) (*File, error) {
+	return load([]string{path}, Options{})
+}
+
+// Load reads configuration from a file or a directory. For a directory, every
+// .hcl file in it is decoded and merged in lexical filename order; with
+// Recursive set, subdirectories are included too. Variables and locals
+// declared in any file are visible to clusters in every file. Errors from all
+// files are reported together, each attributed to its source file and line.
+func Load(path string, opts Options) (*File, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if !info.IsDir() {
+		return load([]string{path}, opts)
+	}
+
+	paths, err := configFiles(path, opts.Recursive)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .hcl files found in %s", path)
+	}
+
+	return load(paths, opts)
+}
+
+// configFiles lists the .hcl files under dir in lexical order
+func configFiles(dir string, recursive bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) == ".hcl" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config files in %s: %w", dir, err)
+	}
+
+	// WalkDir visits entries in lexical order, so paths are already sorted
+	return paths, nil
+}
+
+// load parses every file first so that variables and locals can be resolved
+// across files before any cluster block is decoded
+func load(paths []string, opts Options) (*File, error) {
+	parser := hclparse.NewParser()
+
+	var diags hcl.Diagnostics
+	var variableBlocks, localsBlocks, clusterBlocks, guardrailsBlocks, defaultTagsBlocks, namingBlocks, windowBlocks []*hcl.Block
+	for _, path := range paths {
+		parsed, parseDiags := parser.ParseHCLFile(path)
+		diags = append(diags, parseDiags...)
+		if parseDiags.HasErrors() {
+			continue
+		}
+
+		content, contentDiags := parsed.Body.Content(fileSchema)
+		diags = append(diags, contentDiags...)
+		for _, block := range content.Blocks {
+			switch block.Type {
+			case "variable":
+				variableBlocks = append(variableBlocks, block)
+			case "locals":
+				localsBlocks = append(localsBlocks, block)
+			case "cluster":
+				clusterBlocks = append(clusterBlocks, block)
+			case "guardrails":
+				guardrailsBlocks = append(guardrailsBlocks, block)
+			case "default_tags":
+				defaultTagsBlocks = append(defaultTagsBlocks, block)
+			case "naming":
+				namingBlocks = append(namingBlocks, block)
+			case "maintenance_window":
+				windowBlocks = append(windowBlocks, block)
+			}
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+
+	variables, diags := decodeVariables(parser, variableBlocks, opts)
+	if diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+
+	ctx, diags := evalContext(variables, localsBlocks)
+	if diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+
+	file := &File{}
+	for _, block := range clusterBlocks {
+		// Undefined references would otherwise surface as confusing
+		// "unsupported attribute" errors from decoding
+		refDiags := checkTraversals(bodyTraversals(block.Body),
+			declaredNames(ctx, "var"), declaredNames(ctx, "local"))
+		diags = append(diags, refDiags...)
+		if refDiags.HasErrors() {
+			continue
+		}
+
+		var body clusterBody
+		bodyDiags := gohcl.DecodeBody(block.Body, ctx, &body)
+		diags = append(diags, bodyDiags...)
+		if bodyDiags.HasErrors() {
+			continue
+		}
+		var spec api.ClusterSpec
+		specDiags := gohcl.DecodeBody(body.Spec, ctx, &spec)
+		diags = append(diags, specDiags...)
+		if !specDiags.HasErrors() && spec.Network.VPCCIDR == "" && spec.Network.ExistingNetworkID() == "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Detail:   "network needs a vpc_cidr to create it from, or an existing_vpc_id or existing_vnet_id to provision into",
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+
+		rules, err := api.ParseIgnoreRules(body.IgnoreChanges)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid ignore_changes",
+				Detail:   err.Error(),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+
+		cluster := ClusterBlock{
+			Name:          block.Labels[0],
+			Spec:          spec,
+			IgnoreChanges: rules,
+			DeclRange:     block.DefRange,
+			set:           attributePresence(block.Body),
+		}
+		diags = append(diags, cluster.resolvePools(body.Spec)...)
+		file.Clusters = append(file.Clusters, cluster)
+	}
+	diags = append(diags, file.checkDuplicates()...)
+	diags = append(diags, decodeSingleBlock("guardrails", guardrailsBlocks, ctx, &file.Guardrails)...)
+	diags = append(diags, decodeSingleBlock("default_tags", defaultTagsBlocks, ctx, &file.DefaultTags)...)
+	diags = append(diags, decodeSingleBlock("naming", namingBlocks, ctx, &file.Naming)...)
+	if err := file.Naming.Check(); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid naming rule",
+			Detail:   err.Error(),
+			Subject:  namingBlocks[0].DefRange.Ptr(),
+		})
+	}
+	diags = append(diags, decodeSingleBlock("maintenance_window", windowBlocks, ctx, &file.MaintenanceWindow)...)
+	if _, err := file.MaintenanceWindow.Window(); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid maintenance window",
+			Detail:   err.Error(),
+			Subject:  windowBlocks[0].DefRange.Ptr(),
+		})
+	}
+	if diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+
+	return file, nil
+}
+
+func (f *File) checkDuplicates() hcl.Diagnostics {
+	seen := make(map[string]hcl.Range)
+	var diags hcl.Diagnostics
+	for _, cluster := range f.Clusters {
+		if first, exists := seen[cluster.Name]; exists {
+			declRange := cluster.DeclRange
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate cluster",
+				Detail:   fmt.Sprintf("duplicate cluster %q, first defined at %s", cluster.Name, first),
+				Subject:  &declRange,
+			})
+			continue
+		}
+		seen[cluster.Name] = cluster.DeclRange
+	}
+	return diags
+}
+
+// decodeSingleBlock decodes a top-level block such as guardrails into val;
+// at most one of its kind may be declared across all files
+func decodeSingleBlock(kind string, blocks []*hcl.Block, ctx *hcl.EvalContext, val interface{}) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for i, block := range blocks {
+		if i > 0 {
+			defRange := block.DefRange
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate " + kind,
+				Detail:   fmt.Sprintf("only one %s block is allowed, first defined at %s", kind, blocks[0].DefRange),
+				Subject:  &defRange,
+			})
+			continue
+		}
+
+		refDiags := checkTraversals(bodyTraversals(block.Body),
+			declaredNames(ctx, "var"), declaredNames(ctx, "local"))
+		diags = append(diags, refDiags...)
+		if refDiags.HasErrors() {
+			continue
+		}
+		diags = append(diags, gohcl.DecodeBody(block.Body, ctx, val)...)
+	}
+	return diags
+}
+
+// diagsError joins error diagnostics so that every one of them is reported;
+// hcl.Diagnostics.Error only shows the first
+func diagsError(diags hcl.Diagnostics) error {
+	var errs []error
+	for _, diag := range diags.Errs() {
+		errs = append(errs, diag)
+	}
+	return errors.Join(errs...)
+}
+
+// DesiredState converts the configuration into engine state. Clusters are
+// matched by name against stored state so existing clusters keep their IDs;
+// new clusters use their name as a placeholder ID until they are created.
+// Optional attributes an existing cluster's block omits keep their stored
+// values, so a partial configuration is merged onto state rather than
+// resetting them.
+func (f *File) DesiredState(stored engine.State) engine.State {
+	idsByName := make(map[string]string, len(stored.Clusters))
+	for id, cluster := range stored.Clusters {
+		idsByName[cluster.Metadata.Name] = id
+	}
+
+	desired := engine.State{
+		Clusters:  make(map[string]*api.Cluster, len(f.Clusters)),
+		NodePools: make(map[string]*api.NodePool),
+	}
+
+	for _, block := range f.Clusters {
+		id, exists := idsByName[block.Name]
+		if !exists {
+			id = block.Name
+		}
+
+		spec := block.Spec
+		metadata := api.ResourceMetadata{Name: block.Name}
+		if exists {
+			spec = block.mergeStored(spec, stored.Clusters[id].Spec)
+			// Keep when the cluster was created and last changed
+			metadata.CreatedAt = stored.Clusters[id].Metadata.CreatedAt
+			metadata.UpdatedAt = stored.Clusters[id].Metadata.UpdatedAt
+		}
+		if block.sets("tags") || !exists {
+			spec = spec.WithDefaultTags(f.DefaultTags.Tags)
+		} else if len(f.DefaultTags.Tags) > 0 {
+			// Stored tags stand in for omitted ones, but a changed default
+			// still has to reach the cluster
+			spec.Tags = api.MergeTags(spec.Tags, f.DefaultTags.Tags)
+		}
+		metadata.SetIgnoreChanges(block.IgnoreChanges)
+		spec = spec.WithName(block.Name)
+
+		desired.Clusters[id] = &api.Cluster{
+			ID:       id,
+			Metadata: metadata,
+			Spec:     spec,
+		}
+	}
+
+	return desired
+}
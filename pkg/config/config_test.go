@@ -0,0 +1,842 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/validation"
+)
+
+const testConfig = `
+cluster "production" {
+  provider = "aws"
+  region   = "us-west-2"
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a", "us-west-2b"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.28"
+  }
+
+  worker_pools "general" {
+    instance_type = "m5.large"
+    min_size      = 1
+    max_size      = 5
+  }
+
+  tags = {
+    team = "platform"
+  }
+}
+
+cluster "staging" {
+  provider = "azure"
+  region   = "eastus"
+
+  network {
+    vpc_cidr           = "10.1.0.0/16"
+    availability_zones = ["1"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.29"
+  }
+}
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "clusters.hcl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if len(file.Clusters) != 2 {
+		t.Fatalf("LoadFile() got %d clusters, want 2", len(file.Clusters))
+	}
+
+	prod := file.Clusters[0]
+	if prod.Name != "production" || prod.Spec.ControlPlane.Type != api.ControlPlaneManaged {
+		t.Errorf("cluster = %+v, want managed production", prod)
+	}
+	if len(prod.Spec.WorkerPools) != 1 || prod.Spec.WorkerPools[0].MaxSize != 5 {
+		t.Errorf("worker pools = %+v, want general with max 5", prod.Spec.WorkerPools)
+	}
+	if prod.Spec.Tags["team"] != "platform" {
+		t.Errorf("tags = %v, want team=platform", prod.Spec.Tags)
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "syntax error", content: `cluster "a" {`},
+		{name: "missing required attribute", content: `cluster "a" { provider = "aws" }`},
+		{name: "duplicate cluster", content: testConfig + `
+cluster "staging" {
+  provider = "aws"
+  region   = "us-east-1"
+  network {
+    vpc_cidr           = "10.2.0.0/16"
+    availability_zones = ["us-east-1a"]
+  }
+  control_plane {
+    type    = "managed"
+    version = "1.29"
+  }
+}
+`},
+		{name: "duplicate guardrails", content: testConfig + `
+guardrails {
+  max_total_nodes = 50
+}
+guardrails {
+  max_pool_nodes = 20
+}
+`},
+		{name: "duplicate default_tags", content: testConfig + `
+default_tags {
+  tags = { owner = "platform" }
+}
+default_tags {
+  tags = { owner = "data" }
+}
+`},
+		{name: "invalid naming rule", content: testConfig + `
+naming {
+  cluster_name = { aws = "^[a-z(" }
+}
+`},
+		{name: "invalid maintenance window", content: testConfig + `
+maintenance_window {
+  schedule = "Weekdays 22:00-02:00"
+}
+`},
+		{name: "unknown guardrail", content: testConfig + `
+guardrails {
+  max_nodes = 50
+}
+`},
+		{name: "pool without instance type", content: fmt.Sprintf(poolDefaultsTemplate, `min_size = 1`, `max_size = 3`)},
+		{name: "invalid ignore rule", content: fmt.Sprintf(ignoreTemplate, `["workerPools..desiredSize"]`)},
+		{name: "network without CIDR or existing VPC", content: `
+cluster "a" {
+  provider = "aws"
+  region   = "us-east-1"
+  network {
+    availability_zones = ["us-east-1a"]
+  }
+  control_plane {
+    type    = "managed"
+    version = "1.29"
+  }
+}
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadFile(writeConfig(t, tt.content)); err == nil {
+				t.Error("LoadFile() expected error")
+			}
+		})
+	}
+}
+
+func TestLoadFile_Guardrails(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Guardrails.Enabled() {
+		t.Errorf("Guardrails = %+v, want none when no block is declared", file.Guardrails)
+	}
+
+	file, err = LoadFile(writeConfig(t, testConfig+`
+variable "node_limit" {
+  default = 100
+}
+
+guardrails {
+  max_total_nodes  = var.node_limit
+  max_pool_nodes   = 40
+  max_monthly_cost = 25000
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	want := validation.Guardrails{MaxTotalNodes: 100, MaxPoolNodes: 40, MaxMonthlyCost: 25000}
+	if file.Guardrails != want {
+		t.Errorf("Guardrails = %+v, want %+v", file.Guardrails, want)
+	}
+}
+
+func TestLoadFile_MaintenanceWindow(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if window, err := file.MaintenanceWindow.Window(); err != nil || window != nil {
+		t.Errorf("Window() = %+v, %v, want no window when no block is declared", window, err)
+	}
+
+	file, err = LoadFile(writeConfig(t, testConfig+`
+maintenance_window {
+  schedule = "Sat,Sun 02:00-06:00 Europe/Berlin"
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	window, err := file.MaintenanceWindow.Window()
+	if err != nil {
+		t.Fatalf("Window() error = %v", err)
+	}
+	saturday := time.Date(2024, 6, 1, 3, 0, 0, 0, window.Location)
+	if !window.InWindow(saturday) || window.InWindow(saturday.Add(24*time.Hour*2)) {
+		t.Errorf("Window() = %+v, want weekend nights in Berlin", window)
+	}
+}
+
+func TestLoadFile_APIServer(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, `
+cluster "lab" {
+  provider = "aws"
+  region   = "us-west-2"
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a"]
+  }
+
+  control_plane {
+    type    = "self-managed"
+    version = "1.30"
+
+    feature_gates = {
+      WatchList = true
+      KMSv1     = false
+    }
+    api_server_args = {
+      "audit-log-maxage" = "30"
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	cp := file.Clusters[0].Spec.ControlPlane
+	if !reflect.DeepEqual(cp.FeatureGates, map[string]bool{"WatchList": true, "KMSv1": false}) {
+		t.Errorf("FeatureGates = %v", cp.FeatureGates)
+	}
+	if !reflect.DeepEqual(cp.APIServerArgs, map[string]string{"audit-log-maxage": "30"}) {
+		t.Errorf("APIServerArgs = %v", cp.APIServerArgs)
+	}
+}
+
+func TestLoadFile_Secrets(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, `
+cluster "lab" {
+  provider = "aws"
+  region   = "us-west-2"
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a"]
+  }
+
+  control_plane {
+    type    = "self-managed"
+    version = "1.30"
+  }
+
+  secret "registry-token" {
+    env = "REGISTRY_TOKEN"
+  }
+  secret "license-key" {
+    file = "secrets.json"
+    key  = "license"
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	want := []api.SecretRef{
+		{Name: "registry-token", Env: "REGISTRY_TOKEN"},
+		{Name: "license-key", File: "secrets.json", Key: "license"},
+	}
+	if got := file.Clusters[0].Spec.Secrets; !reflect.DeepEqual(got, want) {
+		t.Errorf("Secrets = %+v, want %+v", got, want)
+	}
+}
+
+func TestFile_DesiredStateDefaultTags(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, testConfig+`
+default_tags {
+  tags = {
+    team  = "shared"
+    owner = "infra"
+  }
+  required = ["owner"]
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !reflect.DeepEqual(file.DefaultTags.Required, []string{"owner"}) {
+		t.Errorf("DefaultTags.Required = %v, want [owner]", file.DefaultTags.Required)
+	}
+
+	stored := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-xyz": {
+				ID:       "cluster-xyz",
+				Metadata: api.ResourceMetadata{Name: "staging"},
+				Spec:     api.ClusterSpec{Tags: map[string]string{"owner": "old", "cost-center": "42"}},
+			},
+		},
+	}
+	desired := file.DesiredState(stored)
+
+	// Tags set on the cluster override the defaults
+	want := map[string]string{"team": "platform", "owner": "infra"}
+	if got := desired.Clusters["production"].Spec.Tags; !reflect.DeepEqual(got, want) {
+		t.Errorf("production tags = %v, want %v", got, want)
+	}
+
+	// Omitted tags keep the stored ones, but defaults replace stale values
+	want = map[string]string{"team": "shared", "owner": "infra", "cost-center": "42"}
+	if got := desired.Clusters["cluster-xyz"].Spec.Tags; !reflect.DeepEqual(got, want) {
+		t.Errorf("staging tags = %v, want %v", got, want)
+	}
+
+	// The decoded configuration itself is unchanged
+	if tags := file.Clusters[0].Spec.Tags; len(tags) != 1 {
+		t.Errorf("decoded production tags = %v, want only its own", tags)
+	}
+}
+
+func TestFile_DesiredState(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, testConfig))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	stored := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-abc": {ID: "cluster-abc", Metadata: api.ResourceMetadata{Name: "production"}},
+		},
+	}
+
+	desired := file.DesiredState(stored)
+
+	prod, exists := desired.Clusters["cluster-abc"]
+	if !exists {
+		t.Fatalf("DesiredState() should reuse stored ID for production, got %v", desired.Clusters)
+	}
+	if prod.Spec.Config["name"] != "production" {
+		t.Errorf("Config[name] = %v, want production", prod.Spec.Config["name"])
+	}
+
+	if _, exists := desired.Clusters["staging"]; !exists {
+		t.Errorf("DesiredState() should key new clusters by name, got %v", desired.Clusters)
+	}
+}
+
+const ignoreTemplate = `
+cluster "production" {
+  provider       = "aws"
+  region         = "us-west-2"
+  ignore_changes = %s
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.28"
+  }
+}
+`
+
+func TestFile_DesiredStateIgnoreChanges(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, fmt.Sprintf(ignoreTemplate, `["workerPools.gpu.desiredSize", "tags"]`)))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Clusters[0].Spec.Provider != "aws" {
+		t.Errorf("Provider = %q, want the spec decoded alongside ignore_changes", file.Clusters[0].Spec.Provider)
+	}
+
+	desired := file.DesiredState(engine.State{})
+	rules := desired.Clusters["production"].Metadata.IgnoreChanges()
+	if want := (api.IgnoreRules{"workerPools.gpu.desiredSize", "tags"}); !reflect.DeepEqual(rules, want) {
+		t.Errorf("IgnoreChanges() = %v, want %v", rules, want)
+	}
+}
+
+const poolDefaultsTemplate = `
+cluster "production" {
+  provider = "aws"
+  region   = "us-west-2"
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.28"
+  }
+
+  pool_defaults {
+    %s
+  }
+
+  worker_pools "general" {
+    %s
+  }
+}
+`
+
+func TestLoadFile_PoolDefaults(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, fmt.Sprintf(poolDefaultsTemplate, `
+    instance_type = "m5.large"
+    min_size      = 1
+    max_size      = 10
+    labels        = { team = "platform" }`, `
+    min_size = 0
+    labels   = { tier = "general" }`)))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	pool := file.Clusters[0].Spec.WorkerPools[0]
+	want := api.WorkerPoolSpec{
+		Name:         "general",
+		InstanceType: "m5.large",
+		MinSize:      0,
+		MaxSize:      10,
+		Labels:       map[string]string{"team": "platform", "tier": "general"},
+	}
+	if !reflect.DeepEqual(pool, want) {
+		t.Errorf("pool = %+v, want %+v", pool, want)
+	}
+
+	// Default labels replace stored ones like labels written on the pool
+	stored := engine.State{Clusters: map[string]*api.Cluster{"cluster-abc": {
+		ID:       "cluster-abc",
+		Metadata: api.ResourceMetadata{Name: "production"},
+		Spec: api.ClusterSpec{WorkerPools: []api.WorkerPoolSpec{
+			{Name: "general", Labels: map[string]string{"team": "legacy"}},
+		}},
+	}}}
+	labels := file.DesiredState(stored).Clusters["cluster-abc"].Spec.WorkerPools[0].Labels
+	if !reflect.DeepEqual(labels, want.Labels) {
+		t.Errorf("merged labels = %v, want %v", labels, want.Labels)
+	}
+}
+
+const clusterTemplate = `
+cluster %q {
+  provider = "aws"
+  region   = "us-east-1"
+
+  network {
+    vpc_cidr           = "10.2.0.0/16"
+    availability_zones = ["us-east-1a"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.29"
+  }
+}
+`
+
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	return dir
+}
+
+func clusterNames(file *File) []string {
+	var names []string
+	for _, cluster := range file.Clusters {
+		names = append(names, cluster.Name)
+	}
+	return names
+}
+
+func TestLoad_Directory(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"b.hcl":          fmt.Sprintf(clusterTemplate, "beta"),
+		"a.hcl":          fmt.Sprintf(clusterTemplate, "alpha"),
+		"notes.txt":      "not configuration",
+		"nested/c.hcl":   fmt.Sprintf(clusterTemplate, "gamma"),
+		"nested/d/d.hcl": fmt.Sprintf(clusterTemplate, "delta"),
+	})
+
+	file, err := Load(dir, Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := strings.Join(clusterNames(file), ","); got != "alpha,beta" {
+		t.Errorf("Load() clusters = %s, want alpha,beta", got)
+	}
+
+	file, err = Load(dir, Options{Recursive: true})
+	if err != nil {
+		t.Fatalf("Load(recursive) error = %v", err)
+	}
+	if got := strings.Join(clusterNames(file), ","); got != "alpha,beta,gamma,delta" {
+		t.Errorf("Load(recursive) clusters = %s, want alpha,beta,gamma,delta", got)
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	file, err := Load(writeConfig(t, testConfig), Options{})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(file.Clusters) != 2 {
+		t.Errorf("Load() got %d clusters, want 2", len(file.Clusters))
+	}
+}
+
+func TestLoad_DirectoryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "duplicate across files",
+			files: map[string]string{
+				"a.hcl": fmt.Sprintf(clusterTemplate, "prod"),
+				"b.hcl": "\n" + fmt.Sprintf(clusterTemplate, "prod"),
+			},
+			want: []string{`duplicate cluster "prod"`, "b.hcl:3", "a.hcl:2"},
+		},
+		{
+			name: "errors in several files",
+			files: map[string]string{
+				"a.hcl": `cluster "a" {`,
+				"b.hcl": `clusters "b" {}`,
+			},
+			want: []string{"a.hcl:1", "b.hcl:1"},
+		},
+		{
+			name:  "no config files",
+			files: map[string]string{"README.md": "docs"},
+			want:  []string{"no .hcl files"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeDir(t, tt.files), Options{})
+			if err == nil {
+				t.Fatal("Load() expected error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+const variablesConfig = `
+variable "region" {
+  type    = string
+  default = "us-west-2"
+}
+
+variable "version" {}
+
+variable "zones" {
+  type    = list(string)
+  default = ["us-west-2a"]
+}
+
+variable "max_size" {
+  type    = number
+  default = 3
+}
+
+locals {
+  cidr = "10.${local.octet}.0.0/16"
+  octet = 4
+}
+
+cluster "prod" {
+  provider = "aws"
+  region   = var.region
+
+  network {
+    vpc_cidr           = local.cidr
+    availability_zones = var.zones
+  }
+
+  control_plane {
+    type    = "managed"
+    version = var.version
+  }
+
+  worker_pools "general" {
+    instance_type = "m5.large"
+    min_size      = 1
+    max_size      = var.max_size
+  }
+}
+`
+
+func TestLoad_Variables(t *testing.T) {
+	dir := writeDir(t, map[string]string{
+		"clusters.hcl":    variablesConfig,
+		"prod.vars":       "region = \"eu-west-1\"\nzones = [\"eu-west-1a\", \"eu-west-1b\"]\n",
+		"override.vars":   "max_size = 10\n",
+		"ignored/foo.txt": "",
+	})
+
+	file, err := Load(dir, Options{
+		VarFiles: []string{filepath.Join(dir, "prod.vars"), filepath.Join(dir, "override.vars")},
+		Vars:     map[string]string{"version": "1.30", "max_size": "7"},
+	})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	spec := file.Clusters[0].Spec
+	if spec.Region != "eu-west-1" {
+		t.Errorf("region = %s, want eu-west-1 from var file", spec.Region)
+	}
+	if strings.Join(spec.Network.AvailabilityZones, ",") != "eu-west-1a,eu-west-1b" {
+		t.Errorf("zones = %v, want list from var file", spec.Network.AvailabilityZones)
+	}
+	if spec.ControlPlane.Version != "1.30" {
+		t.Errorf("version = %s, want 1.30 kept as a string", spec.ControlPlane.Version)
+	}
+	if spec.WorkerPools[0].MaxSize != 7 {
+		t.Errorf("max_size = %d, want 7 from --var overriding var files", spec.WorkerPools[0].MaxSize)
+	}
+	if spec.Network.VPCCIDR != "10.4.0.0/16" {
+		t.Errorf("vpc_cidr = %s, want 10.4.0.0/16 from locals", spec.Network.VPCCIDR)
+	}
+}
+
+func TestLoad_VariableErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		vars    map[string]string
+		want    []string
+	}{
+		{
+			name:    "undefined variable",
+			content: strings.Replace(fmt.Sprintf(clusterTemplate, "prod"), `"us-east-1"`, "var.region", 1),
+			want:    []string{"clusters.hcl:4", `no variable named "region"`},
+		},
+		{
+			name:    "undefined local",
+			content: strings.Replace(fmt.Sprintf(clusterTemplate, "prod"), `"us-east-1"`, "local.region", 1),
+			want:    []string{"clusters.hcl:4", `no local value named "region"`},
+		},
+		{
+			name:    "missing required value",
+			content: "variable \"region\" {}\n",
+			want:    []string{"clusters.hcl:1", `variable "region" has no default`},
+		},
+		{
+			name:    "undeclared command line variable",
+			content: fmt.Sprintf(clusterTemplate, "prod"),
+			vars:    map[string]string{"region": "us-east-2"},
+			want:    []string{`variable "region", which is not declared`},
+		},
+		{
+			name:    "wrong type",
+			content: "variable \"size\" {\n  type = number\n}\n",
+			vars:    map[string]string{"size": "large"},
+			want:    []string{"size"},
+		},
+		{
+			name:    "cyclic locals",
+			content: "locals {\n  a = local.b\n  b = local.a\n}\n",
+			want:    []string{"clusters.hcl:2", "clusters.hcl:3", "depends on itself"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content), Options{Vars: tt.vars})
+			if err == nil {
+				t.Fatal("Load() expected error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFile_DesiredStateMergesOmittedAttributes(t *testing.T) {
+	file, err := LoadFile(writeConfig(t, `
+cluster "production" {
+  provider = "aws"
+  region   = "us-west-2"
+
+  network {
+    vpc_cidr           = "10.0.0.0/16"
+    availability_zones = ["us-west-2a"]
+  }
+
+  control_plane {
+    type    = "managed"
+    version = "1.28"
+  }
+
+  worker_pools "general" {
+    instance_type = "m5.large"
+    min_size      = 1
+    max_size      = 10
+    desired_size  = 6
+  }
+
+  worker_pools "batch" {
+    instance_type = "c5.xlarge"
+    min_size      = 0
+    max_size      = 4
+    labels        = {}
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	stored := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-abc": {
+				ID:       "cluster-abc",
+				Metadata: api.ResourceMetadata{Name: "production"},
+				Spec: api.ClusterSpec{
+					Tags: map[string]string{"team": "platform"},
+					WorkerPools: []api.WorkerPoolSpec{
+						{Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 10, DesiredSize: 3,
+							Labels: map[string]string{"workload": "web"}, Tags: map[string]string{"cost-center": "42"}},
+						{Name: "batch", InstanceType: "c5.xlarge", MaxSize: 4, DesiredSize: 2,
+							Labels: map[string]string{"workload": "batch"}},
+					},
+				},
+			},
+		},
+	}
+
+	spec := file.DesiredState(stored).Clusters["cluster-abc"].Spec
+	pools := make(map[string]api.WorkerPoolSpec)
+	for _, pool := range spec.WorkerPools {
+		pools[pool.Name] = pool
+	}
+
+	// Set attributes win, omitted ones keep their stored value
+	general := pools["general"]
+	if general.DesiredSize != 6 {
+		t.Errorf("general DesiredSize = %d, want 6 from config", general.DesiredSize)
+	}
+	if general.Labels["workload"] != "web" || general.Tags["cost-center"] != "42" {
+		t.Errorf("general labels = %v, tags = %v, want the stored values kept", general.Labels, general.Tags)
+	}
+	if spec.Tags["team"] != "platform" {
+		t.Errorf("cluster tags = %v, want the stored tags kept", spec.Tags)
+	}
+
+	// An explicitly empty value clears the stored one
+	batch := pools["batch"]
+	if len(batch.Labels) != 0 {
+		t.Errorf("batch labels = %v, want cleared by labels = {}", batch.Labels)
+	}
+	if batch.DesiredSize != 2 {
+		t.Errorf("batch DesiredSize = %d, want the stored 2", batch.DesiredSize)
+	}
+
+	// The decoded configuration itself is unchanged
+	if file.Clusters[0].Spec.WorkerPools[0].Labels != nil {
+		t.Errorf("DesiredState() modified the loaded configuration")
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	settings, err := LoadSettings(writeConfig(t, `
+aws {
+  profile  = "ops"
+  role_arn = "arn:aws:iam::123456789012:role/provctl"
+}
+
+azure {
+  subscription_id  = "00000000-0000-0000-0000-000000000000"
+  managed_identity = true
+}
+`))
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+
+	wantAWS := &AWSSettings{Profile: "ops", RoleARN: "arn:aws:iam::123456789012:role/provctl"}
+	if !reflect.DeepEqual(settings.AWS, wantAWS) {
+		t.Errorf("AWS = %+v, want %+v", settings.AWS, wantAWS)
+	}
+	wantAzure := &AzureSettings{SubscriptionID: "00000000-0000-0000-0000-000000000000", ManagedIdentity: true}
+	if !reflect.DeepEqual(settings.Azure, wantAzure) {
+		t.Errorf("Azure = %+v, want %+v", settings.Azure, wantAzure)
+	}
+
+	if _, err := LoadSettings(writeConfig(t, `aws { client_secret = "s3cr3t" }`)); err == nil {
+		t.Error("LoadSettings() error = nil, want an error for an unknown attribute")
+	}
+}
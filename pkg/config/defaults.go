@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// poolSizeAttributes maps the pool fields api.PoolDefaults can supply to
+// their attribute names. The attributes are optional in the schema so a
+// pool can inherit them, but every pool must end up with a value.
+var poolSizeAttributes = map[string]string{
+	"instanceType": "instance_type",
+	"minSize":      "min_size",
+	"maxSize":      "max_size",
+	"desiredSize":  "desired_size",
+}
+
+var (
+	poolsSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "worker_pools", LabelNames: []string{"name"}}},
+	}
+	poolSizeSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "instance_type"}, {Name: "min_size"}, {Name: "max_size"}, {Name: "desired_size"},
+		},
+	}
+)
+
+// resolvePools applies a cluster block's pool_defaults to its worker pools
+// and reports pools left without an instance type or size bounds. Defaults
+// count as set for the merge with stored state, so an existing cluster picks
+// up default labels and desired size like those written on the pool.
+func (b *ClusterBlock) resolvePools(body hcl.Body) hcl.Diagnostics {
+	content, _, _ := body.PartialContent(poolsSchema)
+	if content == nil {
+		return nil
+	}
+
+	explicit := make(map[string]bool)
+	ranges := make(map[string]hcl.Range)
+	for _, block := range content.Blocks {
+		name := block.Labels[0]
+		ranges[name] = block.DefRange
+		poolContent, _, _ := block.Body.PartialContent(poolSizeSchema)
+		if poolContent == nil {
+			continue
+		}
+		for attribute := range poolContent.Attributes {
+			explicit[name+"."+attribute] = true
+		}
+	}
+
+	b.Spec.WorkerPools = b.Spec.ResolvePools(func(pool, field string) bool {
+		return explicit[pool+"."+poolSizeAttributes[field]]
+	})
+
+	var diags hcl.Diagnostics
+	defaults := b.Spec.PoolDefaults
+	for _, pool := range b.Spec.WorkerPools {
+		for _, attribute := range []string{"instance_type", "min_size", "max_size"} {
+			if explicit[pool.Name+"."+attribute] || defaultsSet(defaults, attribute) {
+				continue
+			}
+			declRange := ranges[pool.Name]
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Missing required argument",
+				Detail:   fmt.Sprintf("worker pool %q has no %s; set it on the pool or in pool_defaults", pool.Name, attribute),
+				Subject:  &declRange,
+			})
+		}
+
+		if b.set == nil || defaults == nil {
+			continue
+		}
+		prefix := "worker_pools." + pool.Name + "."
+		if defaults.DesiredSize != nil {
+			b.set[prefix+"desired_size"] = true
+		}
+		if len(defaults.Labels) > 0 {
+			b.set[prefix+"labels"] = true
+		}
+	}
+	return diags
+}
+
+// defaultsSet reports whether pool defaults supply a required pool attribute
+func defaultsSet(defaults *api.PoolDefaults, attribute string) bool {
+	if defaults == nil {
+		return false
+	}
+	switch attribute {
+	case "instance_type":
+		return defaults.InstanceType != ""
+	case "min_size":
+		return defaults.MinSize != nil
+	case "max_size":
+		return defaults.MaxSize != nil
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"maps"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// Merge semantics for partial configuration
+//
+// The configuration is authoritative for every attribute it sets, including
+// one set to an empty value such as labels = {}. For a cluster that already
+// exists, the optional attributes below keep their stored value when the
+// configuration omits them, so a config that only manages a pool's sizing
+// does not wipe labels applied earlier. Required attributes and blocks are
+// always taken from the configuration.
+var (
+	clusterMergeSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "tags"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "worker_pools", LabelNames: []string{"name"}}},
+	}
+	poolMergeSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "desired_size"}, {Name: "labels"}, {Name: "tags"}},
+	}
+)
+
+// attributePresence records which mergeable attributes a cluster block sets,
+// as paths such as "tags" or "worker_pools.general.labels"
+func attributePresence(body hcl.Body) map[string]bool {
+	set := make(map[string]bool)
+
+	content, _, _ := body.PartialContent(clusterMergeSchema)
+	if content == nil {
+		return set
+	}
+	for name := range content.Attributes {
+		set[name] = true
+	}
+	for _, block := range content.Blocks {
+		poolContent, _, _ := block.Body.PartialContent(poolMergeSchema)
+		if poolContent == nil {
+			continue
+		}
+		for name := range poolContent.Attributes {
+			set["worker_pools."+block.Labels[0]+"."+name] = true
+		}
+	}
+	return set
+}
+
+// sets reports whether the block sets a mergeable attribute. Blocks built in
+// code rather than decoded have no presence record and set everything.
+func (b ClusterBlock) sets(path string) bool {
+	return b.set == nil || b.set[path]
+}
+
+// mergeStored fills the mergeable attributes the block omits from the
+// stored spec of the same cluster
+func (b ClusterBlock) mergeStored(spec, stored api.ClusterSpec) api.ClusterSpec {
+	if !b.sets("tags") {
+		spec.Tags = maps.Clone(stored.Tags)
+	}
+
+	storedPools := make(map[string]api.WorkerPoolSpec, len(stored.WorkerPools))
+	for _, pool := range stored.WorkerPools {
+		storedPools[pool.Name] = pool
+	}
+
+	// Copy the pools so merging does not modify the decoded configuration
+	pools := make([]api.WorkerPoolSpec, len(spec.WorkerPools))
+	copy(pools, spec.WorkerPools)
+	for i, pool := range pools {
+		storedPool, exists := storedPools[pool.Name]
+		if !exists {
+			continue
+		}
+
+		prefix := "worker_pools." + pool.Name + "."
+		if !b.sets(prefix + "desired_size") {
+			pools[i].DesiredSize = storedPool.DesiredSize
+		}
+		if !b.sets(prefix + "labels") {
+			pools[i].Labels = maps.Clone(storedPool.Labels)
+		}
+		if !b.sets(prefix + "tags") {
+			pools[i].Tags = maps.Clone(storedPool.Tags)
+		}
+	}
+	if spec.WorkerPools != nil {
+		spec.WorkerPools = pools
+	}
+
+	return spec
+}
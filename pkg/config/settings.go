@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// Settings is the provctl settings file, holding provider settings that the
+// global command-line flags override
+type Settings struct {
+	Region           string  `hcl:"region,optional"`            // Region of new clusters
+	APIRateLimit     float64 `hcl:"api_rate_limit,optional"`    // Cloud API calls per second per provider
+	APIBurst         int     `hcl:"api_burst,optional"`         // Cloud API calls allowed at once
+	ProgressInterval string  `hcl:"progress_interval,optional"` // Such as "1m"
+
+	// Time allowed for each phase of provisioning, such as "45m"
+	NetworkTimeout      string `hcl:"network_timeout,optional"`
+	ControlPlaneTimeout string `hcl:"control_plane_timeout,optional"`
+	NodePoolTimeout     string `hcl:"node_pool_timeout,optional"`
+
+	AWS   *AWSSettings   `hcl:"aws,block"`
+	Azure *AzureSettings `hcl:"azure,block"`
+}
+
+// AWSSettings is the aws block
+type AWSSettings struct {
+	Profile    string `hcl:"profile,optional"`     // Shared config profile
+	RoleARN    string `hcl:"role_arn,optional"`    // Role to assume for provisioning
+	ExternalID string `hcl:"external_id,optional"` // External ID for assuming RoleARN
+}
+
+// AzureSettings is the azure block. A service principal's secret is not
+// read from the file; it comes from AZURE_CLIENT_SECRET.
+type AzureSettings struct {
+	SubscriptionID  string `hcl:"subscription_id,optional"`
+	TenantID        string `hcl:"tenant_id,optional"`        // Tenant of the service principal
+	ClientID        string `hcl:"client_id,optional"`        // Service principal, or user-assigned identity with ManagedIdentity
+	ManagedIdentity bool   `hcl:"managed_identity,optional"` // Authenticate with the host's managed identity
+}
+
+// LoadSettings parses and decodes a settings file
+func LoadSettings(path string) (*Settings, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL(src, path)
+	if diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+
+	settings := &Settings{}
+	if diags := gohcl.DecodeBody(file.Body, nil, settings); diags.HasErrors() {
+		return nil, diagsError(diags)
+	}
+	return settings, nil
+}
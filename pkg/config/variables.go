@@ -0,0 +1,371 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "type"},
+		{Name: "default"},
+		{Name: "description"},
+	},
+}
+
+// variable is a declared input variable and its resolved value
+type variable struct {
+	name      string
+	typ       cty.Type
+	value     cty.Value
+	declRange hcl.Range
+}
+
+// decodeVariables reads variable blocks and assigns their values. Later
+// sources win: defaults, then var files in order, then command line values.
+func decodeVariables(parser *hclparse.Parser, blocks []*hcl.Block, opts Options) (map[string]*variable, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	variables := make(map[string]*variable, len(blocks))
+
+	for _, block := range blocks {
+		v := &variable{
+			name:      block.Labels[0],
+			typ:       cty.DynamicPseudoType,
+			value:     cty.NilVal,
+			declRange: block.DefRange,
+		}
+		if existing, exists := variables[v.name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate variable",
+				Detail:   fmt.Sprintf("variable %q was already declared at %s", v.name, existing.declRange),
+				Subject:  &v.declRange,
+			})
+			continue
+		}
+
+		content, contentDiags := block.Body.Content(variableSchema)
+		diags = append(diags, contentDiags...)
+		if contentDiags.HasErrors() {
+			continue
+		}
+
+		if attr, exists := content.Attributes["type"]; exists {
+			typ, typeDiags := typeexpr.TypeConstraint(attr.Expr)
+			diags = append(diags, typeDiags...)
+			if typeDiags.HasErrors() {
+				continue
+			}
+			v.typ = typ
+		}
+
+		if attr, exists := content.Attributes["default"]; exists {
+			value, valueDiags := attr.Expr.Value(nil)
+			diags = append(diags, valueDiags...)
+			if !valueDiags.HasErrors() {
+				diags = append(diags, v.assign(value, attr.Expr.Range())...)
+			}
+		}
+
+		variables[v.name] = v
+	}
+
+	for _, path := range opts.VarFiles {
+		diags = append(diags, assignVarFile(parser, path, variables)...)
+	}
+
+	names := make([]string, 0, len(opts.Vars))
+	for name := range opts.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		diags = append(diags, assignRaw(name, opts.Vars[name], variables)...)
+	}
+
+	for _, name := range sortedVariableNames(variables) {
+		v := variables[name]
+		if v.value == cty.NilVal {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "No value for required variable",
+				Detail:   fmt.Sprintf("variable %q has no default; set it with --var or --var-file", name),
+				Subject:  &v.declRange,
+			})
+		}
+	}
+
+	return variables, diags
+}
+
+// assign converts value to the variable's type and stores it
+func (v *variable) assign(value cty.Value, rng hcl.Range) hcl.Diagnostics {
+	converted, err := convert.Convert(value, v.typ)
+	if err != nil {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid value for variable",
+			Detail:   fmt.Sprintf("invalid value for variable %q: %s", v.name, err),
+			Subject:  &rng,
+		}}
+	}
+	v.value = converted
+	return nil
+}
+
+func assignVarFile(parser *hclparse.Parser, path string, variables map[string]*variable) hcl.Diagnostics {
+	parsed, diags := parser.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	attrs, attrDiags := parsed.Body.JustAttributes()
+	diags = append(diags, attrDiags...)
+
+	for _, attr := range sortedAttributes(attrs) {
+		v, exists := variables[attr.Name]
+		if !exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Undeclared variable",
+				Detail:   fmt.Sprintf("a value was assigned to variable %q, which is not declared", attr.Name),
+				Subject:  &attr.NameRange,
+			})
+			continue
+		}
+
+		value, valueDiags := attr.Expr.Value(nil)
+		diags = append(diags, valueDiags...)
+		if !valueDiags.HasErrors() {
+			diags = append(diags, v.assign(value, attr.Expr.Range())...)
+		}
+	}
+
+	return diags
+}
+
+// assignRaw sets a variable from a command line value. Strings are taken
+// literally; other types are parsed as HCL expressions, so lists are given
+// as --var 'zones=["a","b"]'.
+func assignRaw(name, raw string, variables map[string]*variable) hcl.Diagnostics {
+	v, exists := variables[name]
+	if !exists {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Undeclared variable",
+			Detail:   fmt.Sprintf("a value was assigned to variable %q, which is not declared", name),
+		}}
+	}
+
+	if v.typ == cty.String || v.typ == cty.DynamicPseudoType {
+		return v.assign(cty.StringVal(raw), hcl.Range{Filename: "<value for var." + name + ">"})
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), "<value for var."+name+">", hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return diags
+	}
+	return v.assign(value, expr.Range())
+}
+
+// evalContext builds the context cluster blocks are decoded with: var.* holds
+// variable values and local.* the evaluated locals. Locals may refer to
+// variables and to other locals, in any order.
+func evalContext(variables map[string]*variable, localsBlocks []*hcl.Block) (*hcl.EvalContext, hcl.Diagnostics) {
+	values := make(map[string]cty.Value, len(variables))
+	for name, v := range variables {
+		values[name] = v.value
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":   cty.ObjectVal(values),
+			"local": cty.EmptyObjectVal,
+		},
+	}
+
+	var diags hcl.Diagnostics
+	pending := make(map[string]*hcl.Attribute)
+	for _, block := range localsBlocks {
+		attrs, attrDiags := block.Body.JustAttributes()
+		diags = append(diags, attrDiags...)
+		for name, attr := range attrs {
+			if existing, exists := pending[name]; exists {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate local value",
+					Detail:   fmt.Sprintf("local %q was already defined at %s", name, existing.NameRange),
+					Subject:  &attr.NameRange,
+				})
+				continue
+			}
+			pending[name] = attr
+		}
+	}
+
+	declared := make(map[string]bool, len(pending))
+	for name := range pending {
+		declared[name] = true
+	}
+	for _, attr := range sortedAttributes(pending) {
+		diags = append(diags, checkTraversals(attr.Expr.Variables(), declaredNames(ctx, "var"), declared)...)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// Evaluate locals whose references are all resolved until none are left.
+	// A pass without progress means the remaining locals refer to each other.
+	locals := make(map[string]cty.Value, len(pending))
+	for len(pending) > 0 {
+		progressed := false
+		for _, attr := range sortedAttributes(pending) {
+			if !localsResolved(attr.Expr, locals) {
+				continue
+			}
+
+			value, valueDiags := attr.Expr.Value(ctx)
+			diags = append(diags, valueDiags...)
+			locals[attr.Name] = value
+			ctx.Variables["local"] = cty.ObjectVal(locals)
+			delete(pending, attr.Name)
+			progressed = true
+		}
+
+		if !progressed {
+			for _, attr := range sortedAttributes(pending) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Cycle in local values",
+					Detail:   fmt.Sprintf("local %q depends on itself through other locals", attr.Name),
+					Subject:  &attr.NameRange,
+				})
+			}
+			break
+		}
+	}
+
+	return ctx, diags
+}
+
+func localsResolved(expr hcl.Expression, locals map[string]cty.Value) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" {
+			continue
+		}
+		if _, exists := locals[traversalAttr(traversal)]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// declaredNames returns the attribute names of a root object in ctx, such
+// as the declared variables under "var"
+func declaredNames(ctx *hcl.EvalContext, root string) map[string]bool {
+	names := make(map[string]bool)
+	for name := range ctx.Variables[root].Type().AttributeTypes() {
+		names[name] = true
+	}
+	return names
+}
+
+// checkTraversals reports references to variables or locals that are not
+// declared, and references to anything other than var.* and local.*
+func checkTraversals(traversals []hcl.Traversal, variables, locals map[string]bool) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, traversal := range traversals {
+		name := traversalAttr(traversal)
+		rng := traversal.SourceRange()
+
+		switch traversal.RootName() {
+		case "var":
+			if !variables[name] {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Undefined variable",
+					Detail:   fmt.Sprintf("no variable named %q is declared", name),
+					Subject:  &rng,
+				})
+			}
+		case "local":
+			if !locals[name] {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Undefined local value",
+					Detail:   fmt.Sprintf("no local value named %q is defined", name),
+					Subject:  &rng,
+				})
+			}
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown reference",
+				Detail:   fmt.Sprintf("%q is not a valid reference; use var.<name> or local.<name>", traversal.RootName()),
+				Subject:  &rng,
+			})
+		}
+	}
+	return diags
+}
+
+// bodyTraversals returns every variable reference in a native syntax body,
+// including those in nested blocks
+func bodyTraversals(body hcl.Body) []hcl.Traversal {
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+
+	var traversals []hcl.Traversal
+	for _, attr := range syntaxBody.Attributes {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+	for _, block := range syntaxBody.Blocks {
+		traversals = append(traversals, bodyTraversals(block.Body)...)
+	}
+	return traversals
+}
+
+// traversalAttr returns the attribute name following the root of a
+// reference such as var.region, or "" if there is none
+func traversalAttr(traversal hcl.Traversal) string {
+	if len(traversal) < 2 {
+		return ""
+	}
+	if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+		return attr.Name
+	}
+	return ""
+}
+
+func sortedAttributes(attrs map[string]*hcl.Attribute) []*hcl.Attribute {
+	sorted := make([]*hcl.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		sorted = append(sorted, attr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func sortedVariableNames(variables map[string]*variable) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
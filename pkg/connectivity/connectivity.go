@@ -0,0 +1,197 @@
+// Package connectivity checks whether a cluster's API server can be reached
+// from where provctl runs, and at which step it cannot
+package connectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Stage is a step of reaching an API server
+type Stage string
+
+const (
+	StageDNS  Stage = "dns"  // Resolving the endpoint's host name
+	StageTCP  Stage = "tcp"  // Connecting to a resolved address
+	StageTLS  Stage = "tls"  // The TLS handshake
+	StageAuth Stage = "auth" // The API server rejected the credentials
+	StageAPI  Stage = "api"  // Any other failed /version request
+)
+
+// Target is an API server to check
+type Target struct {
+	Endpoint string
+	// Credentials verify the server's certificate and authenticate the
+	// request. Without them, the certificate is not verified and the request
+	// is anonymous.
+	Credentials *engine.ClusterCredentials
+}
+
+// Result is the outcome of a check. The fields of the stages before the
+// failed one are set.
+type Result struct {
+	Endpoint  string
+	Addresses []string      // Addresses the host name resolved to
+	Address   string        // The address connected to
+	Private   bool          // Every resolved address is a private one
+	Verified  bool          // The certificate was verified against the cluster's CA
+	Version   string        // Kubernetes version the API server reported
+	Latency   time.Duration // Time taken by the whole check
+	Failed    Stage         // Empty when the API server was reached
+	Err       error
+}
+
+// Reachable reports whether the API server answered
+func (r Result) Reachable() bool {
+	return r.Failed == ""
+}
+
+// Check resolves, connects to and handshakes with the API server at the
+// target's endpoint, then requests its /version, all within timeout. It
+// returns an error only for an endpoint that is not an HTTPS URL.
+func Check(ctx context.Context, target Target, timeout time.Duration) (Result, error) {
+	endpoint, err := url.Parse(target.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" {
+		return Result{}, fmt.Errorf("endpoint %q is not an HTTPS URL", target.Endpoint)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := Result{Endpoint: target.Endpoint}
+	result.Failed, result.Err = check(ctx, endpoint, target.Credentials, &result)
+	result.Latency = time.Since(start)
+	return result, nil
+}
+
+// check runs the stages in order, recording what each learns in result, and
+// returns the stage that failed
+func check(ctx context.Context, endpoint *url.URL, credentials *engine.ClusterCredentials, result *Result) (Stage, error) {
+	host, port := endpoint.Hostname(), endpoint.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return StageDNS, err
+	}
+	result.Addresses = addresses
+	result.Private = allPrivate(addresses)
+
+	var dialer net.Dialer
+	var conn net.Conn
+	for _, address := range addresses {
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, port))
+		if err == nil {
+			result.Address = net.JoinHostPort(address, port)
+			break
+		}
+	}
+	if conn == nil {
+		return StageTCP, err
+	}
+
+	config, err := tlsConfig(host, credentials)
+	if err != nil {
+		conn.Close()
+		return StageTLS, err
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		tlsConn.Close()
+		return StageTLS, err
+	}
+	result.Verified = !config.InsecureSkipVerify
+
+	version, stage, err := getVersion(ctx, endpoint, tlsConn, credentials)
+	if err != nil {
+		return stage, err
+	}
+	result.Version = version
+	return "", nil
+}
+
+// tlsConfig verifies the server against the cluster's CA, or skips
+// verification when there is none to verify against: API servers present
+// certificates of their cluster's own CA, which no system trusts
+func tlsConfig(host string, credentials *engine.ClusterCredentials) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: host,
+		NextProtos: []string{"http/1.1"},
+	}
+	if credentials == nil || len(credentials.CAData) == 0 {
+		config.InsecureSkipVerify = true
+		return config, nil
+	}
+
+	config.RootCAs = x509.NewCertPool()
+	if !config.RootCAs.AppendCertsFromPEM(credentials.CAData) {
+		return nil, errors.New("the cluster's certificate authority holds no PEM certificate")
+	}
+	return config, nil
+}
+
+// getVersion requests /version over the established connection
+func getVersion(ctx context.Context, endpoint *url.URL, conn *tls.Conn, credentials *engine.ClusterCredentials) (string, Stage, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialTLSContext: func(context.Context, string, string) (net.Conn, error) {
+				return conn, nil
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.JoinPath("/version").String(), nil)
+	if err != nil {
+		return "", StageAPI, err
+	}
+	if credentials != nil && credentials.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+credentials.Token)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", StageAPI, err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+		return "", StageAuth, fmt.Errorf("/version returned %s", response.Status)
+	case response.StatusCode != http.StatusOK:
+		return "", StageAPI, fmt.Errorf("/version returned %s", response.Status)
+	}
+
+	var info struct {
+		GitVersion string `json:"gitVersion"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&info); err != nil {
+		return "", StageAPI, fmt.Errorf("/version returned an invalid response: %w", err)
+	}
+	return info.GitVersion, "", nil
+}
+
+// allPrivate reports whether every address is a private or loopback one,
+// which is only reachable from inside the cluster's network
+func allPrivate(addresses []string) bool {
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil || !(ip.IsPrivate() || ip.IsLoopback()) {
+			return false
+		}
+	}
+	return len(addresses) > 0
+}
@@ -0,0 +1,142 @@
+package connectivity
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func apiServer(t *testing.T) (*httptest.Server, []byte) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer good" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"major":"1","minor":"30","gitVersion":"v1.30.2"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, ca
+}
+
+func TestCheck(t *testing.T) {
+	server, ca := apiServer(t)
+	// The test certificate is issued for 127.0.0.1 and example.com only
+	otherHost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name         string
+		endpoint     string
+		credentials  *engine.ClusterCredentials
+		wantFailed   Stage
+		wantVerified bool
+	}{
+		{name: "reachable", credentials: &engine.ClusterCredentials{CAData: ca, Token: "good"}, wantVerified: true},
+		{name: "rejected token", credentials: &engine.ClusterCredentials{CAData: ca, Token: "bad"}, wantFailed: StageAuth, wantVerified: true},
+		{name: "certificate of another host", endpoint: otherHost, credentials: &engine.ClusterCredentials{CAData: ca, Token: "good"}, wantFailed: StageTLS},
+		{name: "no credentials", wantFailed: StageAuth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := server.URL
+			if tt.endpoint != "" {
+				endpoint = tt.endpoint
+			}
+			result, err := Check(context.Background(), Target{Endpoint: endpoint, Credentials: tt.credentials}, 5*time.Second)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Failed != tt.wantFailed {
+				t.Fatalf("Check() failed at %q (%v), want %q", result.Failed, result.Err, tt.wantFailed)
+			}
+			if result.Verified != tt.wantVerified {
+				t.Errorf("Verified = %v, want %v", result.Verified, tt.wantVerified)
+			}
+			if result.Address == "" || !result.Private {
+				t.Errorf("Check() = %+v, want the loopback address connected to", result)
+			}
+			if tt.wantFailed == "" && (!result.Reachable() || result.Version != "v1.30.2") {
+				t.Errorf("Check() = %+v, want reachable at v1.30.2", result)
+			}
+		})
+	}
+}
+
+func TestCheck_Unreachable(t *testing.T) {
+	// A listener closed right away leaves a port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		wantFailed Stage
+	}{
+		{name: "unknown host", endpoint: "https://api.provctl.invalid", wantFailed: StageDNS},
+		{name: "closed port", endpoint: "https://" + closedPort, wantFailed: StageTCP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Check(context.Background(), Target{Endpoint: tt.endpoint}, 5*time.Second)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Failed != tt.wantFailed || result.Err == nil || result.Reachable() {
+				t.Errorf("Check() failed at %q (%v), want %q", result.Failed, result.Err, tt.wantFailed)
+			}
+		})
+	}
+
+	if _, err := Check(context.Background(), Target{Endpoint: "http://example.com"}, time.Second); err == nil {
+		t.Error("Check() of a plain HTTP endpoint error = nil, want an error")
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	// A server that accepts connections but never completes a handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	result, err := Check(context.Background(), Target{Endpoint: "https://" + listener.Addr().String()}, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.Failed != StageTLS {
+		t.Errorf("Check() failed at %q (%v), want %q", result.Failed, result.Err, StageTLS)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Check() took %s, want it bounded by the timeout", elapsed)
+	}
+}
@@ -0,0 +1,42 @@
+package cost
+
+import (
+	"fmt"
+
+	"github.com/vjranagit/cluster-api/pkg/format"
+)
+
+// DefaultIncreaseThreshold is the percentage by which an estimate must exceed
+// the last applied one before it is reported as an anomaly
+const DefaultIncreaseThreshold = 50.0
+
+// Increase compares a cluster's estimated monthly cost with the estimate
+// recorded when it was last applied
+type Increase struct {
+	Cluster  string
+	Previous float64
+	Current  float64
+	Currency string
+}
+
+// Percent returns the change in percent of the previous estimate, or 0
+// without a previous estimate to compare with
+func (i Increase) Percent() float64 {
+	if i.Previous <= 0 {
+		return 0
+	}
+	return (i.Current - i.Previous) / i.Previous * 100
+}
+
+// Exceeds reports whether the estimate went up by more than threshold
+// percent. A threshold of zero or less disables the check.
+func (i Increase) Exceeds(threshold float64) bool {
+	return threshold > 0 && i.Percent() > threshold
+}
+
+// String formats the increase as a warning, such as "⚠ Estimated cost of
+// prod up 180% vs last apply ($500.00 → $1,400.00/mo)"
+func (i Increase) String() string {
+	return fmt.Sprintf("⚠ Estimated cost of %s up %.0f%% vs last apply (%s → %s/mo)", i.Cluster, i.Percent(),
+		format.Money(i.Previous, i.Currency), format.Money(i.Current, i.Currency))
+}
@@ -2,6 +2,8 @@ package cost
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
@@ -145,6 +147,23 @@ func TestEstimator_SpotSavings(t *testing.T) {
 	}
 }
 
+func TestEstimator_FreeControlPlane(t *testing.T) {
+	estimator := NewEstimator()
+	spec := api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.28"},
+	}
+
+	free := estimator.estimateControlPlane(spec, PricingData{})
+	if len(free) != 0 {
+		t.Errorf("estimateControlPlane() of a free control plane = %v, want no line", free)
+	}
+
+	paid := estimator.estimateControlPlane(spec, PricingData{ManagedK8s: ManagedK8sPrice{ControlPlaneHourly: 0.10}})
+	if len(paid) != 1 || paid[0].MonthlyCost != 73 {
+		t.Errorf("estimateControlPlane() of a paid control plane = %v, want one $73.00 line", paid)
+	}
+}
+
 func TestFormatEstimate(t *testing.T) {
 	estimate := &CostEstimate{
 		TotalMonthlyCost: 250.50,
@@ -179,3 +198,604 @@ func TestFormatEstimate(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0
 }
+
+func TestEstimator_Observability(t *testing.T) {
+	estimator := NewEstimator()
+	ctx := context.Background()
+
+	spec := api.ClusterSpec{
+		Provider: "aws",
+		Region:   "us-west-2",
+		ControlPlane: api.ControlPlaneSpec{
+			Type:    api.ControlPlaneManaged,
+			Version: "1.28",
+		},
+		WorkerPools: []api.WorkerPoolSpec{
+			{Name: "general", InstanceType: "t3.medium", DesiredSize: 4},
+		},
+	}
+
+	base, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+
+	spec.Observability = &api.ObservabilitySpec{ControlPlaneLogs: true, Metrics: true}
+	withDefaults, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+
+	if len(withDefaults.Breakdown) != len(base.Breakdown)+2 {
+		t.Errorf("EstimateCost() got %d breakdown items, want %d", len(withDefaults.Breakdown), len(base.Breakdown)+2)
+	}
+
+	var logCost float64
+	for _, item := range withDefaults.Breakdown {
+		if item.Resource.Name == "control-plane-logs" {
+			logCost = item.MonthlyCost
+			if item.ResourceType != ResourceObservability {
+				t.Errorf("log line resource type = %s, want %s", item.ResourceType, ResourceObservability)
+			}
+		}
+	}
+
+	// 10 GB default at $0.50/GB
+	if logCost < 4.99 || logCost > 5.01 {
+		t.Errorf("control-plane log cost = $%.2f, want $5.00", logCost)
+	}
+
+	foundAssumption := false
+	for _, assumption := range withDefaults.Assumptions {
+		if strings.Contains(assumption, "10 GB/month") {
+			foundAssumption = true
+		}
+	}
+	if !foundAssumption {
+		t.Errorf("EstimateCost() assumptions missing log volume: %v", withDefaults.Assumptions)
+	}
+
+	spec.Observability.LogVolumeGB = 100
+	larger, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if larger.TotalMonthlyCost <= withDefaults.TotalMonthlyCost {
+		t.Error("EstimateCost() should grow with configured log volume")
+	}
+
+	if !strings.Contains(FormatEstimate(larger), "observability") {
+		t.Error("FormatEstimate() missing observability breakdown")
+	}
+}
+
+func TestEstimator_LoadBalancerData(t *testing.T) {
+	estimator := NewEstimator()
+	ctx := context.Background()
+
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-east-1",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+	}
+
+	const warning = "Load balancer data processing is excluded"
+	hasWarning := func(estimate *CostEstimate) bool {
+		for _, w := range estimate.Warnings {
+			if strings.Contains(w, warning) {
+				return true
+			}
+		}
+		return false
+	}
+	dataCost := func(estimate *CostEstimate) (float64, bool) {
+		for _, item := range estimate.Breakdown {
+			if item.Resource.Name == "load-balancer-data" {
+				return item.MonthlyCost, true
+			}
+		}
+		return 0, false
+	}
+
+	unspecified, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if _, found := dataCost(unspecified); found {
+		t.Error("EstimateCost() included load balancer data processing without a volume")
+	}
+	if !hasWarning(unspecified) {
+		t.Errorf("Warnings = %q, want %q", unspecified.Warnings, warning)
+	}
+
+	spec.Network.LBProcessedGB = 5000
+	provided, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	// 5000 GB at $0.006/GB
+	if cost, found := dataCost(provided); !found || cost < 29.99 || cost > 30.01 {
+		t.Errorf("load balancer data cost = $%.2f (found %v), want $30.00", cost, found)
+	}
+	if hasWarning(provided) {
+		t.Errorf("Warnings = %q, want no data processing warning", provided.Warnings)
+	}
+}
+
+func TestEstimator_DataTransfer(t *testing.T) {
+	estimator := NewEstimator()
+	ctx := context.Background()
+
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-east-1",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+	}
+
+	transferCost := func(estimate *CostEstimate) (float64, bool) {
+		for _, item := range estimate.Breakdown {
+			if item.ResourceType == ResourceDataTransfer {
+				return item.MonthlyCost, true
+			}
+		}
+		return 0, false
+	}
+
+	unspecified, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	if _, found := transferCost(unspecified); found {
+		t.Error("EstimateCost() included data transfer without a volume")
+	}
+
+	spec.Network.EgressGB = 1000
+	provided, err := estimator.EstimateCost(ctx, spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	// 1000 GB at $0.09/GB
+	if cost, found := transferCost(provided); !found || cost < 89.99 || cost > 90.01 {
+		t.Errorf("data transfer cost = $%.2f (found %v), want $90.00", cost, found)
+	}
+}
+
+func TestEstimator_DiversifiedSpot(t *testing.T) {
+	estimator := NewEstimator()
+
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-west-2",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneSelfManaged, Version: "1.28"},
+		WorkerPools: []api.WorkerPoolSpec{{
+			Name:          "batch",
+			InstanceType:  "m5.large",
+			InstanceTypes: []string{"c5.large", "r5.large", "m7i.large"},
+			DesiredSize:   4,
+			Spot:          &api.SpotConfig{Enabled: true},
+		}},
+	}
+
+	estimate, err := estimator.EstimateCost(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+
+	// c5.large is the cheapest known type at $0.030/hour spot; m7i.large has
+	// no pricing data and is skipped
+	var line CostBreakdown
+	for _, item := range estimate.Breakdown {
+		if item.Resource.Kind == "NodePool" {
+			line = item
+		}
+	}
+	if line.UnitCost != 0.030 {
+		t.Errorf("UnitCost = %v, want 0.030", line.UnitCost)
+	}
+	if want := "4 x c5.large (spot, cheapest of 4 types)"; line.Details != want {
+		t.Errorf("Details = %q, want %q", line.Details, want)
+	}
+}
+
+func TestEstimator_SpotFallbackToOnDemand(t *testing.T) {
+	estimator := NewEstimatorWithPriceProvider(&fakePriceProvider{data: map[string]PricingData{
+		"aws-moon-1": {
+			InstanceTypes: map[string]InstancePrice{"m5.large": {OnDemandHourly: 1, SpotHourly: 0.25, VCPU: 2, MemoryGB: 8}},
+		},
+	}})
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "moon-1",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools: []api.WorkerPoolSpec{{
+			Name:         "batch",
+			InstanceType: "m5.large",
+			MinSize:      1,
+			MaxSize:      7,
+			Spot:         &api.SpotConfig{Enabled: true, FallbackToOnDemand: true},
+		}},
+	}
+
+	estimate, err := estimator.EstimateCost(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+
+	// One on-demand node at $1 and three spot nodes at $0.25 an hour
+	var line CostBreakdown
+	for _, item := range estimate.Breakdown {
+		if item.Resource.Kind == "NodePool" {
+			line = item
+		}
+	}
+	if line.HourlyCost != 1.75 || line.UnitCost != 0.4375 {
+		t.Errorf("HourlyCost = %v, UnitCost = %v, want 1.75 and the blended 0.4375", line.HourlyCost, line.UnitCost)
+	}
+	if want := "4 x m5.large (spot, 1 on-demand base)"; line.Details != want {
+		t.Errorf("Details = %q, want %q", line.Details, want)
+	}
+
+	warning := "⚠ Spot pool batch falls back to on-demand: its 1 base node(s) stay on on-demand capacity through spot shortages, costing $547.50/month more than spot"
+	found := false
+	for _, w := range estimate.Warnings {
+		found = found || w == warning
+	}
+	if !found {
+		t.Errorf("Warnings = %q, want %q", estimate.Warnings, warning)
+	}
+}
+
+func TestEstimator_WeightedCapacity(t *testing.T) {
+	estimator := NewEstimatorWithPriceProvider(&fakePriceProvider{data: map[string]PricingData{
+		"aws-moon-1": {
+			InstanceTypes: map[string]InstancePrice{
+				"m5.large":   {OnDemandHourly: 1, SpotHourly: 0.25, VCPU: 2, MemoryGB: 8},
+				"m5.2xlarge": {OnDemandHourly: 4, SpotHourly: 0.75, VCPU: 8, MemoryGB: 32},
+			},
+		},
+	}})
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "moon-1",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools: []api.WorkerPoolSpec{{
+			Name:            "batch",
+			InstanceType:    "m5.large",
+			InstanceTypes:   []string{"m5.2xlarge"},
+			InstanceWeights: map[string]int{"m5.2xlarge": 4},
+			DesiredSize:     10,
+			Spot:            &api.SpotConfig{Enabled: true},
+		}},
+	}
+
+	estimate, err := estimator.EstimateCost(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+
+	// At $0.1875 a unit the 2xlarge is cheaper than the large at $0.25, and
+	// three of them cover 10 units: 10 nodes at the large's rate would be $2.50
+	var line CostBreakdown
+	for _, item := range estimate.Breakdown {
+		if item.Resource.Kind == "NodePool" {
+			line = item
+		}
+	}
+	if line.Quantity != 3 || line.HourlyCost != 2.25 {
+		t.Errorf("Quantity = %d, HourlyCost = %v, want 3 instances at 2.25", line.Quantity, line.HourlyCost)
+	}
+	if want := "3 x m5.2xlarge (spot, cheapest of 2 types, 10 units at weight 4)"; line.Details != want {
+		t.Errorf("Details = %q, want %q", line.Details, want)
+	}
+}
+
+func TestInstanceArch(t *testing.T) {
+	tests := []struct {
+		provider     string
+		instanceType string
+		want         string
+	}{
+		{"aws", "m5.large", ArchAMD64},
+		{"aws", "m6i.xlarge", ArchAMD64},
+		{"aws", "g5.xlarge", ArchAMD64},
+		{"aws", "m6g.large", ArchARM64},
+		{"aws", "c7gn.2xlarge", ArchARM64},
+		{"aws", "a1.medium", ArchARM64},
+		{"azure", "Standard_D4s_v5", ArchAMD64},
+		{"azure", "Standard_B2s", ArchAMD64},
+		{"azure", "Standard_D4ps_v5", ArchARM64},
+		{"azure", "Standard_E8pds_v5", ArchARM64},
+	}
+
+	for _, tt := range tests {
+		if got := InstanceArch(tt.provider, tt.instanceType); got != tt.want {
+			t.Errorf("InstanceArch(%s, %s) = %s, want %s", tt.provider, tt.instanceType, got, tt.want)
+		}
+	}
+}
+
+func TestEstimator_InstanceMaxPods(t *testing.T) {
+	estimator := NewEstimator()
+
+	tests := []struct {
+		provider     string
+		region       string
+		instanceType string
+		want         int
+	}{
+		{"aws", "us-east-1", "t3.medium", 17},
+		{"aws", "us-east-1", "m5.large", 29},
+		{"aws", "eu-west-1", "m5.2xlarge", 58},
+		{"azure", "eastus", "Standard_D4s_v5", 250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.instanceType, func(t *testing.T) {
+			price, ok := estimator.InstanceType(context.Background(), tt.provider, tt.region, tt.instanceType)
+			if !ok {
+				t.Fatalf("InstanceType(%s, %s, %s) not found", tt.provider, tt.region, tt.instanceType)
+			}
+			if price.MaxPods != tt.want {
+				t.Errorf("MaxPods = %d, want %d", price.MaxPods, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMonthlyDelta(t *testing.T) {
+	tests := []struct {
+		delta float64
+		want  string
+	}{
+		{delta: 1240.4, want: "+$1,240/mo"},
+		{delta: -75, want: "-$75/mo"},
+		{delta: 0, want: "+$0/mo"},
+		{delta: 1234567.5, want: "+$1,234,568/mo"},
+		{delta: -999.6, want: "-$1,000/mo"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMonthlyDelta(tt.delta); got != tt.want {
+			t.Errorf("FormatMonthlyDelta(%v) = %s, want %s", tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestIncrease(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous float64
+		current  float64
+		percent  float64
+		exceeds  bool
+	}{
+		{name: "blowup", previous: 500, current: 1400, percent: 180, exceeds: true},
+		{name: "at threshold", previous: 500, current: 750, percent: 50, exceeds: false},
+		{name: "decrease", previous: 500, current: 250, percent: -50, exceeds: false},
+		{name: "no previous estimate", previous: 0, current: 500, percent: 0, exceeds: false},
+	}
+
+	for _, tt := range tests {
+		increase := Increase{Cluster: "prod", Previous: tt.previous, Current: tt.current, Currency: "USD"}
+		if got := increase.Percent(); got != tt.percent {
+			t.Errorf("%s: Percent() = %v, want %v", tt.name, got, tt.percent)
+		}
+		if got := increase.Exceeds(DefaultIncreaseThreshold); got != tt.exceeds {
+			t.Errorf("%s: Exceeds() = %v, want %v", tt.name, got, tt.exceeds)
+		}
+		if increase.Exceeds(0) {
+			t.Errorf("%s: Exceeds(0) = true, want a zero threshold to disable the check", tt.name)
+		}
+	}
+
+	want := "⚠ Estimated cost of prod up 180% vs last apply ($500.00 → $1,400.00/mo)"
+	if got := (Increase{Cluster: "prod", Previous: 500, Current: 1400, Currency: "USD"}).String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestEstimator_Recommend(t *testing.T) {
+	estimator := NewEstimator()
+
+	tests := []struct {
+		name     string
+		provider string
+		region   string
+		vcpu     int
+		memoryGB float64
+		spot     bool
+		want     string
+		wantErr  bool
+	}{
+		{name: "general purpose", provider: "aws", region: "us-west-2", vcpu: 4, memoryGB: 16, want: "t3.xlarge"},
+		{name: "spot", provider: "aws", region: "us-west-2", vcpu: 4, memoryGB: 16, spot: true, want: "t3.xlarge"},
+		{name: "smallest", provider: "aws", region: "us-west-2", vcpu: 1, memoryGB: 2, want: "t3.medium"},
+		{name: "memory", provider: "aws", region: "us-west-2", vcpu: 2, memoryGB: 16, want: "r5.large"},
+		{name: "azure spot prefers v5", provider: "azure", region: "eastus", vcpu: 4, memoryGB: 16, spot: true, want: "Standard_D4s_v5"},
+		{name: "too large", provider: "aws", region: "us-west-2", vcpu: 64, memoryGB: 256, wantErr: true},
+		{name: "unknown region", provider: "aws", region: "mars-north-1", vcpu: 2, memoryGB: 4, wantErr: true},
+		{name: "no requirement", provider: "aws", region: "us-west-2", vcpu: 0, memoryGB: 4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := estimator.RecommendInstanceType(context.Background(), tt.provider, tt.region, tt.vcpu, tt.memoryGB, tt.spot)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: RecommendInstanceType() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: RecommendInstanceType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	recommendation, err := estimator.Recommend(context.Background(), "aws", "us-west-2", 4, 16, false)
+	if err != nil {
+		t.Fatalf("Recommend() error = %v", err)
+	}
+	if recommendation.HourlyCost != 0.1664 || recommendation.Candidates != 4 || recommendation.Runner != "m5.xlarge" {
+		t.Errorf("Recommend() = %+v, want t3.xlarge at $0.1664 of 4 candidates, then m5.xlarge", recommendation)
+	}
+	want := "cheapest of 4 of 10 instance types with at least 4 vCPU and 16 GB, at on-demand rates; next is m5.xlarge at $0.1920/hour"
+	if got := recommendation.Reason(4, 16); got != want {
+		t.Errorf("Reason() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatEstimateCSV(t *testing.T) {
+	estimate := &CostEstimate{
+		Currency:         "USD",
+		TotalHourlyCost:  1.8832,
+		TotalMonthlyCost: 1374.736,
+		Breakdown: []CostBreakdown{
+			{
+				Resource:     api.ResourceID{Kind: "NodePool", Name: "general, spot"},
+				ResourceType: ResourceCompute,
+				Quantity:     20,
+				UnitCost:     0.0416,
+				HourlyCost:   0.832,
+				MonthlyCost:  607.36,
+			},
+			{
+				Resource:     api.ResourceID{Kind: "ControlPlane", Name: "managed-control-plane"},
+				ResourceType: ResourceManagedK8s,
+				Quantity:     1,
+				UnitCost:     1.0512,
+				HourlyCost:   1.0512,
+				MonthlyCost:  767.376,
+			},
+		},
+	}
+
+	want := "resource,type,quantity,unit_cost_hourly,hourly_cost,monthly_cost,currency\n" +
+		"\"NodePool/general, spot\",compute,20,0.0416,0.8320,607.36,USD\n" +
+		"ControlPlane/managed-control-plane,managed_k8s,1,1.0512,1.0512,767.38,USD\n" +
+		"TOTAL,,,,1.8832,1374.74,USD\n"
+	if got := FormatEstimateCSV(estimate); got != want {
+		t.Errorf("FormatEstimateCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEstimator_RegionalPricing(t *testing.T) {
+	estimator := NewEstimator()
+
+	tests := []struct {
+		provider     string
+		region       string
+		instanceType string
+		wantFallback bool
+	}{
+		{"aws", "us-east-1", "m5.large", false},
+		{"aws", "eu-west-1", "t3.medium", false},
+		{"azure", "westeurope", "Standard_D4s_v3", false},
+		{"azure", "norwayeast", "Standard_D4s_v3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.region, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider:     tt.provider,
+				Region:       tt.region,
+				ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "general", InstanceType: tt.instanceType, MinSize: 1, MaxSize: 3, DesiredSize: 2},
+				},
+			}
+
+			estimate, err := estimator.EstimateCost(context.Background(), spec)
+			if err != nil {
+				t.Fatalf("EstimateCost() error = %v", err)
+			}
+			if estimate.FallbackPricing != tt.wantFallback {
+				t.Errorf("FallbackPricing = %v, want %v", estimate.FallbackPricing, tt.wantFallback)
+			}
+
+			warning := "No pricing data for " + tt.provider + "/" + tt.region
+			warned := false
+			for _, w := range estimate.Warnings {
+				if strings.Contains(w, warning) {
+					warned = true
+				}
+			}
+			if warned != tt.wantFallback {
+				t.Errorf("Warnings = %q, want fallback warning %v", estimate.Warnings, tt.wantFallback)
+			}
+		})
+	}
+
+	// Each region's instance prices should be complete and carry a spot discount
+	for key, pricing := range NewStaticPriceProvider().data {
+		for name, price := range pricing.InstanceTypes {
+			if price.OnDemandHourly <= 0 || price.SpotHourly <= 0 || price.SpotHourly >= price.OnDemandHourly {
+				t.Errorf("%s %s: on-demand %v, spot %v", key, name, price.OnDemandHourly, price.SpotHourly)
+			}
+			if price.VCPU == 0 || price.MemoryGB == 0 || price.MaxPods == 0 {
+				t.Errorf("%s %s: missing instance size", key, name)
+			}
+		}
+	}
+}
+
+// fakePriceProvider serves fixed prices, or fails with err
+type fakePriceProvider struct {
+	data  map[string]PricingData
+	err   error
+	calls []string
+}
+
+func (f *fakePriceProvider) GetPricing(ctx context.Context, provider, region string) (PricingData, error) {
+	f.calls = append(f.calls, provider+"-"+region)
+	if f.err != nil {
+		return PricingData{}, f.err
+	}
+	data, exists := f.data[provider+"-"+region]
+	if !exists {
+		return PricingData{}, ErrNoPricing
+	}
+	return data, nil
+}
+
+func TestEstimator_PriceProvider(t *testing.T) {
+	prices := &fakePriceProvider{data: map[string]PricingData{
+		"aws-moon-1": {
+			Provider:      "aws",
+			Region:        "moon-1",
+			InstanceTypes: map[string]InstancePrice{"m5.large": {OnDemandHourly: 1, SpotHourly: 0.5, VCPU: 2, MemoryGB: 8}},
+			ManagedK8s:    ManagedK8sPrice{ControlPlaneHourly: 2},
+		},
+	}}
+	estimator := NewEstimatorWithPriceProvider(prices)
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "moon-1",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", InstanceType: "m5.large", MinSize: 3, MaxSize: 3}},
+	}
+
+	estimate, err := estimator.EstimateCost(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	// The control plane at $2 and three nodes at $1 an hour
+	if want := 5.0 * 730; estimate.TotalMonthlyCost != want || estimate.FallbackPricing {
+		t.Errorf("EstimateCost() = %v (fallback %v), want %v from the provider's prices", estimate.TotalMonthlyCost, estimate.FallbackPricing, want)
+	}
+	if len(prices.calls) != 1 || prices.calls[0] != "aws-moon-1" {
+		t.Errorf("GetPricing() called for %v, want aws-moon-1", prices.calls)
+	}
+	if price, ok := estimator.InstanceType(context.Background(), "aws", "moon-1", "m5.large"); !ok || price.OnDemandHourly != 1 {
+		t.Errorf("InstanceType() = %+v, %v, want the provider's price", price, ok)
+	}
+
+	spec.Region = "mars-1"
+	if estimate, err := estimator.EstimateCost(context.Background(), spec); err != nil || !estimate.FallbackPricing {
+		t.Errorf("EstimateCost() of an unpriced region = %+v, %v, want fallback pricing", estimate, err)
+	}
+
+	prices.err = errors.New("pricing API unavailable")
+	if _, err := estimator.EstimateCost(context.Background(), spec); !errors.Is(err, prices.err) {
+		t.Errorf("EstimateCost() error = %v, want the provider's error", err)
+	}
+	if _, err := estimator.Recommend(context.Background(), "aws", "moon-1", 2, 8, false); !errors.Is(err, prices.err) {
+		t.Errorf("Recommend() error = %v, want the provider's error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package cost
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// csvHeader names the columns of FormatEstimateCSV. Costs are hourly or
+// monthly amounts in the currency column.
+var csvHeader = []string{"resource", "type", "quantity", "unit_cost_hourly", "hourly_cost", "monthly_cost", "currency"}
+
+// FormatEstimateCSV renders an estimate as CSV for spreadsheets: a header,
+// one row per breakdown item and a final TOTAL row. Numbers are plain decimals
+// without currency symbols or thousands separators so they import as numbers.
+func FormatEstimateCSV(estimate *CostEstimate) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+
+	w.Write(csvHeader)
+	for _, item := range estimate.Breakdown {
+		w.Write([]string{
+			item.Resource.Kind + "/" + item.Resource.Name,
+			string(item.ResourceType),
+			strconv.Itoa(item.Quantity),
+			formatAmount(item.UnitCost, 4),
+			formatAmount(item.HourlyCost, 4),
+			formatAmount(item.MonthlyCost, 2),
+			estimate.Currency,
+		})
+	}
+	w.Write([]string{
+		"TOTAL",
+		"",
+		"",
+		"",
+		formatAmount(estimate.TotalHourlyCost, 4),
+		formatAmount(estimate.TotalMonthlyCost, 2),
+		estimate.Currency,
+	})
+
+	w.Flush()
+	return b.String()
+}
+
+func formatAmount(amount float64, precision int) string {
+	return strconv.FormatFloat(amount, 'f', precision, 64)
+}
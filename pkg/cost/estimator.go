@@ -3,22 +3,30 @@ package cost
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/format"
 )
 
 // Estimator calculates estimated infrastructure costs
 type Estimator struct {
-	pricingData map[string]PricingData
+	prices PriceProvider
 }
 
-// NewEstimator creates a new cost estimator
+// NewEstimator creates a new cost estimator using the built-in prices
 func NewEstimator() *Estimator {
-	return &Estimator{
-		pricingData: loadPricingData(),
-	}
+	return NewEstimatorWithPriceProvider(NewStaticPriceProvider())
+}
+
+// NewEstimatorWithPriceProvider creates a cost estimator using the prices p
+// supplies
+func NewEstimatorWithPriceProvider(p PriceProvider) *Estimator {
+	return &Estimator{prices: p}
 }
 
 // CostEstimate contains cost estimation results
@@ -30,6 +38,10 @@ type CostEstimate struct {
 	Currency          string
 	Assumptions       []string
 	Warnings          []string
+
+	// FallbackPricing is set when there is no pricing data for the cluster's
+	// provider and region, so generic default prices were used
+	FallbackPricing bool
 }
 
 // CostBreakdown shows costs by resource
@@ -47,13 +59,18 @@ type CostBreakdown struct {
 type ResourceType string
 
 const (
-	ResourceCompute      ResourceType = "compute"       // EC2, VMs
-	ResourceNetwork      ResourceType = "network"       // VPC, VNet, Load Balancers
-	ResourceStorage      ResourceType = "storage"       // EBS, Disks
-	ResourceManagedK8s   ResourceType = "managed_k8s"   // EKS, AKS control plane
-	ResourceDataTransfer ResourceType = "data_transfer" // Bandwidth
+	ResourceCompute       ResourceType = "compute"       // EC2, VMs
+	ResourceNetwork       ResourceType = "network"       // VPC, VNet, Load Balancers
+	ResourceStorage       ResourceType = "storage"       // EBS, Disks
+	ResourceManagedK8s    ResourceType = "managed_k8s"   // EKS, AKS control plane
+	ResourceDataTransfer  ResourceType = "data_transfer" // Bandwidth
+	ResourceObservability ResourceType = "observability" // CloudWatch, Log Analytics
 )
 
+// DefaultLogVolumeGB is the assumed monthly control-plane log ingestion when
+// the observability block does not specify one
+const DefaultLogVolumeGB = 10.0
+
 // PricingData contains pricing information for resources
 type PricingData struct {
 	Provider      string
@@ -62,6 +79,7 @@ type PricingData struct {
 	ManagedK8s    ManagedK8sPrice
 	Network       NetworkPrice
 	Storage       StoragePrice
+	Observability ObservabilityPrice
 }
 
 // InstancePrice contains instance pricing
@@ -70,6 +88,7 @@ type InstancePrice struct {
 	SpotHourly     float64
 	VCPU           int
 	MemoryGB       float64
+	MaxPods        int // Pods a node can run with the provider's default CNI
 }
 
 // ManagedK8sPrice contains managed Kubernetes pricing
@@ -81,10 +100,17 @@ type ManagedK8sPrice struct {
 // NetworkPrice contains network resource pricing
 type NetworkPrice struct {
 	LoadBalancerHourly  float64
+	LoadBalancerPerGB   float64 // Data processed, billed per NLCU on AWS
 	NATGatewayHourly    float64
 	DataTransferPerGB   float64
 }
 
+// ObservabilityPrice contains logging and monitoring pricing
+type ObservabilityPrice struct {
+	LogIngestionPerGB   float64
+	MetricsPerNodeMonth float64
+}
+
 // StoragePrice contains storage pricing
 type StoragePrice struct {
 	GP3PerGBMonth float64
@@ -100,14 +126,19 @@ func (e *Estimator) EstimateCost(ctx context.Context, spec api.ClusterSpec) (*Co
 		Assumptions: []string{
 			"Assumes 730 hours per month (24/7 operation)",
 			"Prices based on latest public pricing data",
-			"Does not include data transfer or storage costs",
+			"Does not include storage costs",
 		},
 	}
 
-	pricing, err := e.getPricing(spec.Provider, spec.Region)
+	pricing, found, err := e.getPricing(ctx, spec.Provider, spec.Region)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pricing data: %w", err)
 	}
+	if !found {
+		estimate.FallbackPricing = true
+		estimate.Warnings = append(estimate.Warnings,
+			fmt.Sprintf("⚠ No pricing data for %s/%s — using generic estimates", spec.Provider, spec.Region))
+	}
 
 	// Estimate control plane costs
 	cpCost := e.estimateControlPlane(spec, pricing)
@@ -117,11 +148,33 @@ func (e *Estimator) EstimateCost(ctx context.Context, spec api.ClusterSpec) (*Co
 	for _, pool := range spec.WorkerPools {
 		poolCosts := e.estimateWorkerPool(spec, pool, pricing)
 		estimate.Breakdown = append(estimate.Breakdown, poolCosts...)
+		if base, premium := onDemandFallbackPremium(pool, pricing); premium > 0 {
+			estimate.Warnings = append(estimate.Warnings,
+				fmt.Sprintf("⚠ Spot pool %s falls back to on-demand: its %d base node(s) stay on on-demand capacity through spot shortages, costing $%.2f/month more than spot",
+					pool.Name, base, premium))
+		}
 	}
 
 	// Estimate network costs
 	networkCost := e.estimateNetwork(spec, pricing)
 	estimate.Breakdown = append(estimate.Breakdown, networkCost...)
+	if spec.Network.LBProcessedGB <= 0 {
+		estimate.Warnings = append(estimate.Warnings,
+			"⚠ Load balancer data processing is excluded (set network.lb_processed_gb to include it)")
+	}
+	if spec.Network.EgressGB <= 0 {
+		estimate.Assumptions = append(estimate.Assumptions,
+			"Does not include data transfer out (set network.egress_gb to include it)")
+	}
+
+	// Estimate observability add-on costs
+	observabilityCost := e.estimateObservability(spec, pricing)
+	estimate.Breakdown = append(estimate.Breakdown, observabilityCost...)
+	if spec.Observability.LogsEnabled() {
+		estimate.Assumptions = append(estimate.Assumptions,
+			fmt.Sprintf("Control-plane log cost assumes %.0f GB/month ingestion (set observability.log_volume_gb to refine)",
+				logVolumeGB(spec.Observability)))
+	}
 
 	// Calculate totals
 	for _, item := range estimate.Breakdown {
@@ -151,6 +204,9 @@ func (e *Estimator) estimateControlPlane(spec api.ClusterSpec, pricing PricingDa
 	if spec.ControlPlane.Type == api.ControlPlaneManaged {
 		// Managed Kubernetes (EKS/AKS)
 		hourlyCost := pricing.ManagedK8s.ControlPlaneHourly
+		if hourlyCost == 0 {
+			return costs // Free control plane tier (e.g. AKS)
+		}
 		costs = append(costs, CostBreakdown{
 			Resource: api.ResourceID{
 				Provider: spec.Provider,
@@ -201,23 +257,22 @@ func (e *Estimator) estimateControlPlane(spec api.ClusterSpec, pricing PricingDa
 func (e *Estimator) estimateWorkerPool(spec api.ClusterSpec, pool api.WorkerPoolSpec, pricing PricingData) []CostBreakdown {
 	var costs []CostBreakdown
 
-	instancePrice, exists := pricing.InstanceTypes[pool.InstanceType]
+	// Diversified spot pools are estimated at their cheapest type
+	instanceType, instancePrice, exists := cheapestInstanceType(pool, pricing)
 	if !exists {
+		instanceType = pool.InstanceType
 		instancePrice = InstancePrice{OnDemandHourly: 0.10} // Default estimate
 	}
 
-	// Use desired size, or average of min/max
-	nodeCount := pool.DesiredSize
-	if nodeCount == 0 {
-		nodeCount = (pool.MinSize + pool.MaxSize) / 2
-	}
+	// Use desired size, or average of min/max. The sizes of a weighted pool
+	// are capacity units, which fewer, larger instances may provide.
+	capacity := poolNodeCount(pool)
+	weight := pool.InstanceWeight(instanceType)
+	nodeCount := weightedInstances(capacity, weight)
 
 	unitCost := instancePrice.OnDemandHourly
 	if pool.Spot != nil && pool.Spot.Enabled {
-		unitCost = instancePrice.SpotHourly
-		if pool.Spot.MaxPrice > 0 && pool.Spot.MaxPrice < unitCost {
-			unitCost = pool.Spot.MaxPrice
-		}
+		unitCost = spotUnitCost(pool, instancePrice)
 	}
 
 	hourlyCost := unitCost * float64(nodeCount)
@@ -225,6 +280,18 @@ func (e *Estimator) estimateWorkerPool(spec api.ClusterSpec, pool api.WorkerPool
 	if pool.Spot != nil && pool.Spot.Enabled {
 		costType = "spot"
 	}
+	// The on-demand base of a pool falling back to on-demand blends the rate
+	if base := weightedInstances(min(pool.OnDemandBase(), capacity), weight); base > 0 {
+		hourlyCost = unitCost*float64(nodeCount-base) + instancePrice.OnDemandHourly*float64(base)
+		unitCost = hourlyCost / float64(nodeCount)
+		costType += fmt.Sprintf(", %d on-demand base", base)
+	}
+	if types := len(pool.AllInstanceTypes()); types > 1 {
+		costType += fmt.Sprintf(", cheapest of %d types", types)
+	}
+	if pool.Weighted() {
+		costType += fmt.Sprintf(", %d units at weight %d", capacity, weight)
+	}
 
 	costs = append(costs, CostBreakdown{
 		Resource: api.ResourceID{
@@ -237,7 +304,7 @@ func (e *Estimator) estimateWorkerPool(spec api.ClusterSpec, pool api.WorkerPool
 		UnitCost:     unitCost,
 		HourlyCost:   hourlyCost,
 		MonthlyCost:  hourlyCost * 730,
-		Details:      fmt.Sprintf("%d x %s (%s)", nodeCount, pool.InstanceType, costType),
+		Details:      fmt.Sprintf("%d x %s (%s)", nodeCount, instanceType, costType),
 	})
 
 	return costs
@@ -285,9 +352,150 @@ func (e *Estimator) estimateNetwork(spec api.ClusterSpec, pricing PricingData) [
 		Details:      "Network Load Balancer",
 	})
 
+	// Load Balancer data processing, only when the traffic is known
+	if volume := spec.Network.LBProcessedGB; volume > 0 {
+		monthlyCost := volume * pricing.Network.LoadBalancerPerGB
+		costs = append(costs, CostBreakdown{
+			Resource: api.ResourceID{
+				Provider: spec.Provider,
+				Kind:     "Network",
+				Name:     "load-balancer-data",
+			},
+			ResourceType: ResourceNetwork,
+			Quantity:     1,
+			UnitCost:     monthlyCost / 730,
+			HourlyCost:   monthlyCost / 730,
+			MonthlyCost:  monthlyCost,
+			Details: fmt.Sprintf("~%.0f GB/month processed at $%.4f/GB (estimated)",
+				volume, pricing.Network.LoadBalancerPerGB),
+		})
+	}
+
+	// Data transfer out to the internet, only when the traffic is known
+	if volume := spec.Network.EgressGB; volume > 0 {
+		monthlyCost := volume * pricing.Network.DataTransferPerGB
+		costs = append(costs, CostBreakdown{
+			Resource: api.ResourceID{
+				Provider: spec.Provider,
+				Kind:     "Network",
+				Name:     "data-transfer-out",
+			},
+			ResourceType: ResourceDataTransfer,
+			Quantity:     1,
+			UnitCost:     monthlyCost / 730,
+			HourlyCost:   monthlyCost / 730,
+			MonthlyCost:  monthlyCost,
+			Details: fmt.Sprintf("~%.0f GB/month transferred out at $%.4f/GB (estimated)",
+				volume, pricing.Network.DataTransferPerGB),
+		})
+	}
+
+	return costs
+}
+
+func (e *Estimator) estimateObservability(spec api.ClusterSpec, pricing PricingData) []CostBreakdown {
+	var costs []CostBreakdown
+
+	if spec.Observability == nil {
+		return costs
+	}
+
+	// Control-plane log ingestion (CloudWatch Logs / Log Analytics)
+	if spec.Observability.LogsEnabled() {
+		volume := logVolumeGB(spec.Observability)
+		monthlyCost := volume * pricing.Observability.LogIngestionPerGB
+		costs = append(costs, CostBreakdown{
+			Resource: api.ResourceID{
+				Provider: spec.Provider,
+				Kind:     "Observability",
+				Name:     "control-plane-logs",
+			},
+			ResourceType: ResourceObservability,
+			Quantity:     1,
+			UnitCost:     monthlyCost / 730,
+			HourlyCost:   monthlyCost / 730,
+			MonthlyCost:  monthlyCost,
+			Details: fmt.Sprintf("~%.0f GB/month log ingestion at $%.2f/GB (estimated)",
+				volume, pricing.Observability.LogIngestionPerGB),
+		})
+	}
+
+	// Metrics collection, billed roughly per monitored node
+	if spec.Observability.Metrics {
+		nodeCount := 0
+		for _, pool := range spec.WorkerPools {
+			nodeCount += poolNodeCount(pool)
+		}
+
+		unitCost := pricing.Observability.MetricsPerNodeMonth / 730
+		hourlyCost := unitCost * float64(nodeCount)
+		costs = append(costs, CostBreakdown{
+			Resource: api.ResourceID{
+				Provider: spec.Provider,
+				Kind:     "Observability",
+				Name:     "metrics",
+			},
+			ResourceType: ResourceObservability,
+			Quantity:     nodeCount,
+			UnitCost:     unitCost,
+			HourlyCost:   hourlyCost,
+			MonthlyCost:  hourlyCost * 730,
+			Details:      fmt.Sprintf("Metrics for %d node(s) (estimated)", nodeCount),
+		})
+	}
+
 	return costs
 }
 
+func logVolumeGB(obs *api.ObservabilitySpec) float64 {
+	if obs.LogVolumeGB > 0 {
+		return obs.LogVolumeGB
+	}
+	return DefaultLogVolumeGB
+}
+
+// poolNodeCount returns the node count used for estimation: desired size, or
+// the average of min/max when no desired size is set
+func poolNodeCount(pool api.WorkerPoolSpec) int {
+	if pool.DesiredSize > 0 {
+		return pool.DesiredSize
+	}
+	return (pool.MinSize + pool.MaxSize) / 2
+}
+
+// weightedInstances returns how many instances of weight capacity units
+// each make up capacity. Like Auto Scaling, it rounds up, overshooting the
+// capacity rather than falling short of it.
+func weightedInstances(capacity, weight int) int {
+	return (capacity + weight - 1) / weight
+}
+
+// spotUnitCost returns the hourly spot price of a pool's instances, capped
+// at the pool's maximum price
+func spotUnitCost(pool api.WorkerPoolSpec, price InstancePrice) float64 {
+	unitCost := price.SpotHourly
+	if pool.Spot.MaxPrice > 0 && pool.Spot.MaxPrice < unitCost {
+		unitCost = pool.Spot.MaxPrice
+	}
+	return unitCost
+}
+
+// onDemandFallbackPremium returns how many nodes of a spot pool falling back
+// to on-demand run on-demand, and what they cost per month over running as
+// spot
+func onDemandFallbackPremium(pool api.WorkerPoolSpec, pricing PricingData) (int, float64) {
+	baseCapacity := min(pool.OnDemandBase(), poolNodeCount(pool))
+	if baseCapacity == 0 {
+		return 0, 0
+	}
+	instanceType, instancePrice, exists := cheapestInstanceType(pool, pricing)
+	base := weightedInstances(baseCapacity, pool.InstanceWeight(instanceType))
+	if !exists {
+		return base, 0
+	}
+	return base, (instancePrice.OnDemandHourly - spotUnitCost(pool, instancePrice)) * float64(base) * 730
+}
+
 func (e *Estimator) calculateSpotSavings(spec api.ClusterSpec, pricing PricingData) float64 {
 	savings := 0.0
 
@@ -301,10 +509,7 @@ func (e *Estimator) calculateSpotSavings(spec api.ClusterSpec, pricing PricingDa
 			continue
 		}
 
-		nodeCount := pool.DesiredSize
-		if nodeCount == 0 {
-			nodeCount = (pool.MinSize + pool.MaxSize) / 2
-		}
+		nodeCount := poolNodeCount(pool)
 
 		onDemandMonthlyCost := instancePrice.OnDemandHourly * float64(nodeCount) * 730
 		spotMonthlyCost := instancePrice.SpotHourly * float64(nodeCount) * 730
@@ -314,10 +519,16 @@ func (e *Estimator) calculateSpotSavings(spec api.ClusterSpec, pricing PricingDa
 	return savings
 }
 
-func (e *Estimator) getPricing(provider, region string) (PricingData, error) {
-	key := provider + "-" + region
-	if data, exists := e.pricingData[key]; exists {
-		return data, nil
+// getPricing returns the pricing of a provider region, reporting whether
+// real data exists for it. Regions the price provider has no data for get
+// generic default prices.
+func (e *Estimator) getPricing(ctx context.Context, provider, region string) (PricingData, bool, error) {
+	data, err := e.prices.GetPricing(ctx, provider, region)
+	if err == nil {
+		return data, true, nil
+	}
+	if !errors.Is(err, ErrNoPricing) {
+		return PricingData{}, false, err
 	}
 
 	// Return default pricing if not found
@@ -337,6 +548,7 @@ func (e *Estimator) getPricing(provider, region string) (PricingData, error) {
 		},
 		Network: NetworkPrice{
 			LoadBalancerHourly: 0.025,
+			LoadBalancerPerGB:  0.006,
 			NATGatewayHourly:   0.045,
 			DataTransferPerGB:  0.09,
 		},
@@ -344,44 +556,18 @@ func (e *Estimator) getPricing(provider, region string) (PricingData, error) {
 			GP3PerGBMonth: 0.08,
 			IOPSPerMonth:  0.005,
 		},
-	}, nil
-}
-
-func loadPricingData() map[string]PricingData {
-	// In production, this would load from a pricing database or API
-	// For now, return hardcoded common pricing
-	return map[string]PricingData{
-		"aws-us-west-2": {
-			Provider: "aws",
-			Region:   "us-west-2",
-			InstanceTypes: map[string]InstancePrice{
-				"t3.medium": {OnDemandHourly: 0.0416, SpotHourly: 0.0125, VCPU: 2, MemoryGB: 4},
-				"t3.large":  {OnDemandHourly: 0.0832, SpotHourly: 0.0250, VCPU: 2, MemoryGB: 8},
-				"c5.xlarge": {OnDemandHourly: 0.170, SpotHourly: 0.0510, VCPU: 4, MemoryGB: 8},
-			},
-			ManagedK8s: ManagedK8sPrice{ControlPlaneHourly: 0.10},
-			Network:    NetworkPrice{LoadBalancerHourly: 0.025, NATGatewayHourly: 0.045},
-			Storage:    StoragePrice{GP3PerGBMonth: 0.08},
-		},
-		"azure-eastus": {
-			Provider: "azure",
-			Region:   "eastus",
-			InstanceTypes: map[string]InstancePrice{
-				"Standard_D2s_v3": {OnDemandHourly: 0.096, SpotHourly: 0.0288, VCPU: 2, MemoryGB: 8},
-				"Standard_D4s_v3": {OnDemandHourly: 0.192, SpotHourly: 0.0576, VCPU: 4, MemoryGB: 16},
-			},
-			ManagedK8s: ManagedK8sPrice{ControlPlaneHourly: 0.00}, // AKS is free
-			Network:    NetworkPrice{LoadBalancerHourly: 0.025, NATGatewayHourly: 0.045},
-			Storage:    StoragePrice{GP3PerGBMonth: 0.08},
+		Observability: ObservabilityPrice{
+			LogIngestionPerGB:   0.50,
+			MetricsPerNodeMonth: 2.50,
 		},
-	}
+	}, false, nil
 }
 
 // FormatEstimate generates a human-readable cost estimate
 func FormatEstimate(estimate *CostEstimate) string {
 	output := fmt.Sprintf("💰 Cost Estimate (generated %s)\n\n", estimate.EstimatedAt.Format("2006-01-02 15:04:05"))
 	
-	output += fmt.Sprintf("Total Monthly Cost: $%.2f\n", estimate.TotalMonthlyCost)
+	output += fmt.Sprintf("Total Monthly Cost: %s\n", format.Money(estimate.TotalMonthlyCost, estimate.Currency))
 	output += fmt.Sprintf("Total Hourly Cost:  $%.4f\n\n", estimate.TotalHourlyCost)
 
 	output += "Breakdown by Resource:\n"
@@ -391,10 +577,10 @@ func FormatEstimate(estimate *CostEstimate) string {
 	for _, item := range estimate.Breakdown {
 		typeBreakdown[item.ResourceType] += item.MonthlyCost
 		
-		output += fmt.Sprintf("  • %s/%s: $%.2f/month\n",
+		output += fmt.Sprintf("  • %s/%s: %s/month\n",
 			item.Resource.Kind,
 			item.Resource.Name,
-			item.MonthlyCost,
+			format.Money(item.MonthlyCost, estimate.Currency),
 		)
 		output += fmt.Sprintf("    %s ($%.4f/hour x %d)\n\n",
 			item.Details,
@@ -406,7 +592,7 @@ func FormatEstimate(estimate *CostEstimate) string {
 	output += "Breakdown by Type:\n"
 	for resType, cost := range typeBreakdown {
 		percentage := (cost / estimate.TotalMonthlyCost) * 100
-		output += fmt.Sprintf("  • %s: $%.2f/month (%.1f%%)\n", resType, cost, percentage)
+		output += fmt.Sprintf("  • %s: %s/month (%.1f%%)\n", resType, format.Money(cost, estimate.Currency), percentage)
 	}
 
 	if len(estimate.Assumptions) > 0 {
@@ -425,3 +611,16 @@ func FormatEstimate(estimate *CostEstimate) string {
 
 	return output
 }
+
+// FormatMonthlyDelta formats a change in monthly cost rounded to whole
+// dollars, such as "+$1,240/mo" or "-$75/mo"
+func FormatMonthlyDelta(delta float64) string {
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+
+	dollars := strings.TrimSuffix(format.Money(math.Round(delta), "USD"), ".00")
+	return sign + dollars + "/mo"
+}
@@ -0,0 +1,83 @@
+package cost
+
+import (
+	"context"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// Instance architectures reported by InstanceArch
+const (
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+)
+
+// InstanceType returns the price and size of an instance type in a region,
+// reporting whether pricing data knows it
+func (e *Estimator) InstanceType(ctx context.Context, provider, region, instanceType string) (InstancePrice, bool) {
+	pricing, _, err := e.getPricing(ctx, provider, region)
+	if err != nil {
+		return InstancePrice{}, false
+	}
+	price, exists := pricing.InstanceTypes[instanceType]
+	return price, exists
+}
+
+// InstanceArch infers the CPU architecture of an instance type from its
+// name. Graviton families carry a "g" after the generation (m6g, c7gn) and
+// Azure Ampere sizes a "p" in their features (D4ps_v5).
+func InstanceArch(provider, instanceType string) string {
+	family := instanceType
+	switch provider {
+	case "aws":
+		family, _, _ = strings.Cut(instanceType, ".")
+		if family == "a1" {
+			return ArchARM64
+		}
+	case "azure":
+		family = strings.TrimPrefix(instanceType, "Standard_")
+		family, _, _ = strings.Cut(family, "_")
+	}
+
+	// Skip the series letters and the generation or vCPU digits; what is
+	// left are the feature letters
+	features := strings.TrimLeft(family, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+	features = strings.TrimLeft(features, "0123456789")
+
+	marker := "g"
+	if provider == "azure" {
+		marker = "p"
+	}
+	if strings.Contains(features, marker) {
+		return ArchARM64
+	}
+	return ArchAMD64
+}
+
+// cheapestInstanceType returns the cheapest of a pool's instance types that
+// pricing data knows, at spot or on-demand rates depending on the pool and
+// per capacity unit when the pool is weighted
+func cheapestInstanceType(pool api.WorkerPoolSpec, pricing PricingData) (string, InstancePrice, bool) {
+	spot := pool.Spot != nil && pool.Spot.Enabled
+	unitHourly := func(instanceType string, price InstancePrice) float64 {
+		rate := price.OnDemandHourly
+		if spot {
+			rate = price.SpotHourly
+		}
+		return rate / float64(pool.InstanceWeight(instanceType))
+	}
+
+	var cheapest string
+	var cheapestPrice InstancePrice
+	for _, instanceType := range pool.AllInstanceTypes() {
+		price, exists := pricing.InstanceTypes[instanceType]
+		if !exists {
+			continue
+		}
+		if cheapest == "" || unitHourly(instanceType, price) < unitHourly(cheapest, cheapestPrice) {
+			cheapest, cheapestPrice = instanceType, price
+		}
+	}
+	return cheapest, cheapestPrice, cheapest != ""
+}
@@ -0,0 +1,41 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoPricing is returned by a PriceProvider without prices for a region
+var ErrNoPricing = errors.New("no pricing data")
+
+// PriceProvider supplies the prices the estimator calculates with, such as
+// the built-in static prices or a cloud's pricing API
+type PriceProvider interface {
+	// GetPricing returns the prices of a provider region. An error wrapping
+	// ErrNoPricing makes the estimator fall back to generic prices; any other
+	// error fails the estimate.
+	GetPricing(ctx context.Context, provider, region string) (PricingData, error)
+}
+
+// StaticPriceProvider serves the prices built into provctl for the most used
+// regions of each provider
+type StaticPriceProvider struct {
+	data map[string]PricingData // By provider-region
+}
+
+var _ PriceProvider = (*StaticPriceProvider)(nil)
+
+// NewStaticPriceProvider creates a provider of the built-in prices
+func NewStaticPriceProvider() *StaticPriceProvider {
+	return &StaticPriceProvider{data: loadPricingData()}
+}
+
+// GetPricing returns the built-in prices of a region
+func (s *StaticPriceProvider) GetPricing(ctx context.Context, provider, region string) (PricingData, error) {
+	data, exists := s.data[provider+"-"+region]
+	if !exists {
+		return PricingData{}, fmt.Errorf("%s region %s: %w", provider, region, ErrNoPricing)
+	}
+	return data, nil
+}
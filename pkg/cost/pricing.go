@@ -0,0 +1,337 @@
+package cost
+
+// rate is the on-demand and typical spot hourly price of an instance type
+type rate struct {
+	onDemand float64
+	spot     float64
+}
+
+// size is the shape of an instance type, the same in every region
+type size struct {
+	vcpu     int
+	memoryGB float64
+	maxPods  int
+}
+
+// eniMaxPods returns the pods the AWS VPC CNI can run on an instance with the
+// given network interfaces and IPv4 addresses per interface. Each interface
+// keeps its primary address for itself, and the two host-network pods,
+// aws-node and kube-proxy, need no address.
+func eniMaxPods(interfaces, ipsPerInterface int) int {
+	return interfaces*(ipsPerInterface-1) + 2
+}
+
+var awsInstanceSizes = map[string]size{
+	"t3.medium":  {2, 4, eniMaxPods(3, 6)},
+	"t3.large":   {2, 8, eniMaxPods(3, 12)},
+	"t3.xlarge":  {4, 16, eniMaxPods(4, 15)},
+	"m5.large":   {2, 8, eniMaxPods(3, 10)},
+	"m5.xlarge":  {4, 16, eniMaxPods(4, 15)},
+	"m5.2xlarge": {8, 32, eniMaxPods(4, 15)},
+	"c5.large":   {2, 4, eniMaxPods(3, 10)},
+	"c5.xlarge":  {4, 8, eniMaxPods(4, 15)},
+	"r5.large":   {2, 16, eniMaxPods(3, 10)},
+	"r5.xlarge":  {4, 32, eniMaxPods(4, 15)},
+}
+
+// aksMaxPods is the most pods AKS allows on a node, whatever its VM size
+const aksMaxPods = 250
+
+var azureInstanceSizes = map[string]size{
+	"Standard_B2s":    {2, 4, aksMaxPods},
+	"Standard_D2s_v3": {2, 8, aksMaxPods},
+	"Standard_D4s_v3": {4, 16, aksMaxPods},
+	"Standard_D8s_v3": {8, 32, aksMaxPods},
+	"Standard_D2s_v5": {2, 8, aksMaxPods},
+	"Standard_D4s_v5": {4, 16, aksMaxPods},
+	"Standard_E4s_v3": {4, 32, aksMaxPods},
+	"Standard_F4s_v2": {4, 8, aksMaxPods},
+}
+
+// instanceTypes combines per-region rates with instance sizes
+func instanceTypes(sizes map[string]size, rates map[string]rate) map[string]InstancePrice {
+	prices := make(map[string]InstancePrice, len(rates))
+	for name, r := range rates {
+		s := sizes[name]
+		prices[name] = InstancePrice{
+			OnDemandHourly: r.onDemand,
+			SpotHourly:     r.spot,
+			VCPU:           s.vcpu,
+			MemoryGB:       s.memoryGB,
+			MaxPods:        s.maxPods,
+		}
+	}
+	return prices
+}
+
+// awsRegion builds the pricing of an AWS region. The EKS control plane is
+// billed at the same rate everywhere.
+func awsRegion(region string, rates map[string]rate, network NetworkPrice, logPerGB float64) PricingData {
+	return PricingData{
+		Provider:      "aws",
+		Region:        region,
+		InstanceTypes: instanceTypes(awsInstanceSizes, rates),
+		ManagedK8s:    ManagedK8sPrice{ControlPlaneHourly: 0.10},
+		Network:       network,
+		Storage:       StoragePrice{GP3PerGBMonth: 0.08},
+		Observability: ObservabilityPrice{
+			LogIngestionPerGB:   logPerGB, // CloudWatch Logs (vended logs)
+			MetricsPerNodeMonth: 2.50,     // Container Insights
+		},
+	}
+}
+
+// azureRegion builds the pricing of an Azure region. The AKS free tier has
+// no control plane charge.
+func azureRegion(region string, rates map[string]rate, network NetworkPrice, logPerGB float64) PricingData {
+	return PricingData{
+		Provider:      "azure",
+		Region:        region,
+		InstanceTypes: instanceTypes(azureInstanceSizes, rates),
+		ManagedK8s:    ManagedK8sPrice{ControlPlaneHourly: 0.00},
+		Network:       network,
+		Storage:       StoragePrice{GP3PerGBMonth: 0.08},
+		Observability: ObservabilityPrice{
+			LogIngestionPerGB:   logPerGB, // Log Analytics pay-as-you-go
+			MetricsPerNodeMonth: 1.50,     // Azure Monitor managed Prometheus
+		},
+	}
+}
+
+// awsUSRates are shared by us-east-1, us-east-2 and us-west-2
+var awsUSRates = map[string]rate{
+	"t3.medium":  {0.0416, 0.0125},
+	"t3.large":   {0.0832, 0.0250},
+	"t3.xlarge":  {0.1664, 0.0499},
+	"m5.large":   {0.096, 0.0350},
+	"m5.xlarge":  {0.192, 0.0700},
+	"m5.2xlarge": {0.384, 0.1400},
+	"c5.large":   {0.085, 0.0300},
+	"c5.xlarge":  {0.170, 0.0510},
+	"r5.large":   {0.126, 0.0380},
+	"r5.xlarge":  {0.252, 0.0760},
+}
+
+// azureUSRates are shared by eastus, eastus2 and westus2
+var azureUSRates = map[string]rate{
+	"Standard_B2s":    {0.0416, 0.0125},
+	"Standard_D2s_v3": {0.096, 0.0288},
+	"Standard_D4s_v3": {0.192, 0.0576},
+	"Standard_D8s_v3": {0.384, 0.1152},
+	"Standard_D2s_v5": {0.096, 0.0192},
+	"Standard_D4s_v5": {0.192, 0.0384},
+	"Standard_E4s_v3": {0.252, 0.0504},
+	"Standard_F4s_v2": {0.169, 0.0338},
+}
+
+func loadPricingData() map[string]PricingData {
+	// In production, this would load from a pricing database or API.
+	// For now, return hardcoded on-demand and typical spot rates for the
+	// most used regions of each provider.
+	usNetwork := NetworkPrice{LoadBalancerHourly: 0.025, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.045, DataTransferPerGB: 0.09}
+	azureNetwork := NetworkPrice{LoadBalancerHourly: 0.025, LoadBalancerPerGB: 0.005, NATGatewayHourly: 0.045, DataTransferPerGB: 0.087}
+
+	regions := []PricingData{
+		awsRegion("us-east-1", awsUSRates, usNetwork, 0.50),
+		awsRegion("us-east-2", awsUSRates, usNetwork, 0.50),
+		awsRegion("us-west-2", awsUSRates, usNetwork, 0.50),
+		awsRegion("us-west-1", map[string]rate{
+			"t3.medium":  {0.0496, 0.0149},
+			"t3.large":   {0.0992, 0.0298},
+			"t3.xlarge":  {0.1984, 0.0595},
+			"m5.large":   {0.112, 0.0400},
+			"m5.xlarge":  {0.224, 0.0800},
+			"m5.2xlarge": {0.448, 0.1600},
+			"c5.large":   {0.106, 0.0370},
+			"c5.xlarge":  {0.212, 0.0740},
+			"r5.large":   {0.148, 0.0450},
+			"r5.xlarge":  {0.296, 0.0900},
+		}, NetworkPrice{LoadBalancerHourly: 0.0252, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.048, DataTransferPerGB: 0.09}, 0.50),
+		awsRegion("ca-central-1", map[string]rate{
+			"t3.medium":  {0.0464, 0.0139},
+			"t3.large":   {0.0928, 0.0278},
+			"t3.xlarge":  {0.1856, 0.0557},
+			"m5.large":   {0.107, 0.0380},
+			"m5.xlarge":  {0.214, 0.0760},
+			"m5.2xlarge": {0.428, 0.1520},
+			"c5.large":   {0.093, 0.0330},
+			"c5.xlarge":  {0.186, 0.0660},
+			"r5.large":   {0.138, 0.0420},
+			"r5.xlarge":  {0.276, 0.0840},
+		}, NetworkPrice{LoadBalancerHourly: 0.02475, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.05, DataTransferPerGB: 0.09}, 0.55),
+		awsRegion("eu-west-1", map[string]rate{
+			"t3.medium":  {0.0456, 0.0137},
+			"t3.large":   {0.0912, 0.0274},
+			"t3.xlarge":  {0.1824, 0.0547},
+			"m5.large":   {0.107, 0.0390},
+			"m5.xlarge":  {0.214, 0.0780},
+			"m5.2xlarge": {0.428, 0.1560},
+			"c5.large":   {0.096, 0.0340},
+			"c5.xlarge":  {0.192, 0.0680},
+			"r5.large":   {0.141, 0.0430},
+			"r5.xlarge":  {0.282, 0.0860},
+		}, NetworkPrice{LoadBalancerHourly: 0.0252, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.048, DataTransferPerGB: 0.09}, 0.57),
+		awsRegion("eu-west-2", map[string]rate{
+			"t3.medium":  {0.0472, 0.0142},
+			"t3.large":   {0.0944, 0.0283},
+			"t3.xlarge":  {0.1888, 0.0566},
+			"m5.large":   {0.111, 0.0400},
+			"m5.xlarge":  {0.222, 0.0800},
+			"m5.2xlarge": {0.444, 0.1600},
+			"c5.large":   {0.101, 0.0360},
+			"c5.xlarge":  {0.202, 0.0720},
+			"r5.large":   {0.148, 0.0450},
+			"r5.xlarge":  {0.296, 0.0900},
+		}, NetworkPrice{LoadBalancerHourly: 0.02646, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.05, DataTransferPerGB: 0.09}, 0.5985),
+		awsRegion("eu-central-1", map[string]rate{
+			"t3.medium":  {0.048, 0.0144},
+			"t3.large":   {0.096, 0.0288},
+			"t3.xlarge":  {0.192, 0.0576},
+			"m5.large":   {0.115, 0.0410},
+			"m5.xlarge":  {0.230, 0.0820},
+			"m5.2xlarge": {0.460, 0.1640},
+			"c5.large":   {0.097, 0.0350},
+			"c5.xlarge":  {0.194, 0.0700},
+			"r5.large":   {0.152, 0.0460},
+			"r5.xlarge":  {0.304, 0.0920},
+		}, NetworkPrice{LoadBalancerHourly: 0.027, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.052, DataTransferPerGB: 0.09}, 0.63),
+		awsRegion("ap-southeast-1", map[string]rate{
+			"t3.medium":  {0.0528, 0.0158},
+			"t3.large":   {0.1056, 0.0317},
+			"t3.xlarge":  {0.2112, 0.0634},
+			"m5.large":   {0.120, 0.0430},
+			"m5.xlarge":  {0.240, 0.0860},
+			"m5.2xlarge": {0.480, 0.1720},
+			"c5.large":   {0.098, 0.0350},
+			"c5.xlarge":  {0.196, 0.0700},
+			"r5.large":   {0.152, 0.0460},
+			"r5.xlarge":  {0.304, 0.0920},
+		}, NetworkPrice{LoadBalancerHourly: 0.0252, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.059, DataTransferPerGB: 0.12}, 0.70),
+		awsRegion("ap-southeast-2", map[string]rate{
+			"t3.medium":  {0.0528, 0.0158},
+			"t3.large":   {0.1056, 0.0317},
+			"t3.xlarge":  {0.2112, 0.0634},
+			"m5.large":   {0.120, 0.0430},
+			"m5.xlarge":  {0.240, 0.0860},
+			"m5.2xlarge": {0.480, 0.1720},
+			"c5.large":   {0.111, 0.0400},
+			"c5.xlarge":  {0.222, 0.0800},
+			"r5.large":   {0.151, 0.0450},
+			"r5.xlarge":  {0.302, 0.0910},
+		}, NetworkPrice{LoadBalancerHourly: 0.0252, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.059, DataTransferPerGB: 0.114}, 0.76),
+		awsRegion("ap-northeast-1", map[string]rate{
+			"t3.medium":  {0.0544, 0.0163},
+			"t3.large":   {0.1088, 0.0326},
+			"t3.xlarge":  {0.2176, 0.0653},
+			"m5.large":   {0.124, 0.0440},
+			"m5.xlarge":  {0.248, 0.0880},
+			"m5.2xlarge": {0.496, 0.1760},
+			"c5.large":   {0.107, 0.0380},
+			"c5.xlarge":  {0.214, 0.0760},
+			"r5.large":   {0.152, 0.0460},
+			"r5.xlarge":  {0.304, 0.0920},
+		}, NetworkPrice{LoadBalancerHourly: 0.0243, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.062, DataTransferPerGB: 0.114}, 0.76),
+		awsRegion("ap-south-1", map[string]rate{
+			"t3.medium":  {0.0448, 0.0134},
+			"t3.large":   {0.0896, 0.0269},
+			"t3.xlarge":  {0.1792, 0.0538},
+			"m5.large":   {0.101, 0.0360},
+			"m5.xlarge":  {0.202, 0.0720},
+			"m5.2xlarge": {0.404, 0.1440},
+			"c5.large":   {0.085, 0.0300},
+			"c5.xlarge":  {0.170, 0.0600},
+			"r5.large":   {0.127, 0.0380},
+			"r5.xlarge":  {0.254, 0.0760},
+		}, NetworkPrice{LoadBalancerHourly: 0.0239, LoadBalancerPerGB: 0.006, NATGatewayHourly: 0.056, DataTransferPerGB: 0.1093}, 0.76),
+
+		azureRegion("eastus", azureUSRates, azureNetwork, 2.30),
+		azureRegion("eastus2", azureUSRates, azureNetwork, 2.30),
+		azureRegion("westus2", azureUSRates, azureNetwork, 2.30),
+		azureRegion("centralus", map[string]rate{
+			"Standard_B2s":    {0.0416, 0.0125},
+			"Standard_D2s_v3": {0.110, 0.0330},
+			"Standard_D4s_v3": {0.220, 0.0660},
+			"Standard_D8s_v3": {0.440, 0.1320},
+			"Standard_D2s_v5": {0.107, 0.0214},
+			"Standard_D4s_v5": {0.214, 0.0428},
+			"Standard_E4s_v3": {0.288, 0.0576},
+			"Standard_F4s_v2": {0.193, 0.0386},
+		}, azureNetwork, 2.30),
+		azureRegion("canadacentral", map[string]rate{
+			"Standard_B2s":    {0.0460, 0.0138},
+			"Standard_D2s_v3": {0.106, 0.0318},
+			"Standard_D4s_v3": {0.212, 0.0636},
+			"Standard_D8s_v3": {0.424, 0.1272},
+			"Standard_D2s_v5": {0.106, 0.0212},
+			"Standard_D4s_v5": {0.212, 0.0424},
+			"Standard_E4s_v3": {0.278, 0.0556},
+			"Standard_F4s_v2": {0.186, 0.0372},
+		}, azureNetwork, 2.53),
+		azureRegion("westeurope", map[string]rate{
+			"Standard_B2s":    {0.0480, 0.0144},
+			"Standard_D2s_v3": {0.115, 0.0345},
+			"Standard_D4s_v3": {0.230, 0.0690},
+			"Standard_D8s_v3": {0.460, 0.1380},
+			"Standard_D2s_v5": {0.115, 0.0230},
+			"Standard_D4s_v5": {0.230, 0.0460},
+			"Standard_E4s_v3": {0.296, 0.0592},
+			"Standard_F4s_v2": {0.199, 0.0398},
+		}, azureNetwork, 2.99),
+		azureRegion("northeurope", map[string]rate{
+			"Standard_B2s":    {0.0456, 0.0137},
+			"Standard_D2s_v3": {0.107, 0.0321},
+			"Standard_D4s_v3": {0.214, 0.0642},
+			"Standard_D8s_v3": {0.428, 0.1284},
+			"Standard_D2s_v5": {0.107, 0.0214},
+			"Standard_D4s_v5": {0.214, 0.0428},
+			"Standard_E4s_v3": {0.282, 0.0564},
+			"Standard_F4s_v2": {0.188, 0.0376},
+		}, azureNetwork, 2.76),
+		azureRegion("uksouth", map[string]rate{
+			"Standard_B2s":    {0.0472, 0.0142},
+			"Standard_D2s_v3": {0.111, 0.0333},
+			"Standard_D4s_v3": {0.222, 0.0666},
+			"Standard_D8s_v3": {0.444, 0.1332},
+			"Standard_D2s_v5": {0.111, 0.0222},
+			"Standard_D4s_v5": {0.222, 0.0444},
+			"Standard_E4s_v3": {0.292, 0.0584},
+			"Standard_F4s_v2": {0.195, 0.0390},
+		}, azureNetwork, 2.99),
+		azureRegion("southeastasia", map[string]rate{
+			"Standard_B2s":    {0.0528, 0.0158},
+			"Standard_D2s_v3": {0.120, 0.0360},
+			"Standard_D4s_v3": {0.240, 0.0720},
+			"Standard_D8s_v3": {0.480, 0.1440},
+			"Standard_D2s_v5": {0.120, 0.0240},
+			"Standard_D4s_v5": {0.240, 0.0480},
+			"Standard_E4s_v3": {0.304, 0.0608},
+			"Standard_F4s_v2": {0.203, 0.0406},
+		}, azureNetwork, 3.04),
+		azureRegion("australiaeast", map[string]rate{
+			"Standard_B2s":    {0.0528, 0.0158},
+			"Standard_D2s_v3": {0.124, 0.0372},
+			"Standard_D4s_v3": {0.248, 0.0744},
+			"Standard_D8s_v3": {0.496, 0.1488},
+			"Standard_D2s_v5": {0.124, 0.0248},
+			"Standard_D4s_v5": {0.248, 0.0496},
+			"Standard_E4s_v3": {0.326, 0.0652},
+			"Standard_F4s_v2": {0.214, 0.0428},
+		}, azureNetwork, 3.17),
+		azureRegion("japaneast", map[string]rate{
+			"Standard_B2s":    {0.0544, 0.0163},
+			"Standard_D2s_v3": {0.128, 0.0384},
+			"Standard_D4s_v3": {0.256, 0.0768},
+			"Standard_D8s_v3": {0.512, 0.1536},
+			"Standard_D2s_v5": {0.128, 0.0256},
+			"Standard_D4s_v5": {0.256, 0.0512},
+			"Standard_E4s_v3": {0.336, 0.0672},
+			"Standard_F4s_v2": {0.222, 0.0444},
+		}, azureNetwork, 3.22),
+	}
+
+	data := make(map[string]PricingData, len(regions))
+	for _, pricing := range regions {
+		data[pricing.Provider+"-"+pricing.Region] = pricing
+	}
+	return data
+}
@@ -0,0 +1,113 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Recommendation is the instance type RecommendInstanceType picks for a
+// node size, with what it costs and why it was chosen
+type Recommendation struct {
+	InstanceType string
+	Price        InstancePrice
+	HourlyCost   float64 // At spot or on-demand rates, as requested
+	MonthlyCost  float64
+	Spot         bool
+
+	Candidates int // Known instance types meeting the requirement
+	Considered int // Known instance types in the region
+	Runner     string
+	RunnerCost float64 // Hourly cost of Runner, the next cheapest candidate
+}
+
+// Reason explains the choice, such as "cheapest of 4 of 10 instance types
+// with at least 4 vCPU and 16 GB, at on-demand rates; next is m5.xlarge at
+// $0.1920/hour"
+func (r Recommendation) Reason(vcpu int, memoryGB float64) string {
+	rates := "on-demand"
+	if r.Spot {
+		rates = "spot"
+	}
+	reason := fmt.Sprintf("cheapest of %d of %d instance types with at least %d vCPU and %g GB, at %s rates",
+		r.Candidates, r.Considered, vcpu, memoryGB, rates)
+	if r.Runner != "" {
+		reason += fmt.Sprintf("; next is %s at $%.4f/hour", r.Runner, r.RunnerCost)
+	}
+	return reason
+}
+
+// RecommendInstanceType returns the cheapest instance type in a region with
+// at least vcpu vCPUs and memoryGB of memory per node
+func (e *Estimator) RecommendInstanceType(ctx context.Context, provider, region string, vcpu int, memoryGB float64, spot bool) (string, error) {
+	recommendation, err := e.Recommend(ctx, provider, region, vcpu, memoryGB, spot)
+	if err != nil {
+		return "", err
+	}
+	return recommendation.InstanceType, nil
+}
+
+// Recommend picks the instance type RecommendInstanceType returns. Equally
+// priced types are ranked by the smaller size, then by name, so the choice
+// is stable.
+func (e *Estimator) Recommend(ctx context.Context, provider, region string, vcpu int, memoryGB float64, spot bool) (Recommendation, error) {
+	if vcpu <= 0 || memoryGB <= 0 {
+		return Recommendation{}, fmt.Errorf("vCPU and memory must be positive, got %d vCPU and %g GB", vcpu, memoryGB)
+	}
+
+	// The generic fallback prices mix providers' instance types
+	pricing, known, err := e.getPricing(ctx, provider, region)
+	if err != nil {
+		return Recommendation{}, fmt.Errorf("failed to get pricing data: %w", err)
+	}
+	if !known {
+		return Recommendation{}, fmt.Errorf("no pricing data for %s region %s", provider, region)
+	}
+
+	hourly := func(price InstancePrice) float64 {
+		if spot {
+			return price.SpotHourly
+		}
+		return price.OnDemandHourly
+	}
+
+	var candidates []string
+	for instanceType, price := range pricing.InstanceTypes {
+		if price.VCPU >= vcpu && price.MemoryGB >= memoryGB {
+			candidates = append(candidates, instanceType)
+		}
+	}
+	if len(candidates) == 0 {
+		return Recommendation{}, fmt.Errorf("no %s instance type in %s has %d vCPU and %g GB; use more, smaller nodes",
+			provider, region, vcpu, memoryGB)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := pricing.InstanceTypes[candidates[i]], pricing.InstanceTypes[candidates[j]]
+		switch {
+		case hourly(a) != hourly(b):
+			return hourly(a) < hourly(b)
+		case a.VCPU != b.VCPU:
+			return a.VCPU < b.VCPU
+		case a.MemoryGB != b.MemoryGB:
+			return a.MemoryGB < b.MemoryGB
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	chosen := pricing.InstanceTypes[candidates[0]]
+	recommendation := Recommendation{
+		InstanceType: candidates[0],
+		Price:        chosen,
+		HourlyCost:   hourly(chosen),
+		MonthlyCost:  hourly(chosen) * 730,
+		Spot:         spot,
+		Candidates:   len(candidates),
+		Considered:   len(pricing.InstanceTypes),
+	}
+	if len(candidates) > 1 {
+		recommendation.Runner = candidates[1]
+		recommendation.RunnerCost = hourly(pricing.InstanceTypes[candidates[1]])
+	}
+	return recommendation, nil
+}
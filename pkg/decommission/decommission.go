@@ -0,0 +1,221 @@
+// Package decommission runs the ordered steps of retiring a cluster:
+// preserving its state and data, draining its workloads, then deleting it.
+// Each completed step is recorded as an event, so a decommission that stops
+// part way resumes after the last completed step when run again.
+package decommission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Step is a step of decommissioning a cluster
+type Step string
+
+const (
+	StepStateSnapshot  Step = "state-snapshot"  // Snapshot provctl's state before anything changes
+	StepVolumeSnapshot Step = "volume-snapshot" // Snapshot the disks of persistent volumes
+	StepDrain          Step = "drain"           // Cordon the nodes and evict their pods
+	StepDelete         Step = "delete"          // Delete the cluster and forget it
+)
+
+// Steps are the steps of a decommission in the order they run
+var Steps = []Step{StepStateSnapshot, StepVolumeSnapshot, StepDrain, StepDelete}
+
+// Action performs a step and describes what it did
+type Action func(ctx context.Context) (string, error)
+
+// Outcome is what became of a step in a run
+type Outcome string
+
+const (
+	OutcomeDone    Outcome = "done"
+	OutcomeSkipped Outcome = "skipped"
+	OutcomeEarlier Outcome = "done earlier" // Completed by an earlier run
+)
+
+// StepResult reports a step of a run
+type StepResult struct {
+	Step    Step
+	Outcome Outcome
+	Detail  string
+}
+
+// Workflow decommissions one cluster
+type Workflow struct {
+	cluster  *api.Cluster
+	resource api.ResourceID
+	events   engine.EventStore
+	actions  map[Step]Action
+	skip     map[Step]bool
+	since    time.Time // Progress recorded before is ignored
+}
+
+// New creates a workflow for cluster, recording its progress in events
+func New(cluster *api.Cluster, events engine.EventStore) *Workflow {
+	return &Workflow{
+		cluster: cluster,
+		resource: api.ResourceID{
+			Provider: cluster.Spec.Provider,
+			Kind:     "Cluster",
+			ID:       cluster.ID,
+			Name:     cluster.Metadata.Name,
+		},
+		events:  events,
+		actions: make(map[Step]Action),
+		skip:    make(map[Step]bool),
+	}
+}
+
+// Handle sets the action performing step
+func (w *Workflow) Handle(step Step, action Action) {
+	w.actions[step] = action
+}
+
+// Skip leaves step out of the run. A skipped step is not recorded as
+// completed, so a later run without skipping it performs it.
+func (w *Workflow) Skip(step Step) {
+	w.skip[step] = true
+}
+
+// Restart ignores the progress of earlier runs, running every step again
+func (w *Workflow) Restart(now time.Time) {
+	w.since = now
+}
+
+// Completed returns the steps earlier runs completed, with what each did
+func (w *Workflow) Completed(ctx context.Context) (map[Step]string, error) {
+	steps, err := w.recorded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[Step]string)
+	for _, step := range steps {
+		if !step.Incomplete {
+			completed[Step(step.Step)] = step.Detail
+		}
+	}
+	return completed, nil
+}
+
+// Progress returns what earlier runs recorded of step's progress, merged
+func (w *Workflow) Progress(ctx context.Context, step Step) (map[string]string, error) {
+	steps, err := w.recorded(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make(map[string]string)
+	for _, recorded := range steps {
+		if Step(recorded.Step) != step {
+			continue
+		}
+		for key, value := range recorded.Progress {
+			progress[key] = value
+		}
+	}
+	return progress, nil
+}
+
+// RecordProgress records what an action has done so far, so a run after it
+// fails can carry on from there instead of repeating it
+func (w *Workflow) RecordProgress(ctx context.Context, step Step, progress map[string]string) error {
+	event := api.Event{
+		Type:          api.EventDecommissionStep,
+		Resource:      w.resource,
+		Payload:       api.DecommissionStep{Step: string(step), Incomplete: true, Progress: progress},
+		CorrelationID: engine.CorrelationIDFrom(ctx),
+	}
+	if err := w.events.RecordEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record progress of decommission step %s: %w", step, err)
+	}
+	return nil
+}
+
+// recorded returns the decommission steps recorded since the workflow's start
+func (w *Workflow) recorded(ctx context.Context) ([]api.DecommissionStep, error) {
+	events, err := w.events.GetEventsByTimeRange(ctx, w.since, time.Time{}, w.resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decommission progress of cluster %s: %w", w.cluster.Metadata.Name, err)
+	}
+
+	var steps []api.DecommissionStep
+	for _, event := range events {
+		if event.Type != api.EventDecommissionStep {
+			continue
+		}
+		// Stored payloads are decoded as generic JSON
+		raw, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, err
+		}
+		var step api.DecommissionStep
+		if err := json.Unmarshal(raw, &step); err != nil {
+			return nil, fmt.Errorf("invalid decommission step of event %s: %w", event.ID, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// Run performs the steps in order, passing each result to report as it
+// happens. Steps completed by earlier runs and skipped steps are reported
+// without running. The first failing step stops the run.
+func (w *Workflow) Run(ctx context.Context, report func(StepResult)) error {
+	completed, err := w.Completed(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range Steps {
+		if detail, ok := completed[step]; ok {
+			report(StepResult{Step: step, Outcome: OutcomeEarlier, Detail: detail})
+			continue
+		}
+		if w.skip[step] {
+			report(StepResult{Step: step, Outcome: OutcomeSkipped})
+			continue
+		}
+
+		action, ok := w.actions[step]
+		if !ok {
+			return fmt.Errorf("no action handles decommission step %s", step)
+		}
+		detail, err := action(ctx)
+		if err != nil {
+			return &StepError{Step: step, Err: err}
+		}
+
+		event := api.Event{
+			Type:          api.EventDecommissionStep,
+			Resource:      w.resource,
+			Payload:       api.DecommissionStep{Step: string(step), Detail: detail},
+			CorrelationID: engine.CorrelationIDFrom(ctx),
+		}
+		if err := w.events.RecordEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record decommission step %s: %w", step, err)
+		}
+		report(StepResult{Step: step, Outcome: OutcomeDone, Detail: detail})
+	}
+	return nil
+}
+
+// StepError is the failure of a step
+type StepError struct {
+	Step Step
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step %s failed: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,175 @@
+package decommission
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func newEvents(t *testing.T) *state.SQLiteEventStore {
+	t.Helper()
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	t.Cleanup(func() { sm.Close() })
+	return sm.Events()
+}
+
+// recorder handles every step, failing those in fail, and records the steps
+// it ran
+type recorder struct {
+	ran  []Step
+	fail map[Step]error
+}
+
+func (r *recorder) handle(w *Workflow) {
+	for _, step := range Steps {
+		step := step
+		w.Handle(step, func(ctx context.Context) (string, error) {
+			if err := r.fail[step]; err != nil {
+				return "", err
+			}
+			r.ran = append(r.ran, step)
+			return "did " + string(step), nil
+		})
+	}
+}
+
+func TestWorkflow_Run(t *testing.T) {
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}}
+	events := newEvents(t)
+	ctx := context.Background()
+
+	// The first run stops at the drain
+	first := &recorder{fail: map[Step]error{StepDrain: errors.New("eviction blocked")}}
+	w := New(cluster, events)
+	first.handle(w)
+	w.Skip(StepVolumeSnapshot)
+
+	var outcomes []Outcome
+	err := w.Run(ctx, func(r StepResult) { outcomes = append(outcomes, r.Outcome) })
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != StepDrain {
+		t.Fatalf("Run() error = %v, want the drain to fail", err)
+	}
+	if want := []Step{StepStateSnapshot}; !reflect.DeepEqual(first.ran, want) {
+		t.Errorf("first run ran %v, want %v", first.ran, want)
+	}
+	if want := []Outcome{OutcomeDone, OutcomeSkipped}; !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("first run outcomes = %v, want %v", outcomes, want)
+	}
+
+	// The second run resumes, performing the step skipped before
+	second := &recorder{}
+	w = New(cluster, events)
+	second.handle(w)
+
+	var results []StepResult
+	if err := w.Run(ctx, func(r StepResult) { results = append(results, r) }); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := []Step{StepVolumeSnapshot, StepDrain, StepDelete}; !reflect.DeepEqual(second.ran, want) {
+		t.Errorf("second run ran %v, want %v", second.ran, want)
+	}
+	if results[0].Outcome != OutcomeEarlier || results[0].Detail != "did state-snapshot" {
+		t.Errorf("second run reported %+v first, want the state snapshot done earlier", results[0])
+	}
+
+	completed, err := w.Completed(ctx)
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+	if len(completed) != len(Steps) {
+		t.Errorf("Completed() = %v, want every step", completed)
+	}
+}
+
+func TestWorkflow_Restart(t *testing.T) {
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}}
+	events := newEvents(t)
+	ctx := context.Background()
+
+	w := New(cluster, events)
+	(&recorder{}).handle(w)
+	w.Skip(StepDelete)
+	if err := w.Run(ctx, func(StepResult) {}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	again := &recorder{}
+	w = New(cluster, events)
+	again.handle(w)
+	w.Restart(time.Now().Add(time.Second))
+	if err := w.Run(ctx, func(StepResult) {}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(again.ran, Steps) {
+		t.Errorf("restarted run ran %v, want every step", again.ran)
+	}
+}
+
+func TestWorkflow_NotSupported(t *testing.T) {
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "azure"}}
+	w := New(cluster, newEvents(t))
+	r := &recorder{fail: map[Step]error{StepVolumeSnapshot: engine.ErrNotSupported}}
+	r.handle(w)
+
+	err := w.Run(context.Background(), func(StepResult) {})
+	if !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("Run() error = %v, want ErrNotSupported", err)
+	}
+	if len(r.ran) != 1 {
+		t.Errorf("ran %v, want nothing after the unsupported step", r.ran)
+	}
+}
+
+func TestWorkflow_Progress(t *testing.T) {
+	cluster := &api.Cluster{ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}}
+	events := newEvents(t)
+	ctx := context.Background()
+
+	// The first run snapshots one volume before failing
+	w := New(cluster, events)
+	w.Handle(StepVolumeSnapshot, func(ctx context.Context) (string, error) {
+		if err := w.RecordProgress(ctx, StepVolumeSnapshot, map[string]string{"vol-1": "snap-1"}); err != nil {
+			return "", err
+		}
+		return "", errors.New("throttled")
+	})
+	w.Skip(StepStateSnapshot)
+	if err := w.Run(ctx, func(StepResult) {}); err == nil {
+		t.Fatal("Run() error = nil, want the volume snapshot to fail")
+	}
+
+	w = New(cluster, events)
+	if err := w.RecordProgress(ctx, StepVolumeSnapshot, map[string]string{"vol-2": "snap-2"}); err != nil {
+		t.Fatalf("RecordProgress() error = %v", err)
+	}
+	progress, err := w.Progress(ctx, StepVolumeSnapshot)
+	if err != nil {
+		t.Fatalf("Progress() error = %v", err)
+	}
+	if want := map[string]string{"vol-1": "snap-1", "vol-2": "snap-2"}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("Progress() = %v, want %v", progress, want)
+	}
+	completed, err := w.Completed(ctx)
+	if err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+	if _, ok := completed[StepVolumeSnapshot]; ok {
+		t.Error("Completed() includes the volume snapshot, whose progress alone was recorded")
+	}
+
+	w.Restart(time.Now().Add(time.Second))
+	if progress, err := w.Progress(ctx, StepVolumeSnapshot); err != nil || len(progress) != 0 {
+		t.Errorf("Progress() after Restart() = %v, %v, want nothing", progress, err)
+	}
+}
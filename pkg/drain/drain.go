@@ -0,0 +1,280 @@
+// Package drain empties a cluster's nodes through the Kubernetes API before
+// the cluster is deleted, so workloads shut down gracefully, as kubectl
+// drain --ignore-daemonsets does
+package drain
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// DefaultPollInterval is how often evictions blocked by a disruption budget
+// are retried and evicted pods are checked for termination
+const DefaultPollInterval = 5 * time.Second
+
+// mirrorPodAnnotation marks the API server's copy of a static pod, which
+// cannot be evicted
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// Drainer cordons and drains every node of a cluster
+type Drainer struct {
+	endpoint     *url.URL
+	token        string
+	client       *http.Client
+	pollInterval time.Duration
+	logger       *slog.Logger
+}
+
+// Result is what a drain did
+type Result struct {
+	Nodes   int // Nodes cordoned
+	Evicted int // Pods evicted
+}
+
+// NewDrainer creates a drainer for the API server at endpoint. Draining
+// needs credentials: the API server's CA to trust and a token to act with.
+func NewDrainer(endpoint string, credentials *engine.ClusterCredentials, logger *slog.Logger) (*Drainer, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return nil, fmt.Errorf("endpoint %q is not an HTTPS URL", endpoint)
+	}
+	if credentials == nil || len(credentials.CAData) == 0 || credentials.Token == "" {
+		return nil, errors.New("draining needs the cluster's certificate authority and a token")
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(credentials.CAData) {
+		return nil, errors.New("the cluster's certificate authority holds no PEM certificate")
+	}
+
+	return &Drainer{
+		endpoint: parsed,
+		token:    credentials.Token,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+			Timeout:   30 * time.Second,
+		},
+		pollInterval: DefaultPollInterval,
+		logger:       logger,
+	}, nil
+}
+
+// Drain marks every node unschedulable, evicts the pods running on them and
+// waits for the evicted pods to terminate. Evictions a PodDisruptionBudget
+// refuses are retried until ctx ends. DaemonSet pods, static pods and
+// finished pods are left alone.
+func (d *Drainer) Drain(ctx context.Context) (Result, error) {
+	var result Result
+
+	var nodes nodeList
+	if err := d.do(ctx, http.MethodGet, "/api/v1/nodes", "", nil, &nodes); err != nil {
+		return result, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable {
+			result.Nodes++
+			continue
+		}
+		patch := []byte(`{"spec":{"unschedulable":true}}`)
+		if err := d.do(ctx, http.MethodPatch, "/api/v1/nodes/"+url.PathEscape(node.Metadata.Name), "application/merge-patch+json", patch, nil); err != nil {
+			return result, fmt.Errorf("failed to cordon node %s: %w", node.Metadata.Name, err)
+		}
+		d.logger.InfoContext(ctx, "cordoned node", "node", node.Metadata.Name)
+		result.Nodes++
+	}
+
+	var pods podList
+	if err := d.do(ctx, http.MethodGet, "/api/v1/pods", "", nil, &pods); err != nil {
+		return result, fmt.Errorf("failed to list pods: %w", err)
+	}
+	evicted := make(map[string]string) // UID by namespace/name
+	for _, pod := range pods.Items {
+		if !evictable(pod) {
+			continue
+		}
+		if err := d.evict(ctx, pod); err != nil {
+			return result, err
+		}
+		evicted[pod.key()] = pod.Metadata.UID
+		result.Evicted++
+	}
+
+	return result, d.waitForTermination(ctx, evicted)
+}
+
+// evict asks the API server to evict a pod, retrying while a disruption
+// budget refuses. A pod already gone counts as evicted.
+func (d *Drainer) evict(ctx context.Context, p pod) error {
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/eviction", url.PathEscape(p.Metadata.Namespace), url.PathEscape(p.Metadata.Name))
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "policy/v1",
+		"kind":       "Eviction",
+		"metadata":   map[string]string{"name": p.Metadata.Name, "namespace": p.Metadata.Namespace},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		err := d.do(ctx, http.MethodPost, path, "application/json", body, nil)
+		var status *statusError
+		switch {
+		case err == nil:
+			d.logger.InfoContext(ctx, "evicted pod", "pod", p.key(), "node", p.Spec.NodeName)
+			return nil
+		case errors.As(err, &status) && status.code == http.StatusNotFound:
+			return nil
+		case !errors.As(err, &status) || status.code != http.StatusTooManyRequests:
+			return fmt.Errorf("failed to evict pod %s: %w", p.key(), err)
+		}
+
+		d.logger.InfoContext(ctx, "eviction blocked by a disruption budget, retrying", "pod", p.key())
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to evict pod %s: %w", p.key(), ctx.Err())
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
+
+// waitForTermination waits until none of the evicted pods exist any more. A
+// pod with the same name but another UID is a replacement, which stays
+// pending on the cordoned nodes.
+func (d *Drainer) waitForTermination(ctx context.Context, evicted map[string]string) error {
+	for len(evicted) > 0 {
+		var pods podList
+		if err := d.do(ctx, http.MethodGet, "/api/v1/pods", "", nil, &pods); err != nil {
+			return fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		remaining := 0
+		for _, pod := range pods.Items {
+			if uid, ok := evicted[pod.key()]; ok && uid == pod.Metadata.UID {
+				remaining++
+			}
+		}
+		if remaining == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d evicted pod(s) have not terminated: %w", remaining, ctx.Err())
+		case <-time.After(d.pollInterval):
+		}
+	}
+	return nil
+}
+
+// evictable reports whether draining evicts a pod
+func evictable(p pod) bool {
+	if p.Spec.NodeName == "" || p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed" {
+		return false
+	}
+	if _, mirror := p.Metadata.Annotations[mirrorPodAnnotation]; mirror {
+		return false
+	}
+	for _, owner := range p.Metadata.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// statusError is an API server response other than a success
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API server returned %d: %s", e.code, e.message)
+}
+
+// do sends a request to the API server and decodes a successful response
+// into out, when given
+func (d *Drainer) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	request, err := http.NewRequestWithContext(ctx, method, d.endpoint.JoinPath(path).String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+d.token)
+	request.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+		if json.Unmarshal(data, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(data))
+		}
+		return &statusError{code: response.StatusCode, message: status.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// The parts of Kubernetes objects a drain reads
+
+type objectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	Annotations     map[string]string `json:"annotations"`
+	OwnerReferences []struct {
+		Kind string `json:"kind"`
+	} `json:"ownerReferences"`
+}
+
+type nodeList struct {
+	Items []struct {
+		Metadata objectMeta `json:"metadata"`
+		Spec     struct {
+			Unschedulable bool `json:"unschedulable"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+type pod struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName string `json:"nodeName"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+func (p pod) key() string {
+	return p.Metadata.Namespace + "/" + p.Metadata.Name
+}
+
+type podList struct {
+	Items []pod `json:"items"`
+}
@@ -0,0 +1,162 @@
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// fakeAPIServer serves nodes and pods, and removes a pod once it is evicted
+type fakeAPIServer struct {
+	mu        sync.Mutex
+	cordoned  []string
+	pods      map[string]string // Pod JSON by namespace/name
+	blocked   map[string]int    // Evictions to refuse by namespace/name
+	evictions []string
+}
+
+func (f *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer good" {
+		http.Error(w, `{"message":"Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/nodes":
+		io.WriteString(w, `{"items":[
+			{"metadata":{"name":"node-1"}},
+			{"metadata":{"name":"node-2"},"spec":{"unschedulable":true}}]}`)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/api/v1/nodes/"):
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" || string(body) != `{"spec":{"unschedulable":true}}` {
+			http.Error(w, "bad patch", http.StatusBadRequest)
+			return
+		}
+		f.cordoned = append(f.cordoned, strings.TrimPrefix(r.URL.Path, "/api/v1/nodes/"))
+		io.WriteString(w, `{}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/pods":
+		var items []string
+		for _, pod := range f.pods {
+			items = append(items, pod)
+		}
+		io.WriteString(w, `{"items":[`+strings.Join(items, ",")+`]}`)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/eviction"):
+		var eviction struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&eviction)
+		key := eviction.Metadata.Namespace + "/" + eviction.Metadata.Name
+		if f.blocked[key] > 0 {
+			f.blocked[key]--
+			http.Error(w, `{"message":"Cannot evict pod as it would violate the pod's disruption budget."}`, http.StatusTooManyRequests)
+			return
+		}
+		f.evictions = append(f.evictions, key)
+		delete(f.pods, key)
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDrainer_Drain(t *testing.T) {
+	api := &fakeAPIServer{
+		pods: map[string]string{
+			"shop/web":           `{"metadata":{"name":"web","namespace":"shop","uid":"1","ownerReferences":[{"kind":"ReplicaSet"}]},"spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`,
+			"shop/db":            `{"metadata":{"name":"db","namespace":"shop","uid":"2","ownerReferences":[{"kind":"StatefulSet"}]},"spec":{"nodeName":"node-2"},"status":{"phase":"Running"}}`,
+			"kube-system/proxy":  `{"metadata":{"name":"proxy","namespace":"kube-system","uid":"3","ownerReferences":[{"kind":"DaemonSet"}]},"spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`,
+			"kube-system/static": `{"metadata":{"name":"static","namespace":"kube-system","uid":"4","annotations":{"kubernetes.io/config.mirror":"abc"}},"spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`,
+			"shop/migrate":       `{"metadata":{"name":"migrate","namespace":"shop","uid":"5"},"spec":{"nodeName":"node-1"},"status":{"phase":"Succeeded"}}`,
+			"shop/pending":       `{"metadata":{"name":"pending","namespace":"shop","uid":"6"},"status":{"phase":"Pending"}}`,
+		},
+		blocked: map[string]int{"shop/db": 2},
+	}
+	server := httptest.NewTLSServer(api)
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	drainer, err := NewDrainer(server.URL, &engine.ClusterCredentials{CAData: ca, Token: "good"}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDrainer() error = %v", err)
+	}
+	drainer.pollInterval = time.Millisecond
+
+	result, err := drainer.Drain(context.Background())
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if result.Nodes != 2 || result.Evicted != 2 {
+		t.Errorf("Drain() = %+v, want 2 nodes and 2 evicted pods", result)
+	}
+	if len(api.cordoned) != 1 || api.cordoned[0] != "node-1" {
+		t.Errorf("cordoned %v, want only node-1, which was schedulable", api.cordoned)
+	}
+	if strings.Join(api.evictions, ",") != "shop/web,shop/db" && strings.Join(api.evictions, ",") != "shop/db,shop/web" {
+		t.Errorf("evicted %v, want shop/web and shop/db", api.evictions)
+	}
+	if _, ok := api.pods["kube-system/proxy"]; !ok {
+		t.Error("DaemonSet pod was evicted")
+	}
+}
+
+func TestDrainer_DrainBlocked(t *testing.T) {
+	api := &fakeAPIServer{
+		pods: map[string]string{
+			"shop/db": `{"metadata":{"name":"db","namespace":"shop","uid":"2"},"spec":{"nodeName":"node-1"},"status":{"phase":"Running"}}`,
+		},
+		blocked: map[string]int{"shop/db": 1 << 30},
+	}
+	server := httptest.NewTLSServer(api)
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	drainer, err := NewDrainer(server.URL, &engine.ClusterCredentials{CAData: ca, Token: "good"}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDrainer() error = %v", err)
+	}
+	drainer.pollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := drainer.Drain(ctx); err == nil || !strings.Contains(err.Error(), "shop/db") {
+		t.Errorf("Drain() error = %v, want the blocked pod's eviction to time out", err)
+	}
+}
+
+func TestNewDrainer(t *testing.T) {
+	tests := []struct {
+		name        string
+		endpoint    string
+		credentials *engine.ClusterCredentials
+	}{
+		{name: "plain HTTP", endpoint: "http://example.com", credentials: &engine.ClusterCredentials{CAData: []byte("x"), Token: "t"}},
+		{name: "no credentials", endpoint: "https://example.com"},
+		{name: "no token", endpoint: "https://example.com", credentials: &engine.ClusterCredentials{CAData: []byte("x")}},
+		{name: "invalid CA", endpoint: "https://example.com", credentials: &engine.ClusterCredentials{CAData: []byte("x"), Token: "t"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDrainer(tt.endpoint, tt.credentials, slog.Default()); err == nil {
+				t.Error("NewDrainer() error = nil, want an error")
+			}
+		})
+	}
+}
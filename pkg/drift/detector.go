@@ -5,24 +5,62 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
 	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
 	"github.com/vjranagit/cluster-api/pkg/engine"
 )
 
+// DefaultParallelism is how many providers are scanned for drift at once
+const DefaultParallelism = 4
+
 // DriftDetector detects configuration drift between desired and actual state
 type DriftDetector struct {
-	engine *engine.Engine
-	logger *slog.Logger
+	engine      *engine.Engine
+	logger      *slog.Logger
+	parallelism int
+	settle      time.Duration
+}
+
+// Option configures a DriftDetector
+type Option func(*DriftDetector)
+
+// WithParallelism bounds how many providers are scanned concurrently
+func WithParallelism(n int) Option {
+	return func(d *DriftDetector) {
+		if n > 0 {
+			d.parallelism = n
+		}
+	}
+}
+
+// WithIgnoreChangesWithin skips clusters and node pools whose stored
+// UpdatedAt is less than window ago. Right after an apply, cloud resources may
+// still be settling and would otherwise be reported as drifted. Node pools
+// are matched by the state node pools in the desired state. Zero, the
+// default, checks everything.
+func WithIgnoreChangesWithin(window time.Duration) Option {
+	return func(d *DriftDetector) {
+		d.settle = window
+	}
 }
 
 // NewDriftDetector creates a new drift detector
-func NewDriftDetector(eng *engine.Engine, logger *slog.Logger) *DriftDetector {
-	return &DriftDetector{
-		engine: eng,
-		logger: logger,
+func NewDriftDetector(eng *engine.Engine, logger *slog.Logger, opts ...Option) *DriftDetector {
+	d := &DriftDetector{
+		engine:      eng,
+		logger:      logger,
+		parallelism: DefaultParallelism,
+	}
+	for _, opt := range opts {
+		opt(d)
 	}
+	return d
 }
 
 // DriftReport contains detected drift information
@@ -31,11 +69,13 @@ type DriftReport struct {
 	HasDrift     bool
 	Drifts       []ResourceDrift
 	Summary      DriftSummary
+	Settling     []api.ResourceID // Clusters and node pools skipped because they changed within the settle window
 }
 
 // ResourceDrift represents drift for a single resource
 type ResourceDrift struct {
 	Resource     api.ResourceID
+	Region       string // Region of the resource's cluster, whose provider remediates it
 	DriftType    DriftType
 	Field        string
 	Expected     interface{}
@@ -67,6 +107,37 @@ const (
 	SeverityLow      Severity = "low"      // Informational
 )
 
+// Level returns the rank of the severity: Critical > High > Medium > Low.
+// Unknown severities rank below Low.
+func (s Severity) Level() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether s is as severe as other or more
+func (s Severity) AtLeast(other Severity) bool {
+	return s.Level() >= other.Level()
+}
+
+// ParseSeverity parses a severity name, case-insensitively
+func ParseSeverity(name string) (Severity, error) {
+	severity := Severity(strings.ToLower(name))
+	if severity.Level() == 0 {
+		return "", fmt.Errorf("invalid severity %q: must be one of critical, high, medium, low", name)
+	}
+	return severity, nil
+}
+
 // DriftSummary provides drift statistics
 type DriftSummary struct {
 	TotalDrifts      int
@@ -86,133 +157,345 @@ func (d *DriftDetector) DetectDrift(ctx context.Context, desired engine.State) (
 		Drifts:     []ResourceDrift{},
 	}
 
-	// Detect drift for each provider
-	for providerName, provider := range d.getAllProviders() {
-		d.logger.Debug("checking drift for provider", "provider", providerName)
+	settling := d.settlingResources(desired, report.DetectedAt)
+	for _, resource := range settling {
+		report.Settling = append(report.Settling, resource)
+	}
+	sort.Slice(report.Settling, func(i, j int) bool { return report.Settling[i].Name < report.Settling[j].Name })
+
+	// Group clusters by provider region, as each region is queried through
+	// the provider registered for it
+	groups := make(map[regionKey][]string)
+	for id, cluster := range desired.Clusters {
+		key := regionKey{provider: cluster.Spec.Provider, region: cluster.Spec.Region}
+		groups[key] = append(groups[key], id)
+	}
+	keys := make([]regionKey, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].region < keys[j].region
+	})
+
+	// Detect drift for each provider region concurrently; each goroutine
+	// writes only its own slot so no locking is needed
+	results := make([][]ResourceDrift, len(keys))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.parallelism)
+	for i, key := range keys {
+		i, key := i, key
+		g.Go(func() error {
+			results[i] = d.detectRegionDrift(gctx, key, groups[key], desired, settling)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, drifts := range results {
+		report.Drifts = append(report.Drifts, drifts...)
+	}
+	report.Sort()
+
+	report.summarize()
+
+	d.logger.Info("drift detection complete",
+		"total_drifts", report.Summary.TotalDrifts,
+		"critical", report.Summary.CriticalCount,
+		"high", report.Summary.HighCount,
+	)
+
+	return report, nil
+}
+
+// regionKey identifies the clusters of one provider in one region
+type regionKey struct {
+	provider string
+	region   string
+}
+
+// detectRegionDrift compares the desired clusters ids of one provider region
+// against what the provider reports. Clusters whose provider cannot be
+// loaded are skipped.
+func (d *DriftDetector) detectRegionDrift(ctx context.Context, key regionKey, ids []string, desired engine.State, settling map[string]api.ResourceID) []ResourceDrift {
+	providerName := key.provider
+	d.logger.Debug("checking drift for provider", "provider", providerName, "region", key.region)
+
+	provider, err := d.engine.LoadRegionalProvider(ctx, providerName, key.region)
+	if err != nil {
+		d.logger.Error("failed to load provider", "provider", providerName, "region", key.region, "error", err)
+		return nil
+	}
+
+	var drifts []ResourceDrift
+
+	// Compare clusters
+	for _, id := range ids {
+		desiredCluster := desired.Clusters[id]
+		if _, skip := settling[id]; skip {
+			d.logger.Debug("skipping recently changed cluster", "cluster", desiredCluster.Metadata.Name,
+				"updatedAt", desiredCluster.Metadata.UpdatedAt)
+			continue
+		}
 
 		// Get actual state from cloud provider
-		actual, err := provider.Reconcile(ctx, desired, engine.State{})
+		actualCluster, err := provider.GetCluster(ctx, id)
 		if err != nil {
-			d.logger.Error("failed to get actual state", "provider", providerName, "error", err)
+			d.logger.Error("failed to get actual state", "provider", providerName, "cluster", id, "error", err)
+			continue
+		}
+
+		if actualCluster == nil {
+			drifts = append(drifts, ResourceDrift{
+				Resource: api.ResourceID{
+					Provider: providerName,
+					Kind:     "Cluster",
+					ID:       id,
+					Name:     desiredCluster.Metadata.Name,
+				},
+				DriftType:    DriftResourceDeleted,
+				Field:        "cluster",
+				Expected:     "exists",
+				Actual:       "deleted",
+				Severity:     SeverityCritical,
+				Remediatable: true,
+			})
 			continue
 		}
 
-		// Compare clusters
-		for id, desiredCluster := range desired.Clusters {
-			if desiredCluster.Spec.Provider != providerName {
+		checked := len(drifts)
+
+		// Check version drift
+		if desiredCluster.Spec.ControlPlane.Version != actualCluster.Spec.ControlPlane.Version {
+			drifts = append(drifts, ResourceDrift{
+				Resource: api.ResourceID{
+					Provider: providerName,
+					Kind:     "Cluster",
+					ID:       id,
+					Name:     desiredCluster.Metadata.Name,
+				},
+				DriftType:    DriftVersionSkew,
+				Field:        "controlPlane.version",
+				Expected:     desiredCluster.Spec.ControlPlane.Version,
+				Actual:       actualCluster.Spec.ControlPlane.Version,
+				Severity:     SeverityHigh,
+				Remediatable: true,
+			})
+		}
+
+		// Check worker pool drift
+		for _, desiredPool := range desiredCluster.Spec.WorkerPools {
+			if _, skip := settling[id+"/"+desiredPool.Name]; skip {
+				d.logger.Debug("skipping recently changed node pool", "cluster", desiredCluster.Metadata.Name,
+					"pool", desiredPool.Name)
 				continue
 			}
+			foundPool := false
+			for _, actualPool := range actualCluster.Spec.WorkerPools {
+				if desiredPool.Name == actualPool.Name {
+					foundPool = true
+
+					// Check scale drift
+					if desiredPool.DesiredSize != actualPool.DesiredSize {
+						drifts = append(drifts, ResourceDrift{
+							Resource: api.ResourceID{
+								Provider: providerName,
+								Kind:     "NodePool",
+								ID:       id + "/" + desiredPool.Name,
+								Name:     desiredPool.Name,
+							},
+							DriftType:    DriftScaleChange,
+							Field:        "desiredSize",
+							Expected:     desiredPool.DesiredSize,
+							Actual:       actualPool.DesiredSize,
+							Severity:     SeverityMedium,
+							Remediatable: true,
+						})
+					}
 
-			actualCluster, exists := actual.Clusters[id]
-			if !exists {
-				report.Drifts = append(report.Drifts, ResourceDrift{
-					Resource: api.ResourceID{
+					// Check labels and taints drift
+					poolResource := api.ResourceID{
 						Provider: providerName,
-						Kind:     "Cluster",
-						ID:       id,
-						Name:     desiredCluster.Metadata.Name,
-					},
-					DriftType:    DriftResourceDeleted,
-					Field:        "cluster",
-					Expected:     "exists",
-					Actual:       "deleted",
-					Severity:     SeverityCritical,
-					Remediatable: true,
-				})
-				continue
+						Kind:     "NodePool",
+						ID:       id + "/" + desiredPool.Name,
+						Name:     desiredPool.Name,
+					}
+					drifts = append(drifts, labelDrifts(poolResource, desiredPool.Labels, actualPool.Labels)...)
+					drifts = append(drifts, taintDrifts(poolResource, desiredPool.Taints, actualPool.Taints)...)
+				}
 			}
 
-			// Check version drift
-			if desiredCluster.Spec.ControlPlane.Version != actualCluster.Spec.ControlPlane.Version {
-				report.Drifts = append(report.Drifts, ResourceDrift{
+			if !foundPool {
+				drifts = append(drifts, ResourceDrift{
 					Resource: api.ResourceID{
 						Provider: providerName,
-						Kind:     "Cluster",
-						ID:       id,
-						Name:     desiredCluster.Metadata.Name,
+						Kind:     "NodePool",
+						ID:       id + "/" + desiredPool.Name,
+						Name:     desiredPool.Name,
 					},
-					DriftType:    DriftVersionSkew,
-					Field:        "controlPlane.version",
-					Expected:     desiredCluster.Spec.ControlPlane.Version,
-					Actual:       actualCluster.Spec.ControlPlane.Version,
+					DriftType:    DriftResourceDeleted,
+					Field:        "nodePool",
+					Expected:     "exists",
+					Actual:       "deleted",
 					Severity:     SeverityHigh,
 					Remediatable: true,
 				})
 			}
+		}
 
-			// Check worker pool drift
-			for _, desiredPool := range desiredCluster.Spec.WorkerPools {
-				foundPool := false
-				for _, actualPool := range actualCluster.Spec.WorkerPools {
-					if desiredPool.Name == actualPool.Name {
-						foundPool = true
-
-						// Check scale drift
-						if desiredPool.DesiredSize != actualPool.DesiredSize {
-							report.Drifts = append(report.Drifts, ResourceDrift{
-								Resource: api.ResourceID{
-									Provider: providerName,
-									Kind:     "NodePool",
-									ID:       id + "/" + desiredPool.Name,
-									Name:     desiredPool.Name,
-								},
-								DriftType:    DriftScaleChange,
-								Field:        "desiredSize",
-								Expected:     desiredPool.DesiredSize,
-								Actual:       actualPool.DesiredSize,
-								Severity:     SeverityMedium,
-								Remediatable: true,
-							})
-						}
-					}
-				}
+		drifts = append(drifts[:checked], d.unignored(drifts[checked:], desiredCluster)...)
+	}
 
-				if !foundPool {
-					report.Drifts = append(report.Drifts, ResourceDrift{
-						Resource: api.ResourceID{
-							Provider: providerName,
-							Kind:     "NodePool",
-							ID:       id + "/" + desiredPool.Name,
-							Name:     desiredPool.Name,
-						},
-						DriftType:    DriftResourceDeleted,
-						Field:        "nodePool",
-						Expected:     "exists",
-						Actual:       "deleted",
-						Severity:     SeverityHigh,
-						Remediatable: true,
-					})
-				}
-			}
+	for i := range drifts {
+		drifts[i].Region = key.region
+	}
+	return drifts
+}
+
+// unignored drops the drifts on fields the cluster's ignore_changes rules
+// cover, as those fields are managed outside provctl
+func (d *DriftDetector) unignored(drifts []ResourceDrift, cluster *api.Cluster) []ResourceDrift {
+	rules := cluster.Metadata.IgnoreChanges()
+	if len(rules) == 0 {
+		return drifts
+	}
+
+	kept := drifts[:0]
+	for _, drift := range drifts {
+		if path := specPath(drift); rules.Matches(path) {
+			d.logger.Debug("ignoring drift", "cluster", cluster.Metadata.Name, "field", path)
+			continue
 		}
+		kept = append(kept, drift)
 	}
+	return kept
+}
 
-	// Compute summary
-	report.HasDrift = len(report.Drifts) > 0
-	for _, drift := range report.Drifts {
+// specPath returns the path of a drifted field within the cluster spec, in
+// the dotted convention of api.ClusterSpec.Diff
+func specPath(drift ResourceDrift) string {
+	if drift.Resource.Kind != "NodePool" {
+		return drift.Field
+	}
+	pool := "workerPools." + drift.Resource.Name
+	if drift.Field == "nodePool" {
+		return pool
+	}
+	return pool + "." + drift.Field
+}
+
+// settlingResources returns the desired clusters and node pools that were
+// updated less than the settle window before now, keyed by the IDs their
+// drifts are reported under: the cluster ID, or "<cluster ID>/<pool name>"
+func (d *DriftDetector) settlingResources(desired engine.State, now time.Time) map[string]api.ResourceID {
+	settling := make(map[string]api.ResourceID)
+	if d.settle <= 0 {
+		return settling
+	}
+	recent := func(updated time.Time) bool {
+		return !updated.IsZero() && now.Sub(updated) < d.settle
+	}
+
+	for id, cluster := range desired.Clusters {
+		if !recent(cluster.Metadata.UpdatedAt) {
+			continue
+		}
+		settling[id] = api.ResourceID{
+			Provider: cluster.Spec.Provider,
+			Kind:     "Cluster",
+			ID:       id,
+			Name:     cluster.Metadata.Name,
+		}
+	}
+
+	for _, pool := range desired.NodePools {
+		clusterID := pool.Status.Properties[api.PropertyClusterID]
+		cluster := desired.Clusters[clusterID]
+		if cluster == nil || !recent(pool.Metadata.UpdatedAt) {
+			continue
+		}
+		id := clusterID + "/" + pool.Spec.Name
+		settling[id] = api.ResourceID{
+			Provider: cluster.Spec.Provider,
+			Kind:     "NodePool",
+			ID:       id,
+			Name:     cluster.Metadata.Name + "/" + pool.Spec.Name,
+		}
+	}
+	return settling
+}
+
+// Filter returns a copy of the report containing only drifts at or above
+// minSeverity, with the summary recomputed
+func (r *DriftReport) Filter(minSeverity Severity) *DriftReport {
+	filtered := &DriftReport{
+		DetectedAt: r.DetectedAt,
+		Drifts:     []ResourceDrift{},
+		Settling:   r.Settling,
+	}
+	for _, drift := range r.Drifts {
+		if drift.Severity.AtLeast(minSeverity) {
+			filtered.Drifts = append(filtered.Drifts, drift)
+		}
+	}
+	filtered.summarize()
+	return filtered
+}
+
+// NewReport creates a report of drifts found outside a detector, such as by
+// the reconciler, sorted and summarized like a detector's
+func NewReport(detectedAt time.Time, drifts []ResourceDrift) *DriftReport {
+	report := &DriftReport{DetectedAt: detectedAt, Drifts: append([]ResourceDrift{}, drifts...)}
+	report.Sort()
+	report.summarize()
+	return report
+}
+
+// summarize recomputes HasDrift and the summary counts from Drifts
+func (r *DriftReport) summarize() {
+	r.HasDrift = len(r.Drifts) > 0
+	r.Summary = DriftSummary{TotalDrifts: len(r.Drifts)}
+	for _, drift := range r.Drifts {
 		switch drift.Severity {
 		case SeverityCritical:
-			report.Summary.CriticalCount++
+			r.Summary.CriticalCount++
 		case SeverityHigh:
-			report.Summary.HighCount++
+			r.Summary.HighCount++
 		case SeverityMedium:
-			report.Summary.MediumCount++
+			r.Summary.MediumCount++
 		case SeverityLow:
-			report.Summary.LowCount++
+			r.Summary.LowCount++
 		}
 		if drift.Remediatable {
-			report.Summary.RemediableCount++
+			r.Summary.RemediableCount++
 		}
 	}
-	report.Summary.TotalDrifts = len(report.Drifts)
-
-	d.logger.Info("drift detection complete",
-		"total_drifts", report.Summary.TotalDrifts,
-		"critical", report.Summary.CriticalCount,
-		"high", report.Summary.HighCount,
-	)
+}
 
-	return report, nil
+// Sort orders drifts by provider, kind, name and field so that reports for
+// identical input are identical. The summary is unaffected.
+func (r *DriftReport) Sort() {
+	sort.SliceStable(r.Drifts, func(i, j int) bool {
+		a, b := r.Drifts[i], r.Drifts[j]
+		if a.Resource.Provider != b.Resource.Provider {
+			return a.Resource.Provider < b.Resource.Provider
+		}
+		if a.Resource.Kind != b.Resource.Kind {
+			return a.Resource.Kind < b.Resource.Kind
+		}
+		if a.Resource.Name != b.Resource.Name {
+			return a.Resource.Name < b.Resource.Name
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Resource.ID < b.Resource.ID
+	})
 }
 
 // Remediate automatically fixes detected drift
@@ -249,9 +532,9 @@ func (d *DriftDetector) Remediate(ctx context.Context, report *DriftReport) erro
 }
 
 func (d *DriftDetector) remediateDrift(ctx context.Context, drift ResourceDrift) error {
-	provider := d.engine.GetProvider(drift.Resource.Provider)
-	if provider == nil {
-		return fmt.Errorf("provider %s not found", drift.Resource.Provider)
+	provider, err := d.engine.LoadRegionalProvider(ctx, drift.Resource.Provider, drift.Region)
+	if err != nil {
+		return err
 	}
 
 	// Remediation logic based on drift type
@@ -274,22 +557,153 @@ func (d *DriftDetector) remediateDrift(ctx context.Context, drift ResourceDrift)
 		// Implementation would call provider.UpdateNodePool
 		return nil
 
+	case DriftConfigChange:
+		d.logger.Info("restoring node pool configuration", "resource", drift.Resource.Name,
+			"field", drift.Field, "expected", drift.Expected)
+		return restorePoolConfig(ctx, provider, drift)
+
 	default:
 		return fmt.Errorf("unsupported drift type: %s", drift.DriftType)
 	}
 }
 
-func (d *DriftDetector) getAllProviders() map[string]engine.CloudProvider {
-	// This would be implemented to return all registered providers
-	providers := make(map[string]engine.CloudProvider)
-	// In real implementation, iterate through engine.providers
-	return providers
+// absent is reported for a label or taint that exists on only one side
+const absent = "<absent>"
+
+// restorePoolConfig sets the drifted label or taint of a node pool back to
+// its desired value, removing it if it is not desired. The rest of the pool
+// is updated as the provider reports it.
+func restorePoolConfig(ctx context.Context, provider engine.CloudProvider, drift ResourceDrift) error {
+	clusterID, poolName, ok := strings.Cut(drift.Resource.ID, "/")
+	if drift.Resource.Kind != "NodePool" || !ok {
+		return fmt.Errorf("unsupported %s drift on %s %s", drift.DriftType, drift.Resource.Kind, drift.Resource.Name)
+	}
+
+	pools, err := provider.ListNodePools(ctx, clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to list node pools: %w", err)
+	}
+	var pool *api.NodePool
+	for _, candidate := range pools {
+		if candidate.Spec.Name == poolName {
+			pool = candidate
+		}
+	}
+	if pool == nil {
+		return fmt.Errorf("node pool %s not found", poolName)
+	}
+
+	expected, _ := drift.Expected.(string)
+	field, key, _ := strings.Cut(drift.Field, ".")
+	switch field {
+	case "labels":
+		labels := make(map[string]string, len(pool.Spec.Labels)+1)
+		for k, v := range pool.Spec.Labels {
+			labels[k] = v
+		}
+		delete(labels, key)
+		if expected != absent {
+			labels[key] = expected
+		}
+		pool.Spec.Labels = labels
+
+	case "taints":
+		var taints []api.Taint
+		for _, taint := range pool.Spec.Taints {
+			if taint.Key+":"+taint.Effect != key {
+				taints = append(taints, taint)
+			}
+		}
+		if expected != absent {
+			sep := strings.LastIndex(key, ":")
+			taints = append(taints, api.Taint{Key: key[:sep], Value: expected, Effect: key[sep+1:]})
+		}
+		pool.Spec.Taints = taints
+
+	default:
+		return fmt.Errorf("unsupported %s drift on field %s", drift.DriftType, drift.Field)
+	}
+
+	if err := provider.UpdateNodePool(ctx, pool); err != nil {
+		return fmt.Errorf("failed to update node pool %s: %w", poolName, err)
+	}
+	return nil
+}
+
+// labelDrifts reports each node label key whose value differs between
+// desired and actual
+func labelDrifts(resource api.ResourceID, desired, actual map[string]string) []ResourceDrift {
+	return keyedDrifts(resource, "labels", desired, actual)
+}
+
+// taintDrifts reports each taint whose value differs between desired and
+// actual. Taints are compared as a set keyed by key and effect, so ordering
+// does not matter.
+func taintDrifts(resource api.ResourceID, desired, actual []api.Taint) []ResourceDrift {
+	return keyedDrifts(resource, "taints", taintSet(desired), taintSet(actual))
+}
+
+func taintSet(taints []api.Taint) map[string]string {
+	set := make(map[string]string, len(taints))
+	for _, taint := range taints {
+		set[taint.Key+":"+taint.Effect] = taint.Value
+	}
+	return set
+}
+
+func keyedDrifts(resource api.ResourceID, field string, desired, actual map[string]string) []ResourceDrift {
+	keys := make(map[string]struct{}, len(desired)+len(actual))
+	for key := range desired {
+		keys[key] = struct{}{}
+	}
+	for key := range actual {
+		keys[key] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var drifts []ResourceDrift
+	for _, key := range sorted {
+		expected, inDesired := desired[key]
+		actualValue, inActual := actual[key]
+		if inDesired && inActual && expected == actualValue {
+			continue
+		}
+		if !inDesired {
+			expected = absent
+		}
+		if !inActual {
+			actualValue = absent
+		}
+
+		drifts = append(drifts, ResourceDrift{
+			Resource:     resource,
+			DriftType:    DriftConfigChange,
+			Field:        field + "." + key,
+			Expected:     expected,
+			Actual:       actualValue,
+			Severity:     SeverityMedium,
+			Remediatable: true,
+		})
+	}
+	return drifts
 }
 
 // FormatReport generates a human-readable drift report
 func FormatReport(report *DriftReport) string {
+	return FormatReportColor(report, false)
+}
+
+// FormatReportColor generates a human-readable drift report, coloring each
+// drift by its type when enabled: green for unexpected resources, red for
+// deleted ones and yellow for modifications
+func FormatReportColor(report *DriftReport, enabled bool) string {
 	if !report.HasDrift {
-		return "✓ No drift detected - infrastructure matches configuration"
+		return "✓ No drift detected - infrastructure matches configuration" + formatSettling(report)
 	}
 
 	output := fmt.Sprintf("⚠ Drift Detected at %s\n\n", report.DetectedAt.Format(time.RFC3339))
@@ -310,19 +724,46 @@ func FormatReport(report *DriftReport) string {
 			remediation = " [auto-fixable]"
 		}
 
-		output += fmt.Sprintf("  %s %s/%s - %s%s\n",
+		output += fmt.Sprintf("  %s %s\n",
 			severity,
-			drift.Resource.Kind,
-			drift.Resource.Name,
-			drift.DriftType,
-			remediation,
+			color.Wrap(enabled, driftColor(drift.DriftType), fmt.Sprintf("%s/%s - %s%s",
+				drift.Resource.Kind,
+				drift.Resource.Name,
+				drift.DriftType,
+				remediation,
+			)),
 		)
 		output += fmt.Sprintf("      Field: %s\n", drift.Field)
 		output += fmt.Sprintf("      Expected: %v\n", drift.Expected)
 		output += fmt.Sprintf("      Actual: %v\n\n", drift.Actual)
 	}
 
-	return output
+	return output + formatSettling(report)
+}
+
+// formatSettling notes the clusters and node pools that were not checked
+// because they changed within the settle window
+func formatSettling(report *DriftReport) string {
+	if len(report.Settling) == 0 {
+		return ""
+	}
+
+	names := make([]string, len(report.Settling))
+	for i, resource := range report.Settling {
+		names[i] = resource.Name
+	}
+	return fmt.Sprintf("\nNot checked, changed within the settle window: %s\n", strings.Join(names, ", "))
+}
+
+func driftColor(driftType DriftType) color.Color {
+	switch driftType {
+	case DriftResourceAdded:
+		return color.Green
+	case DriftResourceDeleted:
+		return color.Red
+	default:
+		return color.Yellow
+	}
 }
 
 func getSeverityIcon(severity Severity) string {
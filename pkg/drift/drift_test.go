@@ -1,20 +1,25 @@
 package drift
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"log/slog"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
 	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
 )
 
 func TestDriftDetector_DetectDrift(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	eng := engine.NewEngine(nil, nil)
-	detector := NewDriftDetector(eng, logger)
 
 	tests := []struct {
 		name        string
@@ -122,6 +127,15 @@ func TestDriftDetector_DetectDrift(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			provider := fake.NewProvider("aws")
+			for _, cluster := range tt.actual.Clusters {
+				provider.SeedCluster(cluster)
+			}
+
+			eng := engine.NewEngine(nil, nil)
+			eng.RegisterProvider(provider)
+			detector := NewDriftDetector(eng, logger)
+
 			ctx := context.Background()
 			report, err := detector.DetectDrift(ctx, tt.desired)
 
@@ -141,6 +155,367 @@ func TestDriftDetector_DetectDrift(t *testing.T) {
 	}
 }
 
+func TestDriftDetector_LabelsAndTaints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	noSchedule := api.Taint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+	preferNoSchedule := api.Taint{Key: "spot", Value: "true", Effect: "PreferNoSchedule"}
+
+	tests := []struct {
+		name        string
+		desiredPool api.WorkerPoolSpec
+		actualPool  api.WorkerPoolSpec
+		wantFields  []string
+	}{
+		{
+			name:        "taints reordered",
+			desiredPool: api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule, preferNoSchedule}},
+			actualPool:  api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{preferNoSchedule, noSchedule}},
+			wantFields:  nil,
+		},
+		{
+			name:        "taint added out-of-band",
+			desiredPool: api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule}},
+			actualPool:  api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule, preferNoSchedule}},
+			wantFields:  []string{"taints.spot:PreferNoSchedule"},
+		},
+		{
+			name:        "taint removed out-of-band",
+			desiredPool: api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule, preferNoSchedule}},
+			actualPool:  api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule}},
+			wantFields:  []string{"taints.spot:PreferNoSchedule"},
+		},
+		{
+			name:        "taint value changed",
+			desiredPool: api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{noSchedule}},
+			actualPool:  api.WorkerPoolSpec{Name: "gpu", Taints: []api.Taint{{Key: "dedicated", Value: "batch", Effect: "NoSchedule"}}},
+			wantFields:  []string{"taints.dedicated:NoSchedule"},
+		},
+		{
+			name:        "labels changed",
+			desiredPool: api.WorkerPoolSpec{Name: "gpu", Labels: map[string]string{"tier": "gpu", "team": "ml"}},
+			actualPool:  api.WorkerPoolSpec{Name: "gpu", Labels: map[string]string{"tier": "cpu", "extra": "x"}},
+			wantFields:  []string{"labels.extra", "labels.team", "labels.tier"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := &api.Cluster{
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "test-cluster"},
+				Spec: api.ClusterSpec{
+					Provider:    "aws",
+					WorkerPools: []api.WorkerPoolSpec{tt.desiredPool},
+				},
+			}
+			actual := *desired
+			actual.Spec.WorkerPools = []api.WorkerPoolSpec{tt.actualPool}
+
+			provider := fake.NewProvider("aws")
+			provider.SeedCluster(&actual)
+
+			eng := engine.NewEngine(nil, nil)
+			eng.RegisterProvider(provider)
+			detector := NewDriftDetector(eng, logger)
+
+			report, err := detector.DetectDrift(context.Background(), engine.State{
+				Clusters: map[string]*api.Cluster{desired.ID: desired},
+			})
+			if err != nil {
+				t.Fatalf("DetectDrift() error = %v", err)
+			}
+
+			if len(report.Drifts) != len(tt.wantFields) {
+				t.Fatalf("DetectDrift() got %d drifts, want %d: %+v", len(report.Drifts), len(tt.wantFields), report.Drifts)
+			}
+			for i, drift := range report.Drifts {
+				if drift.Field != tt.wantFields[i] {
+					t.Errorf("drift[%d].Field = %s, want %s", i, drift.Field, tt.wantFields[i])
+				}
+				if drift.DriftType != DriftConfigChange || drift.Severity != SeverityMedium || !drift.Remediatable {
+					t.Errorf("drift[%d] = %+v, want remediatable medium config_change", i, drift)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftDetector_RemediateLabelsAndTaints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	noSchedule := api.Taint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+	preferNoSchedule := api.Taint{Key: "spot", Value: "true", Effect: "PreferNoSchedule"}
+	desiredPool := api.WorkerPoolSpec{
+		Name:   "gpu",
+		Labels: map[string]string{"tier": "gpu", "team": "ml"},
+		Taints: []api.Taint{noSchedule},
+	}
+	actualPool := api.WorkerPoolSpec{
+		Name:   "gpu",
+		Labels: map[string]string{"tier": "cpu", "extra": "x"},
+		Taints: []api.Taint{{Key: "dedicated", Value: "batch", Effect: "NoSchedule"}, preferNoSchedule},
+	}
+
+	desired := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "test-cluster"},
+		Spec: api.ClusterSpec{
+			Provider:    "aws",
+			Region:      "eu-west-1",
+			WorkerPools: []api.WorkerPoolSpec{desiredPool},
+		},
+	}
+	actual := *desired
+	actual.Spec.WorkerPools = []api.WorkerPoolSpec{actualPool}
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&actual)
+	provider.SeedNodePool(desired.ID, &api.NodePool{ID: "pool-1", Metadata: api.ResourceMetadata{Name: "gpu"}, Spec: actualPool})
+
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterRegionalProviderLoader("aws", "eu-west-1", func(ctx context.Context) (engine.CloudProvider, error) {
+		return provider, nil
+	})
+	detector := NewDriftDetector(eng, logger)
+
+	report, err := detector.DetectDrift(context.Background(), engine.State{
+		Clusters: map[string]*api.Cluster{desired.ID: desired},
+	})
+	if err != nil {
+		t.Fatalf("DetectDrift() error = %v", err)
+	}
+	if report.Summary.RemediableCount != 5 {
+		t.Fatalf("DetectDrift() remediable = %d, want 5: %+v", report.Summary.RemediableCount, report.Drifts)
+	}
+
+	if err := detector.Remediate(context.Background(), report); err != nil {
+		t.Fatalf("Remediate() error = %v", err)
+	}
+	if n := provider.CallCount("UpdateNodePool"); n != 5 {
+		t.Errorf("UpdateNodePool called %d times, want once per drift", n)
+	}
+
+	pool := provider.NodePools()["pool-1"]
+	if !reflect.DeepEqual(pool.Spec.Labels, desiredPool.Labels) {
+		t.Errorf("labels after Remediate() = %v, want %v", pool.Spec.Labels, desiredPool.Labels)
+	}
+	if !reflect.DeepEqual(pool.Spec.Taints, desiredPool.Taints) {
+		t.Errorf("taints after Remediate() = %v, want %v", pool.Spec.Taints, desiredPool.Taints)
+	}
+}
+
+func TestDriftDetector_ConcurrentProviders(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{}}
+	eng := engine.NewEngine(nil, nil)
+	for _, name := range []string{"gcp", "aws", "azure", "oci"} {
+		desired.Clusters[name+"-cluster"] = &api.Cluster{
+			ID:       name + "-cluster",
+			Metadata: api.ResourceMetadata{Name: name + "-cluster"},
+			Spec:     api.ClusterSpec{Provider: name},
+		}
+		// Providers are empty, so every desired cluster is reported deleted
+		eng.RegisterProvider(fake.NewProvider(name))
+	}
+
+	detector := NewDriftDetector(eng, logger, WithParallelism(2))
+	report, err := detector.DetectDrift(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("DetectDrift() error = %v", err)
+	}
+
+	want := []string{"aws", "azure", "gcp", "oci"}
+	if len(report.Drifts) != len(want) {
+		t.Fatalf("DetectDrift() got %d drifts, want %d", len(report.Drifts), len(want))
+	}
+	for i, drift := range report.Drifts {
+		if drift.Resource.Provider != want[i] {
+			t.Errorf("drift[%d].Provider = %s, want %s", i, drift.Resource.Provider, want[i])
+		}
+	}
+}
+
+func TestDriftDetector_StableReport(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{}}
+	eng := engine.NewEngine(nil, nil)
+	for _, providerName := range []string{"aws", "azure"} {
+		provider := fake.NewProvider(providerName)
+		for _, name := range []string{"alpha", "bravo", "charlie", "delta"} {
+			cluster := &api.Cluster{
+				ID:       providerName + "-" + name,
+				Metadata: api.ResourceMetadata{Name: name},
+				Spec: api.ClusterSpec{
+					Provider:     providerName,
+					ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+					WorkerPools: []api.WorkerPoolSpec{
+						{Name: "general", DesiredSize: 3, Labels: map[string]string{"a": "1", "b": "2", "c": "3"}},
+					},
+				},
+			}
+			desired.Clusters[cluster.ID] = cluster
+
+			actual := *cluster
+			actual.Spec.ControlPlane.Version = "1.28"
+			actual.Spec.WorkerPools = []api.WorkerPoolSpec{{Name: "general", DesiredSize: 5}}
+			provider.SeedCluster(&actual)
+		}
+		eng.RegisterProvider(provider)
+	}
+
+	detector := NewDriftDetector(eng, logger)
+	detectedAt := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	var wantText string
+	var wantJSON []byte
+	for run := 0; run < 10; run++ {
+		report, err := detector.DetectDrift(context.Background(), desired)
+		if err != nil {
+			t.Fatalf("DetectDrift() error = %v", err)
+		}
+		report.DetectedAt = detectedAt
+
+		text := FormatReport(report)
+		data, err := json.Marshal(report)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+
+		if run == 0 {
+			wantText, wantJSON = text, data
+			continue
+		}
+		if text != wantText {
+			t.Fatalf("run %d: FormatReport() output differs from first run", run)
+		}
+		if !bytes.Equal(data, wantJSON) {
+			t.Fatalf("run %d: JSON output differs from first run", run)
+		}
+	}
+}
+
+func TestDriftReport_Sort(t *testing.T) {
+	report := &DriftReport{
+		Drifts: []ResourceDrift{
+			{Resource: api.ResourceID{Provider: "azure", Kind: "Cluster", Name: "a"}, Field: "cluster"},
+			{Resource: api.ResourceID{Provider: "aws", Kind: "NodePool", Name: "a"}, Field: "desiredSize"},
+			{Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", Name: "b"}, Field: "controlPlane.version"},
+			{Resource: api.ResourceID{Provider: "aws", Kind: "NodePool", Name: "a"}, Field: "labels.team"},
+			{Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", Name: "a"}, Field: "cluster"},
+		},
+		Summary: DriftSummary{TotalDrifts: 5},
+	}
+
+	report.Sort()
+
+	want := []string{
+		"aws/Cluster/a/cluster",
+		"aws/Cluster/b/controlPlane.version",
+		"aws/NodePool/a/desiredSize",
+		"aws/NodePool/a/labels.team",
+		"azure/Cluster/a/cluster",
+	}
+	for i, drift := range report.Drifts {
+		got := drift.Resource.Provider + "/" + drift.Resource.Kind + "/" + drift.Resource.Name + "/" + drift.Field
+		if got != want[i] {
+			t.Errorf("Drifts[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	if report.Summary.TotalDrifts != 5 {
+		t.Errorf("Sort() changed summary: %+v", report.Summary)
+	}
+}
+
+func TestSeverity_AtLeast(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		other    Severity
+		want     bool
+	}{
+		{SeverityCritical, SeverityCritical, true},
+		{SeverityCritical, SeverityLow, true},
+		{SeverityHigh, SeverityCritical, false},
+		{SeverityHigh, SeverityHigh, true},
+		{SeverityMedium, SeverityHigh, false},
+		{SeverityMedium, SeverityMedium, true},
+		{SeverityLow, SeverityMedium, false},
+		{SeverityLow, SeverityLow, true},
+		{Severity("unknown"), SeverityLow, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.severity)+"_"+string(tt.other), func(t *testing.T) {
+			if got := tt.severity.AtLeast(tt.other); got != tt.want {
+				t.Errorf("%s.AtLeast(%s) = %v, want %v", tt.severity, tt.other, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if got, err := ParseSeverity("HIGH"); err != nil || got != SeverityHigh {
+		t.Errorf("ParseSeverity(HIGH) = %v, %v, want high", got, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("ParseSeverity(urgent) expected error")
+	}
+}
+
+func TestDriftReport_Filter(t *testing.T) {
+	report := &DriftReport{
+		DetectedAt: time.Now(),
+		HasDrift:   true,
+		Drifts: []ResourceDrift{
+			{Resource: api.ResourceID{Name: "a"}, Severity: SeverityCritical, Remediatable: true},
+			{Resource: api.ResourceID{Name: "b"}, Severity: SeverityHigh, Remediatable: false},
+			{Resource: api.ResourceID{Name: "c"}, Severity: SeverityMedium, Remediatable: true},
+			{Resource: api.ResourceID{Name: "d"}, Severity: SeverityLow, Remediatable: true},
+		},
+	}
+	report.summarize()
+
+	tests := []struct {
+		name          string
+		minSeverity   Severity
+		wantTotal     int
+		wantRemediate int
+	}{
+		{name: "low keeps all", minSeverity: SeverityLow, wantTotal: 4, wantRemediate: 3},
+		{name: "medium", minSeverity: SeverityMedium, wantTotal: 3, wantRemediate: 2},
+		{name: "high", minSeverity: SeverityHigh, wantTotal: 2, wantRemediate: 1},
+		{name: "critical", minSeverity: SeverityCritical, wantTotal: 1, wantRemediate: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := report.Filter(tt.minSeverity)
+
+			if filtered.Summary.TotalDrifts != tt.wantTotal || len(filtered.Drifts) != tt.wantTotal {
+				t.Errorf("Filter(%s) got %d drifts, want %d", tt.minSeverity, len(filtered.Drifts), tt.wantTotal)
+			}
+			if filtered.Summary.RemediableCount != tt.wantRemediate {
+				t.Errorf("Filter(%s) remediable = %d, want %d", tt.minSeverity, filtered.Summary.RemediableCount, tt.wantRemediate)
+			}
+			if filtered.Summary.LowCount != 0 && tt.minSeverity != SeverityLow {
+				t.Errorf("Filter(%s) kept low drift", tt.minSeverity)
+			}
+		})
+	}
+
+	if len(report.Drifts) != 4 {
+		t.Error("Filter() should not modify the original report")
+	}
+
+	empty := (&DriftReport{Drifts: []ResourceDrift{{Severity: SeverityLow}}}).Filter(SeverityHigh)
+	if empty.HasDrift {
+		t.Error("Filter() removing every drift should clear HasDrift")
+	}
+}
+
 func TestFormatReport(t *testing.T) {
 	report := &DriftReport{
 		DetectedAt: time.Now(),
@@ -176,6 +551,167 @@ func TestFormatReport(t *testing.T) {
 	}
 }
 
+func TestFormatReportColor(t *testing.T) {
+	report := &DriftReport{
+		DetectedAt: time.Now(),
+		HasDrift:   true,
+		Drifts: []ResourceDrift{
+			{
+				Resource:  api.ResourceID{Kind: "Cluster", Name: "gone"},
+				DriftType: DriftResourceDeleted,
+				Severity:  SeverityCritical,
+			},
+			{
+				Resource:  api.ResourceID{Kind: "Cluster", Name: "resized"},
+				DriftType: DriftScaleChange,
+				Severity:  SeverityMedium,
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	out.WriteString(FormatReportColor(report, color.Enabled(&out)))
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("FormatReportColor() to a buffer contains color codes: %q", out.String())
+	}
+
+	output := FormatReportColor(report, true)
+	for _, want := range []string{
+		color.Wrap(true, color.Red, "Cluster/gone - resource_deleted"),
+		color.Wrap(true, color.Yellow, "Cluster/resized - scale_change"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("FormatReportColor() = %q, want %q", output, want)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0
 }
+
+func TestDriftDetector_IgnoreChangesWithin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	now := time.Now()
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"applied": {
+			ID:       "applied",
+			Metadata: api.ResourceMetadata{Name: "applied", UpdatedAt: now.Add(-2 * time.Minute)},
+			Spec:     api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: "1.29"}},
+		},
+		"settled": {
+			ID:       "settled",
+			Metadata: api.ResourceMetadata{Name: "settled", UpdatedAt: now.Add(-time.Hour)},
+			Spec: api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+				WorkerPools: []api.WorkerPoolSpec{{Name: "general", DesiredSize: 3}}},
+		},
+	}, NodePools: map[string]*api.NodePool{
+		// Scaled on its own 2 minutes ago
+		"np-1": {
+			ID:       "np-1",
+			Metadata: api.ResourceMetadata{Name: "general", UpdatedAt: now.Add(-2 * time.Minute)},
+			Spec:     api.WorkerPoolSpec{Name: "general", DesiredSize: 3},
+			Status:   api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: "settled"}},
+		},
+	}}
+
+	// Both clusters still run 1.28, and the pool has 2 nodes
+	provider := fake.NewProvider("aws")
+	for id := range desired.Clusters {
+		provider.SeedCluster(&api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: id},
+			Spec: api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: "1.28"},
+				WorkerPools: []api.WorkerPoolSpec{{Name: "general", DesiredSize: 2}}},
+		})
+	}
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantClusters []string
+		wantSettling []string
+	}{
+		{name: "off by default", wantClusters: []string{"applied", "settled", "general"}},
+		{name: "5m settle window", opts: []Option{WithIgnoreChangesWithin(5 * time.Minute)}, wantClusters: []string{"settled"}, wantSettling: []string{"applied", "settled/general"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewDriftDetector(eng, logger, tt.opts...).DetectDrift(context.Background(), desired)
+			if err != nil {
+				t.Fatalf("DetectDrift() error = %v", err)
+			}
+
+			var clusters []string
+			for _, drift := range report.Drifts {
+				clusters = append(clusters, drift.Resource.Name)
+			}
+			if !reflect.DeepEqual(clusters, tt.wantClusters) {
+				t.Errorf("drifted clusters = %v, want %v", clusters, tt.wantClusters)
+			}
+
+			var settling []string
+			for _, resource := range report.Filter(SeverityLow).Settling {
+				settling = append(settling, resource.Name)
+			}
+			if !reflect.DeepEqual(settling, tt.wantSettling) {
+				t.Errorf("settling clusters = %v, want %v", settling, tt.wantSettling)
+			}
+		})
+	}
+}
+
+func TestDriftDetector_IgnoreChanges(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	pools := func(gpuSize int, team string) []api.WorkerPoolSpec {
+		return []api.WorkerPoolSpec{
+			{Name: "gpu", DesiredSize: gpuSize, Labels: map[string]string{"team": team}},
+			{Name: "general", DesiredSize: gpuSize},
+		}
+	}
+
+	desired := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "production"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+			WorkerPools:  pools(2, "ml"),
+		},
+	}
+	desired.Metadata.SetIgnoreChanges(api.IgnoreRules{"workerPools.gpu.desiredSize"})
+
+	// The autoscaler grew both pools and someone relabeled the GPU pool
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "production"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+			WorkerPools:  pools(5, "research"),
+		},
+	})
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+
+	report, err := NewDriftDetector(eng, logger).DetectDrift(context.Background(),
+		engine.State{Clusters: map[string]*api.Cluster{"cluster-1": desired}})
+	if err != nil {
+		t.Fatalf("DetectDrift() error = %v", err)
+	}
+
+	var got []string
+	for _, drift := range report.Drifts {
+		got = append(got, drift.Resource.Name+"."+drift.Field)
+	}
+	sort.Strings(got)
+	if want := []string{"general.desiredSize", "gpu.labels.team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reported drifts = %v, want %v", got, want)
+	}
+}
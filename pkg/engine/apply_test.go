@@ -0,0 +1,354 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestEngine_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	existing := &api.Cluster{
+		ID:       "cluster-old",
+		Metadata: api.ResourceMetadata{Name: "old"},
+		Spec:     api.ClusterSpec{Provider: "aws"},
+	}
+	pool := func(id, clusterName string) *api.NodePool {
+		return &api.NodePool{ID: id, Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterName: clusterName}}}
+	}
+	if err := sm.SaveState(ctx, engine.State{
+		Clusters:  map[string]*api.Cluster{existing.ID: existing},
+		NodePools: map[string]*api.NodePool{"pool-old": pool("pool-old", "old"), "pool-other": pool("pool-other", "other")},
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(existing)
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	plan := engine.Plan{Actions: []engine.Action{
+		{
+			Type:     engine.ActionCreate,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "new", Name: "new"},
+			Parameters: map[string]interface{}{
+				"spec": api.ClusterSpec{Provider: "aws", Config: map[string]interface{}{"name": "new"}},
+			},
+		},
+		{
+			Type:     engine.ActionDelete,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-old", Name: "old"},
+		},
+	}}
+
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if len(current.Clusters) != 1 {
+		t.Fatalf("state has %d clusters, want 1", len(current.Clusters))
+	}
+	for id, cluster := range current.Clusters {
+		if cluster.Metadata.Name != "new" || id == "new" {
+			t.Errorf("state cluster = %s (%s), want new with provider-assigned ID", cluster.Metadata.Name, id)
+		}
+		if cluster.Metadata.CreatedAt.IsZero() || cluster.Metadata.UpdatedAt.IsZero() {
+			t.Errorf("state cluster metadata = %+v, want creation timestamps", cluster.Metadata)
+		}
+	}
+	// The deleted cluster's node pools go with it
+	if _, ok := current.NodePools["pool-old"]; ok || len(current.NodePools) != 1 {
+		t.Errorf("state node pools = %v, want only pool-other", current.NodePools)
+	}
+
+	if provider.CallCount("CreateCluster") != 1 || provider.CallCount("DeleteCluster") != 1 {
+		t.Errorf("provider calls = %v, want one create and one delete", provider.Calls())
+	}
+}
+
+func TestEngine_ApplyReplacement(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	existing := &api.Cluster{
+		ID:       "cluster-old",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Provider: "aws", Network: api.NetworkSpec{VPCCIDR: "10.0.0.0/16"}},
+	}
+	if err := sm.SaveState(ctx, engine.State{Clusters: map[string]*api.Cluster{existing.ID: existing}}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(existing)
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	spec := existing.Spec
+	spec.Network.VPCCIDR = "10.1.0.0/16"
+	spec.Config = map[string]interface{}{"name": "prod"}
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:       engine.ActionUpdate,
+		Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: existing.ID, Name: "prod"},
+		Parameters: map[string]interface{}{"spec": spec},
+	}}}
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	// The plan shows a destroy and create, so apply must not update in place
+	if provider.CallCount("DeleteCluster") != 1 || provider.CallCount("CreateCluster") != 1 || provider.CallCount("UpdateCluster") != 0 {
+		t.Errorf("provider calls = %v, want one delete and one create", provider.Calls())
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if _, exists := current.Clusters[existing.ID]; exists || len(current.Clusters) != 1 {
+		t.Fatalf("state clusters = %v, want only the replacement", current.Clusters)
+	}
+	for _, cluster := range current.Clusters {
+		if cluster.Spec.Network.VPCCIDR != "10.1.0.0/16" {
+			t.Errorf("replacement VPC CIDR = %q, want 10.1.0.0/16", cluster.Spec.Network.VPCCIDR)
+		}
+	}
+}
+
+func TestEngine_ApplyDefaultTags(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(fake.NewProvider("aws"))
+	eng.SetDefaultTags(map[string]string{"owner": "infra", "team": "shared"})
+
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:     engine.ActionCreate,
+		Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "new", Name: "new"},
+		Parameters: map[string]interface{}{
+			"spec": api.ClusterSpec{
+				Provider: "aws",
+				Config:   map[string]interface{}{"name": "new"},
+				Tags:     map[string]string{"team": "platform"},
+			},
+		},
+	}}}
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	want := map[string]string{"owner": "infra", "team": "platform"}
+	for _, cluster := range current.Clusters {
+		if !reflect.DeepEqual(cluster.Spec.Tags, want) {
+			t.Errorf("cluster tags = %v, want %v", cluster.Spec.Tags, want)
+		}
+	}
+}
+
+func TestEngine_ApplyCorrelationID(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.RegisterProvider(fake.NewProvider("aws"))
+
+	var actions []engine.Action
+	for _, name := range []string{"a", "b", "c"} {
+		actions = append(actions, engine.Action{
+			Type:       engine.ActionCreate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: name, Name: name},
+			Parameters: map[string]interface{}{"spec": api.ClusterSpec{Provider: "aws"}},
+		})
+	}
+
+	correlationIDs := func() map[string]int {
+		t.Helper()
+		events, err := sm.Events().GetEventsByTimeRange(ctx, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("GetEventsByTimeRange() error = %v", err)
+		}
+		ids := make(map[string]int)
+		for _, event := range events {
+			ids[event.CorrelationID]++
+		}
+		return ids
+	}
+
+	if err := eng.Apply(ctx, engine.Plan{Actions: actions}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	ids := correlationIDs()
+	if len(ids) != 1 || ids[""] != 0 {
+		t.Fatalf("events have correlation IDs %v, want one shared ID", ids)
+	}
+
+	// A second apply gets its own ID, or keeps the one of the caller
+	for i := range actions {
+		actions[i].Resource.ID += "-2"
+	}
+	if err := eng.Apply(engine.WithCorrelationID(ctx, "apply-2"), engine.Plan{Actions: actions}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if ids := correlationIDs(); len(ids) != 2 || ids["apply-2"] != len(actions) {
+		t.Errorf("events have correlation IDs %v, want %d for apply-2", ids, len(actions))
+	}
+}
+
+func TestEngine_ApplyResumesSavedPlan(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	provider := fake.NewProvider("aws")
+	provider.FailOn("CreateCluster", 2, errors.New("connection reset"))
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.RegisterProvider(provider)
+
+	plan := engine.Plan{ID: "plan-1"}
+	for _, name := range []string{"a", "b", "c"} {
+		plan.Actions = append(plan.Actions, engine.Action{
+			Type:       engine.ActionCreate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", Name: name},
+			Parameters: map[string]interface{}{"spec": api.ClusterSpec{Provider: "aws", Config: map[string]interface{}{"name": name}}},
+		})
+	}
+
+	// The apply is interrupted after creating a
+	if err := eng.Apply(ctx, plan); err == nil {
+		t.Fatal("Apply() error = nil, want the injected failure")
+	}
+	applied, err := eng.AppliedActions(ctx, plan)
+	if err != nil {
+		t.Fatalf("AppliedActions() error = %v", err)
+	}
+	if !reflect.DeepEqual(applied, map[int]bool{0: true}) {
+		t.Fatalf("AppliedActions() = %v, want only the first action", applied)
+	}
+
+	// Applying the saved plan again only runs the remaining actions
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() resuming error = %v", err)
+	}
+	if n := provider.CallCount("CreateCluster"); n != 4 {
+		t.Errorf("CreateCluster called %d times, want 4: a, the failed b, then b and c", n)
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	names := make(map[string]int)
+	for _, cluster := range current.Clusters {
+		names[cluster.Metadata.Name]++
+	}
+	if !reflect.DeepEqual(names, map[string]int{"a": 1, "b": 1, "c": 1}) {
+		t.Errorf("state clusters = %v, want a, b and c once each", names)
+	}
+
+	if applied, _ := eng.AppliedActions(ctx, plan); len(applied) != 3 {
+		t.Errorf("AppliedActions() after resuming = %v, want all 3", applied)
+	}
+	// Marks belong to the plan that made them
+	if applied, _ := eng.AppliedActions(ctx, engine.Plan{ID: "plan-2", Actions: plan.Actions}); len(applied) != 0 {
+		t.Errorf("AppliedActions() of another plan = %v, want none", applied)
+	}
+}
+
+// deadlineProvider records the deadline CreateCluster is called with
+type deadlineProvider struct {
+	*fake.Provider
+	deadline time.Time
+	ok       bool
+}
+
+func (p *deadlineProvider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*api.Cluster, error) {
+	p.deadline, p.ok = ctx.Deadline()
+	return p.Provider.CreateCluster(ctx, spec)
+}
+
+func TestEngine_ApplyProvisionTimeout(t *testing.T) {
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	provider := &deadlineProvider{Provider: fake.NewProvider("aws")}
+	provider.SetProvisionTime(10 * time.Minute)
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:       engine.ActionCreate,
+		Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "new", Name: "new"},
+		Parameters: map[string]interface{}{"spec": api.ClusterSpec{Provider: "aws"}},
+	}}}
+
+	start := time.Now()
+	if err := eng.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if !provider.ok {
+		t.Fatal("CreateCluster() ran without a deadline")
+	}
+	if timeout := provider.deadline.Sub(start); timeout < 29*time.Minute || timeout > 31*time.Minute {
+		t.Errorf("creation timeout = %v, want three times the 10m estimate", timeout)
+	}
+
+	// A caller's own deadline is kept
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	plan.Actions[0].Resource.ID = "other"
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if want, _ := ctx.Deadline(); !provider.deadline.Equal(want) {
+		t.Errorf("deadline = %v, want the caller's %v", provider.deadline, want)
+	}
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ClockChecker is implemented by providers that can report the time of
+// their cloud API. Clouds reject signed requests and tokens once the local
+// clock drifts a few minutes from theirs.
+type ClockChecker interface {
+	// ServerTime returns the cloud API's current time
+	ServerTime(ctx context.Context) (time.Time, error)
+}
+
+// serverTimeClient makes the requests of HTTPServerTime. Its timeout keeps an
+// unreachable endpoint from hanging a clock check whose context has no
+// deadline.
+var serverTimeClient = &http.Client{Timeout: 10 * time.Second}
+
+// HTTPServerTime returns the time in the Date header of the response to a
+// HEAD request for url. Any response carries the header, so the request needs
+// no credentials.
+func HTTPServerTime(ctx context.Context, url string) (time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	resp, err := serverTimeClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to reach %s: %w", url, err)
+	}
+	resp.Body.Close()
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return time.Time{}, fmt.Errorf("%s returned no Date header", url)
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s returned an invalid Date header %q: %w", url, date, err)
+	}
+	return serverTime, nil
+}
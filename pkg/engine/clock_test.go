@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPServerTime(t *testing.T) {
+	serverTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/nodate" {
+			w.Header()["Date"] = nil
+			return
+		}
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	got, err := HTTPServerTime(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("HTTPServerTime() error = %v", err)
+	}
+	if !got.Equal(serverTime) {
+		t.Errorf("HTTPServerTime() = %v, want %v", got, serverTime)
+	}
+
+	if _, err := HTTPServerTime(context.Background(), server.URL+"/nodate"); err == nil {
+		t.Error("HTTPServerTime() succeeded without a Date header")
+	}
+}
+
+func TestHTTPServerTime_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	timeout := serverTimeClient.Timeout
+	serverTimeClient.Timeout = 50 * time.Millisecond
+	defer func() { serverTimeClient.Timeout = timeout }()
+
+	if _, err := HTTPServerTime(context.Background(), server.URL); err == nil {
+		t.Error("HTTPServerTime() of a server that never answers succeeded, want a timeout")
+	}
+}
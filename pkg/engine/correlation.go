@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// CorrelationIDKey is the log attribute holding an operation's correlation ID
+const CorrelationIDKey = "correlation_id"
+
+// correlationIDKey is the context key of the operation's correlation ID
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a fresh ID for an apply or reconcile operation
+func NewCorrelationID() string {
+	return uuid.NewString()
+}
+
+// WithCorrelationID returns a context carrying the correlation ID that ties
+// the events and log lines of one operation together
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFrom returns the correlation ID carried by ctx, or ""
+func CorrelationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// EnsureCorrelationID returns ctx with a new correlation ID unless it
+// already carries one, so an operation started by a caller keeps its ID
+func EnsureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationIDFrom(ctx) != "" {
+		return ctx
+	}
+	return WithCorrelationID(ctx, NewCorrelationID())
+}
+
+// CorrelationHandler adds the correlation ID of the logging context to every
+// record, so lines logged with the Context methods of slog.Logger can be
+// traced back to their operation
+type CorrelationHandler struct {
+	slog.Handler
+}
+
+// NewCorrelationHandler wraps handler to add correlation IDs
+func NewCorrelationHandler(handler slog.Handler) *CorrelationHandler {
+	return &CorrelationHandler{Handler: handler}
+}
+
+// Handle adds the correlation ID, if any, and passes the record on
+func (h *CorrelationHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := CorrelationIDFrom(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String(CorrelationIDKey, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the wrapper around the handler with attrs
+func (h *CorrelationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewCorrelationHandler(h.Handler.WithAttrs(attrs))
+}
+
+// WithGroup keeps the wrapper around the handler with the group
+func (h *CorrelationHandler) WithGroup(name string) slog.Handler {
+	return NewCorrelationHandler(h.Handler.WithGroup(name))
+}
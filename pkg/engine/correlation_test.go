@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCorrelationHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewCorrelationHandler(slog.NewTextHandler(&buf, nil))).With("cluster", "prod")
+
+	ctx := WithCorrelationID(context.Background(), "op-1")
+	logger.InfoContext(ctx, "creating cluster")
+	logger.Info("without context")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "correlation_id=op-1") || !strings.Contains(lines[0], "cluster=prod") {
+		t.Errorf("line = %q, want correlation ID and logger attributes", lines[0])
+	}
+	if strings.Contains(lines[1], CorrelationIDKey) {
+		t.Errorf("line = %q, want no correlation ID without one in the context", lines[1])
+	}
+
+	if id := CorrelationIDFrom(EnsureCorrelationID(ctx)); id != "op-1" {
+		t.Errorf("EnsureCorrelationID() replaced the ID with %q", id)
+	}
+	if id := CorrelationIDFrom(EnsureCorrelationID(context.Background())); id == "" {
+		t.Error("EnsureCorrelationID() did not assign an ID")
+	}
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// ForgetResource removes a cluster or node pool from state without touching
+// the cloud, for resources deleted out of band. ref is the resource's ID or
+// name; a name shared by several resources must be given as an ID instead.
+// Forgetting a cluster also forgets the node pools recorded as belonging to
+// it. The forgotten resources are returned, the named one first.
+func ForgetResource(state *State, kind, ref string) ([]api.ResourceID, error) {
+	switch kind {
+	case "Cluster":
+		names := make(map[string]string, len(state.Clusters))
+		for id, cluster := range state.Clusters {
+			names[id] = cluster.Metadata.Name
+		}
+		id, err := resolveRef(kind, ref, names)
+		if err != nil {
+			return nil, err
+		}
+
+		cluster := state.Clusters[id]
+		forgotten := []api.ResourceID{{Provider: cluster.Spec.Provider, Kind: kind, ID: id, Name: cluster.Metadata.Name}}
+		delete(state.Clusters, id)
+		return append(forgotten, forgetClusterPools(state, cluster)...), nil
+
+	case "NodePool":
+		names := make(map[string]string, len(state.NodePools))
+		for id, pool := range state.NodePools {
+			names[id] = poolName(pool)
+		}
+		id, err := resolveRef(kind, ref, names)
+		if err != nil {
+			return nil, err
+		}
+
+		forgotten := []api.ResourceID{{Kind: kind, ID: id, Name: poolName(state.NodePools[id])}}
+		delete(state.NodePools, id)
+		return forgotten, nil
+
+	default:
+		return nil, fmt.Errorf("unknown resource kind %q (want Cluster or NodePool)", kind)
+	}
+}
+
+// forgetClusterPools removes the node pools recorded as belonging to a
+// cluster from state, returning them ordered by ID
+func forgetClusterPools(state *State, cluster *api.Cluster) []api.ResourceID {
+	var poolIDs []string
+	for poolID, pool := range state.NodePools {
+		if pool.Status.Properties[api.PropertyClusterName] == cluster.Metadata.Name {
+			poolIDs = append(poolIDs, poolID)
+		}
+	}
+	sort.Strings(poolIDs)
+
+	var forgotten []api.ResourceID
+	for _, poolID := range poolIDs {
+		forgotten = append(forgotten, api.ResourceID{
+			Provider: cluster.Spec.Provider, Kind: "NodePool", ID: poolID, Name: poolName(state.NodePools[poolID]),
+		})
+		delete(state.NodePools, poolID)
+	}
+	return forgotten
+}
+
+// resolveRef finds the ID of the resource whose ID or name is ref, given the
+// names of the resources by ID. An exact ID match wins over name matches.
+func resolveRef(kind, ref string, names map[string]string) (string, error) {
+	if _, exists := names[ref]; exists {
+		return ref, nil
+	}
+
+	var byName []string
+	for id, name := range names {
+		if name == ref {
+			byName = append(byName, id)
+		}
+	}
+
+	switch {
+	case len(byName) == 1:
+		return byName[0], nil
+	case len(byName) > 1:
+		sort.Strings(byName)
+		return "", fmt.Errorf("%s name %q is ambiguous, use one of the IDs: %s", kind, ref, strings.Join(byName, ", "))
+	default:
+		return "", fmt.Errorf("%s %q not found in state", kind, ref)
+	}
+}
+
+func poolName(pool *api.NodePool) string {
+	if pool.Metadata.Name != "" {
+		return pool.Metadata.Name
+	}
+	return pool.Spec.Name
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func forgetTestState() State {
+	owned := func(cluster string) api.ResourceStatus {
+		return api.ResourceStatus{Properties: map[string]string{api.PropertyClusterName: cluster}}
+	}
+	return State{
+		Clusters: map[string]*api.Cluster{
+			"c-1": {ID: "c-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}},
+			"c-2": {ID: "c-2", Metadata: api.ResourceMetadata{Name: "staging"}, Spec: api.ClusterSpec{Provider: "aws"}},
+		},
+		NodePools: map[string]*api.NodePool{
+			"np-1": {ID: "np-1", Metadata: api.ResourceMetadata{Name: "general"}, Status: owned("prod")},
+			"np-2": {ID: "np-2", Metadata: api.ResourceMetadata{Name: "gpu"}, Status: owned("prod")},
+			"np-3": {ID: "np-3", Metadata: api.ResourceMetadata{Name: "general"}, Status: owned("staging")},
+		},
+	}
+}
+
+func TestForgetResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		kind, ref   string
+		wantIDs     []string
+		wantErr     string
+		wantPools   int
+		wantCluster int
+	}{
+		{name: "cluster by name takes its pools", kind: "Cluster", ref: "prod",
+			wantIDs: []string{"c-1", "np-1", "np-2"}, wantCluster: 1, wantPools: 1},
+		{name: "cluster by ID", kind: "Cluster", ref: "c-2",
+			wantIDs: []string{"c-2", "np-3"}, wantCluster: 1, wantPools: 2},
+		{name: "pool by unique name", kind: "NodePool", ref: "gpu",
+			wantIDs: []string{"np-2"}, wantCluster: 2, wantPools: 2},
+		{name: "pool by ID", kind: "NodePool", ref: "np-3",
+			wantIDs: []string{"np-3"}, wantCluster: 2, wantPools: 2},
+		{name: "ambiguous pool name", kind: "NodePool", ref: "general", wantErr: "ambiguous"},
+		{name: "unknown cluster", kind: "Cluster", ref: "dev", wantErr: "not found"},
+		{name: "unknown kind", kind: "Network", ref: "prod", wantErr: "unknown resource kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := forgetTestState()
+			forgotten, err := ForgetResource(&state, tt.kind, tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ForgetResource() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(state.Clusters) != 2 || len(state.NodePools) != 3 {
+					t.Errorf("ForgetResource() modified state on error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ForgetResource() error = %v", err)
+			}
+
+			var ids []string
+			for _, id := range forgotten {
+				ids = append(ids, id.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("ForgetResource() forgot %v, want %v", ids, tt.wantIDs)
+			}
+			if len(state.Clusters) != tt.wantCluster || len(state.NodePools) != tt.wantPools {
+				t.Errorf("state has %d clusters and %d pools, want %d and %d",
+					len(state.Clusters), len(state.NodePools), tt.wantCluster, tt.wantPools)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// ClusterCredentials are what a kubeconfig holds to reach a cluster's API
+// server
+type ClusterCredentials struct {
+	CAData []byte // PEM certificates the API server's certificate is verified against
+	Token  string // Bearer token; short-lived where the cloud issues one
+}
+
+// KubeconfigIssuer is implemented by providers that can issue the
+// credentials of a cluster's kubeconfig
+type KubeconfigIssuer interface {
+	// ClusterCredentials returns credentials for the API server of cluster
+	ClusterCredentials(ctx context.Context, cluster *api.Cluster) (*ClusterCredentials, error)
+}
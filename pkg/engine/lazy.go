@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ProviderLoader constructs a provider the first time the engine needs it
+type ProviderLoader func(ctx context.Context) (CloudProvider, error)
+
+// lazyProvider is a provider registered by its loader and not yet constructed
+type lazyProvider struct {
+	mu   sync.Mutex // Serializes loads, so the provider is constructed once
+	load ProviderLoader
+	done CloudProvider
+}
+
+// get constructs the provider unless an earlier call did. Failures are not
+// remembered, so a load cut short by a cancelled context can be retried.
+func (l *lazyProvider) get(ctx context.Context) (CloudProvider, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done == nil {
+		provider, err := l.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.done = provider
+	}
+	return l.done, nil
+}
+
+// RegisterProviderLoader registers the named provider without constructing
+// it. Cloud SDKs resolve credentials over the network when a provider is
+// constructed, so commands register every provider they might use this way
+// and only pay for those they do use. It replaces a provider of the same
+// name and is safe to call while the engine is in use.
+func (e *Engine) RegisterProviderLoader(name string, load ProviderLoader) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	delete(e.providers, name)
+	e.loaders[name] = &lazyProvider{load: load}
+}
+
+// RegisterRegionalProviderLoader registers the named provider for clusters in
+// one region, for providers whose clients are bound to the region they were
+// constructed for. LoadRegionalProvider prefers it to a provider registered
+// under the name alone.
+func (e *Engine) RegisterRegionalProviderLoader(name, region string, load ProviderLoader) {
+	e.RegisterProviderLoader(regionalProviderKey(name, region), load)
+}
+
+// HasRegionalProvider reports whether the named provider is registered for
+// region, without constructing it
+func (e *Engine) HasRegionalProvider(name, region string) bool {
+	return e.HasProvider(regionalProviderKey(name, region))
+}
+
+// LoadRegionalProvider returns the named provider registered for region,
+// falling back to the one registered under the name alone
+func (e *Engine) LoadRegionalProvider(ctx context.Context, name, region string) (CloudProvider, error) {
+	if e.HasRegionalProvider(name, region) {
+		return e.LoadProvider(ctx, regionalProviderKey(name, region))
+	}
+	return e.LoadProvider(ctx, name)
+}
+
+// regionalProviderKey is the name a regional provider is registered under. A
+// provider for no particular region is registered under its name alone.
+func regionalProviderKey(name, region string) string {
+	if region == "" {
+		return name
+	}
+	return name + "/" + region
+}
+
+// HasProvider reports whether a provider is registered under name, without
+// constructing it
+func (e *Engine) HasProvider(name string) bool {
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	_, constructed := e.providers[name]
+	_, lazy := e.loaders[name]
+	return constructed || lazy
+}
+
+// LoadProvider returns the named provider, constructing it on first use if
+// it was registered by a loader
+func (e *Engine) LoadProvider(ctx context.Context, name string) (CloudProvider, error) {
+	e.providersMu.RLock()
+	provider, constructed := e.providers[name]
+	lazy := e.loaders[name]
+	e.providersMu.RUnlock()
+
+	if constructed {
+		return provider, nil
+	}
+	if lazy == nil {
+		return nil, fmt.Errorf("%w: %q", ErrProviderNotFound, name)
+	}
+
+	provider, err := lazy.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Promote the provider unless it was replaced while loading
+	e.providersMu.Lock()
+	if e.loaders[name] == lazy {
+		delete(e.loaders, name)
+		e.providers[name] = provider
+	}
+	e.providersMu.Unlock()
+	return provider, nil
+}
+
+// loadAll constructs every provider registered by a loader, skipping those
+// that fail; callers that use a provider report its error through
+// LoadProvider
+func (e *Engine) loadAll() {
+	e.providersMu.RLock()
+	names := make([]string, 0, len(e.loaders))
+	for name := range e.loaders {
+		names = append(names, name)
+	}
+	e.providersMu.RUnlock()
+
+	for _, name := range names {
+		_, _ = e.LoadProvider(context.Background(), name)
+	}
+}
@@ -0,0 +1,95 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+func TestEngine_RegisterProviderLoader(t *testing.T) {
+	eng := engine.NewEngine(nil, nil)
+
+	var loads atomic.Int32
+	eng.RegisterProviderLoader("aws", func(ctx context.Context) (engine.CloudProvider, error) {
+		loads.Add(1)
+		return fake.NewProvider("aws"), nil
+	})
+
+	if !eng.HasProvider("aws") || eng.HasProvider("azure") {
+		t.Errorf("HasProvider() = %v, %v, want true for aws only", eng.HasProvider("aws"), eng.HasProvider("azure"))
+	}
+	if n := loads.Load(); n != 0 {
+		t.Fatalf("provider loaded %d time(s) on registration, want 0", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if eng.GetProvider("aws") == nil {
+				t.Error("GetProvider(aws) = nil, want the loaded provider")
+			}
+		}()
+	}
+	wg.Wait()
+	_ = eng.Providers()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("provider loaded %d time(s), want 1", n)
+	}
+
+	if _, err := eng.LoadProvider(context.Background(), "azure"); !errors.Is(err, engine.ErrProviderNotFound) {
+		t.Errorf("LoadProvider(azure) error = %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestEngine_LoadProviderRetriesFailures(t *testing.T) {
+	eng := engine.NewEngine(nil, nil)
+
+	errExpired := errors.New("credentials expired")
+	fail := true
+	eng.RegisterProviderLoader("aws", func(ctx context.Context) (engine.CloudProvider, error) {
+		if fail {
+			return nil, errExpired
+		}
+		return fake.NewProvider("aws"), nil
+	})
+
+	if _, err := eng.LoadProvider(context.Background(), "aws"); !errors.Is(err, errExpired) {
+		t.Fatalf("LoadProvider() error = %v, want the loader's error", err)
+	}
+	if eng.GetProvider("aws") != nil || len(eng.Providers()) != 0 {
+		t.Error("a provider that failed to load is reported as registered")
+	}
+
+	fail = false
+	if _, err := eng.LoadProvider(context.Background(), "aws"); err != nil {
+		t.Errorf("LoadProvider() after a failure error = %v, want a retry to succeed", err)
+	}
+}
+
+// A command that registers providers but never uses them, like plan
+// --refresh=false or a create rejected by validation, must not wait for
+// credential resolution
+func TestEngine_UnusedProvidersAreNotConstructed(t *testing.T) {
+	const resolve = 200 * time.Millisecond
+	slowLoader := func(ctx context.Context) (engine.CloudProvider, error) {
+		time.Sleep(resolve)
+		return fake.NewProvider("aws"), nil
+	}
+
+	start := time.Now()
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProviderLoader("aws", slowLoader)
+	eng.RegisterProviderLoader("azure", slowLoader)
+	if elapsed := time.Since(start); elapsed >= resolve {
+		t.Errorf("registering providers took %v, want well under the %v to construct one", elapsed, resolve)
+	}
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// FindNodePool looks up a node pool by name within a cluster using the
+// provider's ListNodePools. It returns nil if no pool has that name.
+func FindNodePool(ctx context.Context, provider CloudProvider, clusterID, name string) (*api.NodePool, error) {
+	pools, err := provider.ListNodePools(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node pools: %w", err)
+	}
+
+	for _, pool := range pools {
+		if pool.Spec.Name == name {
+			return pool, nil
+		}
+	}
+	return nil, nil
+}
+
+// PoolTags resolves the effective cloud tags for a node pool by merging the
+// owning cluster's tags beneath the pool's own. If the provider does not
+// report the cluster, only the mandatory and pool tags apply.
+func PoolTags(ctx context.Context, provider CloudProvider, clusterID string, spec api.WorkerPoolSpec) (map[string]string, error) {
+	cluster, err := provider.GetCluster(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s: %w", clusterID, err)
+	}
+	if cluster == nil {
+		return api.MergeTags(api.MandatoryTags(clusterID), spec.Tags), nil
+	}
+
+	return cluster.Spec.PoolTags(cluster.Metadata.Name, spec), nil
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// PhaseRecorder centralizes phase changes of resources. With an EventStore
+// every transition is recorded as an EventPhaseChanged event, so GetEvents
+// returns a resource's phase timeline. A nil recorder, or one without a
+// store, only sets the phase.
+type PhaseRecorder struct {
+	events EventStore
+	now    func() time.Time
+}
+
+// NewPhaseRecorder creates a recorder writing transitions to events
+func NewPhaseRecorder(events EventStore) *PhaseRecorder {
+	return &PhaseRecorder{
+		events: events,
+		now:    time.Now,
+	}
+}
+
+// SetPhase moves a resource's status to phase and records the transition
+// with its reason. Setting the phase the resource is already in records
+// nothing.
+func (r *PhaseRecorder) SetPhase(ctx context.Context, resource api.ResourceID, status *api.ResourceStatus, phase api.Phase, reason string) error {
+	from := status.Phase
+	status.Phase = phase
+
+	if from == phase || r == nil || r.events == nil {
+		return nil
+	}
+
+	event := api.Event{
+		ID:        uuid.New(),
+		Timestamp: r.now(),
+		Type:      api.EventPhaseChanged,
+		Resource:  resource,
+		Actor:     resource.Provider,
+		Payload: api.PhaseTransition{
+			From:   from,
+			To:     phase,
+			Reason: reason,
+		},
+		CorrelationID: CorrelationIDFrom(ctx),
+	}
+	if err := r.events.RecordEvent(ctx, event); err != nil {
+		return fmt.Errorf("failed to record phase change of %s %s: %w", resource.Kind, resource.Name, err)
+	}
+	return nil
+}
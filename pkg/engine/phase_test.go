@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// memoryEvents is an in-memory EventStore
+type memoryEvents struct {
+	events []api.Event
+	err    error
+}
+
+func (m *memoryEvents) RecordEvent(ctx context.Context, event api.Event) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryEvents) GetEvents(ctx context.Context, resourceID api.ResourceID) ([]api.Event, error) {
+	var events []api.Event
+	for _, event := range m.events {
+		if event.Resource.ID == resourceID.ID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEvents) GetEventsByTimeRange(ctx context.Context, from, to time.Time, resources ...api.ResourceID) ([]api.Event, error) {
+	return m.events, nil
+}
+
+func (m *memoryEvents) ReplayEvents(ctx context.Context, since *api.Event) (State, error) {
+	return State{}, nil
+}
+
+func (m *memoryEvents) StreamEvents(ctx context.Context, from time.Time, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, event := range m.events {
+		if !event.Timestamp.Before(from) {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestPhaseRecorder_SetPhase(t *testing.T) {
+	ctx := context.Background()
+	events := &memoryEvents{}
+	recorder := NewPhaseRecorder(events)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	resource := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "prod"}
+	status := &api.ResourceStatus{}
+
+	steps := []struct {
+		phase  api.Phase
+		reason string
+	}{
+		{api.PhaseProvisioning, "creating"},
+		{api.PhaseProvisioning, "still creating"},
+		{api.PhaseRunning, "control plane ready"},
+		{api.PhaseFailed, "node group failed"},
+	}
+	for _, step := range steps {
+		if err := recorder.SetPhase(ctx, resource, status, step.phase, step.reason); err != nil {
+			t.Fatalf("SetPhase(%s) error = %v", step.phase, err)
+		}
+		if status.Phase != step.phase {
+			t.Fatalf("status.Phase = %s, want %s", status.Phase, step.phase)
+		}
+	}
+
+	timeline, err := events.GetEvents(ctx, resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []api.PhaseTransition{
+		{From: "", To: api.PhaseProvisioning, Reason: "creating"},
+		{From: api.PhaseProvisioning, To: api.PhaseRunning, Reason: "control plane ready"},
+		{From: api.PhaseRunning, To: api.PhaseFailed, Reason: "node group failed"},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("recorded %d events, want %d: %+v", len(timeline), len(want), timeline)
+	}
+	for i, event := range timeline {
+		if event.Type != api.EventPhaseChanged || event.Resource != resource || !event.Timestamp.Equal(now) {
+			t.Errorf("event %d = %+v", i, event)
+		}
+		if got := event.Payload.(api.PhaseTransition); got != want[i] {
+			t.Errorf("event %d transition = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestPhaseRecorder_WithoutStore(t *testing.T) {
+	status := &api.ResourceStatus{}
+
+	var recorder *PhaseRecorder
+	if err := recorder.SetPhase(context.Background(), api.ResourceID{}, status, api.PhaseRunning, ""); err != nil {
+		t.Fatalf("SetPhase() error = %v", err)
+	}
+	if status.Phase != api.PhaseRunning {
+		t.Errorf("status.Phase = %s, want %s", status.Phase, api.PhaseRunning)
+	}
+}
+
+func TestPhaseRecorder_StoreError(t *testing.T) {
+	storeErr := errors.New("disk full")
+	recorder := NewPhaseRecorder(&memoryEvents{err: storeErr})
+
+	status := &api.ResourceStatus{}
+	err := recorder.SetPhase(context.Background(), api.ResourceID{Kind: "Cluster", Name: "prod"}, status, api.PhaseRunning, "")
+	if !errors.Is(err, storeErr) {
+		t.Errorf("SetPhase() error = %v, want %v", err, storeErr)
+	}
+	if status.Phase != api.PhaseRunning {
+		t.Errorf("status.Phase = %s, want the phase set even if recording fails", status.Phase)
+	}
+}
@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// PreflightSeverity says whether a preflight issue stops an upgrade
+type PreflightSeverity string
+
+const (
+	// PreflightBlocking issues make the upgrade fail or break the cluster;
+	// Apply refuses such upgrades unless forced
+	PreflightBlocking PreflightSeverity = "blocking"
+
+	// PreflightWarning issues need attention but do not stop the upgrade
+	PreflightWarning PreflightSeverity = "warning"
+)
+
+// Preflight issue categories
+const (
+	PreflightVersionSkew   = "version-skew"
+	PreflightDeprecatedAPI = "deprecated-api"
+	PreflightAddon         = "addon"
+)
+
+// PreflightIssue is one finding of an upgrade preflight
+type PreflightIssue struct {
+	Severity  PreflightSeverity `json:"severity"`
+	Category  string            `json:"category"`
+	Summary   string            `json:"summary"`
+	Resources []string          `json:"resources,omitempty"` // Affected APIs or add-ons
+}
+
+func (i PreflightIssue) String() string {
+	s := i.Summary
+	if len(i.Resources) > 0 {
+		s += " (" + strings.Join(i.Resources, ", ") + ")"
+	}
+	return s
+}
+
+// PreflightResult lists what upgrading a cluster's Kubernetes version would
+// run into
+type PreflightResult struct {
+	CurrentVersion string           `json:"currentVersion"`
+	TargetVersion  string           `json:"targetVersion"`
+	Issues         []PreflightIssue `json:"issues,omitempty"`
+}
+
+// Blocking returns the issues that stop the upgrade
+func (r PreflightResult) Blocking() []PreflightIssue {
+	return r.withSeverity(PreflightBlocking)
+}
+
+// Warnings returns the issues that do not stop the upgrade
+func (r PreflightResult) Warnings() []PreflightIssue {
+	return r.withSeverity(PreflightWarning)
+}
+
+func (r PreflightResult) withSeverity(severity PreflightSeverity) []PreflightIssue {
+	var issues []PreflightIssue
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// UpgradePreflighter is implemented by providers that can check a cluster
+// for what a Kubernetes version upgrade would break, such as add-ons
+// without a compatible version or deprecated APIs still in use
+type UpgradePreflighter interface {
+	// UpgradePreflight returns the provider's findings for upgrading the
+	// cluster, as recorded in state, to targetVersion. Checks every provider
+	// shares, like version skew, are left to the engine.
+	UpgradePreflight(ctx context.Context, cluster *api.Cluster, targetVersion string) (PreflightResult, error)
+}
+
+// VersionUpgrade returns the current and target Kubernetes versions of an
+// action that updates a cluster's control plane version
+func VersionUpgrade(action Action, current State) (from, to string, ok bool) {
+	if action.Type != ActionUpdate || action.Resource.Kind != "Cluster" {
+		return "", "", false
+	}
+	spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+	if !ok {
+		return "", "", false
+	}
+	cluster, exists := current.Clusters[action.Resource.ID]
+	if !exists {
+		return "", "", false
+	}
+	from, to = cluster.Spec.ControlPlane.Version, spec.ControlPlane.Version
+	if from == "" || to == "" || from == to {
+		return "", "", false
+	}
+	return from, to, true
+}
+
+// UpgradePreflight checks upgrading a cluster from its current Kubernetes
+// version in state to another: the version skew and the APIs the upgrade
+// removes for every provider, and whatever else the provider can check if it
+// implements UpgradePreflighter
+func UpgradePreflight(ctx context.Context, provider CloudProvider, cluster *api.Cluster, to string) (PreflightResult, error) {
+	from := cluster.Spec.ControlPlane.Version
+	result := PreflightResult{
+		CurrentVersion: from,
+		TargetVersion:  to,
+		Issues:         versionIssues(from, to),
+	}
+
+	if preflighter, ok := provider.(UpgradePreflighter); ok {
+		found, err := preflighter.UpgradePreflight(ctx, cluster, to)
+		if err != nil {
+			return PreflightResult{}, fmt.Errorf("upgrade preflight of cluster %s: %w", cluster.ID, err)
+		}
+		result.Issues = append(result.Issues, found.Issues...)
+	}
+	return result, nil
+}
+
+// removedAPIs lists the beta APIs each Kubernetes minor version stops
+// serving, from the upstream deprecation guide. Manifests and clients still
+// using them fail after the upgrade.
+var removedAPIs = map[int][]string{
+	25: {
+		"batch/v1beta1 CronJob",
+		"discovery.k8s.io/v1beta1 EndpointSlice",
+		"events.k8s.io/v1beta1 Event",
+		"autoscaling/v2beta1 HorizontalPodAutoscaler",
+		"policy/v1beta1 PodDisruptionBudget",
+		"policy/v1beta1 PodSecurityPolicy",
+		"node.k8s.io/v1beta1 RuntimeClass",
+	},
+	26: {
+		"flowcontrol.apiserver.k8s.io/v1beta1 FlowSchema",
+		"flowcontrol.apiserver.k8s.io/v1beta1 PriorityLevelConfiguration",
+		"autoscaling/v2beta2 HorizontalPodAutoscaler",
+	},
+	27: {
+		"storage.k8s.io/v1beta1 CSIStorageCapacity",
+	},
+	29: {
+		"flowcontrol.apiserver.k8s.io/v1beta2 FlowSchema",
+		"flowcontrol.apiserver.k8s.io/v1beta2 PriorityLevelConfiguration",
+	},
+	32: {
+		"flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema",
+		"flowcontrol.apiserver.k8s.io/v1beta3 PriorityLevelConfiguration",
+	},
+}
+
+// versionIssues returns the issues of an upgrade that follow from the
+// versions alone. The control plane moves one minor version at a time and
+// never back; the APIs removed along the way are warnings, since whether
+// anything still uses them cannot be told from here.
+func versionIssues(from, to string) []PreflightIssue {
+	fromMinor, err := MinorVersion(from)
+	if err != nil {
+		return []PreflightIssue{{Severity: PreflightWarning, Category: PreflightVersionSkew, Summary: err.Error()}}
+	}
+	toMinor, err := MinorVersion(to)
+	if err != nil {
+		return []PreflightIssue{{Severity: PreflightWarning, Category: PreflightVersionSkew, Summary: err.Error()}}
+	}
+
+	var issues []PreflightIssue
+	switch {
+	case toMinor < fromMinor:
+		issues = append(issues, PreflightIssue{
+			Severity: PreflightBlocking,
+			Category: PreflightVersionSkew,
+			Summary:  fmt.Sprintf("downgrading the control plane from %s to %s is not supported", from, to),
+		})
+	case toMinor > fromMinor+1:
+		issues = append(issues, PreflightIssue{
+			Severity: PreflightBlocking,
+			Category: PreflightVersionSkew,
+			Summary:  fmt.Sprintf("the control plane upgrades one minor version at a time; upgrade %s to 1.%d first", from, fromMinor+1),
+		})
+	}
+
+	minors := make([]int, 0, len(removedAPIs))
+	for minor := range removedAPIs {
+		if minor > fromMinor && minor <= toMinor {
+			minors = append(minors, minor)
+		}
+	}
+	sort.Ints(minors)
+	for _, minor := range minors {
+		issues = append(issues, PreflightIssue{
+			Severity:  PreflightWarning,
+			Category:  PreflightDeprecatedAPI,
+			Summary:   fmt.Sprintf("1.%d no longer serves these APIs; migrate manifests and clients using them", minor),
+			Resources: removedAPIs[minor],
+		})
+	}
+	return issues
+}
+
+// MinorVersion returns the minor version of a Kubernetes 1.x version such as
+// "1.30", "1.30.2" or "v1.30"
+func MinorVersion(version string) (int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, fmt.Errorf("cannot parse Kubernetes version %q", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("cannot parse Kubernetes version %q", version)
+	}
+	return minor, nil
+}
+
+// checkUpgrades runs the upgrade preflight of every version upgrade a plan
+// has left to apply and returns ErrUpgradeBlocked listing the blocking
+// issues, if any
+func (e *Engine) checkUpgrades(ctx context.Context, plan Plan, current State, applied map[int]bool) error {
+	var blocked []string
+	for i, action := range plan.Actions {
+		if applied[i] {
+			continue
+		}
+		_, to, ok := VersionUpgrade(action, current)
+		if !ok {
+			continue
+		}
+		cluster := current.Clusters[action.Resource.ID]
+		provider, err := e.LoadRegionalProvider(ctx, action.Resource.Provider, cluster.Spec.Region)
+		if err != nil {
+			return err
+		}
+		result, err := UpgradePreflight(ctx, provider, cluster, to)
+		if err != nil {
+			return fmt.Errorf("%w (use --force-upgrade to skip the preflight)", err)
+		}
+		for _, issue := range result.Blocking() {
+			blocked = append(blocked, fmt.Sprintf("%s to %s: %s", action.Resource.Name, to, issue.String()))
+		}
+	}
+
+	if len(blocked) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cannot upgrade %s (use --force-upgrade to override): %w",
+		strings.Join(blocked, "; "), ErrUpgradeBlocked)
+}
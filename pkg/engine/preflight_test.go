@@ -0,0 +1,173 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestMinorVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    int
+		wantErr bool
+	}{
+		{version: "1.30", want: 30},
+		{version: "1.30.2", want: 30},
+		{version: "v1.29", want: 29},
+		{version: "2.1", wantErr: true},
+		{version: "1", wantErr: true},
+		{version: "1.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := engine.MinorVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MinorVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MinorVersion(%q) = %d, want %d", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestUpgradePreflight(t *testing.T) {
+	provider := fake.NewProvider("aws")
+
+	tests := []struct {
+		name         string
+		from, to     string
+		wantBlocking []string
+		wantWarnings []string
+	}{
+		{name: "next minor", from: "1.28", to: "1.29", wantWarnings: []string{"1.29 no longer serves"}},
+		{name: "no removals", from: "1.29", to: "1.30"},
+		{name: "skipped minor", from: "1.28", to: "1.30", wantBlocking: []string{"upgrade 1.28 to 1.29 first"}, wantWarnings: []string{"1.29 no longer serves"}},
+		{name: "downgrade", from: "1.30", to: "1.29", wantBlocking: []string{"downgrading"}},
+		{name: "unparseable", from: "1.30", to: "latest", wantWarnings: []string{"cannot parse"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &api.Cluster{ID: "cluster-1", Spec: api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: tt.from}}}
+			result, err := engine.UpgradePreflight(context.Background(), provider, cluster, tt.to)
+			if err != nil {
+				t.Fatalf("UpgradePreflight() error = %v", err)
+			}
+			checkIssues(t, "blocking", result.Blocking(), tt.wantBlocking)
+			checkIssues(t, "warning", result.Warnings(), tt.wantWarnings)
+		})
+	}
+}
+
+func checkIssues(t *testing.T, kind string, issues []engine.PreflightIssue, want []string) {
+	t.Helper()
+	if len(issues) != len(want) {
+		t.Fatalf("%s issues = %v, want %d", kind, issues, len(want))
+	}
+	for i, issue := range issues {
+		if !strings.Contains(issue.String(), want[i]) {
+			t.Errorf("%s issue = %q, want %q", kind, issue.String(), want[i])
+		}
+	}
+}
+
+func TestEngine_ApplyUpgradePreflight(t *testing.T) {
+	ctx := context.Background()
+
+	cluster := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: "1.28"}},
+	}
+	upgrade := func(version string) engine.Plan {
+		spec := cluster.Spec
+		spec.ControlPlane.Version = version
+		return engine.Plan{Actions: []engine.Action{{
+			Type:       engine.ActionUpdate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: cluster.ID, Name: "prod"},
+			Parameters: map[string]interface{}{"spec": spec},
+		}}}
+	}
+	setup := func(t *testing.T) (*engine.Engine, *fake.Provider) {
+		sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+		if err != nil {
+			t.Fatalf("NewSQLiteStateManager() error = %v", err)
+		}
+		t.Cleanup(func() { sm.Close() })
+		if err := sm.SaveState(ctx, engine.State{Clusters: map[string]*api.Cluster{cluster.ID: cluster}}); err != nil {
+			t.Fatalf("SaveState() error = %v", err)
+		}
+
+		provider := fake.NewProvider("aws")
+		provider.SeedCluster(cluster)
+		eng := engine.NewEngine(sm, nil)
+		eng.RegisterProvider(provider)
+		return eng, provider
+	}
+
+	t.Run("skipped minor version", func(t *testing.T) {
+		eng, provider := setup(t)
+		err := eng.Apply(ctx, upgrade("1.30"))
+		if !errors.Is(err, engine.ErrUpgradeBlocked) || !strings.Contains(err.Error(), "upgrade 1.28 to 1.29 first") {
+			t.Fatalf("Apply() error = %v, want ErrUpgradeBlocked for the skipped version", err)
+		}
+		if n := provider.CallCount("UpdateCluster"); n != 0 {
+			t.Errorf("UpdateCluster called %d times, want 0", n)
+		}
+	})
+
+	t.Run("provider blocking issue", func(t *testing.T) {
+		eng, provider := setup(t)
+		provider.SetUpgradePreflight(engine.PreflightIssue{
+			Severity:  engine.PreflightBlocking,
+			Category:  engine.PreflightAddon,
+			Summary:   "add-on vpc-cni has no version for Kubernetes 1.29",
+			Resources: []string{"vpc-cni"},
+		})
+		err := eng.Apply(ctx, upgrade("1.29"))
+		if !errors.Is(err, engine.ErrUpgradeBlocked) || !strings.Contains(err.Error(), "vpc-cni") {
+			t.Fatalf("Apply() error = %v, want ErrUpgradeBlocked naming the add-on", err)
+		}
+		if n := provider.CallCount("UpdateCluster"); n != 0 {
+			t.Errorf("UpdateCluster called %d times, want 0", n)
+		}
+
+		eng.SetForceUpgrade(true)
+		if err := eng.Apply(ctx, upgrade("1.29")); err != nil {
+			t.Fatalf("Apply() forced error = %v", err)
+		}
+		if n := provider.CallCount("UpdateCluster"); n != 1 {
+			t.Errorf("UpdateCluster called %d times, want 1 when forced", n)
+		}
+	})
+
+	t.Run("warnings only", func(t *testing.T) {
+		eng, provider := setup(t)
+		if err := eng.Apply(ctx, upgrade("1.29")); err != nil {
+			t.Fatalf("Apply() error = %v, want warnings not to block", err)
+		}
+		if n := provider.CallCount("UpgradePreflight"); n != 1 {
+			t.Errorf("UpgradePreflight called %d times, want 1", n)
+		}
+	})
+
+	t.Run("failed preflight", func(t *testing.T) {
+		eng, provider := setup(t)
+		provider.FailOn("UpgradePreflight", 0, errors.New("access denied"))
+		if err := eng.Apply(ctx, upgrade("1.29")); err == nil || !strings.Contains(err.Error(), "access denied") {
+			t.Fatalf("Apply() error = %v, want the preflight failure", err)
+		}
+		if n := provider.CallCount("UpdateCluster"); n != 0 {
+			t.Errorf("UpdateCluster called %d times, want 0", n)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultProgressInterval is how often long-running cloud operations log
+// their progress unless configured otherwise
+const DefaultProgressInterval = 30 * time.Second
+
+// Progress logs the progress of a long-running cloud operation, such as an
+// Azure LRO or an EKS waiter, at most once per interval, so that a slow
+// create can be told apart from a hung one
+type Progress struct {
+	logger    *slog.Logger
+	interval  time.Duration
+	operation string
+	attrs     []any
+	started   time.Time
+	last      time.Time
+	now       func() time.Time
+}
+
+// NewProgress starts tracking an operation. attrs identify the resource it
+// acts on; a zero interval takes DefaultProgressInterval.
+func NewProgress(logger *slog.Logger, interval time.Duration, operation string, attrs ...any) *Progress {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	p := &Progress{
+		logger:    logger,
+		interval:  interval,
+		operation: operation,
+		attrs:     attrs,
+		now:       time.Now,
+	}
+	p.started = p.now()
+	p.last = p.started
+	return p
+}
+
+// Report logs the latest status the operation reported, with its percent
+// complete unless percent is negative, once an interval has passed since the
+// operation started or was last logged
+func (p *Progress) Report(ctx context.Context, status string, percent float64) {
+	now := p.now()
+	if now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+
+	attrs := append([]any{"operation", p.operation}, p.attrs...)
+	attrs = append(attrs, "status", status)
+	if percent >= 0 {
+		attrs = append(attrs, "percentComplete", percent)
+	}
+	attrs = append(attrs, "elapsed", now.Sub(p.started).Round(time.Second).String())
+	p.logger.InfoContext(ctx, "operation in progress", attrs...)
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress_Report(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	progress := NewProgress(logger, 30*time.Second, "create cluster", "cluster", "prod")
+	progress.now = func() time.Time { return now }
+	progress.started, progress.last = start, start
+
+	// Polled every 10s for 100s, progress is logged every 30s
+	for i := 1; i <= 10; i++ {
+		now = start.Add(time.Duration(i) * 10 * time.Second)
+		progress.Report(context.Background(), "InProgress", float64(i*10))
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	wantElapsed := []string{"elapsed=30s", "elapsed=1m0s", "elapsed=1m30s"}
+	if len(lines) != len(wantElapsed) {
+		t.Fatalf("logged %d lines, want %d:\n%s", len(lines), len(wantElapsed), logs.String())
+	}
+	for i, line := range lines {
+		for _, want := range []string{`msg="operation in progress"`, `operation="create cluster"`, "cluster=prod", "status=InProgress", wantElapsed[i]} {
+			if !strings.Contains(line, want) {
+				t.Errorf("line %d = %q, want it to contain %q", i, line, want)
+			}
+		}
+	}
+	if !strings.Contains(lines[0], "percentComplete=30") {
+		t.Errorf("line 0 = %q, want percentComplete=30", lines[0])
+	}
+}
+
+func TestProgress_ReportWithoutPercent(t *testing.T) {
+	var logs bytes.Buffer
+	progress := NewProgress(slog.New(slog.NewTextHandler(&logs, nil)), time.Nanosecond, "create cluster")
+	time.Sleep(time.Millisecond)
+
+	progress.Report(context.Background(), "CREATING", -1)
+	if !strings.Contains(logs.String(), "status=CREATING") || strings.Contains(logs.String(), "percentComplete") {
+		t.Errorf("logged %q, want the status without a percent", logs.String())
+	}
+}
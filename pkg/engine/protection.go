@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// CheckDeletionProtection returns ErrDeletionProtected if the plan deletes or
+// replaces any cluster that has deletion protection enabled in current state
+func CheckDeletionProtection(plan Plan, current State) error {
+	var protected []string
+	for _, action := range plan.Actions {
+		if action.Resource.Kind != "Cluster" {
+			continue
+		}
+		cluster, exists := current.Clusters[action.Resource.ID]
+		if !exists || !cluster.Spec.DeletionProtection {
+			continue
+		}
+
+		switch action.Type {
+		case ActionDelete:
+			protected = append(protected, cluster.Metadata.Name)
+		case ActionUpdate:
+			if spec, ok := action.Parameters["spec"].(api.ClusterSpec); ok && len(cluster.Spec.ReplacementChanges(spec)) > 0 {
+				protected = append(protected, cluster.Metadata.Name)
+			}
+		}
+	}
+
+	if len(protected) == 0 {
+		return nil
+	}
+
+	sort.Strings(protected)
+	return fmt.Errorf("cannot delete %s (use --disable-protection to override): %w",
+		strings.Join(protected, ", "), ErrDeletionProtected)
+}
@@ -0,0 +1,84 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestEngine_ApplyDeletionProtection(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	protected := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Provider: "aws", DeletionProtection: true},
+	}
+	if err := sm.SaveState(ctx, engine.State{
+		Clusters:  map[string]*api.Cluster{protected.ID: protected},
+		NodePools: map[string]*api.NodePool{},
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(protected)
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	plan := engine.Plan{
+		Actions: []engine.Action{{
+			Type:     engine.ActionDelete,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-1", Name: "prod"},
+		}},
+	}
+
+	err = eng.Apply(ctx, plan)
+	if !errors.Is(err, engine.ErrDeletionProtected) {
+		t.Fatalf("Apply() error = %v, want ErrDeletionProtected", err)
+	}
+	if n := provider.CallCount("DeleteCluster"); n != 0 {
+		t.Errorf("DeleteCluster called %d times, want 0", n)
+	}
+
+	// Unprotected clusters are not affected
+	unprotected := engine.State{Clusters: map[string]*api.Cluster{
+		"cluster-1": {ID: "cluster-1", Spec: api.ClusterSpec{Provider: "aws"}},
+	}}
+	if err := engine.CheckDeletionProtection(plan, unprotected); err != nil {
+		t.Errorf("CheckDeletionProtection() error = %v, want nil", err)
+	}
+
+	// Replacing a cluster deletes it too; updating it in place does not
+	current := engine.State{Clusters: map[string]*api.Cluster{protected.ID: protected}}
+	update := func(spec api.ClusterSpec) engine.Plan {
+		return engine.Plan{Actions: []engine.Action{{
+			Type:       engine.ActionUpdate,
+			Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-1", Name: "prod"},
+			Parameters: map[string]interface{}{"spec": spec},
+		}}}
+	}
+	replaced := protected.Spec
+	replaced.Region = "eu-west-1"
+	if err := engine.CheckDeletionProtection(update(replaced), current); !errors.Is(err, engine.ErrDeletionProtected) {
+		t.Errorf("CheckDeletionProtection(replacement) error = %v, want ErrDeletionProtected", err)
+	}
+	inPlace := protected.Spec
+	inPlace.Tags = map[string]string{"team": "platform"}
+	if err := engine.CheckDeletionProtection(update(inPlace), current); err != nil {
+		t.Errorf("CheckDeletionProtection(in-place update) error = %v, want nil", err)
+	}
+}
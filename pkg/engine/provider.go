@@ -3,6 +3,11 @@ package engine
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
 )
@@ -18,8 +23,9 @@ type CloudProvider interface {
 	// UpdateCluster updates an existing cluster
 	UpdateCluster(ctx context.Context, cluster *api.Cluster) error
 
-	// DeleteCluster deletes a cluster
-	DeleteCluster(ctx context.Context, clusterID string) error
+	// DeleteCluster deletes a cluster as recorded in state. Deleting a
+	// cluster that no longer exists succeeds, so retried deletes converge.
+	DeleteCluster(ctx context.Context, cluster *api.Cluster) error
 
 	// GetCluster retrieves cluster information
 	GetCluster(ctx context.Context, clusterID string) (*api.Cluster, error)
@@ -30,11 +36,38 @@ type CloudProvider interface {
 	// UpdateNodePool updates a node pool
 	UpdateNodePool(ctx context.Context, pool *api.NodePool) error
 
-	// DeleteNodePool deletes a node pool
+	// DeleteNodePool deletes a node pool. Like DeleteCluster it succeeds
+	// for a pool that no longer exists.
 	DeleteNodePool(ctx context.Context, poolID string) error
 
+	// ListNodePools lists the node pools of a cluster
+	ListNodePools(ctx context.Context, clusterID string) ([]*api.NodePool, error)
+
 	// Reconcile performs reconciliation between desired and actual state
 	Reconcile(ctx context.Context, desired, actual State) (Plan, error)
+
+	// EstimateProvisionTime returns roughly how long creating a cluster with
+	// the spec takes. It is a heuristic from typical provisioning times, not
+	// a promise, and zero means the provider cannot tell.
+	EstimateProvisionTime(spec api.ClusterSpec) time.Duration
+}
+
+// provisionTimeoutFactor is how many times its estimate a cluster creation
+// may take before it is given up on
+const provisionTimeoutFactor = 3
+
+// ProvisionTimeout returns the default time limit for creating a cluster
+// whose provisioning is estimated to take estimate, or zero for no limit
+func ProvisionTimeout(estimate time.Duration) time.Duration {
+	return provisionTimeoutFactor * estimate
+}
+
+// CredentialValidator is implemented by providers that can cheaply verify
+// their credentials before any real work is done
+type CredentialValidator interface {
+	// Validate returns an error wrapping ErrInvalidCredentials if the
+	// provider's credentials are missing, invalid or expired
+	Validate(ctx context.Context) error
 }
 
 // State represents the complete state of infrastructure
@@ -48,6 +81,11 @@ type State struct {
 // Plan represents a set of actions to apply
 type Plan struct {
 	Actions []Action
+
+	// ID is set on plans saved for a later apply. Applying such a plan marks
+	// each completed action, so applying it again after an interruption
+	// resumes where the interrupted apply stopped.
+	ID string
 }
 
 // Action represents a single infrastructure action
@@ -69,9 +107,17 @@ const (
 
 // Engine is the main provisioning engine
 type Engine struct {
-	providers map[string]CloudProvider
-	state     StateManager
-	events    EventStore
+	providersMu sync.RWMutex // Guards providers and loaders against registration during use
+	providers   map[string]CloudProvider
+	loaders     map[string]*lazyProvider // Registered providers not yet constructed
+
+	state  StateManager
+	events EventStore
+	window *MaintenanceWindow
+
+	disableProtection bool
+	forceUpgrade      bool
+	defaultTags       map[string]string
 }
 
 // StateManager manages infrastructure state
@@ -109,67 +155,210 @@ type EventStore interface {
 	// GetEvents retrieves events for a resource
 	GetEvents(ctx context.Context, resourceID api.ResourceID) ([]api.Event, error)
 
+	// GetEventsByTimeRange retrieves events recorded in [from, to),
+	// optionally only those of the given resources
+	GetEventsByTimeRange(ctx context.Context, from, to time.Time, resources ...api.ResourceID) ([]api.Event, error)
+
 	// ReplayEvents replays events to reconstruct state
 	ReplayEvents(ctx context.Context, since *api.Event) (State, error)
+
+	// StreamEvents writes the events recorded at or after from to w as
+	// newline-delimited JSON, oldest first
+	StreamEvents(ctx context.Context, from time.Time, w io.Writer) error
 }
 
 // NewEngine creates a new provisioning engine
 func NewEngine(state StateManager, events EventStore) *Engine {
 	return &Engine{
 		providers: make(map[string]CloudProvider),
+		loaders:   make(map[string]*lazyProvider),
 		state:     state,
 		events:    events,
 	}
 }
 
-// RegisterProvider registers a cloud provider
+// RegisterProvider registers a cloud provider, replacing one of the same
+// name. It is safe to call while the engine is in use.
 func (e *Engine) RegisterProvider(provider CloudProvider) {
+	e.providersMu.Lock()
+	defer e.providersMu.Unlock()
+	delete(e.loaders, provider.Name())
 	e.providers[provider.Name()] = provider
 }
 
-// GetProvider retrieves a registered provider
+// GetProvider retrieves a registered provider, constructing it on first use
+// if it was registered by a loader. It returns nil if the provider is not
+// registered or cannot be constructed; use LoadProvider to learn why.
 func (e *Engine) GetProvider(name string) CloudProvider {
-	return e.providers[name]
+	provider, _ := e.LoadProvider(context.Background(), name)
+	return provider
 }
 
-// Apply executes a plan
+// SetMaintenanceWindow restricts Apply to the given window; nil removes the restriction
+func (e *Engine) SetMaintenanceWindow(window *MaintenanceWindow) {
+	e.window = window
+}
+
+// MaintenanceWindow returns the configured maintenance window, or nil if unrestricted
+func (e *Engine) MaintenanceWindow() *MaintenanceWindow {
+	return e.window
+}
+
+// SetDisableProtection allows Apply to delete clusters with deletion protection
+func (e *Engine) SetDisableProtection(disable bool) {
+	e.disableProtection = disable
+}
+
+// SetForceUpgrade makes Apply upgrade clusters despite blocking upgrade
+// preflight issues, without running the preflight
+func (e *Engine) SetForceUpgrade(force bool) {
+	e.forceUpgrade = force
+}
+
+// SetDefaultTags sets tags merged into every created or updated cluster;
+// tags the cluster spec sets itself take precedence
+func (e *Engine) SetDefaultTags(tags map[string]string) {
+	e.defaultTags = tags
+}
+
+// Providers returns all registered providers keyed by name, constructing
+// those registered by a loader. Providers that cannot be constructed are
+// left out.
+func (e *Engine) Providers() map[string]CloudProvider {
+	e.loadAll()
+
+	e.providersMu.RLock()
+	defer e.providersMu.RUnlock()
+	providers := make(map[string]CloudProvider, len(e.providers))
+	for name, provider := range e.providers {
+		providers[name] = provider
+	}
+	return providers
+}
+
+// Events returns the event store the engine records to, or nil if it has
+// none
+func (e *Engine) Events() EventStore {
+	return e.events
+}
+
+// StateManager returns the state manager the engine persists to
+func (e *Engine) StateManager() StateManager {
+	return e.state
+}
+
+// Apply executes a plan. Events and context-aware log lines of the apply
+// share the correlation ID of ctx, or a new one if ctx has none.
 func (e *Engine) Apply(ctx context.Context, plan Plan) error {
+	ctx = EnsureCorrelationID(ctx)
+
+	if !e.window.InWindow(time.Now()) {
+		return ErrOutsideMaintenanceWindow
+	}
+
+	// Hold the state lock for the whole apply so concurrent runs cannot interleave
+	if err := e.state.Lock(ctx); err != nil {
+		return err
+	}
+	defer e.state.Unlock(ctx)
+
+	current, err := e.state.GetState(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !e.disableProtection {
+		if err := CheckDeletionProtection(plan, current); err != nil {
+			return err
+		}
+	}
+
+	applied, err := e.AppliedActions(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	if !e.forceUpgrade {
+		if err := e.checkUpgrades(ctx, plan, current, applied); err != nil {
+			return err
+		}
+	}
+
+	secrets, err := resolveSecrets(plan)
+	if err != nil {
+		return err
+	}
+
 	tx := e.state.BeginTransaction()
 	defer tx.Rollback()
 
-	for _, action := range plan.Actions {
-		if err := e.executeAction(ctx, action); err != nil {
+	for i, action := range plan.Actions {
+		if applied[i] {
+			continue
+		}
+		key := planActionKey(plan, i)
+
+		actionCtx := ctx
+		if values, ok := secrets[i]; ok {
+			actionCtx = WithSecrets(ctx, values)
+		}
+		if err := e.executeAction(actionCtx, action, &current); err != nil {
+			// Persist whatever succeeded so state matches the cloud
+			if saveErr := e.state.SaveState(ctx, current); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
 			return err
 		}
 
+		// A saved plan is only marked as progressing once state has the
+		// action's result, so a resumed apply finds what this one created
+		if key != "" {
+			if err := e.state.SaveState(ctx, current); err != nil {
+				return err
+			}
+		}
+
 		// Record event for audit trail
+		if e.events == nil {
+			continue
+		}
 		event := api.Event{
-			Type:     toEventType(action.Type),
-			Resource: action.Resource,
-			Payload:  action.Parameters,
+			Type:          toEventType(action.Type),
+			Resource:      action.Resource,
+			Payload:       api.Redact(action.Parameters),
+			CorrelationID: CorrelationIDFrom(ctx),
+			PlanAction:    key,
 		}
 		if err := e.events.RecordEvent(ctx, event); err != nil {
 			return err
 		}
 	}
 
+	if err := e.state.SaveState(ctx, current); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (e *Engine) executeAction(ctx context.Context, action Action) error {
-	provider := e.GetProvider(action.Resource.Provider)
-	if provider == nil {
-		return ErrProviderNotFound
+func (e *Engine) executeAction(ctx context.Context, action Action, current *State) error {
+	provider, err := e.LoadRegionalProvider(ctx, action.Resource.Provider, actionRegion(action, *current))
+	if err != nil {
+		return err
+	}
+
+	if action.Resource.Kind != "Cluster" {
+		return fmt.Errorf("%s %s: unsupported resource kind %q", action.Type, action.Resource.Name, action.Resource.Kind)
 	}
 
 	// Execute action based on type
 	switch action.Type {
 	case ActionCreate:
-		return e.executeCreate(ctx, provider, action)
+		return e.executeCreate(ctx, provider, action, current)
 	case ActionUpdate:
-		return e.executeUpdate(ctx, provider, action)
+		return e.executeUpdate(ctx, provider, action, current)
 	case ActionDelete:
-		return e.executeDelete(ctx, provider, action)
+		return e.executeDelete(ctx, provider, action, current)
 	case ActionNoop:
 		return nil
 	}
@@ -177,18 +366,110 @@ func (e *Engine) executeAction(ctx context.Context, action Action) error {
 	return nil
 }
 
-func (e *Engine) executeCreate(ctx context.Context, provider CloudProvider, action Action) error {
-	// Implementation depends on resource kind
+// actionRegion returns the region of the cluster an action creates or
+// updates, or of the stored cluster it deletes
+func actionRegion(action Action, current State) string {
+	if spec, ok := action.Parameters["spec"].(api.ClusterSpec); ok {
+		return spec.Region
+	}
+	if existing := current.Clusters[action.Resource.ID]; existing != nil {
+		return existing.Spec.Region
+	}
+	return ""
+}
+
+func (e *Engine) executeCreate(ctx context.Context, provider CloudProvider, action Action, current *State) error {
+	spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+	if !ok {
+		return fmt.Errorf("create %s: missing cluster spec", action.Resource.Name)
+	}
+	spec = spec.WithDefaultTags(e.defaultTags)
+
+	// Without a deadline from the caller, bound creation by the estimate
+	if _, ok := ctx.Deadline(); !ok {
+		if timeout := ProvisionTimeout(provider.EstimateProvisionTime(spec)); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+
+	cluster, err := provider.CreateCluster(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster %s: %w", action.Resource.Name, err)
+	}
+
+	// Providers that do not stamp their clusters get the time of the apply
+	cluster.Metadata.Touch(time.Now())
+
+	if current.Clusters == nil {
+		current.Clusters = make(map[string]*api.Cluster)
+	}
+	current.Clusters[cluster.ID] = cluster
+	return nil
+}
+
+func (e *Engine) executeUpdate(ctx context.Context, provider CloudProvider, action Action, current *State) error {
+	spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+	if !ok {
+		return fmt.Errorf("update %s: missing cluster spec", action.Resource.Name)
+	}
+	spec = spec.WithDefaultTags(e.defaultTags)
+
+	existing, exists := current.Clusters[action.Resource.ID]
+	if !exists {
+		return fmt.Errorf("update %s: cluster %s not in state", action.Resource.Name, action.Resource.ID)
+	}
+	if len(existing.Spec.ReplacementChanges(spec)) > 0 {
+		return e.executeReplace(ctx, provider, action, existing, current)
+	}
+
+	updated := *existing
+	updated.Spec = spec
+	if err := provider.UpdateCluster(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update cluster %s: %w", action.Resource.Name, err)
+	}
+	updated.Metadata.Touch(time.Now())
+
+	current.Clusters[updated.ID] = &updated
 	return nil
 }
 
-func (e *Engine) executeUpdate(ctx context.Context, provider CloudProvider, action Action) error {
-	// Implementation depends on resource kind
+// executeReplace deletes a cluster and creates it again from the action's
+// spec, for updates of fields the cloud cannot change in place. The old
+// cluster is deleted by the provider that created it, which differs from the
+// action's when the update moves the cluster to another provider or region.
+func (e *Engine) executeReplace(ctx context.Context, provider CloudProvider, action Action, existing *api.Cluster, current *State) error {
+	oldProvider := provider
+	if existing.Spec.Provider != "" && (existing.Spec.Provider != action.Resource.Provider || existing.Spec.Region != actionRegion(action, *current)) {
+		var err error
+		if oldProvider, err = e.LoadRegionalProvider(ctx, existing.Spec.Provider, existing.Spec.Region); err != nil {
+			return err
+		}
+	}
+
+	if err := e.executeDelete(ctx, oldProvider, action, current); err != nil {
+		return fmt.Errorf("replace %s: %w", action.Resource.Name, err)
+	}
+	if err := e.executeCreate(ctx, provider, action, current); err != nil {
+		return fmt.Errorf("replace %s: %w", action.Resource.Name, err)
+	}
 	return nil
 }
 
-func (e *Engine) executeDelete(ctx context.Context, provider CloudProvider, action Action) error {
-	// Implementation depends on resource kind
+func (e *Engine) executeDelete(ctx context.Context, provider CloudProvider, action Action, current *State) error {
+	cluster, exists := current.Clusters[action.Resource.ID]
+	if !exists {
+		return fmt.Errorf("delete %s: cluster %s not in state", action.Resource.Name, action.Resource.ID)
+	}
+
+	if err := provider.DeleteCluster(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to delete cluster %s: %w", action.Resource.Name, err)
+	}
+
+	// The cluster's node pools went with it
+	delete(current.Clusters, action.Resource.ID)
+	forgetClusterPools(current, cluster)
 	return nil
 }
 
@@ -207,7 +488,13 @@ func toEventType(actionType ActionType) api.EventType {
 
 // Common errors
 var (
-	ErrProviderNotFound = &EngineError{Code: "PROVIDER_NOT_FOUND", Message: "provider not found"}
+	ErrProviderNotFound         = &EngineError{Code: "PROVIDER_NOT_FOUND", Message: "provider not found"}
+	ErrOutsideMaintenanceWindow = &EngineError{Code: "OUTSIDE_MAINTENANCE_WINDOW", Message: "changes are only allowed during the maintenance window"}
+	ErrDeletionProtected        = &EngineError{Code: "DELETION_PROTECTED", Message: "cluster has deletion protection enabled"}
+	ErrInvalidCredentials       = &EngineError{Code: "INVALID_CREDENTIALS", Message: "cloud credentials are missing, invalid or expired"}
+	ErrNotSupported             = &EngineError{Code: "NOT_SUPPORTED", Message: "not supported by this provider"}
+	ErrUpgradeBlocked           = &EngineError{Code: "UPGRADE_BLOCKED", Message: "the upgrade preflight found blocking issues"}
+	ErrPhaseTimeout             = &EngineError{Code: "PHASE_TIMEOUT", Message: "a provisioning phase ran past its timeout"}
 )
 
 // EngineError represents an engine error
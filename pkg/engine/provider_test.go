@@ -0,0 +1,43 @@
+package engine_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+// Run with -race to detect unsynchronized access to the providers map
+func TestEngine_ConcurrentProviderRegistration(t *testing.T) {
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(fake.NewProvider("aws"))
+
+	const workers = 8
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				eng.RegisterProvider(fake.NewProvider(fmt.Sprintf("provider-%d-%d", i, j)))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if eng.GetProvider("aws") == nil {
+					t.Error("GetProvider(aws) = nil during concurrent registration")
+					return
+				}
+				_ = eng.Providers()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := len(eng.Providers()), 1+workers*50; got != want {
+		t.Errorf("Providers() has %d providers, want %d", got, want)
+	}
+}
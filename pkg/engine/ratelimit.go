@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"golang.org/x/time/rate"
+)
+
+// RateLimit caps how fast a provider calls its cloud's API, so concurrent
+// operations share one budget instead of each getting throttled
+type RateLimit struct {
+	RequestsPerSecond float64 // Sustained rate; negative disables the limit
+	Burst             int     // Calls allowed at once before pacing starts
+}
+
+// Limiter returns a token-bucket limiter for the rate limit, taking fields
+// left at zero from defaults
+func (r RateLimit) Limiter(defaults RateLimit) *rate.Limiter {
+	if r.RequestsPerSecond == 0 {
+		r.RequestsPerSecond = defaults.RequestsPerSecond
+	}
+	if r.Burst == 0 {
+		r.Burst = defaults.Burst
+	}
+
+	if r.RequestsPerSecond < 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(r.RequestsPerSecond), max(r.Burst, 1))
+}
@@ -0,0 +1,52 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestRateLimit_Limiter(t *testing.T) {
+	defaults := engine.RateLimit{RequestsPerSecond: 10, Burst: 20}
+
+	tests := []struct {
+		name      string
+		limit     engine.RateLimit
+		wantRate  rate.Limit
+		wantBurst int
+	}{
+		{"defaults", engine.RateLimit{}, 10, 20},
+		{"rate only", engine.RateLimit{RequestsPerSecond: 2}, 2, 20},
+		{"both", engine.RateLimit{RequestsPerSecond: 5, Burst: 1}, 5, 1},
+		{"unlimited", engine.RateLimit{RequestsPerSecond: -1}, rate.Inf, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := tt.limit.Limiter(defaults)
+			if limiter.Limit() != tt.wantRate || limiter.Burst() != tt.wantBurst {
+				t.Errorf("Limiter() = %v/s burst %d, want %v/s burst %d",
+					limiter.Limit(), limiter.Burst(), tt.wantRate, tt.wantBurst)
+			}
+		})
+	}
+}
+
+func TestRateLimit_PacesBursts(t *testing.T) {
+	limiter := engine.RateLimit{RequestsPerSecond: 50, Burst: 5}.Limiter(engine.RateLimit{})
+
+	// The first 5 calls go at once, the other 10 at 50 per second
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("15 calls took %v, want about 200ms", elapsed)
+	}
+}
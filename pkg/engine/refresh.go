@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// RefreshResult describes how stored state differs from cloud reality
+type RefreshResult struct {
+	State   State           // Stored state updated to match reality
+	Changes []RefreshChange // Resources whose stored spec or status changed
+	Missing []api.ResourceID
+}
+
+// RefreshChange lists the field changes for a single refreshed resource
+type RefreshChange struct {
+	Resource api.ResourceID
+	Fields   []api.FieldChange
+}
+
+// HasChanges reports whether the refresh would modify stored state
+func (r *RefreshResult) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Refresh queries providers for the actual state of every cluster and node
+// pool in stored state and returns the stored state updated to match. It does
+// not modify the cloud or persist anything; callers save result.State.
+// Resources the provider no longer reports are listed in Missing and left
+// in state untouched.
+func (e *Engine) Refresh(ctx context.Context) (*RefreshResult, error) {
+	return e.RefreshMatching(ctx, nil)
+}
+
+// RefreshMatching is Refresh limited to the clusters, and their node pools,
+// for which match returns true. Other resources are returned as stored. A nil
+// match refreshes everything.
+func (e *Engine) RefreshMatching(ctx context.Context, match func(*api.Cluster) bool) (*RefreshResult, error) {
+	stored, err := e.state.GetState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	// Refreshed resources go into copies of the stored maps, so a refresh
+	// failing partway leaves the caller's state as it was
+	result := &RefreshResult{State: stored}
+	result.State.Clusters = maps.Clone(stored.Clusters)
+	result.State.NodePools = maps.Clone(stored.NodePools)
+
+	for id, cluster := range stored.Clusters {
+		if match != nil && !match(cluster) {
+			continue
+		}
+
+		provider, err := e.LoadRegionalProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Metadata.Name, err)
+		}
+
+		resource := api.ResourceID{
+			Provider: cluster.Spec.Provider,
+			Kind:     "Cluster",
+			ID:       id,
+			Name:     cluster.Metadata.Name,
+		}
+
+		actual, err := provider.GetCluster(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cluster %s: %w", cluster.Metadata.Name, err)
+		}
+		if actual == nil {
+			result.Missing = append(result.Missing, resource)
+			continue
+		}
+
+		fields := cluster.Spec.Diff(actual.Spec)
+		if cluster.Status.Phase != actual.Status.Phase {
+			fields = append(fields, api.FieldChange{Path: "status.phase", Old: cluster.Status.Phase, New: actual.Status.Phase})
+		}
+		if len(fields) > 0 {
+			refreshed := *cluster
+			refreshed.Spec = actual.Spec
+			refreshed.Status = actual.Status
+			result.State.Clusters[id] = &refreshed
+			result.Changes = append(result.Changes, RefreshChange{Resource: resource, Fields: fields})
+		}
+
+		pools, err := provider.ListNodePools(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list node pools for %s: %w", cluster.Metadata.Name, err)
+		}
+		for _, actualPool := range pools {
+			storedPool, exists := stored.NodePools[actualPool.ID]
+			if !exists {
+				continue
+			}
+
+			fields := storedPool.Spec.Diff(actualPool.Spec)
+			if storedPool.Status.Phase != actualPool.Status.Phase {
+				fields = append(fields, api.FieldChange{Path: "status.phase", Old: storedPool.Status.Phase, New: actualPool.Status.Phase})
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			refreshed := *storedPool
+			refreshed.Spec = actualPool.Spec
+			refreshed.Status = actualPool.Status
+			result.State.NodePools[actualPool.ID] = &refreshed
+			result.Changes = append(result.Changes, RefreshChange{
+				Resource: api.ResourceID{
+					Provider: cluster.Spec.Provider,
+					Kind:     "NodePool",
+					ID:       actualPool.ID,
+					Name:     storedPool.Metadata.Name,
+				},
+				Fields: fields,
+			})
+		}
+	}
+
+	sort.Slice(result.Changes, func(i, j int) bool {
+		a, b := result.Changes[i].Resource, result.Changes[j].Resource
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestEngine_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	stored := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.28"},
+		},
+		Status: api.ResourceStatus{Phase: api.PhaseRunning},
+	}
+	gone := &api.Cluster{
+		ID:       "cluster-2",
+		Metadata: api.ResourceMetadata{Name: "gone"},
+		Spec:     api.ClusterSpec{Provider: "aws"},
+	}
+	if err := sm.SaveState(ctx, engine.State{
+		Clusters:  map[string]*api.Cluster{stored.ID: stored, gone.ID: gone},
+		NodePools: map[string]*api.NodePool{},
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	actual := *stored
+	actual.Spec.ControlPlane.Version = "1.29"
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&actual)
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	result, err := eng.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if len(result.Changes) != 1 {
+		t.Fatalf("Refresh() got %d changes, want 1", len(result.Changes))
+	}
+	if fields := result.Changes[0].Fields; len(fields) != 1 || fields[0].Path != "controlPlane.version" {
+		t.Errorf("Refresh() fields = %v, want controlPlane.version", fields)
+	}
+	if got := result.State.Clusters["cluster-1"].Spec.ControlPlane.Version; got != "1.29" {
+		t.Errorf("refreshed version = %s, want 1.29", got)
+	}
+
+	if len(result.Missing) != 1 || result.Missing[0].ID != "cluster-2" {
+		t.Errorf("Refresh() missing = %v, want [cluster-2]", result.Missing)
+	}
+	if _, exists := result.State.Clusters["cluster-2"]; !exists {
+		t.Error("Refresh() should leave missing clusters in state")
+	}
+
+	// Refresh never touches the cloud
+	if n := provider.CallCount("UpdateCluster"); n != 0 {
+		t.Errorf("Refresh() called UpdateCluster %d times", n)
+	}
+
+	// Only matching clusters are queried
+	calls := provider.CallCount("GetCluster")
+	result, err = eng.RefreshMatching(ctx, func(cluster *api.Cluster) bool { return cluster.ID == "cluster-1" })
+	if err != nil {
+		t.Fatalf("RefreshMatching() error = %v", err)
+	}
+	if n := provider.CallCount("GetCluster") - calls; n != 1 {
+		t.Errorf("RefreshMatching() called GetCluster %d times, want 1", n)
+	}
+	if len(result.Changes) != 1 || len(result.Missing) != 0 {
+		t.Errorf("RefreshMatching() changes = %v, missing = %v, want cluster-1 only", result.Changes, result.Missing)
+	}
+}
+
+// memoryState is a StateManager handing out the state it holds itself, as a
+// caching state manager would
+type memoryState struct {
+	state engine.State
+}
+
+func (m *memoryState) GetState(ctx context.Context) (engine.State, error) { return m.state, nil }
+func (m *memoryState) SaveState(ctx context.Context, s engine.State) error {
+	m.state = s
+	return nil
+}
+func (m *memoryState) BeginTransaction() engine.Transaction { return nil }
+func (m *memoryState) Lock(ctx context.Context) error       { return nil }
+func (m *memoryState) Unlock(ctx context.Context) error     { return nil }
+
+func TestEngine_RefreshFailureLeavesState(t *testing.T) {
+	ctx := context.Background()
+
+	stored := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: "1.28"}},
+	}
+	sm := &memoryState{state: engine.State{
+		Clusters:  map[string]*api.Cluster{stored.ID: stored},
+		NodePools: map[string]*api.NodePool{},
+	}}
+
+	actual := *stored
+	actual.Spec.ControlPlane.Version = "1.29"
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&actual)
+	provider.FailOn("ListNodePools", 1, errors.New("throttled"))
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	if _, err := eng.Refresh(ctx); err == nil {
+		t.Fatal("Refresh() error = nil, want the ListNodePools failure")
+	}
+	if got := sm.state.Clusters[stored.ID]; got != stored || got.Spec.ControlPlane.Version != "1.28" {
+		t.Errorf("failed Refresh() changed stored cluster to %+v", got)
+	}
+}
+
+func TestEngine_RefreshRegionalProviders(t *testing.T) {
+	ctx := context.Background()
+
+	west := &api.Cluster{
+		ID:       "cluster-west",
+		Metadata: api.ResourceMetadata{Name: "west"},
+		Spec:     api.ClusterSpec{Provider: "aws", Region: "us-west-2"},
+	}
+	east := &api.Cluster{
+		ID:       "cluster-east",
+		Metadata: api.ResourceMetadata{Name: "east"},
+		Spec:     api.ClusterSpec{Provider: "aws", Region: "eu-west-1"},
+	}
+	sm := &memoryState{state: engine.State{
+		Clusters:  map[string]*api.Cluster{west.ID: west, east.ID: east},
+		NodePools: map[string]*api.NodePool{},
+	}}
+
+	// Each region's provider only sees the clusters in its region
+	eng := engine.NewEngine(sm, nil)
+	for _, cluster := range []*api.Cluster{west, east} {
+		provider := fake.NewProvider("aws")
+		provider.SeedCluster(cluster)
+		eng.RegisterRegionalProviderLoader("aws", cluster.Spec.Region, func(ctx context.Context) (engine.CloudProvider, error) {
+			return provider, nil
+		})
+	}
+
+	result, err := eng.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if len(result.Missing) != 0 {
+		t.Errorf("Refresh() missing = %v, want each cluster found in its own region", result.Missing)
+	}
+
+	if _, err := eng.LoadRegionalProvider(ctx, "aws", "ap-south-1"); !errors.Is(err, engine.ErrProviderNotFound) {
+		t.Errorf("LoadRegionalProvider(ap-south-1) error = %v, want ErrProviderNotFound", err)
+	}
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderConfig carries everything a provider factory may need. Fields a
+// provider does not use are ignored.
+type ProviderConfig struct {
+	Region         string
+	SubscriptionID string // Azure subscription
+	Project        string // GCP project
+
+	// Credentials holds provider-specific credential settings such as an AWS
+	// profile or an Azure client ID; each provider documents its keys
+	Credentials map[string]string
+
+	// Events, when set, receives an event for every phase transition of the
+	// resources the provider manages
+	Events EventStore
+
+	// RateLimit paces the provider's API calls; zero fields take the
+	// provider's defaults
+	RateLimit RateLimit
+
+	// ProgressInterval is how often long-running operations log their
+	// progress; zero takes DefaultProgressInterval
+	ProgressInterval time.Duration
+
+	// NetworkTimeout, ControlPlaneTimeout and NodePoolTimeout limit each
+	// phase of provisioning a cluster; zero takes the phase's default
+	NetworkTimeout      time.Duration
+	ControlPlaneTimeout time.Duration
+	NodePoolTimeout     time.Duration
+
+	Logger *slog.Logger
+}
+
+// ProviderFactory constructs a provider from configuration
+type ProviderFactory func(ctx context.Context, cfg ProviderConfig) (CloudProvider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]ProviderFactory)
+)
+
+// RegisterProviderFactory makes a provider constructible by name. Providers
+// call it from an init function, so importing a provider package is enough
+// to make it available. It panics if the name is registered twice.
+func RegisterProviderFactory(name string, factory ProviderFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("engine: RegisterProviderFactory factory is nil for " + name)
+	}
+	if _, exists := factories[name]; exists {
+		panic("engine: RegisterProviderFactory called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// ProviderFactories returns the names of all registered providers, sorted
+func ProviderFactories() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider constructs the named provider with its registered factory
+func NewProvider(ctx context.Context, name string, cfg ProviderConfig) (CloudProvider, error) {
+	factoriesMu.RLock()
+	factory, exists := factories[name]
+	factoriesMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrProviderNotFound, name, strings.Join(ProviderFactories(), ", "))
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	provider, err := factory(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+	return provider, nil
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewProvider_Registry(t *testing.T) {
+	var got ProviderConfig
+	RegisterProviderFactory("registry-test", func(ctx context.Context, cfg ProviderConfig) (CloudProvider, error) {
+		got = cfg
+		return nil, nil
+	})
+	defer func() {
+		factoriesMu.Lock()
+		delete(factories, "registry-test")
+		factoriesMu.Unlock()
+	}()
+
+	if _, err := NewProvider(context.Background(), "registry-test", ProviderConfig{Region: "eu-west-1"}); err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if got.Region != "eu-west-1" || got.Logger == nil {
+		t.Errorf("factory got config %+v, want region and a default logger", got)
+	}
+
+	if _, err := NewProvider(context.Background(), "missing", ProviderConfig{}); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("NewProvider() for unknown name error = %v, want ErrProviderNotFound", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterProviderFactory() should panic on duplicate name")
+		}
+	}()
+	RegisterProviderFactory("registry-test", func(ctx context.Context, cfg ProviderConfig) (CloudProvider, error) {
+		return nil, nil
+	})
+}
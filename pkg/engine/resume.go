@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// planActionKey identifies an action of a saved plan in the event recording
+// its completion, or is empty for plans that were not saved
+func planActionKey(plan Plan, i int) string {
+	if plan.ID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", plan.ID, i)
+}
+
+// AppliedActions returns the indexes of the actions of a saved plan that
+// earlier applies of it completed, which Apply skips. Plans that were not
+// saved, or an engine without an event store, have none.
+func (e *Engine) AppliedActions(ctx context.Context, plan Plan) (map[int]bool, error) {
+	if plan.ID == "" || e.events == nil || len(plan.Actions) == 0 {
+		return nil, nil
+	}
+
+	// Created resources have no ID in the plan, so match on the rest
+	resources := make([]api.ResourceID, 0, len(plan.Actions))
+	for _, action := range plan.Actions {
+		resources = append(resources, api.ResourceID{
+			Provider: action.Resource.Provider,
+			Kind:     action.Resource.Kind,
+			Name:     action.Resource.Name,
+		})
+	}
+	events, err := e.events.GetEventsByTimeRange(ctx, time.Time{}, time.Time{}, resources...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress of plan %s: %w", plan.ID, err)
+	}
+
+	marked := make(map[string]bool)
+	for _, event := range events {
+		if event.PlanAction != "" {
+			marked[event.PlanAction] = true
+		}
+	}
+
+	applied := make(map[int]bool)
+	for i := range plan.Actions {
+		if marked[planActionKey(plan, i)] {
+			applied[i] = true
+		}
+	}
+	return applied, nil
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// secretsKey is the context key of the secrets of the cluster being applied
+type secretsKey struct{}
+
+// WithSecrets returns a context carrying the resolved secrets of the cluster
+// a provider call creates or updates. Secrets travel with the call rather
+// than the spec so they never reach state, events or snapshots.
+func WithSecrets(ctx context.Context, secrets map[string]api.Secret) context.Context {
+	return context.WithValue(ctx, secretsKey{}, secrets)
+}
+
+// SecretsFrom returns the secrets carried by ctx, keyed by name, for the
+// provider to inject into the cluster it creates or updates
+func SecretsFrom(ctx context.Context) map[string]api.Secret {
+	secrets, _ := ctx.Value(secretsKey{}).(map[string]api.Secret)
+	return secrets
+}
+
+// resolveSecrets resolves the secrets of every cluster a plan creates or
+// updates, keyed by action index, before any action runs, so a missing
+// secret fails the apply instead of leaving it half done
+func resolveSecrets(plan Plan) (map[int]map[string]api.Secret, error) {
+	resolved := make(map[int]map[string]api.Secret)
+	for i, action := range plan.Actions {
+		if action.Type != ActionCreate && action.Type != ActionUpdate {
+			continue
+		}
+		spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+		if !ok {
+			continue
+		}
+		secrets, err := spec.ResolveSecrets()
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", action.Type, action.Resource.Name, err)
+		}
+		if secrets != nil {
+			resolved[i] = secrets
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,143 @@
+package engine_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/snapshot"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+// Secrets reach the provider but none of what an apply persists: state,
+// events and snapshots taken of the result
+func TestEngine_ApplySecretsAreNotStored(t *testing.T) {
+	const value = "registry-secret-d41d8cd9"
+	t.Setenv("PROVCTL_TEST_REGISTRY_TOKEN", value)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	provider := fake.NewProvider("aws")
+	eng := engine.NewEngine(sm, sm.Events())
+	eng.RegisterProvider(provider)
+
+	spec := api.ClusterSpec{
+		Provider: "aws",
+		Secrets:  []api.SecretRef{{Name: "registry-token", Env: "PROVCTL_TEST_REGISTRY_TOKEN"}},
+		Config:   map[string]interface{}{"name": "web"},
+	}
+	plan := engine.Plan{Actions: []engine.Action{{
+		Type:       engine.ActionCreate,
+		Resource:   api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "web", Name: "web"},
+		Parameters: map[string]interface{}{"spec": spec},
+	}}}
+	if err := eng.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if got := provider.Secrets("web")["registry-token"]; got.Reveal() != value {
+		t.Fatalf("provider got secret %q, want the resolved value", got.Reveal())
+	}
+
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	serializedState, err := json.Marshal(current)
+	if err != nil {
+		t.Fatalf("json.Marshal(state) error = %v", err)
+	}
+	if !strings.Contains(string(serializedState), "registry-token") {
+		t.Errorf("state = %s, want the secret reference kept", serializedState)
+	}
+
+	var events bytes.Buffer
+	if err := sm.Events().StreamEvents(ctx, time.Time{}, &events); err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+
+	snapshots, err := snapshot.NewManager(filepath.Join(dir, "snapshots"), sm)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	snap, err := snapshots.CreateSnapshot(ctx, "after apply", snapshot.TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	serializedSnapshot, err := os.ReadFile(filepath.Join(dir, "snapshots", snap.ID+".json"))
+	if err != nil {
+		t.Fatalf("reading snapshot: %v", err)
+	}
+	database, err := os.ReadFile(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatalf("reading state database: %v", err)
+	}
+
+	for name, serialized := range map[string][]byte{
+		"state":          serializedState,
+		"events":         events.Bytes(),
+		"snapshot":       serializedSnapshot,
+		"state database": database,
+	} {
+		if bytes.Contains(serialized, []byte(value)) {
+			t.Errorf("%s contains the secret value", name)
+		}
+	}
+}
+
+func TestEngine_ApplyUnresolvableSecretChangesNothing(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	provider := fake.NewProvider("aws")
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	plan := engine.Plan{Actions: []engine.Action{
+		{
+			Type:     engine.ActionCreate,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "first", Name: "first"},
+			Parameters: map[string]interface{}{
+				"spec": api.ClusterSpec{Provider: "aws", Config: map[string]interface{}{"name": "first"}},
+			},
+		},
+		{
+			Type:     engine.ActionCreate,
+			Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "second", Name: "second"},
+			Parameters: map[string]interface{}{
+				"spec": api.ClusterSpec{
+					Provider: "aws",
+					Secrets:  []api.SecretRef{{Name: "token", Env: "PROVCTL_TEST_UNSET_TOKEN"}},
+					Config:   map[string]interface{}{"name": "second"},
+				},
+			},
+		},
+	}}
+
+	err = eng.Apply(ctx, plan)
+	if err == nil || !strings.Contains(err.Error(), "PROVCTL_TEST_UNSET_TOKEN") {
+		t.Fatalf("Apply() error = %v, want the unset variable reported", err)
+	}
+	if n := provider.CallCount("CreateCluster"); n != 0 {
+		t.Errorf("provider created %d cluster(s), want none before secrets resolve", n)
+	}
+}
@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProvisionPhase is a step of creating a cluster that is bounded by its own
+// timeout
+type ProvisionPhase string
+
+const (
+	PhaseNetwork      ProvisionPhase = "network"
+	PhaseControlPlane ProvisionPhase = "control plane"
+	PhaseNodePool     ProvisionPhase = "node pool"
+)
+
+// Default phase timeouts. They leave room for slow regions: an EKS control
+// plane typically takes 10 to 15 minutes, a network or node pool a few.
+const (
+	DefaultNetworkTimeout      = 10 * time.Minute
+	DefaultControlPlaneTimeout = 30 * time.Minute
+	DefaultNodePoolTimeout     = 20 * time.Minute
+)
+
+// PhaseTimeouts limit how long each phase of provisioning may take, so that
+// a stuck network fails on its own instead of eating the control plane's
+// time. Zero fields take the phase's default.
+type PhaseTimeouts struct {
+	Network      time.Duration
+	ControlPlane time.Duration
+	NodePool     time.Duration
+}
+
+// Timeout returns the time limit of a phase
+func (t PhaseTimeouts) Timeout(phase ProvisionPhase) time.Duration {
+	switch phase {
+	case PhaseNetwork:
+		return orDefault(t.Network, DefaultNetworkTimeout)
+	case PhaseControlPlane:
+		return orDefault(t.ControlPlane, DefaultControlPlaneTimeout)
+	case PhaseNodePool:
+		return orDefault(t.NodePool, DefaultNodePoolTimeout)
+	}
+	return 0
+}
+
+func orDefault(timeout, fallback time.Duration) time.Duration {
+	if timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
+
+// RunPhase runs one phase of provisioning, canceling it once its timeout
+// passes. A phase that fails because it ran out of time returns an error
+// wrapping ErrPhaseTimeout and naming the phase; a deadline or cancellation
+// of ctx itself is returned as is.
+func (t PhaseTimeouts) RunPhase(ctx context.Context, phase ProvisionPhase, run func(ctx context.Context) error) error {
+	timeout := t.Timeout(phase)
+	phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := run(phaseCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s phase did not finish within %s: %v", ErrPhaseTimeout, phase, timeout, err)
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockUntilDone runs until its context ends, as a hung cloud operation would
+func blockUntilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPhaseTimeouts_RunPhase(t *testing.T) {
+	timeouts := PhaseTimeouts{Network: time.Millisecond, ControlPlane: time.Hour}
+
+	err := timeouts.RunPhase(context.Background(), PhaseNetwork, blockUntilDone)
+	if !errors.Is(err, ErrPhaseTimeout) {
+		t.Fatalf("RunPhase() error = %v, want ErrPhaseTimeout", err)
+	}
+	if !strings.Contains(err.Error(), "network phase did not finish within 1ms") {
+		t.Errorf("RunPhase() error = %q, want it to name the network phase and its timeout", err)
+	}
+
+	// The network's short timeout does not carry over to the control plane
+	if err := timeouts.RunPhase(context.Background(), PhaseControlPlane, func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) < 59*time.Minute {
+			t.Errorf("control plane deadline = %v, want an hour away", deadline)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("RunPhase() error = %v, want nil", err)
+	}
+
+	failure := errors.New("quota exceeded")
+	if err := timeouts.RunPhase(context.Background(), PhaseControlPlane, func(ctx context.Context) error {
+		return failure
+	}); err != failure {
+		t.Errorf("RunPhase() error = %v, want the phase's own error", err)
+	}
+}
+
+func TestPhaseTimeouts_RunPhaseParentCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	err := PhaseTimeouts{}.RunPhase(ctx, PhaseNodePool, blockUntilDone)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrPhaseTimeout) {
+		t.Errorf("RunPhase() error = %v, want the caller's deadline, not a phase timeout", err)
+	}
+}
+
+func TestPhaseTimeouts_Defaults(t *testing.T) {
+	timeouts := PhaseTimeouts{NodePool: 5 * time.Minute}
+	tests := []struct {
+		phase ProvisionPhase
+		want  time.Duration
+	}{
+		{PhaseNetwork, DefaultNetworkTimeout},
+		{PhaseControlPlane, DefaultControlPlaneTimeout},
+		{PhaseNodePool, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := timeouts.Timeout(tt.phase); got != tt.want {
+			t.Errorf("Timeout(%s) = %v, want %v", tt.phase, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// VolumeSnapshotter is implemented by providers that can snapshot the disks
+// backing a cluster's persistent volumes, to keep their data once the cluster
+// is deleted
+type VolumeSnapshotter interface {
+	// SnapshotVolumes starts a snapshot of every disk of cluster's persistent
+	// volumes but those in taken, the snapshots of an earlier attempt by disk
+	// ID, and returns the snapshots it started by disk ID, including those
+	// started before an error. It returns an error wrapping ErrNotSupported
+	// where the provider cannot find or snapshot them.
+	SnapshotVolumes(ctx context.Context, cluster *api.Cluster, taken map[string]string) (map[string]string, error)
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow restricts when changes may be applied to infrastructure.
+// A nil window allows changes at any time.
+type MaintenanceWindow struct {
+	Weekdays []time.Weekday // Days the window opens on; empty means every day
+	Start    time.Duration  // Offset from midnight when the window opens
+	End      time.Duration  // Offset from midnight when it closes; End <= Start spans midnight
+	Location *time.Location // Timezone the window is defined in; nil means UTC
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseMaintenanceWindow parses a window of the form
+// "<days> <HH:MM>-<HH:MM> [timezone]", for example "Mon-Fri 22:00-02:00
+// Europe/Berlin" or "Sat,Sun 02:00-06:00". Days may be "*" for every day, a
+// comma-separated list, or a range.
+func ParseMaintenanceWindow(spec string) (*MaintenanceWindow, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid maintenance window %q: want \"<days> <HH:MM>-<HH:MM> [timezone]\"", spec)
+	}
+
+	weekdays, err := parseWeekdays(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+
+	start, end, found := strings.Cut(fields[1], "-")
+	if !found {
+		return nil, fmt.Errorf("invalid maintenance window %q: time range must be <HH:MM>-<HH:MM>", spec)
+	}
+
+	window := &MaintenanceWindow{Weekdays: weekdays, Location: time.UTC}
+	if window.Start, err = parseTimeOfDay(start); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+	if window.End, err = parseTimeOfDay(end); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+	}
+
+	if len(fields) == 3 {
+		if window.Location, err = time.LoadLocation(fields[2]); err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %w", spec, err)
+		}
+	}
+
+	return window, nil
+}
+
+// InWindow reports whether changes are allowed at t
+func (w *MaintenanceWindow) InWindow(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+
+	location := w.Location
+	if location == nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End && w.opensOn(local.Weekday())
+	}
+
+	// The window spans midnight: the late part belongs to today's window and
+	// the early part to yesterday's
+	if offset >= w.Start {
+		return w.opensOn(local.Weekday())
+	}
+	if offset < w.End {
+		return w.opensOn((local.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (w *MaintenanceWindow) opensOn(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, weekday := range w.Weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekdays(spec string) ([]time.Weekday, error) {
+	if spec == "*" {
+		return nil, nil
+	}
+
+	var weekdays []time.Weekday
+	for _, part := range strings.Split(spec, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+
+		from, ok := weekdayNames[strings.ToLower(first)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", first)
+		}
+		if !isRange {
+			weekdays = append(weekdays, from)
+			continue
+		}
+
+		to, ok := weekdayNames[strings.ToLower(last)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", last)
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			weekdays = append(weekdays, day)
+			if day == to {
+				break
+			}
+		}
+	}
+
+	return weekdays, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: want HH:MM", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "every day", spec: "* 02:00-04:00"},
+		{name: "list", spec: "Sat,Sun 02:00-06:00"},
+		{name: "range with timezone", spec: "Mon-Fri 22:00-02:00 Europe/Berlin"},
+		{name: "missing time", spec: "Mon", wantErr: true},
+		{name: "bad weekday", spec: "Funday 02:00-04:00", wantErr: true},
+		{name: "bad time", spec: "Mon 25:00-04:00", wantErr: true},
+		{name: "no range", spec: "Mon 02:00", wantErr: true},
+		{name: "bad timezone", spec: "Mon 02:00-04:00 Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMaintenanceWindow(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMaintenanceWindow(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_InWindow(t *testing.T) {
+	weekend, err := ParseMaintenanceWindow("Sat,Sun 02:00-06:00")
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow() error = %v", err)
+	}
+	overnight, err := ParseMaintenanceWindow("Fri 22:00-02:00")
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow() error = %v", err)
+	}
+	berlin, err := ParseMaintenanceWindow("Mon-Fri 09:00-10:00 Europe/Berlin")
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow() error = %v", err)
+	}
+
+	// 2024-02-03 is a Saturday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 2, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		window *MaintenanceWindow
+		t      time.Time
+		want   bool
+	}{
+		{name: "nil window always open", window: nil, t: at(5, 12, 0), want: true},
+		{name: "weekend inside", window: weekend, t: at(3, 3, 0), want: true},
+		{name: "weekend at start", window: weekend, t: at(4, 2, 0), want: true},
+		{name: "weekend at end", window: weekend, t: at(3, 6, 0), want: false},
+		{name: "weekend wrong day", window: weekend, t: at(5, 3, 0), want: false},
+		{name: "overnight late friday", window: overnight, t: at(2, 23, 0), want: true},
+		{name: "overnight early saturday", window: overnight, t: at(3, 1, 30), want: true},
+		{name: "overnight early friday", window: overnight, t: at(2, 1, 30), want: false},
+		{name: "overnight saturday night", window: overnight, t: at(3, 23, 0), want: false},
+		{name: "timezone inside", window: berlin, t: at(5, 8, 30), want: true},
+		{name: "timezone outside", window: berlin, t: at(5, 9, 30), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.InWindow(tt.t); got != tt.want {
+				t.Errorf("InWindow(%s) = %v, want %v", tt.t.Format(time.RFC1123), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// Package format renders byte sizes, durations and amounts of money for
+// human-readable output
+package format
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// byteUnits are the decimal units of Bytes above a kilobyte
+var byteUnits = [...]string{"kB", "MB", "GB", "TB", "PB", "EB"}
+
+// currencySymbols are written before the amount; other currencies are
+// written as a code after it
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+}
+
+// Bytes formats a size with one decimal in the largest decimal unit that
+// keeps it below 1000, such as "512 B" or "4.2 MB"
+func Bytes(n int64) string {
+	var buf [24]byte
+	b := buf[:0]
+
+	// uint64(-n) is also the magnitude of math.MinInt64
+	size := uint64(n)
+	if n < 0 {
+		b = append(b, '-')
+		size = uint64(-n)
+	}
+	if size < 1000 {
+		b = strconv.AppendUint(b, size, 10)
+		return string(append(b, " B"...))
+	}
+
+	// Move up a unit before rounding would print 1000.0
+	value, unit := float64(size)/1000, 0
+	for value >= 999.95 && unit < len(byteUnits)-1 {
+		value /= 1000
+		unit++
+	}
+	b = strconv.AppendFloat(b, value, 'f', 1, 64)
+	b = append(b, ' ')
+	return string(append(b, byteUnits[unit]...))
+}
+
+// Duration formats a duration compactly in its two largest units, truncating
+// the rest: "3m12s", "2h5m" or "4d3h". Durations under a second keep their
+// precision, such as "250ms".
+func Duration(d time.Duration) string {
+	if d > -time.Second && d < time.Second {
+		return d.String()
+	}
+
+	var buf [32]byte
+	b := buf[:0]
+	if d < 0 {
+		b = append(b, '-')
+		if d == math.MinInt64 {
+			d = math.MaxInt64
+		} else {
+			d = -d
+		}
+	}
+
+	day := 24 * time.Hour
+	switch {
+	case d >= day:
+		b = appendUnits(b, d/day, "d", d%day/time.Hour, "h")
+	case d >= time.Hour:
+		b = appendUnits(b, d/time.Hour, "h", d%time.Hour/time.Minute, "m")
+	case d >= time.Minute:
+		b = appendUnits(b, d/time.Minute, "m", d%time.Minute/time.Second, "s")
+	default:
+		b = appendUnits(b, d/time.Second, "s", 0, "")
+	}
+	return string(b)
+}
+
+// appendUnits appends "<major><unit>" followed by "<minor><unit>" unless
+// minor is zero
+func appendUnits(b []byte, major time.Duration, majorUnit string, minor time.Duration, minorUnit string) []byte {
+	b = strconv.AppendInt(b, int64(major), 10)
+	b = append(b, majorUnit...)
+	if minor > 0 {
+		b = strconv.AppendInt(b, int64(minor), 10)
+		b = append(b, minorUnit...)
+	}
+	return b
+}
+
+// Money formats an amount rounded to cents with thousands separators, such
+// as "$1,234.50" for USD or "1,234.50 CHF" for a currency without a symbol
+func Money(amount float64, currency string) string {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return strconv.FormatFloat(amount, 'f', -1, 64) + " " + currency
+	}
+
+	var digitBuf [32]byte
+	digits := strconv.AppendFloat(digitBuf[:0], math.Abs(amount), 'f', 2, 64)
+
+	var buf [48]byte
+	b := buf[:0]
+	// Amounts that round to zero are not negative
+	if amount < 0 && string(digits) != "0.00" {
+		b = append(b, '-')
+	}
+
+	symbol, hasSymbol := currencySymbols[currency]
+	b = append(b, symbol...)
+
+	whole := len(digits) - 3
+	for i := 0; i < whole; i++ {
+		if i > 0 && (whole-i)%3 == 0 {
+			b = append(b, ',')
+		}
+		b = append(b, digits[i])
+	}
+	b = append(b, digits[whole:]...)
+
+	if !hasSymbol && currency != "" {
+		b = append(b, ' ')
+		b = append(b, currency...)
+	}
+	return string(b)
+}
@@ -0,0 +1,98 @@
+package format
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1, "1 B"},
+		{999, "999 B"},
+		{1000, "1.0 kB"},
+		{4_200_000, "4.2 MB"},
+		{999_949, "999.9 kB"},
+		{999_960, "1.0 MB"},
+		{1_500_000_000, "1.5 GB"},
+		{-2048, "-2.0 kB"},
+		{-5, "-5 B"},
+		{math.MaxInt64, "9.2 EB"},
+		{math.MinInt64, "-9.2 EB"},
+	}
+
+	for _, tt := range tests {
+		if got := Bytes(tt.n); got != tt.want {
+			t.Errorf("Bytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{250 * time.Millisecond, "250ms"},
+		{-1500 * time.Microsecond, "-1.5ms"},
+		{time.Second, "1s"},
+		{59*time.Second + 999*time.Millisecond, "59s"},
+		{3*time.Minute + 12*time.Second, "3m12s"},
+		{5 * time.Minute, "5m"},
+		{2*time.Hour + 5*time.Minute + 30*time.Second, "2h5m"},
+		{24 * time.Hour, "1d"},
+		{4*24*time.Hour + 3*time.Hour + 59*time.Minute, "4d3h"},
+		{-(3*time.Minute + 12*time.Second), "-3m12s"},
+		{math.MaxInt64, "106751d23h"},
+		{math.MinInt64, "-106751d23h"},
+	}
+
+	for _, tt := range tests {
+		if got := Duration(tt.d); got != tt.want {
+			t.Errorf("Duration(%d) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     string
+	}{
+		{0, "USD", "$0.00"},
+		{0.005, "USD", "$0.01"},
+		{-0.001, "USD", "$0.00"},
+		{999.999, "USD", "$1,000.00"},
+		{1234.5, "USD", "$1,234.50"},
+		{-75, "USD", "-$75.00"},
+		{1234567.891, "EUR", "€1,234,567.89"},
+		{1e15, "GBP", "£1,000,000,000,000,000.00"},
+		{1234.5, "CHF", "1,234.50 CHF"},
+		{12, "", "12.00"},
+		{math.Inf(1), "USD", "+Inf USD"},
+	}
+
+	for _, tt := range tests {
+		if got := Money(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("Money(%v, %q) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestAllocations(t *testing.T) {
+	for name, f := range map[string]func(){
+		"Bytes":    func() { Bytes(4_200_000) },
+		"Duration": func() { Duration(3*time.Minute + 12*time.Second) },
+		"Money":    func() { Money(1234567.891, "USD") },
+	} {
+		if allocs := testing.AllocsPerRun(100, f); allocs > 1 {
+			t.Errorf("%s allocates %.0f times, want at most 1", name, allocs)
+		}
+	}
+}
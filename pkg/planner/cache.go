@@ -0,0 +1,143 @@
+package planner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// DefaultCacheTTL is how long refreshed state is reused by plans
+const DefaultCacheTTL = 30 * time.Second
+
+// RefreshCache stores refreshed provider state between plans. Entries are
+// recorded with their provider so that an apply can invalidate them.
+type RefreshCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Put(ctx context.Context, key, provider string, value []byte, ttl time.Duration) error
+}
+
+// CacheKey returns the refresh cache key of a provider region
+func CacheKey(provider, region string) string {
+	sum := sha256.Sum256([]byte(provider + "\x00" + region))
+	return hex.EncodeToString(sum[:])
+}
+
+// refreshEntry is the refreshed state of the clusters in one provider region.
+// Only resources whose actual state differs from stored state are kept.
+type refreshEntry struct {
+	ClusterIDs []string                 `json:"clusterIds"`
+	Clusters   map[string]*api.Cluster  `json:"clusters,omitempty"`
+	NodePools  map[string]*api.NodePool `json:"nodePools,omitempty"`
+	Missing    []api.ResourceID         `json:"missing,omitempty"`
+}
+
+// newRefreshEntry extracts the changes of a refresh limited to clusterIDs
+func newRefreshEntry(clusterIDs []string, result *engine.RefreshResult) *refreshEntry {
+	entry := &refreshEntry{
+		ClusterIDs: clusterIDs,
+		Clusters:   make(map[string]*api.Cluster),
+		NodePools:  make(map[string]*api.NodePool),
+		Missing:    result.Missing,
+	}
+	for _, change := range result.Changes {
+		switch change.Resource.Kind {
+		case "Cluster":
+			entry.Clusters[change.Resource.ID] = result.State.Clusters[change.Resource.ID]
+		case "NodePool":
+			entry.NodePools[change.Resource.ID] = result.State.NodePools[change.Resource.ID]
+		}
+	}
+	return entry
+}
+
+// applyTo overlays the refreshed resources onto state and removes those the
+// provider no longer reports
+func (r *refreshEntry) applyTo(state engine.State) {
+	for id, cluster := range r.Clusters {
+		state.Clusters[id] = cluster
+	}
+	for id, pool := range r.NodePools {
+		state.NodePools[id] = pool
+	}
+	removeMissing(state, r.Missing)
+}
+
+// cachedActualState refreshes stored state one provider region at a time,
+// reusing a cached refresh of a region while it has not expired and still
+// covers the same clusters
+func (s *LiveStateSource) cachedActualState(ctx context.Context) (engine.State, error) {
+	stored, err := s.engine.StateManager().GetState(ctx)
+	if err != nil {
+		return engine.State{}, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	groups := make(map[string][]string)
+	providers := make(map[string]string)
+	for id, cluster := range stored.Clusters {
+		key := CacheKey(cluster.Spec.Provider, cluster.Spec.Region)
+		groups[key] = append(groups[key], id)
+		providers[key] = cluster.Spec.Provider
+	}
+
+	for key, ids := range groups {
+		sort.Strings(ids)
+
+		entry, err := s.cachedEntry(ctx, key)
+		if err != nil {
+			return engine.State{}, err
+		}
+		if entry == nil || !slices.Equal(entry.ClusterIDs, ids) {
+			if entry, err = s.refreshGroup(ctx, key, providers[key], ids); err != nil {
+				return engine.State{}, err
+			}
+		}
+		entry.applyTo(stored)
+	}
+
+	return stored, nil
+}
+
+// cachedEntry returns the unexpired refresh stored under key, or nil. An
+// entry that cannot be decoded is treated as absent.
+func (s *LiveStateSource) cachedEntry(ctx context.Context, key string) (*refreshEntry, error) {
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	var entry refreshEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// refreshGroup refreshes the clusters of one provider region and caches the
+// result
+func (s *LiveStateSource) refreshGroup(ctx context.Context, key, provider string, ids []string) (*refreshEntry, error) {
+	result, err := s.engine.RefreshMatching(ctx, func(cluster *api.Cluster) bool {
+		_, found := slices.BinarySearch(ids, cluster.ID)
+		return found
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh state: %w", err)
+	}
+
+	entry := newRefreshEntry(ids, result)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode refreshed state: %w", err)
+	}
+	if err := s.cache.Put(ctx, key, provider, data, s.cacheTTL); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,165 @@
+package planner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+var _ RefreshCache = (*state.SQLiteRefreshCache)(nil)
+
+// cacheFixture stores one cluster per region and seeds the fake provider with
+// their actual state, where the us-west-2 cluster has drifted
+func cacheFixture(tb testing.TB) (*state.SQLiteStateManager, *fake.Provider, *engine.Engine) {
+	tb.Helper()
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(tb.TempDir(), "state.db"))
+	if err != nil {
+		tb.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	tb.Cleanup(func() { sm.Close() })
+
+	provider := fake.NewProvider("aws")
+	clusters := make(map[string]*api.Cluster)
+	for _, region := range []string{"us-west-2", "us-east-1"} {
+		cluster := &api.Cluster{
+			ID:       "cluster-" + region,
+			Metadata: api.ResourceMetadata{Name: region},
+			Spec: api.ClusterSpec{
+				Provider:     "aws",
+				Region:       region,
+				ControlPlane: api.ControlPlaneSpec{Version: "1.28"},
+			},
+		}
+		clusters[cluster.ID] = cluster
+
+		actual := *cluster
+		if region == "us-west-2" {
+			actual.Spec.ControlPlane.Version = "1.29"
+		}
+		provider.SeedCluster(&actual)
+	}
+	if err := sm.SaveState(ctx, engine.State{Clusters: clusters, NodePools: map[string]*api.NodePool{}}); err != nil {
+		tb.Fatalf("SaveState() error = %v", err)
+	}
+
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+	return sm, provider, eng
+}
+
+func TestLiveStateSource_Cache(t *testing.T) {
+	ctx := context.Background()
+	sm, provider, eng := cacheFixture(t)
+
+	// Each plan run builds its own source, as separate provctl runs do
+	actualState := func() engine.State {
+		t.Helper()
+		source := NewLiveStateSource(eng)
+		source.SetCache(sm.RefreshCache(), time.Minute)
+
+		actual, err := source.ActualState(ctx)
+		if err != nil {
+			t.Fatalf("ActualState() error = %v", err)
+		}
+		if got := actual.Clusters["cluster-us-west-2"].Spec.ControlPlane.Version; got != "1.29" {
+			t.Errorf("refreshed version = %s, want 1.29", got)
+		}
+		return actual
+	}
+	calls := func() int { return provider.CallCount("GetCluster") }
+
+	actualState()
+	if n := calls(); n != 2 {
+		t.Fatalf("first plan called GetCluster %d times, want 2", n)
+	}
+
+	actualState()
+	if n := calls(); n != 2 {
+		t.Errorf("cached plan called GetCluster %d more times, want 0", n-2)
+	}
+
+	// A new cluster only refreshes its own region
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	current.Clusters["cluster-new"] = &api.Cluster{
+		ID:       "cluster-new",
+		Metadata: api.ResourceMetadata{Name: "new"},
+		Spec:     api.ClusterSpec{Provider: "aws", Region: "us-east-1"},
+	}
+	if err := sm.SaveState(ctx, current); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	actual := actualState()
+	if n := calls(); n != 4 {
+		t.Errorf("plan after adding a cluster called GetCluster %d more times, want 2", n-2)
+	}
+	if _, exists := actual.Clusters["cluster-new"]; exists {
+		t.Error("cluster the provider does not report should be absent")
+	}
+
+	// An apply against the provider invalidates its entries
+	if err := sm.RefreshCache().Invalidate(ctx, "aws"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	actualState()
+	if n := calls(); n != 7 {
+		t.Errorf("plan after invalidation called GetCluster %d more times, want 3", n-4)
+	}
+}
+
+func TestLiveStateSource_CacheExpired(t *testing.T) {
+	sm, provider, eng := cacheFixture(t)
+
+	source := NewLiveStateSource(eng)
+	source.SetCache(sm.RefreshCache(), time.Nanosecond)
+	for i := 0; i < 2; i++ {
+		if _, err := source.ActualState(context.Background()); err != nil {
+			t.Fatalf("ActualState() error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := provider.CallCount("GetCluster"); n != 4 {
+		t.Errorf("GetCluster called %d times, want 4 once entries expire", n)
+	}
+}
+
+// BenchmarkLiveStateSource compares the provider API calls made by
+// back-to-back plans with and without the refresh cache
+func BenchmarkLiveStateSource(b *testing.B) {
+	for _, tt := range []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{name: "uncached"},
+		{name: "cached", ttl: time.Minute},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			sm, provider, eng := cacheFixture(b)
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				source := NewLiveStateSource(eng)
+				if tt.ttl > 0 {
+					source.SetCache(sm.RefreshCache(), tt.ttl)
+				}
+				if _, err := source.ActualState(ctx); err != nil {
+					b.Fatalf("ActualState() error = %v", err)
+				}
+			}
+
+			b.ReportMetric(float64(provider.CallCount("GetCluster"))/float64(b.N), "api-calls/op")
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package planner
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+)
+
+// diffLine is one line of a rendered diff: a removal, an addition or
+// unchanged context
+type diffLine struct {
+	op   byte // '-', '+' or ' '
+	text string
+}
+
+// formatDiff renders field changes unified-diff style, the old value of
+// each field prefixed "-" and the new one "+". Lists show which elements
+// were removed and added among those kept; whole blocks, such as a new
+// worker pool, show every field. Changes that force replacement are marked.
+func formatDiff(changes, forcing []api.FieldChange, colored bool, indent string) string {
+	replacing := make(map[string]bool, len(forcing))
+	for _, change := range forcing {
+		replacing[change.Path] = true
+	}
+
+	var b strings.Builder
+	for _, change := range changes {
+		lines := diffChange(change)
+		if len(lines) == 0 {
+			continue
+		}
+		if replacing[change.Path] {
+			lines[len(lines)-1].text += " (forces replacement)"
+		}
+		for _, line := range lines {
+			text := string(line.op) + " " + line.text
+			switch line.op {
+			case '-':
+				text = color.Wrap(colored, color.Red, text)
+			case '+':
+				text = color.Wrap(colored, color.Green, text)
+			}
+			b.WriteString(indent + text + "\n")
+		}
+	}
+	return b.String()
+}
+
+func diffChange(change api.FieldChange) []diffLine {
+	old, new := indirect(change.Old), indirect(change.New)
+	if isList(old) || isList(new) {
+		return diffList(change.Path, old, new)
+	}
+
+	var lines []diffLine
+	if old != nil {
+		lines = append(lines, valueLines('-', change.Path, old)...)
+	}
+	if new != nil {
+		lines = append(lines, valueLines('+', change.Path, new)...)
+	}
+	return lines
+}
+
+// diffList shows the elements of a list in their old order, marking those
+// removed, followed by those added. Lists are compared as unordered sets, so
+// reordering alone is never a change.
+func diffList(path string, old, new interface{}) []diffLine {
+	oldElems, newElems := listElements(old), listElements(new)
+	kept := make([]bool, len(newElems))
+
+	lines := []diffLine{{op: ' ', text: path + ":"}}
+	for _, elem := range oldElems {
+		op := byte('-')
+		for j, candidate := range newElems {
+			if !kept[j] && candidate == elem {
+				kept[j] = true
+				op = ' '
+				break
+			}
+		}
+		lines = append(lines, diffLine{op: op, text: "  " + elem})
+	}
+	for j, elem := range newElems {
+		if !kept[j] {
+			lines = append(lines, diffLine{op: '+', text: "  " + elem})
+		}
+	}
+	return lines
+}
+
+// valueLines renders a value after its path, spreading blocks over several
+// lines
+func valueLines(op byte, path string, value interface{}) []diffLine {
+	if !isBlock(value) {
+		return []diffLine{{op: op, text: path + ": " + scalar(value)}}
+	}
+
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return []diffLine{{op: op, text: path + ": " + api.FormatValue(value)}}
+	}
+	var lines []diffLine
+	for i, line := range strings.Split(string(encoded), "\n") {
+		if i == 0 {
+			line = path + ": " + line
+		}
+		lines = append(lines, diffLine{op: op, text: line})
+	}
+	return lines
+}
+
+// listElements renders each element of a list on one line
+func listElements(list interface{}) []string {
+	if list == nil {
+		return nil
+	}
+	v := reflect.ValueOf(list)
+	elems := make([]string, v.Len())
+	for i := range elems {
+		elem := indirect(v.Index(i).Interface())
+		if isBlock(elem) || isList(elem) {
+			elems[i] = api.FormatValue(elem) // Compact JSON
+		} else {
+			elems[i] = scalar(elem)
+		}
+	}
+	return elems
+}
+
+// scalar renders a single value, quoting the empty string so it stays
+// visible
+func scalar(value interface{}) string {
+	if s, ok := value.(string); ok && s == "" {
+		return `""`
+	}
+	return api.FormatValue(value)
+}
+
+// indirect dereferences a pointer, returning nil for a nil pointer
+func indirect(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func isList(value interface{}) bool {
+	return value != nil && reflect.ValueOf(value).Kind() == reflect.Slice
+}
+
+// isBlock reports whether a value has fields of its own: a struct, or a map
+// such as a block read back from a saved plan
+func isBlock(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Struct || kind == reflect.Map
+}
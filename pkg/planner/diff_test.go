@@ -0,0 +1,90 @@
+package planner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestFormatDiff(t *testing.T) {
+	changes := []api.FieldChange{
+		{Path: "controlPlane.version", Old: "1.28", New: "1.29"},
+		{Path: "network.availabilityZones", Old: []string{"us-west-2a", "us-west-2b"}, New: []string{"us-west-2a", "us-west-2c"}},
+		{Path: "tags.team", New: "platform"},
+		{Path: "description", Old: "", New: "production"},
+		{Path: "workerPools.gpu", New: api.WorkerPoolSpec{Name: "gpu", InstanceType: "p3.2xlarge", MaxSize: 2}},
+		{Path: "region", Old: "us-east-1", New: "us-west-2"},
+	}
+	forcing := []api.FieldChange{{Path: "region", Old: "us-east-1", New: "us-west-2"}}
+
+	want := `  - controlPlane.version: 1.28
+  + controlPlane.version: 1.29
+    network.availabilityZones:
+      us-west-2a
+  -   us-west-2b
+  +   us-west-2c
+  + tags.team: platform
+  - description: ""
+  + description: production
+  + workerPools.gpu: {
+  +   "name": "gpu",
+  +   "instanceType": "p3.2xlarge",
+  +   "minSize": 0,
+  +   "maxSize": 2
+  + }
+  - region: us-east-1
+  + region: us-west-2 (forces replacement)
+`
+	if got := formatDiff(changes, forcing, false, "  "); got != want {
+		t.Errorf("formatDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	colored := formatDiff(changes[:1], nil, true, "")
+	if want := color.Wrap(true, color.Red, "- controlPlane.version: 1.28") + "\n" +
+		color.Wrap(true, color.Green, "+ controlPlane.version: 1.29") + "\n"; colored != want {
+		t.Errorf("formatDiff() colored = %q, want %q", colored, want)
+	}
+}
+
+func TestPlanner_PrintPlanDiff(t *testing.T) {
+	cluster := func(version string, labels map[string]string) *api.Cluster {
+		return &api.Cluster{
+			ID:       "c-1",
+			Metadata: api.ResourceMetadata{Name: "prod"},
+			Spec: api.ClusterSpec{
+				Provider:     "aws",
+				ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: version},
+				WorkerPools:  []api.WorkerPoolSpec{{Name: "general", Labels: labels}},
+			},
+		}
+	}
+	desired := engine.State{Clusters: map[string]*api.Cluster{"c-1": cluster("1.29", map[string]string{"tier": "web"})}}
+	actual := engine.State{Clusters: map[string]*api.Cluster{"c-1": cluster("1.28", nil)}}
+
+	p := NewPlanner(nil)
+	plan, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	if changes := Changes(plan.Actions[0]); len(changes) != 2 {
+		t.Fatalf("Changes() = %v, want the version and label changes", changes)
+	}
+
+	if output := p.PrintPlan(plan); strings.Contains(output, "controlPlane.version") {
+		t.Errorf("PrintPlan() without diff = %q, want no field changes", output)
+	}
+
+	p.SetDiff(true)
+	output := p.PrintPlan(plan)
+	want := "    ~ Cluster prod (c-1)\n" +
+		"        - controlPlane.version: 1.28\n" +
+		"        + controlPlane.version: 1.29\n" +
+		"        + workerPools.general.labels.tier: web\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("PrintPlan() = %q, want %q", output, want)
+	}
+}
@@ -0,0 +1,156 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Graph is the dependency graph of the resources in a state
+type Graph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is one resource of a dependency graph
+type GraphNode struct {
+	ID    string // Unique within the graph, e.g. "nodepool/prod/general"
+	Kind  string // "Cluster", "Network" or "NodePool"
+	Label string
+}
+
+// GraphEdge records that To depends on From, so From is provisioned first
+// and deleted last
+type GraphEdge struct {
+	From string
+	To   string
+}
+
+// BuildDependencyGraph returns the resources of a state and their
+// dependencies: each cluster owns its network, and its node pools run in that
+// network. Node pools come from the cluster's worker pool specs and from
+// state node pools, which are matched to their cluster the way plan targets
+// match them. Nodes and edges are sorted so the graph is stable across runs.
+func BuildDependencyGraph(state engine.State) Graph {
+	var graph Graph
+	seen := make(map[string]bool)
+	addNode := func(node GraphNode) {
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			graph.Nodes = append(graph.Nodes, node)
+		}
+	}
+
+	clusterNames := make(map[string]bool, len(state.Clusters))
+	for _, cluster := range state.Clusters {
+		name := cluster.Metadata.Name
+		clusterNames[name] = true
+
+		clusterNode := "cluster/" + name
+		networkNode := "network/" + name
+		addNode(GraphNode{ID: clusterNode, Kind: "Cluster", Label: clusterLabel(cluster)})
+		addNode(GraphNode{ID: networkNode, Kind: "Network", Label: networkLabel(name, cluster.Spec.Network)})
+		graph.Edges = append(graph.Edges, GraphEdge{From: clusterNode, To: networkNode})
+
+		for _, pool := range cluster.Spec.WorkerPools {
+			poolNode := "nodepool/" + name + "/" + pool.Name
+			addNode(GraphNode{ID: poolNode, Kind: "NodePool", Label: poolLabel(pool)})
+			graph.Edges = append(graph.Edges, GraphEdge{From: networkNode, To: poolNode})
+		}
+	}
+
+	for id, pool := range state.NodePools {
+		clusterName := poolCluster(id, state, state).Name
+		if !clusterNames[clusterName] {
+			continue
+		}
+		poolNode := "nodepool/" + clusterName + "/" + pool.Spec.Name
+		if seen[poolNode] {
+			continue
+		}
+		addNode(GraphNode{ID: poolNode, Kind: "NodePool", Label: poolLabel(pool.Spec)})
+		graph.Edges = append(graph.Edges, GraphEdge{From: "network/" + clusterName, To: poolNode})
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return graph
+}
+
+func clusterLabel(cluster *api.Cluster) string {
+	label := fmt.Sprintf("%s\n%s/%s", cluster.Metadata.Name, cluster.Spec.Provider, cluster.Spec.Region)
+	if version := cluster.Spec.ControlPlane.Version; version != "" {
+		label += "\nKubernetes " + version
+	}
+	return label
+}
+
+func networkLabel(cluster string, network api.NetworkSpec) string {
+	label := cluster + " network"
+	switch {
+	case network.ExistingNetworkID() != "":
+		label += "\nexisting " + network.ExistingNetworkID()
+	case network.VPCCIDR != "":
+		label += "\n" + network.VPCCIDR
+	}
+	return label
+}
+
+func poolLabel(pool api.WorkerPoolSpec) string {
+	return fmt.Sprintf("%s\n%s, %d-%d nodes", pool.Name, pool.InstanceType, pool.MinSize, pool.MaxSize)
+}
+
+// graphShapes gives each kind of node a distinct Graphviz shape
+var graphShapes = map[string]string{
+	"Cluster":  "box",
+	"Network":  "ellipse",
+	"NodePool": "component",
+}
+
+// FormatDOT renders a dependency graph in the Graphviz DOT language, e.g.
+// for "dot -Tpng". Edges point from a resource to the resources depending
+// on it.
+func FormatDOT(graph Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph provctl {\n")
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	if len(graph.Nodes) > 0 {
+		b.WriteString("\n")
+	}
+	for _, node := range graph.Nodes {
+		shape := graphShapes[node.Kind]
+		if shape == "" {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(node.ID), dotQuote(node.Label), shape)
+	}
+
+	if len(graph.Edges) > 0 {
+		b.WriteString("\n")
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(edge.From), dotQuote(edge.To))
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes a DOT identifier, keeping line breaks as DOT escapes
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,93 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestBuildDependencyGraph(t *testing.T) {
+	state := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"c-1": {
+				ID:       "c-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec: api.ClusterSpec{
+					Provider:     "aws",
+					Region:       "us-west-2",
+					ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+					Network:      api.NetworkSpec{VPCCIDR: "10.0.0.0/16"},
+					WorkerPools: []api.WorkerPoolSpec{
+						{Name: "general", InstanceType: "m5.large", MinSize: 2, MaxSize: 6},
+					},
+				},
+			},
+		},
+		NodePools: map[string]*api.NodePool{
+			// Already in the cluster spec
+			"np-1": {ID: "np-1", Spec: api.WorkerPoolSpec{Name: "general"},
+				Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterName: "prod"}}},
+			// Created outside the spec
+			"np-2": {ID: "np-2", Spec: api.WorkerPoolSpec{Name: "batch", InstanceType: "c5.xlarge", MaxSize: 4},
+				Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterName: "prod"}}},
+			// Recording only the cluster's ID
+			"np-4": {ID: "np-4", Spec: api.WorkerPoolSpec{Name: "gpu", InstanceType: "p3.2xlarge", MaxSize: 2},
+				Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: "c-1"}}},
+			// Of a cluster not in state
+			"np-3": {ID: "np-3", Spec: api.WorkerPoolSpec{Name: "orphan"}},
+		},
+	}
+
+	graph := BuildDependencyGraph(state)
+
+	var nodes []string
+	for _, node := range graph.Nodes {
+		nodes = append(nodes, node.ID)
+	}
+	wantNodes := []string{"cluster/prod", "network/prod", "nodepool/prod/batch", "nodepool/prod/general", "nodepool/prod/gpu"}
+	if strings.Join(nodes, ",") != strings.Join(wantNodes, ",") {
+		t.Errorf("nodes = %v, want %v", nodes, wantNodes)
+	}
+
+	wantEdges := []GraphEdge{
+		{From: "cluster/prod", To: "network/prod"},
+		{From: "network/prod", To: "nodepool/prod/batch"},
+		{From: "network/prod", To: "nodepool/prod/general"},
+		{From: "network/prod", To: "nodepool/prod/gpu"},
+	}
+	if len(graph.Edges) != len(wantEdges) {
+		t.Fatalf("edges = %v, want %v", graph.Edges, wantEdges)
+	}
+	for i, edge := range wantEdges {
+		if graph.Edges[i] != edge {
+			t.Errorf("edge %d = %v, want %v", i, graph.Edges[i], edge)
+		}
+	}
+}
+
+func TestFormatDOT(t *testing.T) {
+	graph := Graph{
+		Nodes: []GraphNode{
+			{ID: "cluster/prod", Kind: "Cluster", Label: "prod\naws/us-west-2"},
+			{ID: "network/prod", Kind: "Network", Label: `say "hi"`},
+		},
+		Edges: []GraphEdge{{From: "cluster/prod", To: "network/prod"}},
+	}
+
+	out := FormatDOT(graph)
+	for _, want := range []string{
+		"digraph provctl {",
+		`"cluster/prod" [label="prod\naws/us-west-2", shape=box];`,
+		`"network/prod" [label="say \"hi\"", shape=ellipse];`,
+		`"cluster/prod" -> "network/prod";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatDOT() missing %q in:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "}\n") {
+		t.Errorf("FormatDOT() = %q, want a closed graph", out)
+	}
+}
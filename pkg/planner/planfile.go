@@ -0,0 +1,179 @@
+package planner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// planFileVersion is the format version of plan files; ReadPlan rejects
+// other versions. Version 2 added the state fingerprint.
+const planFileVersion = 2
+
+// SavedPlan is a plan written by plan --out, to be applied later exactly as
+// it was reviewed
+type SavedPlan struct {
+	Plan        engine.Plan
+	CreatedAt   time.Time
+	DefaultTags map[string]string // The configuration's default tags, which apply adds to every cluster
+
+	// MaintenanceWindow is the schedule of the configuration's maintenance
+	// window, which apply keeps to; empty for none
+	MaintenanceWindow string
+
+	// StateFingerprint is the StateFingerprint of the stored state the plan
+	// was made against. Apply refuses the plan once state has changed.
+	StateFingerprint string
+}
+
+// StateFingerprint returns a digest of the clusters and node pools of a
+// state, which changes whenever any of them does
+func StateFingerprint(state engine.State) (string, error) {
+	data, err := json.Marshal(struct {
+		Clusters  map[string]*api.Cluster  `json:"clusters"`
+		NodePools map[string]*api.NodePool `json:"nodePools"`
+	}{state.Clusters, state.NodePools})
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint state: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// planFile is the JSON form of a SavedPlan. Action parameters are stored as
+// typed fields, so they read back as the types the engine and the planner
+// expect.
+type planFile struct {
+	Version     int               `json:"version"`
+	ID          string            `json:"id"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	DefaultTags map[string]string `json:"defaultTags,omitempty"`
+	Actions     []planFileAction  `json:"actions"`
+
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty"`
+	StateFingerprint  string `json:"stateFingerprint"`
+}
+
+type planFileAction struct {
+	Type             engine.ActionType       `json:"type"`
+	Resource         api.ResourceID          `json:"resource"`
+	ClusterSpec      *api.ClusterSpec        `json:"clusterSpec,omitempty"`
+	PoolSpec         *api.WorkerPoolSpec     `json:"poolSpec,omitempty"`
+	Replacement      []api.FieldChange       `json:"replacement,omitempty"`
+	Changes          []api.FieldChange       `json:"changes,omitempty"`
+	ProvisionTime    time.Duration           `json:"provisionTime,omitempty"`
+	MonthlyCostDelta *float64                `json:"monthlyCostDelta,omitempty"`
+	UpgradePreflight *engine.PreflightResult `json:"upgradePreflight,omitempty"`
+}
+
+// WritePlan writes a plan as JSON, giving it an ID first if it has none so
+// that applies of the saved plan can track its progress
+func WritePlan(w io.Writer, saved SavedPlan) error {
+	if saved.Plan.ID == "" {
+		saved.Plan.ID = uuid.NewString()
+	}
+
+	file := planFile{
+		Version:     planFileVersion,
+		ID:          saved.Plan.ID,
+		CreatedAt:   saved.CreatedAt,
+		DefaultTags: saved.DefaultTags,
+		Actions:     make([]planFileAction, 0, len(saved.Plan.Actions)),
+
+		MaintenanceWindow: saved.MaintenanceWindow,
+		StateFingerprint:  saved.StateFingerprint,
+	}
+	for _, action := range saved.Plan.Actions {
+		entry := planFileAction{Type: action.Type, Resource: action.Resource}
+		for key, value := range action.Parameters {
+			switch v := value.(type) {
+			case api.ClusterSpec:
+				entry.ClusterSpec = &v
+			case api.WorkerPoolSpec:
+				entry.PoolSpec = &v
+			case []api.FieldChange:
+				if key == ParamChanges {
+					entry.Changes = v
+				} else {
+					entry.Replacement = v
+				}
+			case time.Duration:
+				entry.ProvisionTime = v
+			case engine.PreflightResult:
+				entry.UpgradePreflight = &v
+			case float64:
+				if key == ParamMonthlyCostDelta {
+					entry.MonthlyCostDelta = &v
+				}
+			default:
+				return fmt.Errorf("cannot save parameter %q of %s %s: unsupported type %T", key, action.Type, action.Resource.Name, value)
+			}
+		}
+		file.Actions = append(file.Actions, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// ReadPlan reads a plan written by WritePlan
+func ReadPlan(r io.Reader) (SavedPlan, error) {
+	var file planFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return SavedPlan{}, fmt.Errorf("failed to read plan: %w", err)
+	}
+	if file.Version != planFileVersion {
+		return SavedPlan{}, fmt.Errorf("plan file version %d is not supported, want %d; run plan again", file.Version, planFileVersion)
+	}
+	if file.ID == "" {
+		return SavedPlan{}, fmt.Errorf("plan file has no ID")
+	}
+
+	saved := SavedPlan{
+		Plan:        engine.Plan{ID: file.ID},
+		CreatedAt:   file.CreatedAt,
+		DefaultTags: file.DefaultTags,
+
+		MaintenanceWindow: file.MaintenanceWindow,
+		StateFingerprint:  file.StateFingerprint,
+	}
+	for _, entry := range file.Actions {
+		action := engine.Action{Type: entry.Type, Resource: entry.Resource}
+		params := make(map[string]interface{})
+		switch {
+		case entry.ClusterSpec != nil:
+			params["spec"] = *entry.ClusterSpec
+		case entry.PoolSpec != nil:
+			params["spec"] = *entry.PoolSpec
+		}
+		if entry.Replacement != nil {
+			params[ParamReplacement] = entry.Replacement
+		}
+		if entry.Changes != nil {
+			params[ParamChanges] = entry.Changes
+		}
+		if entry.ProvisionTime > 0 {
+			params[ParamProvisionTime] = entry.ProvisionTime
+		}
+		if entry.MonthlyCostDelta != nil {
+			params[ParamMonthlyCostDelta] = *entry.MonthlyCostDelta
+		}
+		if entry.UpgradePreflight != nil {
+			params[ParamUpgradePreflight] = *entry.UpgradePreflight
+		}
+		if len(params) > 0 {
+			action.Parameters = params
+		}
+		saved.Plan.Actions = append(saved.Plan.Actions, action)
+	}
+	return saved, nil
+}
@@ -0,0 +1,143 @@
+package planner
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestWritePlan_RoundTrip(t *testing.T) {
+	spec := api.ClusterSpec{
+		Provider:     "aws",
+		Region:       "us-west-2",
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.29"},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", InstanceType: "m5.large", MinSize: 1, MaxSize: 3}},
+		Tags:         map[string]string{"team": "platform"},
+	}
+	saved := SavedPlan{
+		Plan: engine.Plan{Actions: []engine.Action{
+			{
+				Type:     engine.ActionCreate,
+				Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", Name: "prod"},
+				Parameters: map[string]interface{}{
+					"spec":                spec,
+					ParamProvisionTime:    15 * time.Minute,
+					ParamMonthlyCostDelta: 212.5,
+				},
+			},
+			{
+				Type:     engine.ActionUpdate,
+				Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "staging"},
+				Parameters: map[string]interface{}{
+					"spec":           spec,
+					ParamReplacement: []api.FieldChange{{Path: "region", Old: "us-east-1", New: "us-west-2"}},
+					ParamChanges: []api.FieldChange{
+						{Path: "controlPlane.version", Old: "1.28", New: "1.29"},
+						{Path: "region", Old: "us-east-1", New: "us-west-2"},
+					},
+					ParamUpgradePreflight: engine.PreflightResult{
+						CurrentVersion: "1.28",
+						TargetVersion:  "1.29",
+						Issues: []engine.PreflightIssue{{
+							Severity:  engine.PreflightWarning,
+							Category:  engine.PreflightDeprecatedAPI,
+							Summary:   "1.29 no longer serves these APIs",
+							Resources: []string{"flowcontrol.apiserver.k8s.io/v1beta2 FlowSchema"},
+						}},
+					},
+				},
+			},
+			{
+				Type:     engine.ActionCreate,
+				Resource: api.ResourceID{Kind: "NodePool", ID: "np-1", Name: "gpu"},
+				Parameters: map[string]interface{}{
+					"spec": api.WorkerPoolSpec{Name: "gpu", InstanceType: "p3.2xlarge", MaxSize: 2},
+				},
+			},
+			{
+				Type:     engine.ActionDelete,
+				Resource: api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-2", Name: "old"},
+			},
+		}},
+		CreatedAt:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		DefaultTags: map[string]string{"owner": "infra"},
+
+		MaintenanceWindow: "Sat,Sun 02:00-06:00",
+		StateFingerprint:  "3f1c",
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlan(&buf, saved); err != nil {
+		t.Fatalf("WritePlan() error = %v", err)
+	}
+	got, err := ReadPlan(&buf)
+	if err != nil {
+		t.Fatalf("ReadPlan() error = %v", err)
+	}
+
+	if got.Plan.ID == "" {
+		t.Error("ReadPlan() plan has no ID, want the one WritePlan assigned")
+	}
+	saved.Plan.ID = got.Plan.ID
+	if !reflect.DeepEqual(got, saved) {
+		t.Errorf("ReadPlan() = %+v\nwant %+v", got, saved)
+	}
+}
+
+func TestStateFingerprint(t *testing.T) {
+	state := func(version string) engine.State {
+		return engine.State{
+			Clusters: map[string]*api.Cluster{
+				"c-1": {ID: "c-1", Spec: api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Version: version}}},
+				"c-2": {ID: "c-2", Spec: api.ClusterSpec{Provider: "azure"}},
+			},
+			NodePools: map[string]*api.NodePool{"np-1": {ID: "np-1", Spec: api.WorkerPoolSpec{Name: "general"}}},
+		}
+	}
+
+	fingerprint := func(s engine.State) string {
+		t.Helper()
+		got, err := StateFingerprint(s)
+		if err != nil {
+			t.Fatalf("StateFingerprint() error = %v", err)
+		}
+		return got
+	}
+	if fingerprint(state("1.29")) != fingerprint(state("1.29")) {
+		t.Error("StateFingerprint() differs between equal states")
+	}
+	if fingerprint(state("1.29")) == fingerprint(state("1.30")) {
+		t.Error("StateFingerprint() is the same after a cluster changed")
+	}
+	withoutPools := state("1.29")
+	withoutPools.NodePools = nil
+	if fingerprint(state("1.29")) == fingerprint(withoutPools) {
+		t.Error("StateFingerprint() is the same after a node pool was removed")
+	}
+}
+
+func TestReadPlan_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"not JSON", "plan", "failed to read plan"},
+		{"other version", `{"version": 1, "id": "p"}`, "version 1 is not supported"},
+		{"no ID", `{"version": 2}`, "no ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadPlan(strings.NewReader(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ReadPlan() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -4,14 +4,47 @@ package planner
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/cost"
 	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/format"
 )
 
+// ParamMonthlyCostDelta is the action parameter holding the estimated change
+// in monthly cost, set only when the planner has an estimator
+const ParamMonthlyCostDelta = "monthlyCostDelta"
+
+// ParamProvisionTime is the action parameter holding the estimated time to
+// create a cluster, set only when the planner knows the cluster's provider
+const ParamProvisionTime = "provisionTime"
+
+// ParamReplacement is the action parameter holding the changes that force
+// an update to replace the cluster, set only on such updates
+const ParamReplacement = "replacement"
+
+// ParamChanges is the action parameter holding the field-level changes of a
+// cluster update
+const ParamChanges = "changes"
+
+// ParamUpgradePreflight is the action parameter holding the upgrade
+// preflight of an update that changes the cluster's Kubernetes version, set
+// only when the planner knows the cluster's provider
+const ParamUpgradePreflight = "upgradePreflight"
+
 // Planner generates execution plans for infrastructure changes
 type Planner struct {
-	provider engine.CloudProvider
+	provider          engine.CloudProvider
+	disableProtection bool
+	estimator         *cost.Estimator
+	providers         ProviderLookup
+	targets           []Target
+	color             bool
+	diff              bool
 }
 
 // NewPlanner creates a new planner
@@ -21,6 +54,51 @@ func NewPlanner(provider engine.CloudProvider) *Planner {
 	}
 }
 
+// SetDisableProtection allows plans that delete clusters with deletion protection
+func (p *Planner) SetDisableProtection(disable bool) {
+	p.disableProtection = disable
+}
+
+// SetEstimator enables per-action cost annotation. Without an estimator
+// plans carry no cost information.
+func (p *Planner) SetEstimator(estimator *cost.Estimator) {
+	p.estimator = estimator
+}
+
+// ProviderLookup returns the named provider for region.
+// Engine.LoadRegionalProvider is one, constructing a provider only when a
+// plan first needs it.
+type ProviderLookup func(ctx context.Context, name, region string) (engine.CloudProvider, error)
+
+// SetProviders supplies the lookup of the providers whose provisioning time
+// estimates and upgrade preflights annotate plans. Without it plans carry
+// neither.
+func (p *Planner) SetProviders(lookup ProviderLookup) {
+	p.providers = lookup
+}
+
+// lookupProvider returns the named provider for region, or false if there is
+// no lookup or it fails. Annotations are best effort, so a provider that
+// cannot be loaded leaves its actions unannotated; apply reports the error.
+func (p *Planner) lookupProvider(ctx context.Context, name, region string) (engine.CloudProvider, bool) {
+	if p.providers == nil {
+		return nil, false
+	}
+	provider, err := p.providers(ctx, name, region)
+	return provider, err == nil
+}
+
+// SetColor enables ANSI coloring of PrintPlan output by action type
+func (p *Planner) SetColor(enabled bool) {
+	p.color = enabled
+}
+
+// SetDiff makes PrintPlan show the field-level changes of each update, old
+// values against new ones
+func (p *Planner) SetDiff(enabled bool) {
+	p.diff = enabled
+}
+
 // GeneratePlan creates a plan by comparing desired and actual state
 func (p *Planner) GeneratePlan(ctx context.Context, desired, actual engine.State) (engine.Plan, error) {
 	plan := engine.Plan{
@@ -97,37 +175,309 @@ func (p *Planner) GeneratePlan(ctx context.Context, desired, actual engine.State
 		}
 	}
 
+	if len(p.targets) > 0 {
+		plan = p.applyTargets(plan, desired, actual)
+	}
+
+	if !p.disableProtection {
+		if err := engine.CheckDeletionProtection(plan, actual); err != nil {
+			return engine.Plan{}, err
+		}
+	}
+
+	annotateChanges(plan, actual)
+	annotateReplacements(plan, actual)
+	if p.estimator != nil {
+		p.annotateCosts(ctx, plan, actual)
+	}
+	p.annotateProvisionTimes(ctx, plan)
+	p.annotateUpgradePreflights(ctx, plan, actual)
+
 	return plan, nil
 }
 
-// PrintPlan formats and displays a plan
+// annotateChanges records the field-level changes of each cluster update
+func annotateChanges(plan engine.Plan, actual engine.State) {
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if action.Type != engine.ActionUpdate || action.Resource.Kind != "Cluster" {
+			continue
+		}
+		cluster, exists := actual.Clusters[action.Resource.ID]
+		if !exists {
+			continue
+		}
+		spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+		if !ok {
+			continue
+		}
+		if changes := cluster.Spec.Diff(spec); len(changes) > 0 {
+			action.Parameters[ParamChanges] = changes
+		}
+	}
+}
+
+// Changes returns the field-level changes of a cluster update, or nil for
+// other actions
+func Changes(action engine.Action) []api.FieldChange {
+	changes, _ := action.Parameters[ParamChanges].([]api.FieldChange)
+	return changes
+}
+
+// annotateReplacements marks the cluster updates that change fields the
+// cloud cannot update in place, so that the cluster is destroyed and created
+// again
+func annotateReplacements(plan engine.Plan, actual engine.State) {
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if action.Type != engine.ActionUpdate || action.Resource.Kind != "Cluster" {
+			continue
+		}
+		cluster, exists := actual.Clusters[action.Resource.ID]
+		if !exists {
+			continue
+		}
+		spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+		if !ok {
+			continue
+		}
+		if changes := cluster.Spec.ReplacementChanges(spec); len(changes) > 0 {
+			action.Parameters[ParamReplacement] = changes
+		}
+	}
+}
+
+// ReplacementChanges returns the changes that make an update replace its
+// resource, or nil for an action applied in place
+func ReplacementChanges(action engine.Action) []api.FieldChange {
+	changes, _ := action.Parameters[ParamReplacement].([]api.FieldChange)
+	return changes
+}
+
+// annotateProvisionTimes sets the estimated provisioning time of each
+// cluster creation whose provider is known and can estimate it
+func (p *Planner) annotateProvisionTimes(ctx context.Context, plan engine.Plan) {
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if action.Type != engine.ActionCreate || action.Resource.Kind != "Cluster" {
+			continue
+		}
+		spec, ok := action.Parameters["spec"].(api.ClusterSpec)
+		if !ok {
+			continue
+		}
+		provider, ok := p.lookupProvider(ctx, action.Resource.Provider, spec.Region)
+		if !ok {
+			continue
+		}
+		if estimate := provider.EstimateProvisionTime(spec); estimate > 0 {
+			action.Parameters[ParamProvisionTime] = estimate
+		}
+	}
+}
+
+// ProvisionTime returns the estimated provisioning time of an action, if the
+// planner annotated one
+func ProvisionTime(action engine.Action) (time.Duration, bool) {
+	estimate, ok := action.Parameters[ParamProvisionTime].(time.Duration)
+	return estimate, ok
+}
+
+// annotateUpgradePreflights sets the upgrade preflight of each cluster
+// update that changes the Kubernetes version and whose provider is known. A
+// preflight that fails is noted as a warning rather than failing the plan;
+// apply runs it again and refuses the upgrade if it still fails.
+func (p *Planner) annotateUpgradePreflights(ctx context.Context, plan engine.Plan, actual engine.State) {
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		from, to, ok := engine.VersionUpgrade(*action, actual)
+		if !ok {
+			continue
+		}
+		cluster := actual.Clusters[action.Resource.ID]
+		provider, ok := p.lookupProvider(ctx, action.Resource.Provider, cluster.Spec.Region)
+		if !ok {
+			continue
+		}
+
+		result, err := engine.UpgradePreflight(ctx, provider, cluster, to)
+		if err != nil {
+			result = engine.PreflightResult{
+				CurrentVersion: from,
+				TargetVersion:  to,
+				Issues: []engine.PreflightIssue{{
+					Severity: engine.PreflightWarning,
+					Summary:  err.Error(),
+				}},
+			}
+		}
+		action.Parameters[ParamUpgradePreflight] = result
+	}
+}
+
+// UpgradePreflight returns the upgrade preflight of an action, if the
+// planner annotated one
+func UpgradePreflight(action engine.Action) (engine.PreflightResult, bool) {
+	result, ok := action.Parameters[ParamUpgradePreflight].(engine.PreflightResult)
+	return result, ok
+}
+
+// annotateCosts sets the monthly cost delta of each cluster action. Actions
+// whose cost cannot be estimated, e.g. for lack of pricing data, are left
+// without one.
+func (p *Planner) annotateCosts(ctx context.Context, plan engine.Plan, actual engine.State) {
+	monthly := func(spec *api.ClusterSpec) (float64, bool) {
+		if spec == nil {
+			return 0, true
+		}
+		estimate, err := p.estimator.EstimateCost(ctx, *spec)
+		if err != nil {
+			return 0, false
+		}
+		return estimate.TotalMonthlyCost, true
+	}
+
+	for i := range plan.Actions {
+		action := &plan.Actions[i]
+		if action.Resource.Kind != "Cluster" {
+			continue
+		}
+
+		// The planned spec may differ from the desired one when targeting
+		var after, before *api.ClusterSpec
+		if spec, ok := action.Parameters["spec"].(api.ClusterSpec); ok && action.Type != engine.ActionDelete {
+			after = &spec
+		}
+		if cluster, exists := actual.Clusters[action.Resource.ID]; exists {
+			before = &cluster.Spec
+		}
+
+		afterCost, ok := monthly(after)
+		if !ok {
+			continue
+		}
+		beforeCost, ok := monthly(before)
+		if !ok {
+			continue
+		}
+
+		if action.Parameters == nil {
+			action.Parameters = make(map[string]interface{})
+		}
+		action.Parameters[ParamMonthlyCostDelta] = afterCost - beforeCost
+	}
+}
+
+// MonthlyCostDelta returns the estimated monthly cost change of an action,
+// if the planner annotated one
+func MonthlyCostDelta(action engine.Action) (float64, bool) {
+	delta, ok := action.Parameters[ParamMonthlyCostDelta].(float64)
+	return delta, ok
+}
+
+// PrintPlan formats a plan grouped by action type, with per-group counts
 func (p *Planner) PrintPlan(plan engine.Plan) string {
-	output := "Infrastructure Plan:\n\n"
+	output := "Infrastructure Plan:\n"
 
-	creates := 0
-	updates := 0
-	deletes := 0
+	if len(p.targets) > 0 {
+		targets := make([]string, len(p.targets))
+		for i, target := range p.targets {
+			targets[i] = target.String()
+		}
+		output += fmt.Sprintf("\n  Warning: resource targeting is in effect (%s).\n", strings.Join(targets, ", "))
+		output += "  Changes to other resources are not planned, so state may still differ from the configuration.\n"
+	}
 
-	for _, action := range plan.Actions {
-		switch action.Type {
-		case engine.ActionCreate:
-			creates++
-			output += fmt.Sprintf("  + %s %s (%s)\n", action.Resource.Kind, action.Resource.Name, action.Resource.ID)
-		case engine.ActionUpdate:
-			updates++
-			output += fmt.Sprintf("  ~ %s %s (%s)\n", action.Resource.Kind, action.Resource.Name, action.Resource.ID)
-		case engine.ActionDelete:
-			deletes++
-			output += fmt.Sprintf("  - %s %s (%s)\n", action.Resource.Kind, action.Resource.Name, action.Resource.ID)
+	groups := []struct {
+		actionType engine.ActionType
+		title      string
+		symbol     string
+		color      color.Color
+	}{
+		{engine.ActionCreate, "create", "+", color.Green},
+		{engine.ActionUpdate, "update", "~", color.Yellow},
+		{engine.ActionDelete, "delete", "-", color.Red},
+	}
+
+	summary := Summarize(plan)
+	var provisionTime time.Duration
+	var blockedUpgrades int
+	for _, group := range groups {
+		actions := ActionsOfType(plan, group.actionType)
+		if len(actions) == 0 {
+			continue
+		}
+
+		output += fmt.Sprintf("\n  %d to %s:\n", len(actions), group.title)
+		for _, action := range actions {
+			line := fmt.Sprintf("%s %s %s (%s)", group.symbol, action.Resource.Kind, action.Resource.Name, action.Resource.ID)
+			if delta, ok := MonthlyCostDelta(action); ok {
+				line += " (" + cost.FormatMonthlyDelta(delta) + ")"
+			}
+			if estimate, ok := ProvisionTime(action); ok {
+				line += " (~" + format.Duration(estimate) + " to create)"
+				provisionTime += estimate
+			}
+			forcing := ReplacementChanges(action)
+			if len(forcing) > 0 {
+				line += " (forces replacement)"
+			}
+			preflight, upgrade := UpgradePreflight(action)
+			if upgrade {
+				line += fmt.Sprintf(" (upgrade %s → %s)", preflight.CurrentVersion, preflight.TargetVersion)
+			}
+			output += "    " + color.Wrap(p.color, group.color, line) + "\n"
+			if changes := Changes(action); p.diff && len(changes) > 0 {
+				output += formatDiff(changes, forcing, p.color, "        ")
+			} else {
+				for _, change := range forcing {
+					output += "        " + color.Wrap(p.color, color.Red, change.String()+" (forces replacement)") + "\n"
+				}
+			}
+			for _, issue := range preflight.Blocking() {
+				output += "        " + color.Wrap(p.color, color.Red, "blocking: "+issue.String()) + "\n"
+			}
+			for _, issue := range preflight.Warnings() {
+				output += "        " + color.Wrap(p.color, color.Yellow, "warning: "+issue.String()) + "\n"
+			}
+			if len(preflight.Blocking()) > 0 {
+				blockedUpgrades++
+			}
 		}
 	}
 
-	output += fmt.Sprintf("\nPlan: %d to create, %d to update, %d to delete\n", creates, updates, deletes)
+	output += fmt.Sprintf("\nPlan: %d to create, %d to update, %d to delete\n", summary.Creates, summary.Updates, summary.Deletes)
+	if summary.Replacements > 0 {
+		output += fmt.Sprintf("Warning: %d update(s) force replacement: the cluster is destroyed and created again, with downtime\n", summary.Replacements)
+	}
+	if blockedUpgrades > 0 {
+		output += fmt.Sprintf("Warning: %d upgrade(s) have blocking preflight issues; apply refuses them without --force-upgrade\n", blockedUpgrades)
+	}
+	if provisionTime > 0 {
+		output += fmt.Sprintf("Estimated provisioning time: ~%s (a heuristic; actual times vary)\n", format.Duration(provisionTime))
+	}
 	return output
 }
 
+// ActionsOfType returns the plan's actions of one type sorted by kind and name
+func ActionsOfType(plan engine.Plan, actionType engine.ActionType) []engine.Action {
+	var actions []engine.Action
+	for _, action := range plan.Actions {
+		if action.Type == actionType {
+			actions = append(actions, action)
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		a, b := actions[i].Resource, actions[j].Resource
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return actions
+}
+
 func needsUpdate(desired, actual *api.Cluster) bool {
-	// Compare specs to determine if update is needed
-	// Simplified - real implementation would deep compare
-	return desired.Spec.ControlPlane.Version != actual.Spec.ControlPlane.Version
+	return !desired.Spec.Equal(actual.Spec)
 }
@@ -0,0 +1,310 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/color"
+	"github.com/vjranagit/cluster-api/pkg/cost"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+func TestPlanner_DeletionProtection(t *testing.T) {
+	actual := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec:     api.ClusterSpec{Provider: "aws", DeletionProtection: true},
+			},
+		},
+	}
+	// The cluster was removed from config, so the plan deletes it
+	desired := engine.State{Clusters: map[string]*api.Cluster{}}
+
+	p := NewPlanner(nil)
+	if _, err := p.GeneratePlan(context.Background(), desired, actual); !errors.Is(err, engine.ErrDeletionProtected) {
+		t.Fatalf("GeneratePlan() error = %v, want ErrDeletionProtected", err)
+	}
+
+	p.SetDisableProtection(true)
+	plan, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() with protection disabled error = %v", err)
+	}
+	if len(plan.Actions) != 1 || plan.Actions[0].Type != engine.ActionDelete {
+		t.Errorf("GeneratePlan() = %+v, want one delete action", plan.Actions)
+	}
+}
+
+func TestPlanner_CostAnnotation(t *testing.T) {
+	spec := func(desiredSize int) api.ClusterSpec {
+		return api.ClusterSpec{
+			Provider:     "aws",
+			Region:       "us-west-2",
+			ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.28"},
+			WorkerPools: []api.WorkerPoolSpec{
+				{Name: "general", InstanceType: "t3.medium", MinSize: 1, MaxSize: 10, DesiredSize: desiredSize},
+			},
+		}
+	}
+	cluster := func(id string, desiredSize int) *api.Cluster {
+		return &api.Cluster{ID: id, Metadata: api.ResourceMetadata{Name: id}, Spec: spec(desiredSize)}
+	}
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"new":     cluster("new", 2),
+		"resized": cluster("resized", 6),
+	}}
+	actual := engine.State{Clusters: map[string]*api.Cluster{
+		"resized": cluster("resized", 2),
+		"old":     cluster("old", 2),
+	}}
+
+	p := NewPlanner(nil)
+	plain, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	for _, action := range plain.Actions {
+		if _, ok := MonthlyCostDelta(action); ok {
+			t.Errorf("action %s has a cost delta without an estimator", action.Resource.Name)
+		}
+	}
+
+	p.SetEstimator(cost.NewEstimator())
+	plan, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+
+	deltas := make(map[string]float64)
+	for _, action := range plan.Actions {
+		delta, ok := MonthlyCostDelta(action)
+		if !ok {
+			t.Fatalf("action %s has no cost delta", action.Resource.Name)
+		}
+		deltas[action.Resource.Name] = delta
+	}
+
+	if deltas["new"] <= 0 {
+		t.Errorf("create delta = %v, want positive", deltas["new"])
+	}
+	if deltas["old"] != -deltas["new"] {
+		t.Errorf("delete delta = %v, want %v", deltas["old"], -deltas["new"])
+	}
+	if deltas["resized"] <= 0 || deltas["resized"] >= deltas["new"] {
+		t.Errorf("update delta = %v, want the cost of 4 extra nodes", deltas["resized"])
+	}
+
+	output := p.PrintPlan(plan)
+	if want := "+ Cluster new (new) (" + cost.FormatMonthlyDelta(deltas["new"]) + ")"; !strings.Contains(output, want) {
+		t.Errorf("PrintPlan() = %q, want line %q", output, want)
+	}
+}
+
+func TestPlanner_ProvisionTimeAnnotation(t *testing.T) {
+	spec := api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged}}
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"new":   {ID: "new", Metadata: api.ResourceMetadata{Name: "new"}, Spec: spec},
+		"other": {ID: "other", Metadata: api.ResourceMetadata{Name: "other"}, Spec: api.ClusterSpec{Provider: "azure"}},
+	}}
+
+	provider := fake.NewProvider("aws")
+	provider.SetProvisionTime(17 * time.Minute)
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+	unused := false
+	eng.RegisterProviderLoader("gcp", func(ctx context.Context) (engine.CloudProvider, error) {
+		unused = true
+		return fake.NewProvider("gcp"), nil
+	})
+	p := NewPlanner(nil)
+	p.SetProviders(eng.LoadRegionalProvider)
+
+	plan, err := p.GeneratePlan(context.Background(), desired, engine.State{})
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	for _, action := range plan.Actions {
+		estimate, ok := ProvisionTime(action)
+		switch action.Resource.Name {
+		case "new":
+			if estimate != 17*time.Minute {
+				t.Errorf("ProvisionTime(new) = %v, want 17m", estimate)
+			}
+		case "other":
+			if ok {
+				t.Errorf("ProvisionTime(other) = %v, want none for an unknown provider", estimate)
+			}
+		}
+	}
+	if unused {
+		t.Error("GeneratePlan() constructed a provider no action uses")
+	}
+
+	output := p.PrintPlan(plan)
+	for _, want := range []string{"+ Cluster new (new) (~17m to create)", "Estimated provisioning time: ~17m"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintPlan() = %q, want %q", output, want)
+		}
+	}
+}
+
+func TestPlanner_ReplacementAnnotation(t *testing.T) {
+	spec := func(cidr, version string) api.ClusterSpec {
+		return api.ClusterSpec{
+			Provider:     "aws",
+			Network:      api.NetworkSpec{VPCCIDR: cidr},
+			ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: version},
+		}
+	}
+	cluster := func(id string, spec api.ClusterSpec) *api.Cluster {
+		return &api.Cluster{ID: id, Metadata: api.ResourceMetadata{Name: id}, Spec: spec}
+	}
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"renumbered": cluster("renumbered", spec("10.1.0.0/16", "1.28")),
+		"upgraded":   cluster("upgraded", spec("10.0.0.0/16", "1.29")),
+	}}
+	actual := engine.State{Clusters: map[string]*api.Cluster{
+		"renumbered": cluster("renumbered", spec("10.0.0.0/16", "1.28")),
+		"upgraded":   cluster("upgraded", spec("10.0.0.0/16", "1.28")),
+	}}
+
+	p := NewPlanner(nil)
+	plan, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	for _, action := range plan.Actions {
+		changes := ReplacementChanges(action)
+		switch action.Resource.Name {
+		case "renumbered":
+			if len(changes) != 1 || changes[0].Path != "network.vpcCidr" {
+				t.Errorf("ReplacementChanges(renumbered) = %v, want the VPC CIDR change", changes)
+			}
+		case "upgraded":
+			if changes != nil {
+				t.Errorf("ReplacementChanges(upgraded) = %v, want an in-place update", changes)
+			}
+		}
+	}
+
+	output := p.PrintPlan(plan)
+	for _, want := range []string{
+		"~ Cluster renumbered (renumbered) (forces replacement)\n",
+		"network.vpcCidr: 10.0.0.0/16 → 10.1.0.0/16 (forces replacement)",
+		"~ Cluster upgraded (upgraded)\n",
+		"Warning: 1 update(s) force replacement",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintPlan() = %q, want %q", output, want)
+		}
+	}
+}
+
+func TestPlanner_UpgradePreflightAnnotation(t *testing.T) {
+	cluster := func(id, version string) *api.Cluster {
+		return &api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: id},
+			Spec:     api.ClusterSpec{Provider: "aws", ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: version}},
+		}
+	}
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"upgraded": cluster("upgraded", "1.29"),
+		"tagged":   cluster("tagged", "1.28"),
+	}}
+	desired.Clusters["tagged"].Spec.Tags = map[string]string{"team": "web"}
+	actual := engine.State{Clusters: map[string]*api.Cluster{
+		"upgraded": cluster("upgraded", "1.28"),
+		"tagged":   cluster("tagged", "1.28"),
+	}}
+
+	provider := fake.NewProvider("aws")
+	provider.SetUpgradePreflight(engine.PreflightIssue{
+		Severity:  engine.PreflightBlocking,
+		Category:  engine.PreflightAddon,
+		Summary:   "add-on vpc-cni has no version for Kubernetes 1.29",
+		Resources: []string{"vpc-cni"},
+	})
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+	p := NewPlanner(nil)
+	p.SetProviders(eng.LoadRegionalProvider)
+
+	plan, err := p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	for _, action := range plan.Actions {
+		result, ok := UpgradePreflight(action)
+		switch action.Resource.Name {
+		case "upgraded":
+			if !ok || len(result.Blocking()) != 1 || len(result.Warnings()) != 1 {
+				t.Errorf("UpgradePreflight(upgraded) = %+v, want the add-on and removed API issues", result)
+			}
+		case "tagged":
+			if ok {
+				t.Errorf("UpgradePreflight(tagged) = %+v, want none without a version change", result)
+			}
+		}
+	}
+
+	output := p.PrintPlan(plan)
+	for _, want := range []string{
+		"~ Cluster upgraded (upgraded) (upgrade 1.28 → 1.29)\n",
+		"blocking: add-on vpc-cni has no version for Kubernetes 1.29 (vpc-cni)",
+		"warning: 1.29 no longer serves these APIs",
+		"Warning: 1 upgrade(s) have blocking preflight issues",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintPlan() = %q, want %q", output, want)
+		}
+	}
+
+	// A failing preflight is noted rather than failing the plan
+	provider.FailOn("UpgradePreflight", 0, errors.New("throttled"))
+	plan, err = p.GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+	if output := p.PrintPlan(plan); !strings.Contains(output, "warning: upgrade preflight of cluster upgraded: throttled") {
+		t.Errorf("PrintPlan() = %q, want the failed preflight noted", output)
+	}
+}
+
+func TestPlanner_PrintPlanColor(t *testing.T) {
+	plan := engine.Plan{Actions: []engine.Action{
+		{Type: engine.ActionCreate, Resource: api.ResourceID{Kind: "Cluster", Name: "new", ID: "new"}},
+		{Type: engine.ActionUpdate, Resource: api.ResourceID{Kind: "Cluster", Name: "changed", ID: "changed"}},
+		{Type: engine.ActionDelete, Resource: api.ResourceID{Kind: "Cluster", Name: "old", ID: "old"}},
+	}}
+
+	var out bytes.Buffer
+	p := NewPlanner(nil)
+	p.SetColor(color.Enabled(&out))
+	out.WriteString(p.PrintPlan(plan))
+	if strings.Contains(out.String(), "\033[") {
+		t.Errorf("PrintPlan() to a buffer contains color codes: %q", out.String())
+	}
+
+	p.SetColor(true)
+	output := p.PrintPlan(plan)
+	for _, want := range []string{
+		color.Wrap(true, color.Green, "+ Cluster new (new)"),
+		color.Wrap(true, color.Yellow, "~ Cluster changed (changed)"),
+		color.Wrap(true, color.Red, "- Cluster old (old)"),
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintPlan() = %q, want %q", output, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// StateSource supplies the actual state a plan is compared against
+type StateSource interface {
+	// Name describes the source for plan output
+	Name() string
+
+	// ActualState returns the state to diff desired state against
+	ActualState(ctx context.Context) (engine.State, error)
+}
+
+// StoredStateSource uses the last persisted state without querying providers.
+// It is fast but may be stale if resources changed outside provctl.
+type StoredStateSource struct {
+	state engine.StateManager
+}
+
+// NewStoredStateSource creates a source backed by the state manager
+func NewStoredStateSource(state engine.StateManager) *StoredStateSource {
+	return &StoredStateSource{state: state}
+}
+
+// Name returns the source label
+func (s *StoredStateSource) Name() string {
+	return "stored state (not refreshed)"
+}
+
+// ActualState returns the persisted state
+func (s *StoredStateSource) ActualState(ctx context.Context) (engine.State, error) {
+	current, err := s.state.GetState(ctx)
+	if err != nil {
+		return engine.State{}, fmt.Errorf("failed to get state: %w", err)
+	}
+	return current, nil
+}
+
+// LiveStateSource refreshes stored state against the providers, so the plan
+// reflects actual cloud reality. Resources the providers no longer report
+// are treated as absent.
+type LiveStateSource struct {
+	engine   *engine.Engine
+	cache    RefreshCache
+	cacheTTL time.Duration
+}
+
+// NewLiveStateSource creates a source that queries the engine's providers
+func NewLiveStateSource(eng *engine.Engine) *LiveStateSource {
+	return &LiveStateSource{engine: eng}
+}
+
+// SetCache makes the source reuse the refreshed state of a provider region
+// for ttl instead of querying the provider again. Without a cache, the
+// default, every plan queries the providers.
+func (s *LiveStateSource) SetCache(cache RefreshCache, ttl time.Duration) {
+	s.cache = cache
+	s.cacheTTL = ttl
+}
+
+// Name returns the source label
+func (s *LiveStateSource) Name() string {
+	if s.cache != nil && s.cacheTTL > 0 {
+		return fmt.Sprintf("live provider state (cached up to %s)", s.cacheTTL)
+	}
+	return "live provider state"
+}
+
+// ActualState returns stored state refreshed from the providers
+func (s *LiveStateSource) ActualState(ctx context.Context) (engine.State, error) {
+	if s.cache != nil && s.cacheTTL > 0 {
+		return s.cachedActualState(ctx)
+	}
+
+	result, err := s.engine.Refresh(ctx)
+	if err != nil {
+		return engine.State{}, fmt.Errorf("failed to refresh state: %w", err)
+	}
+
+	removeMissing(result.State, result.Missing)
+	return result.State, nil
+}
+
+// removeMissing deletes resources the providers no longer report from state
+func removeMissing(state engine.State, missing []api.ResourceID) {
+	for _, resource := range missing {
+		switch resource.Kind {
+		case "Cluster":
+			delete(state.Clusters, resource.ID)
+		case "NodePool":
+			delete(state.NodePools, resource.ID)
+		}
+	}
+}
+
+// PlanFrom generates a plan comparing desired state against the actual state
+// supplied by source
+func (p *Planner) PlanFrom(ctx context.Context, desired engine.State, source StateSource) (engine.Plan, error) {
+	actual, err := source.ActualState(ctx)
+	if err != nil {
+		return engine.Plan{}, err
+	}
+	return p.GeneratePlan(ctx, desired, actual)
+}
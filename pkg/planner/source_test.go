@@ -0,0 +1,73 @@
+package planner
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+	"github.com/vjranagit/cluster-api/pkg/state"
+)
+
+func TestPlanner_PlanFrom(t *testing.T) {
+	ctx := context.Background()
+
+	sm, err := state.NewSQLiteStateManager(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	defer sm.Close()
+
+	cluster := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.28"},
+		},
+	}
+	if err := sm.SaveState(ctx, engine.State{
+		Clusters:  map[string]*api.Cluster{cluster.ID: cluster},
+		NodePools: map[string]*api.NodePool{},
+	}); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	desired := engine.State{
+		Clusters: map[string]*api.Cluster{cluster.ID: cluster},
+	}
+
+	// The cluster was deleted out-of-band: only the live source notices
+	provider := fake.NewProvider("aws")
+	eng := engine.NewEngine(sm, nil)
+	eng.RegisterProvider(provider)
+
+	tests := []struct {
+		name        string
+		source      StateSource
+		wantActions int
+	}{
+		{name: "stored", source: NewStoredStateSource(sm), wantActions: 0},
+		{name: "live", source: NewLiveStateSource(eng), wantActions: 1},
+	}
+
+	p := NewPlanner(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := p.PlanFrom(ctx, desired, tt.source)
+			if err != nil {
+				t.Fatalf("PlanFrom() error = %v", err)
+			}
+
+			if len(plan.Actions) != tt.wantActions {
+				t.Errorf("PlanFrom() got %d actions, want %d", len(plan.Actions), tt.wantActions)
+			}
+		})
+	}
+
+	if n := provider.CallCount("GetCluster"); n != 1 {
+		t.Errorf("GetCluster called %d times, want 1 (live source only)", n)
+	}
+}
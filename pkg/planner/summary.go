@@ -0,0 +1,54 @@
+package planner
+
+import (
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// PlanSummary describes what a plan changes, for callers that act on a plan
+// rather than print it, such as CI checks or JSON output. Resources are
+// sorted by kind and name.
+type PlanSummary struct {
+	Creates      int `json:"creates"`
+	Updates      int `json:"updates"`
+	Deletes      int `json:"deletes"`
+	Replacements int `json:"replacements"` // Updates that destroy and recreate the resource
+
+	Created  []api.ResourceID `json:"created,omitempty"`
+	Updated  []api.ResourceID `json:"updated,omitempty"`
+	Deleted  []api.ResourceID `json:"deleted,omitempty"`
+	Replaced []api.ResourceID `json:"replaced,omitempty"` // Also listed in Updated
+
+	// Destructive is set when the plan deletes or replaces a resource
+	Destructive bool `json:"destructive"`
+}
+
+// Summarize counts and lists the resources a plan creates, updates and
+// deletes. No-op actions are left out.
+func Summarize(plan engine.Plan) PlanSummary {
+	var summary PlanSummary
+	for _, action := range ActionsOfType(plan, engine.ActionCreate) {
+		summary.Created = append(summary.Created, action.Resource)
+	}
+	for _, action := range ActionsOfType(plan, engine.ActionUpdate) {
+		summary.Updated = append(summary.Updated, action.Resource)
+		if len(ReplacementChanges(action)) > 0 {
+			summary.Replaced = append(summary.Replaced, action.Resource)
+		}
+	}
+	for _, action := range ActionsOfType(plan, engine.ActionDelete) {
+		summary.Deleted = append(summary.Deleted, action.Resource)
+	}
+
+	summary.Creates = len(summary.Created)
+	summary.Updates = len(summary.Updated)
+	summary.Deletes = len(summary.Deleted)
+	summary.Replacements = len(summary.Replaced)
+	summary.Destructive = summary.Deletes > 0 || summary.Replacements > 0
+	return summary
+}
+
+// HasChanges reports whether the plan changes anything
+func (s PlanSummary) HasChanges() bool {
+	return s.Creates+s.Updates+s.Deletes > 0
+}
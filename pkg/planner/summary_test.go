@@ -0,0 +1,71 @@
+package planner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestSummarize(t *testing.T) {
+	spec := func(cidr, version string) api.ClusterSpec {
+		return api.ClusterSpec{
+			Provider:     "aws",
+			Network:      api.NetworkSpec{VPCCIDR: cidr},
+			ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: version},
+		}
+	}
+	cluster := func(id string, spec api.ClusterSpec) *api.Cluster {
+		return &api.Cluster{ID: id, Metadata: api.ResourceMetadata{Name: id}, Spec: spec}
+	}
+	resource := func(id string) api.ResourceID {
+		return api.ResourceID{Provider: "aws", Kind: "Cluster", ID: id, Name: id}
+	}
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"new-b":      cluster("new-b", spec("10.0.0.0/16", "1.28")),
+		"new-a":      cluster("new-a", spec("10.0.0.0/16", "1.28")),
+		"renumbered": cluster("renumbered", spec("10.1.0.0/16", "1.28")),
+		"upgraded":   cluster("upgraded", spec("10.0.0.0/16", "1.29")),
+		"unchanged":  cluster("unchanged", spec("10.0.0.0/16", "1.28")),
+	}}
+	actual := engine.State{Clusters: map[string]*api.Cluster{
+		"renumbered": cluster("renumbered", spec("10.0.0.0/16", "1.28")),
+		"upgraded":   cluster("upgraded", spec("10.0.0.0/16", "1.28")),
+		"unchanged":  cluster("unchanged", spec("10.0.0.0/16", "1.28")),
+	}}
+
+	plan, err := NewPlanner(nil).GeneratePlan(context.Background(), desired, actual)
+	if err != nil {
+		t.Fatalf("GeneratePlan() error = %v", err)
+	}
+
+	got := Summarize(plan)
+	want := PlanSummary{
+		Creates:      2,
+		Updates:      2,
+		Replacements: 1,
+		Created:      []api.ResourceID{resource("new-a"), resource("new-b")},
+		Updated:      []api.ResourceID{resource("renumbered"), resource("upgraded")},
+		Replaced:     []api.ResourceID{resource("renumbered")},
+		Destructive:  true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+	if got.Creates+got.Updates+got.Deletes != len(plan.Actions) {
+		t.Errorf("Summarize() counts %d actions, plan has %d", got.Creates+got.Updates+got.Deletes, len(plan.Actions))
+	}
+
+	plan.Actions = append(plan.Actions, engine.Action{Type: engine.ActionDelete, Resource: resource("old")})
+	if got := Summarize(plan); got.Deletes != 1 || !reflect.DeepEqual(got.Deleted, []api.ResourceID{resource("old")}) {
+		t.Errorf("Summarize() deletes = %d %v, want old", got.Deletes, got.Deleted)
+	}
+
+	empty := Summarize(engine.Plan{Actions: []engine.Action{{Type: engine.ActionNoop, Resource: resource("unchanged")}}})
+	if empty.HasChanges() || empty.Destructive {
+		t.Errorf("Summarize() of a no-op plan = %+v, want no changes", empty)
+	}
+}
@@ -0,0 +1,189 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Target restricts a plan to one cluster or one worker pool of a cluster
+type Target struct {
+	Kind    string // "Cluster" or "NodePool"
+	Cluster string // Cluster name or ID
+	Pool    string // Worker pool name, for NodePool targets
+}
+
+// ParseTarget parses a target of the form cluster=<name> or
+// nodepool=<cluster>/<pool>
+func ParseTarget(s string) (Target, error) {
+	kind, name, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return Target{}, fmt.Errorf("invalid target %q: expected cluster=<name> or nodepool=<cluster>/<pool>", s)
+	}
+
+	switch strings.ToLower(kind) {
+	case "cluster":
+		return Target{Kind: "Cluster", Cluster: name}, nil
+	case "nodepool":
+		cluster, pool, ok := strings.Cut(name, "/")
+		if !ok || cluster == "" || pool == "" {
+			return Target{}, fmt.Errorf("invalid target %q: node pools are targeted as nodepool=<cluster>/<pool>", s)
+		}
+		return Target{Kind: "NodePool", Cluster: cluster, Pool: pool}, nil
+	default:
+		return Target{}, fmt.Errorf("invalid target %q: unknown kind %q (want cluster or nodepool)", s, kind)
+	}
+}
+
+// String returns the target in the form accepted by ParseTarget
+func (t Target) String() string {
+	if t.Kind == "NodePool" {
+		return "nodepool=" + t.Cluster + "/" + t.Pool
+	}
+	return "cluster=" + t.Cluster
+}
+
+func (t Target) matchesCluster(id api.ResourceID) bool {
+	return t.Cluster == id.Name || t.Cluster == id.ID
+}
+
+// SetTargets restricts generated plans to the targeted resources and the
+// resources they depend on. Everything else is left out of the plan even if
+// it differs from the desired state.
+func (p *Planner) SetTargets(targets []Target) {
+	p.targets = targets
+}
+
+// applyTargets drops actions that are not targeted. Worker pools are part of
+// the cluster spec, so a node pool target narrows a cluster update to the
+// targeted pools, and a cluster that does not exist yet is created with only
+// the targeted pools.
+func (p *Planner) applyTargets(plan engine.Plan, desired, actual engine.State) engine.Plan {
+	targeted := engine.Plan{
+		Actions: []engine.Action{},
+	}
+
+	for _, action := range plan.Actions {
+		switch action.Resource.Kind {
+		case "Cluster":
+			if p.targetsCluster(action.Resource) {
+				targeted.Actions = append(targeted.Actions, action)
+				continue
+			}
+
+			pools := p.targetedPools(action.Resource)
+			if len(pools) == 0 || action.Type == engine.ActionDelete {
+				continue
+			}
+
+			desiredSpec := desired.Clusters[action.Resource.ID].Spec
+			base := desiredSpec
+			base.WorkerPools = nil
+			if action.Type == engine.ActionUpdate {
+				base = actual.Clusters[action.Resource.ID].Spec
+			}
+
+			spec := withPools(base, desiredSpec.WorkerPools, pools)
+			if action.Type == engine.ActionUpdate && spec.Equal(base) {
+				continue
+			}
+
+			parameters := make(map[string]interface{}, len(action.Parameters))
+			for key, value := range action.Parameters {
+				parameters[key] = value
+			}
+			parameters["spec"] = spec
+			action.Parameters = parameters
+			targeted.Actions = append(targeted.Actions, action)
+
+		case "NodePool":
+			cluster := poolCluster(action.Resource.ID, desired, actual)
+			for _, target := range p.targets {
+				if target.Kind == "NodePool" && target.matchesCluster(cluster) &&
+					(target.Pool == action.Resource.Name || target.Pool == action.Resource.ID) {
+					targeted.Actions = append(targeted.Actions, action)
+					break
+				}
+			}
+		}
+	}
+
+	return targeted
+}
+
+func (p *Planner) targetsCluster(id api.ResourceID) bool {
+	for _, target := range p.targets {
+		if target.Kind == "Cluster" && target.matchesCluster(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetedPools returns the names of the targeted worker pools of a cluster
+func (p *Planner) targetedPools(id api.ResourceID) map[string]bool {
+	pools := make(map[string]bool)
+	for _, target := range p.targets {
+		if target.Kind == "NodePool" && target.matchesCluster(id) {
+			pools[target.Pool] = true
+		}
+	}
+	return pools
+}
+
+// poolCluster identifies the cluster owning the node pool with the given ID,
+// from the properties the provider recorded on it. Either field is empty
+// when it is not known.
+func poolCluster(poolID string, desired, actual engine.State) api.ResourceID {
+	pool := desired.NodePools[poolID]
+	if pool == nil {
+		pool = actual.NodePools[poolID]
+	}
+	if pool == nil {
+		return api.ResourceID{}
+	}
+
+	id := api.ResourceID{
+		ID:   pool.Status.Properties[api.PropertyClusterID],
+		Name: pool.Status.Properties[api.PropertyClusterName],
+	}
+	if id.Name == "" {
+		if cluster := desired.Clusters[id.ID]; cluster != nil {
+			id.Name = cluster.Metadata.Name
+		} else if cluster := actual.Clusters[id.ID]; cluster != nil {
+			id.Name = cluster.Metadata.Name
+		}
+	}
+	return id
+}
+
+// withPools returns base with the named worker pools taken from desired:
+// changed pools are replaced in place, removed pools dropped and new pools
+// added at the end in their desired order
+func withPools(base api.ClusterSpec, desired []api.WorkerPoolSpec, names map[string]bool) api.ClusterSpec {
+	desiredPools := make(map[string]api.WorkerPoolSpec, len(desired))
+	for _, pool := range desired {
+		desiredPools[pool.Name] = pool
+	}
+
+	var pools []api.WorkerPoolSpec
+	existing := make(map[string]bool, len(base.WorkerPools))
+	for _, pool := range base.WorkerPools {
+		existing[pool.Name] = true
+		if !names[pool.Name] {
+			pools = append(pools, pool)
+		} else if replacement, ok := desiredPools[pool.Name]; ok {
+			pools = append(pools, replacement)
+		}
+	}
+	for _, pool := range desired {
+		if names[pool.Name] && !existing[pool.Name] {
+			pools = append(pools, pool)
+		}
+	}
+
+	base.WorkerPools = pools
+	return base
+}
@@ -0,0 +1,180 @@
+package planner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Target
+		wantErr bool
+	}{
+		{input: "cluster=prod", want: Target{Kind: "Cluster", Cluster: "prod"}},
+		{input: "nodepool=prod/gpu", want: Target{Kind: "NodePool", Cluster: "prod", Pool: "gpu"}},
+		{input: "NodePool=prod/gpu", want: Target{Kind: "NodePool", Cluster: "prod", Pool: "gpu"}},
+		{input: "nodepool=gpu", wantErr: true},
+		{input: "cluster=", wantErr: true},
+		{input: "network=main", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTarget(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTarget(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseTarget(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if !tt.wantErr && got.String() != strings.Replace(tt.input, "NodePool", "nodepool", 1) {
+			t.Errorf("String() = %s, want %s", got.String(), tt.input)
+		}
+	}
+}
+
+func TestPlanner_Targets(t *testing.T) {
+	pool := func(name string, size int) api.WorkerPoolSpec {
+		return api.WorkerPoolSpec{Name: name, InstanceType: "m5.large", DesiredSize: size}
+	}
+	cluster := func(id, version string, pools ...api.WorkerPoolSpec) *api.Cluster {
+		return &api.Cluster{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: id},
+			Spec: api.ClusterSpec{
+				Provider:     "aws",
+				ControlPlane: api.ControlPlaneSpec{Version: version},
+				WorkerPools:  pools,
+			},
+		}
+	}
+
+	desired := engine.State{Clusters: map[string]*api.Cluster{
+		"prod":    cluster("prod", "1.29", pool("general", 3), pool("gpu", 4)),
+		"staging": cluster("staging", "1.29"),
+		"new":     cluster("new", "1.29", pool("general", 1), pool("gpu", 1)),
+	}}
+	actual := engine.State{Clusters: map[string]*api.Cluster{
+		"prod":    cluster("prod", "1.28", pool("general", 3), pool("gpu", 2)),
+		"staging": cluster("staging", "1.28"),
+		"old":     cluster("old", "1.28"),
+	}}
+
+	t.Run("cluster", func(t *testing.T) {
+		p := NewPlanner(nil)
+		p.SetTargets([]Target{{Kind: "Cluster", Cluster: "staging"}})
+
+		plan, err := p.GeneratePlan(context.Background(), desired, actual)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		if len(plan.Actions) != 1 || plan.Actions[0].Resource.Name != "staging" {
+			t.Fatalf("GeneratePlan() = %+v, want only the staging update", plan.Actions)
+		}
+		if output := p.PrintPlan(plan); !strings.Contains(output, "Warning: resource targeting is in effect (cluster=staging)") {
+			t.Errorf("PrintPlan() = %q, want a targeting warning", output)
+		}
+	})
+
+	t.Run("node pool narrows update", func(t *testing.T) {
+		p := NewPlanner(nil)
+		p.SetTargets([]Target{{Kind: "NodePool", Cluster: "prod", Pool: "gpu"}})
+
+		plan, err := p.GeneratePlan(context.Background(), desired, actual)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		if len(plan.Actions) != 1 || plan.Actions[0].Type != engine.ActionUpdate {
+			t.Fatalf("GeneratePlan() = %+v, want one prod update", plan.Actions)
+		}
+
+		spec := plan.Actions[0].Parameters["spec"].(api.ClusterSpec)
+		changes := actual.Clusters["prod"].Spec.Diff(spec)
+		if len(changes) != 1 || changes[0].Path != "workerPools.gpu.desiredSize" {
+			t.Errorf("targeted update changes = %v, want only workerPools.gpu.desiredSize", changes)
+		}
+
+		if desired.Clusters["prod"].Spec.WorkerPools[1].DesiredSize != 4 {
+			t.Error("targeting should not modify the desired state")
+		}
+	})
+
+	t.Run("node pool of new cluster", func(t *testing.T) {
+		p := NewPlanner(nil)
+		p.SetTargets([]Target{{Kind: "NodePool", Cluster: "new", Pool: "gpu"}})
+
+		plan, err := p.GeneratePlan(context.Background(), desired, actual)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		if len(plan.Actions) != 1 || plan.Actions[0].Type != engine.ActionCreate {
+			t.Fatalf("GeneratePlan() = %+v, want the new cluster created as a dependency", plan.Actions)
+		}
+
+		pools := plan.Actions[0].Parameters["spec"].(api.ClusterSpec).WorkerPools
+		if len(pools) != 1 || pools[0].Name != "gpu" {
+			t.Errorf("created pools = %+v, want only gpu", pools)
+		}
+	})
+
+	t.Run("standalone node pool of another cluster", func(t *testing.T) {
+		standalone := func(id, clusterID string) *api.NodePool {
+			return &api.NodePool{
+				ID:       id,
+				Metadata: api.ResourceMetadata{Name: "batch"},
+				Status:   api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: clusterID}},
+			}
+		}
+		desired := engine.State{
+			Clusters: desired.Clusters,
+			NodePools: map[string]*api.NodePool{
+				"np-prod":    standalone("np-prod", "prod"),
+				"np-staging": standalone("np-staging", "staging"),
+			},
+		}
+
+		p := NewPlanner(nil)
+		p.SetTargets([]Target{{Kind: "NodePool", Cluster: "staging", Pool: "batch"}})
+
+		plan, err := p.GeneratePlan(context.Background(), desired, actual)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		if len(plan.Actions) != 1 || plan.Actions[0].Resource.ID != "np-staging" {
+			t.Errorf("GeneratePlan() = %+v, want only the staging pool", plan.Actions)
+		}
+	})
+
+	t.Run("unchanged node pool", func(t *testing.T) {
+		p := NewPlanner(nil)
+		p.SetTargets([]Target{{Kind: "NodePool", Cluster: "prod", Pool: "general"}})
+
+		plan, err := p.GeneratePlan(context.Background(), desired, actual)
+		if err != nil {
+			t.Fatalf("GeneratePlan() error = %v", err)
+		}
+		if len(plan.Actions) != 0 {
+			t.Errorf("GeneratePlan() = %+v, want no actions", plan.Actions)
+		}
+	})
+}
+
+func TestWithPools(t *testing.T) {
+	pool := func(name string, size int) api.WorkerPoolSpec {
+		return api.WorkerPoolSpec{Name: name, DesiredSize: size}
+	}
+	base := api.ClusterSpec{WorkerPools: []api.WorkerPoolSpec{pool("gpu", 2), pool("general", 3), pool("batch", 1)}}
+	desired := []api.WorkerPoolSpec{pool("general", 3), pool("spot", 5), pool("gpu", 4)}
+
+	spec := withPools(base, desired, map[string]bool{"gpu": true, "batch": true, "spot": true})
+	want := []api.WorkerPoolSpec{pool("gpu", 4), pool("general", 3), pool("spot", 5)}
+	if !reflect.DeepEqual(spec.WorkerPools, want) {
+		t.Errorf("withPools() pools = %+v, want %+v", spec.WorkerPools, want)
+	}
+}
@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// launchTemplateAPI is the part of the EC2 API used to update the launch
+// template of a node pool's Auto Scaling group
+type launchTemplateAPI interface {
+	DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+	CreateLaunchTemplateVersion(ctx context.Context, params *ec2.CreateLaunchTemplateVersionInput, optFns ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateVersionOutput, error)
+	ModifyLaunchTemplate(ctx context.Context, params *ec2.ModifyLaunchTemplateInput, optFns ...func(*ec2.Options)) (*ec2.ModifyLaunchTemplateOutput, error)
+}
+
+// defaultTemplateData returns what the instances of a group launch with: the
+// data of its launch template's default version, or nil if it has none
+func (p *Provider) defaultTemplateData(ctx context.Context, name string) (*ec2types.ResponseLaunchTemplateData, error) {
+	output, err := p.templates.DescribeLaunchTemplateVersions(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateName: aws.String(name),
+		Versions:           []string{"$Default"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("EC2 DescribeLaunchTemplateVersions API failed: %w", err)
+	}
+	if len(output.LaunchTemplateVersions) == 0 {
+		return nil, nil
+	}
+	return output.LaunchTemplateVersions[0].LaunchTemplateData, nil
+}
+
+// setDefaultTemplateData creates a version of a launch template from data
+// and makes it the default, returning its number
+func (p *Provider) setDefaultTemplateData(ctx context.Context, name string, data *ec2types.RequestLaunchTemplateData) (int64, error) {
+	created, err := p.templates.CreateLaunchTemplateVersion(ctx, &ec2.CreateLaunchTemplateVersionInput{
+		LaunchTemplateName: aws.String(name),
+		LaunchTemplateData: data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("EC2 CreateLaunchTemplateVersion API failed: %w", err)
+	}
+	version := aws.ToInt64(created.LaunchTemplateVersion.VersionNumber)
+
+	_, err = p.templates.ModifyLaunchTemplate(ctx, &ec2.ModifyLaunchTemplateInput{
+		LaunchTemplateName: aws.String(name),
+		DefaultVersion:     aws.String(strconv.FormatInt(version, 10)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("EC2 ModifyLaunchTemplate API failed: %w", err)
+	}
+	return version, nil
+}
+
+// templateChanged reports whether instances launched from desired would
+// differ from ones launched from current
+func templateChanged(current *ec2types.ResponseLaunchTemplateData, desired *ec2types.RequestLaunchTemplateData) bool {
+	if current == nil {
+		return true
+	}
+	return current.InstanceType != desired.InstanceType ||
+		aws.ToString(current.ImageId) != aws.ToString(desired.ImageId) ||
+		aws.ToString(current.UserData) != aws.ToString(desired.UserData) ||
+		aws.ToString(current.KeyName) != aws.ToString(desired.KeyName)
+}
+
+// replaceInstances rolls the running instances of a group onto a changed
+// launch template
+func (p *Provider) replaceInstances(name string) error {
+	return fmt.Errorf("%w: changing what the instances of Auto Scaling group %s launch with requires replacing them, which is not supported; update launch template %s and start an instance refresh",
+		engine.ErrNotSupported, name, name)
+}
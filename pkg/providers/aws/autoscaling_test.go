@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// fakeTemplates serves the default version of one launch template and
+// records the versions created
+type fakeTemplates struct {
+	current  *ec2types.ResponseLaunchTemplateData
+	names    []string
+	versions []*ec2.CreateLaunchTemplateVersionInput
+	defaults []string
+}
+
+func (f *fakeTemplates) DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	f.names = append(f.names, aws.ToString(params.LaunchTemplateName))
+	return &ec2.DescribeLaunchTemplateVersionsOutput{
+		LaunchTemplateVersions: []ec2types.LaunchTemplateVersion{{VersionNumber: aws.Int64(1), LaunchTemplateData: f.current}},
+	}, nil
+}
+
+func (f *fakeTemplates) CreateLaunchTemplateVersion(ctx context.Context, params *ec2.CreateLaunchTemplateVersionInput, optFns ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateVersionOutput, error) {
+	f.versions = append(f.versions, params)
+	return &ec2.CreateLaunchTemplateVersionOutput{
+		LaunchTemplateVersion: &ec2types.LaunchTemplateVersion{VersionNumber: aws.Int64(int64(len(f.versions) + 1))},
+	}, nil
+}
+
+func (f *fakeTemplates) ModifyLaunchTemplate(ctx context.Context, params *ec2.ModifyLaunchTemplateInput, optFns ...func(*ec2.Options)) (*ec2.ModifyLaunchTemplateOutput, error) {
+	f.defaults = append(f.defaults, aws.ToString(params.DefaultVersion))
+	return &ec2.ModifyLaunchTemplateOutput{}, nil
+}
+
+func TestProvider_UpdateNodePool(t *testing.T) {
+	newTemplates := func() *fakeTemplates {
+		return &fakeTemplates{current: &ec2types.ResponseLaunchTemplateData{InstanceType: ec2types.InstanceTypeM5Large}}
+	}
+	newPool := func() *api.NodePool {
+		return &api.NodePool{
+			ID: "nodepool-1",
+			Spec: api.WorkerPoolSpec{
+				Name:         "general",
+				InstanceType: "m5.large",
+				MinSize:      1,
+				MaxSize:      5,
+			},
+			Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: "cluster-1"}},
+		}
+	}
+
+	t.Run("scaling change keeps the launch template", func(t *testing.T) {
+		templates := newTemplates()
+		p := &Provider{templates: templates, logger: slog.Default()}
+
+		pool := newPool()
+		pool.Spec.MaxSize = 10
+		if err := p.UpdateNodePool(context.Background(), pool); err != nil {
+			t.Fatalf("UpdateNodePool() error = %v", err)
+		}
+
+		if len(templates.names) != 1 || templates.names[0] != "cluster-1-general" {
+			t.Errorf("described launch templates %v, want cluster-1-general", templates.names)
+		}
+		if len(templates.versions) != 0 {
+			t.Errorf("CreateLaunchTemplateVersion called %d times, want 0", len(templates.versions))
+		}
+		if pool.Metadata.UpdatedAt.IsZero() {
+			t.Error("UpdatedAt not set by an update")
+		}
+	})
+
+	t.Run("instance type change fails before changing the launch template", func(t *testing.T) {
+		templates := newTemplates()
+		p := &Provider{templates: templates, logger: slog.Default()}
+
+		pool := newPool()
+		pool.Spec.InstanceType = "m5.xlarge"
+		pool.Spec.MaxSize = 10
+		err := p.UpdateNodePool(context.Background(), pool)
+		if !errors.Is(err, engine.ErrNotSupported) {
+			t.Fatalf("UpdateNodePool() error = %v, want ErrNotSupported for rolling the instances", err)
+		}
+
+		if len(templates.versions) != 0 || len(templates.defaults) != 0 {
+			t.Errorf("created versions %+v and set defaults %v, want the launch template left alone", templates.versions, templates.defaults)
+		}
+		if !pool.Metadata.UpdatedAt.IsZero() {
+			t.Error("UpdatedAt set by an update that failed")
+		}
+	})
+
+	t.Run("label and taint change makes a new default version", func(t *testing.T) {
+		templates := newTemplates()
+		p := &Provider{templates: templates, logger: slog.Default()}
+
+		labelled := newPool()
+		labelled.Spec.Labels = map[string]string{"tier": "cpu"}
+		templates.current.UserData = launchTemplateData(labelled.Spec, nil).UserData
+
+		pool := newPool()
+		pool.Spec.Labels = map[string]string{"tier": "gpu"}
+		pool.Spec.Taints = []api.Taint{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}
+		pool.Spec.MaxSize = 10
+		if err := p.UpdateNodePool(context.Background(), pool); err != nil {
+			t.Fatalf("UpdateNodePool() error = %v", err)
+		}
+
+		if len(templates.versions) != 1 || len(templates.defaults) != 1 || templates.defaults[0] != "2" {
+			t.Fatalf("created versions %+v and set defaults %v, want one new default", templates.versions, templates.defaults)
+		}
+		registered := withRegisteredNodeConfig(pool.Spec, aws.ToString(templates.versions[0].LaunchTemplateData.UserData))
+		if !registered.Equal(pool.Spec) {
+			t.Errorf("new version registers nodes with labels %v and taints %v, want %v and %v",
+				registered.Labels, registered.Taints, pool.Spec.Labels, pool.Spec.Taints)
+		}
+		if pool.Metadata.UpdatedAt.IsZero() {
+			t.Error("UpdatedAt not set by an update")
+		}
+	})
+
+	t.Run("missing cluster ID", func(t *testing.T) {
+		p := &Provider{templates: newTemplates(), logger: slog.Default()}
+
+		pool := newPool()
+		pool.Status.Properties = nil
+		if err := p.UpdateNodePool(context.Background(), pool); err == nil {
+			t.Error("UpdateNodePool() error = nil, want error without a cluster ID")
+		}
+	})
+}
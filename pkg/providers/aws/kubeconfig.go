@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// eksTokenPrefix marks an EKS bearer token, a presigned STS
+// GetCallerIdentity URL that the cluster's authenticator calls to learn who
+// the bearer is
+const eksTokenPrefix = "k8s-aws-v1."
+
+var _ engine.KubeconfigIssuer = (*Provider)(nil)
+
+// ClusterCredentials returns the certificate authority of an EKS cluster and
+// a bearer token for it, the same token aws eks get-token issues
+func (p *Provider) ClusterCredentials(ctx context.Context, cluster *api.Cluster) (*engine.ClusterCredentials, error) {
+	output, err := p.eksClient.DescribeCluster(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(cluster.Metadata.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("EKS DescribeCluster API failed: %w", err)
+	}
+
+	credentials := &engine.ClusterCredentials{}
+	if ca := output.Cluster.CertificateAuthority; ca != nil && ca.Data != nil {
+		credentials.CAData, err = base64.StdEncoding.DecodeString(*ca.Data)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s has an invalid certificate authority: %w", cluster.Metadata.Name, err)
+		}
+	}
+
+	credentials.Token, err = p.eksToken(ctx, cluster.Metadata.Name)
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// eksToken presigns a GetCallerIdentity request bound to the cluster name,
+// which EKS accepts as a bearer token for about a minute
+func (p *Provider) eksToken(ctx context.Context, clusterName string) (string, error) {
+	presigner := sts.NewPresignClient(sts.NewFromConfig(p.awsConfig))
+	request, err := presigner.PresignGetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}, func(o *sts.PresignOptions) {
+		o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+			o.APIOptions = append(o.APIOptions,
+				smithyhttp.SetHeaderValue("x-k8s-aws-id", clusterName),
+				smithyhttp.SetHeaderValue("X-Amz-Expires", "60"),
+			)
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign EKS token: %w", err)
+	}
+	return eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(request.URL)), nil
+}
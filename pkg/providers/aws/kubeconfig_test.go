@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestEKSToken(t *testing.T) {
+	p := &Provider{
+		awsConfig: aws.Config{
+			Region:      "us-west-2",
+			Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		},
+		logger: slog.Default(),
+	}
+
+	token, err := p.eksToken(context.Background(), "prod")
+	if err != nil {
+		t.Fatalf("eksToken() error = %v", err)
+	}
+	if !strings.HasPrefix(token, eksTokenPrefix) {
+		t.Fatalf("token = %q, want the %s prefix", token, eksTokenPrefix)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, eksTokenPrefix))
+	if err != nil {
+		t.Fatalf("token is not base64url: %v", err)
+	}
+	presigned, err := url.Parse(string(decoded))
+	if err != nil {
+		t.Fatalf("token holds no URL: %v", err)
+	}
+	query := presigned.Query()
+	if query.Get("Action") != "GetCallerIdentity" || presigned.Host != "sts.us-west-2.amazonaws.com" {
+		t.Errorf("presigned URL = %s, want a regional GetCallerIdentity request", presigned)
+	}
+	if !strings.Contains(query.Get("X-Amz-SignedHeaders"), "x-k8s-aws-id") {
+		t.Errorf("signed headers = %q, want the cluster ID header signed", query.Get("X-Amz-SignedHeaders"))
+	}
+}
@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// userDataBoundary separates the parts of multi-part node user data
+const userDataBoundary = "==PROVCTL=="
+
+// Kubelet flags nodes register their Kubernetes labels and taints with
+const (
+	flagNodeLabels = "--node-labels="
+	flagTaints     = "--register-with-taints="
+)
+
+// nodeUserData returns the user data of a pool's nodes. Without a pod limit,
+// labels or taints it is the pool's own user data. With any, it is a MIME
+// multi-part document whose first part is a nodeadm NodeConfig setting the
+// kubelet's max pods and the labels and taints nodes register with, which
+// Amazon Linux 2023 EKS AMIs merge with the cluster's bootstrap
+// configuration, followed by the pool's own user data. Other images, such as
+// a custom image_id built on AL2 or Bottlerocket, ignore the NodeConfig.
+func nodeUserData(spec api.WorkerPoolSpec) string {
+	flags := kubeletFlags(spec)
+	if spec.MaxPods <= 0 && len(flags) == 0 {
+		return spec.UserData
+	}
+
+	var doc strings.Builder
+	doc.WriteString("MIME-Version: 1.0\n")
+	doc.WriteString("Content-Type: multipart/mixed; boundary=\"" + userDataBoundary + "\"\n\n")
+
+	doc.WriteString("--" + userDataBoundary + "\n")
+	doc.WriteString("Content-Type: application/node.eks.aws\n\n")
+	doc.WriteString("---\n")
+	doc.WriteString("apiVersion: node.eks.aws/v1alpha1\n")
+	doc.WriteString("kind: NodeConfig\n")
+	doc.WriteString("spec:\n")
+	doc.WriteString("  kubelet:\n")
+	if spec.MaxPods > 0 {
+		doc.WriteString("    config:\n")
+		doc.WriteString("      maxPods: " + strconv.Itoa(spec.MaxPods) + "\n")
+	}
+	if len(flags) > 0 {
+		doc.WriteString("    flags:\n")
+		for _, flag := range flags {
+			doc.WriteString("      - " + strconv.Quote(flag) + "\n")
+		}
+	}
+
+	if spec.UserData != "" {
+		doc.WriteString("\n--" + userDataBoundary + "\n")
+		doc.WriteString("Content-Type: " + userDataContentType(spec.UserData) + "\n\n")
+		doc.WriteString(strings.TrimSuffix(spec.UserData, "\n") + "\n")
+	}
+
+	doc.WriteString("\n--" + userDataBoundary + "--\n")
+	return doc.String()
+}
+
+// userDataContentType returns the MIME type cloud-init runs user data as
+func userDataContentType(userData string) string {
+	if strings.HasPrefix(userData, "#cloud-config") {
+		return "text/cloud-config; charset=\"us-ascii\""
+	}
+	return "text/x-shellscript; charset=\"us-ascii\""
+}
+
+// kubeletFlags returns the kubelet flags registering a pool's nodes with its
+// labels and taints, sorted so that unchanged pools keep identical user data
+func kubeletFlags(spec api.WorkerPoolSpec) []string {
+	var flags []string
+	if len(spec.Labels) > 0 {
+		labels := make([]string, 0, len(spec.Labels))
+		for key, value := range spec.Labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+		flags = append(flags, flagNodeLabels+strings.Join(labels, ","))
+	}
+	if len(spec.Taints) > 0 {
+		taints := make([]string, 0, len(spec.Taints))
+		for _, taint := range spec.Taints {
+			if taint.Value == "" {
+				taints = append(taints, taint.Key+":"+taint.Effect)
+				continue
+			}
+			taints = append(taints, taint.Key+"="+taint.Value+":"+taint.Effect)
+		}
+		sort.Strings(taints)
+		flags = append(flags, flagTaints+strings.Join(taints, ","))
+	}
+	return flags
+}
+
+// withRegisteredNodeConfig returns spec with the labels and taints that nodes
+// launched from encoded user data register with, as nodeUserData wrote them
+func withRegisteredNodeConfig(spec api.WorkerPoolSpec, encoded string) api.WorkerPoolSpec {
+	spec.Labels, spec.Taints = nil, nil
+
+	userData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return spec
+	}
+	for _, line := range strings.Split(string(userData), "\n") {
+		flag, err := strconv.Unquote(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(flag, flagNodeLabels):
+			spec.Labels = make(map[string]string)
+			for _, label := range strings.Split(strings.TrimPrefix(flag, flagNodeLabels), ",") {
+				key, value, _ := strings.Cut(label, "=")
+				spec.Labels[key] = value
+			}
+		case strings.HasPrefix(flag, flagTaints):
+			for _, taint := range strings.Split(strings.TrimPrefix(flag, flagTaints), ",") {
+				keyValue, effect, _ := strings.Cut(taint, ":")
+				key, value, _ := strings.Cut(keyValue, "=")
+				spec.Taints = append(spec.Taints, api.Taint{Key: key, Value: value, Effect: effect})
+			}
+		}
+	}
+	return spec
+}
@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestLaunchTemplateData_MaxPods(t *testing.T) {
+	script := "#!/bin/bash\necho ready\n"
+
+	tests := []struct {
+		name     string
+		spec     api.WorkerPoolSpec
+		want     []string
+		wantNone bool
+	}{
+		{name: "default", spec: api.WorkerPoolSpec{InstanceType: "m5.large"}, wantNone: true},
+		{name: "user data only", spec: api.WorkerPoolSpec{InstanceType: "m5.large", UserData: script}, want: []string{script}},
+		{
+			name: "max pods",
+			spec: api.WorkerPoolSpec{InstanceType: "m5.large", MaxPods: 110},
+			want: []string{"Content-Type: application/node.eks.aws\n", "kind: NodeConfig\n", "      maxPods: 110\n"},
+		},
+		{
+			name: "max pods with user data",
+			spec: api.WorkerPoolSpec{InstanceType: "m5.large", MaxPods: 29, UserData: script},
+			want: []string{"      maxPods: 29\n", "Content-Type: text/x-shellscript; charset=\"us-ascii\"\n\n" + script, "--" + userDataBoundary + "--\n"},
+		},
+		{
+			name: "labels and taints",
+			spec: api.WorkerPoolSpec{
+				InstanceType: "m5.large",
+				Labels:       map[string]string{"tier": "gpu", "team": "ml"},
+				Taints:       []api.Taint{{Key: "spot", Effect: "NoExecute"}, {Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}},
+			},
+			want: []string{
+				"    flags:\n",
+				`      - "--node-labels=team=ml,tier=gpu"` + "\n",
+				`      - "--register-with-taints=dedicated=gpu:NoSchedule,spot:NoExecute"` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := launchTemplateData(tt.spec, nil)
+			if tt.wantNone {
+				if data.UserData != nil {
+					t.Errorf("UserData = %q, want none", aws.ToString(data.UserData))
+				}
+				return
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(aws.ToString(data.UserData))
+			if err != nil {
+				t.Fatalf("user data is not base64: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(decoded), want) {
+					t.Errorf("user data = %q, want it to contain %q", decoded, want)
+				}
+			}
+		})
+	}
+}
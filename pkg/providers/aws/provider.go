@@ -3,13 +3,24 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
 	"github.com/vjranagit/cluster-api/pkg/engine"
@@ -21,24 +32,150 @@ type Provider struct {
 	awsConfig aws.Config
 	ec2Client *ec2.Client
 	eksClient *eks.Client
+	clusters  clusterAPI
+	templates launchTemplateAPI
+	networks  networkAPI
+	addons    addonAPI
+	volumes   volumeAPI
+	callers   identityAPI
+	phases    *engine.PhaseRecorder
 	logger    *slog.Logger
+
+	progressInterval time.Duration // Zero takes engine.DefaultProgressInterval
+	pollInterval     time.Duration // Zero takes eksPollInterval
+	timeouts         engine.PhaseTimeouts
+}
+
+// Credential keys read from engine.ProviderConfig.Credentials
+const (
+	CredentialProfile    = "profile"
+	CredentialRoleARN    = "role_arn"
+	CredentialExternalID = "external_id"
+)
+
+func init() {
+	engine.RegisterProviderFactory("aws", func(ctx context.Context, cfg engine.ProviderConfig) (engine.CloudProvider, error) {
+		provider, err := NewProviderWithOptions(ctx, Options{
+			Region:     cfg.Region,
+			Profile:    cfg.Credentials[CredentialProfile],
+			RoleARN:    cfg.Credentials[CredentialRoleARN],
+			ExternalID: cfg.Credentials[CredentialExternalID],
+			RateLimit:  cfg.RateLimit,
+
+			ProgressInterval: cfg.ProgressInterval,
+
+			NetworkTimeout:      cfg.NetworkTimeout,
+			ControlPlaneTimeout: cfg.ControlPlaneTimeout,
+			NodePoolTimeout:     cfg.NodePoolTimeout,
+		}, cfg.Logger)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Events != nil {
+			provider.SetEventStore(cfg.Events)
+		}
+		return provider, nil
+	})
+}
+
+// Options configure how the AWS provider obtains credentials
+type Options struct {
+	Region     string
+	Profile    string // Shared config profile; empty uses the default chain
+	RoleARN    string // Role to assume, e.g. in a workload account
+	ExternalID string // External ID required by the role's trust policy
+
+	// WebIdentityTokenFile and WebIdentityRoleARN authenticate with an OIDC
+	// token, as a pod using IAM roles for service accounts (IRSA) does.
+	// When both are empty and no profile is set they are read from
+	// AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN.
+	WebIdentityTokenFile string
+	WebIdentityRoleARN   string
+
+	// RateLimit paces the provider's API calls, including those resolving
+	// credentials; zero fields take DefaultRateLimit
+	RateLimit engine.RateLimit
+
+	// ProgressInterval is how often waiters log the progress of long-running
+	// operations; zero takes engine.DefaultProgressInterval
+	ProgressInterval time.Duration
+
+	// NetworkTimeout, ControlPlaneTimeout and NodePoolTimeout limit how long
+	// each phase of creating a cluster may take, failing it with an error
+	// naming the phase; zero takes engine.DefaultNetworkTimeout and so on
+	NetworkTimeout      time.Duration
+	ControlPlaneTimeout time.Duration
+	NodePoolTimeout     time.Duration
 }
 
-// NewProvider creates a new AWS provider
+// NewProvider creates a new AWS provider using the default credential chain
 func NewProvider(ctx context.Context, region string, logger *slog.Logger) (*Provider, error) {
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region),
-	)
+	return NewProviderWithOptions(ctx, Options{Region: region}, logger)
+}
+
+// NewProviderWithOptions creates a new AWS provider with a specific shared
+// profile or web identity and/or an assumed role. The role is assumed using
+// the credentials of the profile, web identity or default chain, so provctl
+// can run in a management account and provision into workload accounts.
+func NewProviderWithOptions(ctx context.Context, opts Options, logger *slog.Logger) (*Provider, error) {
+	if opts.ExternalID != "" && opts.RoleARN == "" {
+		return nil, fmt.Errorf("an external ID requires a role ARN to assume")
+	}
+	identity, err := resolveWebIdentity(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	loadOpts := []func(*config.LoadOptions) error{
+		config.WithRegion(opts.Region),
+	}
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	// Every client below is built from cfg, so they share one budget
+	cfg.APIOptions = append(cfg.APIOptions, withRateLimit(opts.RateLimit.Limiter(DefaultRateLimit)))
 
+	if identity != nil {
+		logger.DebugContext(ctx, "using web identity credentials", "role", identity.RoleARN, "tokenFile", identity.TokenFile)
+		cfg.Credentials = webIdentityCredentials(cfg, identity)
+	}
+	if opts.RoleARN != "" {
+		assumeRole := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opts.RoleARN,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = "provctl"
+				if opts.ExternalID != "" {
+					o.ExternalID = aws.String(opts.ExternalID)
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(assumeRole)
+	}
+
+	eksClient := eks.NewFromConfig(cfg)
+	ec2Client := ec2.NewFromConfig(cfg)
 	return &Provider{
-		region:    region,
+		region:    opts.Region,
 		awsConfig: cfg,
-		ec2Client: ec2.NewFromConfig(cfg),
-		eksClient: eks.NewFromConfig(cfg),
+		ec2Client: ec2Client,
+		eksClient: eksClient,
+		clusters:  eksClient,
+		templates: ec2Client,
+		networks:  ec2Client,
+		addons:    eksClient,
+		volumes:   ec2Client,
+		callers:   sts.NewFromConfig(cfg),
 		logger:    logger,
+
+		progressInterval: opts.ProgressInterval,
+		timeouts: engine.PhaseTimeouts{
+			Network:      opts.NetworkTimeout,
+			ControlPlane: opts.ControlPlaneTimeout,
+			NodePool:     opts.NodePoolTimeout,
+		},
 	}, nil
 }
 
@@ -47,12 +184,70 @@ func (p *Provider) Name() string {
 	return "aws"
 }
 
+// SetEventStore records every phase transition of the clusters and node
+// pools this provider manages as an event in events
+func (p *Provider) SetEventStore(events engine.EventStore) {
+	p.phases = engine.NewPhaseRecorder(events)
+}
+
+// setPhase moves a resource to phase through the phase recorder. Failing to
+// record the transition is logged but does not fail the operation.
+func (p *Provider) setPhase(ctx context.Context, resource api.ResourceID, status *api.ResourceStatus, phase api.Phase, reason string) {
+	if err := p.phases.SetPhase(ctx, resource, status, phase, reason); err != nil {
+		p.logger.WarnContext(ctx, "failed to record phase change", "resource", resource.ID, "phase", phase, "error", err)
+	}
+}
+
+// identityAPI is the part of the STS client used to validate credentials
+type identityAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// Validate checks that the configured credentials resolve and are accepted
+// by AWS, using sts:GetCallerIdentity which needs no IAM permissions
+func (p *Provider) Validate(ctx context.Context) error {
+	if _, err := p.awsConfig.Credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("%w: could not load AWS credentials, check your profile or environment: %v",
+			engine.ErrInvalidCredentials, err)
+	}
+
+	identity, err := p.callers.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && invalidCredentialCodes[apiErr.ErrorCode()] {
+			return fmt.Errorf("%w: AWS rejected the credentials (%s), refresh or replace them",
+				engine.ErrInvalidCredentials, apiErr.ErrorCode())
+		}
+		return fmt.Errorf("failed to verify AWS credentials: %w", err)
+	}
+
+	p.logger.InfoContext(ctx, "validated AWS credentials", "account", aws.ToString(identity.Account), "arn", aws.ToString(identity.Arn))
+	return nil
+}
+
+// ServerTime returns the time of the regional STS endpoint, which
+// Validate also calls, for detecting clock skew that breaks request signing
+func (p *Provider) ServerTime(ctx context.Context) (time.Time, error) {
+	return engine.HTTPServerTime(ctx, "https://sts."+p.region+".amazonaws.com/")
+}
+
+// invalidCredentialCodes are STS error codes caused by bad or expired credentials
+var invalidCredentialCodes = map[string]bool{
+	"ExpiredToken":                true,
+	"ExpiredTokenException":       true,
+	"InvalidClientTokenId":        true,
+	"SignatureDoesNotMatch":       true,
+	"UnrecognizedClientException": true,
+}
+
 // CreateCluster creates a new Kubernetes cluster on AWS
 func (p *Provider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*api.Cluster, error) {
-	p.logger.Info("creating AWS cluster",
+	p.logger.InfoContext(ctx, "creating AWS cluster",
 		"region", p.region,
 		"controlPlaneType", spec.ControlPlane.Type,
 	)
+	// Sensitive fields of the spec are redacted from the log
+	p.logger.DebugContext(ctx, "cluster spec", "spec", spec)
 
 	cluster := &api.Cluster{
 		ID: generateClusterID(),
@@ -60,53 +255,159 @@ func (p *Provider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*ap
 			Name: spec.Config["name"].(string),
 		},
 		Spec: spec,
-		Status: api.ResourceStatus{
-			Phase: api.PhaseProvisioning,
-		},
 	}
+	cluster.Metadata.Touch(time.Now())
+	resource := api.ResourceID{Provider: p.Name(), Kind: "Cluster", ID: cluster.ID, Name: cluster.Metadata.Name}
+	p.setPhase(ctx, resource, &cluster.Status, api.PhaseProvisioning, "cluster creation started")
 
-	// Create VPC and networking
-	if err := p.createNetwork(ctx, cluster); err != nil {
-		return nil, fmt.Errorf("failed to create network: %w", err)
+	if err := p.provisionCluster(ctx, cluster); err != nil {
+		p.setPhase(ctx, resource, &cluster.Status, api.PhaseFailed, err.Error())
+		return nil, err
+	}
+
+	p.setPhase(ctx, resource, &cluster.Status, api.PhaseRunning, "cluster created")
+	return cluster, nil
+}
+
+// provisionCluster creates the cloud resources of a new cluster, the
+// network and the control plane each within their phase's timeout
+func (p *Provider) provisionCluster(ctx context.Context, cluster *api.Cluster) error {
+	// Create VPC and networking, unless the cluster goes into an existing VPC
+	if vpcID := cluster.Spec.Network.ExistingVPCID; vpcID != "" {
+		p.logger.InfoContext(ctx, "using existing VPC", "cluster", cluster.ID, "vpc", vpcID,
+			"subnets", cluster.Spec.Network.ExistingSubnetIDs)
+		cluster.Status.SetProperty(api.PropertyVPCID, vpcID)
+	} else if err := p.timeouts.RunPhase(ctx, engine.PhaseNetwork, func(ctx context.Context) error {
+		return p.createNetwork(ctx, cluster)
+	}); err != nil {
+		return fmt.Errorf("failed to create network: %w", err)
 	}
 
 	// Create control plane
-	switch spec.ControlPlane.Type {
-	case api.ControlPlaneManaged:
-		if err := p.createEKSCluster(ctx, cluster); err != nil {
-			return nil, fmt.Errorf("failed to create EKS cluster: %w", err)
+	return p.timeouts.RunPhase(ctx, engine.PhaseControlPlane, func(ctx context.Context) error {
+		switch cluster.Spec.ControlPlane.Type {
+		case api.ControlPlaneManaged:
+			if err := p.createEKSCluster(ctx, cluster); err != nil {
+				return fmt.Errorf("failed to create EKS cluster: %w", err)
+			}
+		case api.ControlPlaneSelfManaged:
+			if err := p.createEC2ControlPlane(ctx, cluster); err != nil {
+				return fmt.Errorf("failed to create EC2 control plane: %w", err)
+			}
 		}
-	case api.ControlPlaneSelfManaged:
-		if err := p.createEC2ControlPlane(ctx, cluster); err != nil {
-			return nil, fmt.Errorf("failed to create EC2 control plane: %w", err)
+		return nil
+	})
+}
+
+// Typical AWS provisioning times, from which EstimateProvisionTime adds up
+// a cluster
+
+const (
+	vpcProvisionTime         = time.Minute
+	natGatewayProvisionTime  = 2 * time.Minute
+	eksProvisionTime         = 12 * time.Minute
+	ec2ProvisionTime         = 6 * time.Minute
+	nodeGroupProvisionTime   = 4 * time.Minute
+	provisionTimePerTenNodes = 30 * time.Second
+)
+
+// EstimateProvisionTime adds up typical durations of the steps creating the
+// cluster takes: networking, the control plane, then each worker pool in
+// turn. An EKS control plane alone usually takes 10 to 15 minutes. This is a
+// heuristic; actual times vary by region and load.
+func (p *Provider) EstimateProvisionTime(spec api.ClusterSpec) time.Duration {
+	var estimate time.Duration
+	if spec.Network.ExistingVPCID == "" {
+		estimate += vpcProvisionTime
+		if spec.Network.NATGateway {
+			estimate += natGatewayProvisionTime
 		}
 	}
 
-	cluster.Status.Phase = api.PhaseRunning
-	return cluster, nil
+	switch spec.ControlPlane.Type {
+	case api.ControlPlaneSelfManaged:
+		estimate += ec2ProvisionTime
+	default:
+		estimate += eksProvisionTime
+	}
+
+	for _, pool := range spec.WorkerPools {
+		estimate += nodeGroupProvisionTime + provisionTimePerTenNodes*time.Duration(pool.DesiredSize/10)
+	}
+	return estimate
 }
 
 // UpdateCluster updates an existing cluster
 func (p *Provider) UpdateCluster(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("updating AWS cluster", "id", cluster.ID)
+	p.logger.InfoContext(ctx, "updating AWS cluster", "id", cluster.ID)
+	cluster.Metadata.Touch(time.Now())
 	return nil
 }
 
-// DeleteCluster deletes a cluster
-func (p *Provider) DeleteCluster(ctx context.Context, clusterID string) error {
-	p.logger.Info("deleting AWS cluster", "id", clusterID)
+// DeleteCluster deletes a cluster. A cluster that is already gone, deleted
+// by an earlier partial run or out of band, counts as deleted.
+func (p *Provider) DeleteCluster(ctx context.Context, cluster *api.Cluster) error {
+	p.logger.InfoContext(ctx, "deleting AWS cluster", "id", cluster.ID)
+
+	switch cluster.Spec.ControlPlane.Type {
+	case api.ControlPlaneSelfManaged:
+		// Implementation: Terminate the EC2 control plane instances
+	default:
+		_, err := p.clusters.DeleteCluster(ctx, &eks.DeleteClusterInput{Name: aws.String(cluster.Metadata.Name)})
+		if isNotFound(err) {
+			p.logger.InfoContext(ctx, "cluster already deleted", "id", cluster.ID)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("EKS DeleteCluster API failed: %w", err)
+		}
+	}
+
+	// Implementation: Delete the VPC and networking unless the cluster used an existing VPC
 	return nil
 }
 
+// notFoundCodes are EKS and EC2 error codes of resources that do not exist
+var notFoundCodes = map[string]bool{
+	"ResourceNotFoundException":  true,
+	"NotFoundException":          true,
+	"InvalidInstanceID.NotFound": true,
+	"InvalidVpcID.NotFound":      true,
+}
+
+// isNotFound reports whether err is an AWS API error for a resource that
+// does not exist
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && notFoundCodes[apiErr.ErrorCode()]
+}
+
 // GetCluster retrieves cluster information
 func (p *Provider) GetCluster(ctx context.Context, clusterID string) (*api.Cluster, error) {
-	p.logger.Info("getting AWS cluster", "id", clusterID)
+	p.logger.InfoContext(ctx, "getting AWS cluster", "id", clusterID)
 	return nil, nil
 }
 
-// CreateNodePool creates a worker node pool
+// CreateNodePool creates a worker node pool, or updates the existing pool
+// if one with the same name already exists in the cluster
 func (p *Provider) CreateNodePool(ctx context.Context, clusterID string, spec api.WorkerPoolSpec) (*api.NodePool, error) {
-	p.logger.Info("creating node pool",
+	if err := checkPoolSupported(spec); err != nil {
+		return nil, err
+	}
+
+	existing, err := engine.FindNodePool(ctx, p, clusterID, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		existing.Spec = spec
+		if err := p.UpdateNodePool(ctx, existing); err != nil {
+			return nil, fmt.Errorf("failed to update existing node pool: %w", err)
+		}
+		return existing, nil
+	}
+
+	p.logger.InfoContext(ctx, "creating node pool",
 		"cluster", clusterID,
 		"pool", spec.Name,
 		"instanceType", spec.InstanceType,
@@ -118,35 +419,105 @@ func (p *Provider) CreateNodePool(ctx context.Context, clusterID string, spec ap
 			Name: spec.Name,
 		},
 		Spec: spec,
-		Status: api.ResourceStatus{
-			Phase: api.PhaseProvisioning,
-		},
 	}
+	pool.Metadata.Touch(time.Now())
+	resource := api.ResourceID{Provider: p.Name(), Kind: "NodePool", ID: pool.ID, Name: pool.Metadata.Name}
+	p.setPhase(ctx, resource, &pool.Status, api.PhaseProvisioning, "node pool creation started")
+
+	tags, err := engine.PoolTags(ctx, p, clusterID, spec)
+	if err != nil {
+		return nil, err
+	}
+	pool.Status.SetProperty(api.PropertyClusterName, tags[api.TagCluster])
+	pool.Status.SetProperty(api.PropertyClusterID, clusterID)
 
 	// Create Auto Scaling Group
-	if err := p.createAutoScalingGroup(ctx, clusterID, pool); err != nil {
-		return nil, fmt.Errorf("failed to create ASG: %w", err)
+	if err := p.timeouts.RunPhase(ctx, engine.PhaseNodePool, func(ctx context.Context) error {
+		return p.createAutoScalingGroup(ctx, clusterID, pool, tags)
+	}); err != nil {
+		err = fmt.Errorf("failed to create ASG: %w", err)
+		p.setPhase(ctx, resource, &pool.Status, api.PhaseFailed, err.Error())
+		return nil, err
 	}
 
-	pool.Status.Phase = api.PhaseRunning
+	p.setPhase(ctx, resource, &pool.Status, api.PhaseRunning, "node pool created")
 	return pool, nil
 }
 
-// UpdateNodePool updates a node pool
+// UpdateNodePool updates the Auto Scaling group of a node pool. Sizes are
+// set on the group. Labels and taints become a new default version of the
+// group's launch template, so nodes launched from then on register with
+// them; nodes already running keep those they registered with. A change to
+// what instances launch from, such as the instance type, image or user data,
+// would require rolling the running instances, which is not supported, so it
+// fails before anything is changed.
 func (p *Provider) UpdateNodePool(ctx context.Context, pool *api.NodePool) error {
-	p.logger.Info("updating node pool", "id", pool.ID)
+	p.logger.InfoContext(ctx, "updating node pool", "id", pool.ID)
+	if err := checkPoolSupported(pool.Spec); err != nil {
+		return err
+	}
+
+	clusterID := pool.Status.Properties[api.PropertyClusterID]
+	if clusterID == "" {
+		return fmt.Errorf("node pool %s has no recorded cluster ID", pool.ID)
+	}
+	name := api.NodeGroupName(clusterID, pool.Spec.Name)
+
+	current, err := p.defaultTemplateData(ctx, name)
+	if err != nil {
+		return err
+	}
+	tags, err := engine.PoolTags(ctx, p, clusterID, pool.Spec)
+	if err != nil {
+		return err
+	}
+	desired := launchTemplateData(pool.Spec, tags)
+	if templateChanged(current, desired) {
+		if current == nil || templateChanged(current, launchTemplateData(withRegisteredNodeConfig(pool.Spec, aws.ToString(current.UserData)), tags)) {
+			return p.replaceInstances(name)
+		}
+
+		// Only the labels or taints changed
+		version, err := p.setDefaultTemplateData(ctx, name, desired)
+		if err != nil {
+			return err
+		}
+		p.logger.InfoContext(ctx, "updated node labels and taints; running nodes keep those they registered with",
+			"group", name, "launchTemplateVersion", version)
+	}
+
+	p.logger.InfoContext(ctx, "updating Auto Scaling group sizes", "group", name,
+		"minSize", pool.Spec.MinSize, "maxSize", pool.Spec.MaxSize, "desiredSize", pool.Spec.DesiredSize)
+	// Implementation: Call Auto Scaling UpdateAutoScalingGroup with the pool's sizes
+
+	pool.Metadata.Touch(time.Now())
 	return nil
 }
 
-// DeleteNodePool deletes a node pool
+// DeleteNodePool deletes a node pool. A pool that is already gone counts as
+// deleted.
 func (p *Provider) DeleteNodePool(ctx context.Context, poolID string) error {
-	p.logger.Info("deleting node pool", "id", poolID)
+	p.logger.InfoContext(ctx, "deleting node pool", "id", poolID)
+
+	if err := p.deleteNodePoolResources(ctx, poolID); err != nil {
+		if isNotFound(err) {
+			p.logger.InfoContext(ctx, "node pool already deleted", "id", poolID)
+			return nil
+		}
+		return fmt.Errorf("failed to delete node pool %s: %w", poolID, err)
+	}
 	return nil
 }
 
+// ListNodePools lists the node pools of a cluster
+func (p *Provider) ListNodePools(ctx context.Context, clusterID string) ([]*api.NodePool, error) {
+	p.logger.InfoContext(ctx, "listing node pools", "cluster", clusterID)
+	return nil, nil
+}
+
 // Reconcile performs reconciliation between desired and actual state
 func (p *Provider) Reconcile(ctx context.Context, desired, actual engine.State) (engine.Plan, error) {
-	p.logger.Info("reconciling AWS infrastructure")
+	p.logger.InfoContext(ctx, "reconciling AWS infrastructure")
 
 	plan := engine.Plan{
 		Actions: []engine.Action{},
@@ -159,51 +530,287 @@ func (p *Provider) Reconcile(ctx context.Context, desired, actual engine.State)
 
 // Helper functions
 
+// networkAPI is the part of the EC2 API used to create a cluster's network
+type networkAPI interface {
+	CreateVpc(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error)
+}
+
 func (p *Provider) createNetwork(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating VPC and networking", "cluster", cluster.ID)
-	// Implementation: Create VPC, subnets, internet gateway, NAT gateways, route tables
+	p.logger.InfoContext(ctx, "creating VPC and networking", "cluster", cluster.ID)
+
+	tags := api.MergeTags(api.MandatoryTags(cluster.Metadata.Name), cluster.Spec.Tags)
+	output, err := p.networks.CreateVpc(ctx, &ec2.CreateVpcInput{
+		CidrBlock: aws.String(cluster.Spec.Network.VPCCIDR),
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeVpc, Tags: ec2Tags(tags)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("EC2 CreateVpc API failed: %w", err)
+	}
+	cluster.Status.SetProperty(api.PropertyVPCID, aws.ToString(output.Vpc.VpcId))
+
+	// Implementation: Create subnets, internet gateway, NAT gateways, route tables
 	return nil
 }
 
 func (p *Provider) createEKSCluster(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating EKS cluster", "cluster", cluster.ID)
+	p.logger.InfoContext(ctx, "creating EKS cluster", "cluster", cluster.ID)
 
 	// Create EKS cluster
 	input := &eks.CreateClusterInput{
-		Name:    aws.String(cluster.Metadata.Name),
-		Version: aws.String(cluster.Spec.ControlPlane.Version),
-		ResourcesVpcConfig: &eks.VpcConfigRequest{
-			// VPC configuration from network spec
-		},
+		Name:               aws.String(cluster.Metadata.Name),
+		Version:            aws.String(cluster.Spec.ControlPlane.Version),
+		ResourcesVpcConfig: vpcConfig(cluster.Spec.Network),
+		Logging:            eksLogging(cluster.Spec.Observability),
 	}
 
-	_, err := p.eksClient.CreateCluster(ctx, input)
+	_, err := p.clusters.CreateCluster(ctx, input)
 	if err != nil {
 		return fmt.Errorf("EKS CreateCluster API failed: %w", err)
 	}
 
 	// Wait for cluster to be active
-	return p.waitForEKSCluster(ctx, cluster.Metadata.Name)
+	if err := p.waitForEKSCluster(ctx, cluster.Metadata.Name); err != nil {
+		return err
+	}
+
+	if destination := cluster.Spec.Observability.LogDestination(); destination != "" && destination != eksLogGroup(cluster.Metadata.Name) {
+		p.logger.InfoContext(ctx, "forwarding control-plane logs", "cluster", cluster.ID, "logGroup", destination)
+		// Implementation: Subscribe the destination group to the cluster's EKS log group
+	}
+
+	// The endpoint and OIDC issuer are only assigned once the cluster is active
+	output, err := p.clusters.DescribeCluster(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(cluster.Metadata.Name),
+	})
+	if err != nil {
+		return fmt.Errorf("EKS DescribeCluster API failed: %w", err)
+	}
+	setEKSProperties(&cluster.Status, output.Cluster)
+
+	return nil
+}
+
+// vpcConfig maps the network spec onto the EKS VPC configuration, including
+// the existing subnets to place the cluster in, which endpoints serve the
+// Kubernetes API and who may reach the public one
+func vpcConfig(network api.NetworkSpec) *ekstypes.VpcConfigRequest {
+	access := network.EndpointAccess()
+	config := &ekstypes.VpcConfigRequest{
+		EndpointPublicAccess:  aws.Bool(access.PublicAccess),
+		EndpointPrivateAccess: aws.Bool(access.PrivateAccess),
+	}
+	if network.ExistingVPCID != "" {
+		config.SubnetIds = append([]string{}, network.ExistingSubnetIDs...)
+	}
+	if access.PublicAccess && len(access.AuthorizedCIDRs) > 0 {
+		config.PublicAccessCidrs = append([]string{}, access.AuthorizedCIDRs...)
+	}
+	return config
+}
+
+// eksLogging maps the control-plane log types onto the EKS logging
+// configuration, or returns nil when logs are off. EKS always writes to the
+// log group named by eksLogGroup.
+func eksLogging(obs *api.ObservabilitySpec) *ekstypes.Logging {
+	logTypes := obs.LogTypes()
+	if len(logTypes) == 0 {
+		return nil
+	}
+
+	types := make([]ekstypes.LogType, len(logTypes))
+	for i, logType := range logTypes {
+		types[i] = ekstypes.LogType(logType)
+	}
+	return &ekstypes.Logging{
+		ClusterLogging: []ekstypes.LogSetup{{Enabled: aws.Bool(true), Types: types}},
+	}
+}
+
+// eksLogGroup returns the CloudWatch log group EKS sends a cluster's
+// control-plane logs to
+func eksLogGroup(clusterName string) string {
+	return "/aws/eks/" + clusterName + "/cluster"
+}
+
+// setEKSProperties records the attributes downstream tooling needs to reach
+// an EKS cluster
+func setEKSProperties(status *api.ResourceStatus, cluster *ekstypes.Cluster) {
+	if cluster == nil {
+		return
+	}
+
+	status.SetProperty(api.PropertyEndpoint, aws.ToString(cluster.Endpoint))
+	if cluster.Identity != nil && cluster.Identity.Oidc != nil {
+		status.SetProperty(api.PropertyOIDCIssuer, aws.ToString(cluster.Identity.Oidc.Issuer))
+	}
+
+	if vpc := cluster.ResourcesVpcConfig; vpc != nil {
+		status.SetProperty(api.PropertyVPCID, aws.ToString(vpc.VpcId))
+
+		groups := append([]string{}, vpc.SecurityGroupIds...)
+		if vpc.ClusterSecurityGroupId != nil {
+			groups = append(groups, *vpc.ClusterSecurityGroupId)
+		}
+		status.SetProperty(api.PropertySecurityGroupIDs, strings.Join(groups, ","))
+	}
 }
 
 func (p *Provider) createEC2ControlPlane(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating EC2 control plane", "cluster", cluster.ID)
-	// Implementation: Create EC2 instances for control plane
+	p.logger.InfoContext(ctx, "creating EC2 control plane", "cluster", cluster.ID,
+		"apiServerFlags", cluster.Spec.ControlPlane.APIServerFlags())
+	// Implementation: Create EC2 instances for control plane, bootstrapping
+	// kube-apiserver with the API server flags
+
+	secrets := engine.SecretsFrom(ctx)
+	if userData := secretsUserData(secrets); userData != "" {
+		p.logger.InfoContext(ctx, "injecting secrets", "cluster", cluster.ID, "secrets", api.SecretNames(secrets))
+		// Implementation: Pass userData as the user data of the instances
+	}
 	return nil
 }
 
-func (p *Provider) createAutoScalingGroup(ctx context.Context, clusterID string, pool *api.NodePool) error {
-	p.logger.Info("creating Auto Scaling Group", "pool", pool.ID)
-	// Implementation: Create Launch Template and ASG
+func (p *Provider) deleteNodePoolResources(ctx context.Context, poolID string) error {
+	p.logger.InfoContext(ctx, "deleting node group", "pool", poolID)
+	// Implementation: Delete the managed node group, or the Auto Scaling Group and launch template
 	return nil
 }
 
-func (p *Provider) waitForEKSCluster(ctx context.Context, clusterName string) error {
-	p.logger.Info("waiting for EKS cluster to be active", "cluster", clusterName)
-	// Implementation: Poll EKS describe-cluster until active
+func (p *Provider) createAutoScalingGroup(ctx context.Context, clusterID string, pool *api.NodePool, tags map[string]string) error {
+	p.logger.InfoContext(ctx, "creating Auto Scaling Group", "pool", pool.ID)
+
+	_, err := p.ec2Client.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
+		LaunchTemplateName: aws.String(api.NodeGroupName(clusterID, pool.Spec.Name)),
+		LaunchTemplateData: launchTemplateData(pool.Spec, tags),
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeLaunchTemplate, Tags: ec2Tags(tags)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("EC2 CreateLaunchTemplate API failed: %w", err)
+	}
+
+	// Implementation: Create ASG from the launch template
+	if policy := mixedInstancesPolicy(api.NodeGroupName(clusterID, pool.Spec.Name), pool.Spec); policy != nil {
+		p.logger.InfoContext(ctx, "launching spot instances",
+			"pool", pool.ID,
+			"instanceTypes", policy.InstanceTypes,
+			"weightedCapacity", policy.WeightedCapacity,
+			"allocationStrategy", policy.SpotAllocationStrategy,
+			"onDemandBase", policy.OnDemandBaseCapacity,
+		)
+		// Implementation: Pass as the ASG's MixedInstancesPolicy instead of the launch template alone
+	}
 	return nil
 }
 
+// mixedInstances holds the Auto Scaling MixedInstancesPolicy of a group: the
+// launch template with one instance type override per entry
+type mixedInstances struct {
+	LaunchTemplateName                  string
+	InstanceTypes                       []string
+	WeightedCapacity                    []string // Of each instance type, when weighted; the group's sizes are then in units
+	OnDemandBaseCapacity                int32
+	OnDemandPercentageAboveBaseCapacity int32
+	SpotAllocationStrategy              string
+	CapacityRebalance                   bool // Set on the group itself
+}
+
+// mixedInstancesPolicy maps a spot pool onto an all-spot mixed instances
+// policy with one override per instance type, or returns nil for an
+// on-demand pool. Auto Scaling picks capacity pools by the pool's allocation
+// strategy, by default those least likely to be interrupted at the lowest
+// price. A pool falling back to on-demand keeps its on-demand base on
+// on-demand instances and turns on capacity rebalancing, so the group
+// replaces spot instances at elevated risk of interruption ahead of time.
+// A weighted pool gives every override a weight, as Auto Scaling requires
+// once any has one; types without a weight count as one unit.
+func mixedInstancesPolicy(templateName string, spec api.WorkerPoolSpec) *mixedInstances {
+	if spec.Spot == nil || !spec.Spot.Enabled {
+		return nil
+	}
+	strategy := spec.Spot.AllocationStrategy
+	if strategy == "" {
+		strategy = api.SpotPriceCapacityOptimized
+	}
+	policy := &mixedInstances{
+		LaunchTemplateName:                  templateName,
+		InstanceTypes:                       spec.AllInstanceTypes(),
+		OnDemandBaseCapacity:                int32(spec.OnDemandBase()),
+		OnDemandPercentageAboveBaseCapacity: 0,
+		SpotAllocationStrategy:              spotAllocationStrategies[strategy],
+		CapacityRebalance:                   spec.Spot.FallbackToOnDemand,
+	}
+	if spec.Weighted() {
+		for _, instanceType := range policy.InstanceTypes {
+			policy.WeightedCapacity = append(policy.WeightedCapacity, strconv.Itoa(spec.InstanceWeight(instanceType)))
+		}
+	}
+	return policy
+}
+
+// spotAllocationStrategies maps spot allocation strategies onto their Auto
+// Scaling names
+var spotAllocationStrategies = map[string]string{
+	api.SpotLowestPrice:            "lowest-price",
+	api.SpotCapacityOptimized:      "capacity-optimized",
+	api.SpotPriceCapacityOptimized: "price-capacity-optimized",
+}
+
+// checkPoolSupported rejects worker pool features provctl cannot yet set up
+// on AWS. Warm pools are configured through the Auto Scaling API, which the
+// provider has no client for.
+func checkPoolSupported(spec api.WorkerPoolSpec) error {
+	if spec.WarmPool != nil && spec.WarmPool.Enabled {
+		return fmt.Errorf("node pool %s: warm pools: %w", spec.Name, engine.ErrNotSupported)
+	}
+	return nil
+}
+
+// launchTemplateData maps a worker pool spec onto EC2 launch template data.
+// Tags are propagated to the instances and volumes launched from it.
+func launchTemplateData(spec api.WorkerPoolSpec, tags map[string]string) *ec2types.RequestLaunchTemplateData {
+	data := &ec2types.RequestLaunchTemplateData{
+		InstanceType: ec2types.InstanceType(spec.InstanceType),
+	}
+
+	if len(tags) > 0 {
+		data.TagSpecifications = []ec2types.LaunchTemplateTagSpecificationRequest{
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: ec2Tags(tags)},
+			{ResourceType: ec2types.ResourceTypeVolume, Tags: ec2Tags(tags)},
+		}
+	}
+
+	if spec.ImageID != "" {
+		data.ImageId = aws.String(spec.ImageID)
+	}
+	if userData := nodeUserData(spec); userData != "" {
+		// EC2 expects launch template user data to be base64 encoded
+		data.UserData = aws.String(base64.StdEncoding.EncodeToString([]byte(userData)))
+	}
+	if spec.SSHKeyName != "" {
+		data.KeyName = aws.String(spec.SSHKeyName)
+	}
+
+	return data
+}
+
+// ec2Tags converts a tag map into EC2 tags sorted by key
+func ec2Tags(tags map[string]string) []ec2types.Tag {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]ec2types.Tag, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, ec2types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return result
+}
+
 func generateClusterID() string {
 	return "cluster-" + generateID()
 }
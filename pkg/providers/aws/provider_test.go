@@ -0,0 +1,461 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestNewProviderWithOptions_ExternalIDRequiresRole(t *testing.T) {
+	_, err := NewProviderWithOptions(context.Background(), Options{
+		Region:     "us-west-2",
+		ExternalID: "tenant-42",
+	}, slog.Default())
+	if err == nil {
+		t.Error("NewProviderWithOptions() expected error for external ID without role ARN")
+	}
+}
+
+func TestNewProviderWithOptions_AssumeRole(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	p, err := NewProviderWithOptions(context.Background(), Options{
+		Region:  "eu-west-1",
+		RoleARN: "arn:aws:iam::123456789012:role/provisioner",
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewProviderWithOptions() error = %v", err)
+	}
+
+	if p.region != "eu-west-1" || p.awsConfig.Region != "eu-west-1" {
+		t.Errorf("region = %s/%s, want eu-west-1", p.region, p.awsConfig.Region)
+	}
+	if !aws.IsCredentialsProvider(p.awsConfig.Credentials, &stscreds.AssumeRoleProvider{}) {
+		t.Errorf("credentials = %T, want assumed role provider", p.awsConfig.Credentials)
+	}
+}
+
+func TestVPCConfig_EndpointAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		network     api.NetworkSpec
+		wantPublic  bool
+		wantPrivate bool
+		wantCIDRs   []string
+	}{
+		{name: "default public", network: api.NetworkSpec{}, wantPublic: true},
+		{name: "private cluster", network: api.NetworkSpec{PrivateCluster: true}, wantPrivate: true},
+		{
+			name: "public allowlist and private",
+			network: api.NetworkSpec{APIServerAccess: &api.APIServerAccessSpec{
+				PublicAccess: true, PrivateAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24"},
+			}},
+			wantPublic:  true,
+			wantPrivate: true,
+			wantCIDRs:   []string{"203.0.113.0/24"},
+		},
+		{
+			name: "allowlist ignored without public endpoint",
+			network: api.NetworkSpec{APIServerAccess: &api.APIServerAccessSpec{
+				PrivateAccess: true, AuthorizedCIDRs: []string{"10.0.0.0/8"},
+			}},
+			wantPrivate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := vpcConfig(tt.network)
+			if aws.ToBool(config.EndpointPublicAccess) != tt.wantPublic || aws.ToBool(config.EndpointPrivateAccess) != tt.wantPrivate {
+				t.Errorf("endpoint access = public %v, private %v; want %v, %v",
+					aws.ToBool(config.EndpointPublicAccess), aws.ToBool(config.EndpointPrivateAccess), tt.wantPublic, tt.wantPrivate)
+			}
+			if !reflect.DeepEqual(config.PublicAccessCidrs, tt.wantCIDRs) {
+				t.Errorf("PublicAccessCidrs = %v, want %v", config.PublicAccessCidrs, tt.wantCIDRs)
+			}
+		})
+	}
+}
+
+func TestVPCConfig_ExistingSubnets(t *testing.T) {
+	subnets := []string{"subnet-1", "subnet-2"}
+	config := vpcConfig(api.NetworkSpec{ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: subnets})
+	if !reflect.DeepEqual(config.SubnetIds, subnets) {
+		t.Errorf("SubnetIds = %v, want %v", config.SubnetIds, subnets)
+	}
+	if config := vpcConfig(api.NetworkSpec{VPCCIDR: "10.0.0.0/16"}); config.SubnetIds != nil {
+		t.Errorf("SubnetIds = %v, want none for a new network", config.SubnetIds)
+	}
+}
+
+// fakeNetworks records the VPCs created. With block set, creating one waits
+// until the context is done.
+type fakeNetworks struct {
+	created []*ec2.CreateVpcInput
+	block   bool
+}
+
+func (f *fakeNetworks) CreateVpc(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error) {
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	f.created = append(f.created, params)
+	return &ec2.CreateVpcOutput{Vpc: &ec2types.Vpc{VpcId: aws.String("vpc-new")}}, nil
+}
+
+func TestProvisionCluster_ExistingVPC(t *testing.T) {
+	tests := []struct {
+		name      string
+		network   api.NetworkSpec
+		wantCIDRs []string
+		wantVPCID string
+	}{
+		{name: "new network", network: api.NetworkSpec{VPCCIDR: "10.0.0.0/16"}, wantCIDRs: []string{"10.0.0.0/16"}, wantVPCID: "vpc-new"},
+		{name: "existing VPC", network: api.NetworkSpec{ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: []string{"subnet-1"}}, wantVPCID: "vpc-0abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks := &fakeNetworks{}
+			p := &Provider{networks: networks, logger: slog.Default()}
+			cluster := &api.Cluster{
+				ID: "cluster-abc",
+				Spec: api.ClusterSpec{
+					Network:      tt.network,
+					ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneSelfManaged},
+				},
+			}
+
+			if err := p.provisionCluster(context.Background(), cluster); err != nil {
+				t.Fatalf("provisionCluster() error = %v", err)
+			}
+			var cidrs []string
+			for _, input := range networks.created {
+				cidrs = append(cidrs, aws.ToString(input.CidrBlock))
+			}
+			if !reflect.DeepEqual(cidrs, tt.wantCIDRs) {
+				t.Errorf("created VPCs = %v, want %v", cidrs, tt.wantCIDRs)
+			}
+			if got := cluster.Status.Properties[api.PropertyVPCID]; got != tt.wantVPCID {
+				t.Errorf("%s = %q, want %q", api.PropertyVPCID, got, tt.wantVPCID)
+			}
+		})
+	}
+}
+
+func TestEKSLogging(t *testing.T) {
+	if got := eksLogging(&api.ObservabilitySpec{Metrics: true}); got != nil {
+		t.Errorf("eksLogging() = %+v, want nil with logs off", got)
+	}
+
+	tests := []struct {
+		name string
+		obs  *api.ObservabilitySpec
+		want []ekstypes.LogType
+	}{
+		{name: "defaults", obs: &api.ObservabilitySpec{ControlPlaneLogs: true}, want: []ekstypes.LogType{ekstypes.LogTypeApi, ekstypes.LogTypeAudit}},
+		{
+			name: "configured types",
+			obs:  &api.ObservabilitySpec{Logging: &api.LoggingSpec{Types: []string{api.LogTypeAuthenticator, api.LogTypeControllerManager}}},
+			want: []ekstypes.LogType{ekstypes.LogTypeAuthenticator, ekstypes.LogTypeControllerManager},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logging := eksLogging(tt.obs)
+			if len(logging.ClusterLogging) != 1 || !aws.ToBool(logging.ClusterLogging[0].Enabled) {
+				t.Fatalf("ClusterLogging = %+v, want one enabled setup", logging.ClusterLogging)
+			}
+			if got := logging.ClusterLogging[0].Types; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_CreateNodePoolWarmPool(t *testing.T) {
+	templates := &fakeTemplates{}
+	p := &Provider{templates: templates, logger: slog.Default()}
+
+	spec := api.WorkerPoolSpec{Name: "general", InstanceType: "m5.large", MinSize: 2, MaxSize: 10,
+		WarmPool: &api.WarmPoolConfig{Enabled: true, MinSize: 1}}
+	if _, err := p.CreateNodePool(context.Background(), "cluster-1", spec); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("CreateNodePool() error = %v, want ErrNotSupported for a warm pool", err)
+	}
+
+	pool := &api.NodePool{ID: "nodepool-1", Spec: spec,
+		Status: api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: "cluster-1"}}}
+	if err := p.UpdateNodePool(context.Background(), pool); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("UpdateNodePool() error = %v, want ErrNotSupported for a warm pool", err)
+	}
+	if len(templates.names)+len(templates.versions) != 0 {
+		t.Error("launch templates touched for an unsupported pool")
+	}
+}
+
+func TestMixedInstancesPolicy(t *testing.T) {
+	pool := api.WorkerPoolSpec{Name: "batch", InstanceType: "m5.large", InstanceTypes: []string{"c5.large", "r5.large"}}
+	if got := mixedInstancesPolicy("c-batch", pool); got != nil {
+		t.Errorf("mixedInstancesPolicy() = %+v, want nil for an on-demand pool", got)
+	}
+
+	pool.Spot = &api.SpotConfig{Enabled: true}
+	want := &mixedInstances{
+		LaunchTemplateName:     "c-batch",
+		InstanceTypes:          []string{"m5.large", "c5.large", "r5.large"},
+		SpotAllocationStrategy: "price-capacity-optimized",
+	}
+	if got := mixedInstancesPolicy("c-batch", pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("mixedInstancesPolicy() = %+v, want %+v", got, want)
+	}
+
+	// Every spot pool gets a policy so that it follows the allocation strategy
+	pool.InstanceTypes = nil
+	pool.Spot.AllocationStrategy = api.SpotCapacityOptimized
+	want = &mixedInstances{
+		LaunchTemplateName:     "c-batch",
+		InstanceTypes:          []string{"m5.large"},
+		SpotAllocationStrategy: "capacity-optimized",
+	}
+	if got := mixedInstancesPolicy("c-batch", pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("mixedInstancesPolicy() = %+v, want %+v", got, want)
+	}
+
+	// Falling back to on-demand keeps the minimum on on-demand instances
+	pool.MinSize = 2
+	pool.Spot.FallbackToOnDemand = true
+	want.OnDemandBaseCapacity = 2
+	want.CapacityRebalance = true
+	if got := mixedInstancesPolicy("c-batch", pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("mixedInstancesPolicy() = %+v, want %+v", got, want)
+	}
+
+	// Weights apply to every override, unweighted types counting one unit
+	pool.InstanceTypes = []string{"m5.2xlarge", "m5.4xlarge"}
+	pool.InstanceWeights = map[string]int{"m5.2xlarge": 4, "m5.4xlarge": 8}
+	want.InstanceTypes = []string{"m5.large", "m5.2xlarge", "m5.4xlarge"}
+	want.WeightedCapacity = []string{"1", "4", "8"}
+	if got := mixedInstancesPolicy("c-batch", pool); !reflect.DeepEqual(got, want) {
+		t.Errorf("mixedInstancesPolicy() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEstimateProvisionTime(t *testing.T) {
+	p := &Provider{}
+	small := api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", DesiredSize: 3}},
+	}
+	large := small
+	large.Network.NATGateway = true
+	large.WorkerPools = []api.WorkerPoolSpec{
+		{Name: "general", DesiredSize: 3},
+		{Name: "batch", DesiredSize: 50},
+		{Name: "gpu", DesiredSize: 2},
+	}
+
+	smallEstimate, largeEstimate := p.EstimateProvisionTime(small), p.EstimateProvisionTime(large)
+	if smallEstimate < 10*time.Minute {
+		t.Errorf("EstimateProvisionTime(small) = %v, want at least the EKS control plane's 10m", smallEstimate)
+	}
+	if largeEstimate <= smallEstimate {
+		t.Errorf("EstimateProvisionTime(large) = %v, want more than %v", largeEstimate, smallEstimate)
+	}
+
+	existing := small
+	existing.Network = api.NetworkSpec{ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: []string{"subnet-1"}}
+	if got := p.EstimateProvisionTime(existing); got >= smallEstimate {
+		t.Errorf("EstimateProvisionTime(existing VPC) = %v, want less than %v", got, smallEstimate)
+	}
+}
+
+// recordedEvents is an event store keeping only the events recorded
+type recordedEvents struct {
+	engine.EventStore
+	events []api.Event
+}
+
+func (r *recordedEvents) RecordEvent(ctx context.Context, event api.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestProviderFactory_Events(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	events := &recordedEvents{}
+	provider, err := engine.NewProvider(context.Background(), "aws", engine.ProviderConfig{Region: "us-west-2", Events: events})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+
+	p := provider.(*Provider)
+	var status api.ResourceStatus
+	resource := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "cluster-1", Name: "prod"}
+	p.setPhase(context.Background(), resource, &status, api.PhaseProvisioning, "cluster creation started")
+	if len(events.events) != 1 || events.events[0].Resource != resource {
+		t.Errorf("recorded events = %+v, want one phase change of cluster-1", events.events)
+	}
+}
+
+// fakeCallers answers sts:GetCallerIdentity with err, or an identity
+type fakeCallers struct {
+	err error
+}
+
+func (f *fakeCallers) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sts.GetCallerIdentityOutput{Account: aws.String("123456789012"), Arn: aws.String("arn:aws:iam::123456789012:user/ci")}, nil
+}
+
+func TestProvider_Validate(t *testing.T) {
+	static := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+	})
+	missing := aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		return aws.Credentials{}, errors.New("no EC2 IMDS role found")
+	})
+
+	tests := []struct {
+		name        string
+		credentials aws.CredentialsProvider
+		stsErr      error
+		wantInvalid bool
+		wantErr     bool
+	}{
+		{name: "valid", credentials: static},
+		{name: "missing", credentials: missing, wantInvalid: true, wantErr: true},
+		{name: "invalid", credentials: static, stsErr: &smithy.GenericAPIError{Code: "InvalidClientTokenId"}, wantInvalid: true, wantErr: true},
+		{name: "expired", credentials: static, stsErr: &smithy.GenericAPIError{Code: "ExpiredToken"}, wantInvalid: true, wantErr: true},
+		{name: "other failure", credentials: static, stsErr: errors.New("connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{
+				awsConfig: aws.Config{Credentials: tt.credentials},
+				callers:   &fakeCallers{err: tt.stsErr},
+				logger:    slog.Default(),
+			}
+			err := p.Validate(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, engine.ErrInvalidCredentials); got != tt.wantInvalid {
+				t.Errorf("Validate() error = %v, want ErrInvalidCredentials %v", err, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestProvider_DeleteCluster(t *testing.T) {
+	tests := []struct {
+		name      string
+		deleteErr error
+		wantErr   bool
+	}{
+		{name: "deleted"},
+		{name: "already gone", deleteErr: &ekstypes.ResourceNotFoundException{Message: aws.String("No cluster found for name: prod")}},
+		{name: "denied", deleteErr: &smithy.GenericAPIError{Code: "AccessDeniedException"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := &fakeClusters{deleteErr: tt.deleteErr}
+			p := &Provider{clusters: clusters, logger: slog.Default()}
+			cluster := &api.Cluster{
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged}},
+			}
+
+			err := p.DeleteCluster(context.Background(), cluster)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if want := []string{"prod"}; !reflect.DeepEqual(clusters.deleted, want) {
+				t.Errorf("deleted clusters = %v, want %v", clusters.deleted, want)
+			}
+		})
+	}
+}
+
+func TestProvider_DeleteNotFound(t *testing.T) {
+	ctx := context.Background()
+	p := &Provider{logger: slog.Default()}
+
+	if err := p.DeleteNodePool(ctx, "nodepool-gone"); err != nil {
+		t.Errorf("DeleteNodePool() of a missing pool error = %v, want nil", err)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "EKS resource not found", err: &ekstypes.ResourceNotFoundException{Message: aws.String("No cluster found")}, want: true},
+		{name: "wrapped", err: fmt.Errorf("EKS DeleteCluster API failed: %w", &smithy.GenericAPIError{Code: "InvalidVpcID.NotFound"}), want: true},
+		{name: "other API error", err: &smithy.GenericAPIError{Code: "AccessDeniedException"}},
+		{name: "plain error", err: errors.New("not found")},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNotFound(tt.err); got != tt.want {
+				t.Errorf("isNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_CreateClusterRedactsSpecLog(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/cluster-workloads"
+
+	var logs bytes.Buffer
+	p := &Provider{logger: slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	spec := api.ClusterSpec{
+		Network: api.NetworkSpec{ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: []string{"subnet-1"}},
+		ControlPlane: api.ControlPlaneSpec{
+			Type:     api.ControlPlaneSelfManaged,
+			Identity: &api.IdentitySpec{Type: "oidc", RoleARN: roleARN},
+		},
+		Config: map[string]interface{}{"name": "prod"},
+	}
+
+	cluster, err := p.CreateCluster(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+	if got := cluster.Spec.ControlPlane.Identity.RoleARN; got != roleARN {
+		t.Errorf("cluster role ARN = %q, want %q provisioned", got, roleARN)
+	}
+	if strings.Contains(logs.String(), roleARN) || !strings.Contains(logs.String(), `"roleArn":"***"`) {
+		t.Errorf("logs = %s, want the role ARN redacted", logs.String())
+	}
+}
@@ -0,0 +1,33 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// DefaultRateLimit keeps provctl under the API rate limits of an account:
+// EKS throttles describe calls at around 10 per second, shared with every
+// other client in the account and region
+var DefaultRateLimit = engine.RateLimit{RequestsPerSecond: 10, Burst: 20}
+
+// withRateLimit paces every call of clients built from an aws.Config through
+// limiter. It runs once per operation, before the SDK's own retries, which
+// back off when AWS throttles anyway.
+func withRateLimit(limiter *rate.Limiter) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("RateLimit",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (
+				middleware.InitializeOutput, middleware.Metadata, error,
+			) {
+				if err := limiter.Wait(ctx); err != nil {
+					return middleware.InitializeOutput{}, middleware.Metadata{}, fmt.Errorf("waiting for rate limit: %w", err)
+				}
+				return next.HandleInitialize(ctx, in)
+			}), middleware.Before)
+	}
+}
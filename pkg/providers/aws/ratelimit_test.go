@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// countingHTTPClient answers every request with an empty JSON object
+type countingHTTPClient struct {
+	requests atomic.Int32
+}
+
+func (c *countingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+func rateLimitedEKSClient(httpClient aws.HTTPClient, limit engine.RateLimit) *eks.Client {
+	return eks.NewFromConfig(aws.Config{
+		Region:      "us-west-2",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		HTTPClient:  httpClient,
+		APIOptions:  []func(*middleware.Stack) error{withRateLimit(limit.Limiter(DefaultRateLimit))},
+	})
+}
+
+func TestWithRateLimit_PacesBursts(t *testing.T) {
+	httpClient := &countingHTTPClient{}
+	client := rateLimitedEKSClient(httpClient, engine.RateLimit{RequestsPerSecond: 20, Burst: 2})
+
+	// Concurrent operations share the budget: 2 calls go at once and the
+	// other 4 follow at 20 per second
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.ListClusters(context.Background(), &eks.ListClustersInput{}); err != nil {
+				t.Errorf("ListClusters() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("6 calls took %v, want them paced over about 200ms", elapsed)
+	}
+	if n := httpClient.requests.Load(); n != 6 {
+		t.Errorf("sent %d requests, want 6", n)
+	}
+}
+
+func TestWithRateLimit_Canceled(t *testing.T) {
+	httpClient := &countingHTTPClient{}
+	client := rateLimitedEKSClient(httpClient, engine.RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+
+	if _, err := client.ListClusters(context.Background(), &eks.ListClustersInput{}); err != nil {
+		t.Fatalf("ListClusters() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.ListClusters(ctx, &eks.ListClustersInput{})
+	if err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("ListClusters() error = %v, want the rate limit wait to fail", err)
+	}
+	if n := httpClient.requests.Load(); n != 1 {
+		t.Errorf("sent %d requests, want only the first", n)
+	}
+}
@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// nodeSecretsDir is where bootstrap writes the cluster's secrets on nodes
+const nodeSecretsDir = "/etc/provctl/secrets"
+
+// secretsUserData returns base64 EC2 user data, a cloud-config document
+// writing each secret to a root-only file under nodeSecretsDir for node
+// bootstrap and add-ons to read, or "" without secrets. The values are
+// encoded rather than embedded so any value survives the YAML.
+func secretsUserData(secrets map[string]api.Secret) string {
+	if len(secrets) == 0 {
+		return ""
+	}
+
+	var doc strings.Builder
+	doc.WriteString("#cloud-config\nwrite_files:\n")
+	for _, name := range api.SecretNames(secrets) {
+		doc.WriteString("  - path: " + nodeSecretsDir + "/" + name + "\n")
+		doc.WriteString("    permissions: \"0600\"\n")
+		doc.WriteString("    encoding: b64\n")
+		doc.WriteString("    content: " + base64.StdEncoding.EncodeToString([]byte(secrets[name].Reveal())) + "\n")
+	}
+	return base64.StdEncoding.EncodeToString([]byte(doc.String()))
+}
@@ -0,0 +1,36 @@
+package aws
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestSecretsUserData(t *testing.T) {
+	if got := secretsUserData(nil); got != "" {
+		t.Errorf("secretsUserData(nil) = %q, want none", got)
+	}
+
+	encoded := secretsUserData(map[string]api.Secret{"registry-token": "s3cr3t: \"quoted\""})
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("user data is not base64: %v", err)
+	}
+
+	userData := string(decoded)
+	for _, want := range []string{
+		"#cloud-config\n",
+		"path: /etc/provctl/secrets/registry-token\n",
+		`permissions: "0600"`,
+		"content: " + base64.StdEncoding.EncodeToString([]byte("s3cr3t: \"quoted\"")) + "\n",
+	} {
+		if !strings.Contains(userData, want) {
+			t.Errorf("user data = %q, want it to contain %q", userData, want)
+		}
+	}
+	if strings.Contains(userData, "s3cr3t") {
+		t.Errorf("user data = %q, want the value encoded", userData)
+	}
+}
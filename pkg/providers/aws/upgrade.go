@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// addonAPI is the part of the EKS API used to check add-ons against a
+// Kubernetes version
+type addonAPI interface {
+	ListAddons(ctx context.Context, params *eks.ListAddonsInput, optFns ...func(*eks.Options)) (*eks.ListAddonsOutput, error)
+	DescribeAddon(ctx context.Context, params *eks.DescribeAddonInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonOutput, error)
+	DescribeAddonVersions(ctx context.Context, params *eks.DescribeAddonVersionsInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonVersionsOutput, error)
+}
+
+var _ engine.UpgradePreflighter = (*Provider)(nil)
+
+// UpgradePreflight checks the EKS add-ons of a cluster against the target
+// Kubernetes version. An add-on with no version for the target blocks the
+// upgrade; one whose installed version does not support it is a warning,
+// since it can be updated right after the control plane.
+//
+// EKS upgrade insights, which report deprecated API usage, are not in the
+// EKS API version this provider is built with, so deprecated APIs are only
+// covered by the engine's generic checks.
+func (p *Provider) UpgradePreflight(ctx context.Context, cluster *api.Cluster, targetVersion string) (engine.PreflightResult, error) {
+	result := engine.PreflightResult{CurrentVersion: cluster.Spec.ControlPlane.Version, TargetVersion: targetVersion}
+	if cluster.Spec.ControlPlane.Type == api.ControlPlaneSelfManaged {
+		// Clusters running their own control plane have no EKS add-ons
+		return result, nil
+	}
+
+	issues, err := p.addonIssues(ctx, cluster.Metadata.Name, targetVersion)
+	if err != nil {
+		return result, err
+	}
+	result.Issues = issues
+	return result, nil
+}
+
+// addonIssues returns the preflight issues of the add-ons installed in an
+// EKS cluster for an upgrade to targetVersion
+func (p *Provider) addonIssues(ctx context.Context, clusterName, targetVersion string) ([]engine.PreflightIssue, error) {
+	var issues []engine.PreflightIssue
+
+	addons := eks.NewListAddonsPaginator(p.addons, &eks.ListAddonsInput{ClusterName: aws.String(clusterName)})
+	for addons.HasMorePages() {
+		page, err := addons.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("EKS ListAddons API failed: %w", err)
+		}
+
+		for _, name := range page.Addons {
+			installed, err := p.addons.DescribeAddon(ctx, &eks.DescribeAddonInput{
+				ClusterName: aws.String(clusterName),
+				AddonName:   aws.String(name),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("EKS DescribeAddon API failed: %w", err)
+			}
+			compatible, defaultVersion, err := p.compatibleAddonVersions(ctx, name, targetVersion)
+			if err != nil {
+				return nil, err
+			}
+
+			var version string
+			if installed.Addon != nil {
+				version = aws.ToString(installed.Addon.AddonVersion)
+			}
+			switch {
+			case len(compatible) == 0:
+				issues = append(issues, engine.PreflightIssue{
+					Severity:  engine.PreflightBlocking,
+					Category:  engine.PreflightAddon,
+					Summary:   fmt.Sprintf("add-on %s has no version for Kubernetes %s; remove it before upgrading", name, targetVersion),
+					Resources: []string{name},
+				})
+			case !compatible[version]:
+				update := "update it"
+				if defaultVersion != "" {
+					update += " to " + defaultVersion
+				}
+				issues = append(issues, engine.PreflightIssue{
+					Severity:  engine.PreflightWarning,
+					Category:  engine.PreflightAddon,
+					Summary:   fmt.Sprintf("add-on %s %s does not support Kubernetes %s; %s after the control plane", name, version, targetVersion, update),
+					Resources: []string{name},
+				})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// compatibleAddonVersions returns the versions of an add-on that support a
+// Kubernetes version, and the one EKS installs by default
+func (p *Provider) compatibleAddonVersions(ctx context.Context, addon, kubernetesVersion string) (map[string]bool, string, error) {
+	compatible := make(map[string]bool)
+	var defaultVersion string
+
+	versions := eks.NewDescribeAddonVersionsPaginator(p.addons, &eks.DescribeAddonVersionsInput{
+		AddonName:         aws.String(addon),
+		KubernetesVersion: aws.String(kubernetesVersion),
+	})
+	for versions.HasMorePages() {
+		page, err := versions.NextPage(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("EKS DescribeAddonVersions API failed: %w", err)
+		}
+		for _, info := range page.Addons {
+			for _, version := range info.AddonVersions {
+				for _, compatibility := range version.Compatibilities {
+					if aws.ToString(compatibility.ClusterVersion) != kubernetesVersion {
+						continue
+					}
+					compatible[aws.ToString(version.AddonVersion)] = true
+					if compatibility.DefaultVersion {
+						defaultVersion = aws.ToString(version.AddonVersion)
+					}
+				}
+			}
+		}
+	}
+	return compatible, defaultVersion, nil
+}
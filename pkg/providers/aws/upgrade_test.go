@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// fakeAddons serves installed add-on versions and the add-on versions
+// compatible with each Kubernetes version
+type fakeAddons struct {
+	installed  map[string]string
+	compatible map[string]map[string][]string // Kubernetes version -> add-on -> versions, the first the default
+}
+
+func (f *fakeAddons) ListAddons(ctx context.Context, params *eks.ListAddonsInput, optFns ...func(*eks.Options)) (*eks.ListAddonsOutput, error) {
+	output := &eks.ListAddonsOutput{}
+	for name := range f.installed {
+		output.Addons = append(output.Addons, name)
+	}
+	return output, nil
+}
+
+func (f *fakeAddons) DescribeAddon(ctx context.Context, params *eks.DescribeAddonInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonOutput, error) {
+	name := aws.ToString(params.AddonName)
+	return &eks.DescribeAddonOutput{Addon: &ekstypes.Addon{
+		AddonName:    params.AddonName,
+		AddonVersion: aws.String(f.installed[name]),
+	}}, nil
+}
+
+func (f *fakeAddons) DescribeAddonVersions(ctx context.Context, params *eks.DescribeAddonVersionsInput, optFns ...func(*eks.Options)) (*eks.DescribeAddonVersionsOutput, error) {
+	kubernetesVersion := aws.ToString(params.KubernetesVersion)
+	versions := f.compatible[kubernetesVersion][aws.ToString(params.AddonName)]
+	if len(versions) == 0 {
+		return &eks.DescribeAddonVersionsOutput{}, nil
+	}
+
+	info := ekstypes.AddonInfo{AddonName: params.AddonName}
+	for i, version := range versions {
+		info.AddonVersions = append(info.AddonVersions, ekstypes.AddonVersionInfo{
+			AddonVersion: aws.String(version),
+			Compatibilities: []ekstypes.Compatibility{
+				{ClusterVersion: aws.String(kubernetesVersion), DefaultVersion: i == 0},
+			},
+		})
+	}
+	return &eks.DescribeAddonVersionsOutput{Addons: []ekstypes.AddonInfo{info}}, nil
+}
+
+func TestProvider_AddonIssues(t *testing.T) {
+	addons := &fakeAddons{
+		installed: map[string]string{
+			"coredns":    "v1.11.1-eksbuild.4",
+			"kube-proxy": "v1.28.2-eksbuild.2",
+			"legacy":     "v0.1.0",
+		},
+		compatible: map[string]map[string][]string{"1.29": {
+			"coredns":    {"v1.11.1-eksbuild.9", "v1.11.1-eksbuild.4"},
+			"kube-proxy": {"v1.29.0-eksbuild.1"},
+		}},
+	}
+	p := &Provider{addons: addons, logger: slog.Default()}
+
+	issues, err := p.addonIssues(context.Background(), "prod", "1.29")
+	if err != nil {
+		t.Fatalf("addonIssues() error = %v", err)
+	}
+
+	byAddon := make(map[string]engine.PreflightIssue)
+	for _, issue := range issues {
+		byAddon[issue.Resources[0]] = issue
+	}
+	if _, ok := byAddon["coredns"]; ok || len(issues) != 2 {
+		t.Fatalf("addonIssues() = %v, want issues for kube-proxy and legacy only", issues)
+	}
+	if issue := byAddon["kube-proxy"]; issue.Severity != engine.PreflightWarning || !strings.Contains(issue.Summary, "update it to v1.29.0-eksbuild.1") {
+		t.Errorf("kube-proxy issue = %+v, want a warning to update to the default version", issue)
+	}
+	if issue := byAddon["legacy"]; issue.Severity != engine.PreflightBlocking {
+		t.Errorf("legacy issue = %+v, want it blocking without a compatible version", issue)
+	}
+}
+
+func TestProvider_UpgradePreflight(t *testing.T) {
+	addons := &fakeAddons{installed: map[string]string{"legacy": "v0.1.0"}}
+	p := &Provider{addons: addons, logger: slog.Default()}
+	cluster := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.28"}},
+	}
+
+	result, err := p.UpgradePreflight(context.Background(), cluster, "1.29")
+	if err != nil {
+		t.Fatalf("UpgradePreflight() error = %v", err)
+	}
+	if result.CurrentVersion != "1.28" || len(result.Blocking()) != 1 {
+		t.Errorf("UpgradePreflight() = %+v, want current version 1.28 and the legacy add-on blocking", result)
+	}
+
+	// Self-managed control planes have no EKS add-ons to check
+	cluster.Spec.ControlPlane.Type = api.ControlPlaneSelfManaged
+	if result, err := p.UpgradePreflight(context.Background(), cluster, "1.29"); err != nil || len(result.Issues) != 0 {
+		t.Errorf("UpgradePreflight(self-managed) = %+v, %v, want no issues", result, err)
+	}
+}
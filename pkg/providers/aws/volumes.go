@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Tags the EBS CSI driver sets on the volumes it provisions. It always sets
+// tagCSICluster, which does not name the cluster. The cluster tag is only set
+// when the driver runs with the cluster's name as its k8s-tag-cluster-id.
+const (
+	tagPVName         = "kubernetes.io/created-for/pv/name"
+	tagClusterOwnedBy = "kubernetes.io/cluster/"
+	tagCSICluster     = "ebs.csi.aws.com/cluster"
+)
+
+// maxFilterValues is the most values EC2 accepts in one filter
+const maxFilterValues = 200
+
+// volumeAPI is the part of the EC2 API used to snapshot persistent volumes
+type volumeAPI interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+	CreateSnapshot(ctx context.Context, params *ec2.CreateSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.CreateSnapshotOutput, error)
+}
+
+var _ engine.VolumeSnapshotter = (*Provider)(nil)
+
+// SnapshotVolumes snapshots the EBS volumes the CSI driver provisioned for
+// the cluster's persistent volumes, skipping those in taken. The snapshots
+// carry the cluster's tags and the name of the volume's PV, and complete in
+// the background.
+func (p *Provider) SnapshotVolumes(ctx context.Context, cluster *api.Cluster, taken map[string]string) (map[string]string, error) {
+	name := cluster.Metadata.Name
+	volumes, err := p.clusterVolumes(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string]string)
+	for _, volume := range volumes {
+		volumeID := aws.ToString(volume.VolumeId)
+		if snapshotID, ok := taken[volumeID]; ok {
+			p.logger.InfoContext(ctx, "volume already snapshotted", "volume", volumeID, "snapshot", snapshotID)
+			continue
+		}
+
+		tags := api.MergeTags(api.MandatoryTags(name), cluster.Spec.Tags, map[string]string{
+			tagPVName: volumeTag(volume, tagPVName),
+		})
+		snapshot, err := p.volumes.CreateSnapshot(ctx, &ec2.CreateSnapshotInput{
+			VolumeId:    volume.VolumeId,
+			Description: aws.String(fmt.Sprintf("%s before decommissioning cluster %s", volumeTag(volume, tagPVName), name)),
+			TagSpecifications: []ec2types.TagSpecification{
+				{ResourceType: ec2types.ResourceTypeSnapshot, Tags: ec2Tags(tags)},
+			},
+		})
+		if err != nil {
+			return snapshots, fmt.Errorf("EC2 CreateSnapshot API failed for volume %s: %w", volumeID, err)
+		}
+		p.logger.InfoContext(ctx, "snapshotting volume", "volume", volumeID, "snapshot", aws.ToString(snapshot.SnapshotId))
+		snapshots[volumeID] = aws.ToString(snapshot.SnapshotId)
+	}
+	return snapshots, nil
+}
+
+// clusterVolumes returns the EBS volumes of a cluster's persistent volumes:
+// those the CSI driver tagged as owned by the cluster, and those it
+// provisioned with only its default tags that are attached to the cluster's
+// nodes. A volume with only the default tags that no node has attached cannot
+// be told apart from another cluster's and is left out.
+func (p *Provider) clusterVolumes(ctx context.Context, name string) ([]ec2types.Volume, error) {
+	volumes, err := p.describeVolumes(ctx, []ec2types.Filter{
+		{Name: aws.String("tag:" + tagClusterOwnedBy + name), Values: []string{"owned"}},
+		{Name: aws.String("tag-key"), Values: []string{tagPVName}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := p.clusterInstances(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(instances); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(instances))
+		attached, err := p.describeVolumes(ctx, []ec2types.Filter{
+			{Name: aws.String("tag:" + tagCSICluster), Values: []string{"true"}},
+			{Name: aws.String("attachment.instance-id"), Values: instances[start:end]},
+		})
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, attached...)
+	}
+
+	seen := make(map[string]bool, len(volumes))
+	unique := volumes[:0]
+	for _, volume := range volumes {
+		if id := aws.ToString(volume.VolumeId); !seen[id] {
+			seen[id] = true
+			unique = append(unique, volume)
+		}
+	}
+	return unique, nil
+}
+
+// describeVolumes returns every volume matching filters
+func (p *Provider) describeVolumes(ctx context.Context, filters []ec2types.Filter) ([]ec2types.Volume, error) {
+	input := &ec2.DescribeVolumesInput{Filters: filters}
+	var volumes []ec2types.Volume
+	for {
+		output, err := p.volumes.DescribeVolumes(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("EC2 DescribeVolumes API failed: %w", err)
+		}
+		volumes = append(volumes, output.Volumes...)
+		if output.NextToken == nil {
+			return volumes, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// clusterInstances returns the IDs of the cluster's nodes, which carry the
+// tags of their launch template
+func (p *Provider) clusterInstances(ctx context.Context, name string) ([]string, error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String("tag:" + api.TagCluster), Values: []string{name}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+		},
+	}
+	var instances []string
+	for {
+		output, err := p.volumes.DescribeInstances(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("EC2 DescribeInstances API failed: %w", err)
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				instances = append(instances, aws.ToString(instance.InstanceId))
+			}
+		}
+		if output.NextToken == nil {
+			return instances, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// volumeTag returns the value of a volume's tag, or an empty string
+func volumeTag(volume ec2types.Volume, key string) string {
+	for _, tag := range volume.Tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// fakeVolumes serves the volumes matching the cluster's ownership tag in
+// pages, the volumes with the CSI driver's default tags by the instance they
+// are attached to, and records the snapshots requested
+type fakeVolumes struct {
+	pages     [][]ec2types.Volume
+	attached  map[string][]ec2types.Volume
+	instances []string
+	filters   [][]ec2types.Filter
+	snapshots []*ec2.CreateSnapshotInput
+	err       error
+}
+
+func (f *fakeVolumes) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	var instances []ec2types.Instance
+	for _, id := range f.instances {
+		instances = append(instances, ec2types.Instance{InstanceId: aws.String(id)})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{Instances: instances}}}, nil
+}
+
+func (f *fakeVolumes) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	f.filters = append(f.filters, params.Filters)
+	if aws.ToString(params.Filters[0].Name) == "tag:"+tagCSICluster {
+		var volumes []ec2types.Volume
+		for _, instance := range params.Filters[1].Values {
+			volumes = append(volumes, f.attached[instance]...)
+		}
+		return &ec2.DescribeVolumesOutput{Volumes: volumes}, nil
+	}
+
+	page := 0
+	if params.NextToken != nil {
+		page = 1
+	}
+	output := &ec2.DescribeVolumesOutput{Volumes: f.pages[page]}
+	if page+1 < len(f.pages) {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func (f *fakeVolumes) CreateSnapshot(ctx context.Context, params *ec2.CreateSnapshotInput, optFns ...func(*ec2.Options)) (*ec2.CreateSnapshotOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.snapshots = append(f.snapshots, params)
+	return &ec2.CreateSnapshotOutput{SnapshotId: aws.String("snap-" + aws.ToString(params.VolumeId))}, nil
+}
+
+func TestProvider_SnapshotVolumes(t *testing.T) {
+	volume := func(id, pv string) ec2types.Volume {
+		return ec2types.Volume{VolumeId: aws.String(id), Tags: []ec2types.Tag{{Key: aws.String(tagPVName), Value: aws.String(pv)}}}
+	}
+	volumes := &fakeVolumes{
+		pages: [][]ec2types.Volume{
+			{volume("vol-1", "pvc-data")},
+			{volume("vol-2", "pvc-logs")},
+		},
+		instances: []string{"i-1", "i-2"},
+		attached: map[string][]ec2types.Volume{
+			"i-1": {volume("vol-2", "pvc-logs")}, // Also tagged as owned by the cluster
+			"i-2": {volume("vol-3", "pvc-cache")},
+		},
+	}
+	p := &Provider{volumes: volumes, logger: slog.Default()}
+	cluster := &api.Cluster{
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{Tags: map[string]string{"team": "platform"}},
+	}
+
+	snapshots, err := p.SnapshotVolumes(context.Background(), cluster, nil)
+	if err != nil {
+		t.Fatalf("SnapshotVolumes() error = %v", err)
+	}
+	want := map[string]string{"vol-1": "snap-vol-1", "vol-2": "snap-vol-2", "vol-3": "snap-vol-3"}
+	if !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("SnapshotVolumes() = %v, want %v", snapshots, want)
+	}
+	if aws.ToString(volumes.filters[0][0].Name) != "tag:kubernetes.io/cluster/prod" {
+		t.Errorf("volumes filtered by %s, want the cluster's ownership tag", aws.ToString(volumes.filters[0][0].Name))
+	}
+
+	tags := map[string]string{}
+	for _, tag := range volumes.snapshots[1].TagSpecifications[0].Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	wantTags := map[string]string{api.TagManagedBy: "provctl", api.TagCluster: "prod", "team": "platform", tagPVName: "pvc-logs"}
+	if !reflect.DeepEqual(tags, wantTags) {
+		t.Errorf("snapshot tags = %v, want %v", tags, wantTags)
+	}
+
+	// A rerun snapshots only the volumes an earlier attempt did not
+	volumes.snapshots = nil
+	snapshots, err = p.SnapshotVolumes(context.Background(), cluster, map[string]string{"vol-1": "snap-1", "vol-3": "snap-3"})
+	if err != nil {
+		t.Fatalf("SnapshotVolumes() error = %v", err)
+	}
+	if want := map[string]string{"vol-2": "snap-vol-2"}; !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("SnapshotVolumes() after a partial attempt = %v, want %v", snapshots, want)
+	}
+
+	p.volumes = &fakeVolumes{pages: [][]ec2types.Volume{{volume("vol-1", "pvc-data")}}, err: errors.New("throttled")}
+	if _, err := p.SnapshotVolumes(context.Background(), cluster, nil); err == nil {
+		t.Error("SnapshotVolumes() error = nil, want the CreateSnapshot error")
+	}
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// eksPollInterval is how often a waiter describes the resource it waits on.
+// EKS control planes take ten to fifteen minutes to create.
+const eksPollInterval = 15 * time.Second
+
+// clusterAPI is the part of the EKS API used to create, wait on and delete
+// clusters
+type clusterAPI interface {
+	CreateCluster(ctx context.Context, params *eks.CreateClusterInput, optFns ...func(*eks.Options)) (*eks.CreateClusterOutput, error)
+	DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error)
+	DeleteCluster(ctx context.Context, params *eks.DeleteClusterInput, optFns ...func(*eks.Options)) (*eks.DeleteClusterOutput, error)
+}
+
+// waitForEKSCluster polls a cluster until it is active, logging its status
+// every progress interval. EKS reports no percent complete.
+func (p *Provider) waitForEKSCluster(ctx context.Context, clusterName string) error {
+	p.logger.InfoContext(ctx, "waiting for EKS cluster to be active", "cluster", clusterName)
+
+	interval := p.pollInterval
+	if interval <= 0 {
+		interval = eksPollInterval
+	}
+	progress := engine.NewProgress(p.logger, p.progressInterval, "create EKS cluster", "cluster", clusterName)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		output, err := p.clusters.DescribeCluster(ctx, &eks.DescribeClusterInput{
+			Name: aws.String(clusterName),
+		})
+		if err != nil {
+			return fmt.Errorf("EKS DescribeCluster API failed: %w", err)
+		}
+
+		status := output.Cluster.Status
+		switch status {
+		case ekstypes.ClusterStatusActive:
+			return nil
+		case ekstypes.ClusterStatusFailed, ekstypes.ClusterStatusDeleting:
+			return fmt.Errorf("EKS cluster %s is %s instead of becoming active", clusterName, status)
+		}
+		progress.Report(ctx, string(status), -1)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for EKS cluster %s: %w", clusterName, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
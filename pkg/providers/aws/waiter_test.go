@@ -0,0 +1,165 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// fakeClusters reports each status in turn, then the last one forever.
+// CreateCluster and DeleteCluster record the names they were asked to create
+// and delete; DeleteCluster fails with deleteErr.
+type fakeClusters struct {
+	statuses  []ekstypes.ClusterStatus
+	calls     int
+	created   []string
+	deleted   []string
+	deleteErr error
+}
+
+func (f *fakeClusters) CreateCluster(ctx context.Context, params *eks.CreateClusterInput, optFns ...func(*eks.Options)) (*eks.CreateClusterOutput, error) {
+	f.created = append(f.created, aws.ToString(params.Name))
+	return &eks.CreateClusterOutput{}, nil
+}
+
+func (f *fakeClusters) DescribeCluster(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+	status := f.statuses[min(f.calls, len(f.statuses)-1)]
+	f.calls++
+	return &eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{Name: params.Name, Status: status}}, nil
+}
+
+func (f *fakeClusters) DeleteCluster(ctx context.Context, params *eks.DeleteClusterInput, optFns ...func(*eks.Options)) (*eks.DeleteClusterOutput, error) {
+	f.deleted = append(f.deleted, aws.ToString(params.Name))
+	if f.deleteErr != nil {
+		return nil, f.deleteErr
+	}
+	return &eks.DeleteClusterOutput{}, nil
+}
+
+func TestWaitForEKSCluster(t *testing.T) {
+	creating := []ekstypes.ClusterStatus{
+		ekstypes.ClusterStatusCreating,
+		ekstypes.ClusterStatusCreating,
+		ekstypes.ClusterStatusCreating,
+		ekstypes.ClusterStatusActive,
+	}
+
+	tests := []struct {
+		name             string
+		statuses         []ekstypes.ClusterStatus
+		progressInterval time.Duration
+		wantLogs         int
+		wantErr          bool
+	}{
+		{name: "progress logged every poll", statuses: creating, progressInterval: time.Nanosecond, wantLogs: 3},
+		{name: "progress throttled", statuses: creating, progressInterval: time.Hour, wantLogs: 0},
+		{name: "already active", statuses: creating[3:], progressInterval: time.Nanosecond, wantLogs: 0},
+		{name: "creation failed", statuses: []ekstypes.ClusterStatus{ekstypes.ClusterStatusCreating, ekstypes.ClusterStatusFailed}, progressInterval: time.Nanosecond, wantLogs: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			clusters := &fakeClusters{statuses: tt.statuses}
+			p := &Provider{
+				clusters:         clusters,
+				logger:           slog.New(slog.NewTextHandler(&logs, nil)),
+				progressInterval: tt.progressInterval,
+				pollInterval:     time.Millisecond,
+			}
+
+			err := p.waitForEKSCluster(context.Background(), "prod")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waitForEKSCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := strings.Count(logs.String(), "operation in progress"); got != tt.wantLogs {
+				t.Errorf("logged progress %d times, want %d:\n%s", got, tt.wantLogs, logs.String())
+			}
+			if tt.wantLogs > 0 && !strings.Contains(logs.String(), "status=CREATING") {
+				t.Errorf("progress logs = %q, want the cluster status", logs.String())
+			}
+		})
+	}
+}
+
+func TestWaitForEKSCluster_Canceled(t *testing.T) {
+	p := &Provider{
+		clusters:     &fakeClusters{statuses: []ekstypes.ClusterStatus{ekstypes.ClusterStatusCreating}},
+		logger:       slog.Default(),
+		pollInterval: time.Hour,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := p.waitForEKSCluster(ctx, "prod"); err == nil {
+		t.Error("waitForEKSCluster() error = nil, want the context's error")
+	}
+}
+
+func TestProvisionCluster_PhaseTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		networks    *fakeNetworks
+		statuses    []ekstypes.ClusterStatus
+		wantPhase   string
+		wantCreated int
+	}{
+		{
+			name:      "stuck network",
+			networks:  &fakeNetworks{block: true},
+			statuses:  []ekstypes.ClusterStatus{ekstypes.ClusterStatusActive},
+			wantPhase: "network phase",
+		},
+		{
+			name:        "stuck control plane",
+			networks:    &fakeNetworks{},
+			statuses:    []ekstypes.ClusterStatus{ekstypes.ClusterStatusCreating},
+			wantPhase:   "control plane phase",
+			wantCreated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := &fakeClusters{statuses: tt.statuses}
+			p := &Provider{
+				clusters:     clusters,
+				networks:     tt.networks,
+				logger:       slog.Default(),
+				pollInterval: time.Millisecond,
+				timeouts:     engine.PhaseTimeouts{Network: 20 * time.Millisecond, ControlPlane: 20 * time.Millisecond},
+			}
+			cluster := &api.Cluster{
+				ID:       "cluster-abc",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec: api.ClusterSpec{
+					Network:      api.NetworkSpec{VPCCIDR: "10.0.0.0/16"},
+					ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.29"},
+				},
+			}
+
+			err := p.provisionCluster(context.Background(), cluster)
+			if !errors.Is(err, engine.ErrPhaseTimeout) {
+				t.Fatalf("provisionCluster() error = %v, want ErrPhaseTimeout", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantPhase) {
+				t.Errorf("provisionCluster() error = %q, want it to name the %s", err, tt.wantPhase)
+			}
+			if len(clusters.created) != tt.wantCreated {
+				t.Errorf("created EKS clusters %v, want %d", clusters.created, tt.wantCreated)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Environment variables EKS injects into pods whose service account is
+// annotated with an IAM role (IRSA)
+const (
+	envWebIdentityTokenFile = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	envRoleARN              = "AWS_ROLE_ARN"
+	envRoleSessionName      = "AWS_ROLE_SESSION_NAME"
+)
+
+// webIdentity is the resolved IRSA configuration
+type webIdentity struct {
+	TokenFile   string
+	RoleARN     string
+	SessionName string
+}
+
+// resolveWebIdentity returns the web identity to authenticate with, from
+// the options or else the IRSA environment, or nil when there is none. An
+// explicit profile takes precedence over the environment.
+func resolveWebIdentity(opts Options) (*webIdentity, error) {
+	identity := webIdentity{
+		TokenFile:   opts.WebIdentityTokenFile,
+		RoleARN:     opts.WebIdentityRoleARN,
+		SessionName: os.Getenv(envRoleSessionName),
+	}
+	if identity.TokenFile == "" && identity.RoleARN == "" {
+		if opts.Profile != "" {
+			return nil, nil
+		}
+		identity.TokenFile = os.Getenv(envWebIdentityTokenFile)
+		identity.RoleARN = os.Getenv(envRoleARN)
+	}
+
+	switch {
+	case identity.TokenFile == "" && identity.RoleARN == "":
+		return nil, nil
+	case identity.TokenFile == "":
+		return nil, fmt.Errorf("web identity role %s has no token file; set %s", identity.RoleARN, envWebIdentityTokenFile)
+	case identity.RoleARN == "":
+		return nil, fmt.Errorf("web identity token file %s has no role; set %s", identity.TokenFile, envRoleARN)
+	}
+	if identity.SessionName == "" {
+		identity.SessionName = "provctl"
+	}
+
+	if err := checkWebIdentityToken(identity.TokenFile, time.Now()); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// checkWebIdentityToken fails early on a token file that is missing, empty
+// or holds an expired JWT, rather than on the first AWS call. The kubelet
+// refreshes projected tokens well before they expire, so an expired one
+// means the pod's token projection is broken.
+func checkWebIdentityToken(path string, now time.Time) error {
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: cannot read web identity token: %v; check the service account token projection of the pod",
+			engine.ErrInvalidCredentials, err)
+	}
+	if len(strings.TrimSpace(string(token))) == 0 {
+		return fmt.Errorf("%w: web identity token file %s is empty", engine.ErrInvalidCredentials, path)
+	}
+
+	if expiry, ok := tokenExpiry(string(token)); ok && !now.Before(expiry) {
+		return fmt.Errorf("%w: web identity token in %s expired at %s; check the service account token projection of the pod",
+			engine.ErrInvalidCredentials, path, expiry.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// tokenExpiry returns the exp claim of a JWT, or false if the token is not
+// a JWT with one. The signature is not checked; STS does that.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+// webIdentityCredentials exchanges the token for credentials of the role.
+// The token file is read again on every refresh, picking up rotated tokens.
+func webIdentityCredentials(cfg aws.Config, identity *webIdentity) aws.CredentialsProvider {
+	provider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(cfg), identity.RoleARN,
+		stscreds.IdentityTokenFile(identity.TokenFile),
+		func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = identity.SessionName
+		})
+	return aws.NewCredentialsCache(provider)
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// writeToken writes an unsigned JWT expiring at exp, like a projected
+// service account token, and returns its path
+func writeToken(t *testing.T, exp time.Time) string {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+	token := encode([]byte(`{"alg":"RS256"}`)) + "." +
+		encode([]byte(fmt.Sprintf(`{"sub":"system:serviceaccount:provctl:provctl","exp":%d}`, exp.Unix()))) + "." +
+		encode([]byte("signature"))
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestNewProviderWithOptions_WebIdentityFromEnvironment(t *testing.T) {
+	t.Setenv(envWebIdentityTokenFile, writeToken(t, time.Now().Add(time.Hour)))
+	t.Setenv(envRoleARN, "arn:aws:iam::123456789012:role/provctl-irsa")
+
+	p, err := NewProviderWithOptions(context.Background(), Options{Region: "us-west-2"}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewProviderWithOptions() error = %v", err)
+	}
+	if !aws.IsCredentialsProvider(p.awsConfig.Credentials, &stscreds.WebIdentityRoleProvider{}) {
+		t.Errorf("credentials = %T, want the web identity provider", p.awsConfig.Credentials)
+	}
+}
+
+func TestNewProviderWithOptions_WebIdentityErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name      string
+		tokenFile string
+		roleARN   string
+		want      string
+		invalid   bool // Wraps engine.ErrInvalidCredentials
+	}{
+		{name: "missing token file", tokenFile: missing, roleARN: "arn:aws:iam::123456789012:role/irsa", want: "cannot read web identity token", invalid: true},
+		{name: "expired token", tokenFile: writeToken(t, time.Now().Add(-time.Minute)), roleARN: "arn:aws:iam::123456789012:role/irsa", want: "expired at", invalid: true},
+		{name: "token without role", tokenFile: writeToken(t, time.Now().Add(time.Hour)), want: "set AWS_ROLE_ARN"},
+		{name: "role without token", roleARN: "arn:aws:iam::123456789012:role/irsa", want: "set AWS_WEB_IDENTITY_TOKEN_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envWebIdentityTokenFile, tt.tokenFile)
+			t.Setenv(envRoleARN, tt.roleARN)
+
+			_, err := NewProviderWithOptions(context.Background(), Options{Region: "us-west-2"}, slog.Default())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("NewProviderWithOptions() error = %v, want %q", err, tt.want)
+			}
+			if got := errors.Is(err, engine.ErrInvalidCredentials); got != tt.invalid {
+				t.Errorf("errors.Is(err, ErrInvalidCredentials) = %v, want %v", got, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestResolveWebIdentity(t *testing.T) {
+	token := writeToken(t, time.Now().Add(time.Hour))
+	t.Setenv(envWebIdentityTokenFile, token)
+	t.Setenv(envRoleARN, "arn:aws:iam::123456789012:role/from-env")
+
+	identity, err := resolveWebIdentity(Options{Profile: "admin"})
+	if err != nil || identity != nil {
+		t.Errorf("resolveWebIdentity() with a profile = %+v, %v, want the environment ignored", identity, err)
+	}
+
+	identity, err = resolveWebIdentity(Options{WebIdentityTokenFile: token, WebIdentityRoleARN: "arn:aws:iam::123456789012:role/explicit"})
+	if err != nil {
+		t.Fatalf("resolveWebIdentity() error = %v", err)
+	}
+	if identity.RoleARN != "arn:aws:iam::123456789012:role/explicit" || identity.SessionName != "provctl" {
+		t.Errorf("resolveWebIdentity() = %+v, want the explicit role and the default session name", identity)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	exp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	raw, err := os.ReadFile(writeToken(t, exp))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if got, ok := tokenExpiry(string(raw)); !ok || !got.Equal(exp) {
+		t.Errorf("tokenExpiry() = %v, %v, want %v", got, ok, exp)
+	}
+	if _, ok := tokenExpiry("not-a-jwt"); ok {
+		t.Error("tokenExpiry() of an opaque token reported an expiry")
+	}
+}
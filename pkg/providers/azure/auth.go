@@ -0,0 +1,118 @@
+package azure
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// Credential keys read from engine.ProviderConfig.Credentials
+const (
+	CredentialTenantID                = "tenant_id"
+	CredentialClientID                = "client_id"
+	CredentialClientSecret            = "client_secret"
+	CredentialManagedIdentity         = "managed_identity" // "true" to use the host's managed identity
+	CredentialManagedIdentityClientID = "managed_identity_client_id"
+)
+
+// AuthOptions select how the Azure provider authenticates. Configure at
+// most one method; with none set the default credential chain is used.
+type AuthOptions struct {
+	// Service principal with a client secret, typically used in CI
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+
+	// Managed identity of the host. ManagedIdentityClientID selects a
+	// user-assigned identity; empty uses the system-assigned one.
+	ManagedIdentity         bool
+	ManagedIdentityClientID string
+}
+
+// servicePrincipal reports whether any service principal field is set
+func (o AuthOptions) servicePrincipal() bool {
+	return o.TenantID != "" || o.ClientID != "" || o.ClientSecret != ""
+}
+
+// Validate checks that at most one auth method is configured and that the
+// chosen method is complete
+func (o AuthOptions) Validate() error {
+	managedIdentity := o.ManagedIdentity || o.ManagedIdentityClientID != ""
+	if o.servicePrincipal() && managedIdentity {
+		return fmt.Errorf("configure exactly one Azure auth method: service principal or managed identity, not both")
+	}
+
+	if o.servicePrincipal() {
+		var missing []string
+		if o.TenantID == "" {
+			missing = append(missing, "tenant ID")
+		}
+		if o.ClientID == "" {
+			missing = append(missing, "client ID")
+		}
+		if o.ClientSecret == "" {
+			missing = append(missing, "client secret")
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("incomplete Azure service principal: missing %s", strings.Join(missing, ", "))
+		}
+	}
+
+	return nil
+}
+
+// AuthOptionsFromCredentials reads auth options from provider config
+// credentials
+func AuthOptionsFromCredentials(credentials map[string]string) (AuthOptions, error) {
+	opts := AuthOptions{
+		TenantID:                credentials[CredentialTenantID],
+		ClientID:                credentials[CredentialClientID],
+		ClientSecret:            credentials[CredentialClientSecret],
+		ManagedIdentityClientID: credentials[CredentialManagedIdentityClientID],
+	}
+
+	if raw, exists := credentials[CredentialManagedIdentity]; exists && raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return AuthOptions{}, fmt.Errorf("invalid %s %q: want true or false", CredentialManagedIdentity, raw)
+		}
+		opts.ManagedIdentity = enabled
+	}
+
+	return opts, opts.Validate()
+}
+
+// NewCredential builds the token credential selected by opts
+func NewCredential(opts AuthOptions) (azcore.TokenCredential, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.servicePrincipal():
+		cred, err := azidentity.NewClientSecretCredential(opts.TenantID, opts.ClientID, opts.ClientSecret, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create service principal credential: %w", err)
+		}
+		return cred, nil
+	case opts.ManagedIdentity || opts.ManagedIdentityClientID != "":
+		miOpts := &azidentity.ManagedIdentityCredentialOptions{}
+		if opts.ManagedIdentityClientID != "" {
+			miOpts.ID = azidentity.ClientID(opts.ManagedIdentityClientID)
+		}
+		cred, err := azidentity.NewManagedIdentityCredential(miOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create managed identity credential: %w", err)
+		}
+		return cred, nil
+	default:
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+		}
+		return cred, nil
+	}
+}
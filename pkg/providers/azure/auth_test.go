@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuthOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AuthOptions
+		wantErr string
+	}{
+		{name: "default chain", opts: AuthOptions{}},
+		{
+			name: "service principal",
+			opts: AuthOptions{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"},
+		},
+		{name: "system-assigned identity", opts: AuthOptions{ManagedIdentity: true}},
+		{name: "user-assigned identity", opts: AuthOptions{ManagedIdentityClientID: "identity"}},
+		{
+			name:    "both methods",
+			opts:    AuthOptions{TenantID: "tenant", ClientID: "client", ClientSecret: "secret", ManagedIdentity: true},
+			wantErr: "exactly one",
+		},
+		{
+			name:    "incomplete service principal",
+			opts:    AuthOptions{ClientID: "client"},
+			wantErr: "missing tenant ID, client secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCredential_ServicePrincipal(t *testing.T) {
+	cred, err := NewCredential(AuthOptions{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewCredential() error = %v", err)
+	}
+	if cred == nil {
+		t.Error("NewCredential() returned nil credential")
+	}
+}
+
+func TestAuthOptionsFromCredentials(t *testing.T) {
+	opts, err := AuthOptionsFromCredentials(map[string]string{
+		CredentialManagedIdentity:         "true",
+		CredentialManagedIdentityClientID: "identity",
+	})
+	if err != nil {
+		t.Fatalf("AuthOptionsFromCredentials() error = %v", err)
+	}
+	if !opts.ManagedIdentity || opts.ManagedIdentityClientID != "identity" {
+		t.Errorf("AuthOptionsFromCredentials() = %+v, want user-assigned identity", opts)
+	}
+
+	if _, err := AuthOptionsFromCredentials(map[string]string{CredentialManagedIdentity: "sometimes"}); err == nil {
+		t.Error("AuthOptionsFromCredentials() expected error for invalid managed_identity")
+	}
+	if _, err := AuthOptionsFromCredentials(map[string]string{
+		CredentialClientID:        "client",
+		CredentialManagedIdentity: "true",
+	}); err == nil {
+		t.Error("AuthOptionsFromCredentials() expected error for two auth methods")
+	}
+}
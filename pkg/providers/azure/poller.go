@@ -0,0 +1,87 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// lroPollFrequency is how often a long-running operation is polled when
+// Azure does not ask for a different delay, as PollUntilDone does by default
+const lroPollFrequency = 30 * time.Second
+
+// lroPoller is the part of runtime.Poller used to wait on an operation
+type lroPoller[T any] interface {
+	Done() bool
+	Poll(ctx context.Context) (*http.Response, error)
+	Result(ctx context.Context) (T, error)
+}
+
+// pollUntilDone waits on a long-running operation as PollUntilDone does,
+// polling every frequency unless Azure asks for another delay, and reports
+// the status and percent complete of each poll to progress
+func pollUntilDone[T any](ctx context.Context, poller lroPoller[T], frequency time.Duration, progress *engine.Progress) (T, error) {
+	if frequency <= 0 {
+		frequency = lroPollFrequency
+	}
+
+	var zero T
+	for {
+		resp, err := poller.Poll(ctx)
+		if err != nil {
+			return zero, err
+		}
+		if poller.Done() {
+			return poller.Result(ctx)
+		}
+		status, percent := lroStatus(resp)
+		progress.Report(ctx, status, percent)
+
+		delay := frequency
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// lroStatus reads the status of an operation from a poll's response: the
+// status and percent complete of an Azure-AsyncOperation, or the
+// provisioning state of the resource itself. percent is -1 when Azure does
+// not report one.
+func lroStatus(resp *http.Response) (string, float64) {
+	var body struct {
+		Status          string   `json:"status"`
+		PercentComplete *float64 `json:"percentComplete"`
+		Properties      struct {
+			ProvisioningState string `json:"provisioningState"`
+		} `json:"properties"`
+	}
+	// A body that is not JSON leaves the HTTP status to report
+	if payload, err := runtime.Payload(resp); err == nil {
+		_ = json.Unmarshal(payload, &body)
+	}
+
+	status := body.Status
+	if status == "" {
+		status = body.Properties.ProvisioningState
+	}
+	if status == "" {
+		status = resp.Status
+	}
+	percent := -1.0
+	if body.PercentComplete != nil {
+		percent = *body.PercentComplete
+	}
+	return status, percent
+}
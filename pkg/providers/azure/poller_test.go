@@ -0,0 +1,105 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// fakePoller answers each poll with the next body, and is done once they run out
+type fakePoller struct {
+	bodies []string
+	polls  int
+}
+
+func (f *fakePoller) Done() bool {
+	return f.polls > len(f.bodies)
+}
+
+func (f *fakePoller) Poll(ctx context.Context) (*http.Response, error) {
+	f.polls++
+	body := "{}"
+	if f.polls <= len(f.bodies) {
+		body = f.bodies[f.polls-1]
+	}
+	return &http.Response{
+		Status:     "202 Accepted",
+		StatusCode: http.StatusAccepted,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func (f *fakePoller) Result(ctx context.Context) (string, error) {
+	return "done", nil
+}
+
+func TestPollUntilDone(t *testing.T) {
+	bodies := []string{
+		`{"status":"InProgress","percentComplete":10}`,
+		`{"status":"InProgress","percentComplete":55.5}`,
+		`{"properties":{"provisioningState":"Updating"}}`,
+		`not json`,
+	}
+
+	tests := []struct {
+		name             string
+		progressInterval time.Duration
+		want             []string
+	}{
+		{
+			name:             "progress logged every poll",
+			progressInterval: time.Nanosecond,
+			want: []string{
+				"status=InProgress percentComplete=10",
+				"status=InProgress percentComplete=55.5",
+				"status=Updating elapsed",
+				`status="202 Accepted" elapsed`,
+			},
+		},
+		{name: "progress throttled", progressInterval: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			progress := engine.NewProgress(slog.New(slog.NewTextHandler(&logs, nil)), tt.progressInterval, "update AKS agent pool")
+
+			result, err := pollUntilDone[string](context.Background(), &fakePoller{bodies: bodies}, time.Millisecond, progress)
+			if err != nil || result != "done" {
+				t.Fatalf("pollUntilDone() = %q, %v, want done", result, err)
+			}
+
+			var lines []string
+			if out := strings.TrimSpace(logs.String()); out != "" {
+				lines = strings.Split(out, "\n")
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("logged %d lines, want %d:\n%s", len(lines), len(tt.want), logs.String())
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(lines[i], want) {
+					t.Errorf("line %d = %q, want it to contain %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPollUntilDone_Canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	poller := &fakePoller{bodies: []string{`{"status":"InProgress"}`}}
+	progress := engine.NewProgress(slog.Default(), 0, "delete AKS cluster")
+	if _, err := pollUntilDone[string](ctx, poller, time.Hour, progress); err == nil {
+		t.Error("pollUntilDone() error = nil, want the context's error")
+	}
+}
@@ -3,11 +3,18 @@ package azure
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
@@ -21,30 +28,102 @@ type Provider struct {
 	subscriptionID string
 	region         string
 	credential     azcore.TokenCredential
+	clientOptions  *arm.ClientOptions // Shared by every client, so they share one rate limit
 	vmsClient      *armcompute.VirtualMachinesClient
 	aksClient      *armcontainerservice.ManagedClustersClient
+	scaleSets      *armcompute.VirtualMachineScaleSetsClient
 	vnetClient     *armnetwork.VirtualNetworksClient
+	phases         *engine.PhaseRecorder
 	logger         *slog.Logger
+
+	progressInterval time.Duration // Zero takes engine.DefaultProgressInterval
+	pollFrequency    time.Duration // Zero takes lroPollFrequency
+	timeouts         engine.PhaseTimeouts
+}
+
+func init() {
+	engine.RegisterProviderFactory("azure", func(ctx context.Context, cfg engine.ProviderConfig) (engine.CloudProvider, error) {
+		opts, err := AuthOptionsFromCredentials(cfg.Credentials)
+		if err != nil {
+			return nil, err
+		}
+		cred, err := NewCredential(opts)
+		if err != nil {
+			return nil, err
+		}
+		provider, err := NewProviderWithOptions(ctx, cfg.SubscriptionID, cfg.Region, cred, Options{
+			RateLimit:        cfg.RateLimit,
+			ProgressInterval: cfg.ProgressInterval,
+
+			NetworkTimeout:      cfg.NetworkTimeout,
+			ControlPlaneTimeout: cfg.ControlPlaneTimeout,
+			NodePoolTimeout:     cfg.NodePoolTimeout,
+		}, cfg.Logger)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Events != nil {
+			provider.SetEventStore(cfg.Events)
+		}
+		return provider, nil
+	})
 }
 
-// NewProvider creates a new Azure provider
+// NewProvider creates a new Azure provider using the default credential chain
 func NewProvider(ctx context.Context, subscriptionID, region string, logger *slog.Logger) (*Provider, error) {
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	cred, err := NewCredential(AuthOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Azure credential: %w", err)
+		return nil, err
+	}
+	return NewProviderWithCredential(ctx, subscriptionID, region, cred, logger)
+}
+
+// Options configure the Azure provider's API clients
+type Options struct {
+	RateLimit engine.RateLimit // Paces API calls; zero fields take DefaultRateLimit
+
+	// ProgressInterval is how often long-running operations log their
+	// progress; zero takes engine.DefaultProgressInterval
+	ProgressInterval time.Duration
+
+	// NetworkTimeout, ControlPlaneTimeout and NodePoolTimeout limit how long
+	// each phase of creating a cluster may take, failing it with an error
+	// naming the phase; zero takes engine.DefaultNetworkTimeout and so on
+	NetworkTimeout      time.Duration
+	ControlPlaneTimeout time.Duration
+	NodePoolTimeout     time.Duration
+}
+
+// NewProviderWithCredential creates a new Azure provider authenticating with
+// cred, such as one built by NewCredential
+func NewProviderWithCredential(ctx context.Context, subscriptionID, region string, cred azcore.TokenCredential, logger *slog.Logger) (*Provider, error) {
+	return NewProviderWithOptions(ctx, subscriptionID, region, cred, Options{}, logger)
+}
+
+// NewProviderWithOptions creates a new Azure provider authenticating with
+// cred and configured by opts
+func NewProviderWithOptions(ctx context.Context, subscriptionID, region string, cred azcore.TokenCredential, opts Options, logger *slog.Logger) (*Provider, error) {
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("an Azure subscription ID is required")
 	}
+	clientOptions := rateLimitedClientOptions(opts.RateLimit.Limiter(DefaultRateLimit))
 
-	vmsClient, err := armcompute.NewVirtualMachinesClient(subscriptionID, cred, nil)
+	vmsClient, err := armcompute.NewVirtualMachinesClient(subscriptionID, cred, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VMs client: %w", err)
 	}
 
-	aksClient, err := armcontainerservice.NewManagedClustersClient(subscriptionID, cred, nil)
+	aksClient, err := armcontainerservice.NewManagedClustersClient(subscriptionID, cred, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AKS client: %w", err)
 	}
 
-	vnetClient, err := armnetwork.NewVirtualNetworksClient(subscriptionID, cred, nil)
+	scaleSets, err := armcompute.NewVirtualMachineScaleSetsClient(subscriptionID, cred, clientOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create VMSS client: %w", err)
+	}
+
+	vnetClient, err := armnetwork.NewVirtualNetworksClient(subscriptionID, cred, clientOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VNet client: %w", err)
 	}
@@ -53,10 +132,19 @@ func NewProvider(ctx context.Context, subscriptionID, region string, logger *slo
 		subscriptionID: subscriptionID,
 		region:         region,
 		credential:     cred,
+		clientOptions:  clientOptions,
 		vmsClient:      vmsClient,
 		aksClient:      aksClient,
+		scaleSets:      scaleSets,
 		vnetClient:     vnetClient,
 		logger:         logger,
+
+		progressInterval: opts.ProgressInterval,
+		timeouts: engine.PhaseTimeouts{
+			Network:      opts.NetworkTimeout,
+			ControlPlane: opts.ControlPlaneTimeout,
+			NodePool:     opts.NodePoolTimeout,
+		},
 	}, nil
 }
 
@@ -65,12 +153,79 @@ func (p *Provider) Name() string {
 	return "azure"
 }
 
+// SetEventStore records every phase transition of the clusters and node
+// pools this provider manages as an event in events
+func (p *Provider) SetEventStore(events engine.EventStore) {
+	p.phases = engine.NewPhaseRecorder(events)
+}
+
+// setPhase moves a resource to phase through the phase recorder. Failing to
+// record the transition is logged but does not fail the operation.
+func (p *Provider) setPhase(ctx context.Context, resource api.ResourceID, status *api.ResourceStatus, phase api.Phase, reason string) {
+	if err := p.phases.SetPhase(ctx, resource, status, phase, reason); err != nil {
+		p.logger.WarnContext(ctx, "failed to record phase change", "resource", resource.ID, "phase", phase, "error", err)
+	}
+}
+
+// Validate checks that a token can be acquired and that it grants access to
+// the configured subscription
+func (p *Provider) Validate(ctx context.Context) error {
+	client, err := arm.NewClient("provctl", "v1.0.0", p.credential, p.clientOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create ARM client: %w", err)
+	}
+
+	_, err = p.credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{client.Endpoint() + "/.default"},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: could not acquire an Azure token, check your login or service principal: %v",
+			engine.ErrInvalidCredentials, err)
+	}
+
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(client.Endpoint(), "subscriptions", p.subscriptionID))
+	if err != nil {
+		return fmt.Errorf("failed to build subscription request: %w", err)
+	}
+	req.Raw().URL.RawQuery = "api-version=2022-12-01"
+
+	resp, err := client.Pipeline().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify Azure credentials: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		p.logger.InfoContext(ctx, "validated Azure credentials", "subscription", p.subscriptionID)
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: credentials have no access to subscription %s",
+			engine.ErrInvalidCredentials, p.subscriptionID)
+	case http.StatusNotFound:
+		return fmt.Errorf("subscription %s not found", p.subscriptionID)
+	default:
+		return fmt.Errorf("failed to verify Azure credentials: %w", runtime.NewResponseError(resp))
+	}
+}
+
+// ServerTime returns the time of the Azure Resource Manager endpoint, which
+// Validate also calls, for detecting clock skew that invalidates tokens
+func (p *Provider) ServerTime(ctx context.Context) (time.Time, error) {
+	client, err := arm.NewClient("provctl", "v1.0.0", p.credential, p.clientOptions)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create ARM client: %w", err)
+	}
+	return engine.HTTPServerTime(ctx, client.Endpoint()+"/")
+}
+
 // CreateCluster creates a new Kubernetes cluster on Azure
 func (p *Provider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*api.Cluster, error) {
-	p.logger.Info("creating Azure cluster",
+	p.logger.InfoContext(ctx, "creating Azure cluster",
 		"region", p.region,
 		"controlPlaneType", spec.ControlPlane.Type,
 	)
+	// Sensitive fields of the spec are redacted from the log
+	p.logger.DebugContext(ctx, "cluster spec", "spec", spec)
 
 	cluster := &api.Cluster{
 		ID: generateClusterID(),
@@ -78,58 +233,162 @@ func (p *Provider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*ap
 			Name: spec.Config["name"].(string),
 		},
 		Spec: spec,
-		Status: api.ResourceStatus{
-			Phase: api.PhaseProvisioning,
-		},
 	}
+	cluster.Metadata.Touch(time.Now())
+	resource := api.ResourceID{Provider: p.Name(), Kind: "Cluster", ID: cluster.ID, Name: cluster.Metadata.Name}
+	p.setPhase(ctx, resource, &cluster.Status, api.PhaseProvisioning, "cluster creation started")
 
-	// Create resource group
-	if err := p.createResourceGroup(ctx, cluster); err != nil {
-		return nil, fmt.Errorf("failed to create resource group: %w", err)
+	if err := p.provisionCluster(ctx, cluster); err != nil {
+		p.setPhase(ctx, resource, &cluster.Status, api.PhaseFailed, err.Error())
+		return nil, err
 	}
 
-	// Create VNet and networking
-	if err := p.createNetwork(ctx, cluster); err != nil {
-		return nil, fmt.Errorf("failed to create network: %w", err)
+	p.setPhase(ctx, resource, &cluster.Status, api.PhaseRunning, "cluster created")
+	return cluster, nil
+}
+
+// provisionCluster creates the cloud resources of a new cluster. The
+// resource group and network count as the network phase; each phase runs
+// within its timeout.
+func (p *Provider) provisionCluster(ctx context.Context, cluster *api.Cluster) error {
+	err := p.timeouts.RunPhase(ctx, engine.PhaseNetwork, func(ctx context.Context) error {
+		// Create resource group
+		if err := p.createResourceGroup(ctx, cluster); err != nil {
+			return fmt.Errorf("failed to create resource group: %w", err)
+		}
+
+		// Create VNet and networking, unless the cluster goes into an existing VNet
+		if vnetID := cluster.Spec.Network.ExistingVNetID; vnetID != "" {
+			p.logger.InfoContext(ctx, "using existing VNet", "cluster", cluster.ID, "vnet", vnetID,
+				"subnets", cluster.Spec.Network.ExistingSubnetIDs)
+		} else if err := p.createNetwork(ctx, cluster); err != nil {
+			return fmt.Errorf("failed to create network: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Create control plane
-	switch spec.ControlPlane.Type {
-	case api.ControlPlaneManaged:
-		if err := p.createAKSCluster(ctx, cluster); err != nil {
-			return nil, fmt.Errorf("failed to create AKS cluster: %w", err)
+	return p.timeouts.RunPhase(ctx, engine.PhaseControlPlane, func(ctx context.Context) error {
+		switch cluster.Spec.ControlPlane.Type {
+		case api.ControlPlaneManaged:
+			if err := p.createAKSCluster(ctx, cluster); err != nil {
+				return fmt.Errorf("failed to create AKS cluster: %w", err)
+			}
+		case api.ControlPlaneSelfManaged:
+			if err := p.createVMControlPlane(ctx, cluster); err != nil {
+				return fmt.Errorf("failed to create VM control plane: %w", err)
+			}
 		}
+		return nil
+	})
+}
+
+// Typical Azure provisioning times, from which EstimateProvisionTime adds up
+// a cluster
+const (
+	networkProvisionTime     = time.Minute
+	aksProvisionTime         = 7 * time.Minute
+	vmProvisionTime          = 5 * time.Minute
+	agentPoolProvisionTime   = 3 * time.Minute
+	provisionTimePerTenNodes = 30 * time.Second
+)
+
+// EstimateProvisionTime adds up typical durations of the steps creating the
+// cluster takes: the resource group and network, the control plane, then
+// each worker pool in turn. AKS brings up its control plane faster than EKS,
+// but pools take a similar time. This is a heuristic; actual times vary by
+// region and load.
+func (p *Provider) EstimateProvisionTime(spec api.ClusterSpec) time.Duration {
+	estimate := networkProvisionTime
+
+	switch spec.ControlPlane.Type {
 	case api.ControlPlaneSelfManaged:
-		if err := p.createVMControlPlane(ctx, cluster); err != nil {
-			return nil, fmt.Errorf("failed to create VM control plane: %w", err)
-		}
+		estimate += vmProvisionTime
+	default:
+		estimate += aksProvisionTime
 	}
 
-	cluster.Status.Phase = api.PhaseRunning
-	return cluster, nil
+	for _, pool := range spec.WorkerPools {
+		estimate += agentPoolProvisionTime + provisionTimePerTenNodes*time.Duration(pool.DesiredSize/10)
+	}
+	return estimate
 }
 
 // UpdateCluster updates an existing cluster
 func (p *Provider) UpdateCluster(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("updating Azure cluster", "id", cluster.ID)
+	p.logger.InfoContext(ctx, "updating Azure cluster", "id", cluster.ID)
+	cluster.Metadata.Touch(time.Now())
 	return nil
 }
 
-// DeleteCluster deletes a cluster
-func (p *Provider) DeleteCluster(ctx context.Context, clusterID string) error {
-	p.logger.Info("deleting Azure cluster", "id", clusterID)
+// DeleteCluster deletes a cluster. A cluster that is already gone, deleted
+// by an earlier partial run or out of band, counts as deleted.
+func (p *Provider) DeleteCluster(ctx context.Context, cluster *api.Cluster) error {
+	p.logger.InfoContext(ctx, "deleting Azure cluster", "id", cluster.ID)
+
+	switch cluster.Spec.ControlPlane.Type {
+	case api.ControlPlaneSelfManaged:
+		// Implementation: Delete the control plane VMs
+	default:
+		name := cluster.Metadata.Name
+		group, err := resourceGroup("cluster", cluster.ID, cluster.Status)
+		if err != nil {
+			return err
+		}
+		poller, err := p.aksClient.BeginDelete(ctx, group, name, nil)
+		if err == nil {
+			progress := engine.NewProgress(p.logger, p.progressInterval, "delete AKS cluster", "cluster", name)
+			_, err = pollUntilDone[armcontainerservice.ManagedClustersClientDeleteResponse](ctx, poller, p.pollFrequency, progress)
+		}
+		if isNotFound(err) {
+			p.logger.InfoContext(ctx, "cluster already deleted", "id", cluster.ID)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("AKS Delete failed: %w", err)
+		}
+	}
+
+	// Implementation: Delete the resource group, which holds the VNet unless the cluster used an existing one
 	return nil
 }
 
+// isNotFound reports whether err is an Azure API error for a resource that
+// does not exist
+func isNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
 // GetCluster retrieves cluster information
 func (p *Provider) GetCluster(ctx context.Context, clusterID string) (*api.Cluster, error) {
-	p.logger.Info("getting Azure cluster", "id", clusterID)
+	p.logger.InfoContext(ctx, "getting Azure cluster", "id", clusterID)
 	return nil, nil
 }
 
-// CreateNodePool creates a worker node pool
+// CreateNodePool creates a worker node pool, or updates the existing pool
+// if one with the same name already exists in the cluster
 func (p *Provider) CreateNodePool(ctx context.Context, clusterID string, spec api.WorkerPoolSpec) (*api.NodePool, error) {
-	p.logger.Info("creating node pool",
+	if err := checkPoolSupported(spec); err != nil {
+		return nil, err
+	}
+
+	existing, err := engine.FindNodePool(ctx, p, clusterID, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		existing.Spec = spec
+		if err := p.UpdateNodePool(ctx, existing); err != nil {
+			return nil, fmt.Errorf("failed to update existing node pool: %w", err)
+		}
+		return existing, nil
+	}
+
+	p.logger.InfoContext(ctx, "creating node pool",
 		"cluster", clusterID,
 		"pool", spec.Name,
 		"instanceType", spec.InstanceType,
@@ -141,35 +400,112 @@ func (p *Provider) CreateNodePool(ctx context.Context, clusterID string, spec ap
 			Name: spec.Name,
 		},
 		Spec: spec,
-		Status: api.ResourceStatus{
-			Phase: api.PhaseProvisioning,
-		},
 	}
+	pool.Metadata.Touch(time.Now())
+	resource := api.ResourceID{Provider: p.Name(), Kind: "NodePool", ID: pool.ID, Name: pool.Metadata.Name}
+	p.setPhase(ctx, resource, &pool.Status, api.PhaseProvisioning, "node pool creation started")
+
+	cluster, err := p.findPoolCluster(ctx, clusterID)
+	if err != nil {
+		err = fmt.Errorf("failed to find cluster %s: %w", clusterID, err)
+		p.setPhase(ctx, resource, &pool.Status, api.PhaseFailed, err.Error())
+		return nil, err
+	}
+	tags := cluster.poolTags(spec)
+	pool.Status.SetProperty(api.PropertyClusterName, cluster.name)
+	pool.Status.SetProperty(api.PropertyClusterID, clusterID)
+	// The scale set goes into the resource group holding its cluster
+	pool.Status.SetProperty(api.PropertyResourceGroup, cluster.resourceGroup)
 
 	// Create VM Scale Set
-	if err := p.createVMScaleSet(ctx, clusterID, pool); err != nil {
-		return nil, fmt.Errorf("failed to create VMSS: %w", err)
+	if err := p.timeouts.RunPhase(ctx, engine.PhaseNodePool, func(ctx context.Context) error {
+		return p.createVMScaleSet(ctx, clusterID, pool, tags)
+	}); err != nil {
+		err = fmt.Errorf("failed to create VMSS: %w", err)
+		p.setPhase(ctx, resource, &pool.Status, api.PhaseFailed, err.Error())
+		return nil, err
 	}
 
-	pool.Status.Phase = api.PhaseRunning
+	p.setPhase(ctx, resource, &pool.Status, api.PhaseRunning, "node pool created")
 	return pool, nil
 }
 
-// UpdateNodePool updates a node pool
+// UpdateNodePool updates the scale set of a node pool. A new capacity is
+// applied in place; a new VM size or image would replace its VMs, which is
+// not supported, and fails before anything changes.
 func (p *Provider) UpdateNodePool(ctx context.Context, pool *api.NodePool) error {
-	p.logger.Info("updating node pool", "id", pool.ID)
+	p.logger.InfoContext(ctx, "updating node pool", "id", pool.ID)
+
+	if err := checkPoolSupported(pool.Spec); err != nil {
+		return err
+	}
+
+	clusterID := pool.Status.Properties[api.PropertyClusterID]
+	if clusterID == "" {
+		return fmt.Errorf("node pool %s has no recorded cluster ID", pool.ID)
+	}
+	group, err := resourceGroup("node pool", pool.ID, pool.Status)
+	if err != nil {
+		return err
+	}
+	name := api.NodeGroupName(clusterID, pool.Spec.Name)
+
+	current, err := p.scaleSets.Get(ctx, group, name, nil)
+	if err != nil {
+		return fmt.Errorf("VMSS Get failed: %w", err)
+	}
+
+	observed := observedPoolSpec(pool.Spec, &current.VirtualMachineScaleSet)
+	if observed.ClassifyChange(pool.Spec) == api.PoolChangeReplacement {
+		return p.replaceScaleSet(name)
+	}
+	if observed.DesiredSize != pool.Spec.DesiredSize {
+		p.logger.InfoContext(ctx, "resizing VM Scale Set", "name", name,
+			"from", observed.DesiredSize, "to", pool.Spec.DesiredSize)
+		poller, err := p.scaleSets.BeginUpdate(ctx, group, name, armcompute.VirtualMachineScaleSetUpdate{
+			SKU: vmssSKU(pool.Spec),
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("VMSS update failed: %w", err)
+		}
+		progress := engine.NewProgress(p.logger, p.progressInterval, "resize VM Scale Set", "name", name)
+		if _, err := pollUntilDone[armcompute.VirtualMachineScaleSetsClientUpdateResponse](ctx, poller, p.pollFrequency, progress); err != nil {
+			return fmt.Errorf("VMSS update failed: %w", err)
+		}
+	}
+
+	p.logger.InfoContext(ctx, "updating VM Scale Set autoscale bounds", "name", name,
+		"minSize", pool.Spec.MinSize, "maxSize", pool.Spec.MaxSize)
+	// Implementation: Update the scale set's autoscale setting with the pool's bounds
+
+	pool.Metadata.Touch(time.Now())
 	return nil
 }
 
-// DeleteNodePool deletes a node pool
+// DeleteNodePool deletes a node pool. A pool that is already gone counts as
+// deleted.
 func (p *Provider) DeleteNodePool(ctx context.Context, poolID string) error {
-	p.logger.Info("deleting node pool", "id", poolID)
+	p.logger.InfoContext(ctx, "deleting node pool", "id", poolID)
+
+	if err := p.deleteNodePoolResources(ctx, poolID); err != nil {
+		if isNotFound(err) {
+			p.logger.InfoContext(ctx, "node pool already deleted", "id", poolID)
+			return nil
+		}
+		return fmt.Errorf("failed to delete node pool %s: %w", poolID, err)
+	}
 	return nil
 }
 
+// ListNodePools lists the node pools of a cluster
+func (p *Provider) ListNodePools(ctx context.Context, clusterID string) ([]*api.NodePool, error) {
+	p.logger.InfoContext(ctx, "listing node pools", "cluster", clusterID)
+	return nil, nil
+}
+
 // Reconcile performs reconciliation between desired and actual state
 func (p *Provider) Reconcile(ctx context.Context, desired, actual engine.State) (engine.Plan, error) {
-	p.logger.Info("reconciling Azure infrastructure")
+	p.logger.InfoContext(ctx, "reconciling Azure infrastructure")
 
 	plan := engine.Plan{
 		Actions: []engine.Action{},
@@ -181,56 +517,337 @@ func (p *Provider) Reconcile(ctx context.Context, desired, actual engine.State)
 
 // Helper functions
 
+func (p *Provider) deleteNodePoolResources(ctx context.Context, poolID string) error {
+	p.logger.InfoContext(ctx, "deleting agent pool", "pool", poolID)
+	// Implementation: Delete the agent pool, or the scale set of a self-managed pool
+	return nil
+}
+
 func (p *Provider) createResourceGroup(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating resource group", "cluster", cluster.ID)
+	group := newResourceGroupName(cluster.Metadata.Name)
+	p.logger.InfoContext(ctx, "creating resource group", "cluster", cluster.ID, "resourceGroup", group)
 	// Implementation: Create Azure resource group
+	cluster.Status.SetProperty(api.PropertyResourceGroup, group)
 	return nil
 }
 
 func (p *Provider) createNetwork(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating VNet and networking", "cluster", cluster.ID)
+	p.logger.InfoContext(ctx, "creating VNet and networking", "cluster", cluster.ID)
 	// Implementation: Create VNet, subnets, NSGs, route tables
 	return nil
 }
 
 func (p *Provider) createAKSCluster(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating AKS cluster", "cluster", cluster.ID)
+	p.logger.InfoContext(ctx, "creating AKS cluster", "cluster", cluster.ID)
 
-	// Create AKS cluster
-	// Note: This is simplified - real implementation would have more parameters
-	/*
-	_, err := p.aksClient.BeginCreateOrUpdate(ctx,
-		resourceGroup,
+	group, err := resourceGroup("cluster", cluster.ID, cluster.Status)
+	if err != nil {
+		return err
+	}
+	poller, err := p.aksClient.BeginCreateOrUpdate(ctx,
+		group,
 		cluster.Metadata.Name,
-		armcontainerservice.ManagedCluster{
-			Location: &p.region,
-			Properties: &armcontainerservice.ManagedClusterProperties{
-				KubernetesVersion: &cluster.Spec.ControlPlane.Version,
-				// ... more properties
-			},
-		},
+		p.managedCluster(ctx, cluster),
 		nil,
 	)
 	if err != nil {
 		return fmt.Errorf("AKS CreateOrUpdate failed: %w", err)
 	}
-	*/
+	progress := engine.NewProgress(p.logger, p.progressInterval, "create AKS cluster", "cluster", cluster.Metadata.Name)
+	result, err := pollUntilDone[armcontainerservice.ManagedClustersClientCreateOrUpdateResponse](ctx, poller, p.pollFrequency, progress)
+	if err != nil {
+		return fmt.Errorf("AKS CreateOrUpdate failed: %w", err)
+	}
+	setAKSProperties(&cluster.Status, &result.ManagedCluster)
+
+	if settings := diagnosticSettings(cluster.Spec.Observability); settings != nil {
+		p.logger.InfoContext(ctx, "configuring control-plane logs",
+			"cluster", cluster.ID,
+			"workspace", settings.WorkspaceID,
+			"categories", settings.Categories,
+		)
+		// Implementation: Create the diagnostic setting on the managed cluster
+	}
+
+	if secrets := engine.SecretsFrom(ctx); len(secrets) > 0 {
+		p.logger.InfoContext(ctx, "storing secrets in Key Vault",
+			"cluster", cluster.ID,
+			"vault", keyVaultName(cluster.Metadata.Name),
+			"secrets", api.SecretNames(secrets),
+		)
+		// Implementation: Set each secret in the vault and grant the
+		// secrets provider add-on's identity read access to it
+	}
 
 	return nil
 }
 
+// managedCluster builds the AKS create request for a cluster
+func (p *Provider) managedCluster(ctx context.Context, cluster *api.Cluster) armcontainerservice.ManagedCluster {
+	tags := api.MergeTags(api.MandatoryTags(cluster.Metadata.Name), cluster.Spec.Tags, map[string]string{
+		tagClusterID: cluster.ID,
+	})
+	return armcontainerservice.ManagedCluster{
+		Location: &p.region,
+		Tags:     azureTags(tags),
+		Properties: &armcontainerservice.ManagedClusterProperties{
+			KubernetesVersion:      &cluster.Spec.ControlPlane.Version,
+			APIServerAccessProfile: apiServerAccessProfile(cluster.Spec.Network),
+			AddonProfiles:          secretStoreAddons(engine.SecretsFrom(ctx)),
+		},
+	}
+}
+
+// aksLogCategories maps control-plane log types onto AKS diagnostic log
+// categories
+var aksLogCategories = map[string]string{
+	api.LogTypeAPI:               "kube-apiserver",
+	api.LogTypeAudit:             "kube-audit",
+	api.LogTypeAuthenticator:     "guard",
+	api.LogTypeControllerManager: "kube-controller-manager",
+	api.LogTypeScheduler:         "kube-scheduler",
+	api.LogTypeClusterAutoscaler: "cluster-autoscaler",
+}
+
+// diagnostics holds an AKS diagnostic setting: the log categories sent to a
+// Log Analytics workspace
+type diagnostics struct {
+	Name        string
+	WorkspaceID string
+	Categories  []string
+}
+
+// diagnosticSettings maps control-plane logging onto an AKS diagnostic
+// setting, or returns nil when logs are off or have no workspace to go to
+func diagnosticSettings(obs *api.ObservabilitySpec) *diagnostics {
+	logTypes := obs.LogTypes()
+	if len(logTypes) == 0 || obs.LogDestination() == "" {
+		return nil
+	}
+
+	categories := make([]string, 0, len(logTypes))
+	for _, logType := range logTypes {
+		if category, ok := aksLogCategories[logType]; ok {
+			categories = append(categories, category)
+		}
+	}
+	return &diagnostics{
+		Name:        "provctl-control-plane",
+		WorkspaceID: obs.LogDestination(),
+		Categories:  categories,
+	}
+}
+
+// apiServerAccessProfile maps the network's API endpoint access onto AKS. A
+// private endpoint makes a private cluster, which keeps a public FQDN when
+// public access is also enabled. AKS does not support authorized IP ranges on
+// private clusters, so they are only set for a public-only endpoint.
+func apiServerAccessProfile(network api.NetworkSpec) *armcontainerservice.ManagedClusterAPIServerAccessProfile {
+	access := network.EndpointAccess()
+	private := access.PrivateAccess
+	publicFQDN := access.PrivateAccess && access.PublicAccess
+	profile := &armcontainerservice.ManagedClusterAPIServerAccessProfile{
+		EnablePrivateCluster: &private,
+	}
+	if private {
+		profile.EnablePrivateClusterPublicFQDN = &publicFQDN
+	}
+
+	if access.PublicAccess && !access.PrivateAccess {
+		for _, cidr := range access.AuthorizedCIDRs {
+			cidr := cidr
+			profile.AuthorizedIPRanges = append(profile.AuthorizedIPRanges, &cidr)
+		}
+	}
+	return profile
+}
+
+// setAKSProperties records the attributes downstream tooling needs to reach
+// an AKS cluster
+func setAKSProperties(status *api.ResourceStatus, cluster *armcontainerservice.ManagedCluster) {
+	props := cluster.Properties
+	if props == nil {
+		return
+	}
+
+	if props.Fqdn != nil {
+		status.SetProperty(api.PropertyEndpoint, "https://"+*props.Fqdn+":443")
+	}
+	if props.OidcIssuerProfile != nil && props.OidcIssuerProfile.IssuerURL != nil {
+		status.SetProperty(api.PropertyOIDCIssuer, *props.OidcIssuerProfile.IssuerURL)
+	}
+
+	// AKS reports the subnet of each agent pool; the VNet is its parent
+	for _, pool := range props.AgentPoolProfiles {
+		if pool.VnetSubnetID == nil {
+			continue
+		}
+		if vnet, _, found := strings.Cut(*pool.VnetSubnetID, "/subnets/"); found {
+			status.SetProperty(api.PropertyVPCID, vnet)
+			break
+		}
+	}
+}
+
 func (p *Provider) createVMControlPlane(ctx context.Context, cluster *api.Cluster) error {
-	p.logger.Info("creating VM control plane", "cluster", cluster.ID)
-	// Implementation: Create VMs for control plane
+	p.logger.InfoContext(ctx, "creating VM control plane", "cluster", cluster.ID,
+		"apiServerFlags", cluster.Spec.ControlPlane.APIServerFlags())
+	// Implementation: Create VMs for control plane, bootstrapping
+	// kube-apiserver with the API server flags
+	return nil
+}
+
+func (p *Provider) createVMScaleSet(ctx context.Context, clusterID string, pool *api.NodePool, tags map[string]string) error {
+	name := api.NodeGroupName(clusterID, pool.Spec.Name)
+	p.logger.InfoContext(ctx, "creating VM Scale Set", "pool", pool.ID, "name", name)
+
+	vmss := armcompute.VirtualMachineScaleSet{
+		Name:     &name,
+		Location: &p.region,
+		SKU:      vmssSKU(pool.Spec),
+		Tags:     azureTags(tags),
+		Properties: &armcompute.VirtualMachineScaleSetProperties{
+			OrchestrationMode:     orchestrationMode(pool.Spec),
+			PriorityMixPolicy:     priorityMixPolicy(pool.Spec),
+			SpotRestorePolicy:     spotRestorePolicy(pool.Spec),
+			VirtualMachineProfile: vmssProfile(pool.Spec),
+		},
+	}
+	profile := vmss.Properties.VirtualMachineProfile
+	p.logger.DebugContext(ctx, "prepared VMSS profile",
+		"pool", pool.ID,
+		"customImage", profile.StorageProfile.ImageReference != nil,
+		"customData", profile.OSProfile.CustomData != nil,
+		"tags", len(vmss.Tags),
+	)
+	if vmss.Properties.PriorityMixPolicy != nil {
+		p.logger.InfoContext(ctx, "keeping on-demand base capacity",
+			"pool", pool.ID,
+			"regularPriorityBase", *vmss.Properties.PriorityMixPolicy.BaseRegularPriorityCount,
+		)
+	}
+	if vmss.Properties.OrchestrationMode != nil && pool.Spec.Diversified() {
+		p.logger.InfoContext(ctx, "diversifying spot VM sizes",
+			"pool", pool.ID,
+			"sizes", pool.Spec.AllInstanceTypes(),
+			"allocationStrategy", skuAllocationStrategy(pool.Spec),
+		)
+		// Implementation: Set the scale set's SKU profile (instance mix) to these sizes and strategy
+	}
+
+	// Implementation: Create VMSS with the VM profile
 	return nil
 }
 
-func (p *Provider) createVMScaleSet(ctx context.Context, clusterID string, pool *api.NodePool) error {
-	p.logger.Info("creating VM Scale Set", "pool", pool.ID)
-	// Implementation: Create VMSS
+// checkPoolSupported rejects worker pool features Azure cannot provide
+func checkPoolSupported(spec api.WorkerPoolSpec) error {
+	if spec.WarmPool != nil && spec.WarmPool.Enabled {
+		return fmt.Errorf("node pool %s: warm pools: %w", spec.Name, engine.ErrNotSupported)
+	}
+	if spec.Weighted() {
+		return fmt.Errorf("node pool %s: instance weights: %w", spec.Name, engine.ErrNotSupported)
+	}
 	return nil
 }
 
+var _ engine.VolumeSnapshotter = (*Provider)(nil)
+
+// SnapshotVolumes is not supported: the managed disks of an AKS cluster's
+// persistent volumes live in its node resource group, which provctl does not
+// track
+func (p *Provider) SnapshotVolumes(ctx context.Context, cluster *api.Cluster, taken map[string]string) (map[string]string, error) {
+	return nil, fmt.Errorf("snapshotting the volumes of cluster %s: %w", cluster.Metadata.Name, engine.ErrNotSupported)
+}
+
+// orchestrationMode returns flexible orchestration for a spot pool
+// diversified over several VM sizes or falling back to on-demand, since only
+// flexible scale sets can mix sizes or priorities, or nil to keep the default
+func orchestrationMode(spec api.WorkerPoolSpec) *armcompute.OrchestrationMode {
+	if spec.Spot == nil || !spec.Spot.Enabled || !spec.Diversified() && !spec.Spot.FallbackToOnDemand {
+		return nil
+	}
+	mode := armcompute.OrchestrationModeFlexible
+	return &mode
+}
+
+// priorityMixPolicy keeps the on-demand base of a spot pool falling back to
+// on-demand on regular priority VMs, running the rest as spot VMs, or
+// returns nil for every other pool
+func priorityMixPolicy(spec api.WorkerPoolSpec) *armcompute.PriorityMixPolicy {
+	if spec.Spot == nil || !spec.Spot.Enabled || !spec.Spot.FallbackToOnDemand {
+		return nil
+	}
+	base, aboveBase := int32(spec.OnDemandBase()), int32(0)
+	return &armcompute.PriorityMixPolicy{
+		BaseRegularPriorityCount:           &base,
+		RegularPriorityPercentageAboveBase: &aboveBase,
+	}
+}
+
+// spotRestorePolicy has a spot pool falling back to on-demand restore its
+// evicted spot VMs once capacity returns, or returns nil for every other
+// pool
+func spotRestorePolicy(spec api.WorkerPoolSpec) *armcompute.SpotRestorePolicy {
+	if spec.Spot == nil || !spec.Spot.Enabled || !spec.Spot.FallbackToOnDemand {
+		return nil
+	}
+	enabled := true
+	return &armcompute.SpotRestorePolicy{Enabled: &enabled}
+}
+
+// skuAllocationStrategies maps spot allocation strategies onto the
+// allocation strategies of a scale set's SKU profile
+var skuAllocationStrategies = map[string]string{
+	api.SpotLowestPrice:       "LowestPrice",
+	api.SpotCapacityOptimized: "CapacityOptimized",
+}
+
+// skuAllocationStrategy returns the SKU profile allocation strategy of a
+// spot pool, or "" for Azure's default of the lowest price
+func skuAllocationStrategy(spec api.WorkerPoolSpec) string {
+	if spec.Spot == nil {
+		return ""
+	}
+	return skuAllocationStrategies[spec.Spot.AllocationStrategy]
+}
+
+// azureTags converts a tag map into the pointer map used by Azure resources
+func azureTags(tags map[string]string) map[string]*string {
+	result := make(map[string]*string, len(tags))
+	for key, value := range tags {
+		value := value
+		result[key] = &value
+	}
+	return result
+}
+
+// vmssProfile maps a worker pool spec onto a VMSS VM profile
+func vmssProfile(spec api.WorkerPoolSpec) *armcompute.VirtualMachineScaleSetVMProfile {
+	profile := &armcompute.VirtualMachineScaleSetVMProfile{
+		OSProfile:      &armcompute.VirtualMachineScaleSetOSProfile{},
+		StorageProfile: &armcompute.VirtualMachineScaleSetStorageProfile{},
+	}
+
+	if spec.ImageID != "" {
+		profile.StorageProfile.ImageReference = &armcompute.ImageReference{
+			ID: &spec.ImageID,
+		}
+	}
+	if spec.UserData != "" {
+		// VMSS custom data must be base64 encoded
+		customData := base64.StdEncoding.EncodeToString([]byte(spec.UserData))
+		profile.OSProfile.CustomData = &customData
+	}
+	if spec.SSHKeyName != "" {
+		// Implementation: Resolve the SSH public key resource into LinuxConfiguration
+		profile.OSProfile.LinuxConfiguration = &armcompute.LinuxConfiguration{
+			SSH: &armcompute.SSHConfiguration{},
+		}
+	}
+
+	return profile
+}
+
 func generateClusterID() string {
 	return "cluster-" + generateID()
 }
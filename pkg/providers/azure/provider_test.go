@@ -0,0 +1,416 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestAPIServerAccessProfile(t *testing.T) {
+	deref := func(b *bool) bool { return b != nil && *b }
+
+	tests := []struct {
+		name           string
+		network        api.NetworkSpec
+		wantPrivate    bool
+		wantPublicFQDN bool
+		wantRanges     []string
+	}{
+		{name: "default public", network: api.NetworkSpec{}},
+		{name: "private cluster", network: api.NetworkSpec{PrivateCluster: true}, wantPrivate: true},
+		{
+			name: "public allowlist",
+			network: api.NetworkSpec{APIServerAccess: &api.APIServerAccessSpec{
+				PublicAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24", "198.51.100.7/32"},
+			}},
+			wantRanges: []string{"203.0.113.0/24", "198.51.100.7/32"},
+		},
+		{
+			name: "private with public FQDN",
+			network: api.NetworkSpec{APIServerAccess: &api.APIServerAccessSpec{
+				PublicAccess: true, PrivateAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24"},
+			}},
+			wantPrivate:    true,
+			wantPublicFQDN: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := apiServerAccessProfile(tt.network)
+			if deref(profile.EnablePrivateCluster) != tt.wantPrivate {
+				t.Errorf("EnablePrivateCluster = %v, want %v", deref(profile.EnablePrivateCluster), tt.wantPrivate)
+			}
+			if deref(profile.EnablePrivateClusterPublicFQDN) != tt.wantPublicFQDN {
+				t.Errorf("EnablePrivateClusterPublicFQDN = %v, want %v", deref(profile.EnablePrivateClusterPublicFQDN), tt.wantPublicFQDN)
+			}
+
+			var ranges []string
+			for _, r := range profile.AuthorizedIPRanges {
+				ranges = append(ranges, *r)
+			}
+			if len(ranges) != len(tt.wantRanges) {
+				t.Fatalf("AuthorizedIPRanges = %v, want %v", ranges, tt.wantRanges)
+			}
+			for i := range ranges {
+				if ranges[i] != tt.wantRanges[i] {
+					t.Errorf("AuthorizedIPRanges = %v, want %v", ranges, tt.wantRanges)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckPoolSupported_WarmPool(t *testing.T) {
+	spec := api.WorkerPoolSpec{Name: "general", WarmPool: &api.WarmPoolConfig{Enabled: false}}
+	if err := checkPoolSupported(spec); err != nil {
+		t.Errorf("checkPoolSupported() error = %v for a disabled warm pool", err)
+	}
+
+	spec.WarmPool.Enabled = true
+	if err := checkPoolSupported(spec); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("checkPoolSupported() error = %v, want ErrNotSupported", err)
+	}
+}
+
+// fakeCredential issues a token, or fails with err
+type fakeCredential struct {
+	err error
+}
+
+func (f *fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	if f.err != nil {
+		return azcore.AccessToken{}, f.err
+	}
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// statusTransport answers every ARM request with status
+type statusTransport struct {
+	status int
+}
+
+func (s *statusTransport) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: s.status, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestProvider_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenErr    error
+		status      int
+		wantInvalid bool
+		wantErr     bool
+	}{
+		{name: "valid", status: http.StatusOK},
+		{name: "no token", tokenErr: errors.New("AADSTS7000215: invalid client secret"), wantInvalid: true, wantErr: true},
+		{name: "expired", status: http.StatusUnauthorized, wantInvalid: true, wantErr: true},
+		{name: "no access", status: http.StatusForbidden, wantInvalid: true, wantErr: true},
+		{name: "unknown subscription", status: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &arm.ClientOptions{}
+			options.Transport = &statusTransport{status: tt.status}
+			p := &Provider{
+				subscriptionID: "00000000-0000-0000-0000-000000000000",
+				credential:     &fakeCredential{err: tt.tokenErr},
+				clientOptions:  options,
+				logger:         slog.Default(),
+			}
+			err := p.Validate(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, engine.ErrInvalidCredentials); got != tt.wantInvalid {
+				t.Errorf("Validate() error = %v, want ErrInvalidCredentials %v", err, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestProvider_ManagedCluster(t *testing.T) {
+	p := &Provider{region: "westeurope"}
+	cluster := &api.Cluster{ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+		Network:      api.NetworkSpec{PrivateCluster: true},
+	}}
+
+	req := p.managedCluster(context.Background(), cluster)
+	if req.Location == nil || *req.Location != "westeurope" {
+		t.Errorf("Location = %v, want westeurope", req.Location)
+	}
+	if id := req.Tags[tagClusterID]; id == nil || *id != "cluster-1" {
+		t.Errorf("Tags[%s] = %v, want cluster-1 so node pools can find the cluster", tagClusterID, id)
+	}
+	props := req.Properties
+	if props == nil || props.KubernetesVersion == nil || *props.KubernetesVersion != "1.29" {
+		t.Fatalf("Properties = %+v, want KubernetesVersion 1.29", props)
+	}
+	if props.APIServerAccessProfile == nil || props.APIServerAccessProfile.EnablePrivateCluster == nil || !*props.APIServerAccessProfile.EnablePrivateCluster {
+		t.Errorf("APIServerAccessProfile = %+v, want a private cluster", props.APIServerAccessProfile)
+	}
+}
+
+func TestProvider_ManagedClusterAPIServerAccess(t *testing.T) {
+	p := &Provider{region: "westeurope"}
+
+	t.Run("authorized ranges", func(t *testing.T) {
+		cluster := &api.Cluster{Spec: api.ClusterSpec{Network: api.NetworkSpec{
+			APIServerAccess: &api.APIServerAccessSpec{PublicAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24", "198.51.100.7/32"}},
+		}}}
+
+		profile := p.managedCluster(context.Background(), cluster).Properties.APIServerAccessProfile
+		if profile == nil {
+			t.Fatal("APIServerAccessProfile = nil, want one on the create request")
+		}
+		var ranges []string
+		for _, cidr := range profile.AuthorizedIPRanges {
+			ranges = append(ranges, *cidr)
+		}
+		if want := []string{"203.0.113.0/24", "198.51.100.7/32"}; !reflect.DeepEqual(ranges, want) {
+			t.Errorf("AuthorizedIPRanges = %v, want %v", ranges, want)
+		}
+		if profile.EnablePrivateCluster == nil || *profile.EnablePrivateCluster {
+			t.Errorf("EnablePrivateCluster = %v, want false", profile.EnablePrivateCluster)
+		}
+	})
+
+	t.Run("private with public FQDN", func(t *testing.T) {
+		cluster := &api.Cluster{Spec: api.ClusterSpec{Network: api.NetworkSpec{
+			APIServerAccess: &api.APIServerAccessSpec{PublicAccess: true, PrivateAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24"}},
+		}}}
+
+		profile := p.managedCluster(context.Background(), cluster).Properties.APIServerAccessProfile
+		if profile == nil || profile.EnablePrivateClusterPublicFQDN == nil || !*profile.EnablePrivateClusterPublicFQDN {
+			t.Fatalf("APIServerAccessProfile = %+v, want a private cluster with a public FQDN", profile)
+		}
+		// AKS rejects authorized ranges on private clusters
+		if len(profile.AuthorizedIPRanges) != 0 {
+			t.Errorf("AuthorizedIPRanges = %d ranges, want none on a private cluster", len(profile.AuthorizedIPRanges))
+		}
+	})
+}
+
+func TestSetAKSProperties(t *testing.T) {
+	fqdn := "prod-abc.hcp.westeurope.azmk8s.io"
+	issuer := "https://westeurope.oic.prod-aks.azure.com/tenant/issuer/"
+	subnet := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/nodes"
+	cluster := &armcontainerservice.ManagedCluster{Properties: &armcontainerservice.ManagedClusterProperties{
+		Fqdn:              &fqdn,
+		OidcIssuerProfile: &armcontainerservice.ManagedClusterOIDCIssuerProfile{IssuerURL: &issuer},
+		AgentPoolProfiles: []*armcontainerservice.ManagedClusterAgentPoolProfile{{VnetSubnetID: &subnet}},
+	}}
+
+	var status api.ResourceStatus
+	setAKSProperties(&status, cluster)
+
+	want := map[string]string{
+		api.PropertyEndpoint:   "https://" + fqdn + ":443",
+		api.PropertyOIDCIssuer: issuer,
+		api.PropertyVPCID:      "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+	}
+	if !reflect.DeepEqual(status.Properties, want) {
+		t.Errorf("Properties = %v, want %v", status.Properties, want)
+	}
+}
+
+func TestCheckPoolSupported_InstanceWeights(t *testing.T) {
+	spec := api.WorkerPoolSpec{Name: "batch", InstanceWeights: map[string]int{"Standard_D8s_v5": 2}}
+	if err := checkPoolSupported(spec); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("checkPoolSupported() error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestProvider_SnapshotVolumes(t *testing.T) {
+	p := &Provider{logger: slog.Default()}
+	cluster := &api.Cluster{Metadata: api.ResourceMetadata{Name: "prod"}}
+	if _, err := p.SnapshotVolumes(context.Background(), cluster, nil); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("SnapshotVolumes() error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestOrchestrationMode(t *testing.T) {
+	spec := api.WorkerPoolSpec{Name: "batch", InstanceType: "Standard_D4s_v5", Spot: &api.SpotConfig{Enabled: true}}
+	if mode := orchestrationMode(spec); mode != nil {
+		t.Errorf("orchestrationMode() = %v, want nil for a single size", *mode)
+	}
+
+	spec.InstanceTypes = []string{"Standard_D4s_v3"}
+	if mode := orchestrationMode(spec); mode == nil || *mode != armcompute.OrchestrationModeFlexible {
+		t.Errorf("orchestrationMode() = %v, want Flexible", mode)
+	}
+
+	// Mixing priorities needs flexible orchestration too
+	spec.InstanceTypes = nil
+	spec.Spot.FallbackToOnDemand = true
+	if mode := orchestrationMode(spec); mode == nil || *mode != armcompute.OrchestrationModeFlexible {
+		t.Errorf("orchestrationMode() = %v, want Flexible when falling back to on-demand", mode)
+	}
+}
+
+func TestPriorityMixPolicy(t *testing.T) {
+	spec := api.WorkerPoolSpec{Name: "batch", InstanceType: "Standard_D4s_v5", MinSize: 2, Spot: &api.SpotConfig{Enabled: true}}
+	if policy := priorityMixPolicy(spec); policy != nil {
+		t.Errorf("priorityMixPolicy() = %+v, want nil without fallback", policy)
+	}
+	if policy := spotRestorePolicy(spec); policy != nil {
+		t.Errorf("spotRestorePolicy() = %+v, want nil without fallback", policy)
+	}
+
+	spec.Spot.FallbackToOnDemand = true
+	policy := priorityMixPolicy(spec)
+	if policy == nil || *policy.BaseRegularPriorityCount != 2 || *policy.RegularPriorityPercentageAboveBase != 0 {
+		t.Errorf("priorityMixPolicy() = %+v, want a regular priority base of 2 and spot above it", policy)
+	}
+	if restore := spotRestorePolicy(spec); restore == nil || !*restore.Enabled {
+		t.Errorf("spotRestorePolicy() = %+v, want restoring enabled", restore)
+	}
+}
+
+func TestSKUAllocationStrategy(t *testing.T) {
+	tests := []struct {
+		spot *api.SpotConfig
+		want string
+	}{
+		{spot: nil, want: ""},
+		{spot: &api.SpotConfig{Enabled: true}, want: ""},
+		{spot: &api.SpotConfig{Enabled: true, AllocationStrategy: api.SpotLowestPrice}, want: "LowestPrice"},
+		{spot: &api.SpotConfig{Enabled: true, AllocationStrategy: api.SpotCapacityOptimized}, want: "CapacityOptimized"},
+	}
+
+	for _, tt := range tests {
+		spec := api.WorkerPoolSpec{Name: "batch", Spot: tt.spot}
+		if got := skuAllocationStrategy(spec); got != tt.want {
+			t.Errorf("skuAllocationStrategy(%+v) = %q, want %q", tt.spot, got, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticSettings(t *testing.T) {
+	const workspace = "/subscriptions/sub/resourceGroups/logs/providers/Microsoft.OperationalInsights/workspaces/clusters"
+
+	if got := diagnosticSettings(&api.ObservabilitySpec{ControlPlaneLogs: true}); got != nil {
+		t.Errorf("diagnosticSettings() = %+v, want nil without a workspace", got)
+	}
+
+	got := diagnosticSettings(&api.ObservabilitySpec{Logging: &api.LoggingSpec{Destination: workspace}})
+	if got == nil || got.WorkspaceID != workspace {
+		t.Fatalf("diagnosticSettings() = %+v, want settings for %s", got, workspace)
+	}
+	if want := []string{"kube-apiserver", "kube-audit"}; !reflect.DeepEqual(got.Categories, want) {
+		t.Errorf("Categories = %v, want %v", got.Categories, want)
+	}
+
+	got = diagnosticSettings(&api.ObservabilitySpec{Logging: &api.LoggingSpec{
+		Destination: workspace,
+		Types:       []string{api.LogTypeAuthenticator, api.LogTypeClusterAutoscaler},
+	}})
+	if want := []string{"guard", "cluster-autoscaler"}; !reflect.DeepEqual(got.Categories, want) {
+		t.Errorf("Categories = %v, want %v", got.Categories, want)
+	}
+}
+
+func TestEstimateProvisionTime(t *testing.T) {
+	p := &Provider{}
+	small := api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", DesiredSize: 3}},
+	}
+	large := small
+	large.WorkerPools = []api.WorkerPoolSpec{
+		{Name: "general", DesiredSize: 3},
+		{Name: "batch", DesiredSize: 50},
+	}
+
+	smallEstimate, largeEstimate := p.EstimateProvisionTime(small), p.EstimateProvisionTime(large)
+	if largeEstimate <= smallEstimate {
+		t.Errorf("EstimateProvisionTime(large) = %v, want more than %v", largeEstimate, smallEstimate)
+	}
+
+	// AKS control planes come up faster than the 10m or more EKS takes
+	if smallEstimate >= 15*time.Minute {
+		t.Errorf("EstimateProvisionTime(small) = %v, want an AKS estimate under 15m", smallEstimate)
+	}
+}
+
+func TestProvider_DeleteCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		status  int
+		wantErr bool
+	}{
+		{name: "already gone", group: "rg-prod", status: http.StatusNotFound},
+		{name: "no access", group: "rg-prod", status: http.StatusForbidden, wantErr: true},
+		{name: "no resource group", status: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &arm.ClientOptions{}
+			options.Transport = &statusTransport{status: tt.status}
+			aksClient, err := armcontainerservice.NewManagedClustersClient("00000000-0000-0000-0000-000000000000", &fakeCredential{}, options)
+			if err != nil {
+				t.Fatalf("NewManagedClustersClient() error = %v", err)
+			}
+			p := &Provider{aksClient: aksClient, logger: slog.Default()}
+			cluster := &api.Cluster{
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged}},
+				Status:   api.ResourceStatus{Properties: map[string]string{api.PropertyResourceGroup: tt.group}},
+			}
+
+			if err := p.DeleteCluster(context.Background(), cluster); (err != nil) != tt.wantErr {
+				t.Errorf("DeleteCluster() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProvider_DeleteNotFound(t *testing.T) {
+	ctx := context.Background()
+	p := &Provider{logger: slog.Default()}
+
+	if err := p.DeleteNodePool(ctx, "nodepool-gone"); err != nil {
+		t.Errorf("DeleteNodePool() of a missing pool error = %v, want nil", err)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not found", err: &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ResourceNotFound"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("AKS Delete failed: %w", &azcore.ResponseError{StatusCode: http.StatusNotFound}), want: true},
+		{name: "forbidden", err: &azcore.ResponseError{StatusCode: http.StatusForbidden}},
+		{name: "plain error", err: errors.New("not found")},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNotFound(tt.err); got != tt.want {
+				t.Errorf("isNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
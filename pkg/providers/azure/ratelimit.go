@@ -0,0 +1,42 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"golang.org/x/time/rate"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// DefaultRateLimit keeps provctl well under Azure Resource Manager's
+// throttling of a subscription, whose budget other clients share
+var DefaultRateLimit = engine.RateLimit{RequestsPerSecond: 10, Burst: 20}
+
+// rateLimitPolicy paces the requests of every client it is installed in
+// through one limiter
+type rateLimitPolicy struct {
+	limiter *rate.Limiter
+}
+
+// Do waits for the limiter before passing the request on, giving up when
+// the request's context is done
+func (p rateLimitPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if err := p.limiter.Wait(req.Raw().Context()); err != nil {
+		return nil, fmt.Errorf("waiting for rate limit: %w", err)
+	}
+	return req.Next()
+}
+
+// rateLimitedClientOptions returns ARM client options pacing calls through
+// limiter. The policy runs once per operation, before the SDK's own retries,
+// which back off when Azure throttles anyway.
+func rateLimitedClientOptions(limiter *rate.Limiter) *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			PerCallPolicies: []policy.Policy{rateLimitPolicy{limiter: limiter}},
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// countingTransport answers every request with an empty 200 response
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) Do(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+// rateLimitedPipeline builds a pipeline as the provider's clients do, with
+// the rate limit policy of their client options
+func rateLimitedPipeline(transport policy.Transporter, limit engine.RateLimit) runtime.Pipeline {
+	options := rateLimitedClientOptions(limit.Limiter(DefaultRateLimit))
+	options.Transport = transport
+	return runtime.NewPipeline("provctl", "v1", runtime.PipelineOptions{}, &options.ClientOptions)
+}
+
+func send(ctx context.Context, pipeline runtime.Pipeline) error {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, "https://management.azure.com/subscriptions")
+	if err != nil {
+		return err
+	}
+	_, err = pipeline.Do(req)
+	return err
+}
+
+func TestRateLimitPolicy_PacesBursts(t *testing.T) {
+	transport := &countingTransport{}
+	pipeline := rateLimitedPipeline(transport, engine.RateLimit{RequestsPerSecond: 20, Burst: 2})
+
+	// Concurrent operations share the budget: 2 calls go at once and the
+	// other 4 follow at 20 per second
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := send(context.Background(), pipeline); err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("6 calls took %v, want them paced over about 200ms", elapsed)
+	}
+	if n := transport.requests.Load(); n != 6 {
+		t.Errorf("sent %d requests, want 6", n)
+	}
+}
+
+func TestRateLimitPolicy_Canceled(t *testing.T) {
+	transport := &countingTransport{}
+	pipeline := rateLimitedPipeline(transport, engine.RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+
+	if err := send(context.Background(), pipeline); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := send(ctx, pipeline); err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("Do() error = %v, want the rate limit wait to fail", err)
+	}
+	if n := transport.requests.Load(); n != 1 {
+		t.Errorf("sent %d requests, want only the first", n)
+	}
+}
@@ -0,0 +1,117 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// tagClusterID is set on AKS clusters to the ID provctl gave the cluster, by
+// which node pools created later find it
+const tagClusterID = "provctl.io/cluster-id"
+
+// newResourceGroupName names the resource group created for a cluster's
+// resources. Once created, its name is recorded in the cluster's and node
+// pools' PropertyResourceGroup, which is what later operations use.
+func newResourceGroupName(clusterName string) string {
+	return "rg-" + clusterName
+}
+
+// resourceGroup returns the resource group recorded in a cluster's or node
+// pool's status
+func resourceGroup(kind, id string, status api.ResourceStatus) (string, error) {
+	if group := status.Properties[api.PropertyResourceGroup]; group != "" {
+		return group, nil
+	}
+	return "", fmt.Errorf("%s %s has no recorded resource group", kind, id)
+}
+
+// poolCluster is the AKS cluster a node pool is created in
+type poolCluster struct {
+	name          string
+	resourceGroup string
+	tags          map[string]string // The cluster's tags, without its ID
+}
+
+// poolTags returns the tags of a pool of the cluster
+func (c *poolCluster) poolTags(spec api.WorkerPoolSpec) map[string]string {
+	return api.ClusterSpec{Tags: c.tags}.PoolTags(c.name, spec)
+}
+
+// findPoolCluster finds the AKS cluster provctl created with ID clusterID
+func (p *Provider) findPoolCluster(ctx context.Context, clusterID string) (*poolCluster, error) {
+	pager := p.aksClient.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("AKS List failed: %w", err)
+		}
+		for _, cluster := range page.Value {
+			if cluster == nil || cluster.ID == nil || cluster.Name == nil {
+				continue
+			}
+			if id := cluster.Tags[tagClusterID]; id == nil || *id != clusterID {
+				continue
+			}
+
+			resourceID, err := arm.ParseResourceID(*cluster.ID)
+			if err != nil {
+				return nil, fmt.Errorf("AKS cluster %s: %w", *cluster.Name, err)
+			}
+			found := &poolCluster{
+				name:          *cluster.Name,
+				resourceGroup: resourceID.ResourceGroupName,
+				tags:          make(map[string]string, len(cluster.Tags)),
+			}
+			for key, value := range cluster.Tags {
+				if value != nil && key != tagClusterID {
+					found.tags[key] = *value
+				}
+			}
+			return found, nil
+		}
+	}
+	return nil, fmt.Errorf("no AKS cluster has ID %s", clusterID)
+}
+
+// replaceScaleSet rolls a scale set's VMs onto its new configuration
+func (p *Provider) replaceScaleSet(name string) error {
+	return fmt.Errorf("%w: changing the VM size or image of scale set %s needs its VMs replaced, which provctl cannot do yet; create a new pool and delete the old one",
+		engine.ErrNotSupported, name)
+}
+
+// vmssSKU returns the VM size and initial capacity of a pool's scale set
+func vmssSKU(spec api.WorkerPoolSpec) *armcompute.SKU {
+	capacity := int64(spec.DesiredSize)
+	if capacity == 0 {
+		capacity = int64(spec.MinSize)
+	}
+	return &armcompute.SKU{Name: &spec.InstanceType, Capacity: &capacity}
+}
+
+// observedPoolSpec returns spec with the fields a scale set reports replaced
+// by their current values: its VM size, image and capacity. Scale sets do
+// not hold a pool's labels, taints or scaling bounds, which keep spec's.
+func observedPoolSpec(spec api.WorkerPoolSpec, vmss *armcompute.VirtualMachineScaleSet) api.WorkerPoolSpec {
+	observed := spec
+	if sku := vmss.SKU; sku != nil {
+		if sku.Name != nil {
+			observed.InstanceType = *sku.Name
+		}
+		if sku.Capacity != nil && spec.DesiredSize != 0 {
+			observed.DesiredSize = int(*sku.Capacity)
+		}
+	}
+	if props := vmss.Properties; props != nil && props.VirtualMachineProfile != nil && props.VirtualMachineProfile.StorageProfile != nil {
+		observed.ImageID = ""
+		if image := props.VirtualMachineProfile.StorageProfile.ImageReference; image != nil && image.ID != nil {
+			observed.ImageID = *image.ID
+		}
+	}
+	return observed
+}
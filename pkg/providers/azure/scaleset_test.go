@@ -0,0 +1,207 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v5"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+const testSubscriptionID = "00000000-0000-0000-0000-000000000000"
+
+// armTransport answers ARM requests with the JSON body registered for their
+// method and the end of their path, and records the requests it receives
+type armTransport struct {
+	responses map[string]string // "GET /managedClusters" → body
+	requests  []string          // Method, path and body of each request
+}
+
+func (a *armTransport) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	a.requests = append(a.requests, req.Method+" "+req.URL.Path+" "+string(body))
+
+	for key, response := range a.responses {
+		method, suffix, _ := strings.Cut(key, " ")
+		if req.Method == method && strings.HasSuffix(req.URL.Path, suffix) {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}},
+				Body: io.NopCloser(strings.NewReader(response)), Request: req}, nil
+		}
+	}
+	return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+// armTestProvider returns a provider whose clients are answered by transport
+func armTestProvider(t *testing.T, transport *armTransport) *Provider {
+	t.Helper()
+	options := &arm.ClientOptions{}
+	options.Transport = transport
+	aksClient, err := armcontainerservice.NewManagedClustersClient(testSubscriptionID, &fakeCredential{}, options)
+	if err != nil {
+		t.Fatalf("NewManagedClustersClient() error = %v", err)
+	}
+	scaleSets, err := armcompute.NewVirtualMachineScaleSetsClient(testSubscriptionID, &fakeCredential{}, options)
+	if err != nil {
+		t.Fatalf("NewVirtualMachineScaleSetsClient() error = %v", err)
+	}
+	return &Provider{region: "westeurope", aksClient: aksClient, scaleSets: scaleSets, logger: slog.Default()}
+}
+
+func TestObservedPoolSpec(t *testing.T) {
+	str := func(s string) *string { return &s }
+	i64 := func(n int64) *int64 { return &n }
+
+	vmss := armcompute.VirtualMachineScaleSet{
+		SKU: &armcompute.SKU{Name: str("Standard_D4s_v5"), Capacity: i64(3)},
+		Properties: &armcompute.VirtualMachineScaleSetProperties{
+			VirtualMachineProfile: &armcompute.VirtualMachineScaleSetVMProfile{
+				StorageProfile: &armcompute.VirtualMachineScaleSetStorageProfile{},
+			},
+		},
+	}
+	spec := api.WorkerPoolSpec{
+		Name:         "general",
+		InstanceType: "Standard_D4s_v5",
+		MinSize:      1,
+		MaxSize:      5,
+		DesiredSize:  3,
+		Labels:       map[string]string{"team": "web"},
+	}
+
+	tests := []struct {
+		name   string
+		change func(*api.WorkerPoolSpec)
+		want   api.PoolChange
+	}{
+		{name: "unchanged", change: func(*api.WorkerPoolSpec) {}, want: api.PoolChangeNone},
+		{name: "capacity", change: func(s *api.WorkerPoolSpec) { s.DesiredSize = 4 }, want: api.PoolChangeInPlace},
+		{name: "VM size", change: func(s *api.WorkerPoolSpec) { s.InstanceType = "Standard_D8s_v5" }, want: api.PoolChangeReplacement},
+		{name: "image", change: func(s *api.WorkerPoolSpec) { s.ImageID = "/images/custom" }, want: api.PoolChangeReplacement},
+		{name: "labels", change: func(s *api.WorkerPoolSpec) { s.Labels = map[string]string{"team": "api"} }, want: api.PoolChangeNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := spec
+			tt.change(&desired)
+			if got := observedPoolSpec(desired, &vmss).ClassifyChange(desired); got != tt.want {
+				t.Errorf("ClassifyChange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_UpdateNodePool(t *testing.T) {
+	const current = `{"sku": {"name": "Standard_D4s_v5", "capacity": 3}, "properties": {"virtualMachineProfile": {"storageProfile": {}}}}`
+
+	tests := []struct {
+		name      string
+		spec      api.WorkerPoolSpec
+		wantPatch string
+		wantErr   error
+	}{
+		{name: "unchanged", spec: api.WorkerPoolSpec{Name: "general", InstanceType: "Standard_D4s_v5", DesiredSize: 3}},
+		{name: "resize", spec: api.WorkerPoolSpec{Name: "general", InstanceType: "Standard_D4s_v5", DesiredSize: 5}, wantPatch: `"capacity":5`},
+		{name: "new VM size", spec: api.WorkerPoolSpec{Name: "general", InstanceType: "Standard_D8s_v5", DesiredSize: 5}, wantErr: engine.ErrNotSupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &armTransport{responses: map[string]string{
+				"GET /resourceGroups/rg-prod/providers/Microsoft.Compute/virtualMachineScaleSets/cluster-1-general":   current,
+				"PATCH /resourceGroups/rg-prod/providers/Microsoft.Compute/virtualMachineScaleSets/cluster-1-general": current,
+			}}
+			p := armTestProvider(t, transport)
+			pool := &api.NodePool{ID: "nodepool-1", Spec: tt.spec, Status: api.ResourceStatus{Properties: map[string]string{
+				api.PropertyClusterID:     "cluster-1",
+				api.PropertyResourceGroup: "rg-prod",
+			}}}
+
+			err := p.UpdateNodePool(context.Background(), pool)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateNodePool() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("UpdateNodePool() error = %v", err)
+			}
+
+			var patches []string
+			for _, req := range transport.requests {
+				if strings.HasPrefix(req, "PATCH ") {
+					patches = append(patches, req)
+				}
+			}
+			switch {
+			case tt.wantPatch == "" && len(patches) != 0:
+				t.Errorf("UpdateNodePool() sent %v, want no update", patches)
+			case tt.wantPatch != "" && (len(patches) != 1 || !strings.Contains(patches[0], tt.wantPatch)):
+				t.Errorf("UpdateNodePool() sent %v, want one update with %s", patches, tt.wantPatch)
+			}
+		})
+	}
+}
+
+func TestProvider_CreateNodePoolRecordsCluster(t *testing.T) {
+	transport := &armTransport{responses: map[string]string{
+		"GET /providers/Microsoft.ContainerService/managedClusters": `{"value": [
+			{"id": "/subscriptions/` + testSubscriptionID + `/resourceGroups/rg-other/providers/Microsoft.ContainerService/managedClusters/other",
+			 "name": "other", "tags": {"provctl.io/cluster-id": "cluster-2"}},
+			{"id": "/subscriptions/` + testSubscriptionID + `/resourceGroups/rg-prod/providers/Microsoft.ContainerService/managedClusters/prod",
+			 "name": "prod", "tags": {"provctl.io/cluster-id": "cluster-1", "provctl.io/cluster": "prod", "team": "platform"}}
+		]}`,
+	}}
+	p := armTestProvider(t, transport)
+
+	pool, err := p.CreateNodePool(context.Background(), "cluster-1", api.WorkerPoolSpec{Name: "general", InstanceType: "Standard_D4s_v5", MinSize: 1, MaxSize: 3})
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+	if got := pool.Status.Properties[api.PropertyClusterName]; got != "prod" {
+		t.Errorf("cluster name = %q, want prod", got)
+	}
+	if got := pool.Status.Properties[api.PropertyResourceGroup]; got != "rg-prod" {
+		t.Errorf("resource group = %q, want rg-prod", got)
+	}
+
+	if _, err := p.CreateNodePool(context.Background(), "cluster-3", api.WorkerPoolSpec{Name: "general"}); err == nil {
+		t.Error("CreateNodePool() error = nil, want an error for a cluster AKS does not have")
+	}
+}
+
+func TestPoolCluster_PoolTags(t *testing.T) {
+	cluster := &poolCluster{name: "prod", tags: map[string]string{api.TagCluster: "prod", "team": "platform"}}
+	tags := cluster.poolTags(api.WorkerPoolSpec{Tags: map[string]string{"team": "web"}})
+	if tags[api.TagCluster] != "prod" || tags["team"] != "web" {
+		t.Errorf("poolTags() = %v, want the cluster's name and the pool's team", tags)
+	}
+}
+
+func TestProvider_ReplaceScaleSet(t *testing.T) {
+	p := &Provider{}
+	if err := p.replaceScaleSet("cluster-1-general"); !errors.Is(err, engine.ErrNotSupported) {
+		t.Errorf("replaceScaleSet() error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestResourceGroup(t *testing.T) {
+	status := api.ResourceStatus{Properties: map[string]string{api.PropertyResourceGroup: "rg-shared"}}
+	if group, err := resourceGroup("cluster", "cluster-1", status); err != nil || group != "rg-shared" {
+		t.Errorf("resourceGroup() = %q, %v, want the recorded rg-shared", group, err)
+	}
+	if _, err := resourceGroup("cluster", "cluster-1", api.ResourceStatus{}); err == nil {
+		t.Error("resourceGroup() error = nil, want an error when none is recorded")
+	}
+}
@@ -0,0 +1,42 @@
+package azure
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// secretsProviderAddon is the AKS add-on that mounts Key Vault secrets into
+// pods through the Secrets Store CSI driver
+const secretsProviderAddon = "azureKeyvaultSecretsProvider"
+
+// keyVaultName returns the name of the Key Vault holding a cluster's
+// secrets. Vault names are global, 3 to 24 letters, digits and dashes, and
+// cannot end in or repeat a dash.
+func keyVaultName(clusterName string) string {
+	name := "kv-" + strings.ToLower(clusterName)
+	if len(name) > 24 {
+		name = name[:24]
+	}
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// secretStoreAddons returns the add-on profiles that make a cluster's
+// secrets available in the cluster, or nil without secrets
+func secretStoreAddons(secrets map[string]api.Secret) map[string]*armcontainerservice.ManagedClusterAddonProfile {
+	if len(secrets) == 0 {
+		return nil
+	}
+	enabled, rotation := true, "true"
+	return map[string]*armcontainerservice.ManagedClusterAddonProfile{
+		secretsProviderAddon: {
+			Enabled: &enabled,
+			Config:  map[string]*string{"enableSecretRotation": &rotation},
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestKeyVaultName(t *testing.T) {
+	tests := []struct {
+		cluster string
+		want    string
+	}{
+		{"prod", "kv-prod"},
+		{"Prod-EU", "kv-prod-eu"},
+		{"payments-production-westeurope", "kv-payments-production-w"},
+		{"payments-productions-eu", "kv-payments-productions"},
+	}
+
+	for _, tt := range tests {
+		if got := keyVaultName(tt.cluster); got != tt.want {
+			t.Errorf("keyVaultName(%q) = %q, want %q", tt.cluster, got, tt.want)
+		}
+	}
+}
+
+func TestSecretStoreAddons(t *testing.T) {
+	if addons := secretStoreAddons(nil); addons != nil {
+		t.Errorf("secretStoreAddons(nil) = %v, want none", addons)
+	}
+
+	addons := secretStoreAddons(map[string]api.Secret{"token": "value"})
+	addon := addons[secretsProviderAddon]
+	if addon == nil || addon.Enabled == nil || !*addon.Enabled {
+		t.Fatalf("secretStoreAddons() = %v, want the secrets provider enabled", addons)
+	}
+}
@@ -0,0 +1,114 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// maxNodePoolSkew is how many minor versions AKS node pools may lag behind
+// the control plane
+const maxNodePoolSkew = 3
+
+var _ engine.UpgradePreflighter = (*Provider)(nil)
+
+// UpgradePreflight checks the AKS upgrade profile of a cluster: whether AKS
+// offers the target version as an upgrade, and whether its node pools stay
+// within the supported version skew once the control plane is upgraded.
+// AKS does not report deprecated API usage through its API, so those are
+// only covered by the engine's generic checks.
+func (p *Provider) UpgradePreflight(ctx context.Context, cluster *api.Cluster, targetVersion string) (engine.PreflightResult, error) {
+	result := engine.PreflightResult{CurrentVersion: cluster.Spec.ControlPlane.Version, TargetVersion: targetVersion}
+	if cluster.Spec.ControlPlane.Type == api.ControlPlaneSelfManaged {
+		// Clusters running their own control plane have no AKS upgrade profile
+		return result, nil
+	}
+
+	name := cluster.Metadata.Name
+	group, err := resourceGroup("cluster", cluster.ID, cluster.Status)
+	if err != nil {
+		return result, err
+	}
+	profile, err := p.aksClient.GetUpgradeProfile(ctx, group, name, nil)
+	if err != nil {
+		return result, fmt.Errorf("AKS GetUpgradeProfile failed: %w", err)
+	}
+	result.Issues = upgradeProfileIssues(profile.Properties, targetVersion)
+	return result, nil
+}
+
+// upgradeProfileIssues returns the preflight issues an AKS upgrade profile
+// reveals for an upgrade to targetVersion
+func upgradeProfileIssues(profile *armcontainerservice.ManagedClusterUpgradeProfileProperties, targetVersion string) []engine.PreflightIssue {
+	if profile == nil || profile.ControlPlaneProfile == nil {
+		return nil
+	}
+
+	var issues []engine.PreflightIssue
+	var offered []string
+	found, stable := false, false
+	for _, upgrade := range profile.ControlPlaneProfile.Upgrades {
+		if upgrade == nil || upgrade.KubernetesVersion == nil {
+			continue
+		}
+		version := *upgrade.KubernetesVersion
+		offered = append(offered, version)
+		if matchesVersion(version, targetVersion) {
+			found = true
+			stable = stable || upgrade.IsPreview == nil || !*upgrade.IsPreview
+		}
+	}
+	switch {
+	case !found:
+		available := "none"
+		if len(offered) > 0 {
+			available = strings.Join(offered, ", ")
+		}
+		issues = append(issues, engine.PreflightIssue{
+			Severity: engine.PreflightBlocking,
+			Category: engine.PreflightVersionSkew,
+			Summary:  fmt.Sprintf("AKS offers no upgrade to Kubernetes %s for this cluster (available: %s)", targetVersion, available),
+		})
+	case !stable:
+		issues = append(issues, engine.PreflightIssue{
+			Severity: engine.PreflightWarning,
+			Category: engine.PreflightVersionSkew,
+			Summary:  fmt.Sprintf("Kubernetes %s is a preview version on AKS", targetVersion),
+		})
+	}
+
+	target, err := engine.MinorVersion(targetVersion)
+	if err != nil {
+		return issues
+	}
+	var lagging []string
+	for _, pool := range profile.AgentPoolProfiles {
+		if pool == nil || pool.Name == nil || pool.KubernetesVersion == nil {
+			continue
+		}
+		minor, err := engine.MinorVersion(*pool.KubernetesVersion)
+		if err == nil && target-minor > maxNodePoolSkew {
+			lagging = append(lagging, *pool.Name)
+		}
+	}
+	if len(lagging) > 0 {
+		issues = append(issues, engine.PreflightIssue{
+			Severity:  engine.PreflightBlocking,
+			Category:  engine.PreflightVersionSkew,
+			Summary:   fmt.Sprintf("node pools would lag more than %d minor versions behind Kubernetes %s; upgrade them first", maxNodePoolSkew, targetVersion),
+			Resources: lagging,
+		})
+	}
+	return issues
+}
+
+// matchesVersion reports whether an AKS version such as "1.30.2" is the
+// version a spec names, which may leave out the patch ("1.30")
+func matchesVersion(version, spec string) bool {
+	return version == spec || strings.HasPrefix(version, spec+".")
+}
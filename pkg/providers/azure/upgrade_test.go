@@ -0,0 +1,93 @@
+package azure
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v4"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestUpgradeProfileIssues(t *testing.T) {
+	str := func(s string) *string { return &s }
+	profile := func(pools map[string]string, upgrades ...*armcontainerservice.ManagedClusterPoolUpgradeProfileUpgradesItem) *armcontainerservice.ManagedClusterUpgradeProfileProperties {
+		properties := &armcontainerservice.ManagedClusterUpgradeProfileProperties{
+			ControlPlaneProfile: &armcontainerservice.ManagedClusterPoolUpgradeProfile{
+				KubernetesVersion: str("1.28.5"),
+				Upgrades:          upgrades,
+			},
+		}
+		for name, version := range pools {
+			properties.AgentPoolProfiles = append(properties.AgentPoolProfiles, &armcontainerservice.ManagedClusterPoolUpgradeProfile{
+				Name:              str(name),
+				KubernetesVersion: str(version),
+			})
+		}
+		return properties
+	}
+	upgrade := func(version string, preview bool) *armcontainerservice.ManagedClusterPoolUpgradeProfileUpgradesItem {
+		return &armcontainerservice.ManagedClusterPoolUpgradeProfileUpgradesItem{KubernetesVersion: str(version), IsPreview: &preview}
+	}
+
+	tests := []struct {
+		name     string
+		profile  *armcontainerservice.ManagedClusterUpgradeProfileProperties
+		target   string
+		severity engine.PreflightSeverity
+		want     string
+	}{
+		{name: "offered", profile: profile(nil, upgrade("1.29.0", false), upgrade("1.29.2", false)), target: "1.29"},
+		{name: "not offered", profile: profile(nil, upgrade("1.29.2", false)), target: "1.30", severity: engine.PreflightBlocking, want: "available: 1.29.2"},
+		{name: "preview", profile: profile(nil, upgrade("1.29.2", true)), target: "1.29", severity: engine.PreflightWarning, want: "preview"},
+		{name: "lagging pool", profile: profile(map[string]string{"old": "1.25.6", "system": "1.28.5"}, upgrade("1.29.2", false)), target: "1.29", severity: engine.PreflightBlocking, want: "(old)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := upgradeProfileIssues(tt.profile, tt.target)
+			if tt.want == "" {
+				if len(issues) != 0 {
+					t.Errorf("upgradeProfileIssues() = %v, want none", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Severity != tt.severity || !strings.Contains(issues[0].String(), tt.want) {
+				t.Errorf("upgradeProfileIssues() = %v, want one %s issue containing %q", issues, tt.severity, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_UpgradePreflight(t *testing.T) {
+	options := &arm.ClientOptions{}
+	options.Transport = &statusTransport{status: http.StatusForbidden}
+	aksClient, err := armcontainerservice.NewManagedClustersClient("00000000-0000-0000-0000-000000000000", &fakeCredential{}, options)
+	if err != nil {
+		t.Fatalf("NewManagedClustersClient() error = %v", err)
+	}
+	p := &Provider{aksClient: aksClient, logger: slog.Default()}
+	cluster := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged, Version: "1.28"}},
+		Status:   api.ResourceStatus{Properties: map[string]string{api.PropertyResourceGroup: "rg-prod"}},
+	}
+
+	// The cluster from state is checked against AKS, whose error surfaces
+	if _, err := p.UpgradePreflight(context.Background(), cluster, "1.29"); err == nil || !strings.Contains(err.Error(), "GetUpgradeProfile") {
+		t.Errorf("UpgradePreflight() error = %v, want the GetUpgradeProfile failure", err)
+	}
+
+	// Self-managed control planes have no AKS upgrade profile
+	cluster.Spec.ControlPlane.Type = api.ControlPlaneSelfManaged
+	result, err := p.UpgradePreflight(context.Background(), cluster, "1.29")
+	if err != nil || result.CurrentVersion != "1.28" || len(result.Issues) != 0 {
+		t.Errorf("UpgradePreflight(self-managed) = %+v, %v, want current version 1.28 and no issues", result, err)
+	}
+}
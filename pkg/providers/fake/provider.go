@@ -0,0 +1,485 @@
+// Package fake implements an in-memory cloud provider for tests
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Call records a single method invocation on the provider
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Provider is an in-memory CloudProvider. Clusters and node pools live in
+// maps, every call is recorded, and errors can be injected per method.
+// It is safe for concurrent use.
+type Provider struct {
+	name string
+
+	mu          sync.Mutex
+	clusters    map[string]*api.Cluster
+	nodePools   map[string]*api.NodePool
+	poolCluster map[string]string // node pool ID -> cluster ID
+	calls       []Call
+	counts      map[string]int
+	faults      map[string]fault
+	secrets     map[string]map[string]api.Secret // Cluster name -> secrets injected into it
+	nextID      int
+
+	provisionTime time.Duration
+	preflight     []engine.PreflightIssue
+}
+
+type fault struct {
+	call int
+	err  error
+}
+
+var (
+	_ engine.CloudProvider      = (*Provider)(nil)
+	_ engine.UpgradePreflighter = (*Provider)(nil)
+)
+
+// NewProvider creates an empty fake provider reporting the given name
+func NewProvider(name string) *Provider {
+	return &Provider{
+		name:        name,
+		clusters:    make(map[string]*api.Cluster),
+		nodePools:   make(map[string]*api.NodePool),
+		poolCluster: make(map[string]string),
+		counts:      make(map[string]int),
+		faults:      make(map[string]fault),
+		secrets:     make(map[string]map[string]api.Secret),
+	}
+}
+
+// SeedCluster adds an existing cluster without recording a call
+func (p *Provider) SeedCluster(cluster *api.Cluster) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clusters[cluster.ID] = copyCluster(cluster)
+}
+
+// SeedNodePool adds an existing node pool to a cluster without recording a call
+func (p *Provider) SeedNodePool(clusterID string, pool *api.NodePool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nodePools[pool.ID] = copyNodePool(pool)
+	p.poolCluster[pool.ID] = clusterID
+}
+
+// SetProvisionTime sets the estimate EstimateProvisionTime returns
+func (p *Provider) SetProvisionTime(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.provisionTime = d
+}
+
+// SetUpgradePreflight sets the issues UpgradePreflight reports for any
+// cluster and version
+func (p *Provider) SetUpgradePreflight(issues ...engine.PreflightIssue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.preflight = issues
+}
+
+// FailOn makes the nth call (1-based) to method return err. Use n <= 0 to
+// fail every call to method.
+func (p *Provider) FailOn(method string, n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.faults[method] = fault{call: n, err: err}
+}
+
+// Calls returns every recorded call in order
+func (p *Provider) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	calls := make([]Call, len(p.calls))
+	copy(calls, p.calls)
+	return calls
+}
+
+// CallCount returns how many times method has been called
+func (p *Provider) CallCount(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.counts[method]
+}
+
+// Clusters returns a copy of the clusters currently held by the provider
+func (p *Provider) Clusters() map[string]*api.Cluster {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clusters := make(map[string]*api.Cluster, len(p.clusters))
+	for id, cluster := range p.clusters {
+		clusters[id] = copyCluster(cluster)
+	}
+	return clusters
+}
+
+// NodePools returns a copy of the node pools currently held by the provider
+func (p *Provider) NodePools() map[string]*api.NodePool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pools := make(map[string]*api.NodePool, len(p.nodePools))
+	for id, pool := range p.nodePools {
+		pools[id] = copyNodePool(pool)
+	}
+	return pools
+}
+
+// Secrets returns the secrets last injected into the named cluster
+func (p *Provider) Secrets(clusterName string) map[string]api.Secret {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.secrets[clusterName]
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// CreateCluster creates a new cluster in memory
+func (p *Provider) CreateCluster(ctx context.Context, spec api.ClusterSpec) (*api.Cluster, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("CreateCluster", spec); err != nil {
+		return nil, err
+	}
+
+	name, _ := spec.Config["name"].(string)
+	cluster := &api.Cluster{
+		ID: p.generateID("cluster"),
+		Metadata: api.ResourceMetadata{
+			Name: name,
+		},
+		Spec: spec,
+		Status: api.ResourceStatus{
+			Phase: api.PhaseRunning,
+		},
+	}
+
+	cluster.Metadata.Touch(time.Now())
+
+	p.clusters[cluster.ID] = copyCluster(cluster)
+	p.injectSecrets(ctx, name)
+	return cluster, nil
+}
+
+// EstimateProvisionTime returns the time set with SetProvisionTime, zero by
+// default
+func (p *Provider) EstimateProvisionTime(spec api.ClusterSpec) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.provisionTime
+}
+
+// UpdateCluster replaces the stored cluster
+func (p *Provider) UpdateCluster(ctx context.Context, cluster *api.Cluster) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("UpdateCluster", cluster); err != nil {
+		return err
+	}
+
+	if _, exists := p.clusters[cluster.ID]; !exists {
+		return fmt.Errorf("cluster %s not found", cluster.ID)
+	}
+
+	cluster.Metadata.Touch(time.Now())
+	p.clusters[cluster.ID] = copyCluster(cluster)
+	p.injectSecrets(ctx, cluster.Metadata.Name)
+	return nil
+}
+
+// DeleteCluster removes a cluster and its node pools. Like the real
+// providers it succeeds for a cluster that does not exist.
+func (p *Provider) DeleteCluster(ctx context.Context, cluster *api.Cluster) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clusterID := cluster.ID
+	if err := p.record("DeleteCluster", clusterID); err != nil {
+		return err
+	}
+
+	delete(p.clusters, clusterID)
+	for poolID, owner := range p.poolCluster {
+		if owner == clusterID {
+			delete(p.nodePools, poolID)
+			delete(p.poolCluster, poolID)
+		}
+	}
+	return nil
+}
+
+// GetCluster returns the cluster, or nil if it does not exist
+func (p *Provider) GetCluster(ctx context.Context, clusterID string) (*api.Cluster, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("GetCluster", clusterID); err != nil {
+		return nil, err
+	}
+
+	cluster, exists := p.clusters[clusterID]
+	if !exists {
+		return nil, nil
+	}
+	return copyCluster(cluster), nil
+}
+
+// UpgradePreflight reports the issues set with SetUpgradePreflight
+func (p *Provider) UpgradePreflight(ctx context.Context, cluster *api.Cluster, targetVersion string) (engine.PreflightResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("UpgradePreflight", cluster.ID, targetVersion); err != nil {
+		return engine.PreflightResult{}, err
+	}
+
+	result := engine.PreflightResult{
+		CurrentVersion: cluster.Spec.ControlPlane.Version,
+		TargetVersion:  targetVersion,
+	}
+	result.Issues = append(result.Issues, p.preflight...)
+	return result, nil
+}
+
+// CreateNodePool creates a node pool in the given cluster, or updates the
+// existing pool if one with the same name already exists. The lookup and
+// the insert happen under one lock, so concurrent creates of the same pool
+// create it once.
+func (p *Provider) CreateNodePool(ctx context.Context, clusterID string, spec api.WorkerPoolSpec) (*api.NodePool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("CreateNodePool", clusterID, spec); err != nil {
+		return nil, err
+	}
+	if _, exists := p.clusters[clusterID]; !exists {
+		return nil, fmt.Errorf("cluster %s not found", clusterID)
+	}
+
+	pools, err := p.listNodePools(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node pools: %w", err)
+	}
+	for _, existing := range pools {
+		if existing.Spec.Name != spec.Name {
+			continue
+		}
+		existing.Spec = spec
+		if err := p.updateNodePool(existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	pool := &api.NodePool{
+		ID: p.generateID("nodepool"),
+		Metadata: api.ResourceMetadata{
+			Name: spec.Name,
+		},
+		Spec: spec,
+		Status: api.ResourceStatus{
+			Phase: api.PhaseRunning,
+		},
+	}
+
+	pool.Metadata.Touch(time.Now())
+
+	p.nodePools[pool.ID] = copyNodePool(pool)
+	p.poolCluster[pool.ID] = clusterID
+	return pool, nil
+}
+
+// UpdateNodePool replaces the stored node pool
+func (p *Provider) UpdateNodePool(ctx context.Context, pool *api.NodePool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.updateNodePool(pool)
+}
+
+// updateNodePool is UpdateNodePool for callers holding p.mu
+func (p *Provider) updateNodePool(pool *api.NodePool) error {
+	if err := p.record("UpdateNodePool", pool); err != nil {
+		return err
+	}
+
+	if _, exists := p.nodePools[pool.ID]; !exists {
+		return fmt.Errorf("node pool %s not found", pool.ID)
+	}
+
+	pool.Metadata.Touch(time.Now())
+	p.nodePools[pool.ID] = copyNodePool(pool)
+	return nil
+}
+
+// DeleteNodePool removes a node pool, succeeding for one that does not exist
+func (p *Provider) DeleteNodePool(ctx context.Context, poolID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("DeleteNodePool", poolID); err != nil {
+		return err
+	}
+
+	delete(p.nodePools, poolID)
+	delete(p.poolCluster, poolID)
+	return nil
+}
+
+// ListNodePools returns the node pools of a cluster ordered by name
+func (p *Provider) ListNodePools(ctx context.Context, clusterID string) ([]*api.NodePool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.listNodePools(clusterID)
+}
+
+// listNodePools is ListNodePools for callers holding p.mu
+func (p *Provider) listNodePools(clusterID string) ([]*api.NodePool, error) {
+	if err := p.record("ListNodePools", clusterID); err != nil {
+		return nil, err
+	}
+
+	var pools []*api.NodePool
+	for poolID, owner := range p.poolCluster {
+		if owner == clusterID {
+			pools = append(pools, copyNodePool(p.nodePools[poolID]))
+		}
+	}
+
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Metadata.Name < pools[j].Metadata.Name
+	})
+	return pools, nil
+}
+
+// Reconcile returns create, update and delete actions for clusters that
+// differ between desired and actual state
+func (p *Provider) Reconcile(ctx context.Context, desired, actual engine.State) (engine.Plan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.record("Reconcile", desired, actual); err != nil {
+		return engine.Plan{}, err
+	}
+
+	plan := engine.Plan{
+		Actions: []engine.Action{},
+	}
+
+	for _, id := range sortedKeys(desired.Clusters) {
+		cluster := desired.Clusters[id]
+		existing, exists := actual.Clusters[id]
+
+		actionType := engine.ActionNoop
+		switch {
+		case !exists:
+			actionType = engine.ActionCreate
+		case !cluster.Spec.Equal(existing.Spec):
+			actionType = engine.ActionUpdate
+		}
+		if actionType == engine.ActionNoop {
+			continue
+		}
+
+		plan.Actions = append(plan.Actions, engine.Action{
+			Type:     actionType,
+			Resource: clusterResource(p.name, cluster),
+		})
+	}
+
+	for _, id := range sortedKeys(actual.Clusters) {
+		if _, exists := desired.Clusters[id]; !exists {
+			plan.Actions = append(plan.Actions, engine.Action{
+				Type:     engine.ActionDelete,
+				Resource: clusterResource(p.name, actual.Clusters[id]),
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// record logs a call and returns an injected error if one is due.
+// Callers must hold p.mu.
+func (p *Provider) record(method string, args ...interface{}) error {
+	p.calls = append(p.calls, Call{Method: method, Args: args})
+	p.counts[method]++
+
+	f, exists := p.faults[method]
+	if !exists {
+		return nil
+	}
+	if f.call <= 0 || f.call == p.counts[method] {
+		return f.err
+	}
+	return nil
+}
+
+// injectSecrets records the secrets the engine passed for a cluster, as a
+// real provider would inject them into its nodes. Callers must hold p.mu.
+func (p *Provider) injectSecrets(ctx context.Context, clusterName string) {
+	if secrets := engine.SecretsFrom(ctx); len(secrets) > 0 {
+		p.secrets[clusterName] = secrets
+	}
+}
+
+func (p *Provider) generateID(prefix string) string {
+	p.nextID++
+	return fmt.Sprintf("%s-%s-%d", prefix, p.name, p.nextID)
+}
+
+func clusterResource(provider string, cluster *api.Cluster) api.ResourceID {
+	return api.ResourceID{
+		Provider: provider,
+		Kind:     "Cluster",
+		ID:       cluster.ID,
+		Name:     cluster.Metadata.Name,
+	}
+}
+
+// copyCluster and copyNodePool return deep copies, so that neither callers
+// nor the provider see the other's later changes to maps or slices
+func copyCluster(cluster *api.Cluster) *api.Cluster {
+	return api.DeepCopy(cluster)
+}
+
+func copyNodePool(pool *api.NodePool) *api.NodePool {
+	return api.DeepCopy(pool)
+}
+
+func sortedKeys(clusters map[string]*api.Cluster) []string {
+	keys := make([]string, 0, len(clusters))
+	for id := range clusters {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+	return keys
+}
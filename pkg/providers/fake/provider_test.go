@@ -0,0 +1,259 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestProvider_Timestamps(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+
+	cluster, err := p.CreateCluster(ctx, api.ClusterSpec{Provider: "aws", Config: map[string]interface{}{"name": "prod"}})
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+	created := cluster.Metadata.CreatedAt
+	if created.IsZero() || !cluster.Metadata.UpdatedAt.Equal(created) {
+		t.Fatalf("new cluster metadata = %+v, want CreatedAt = UpdatedAt, both set", cluster.Metadata)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := p.UpdateCluster(ctx, cluster); err != nil {
+		t.Fatalf("UpdateCluster() error = %v", err)
+	}
+	got, _ := p.GetCluster(ctx, cluster.ID)
+	if !got.Metadata.CreatedAt.Equal(created) || !got.Metadata.UpdatedAt.After(created) {
+		t.Errorf("updated cluster metadata = %+v, want CreatedAt kept and UpdatedAt bumped", got.Metadata)
+	}
+
+	pool, err := p.CreateNodePool(ctx, cluster.ID, api.WorkerPoolSpec{Name: "general"})
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+	if pool.Metadata.CreatedAt.IsZero() || pool.Metadata.UpdatedAt.IsZero() {
+		t.Errorf("new node pool metadata = %+v, want timestamps set", pool.Metadata)
+	}
+}
+
+func TestProvider_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+
+	cluster, err := p.CreateCluster(ctx, api.ClusterSpec{
+		Provider: "aws",
+		Config:   map[string]interface{}{"name": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCluster() error = %v", err)
+	}
+
+	if _, err := p.CreateNodePool(ctx, cluster.ID, api.WorkerPoolSpec{Name: "general"}); err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+
+	got, err := p.GetCluster(ctx, cluster.ID)
+	if err != nil {
+		t.Fatalf("GetCluster() error = %v", err)
+	}
+	if got == nil || got.Metadata.Name != "prod" {
+		t.Errorf("GetCluster() = %v, want cluster prod", got)
+	}
+
+	pools, err := p.ListNodePools(ctx, cluster.ID)
+	if err != nil {
+		t.Fatalf("ListNodePools() error = %v", err)
+	}
+	if len(pools) != 1 || pools[0].Spec.Name != "general" {
+		t.Errorf("ListNodePools() = %v, want [general]", pools)
+	}
+
+	if err := p.DeleteCluster(ctx, cluster); err != nil {
+		t.Fatalf("DeleteCluster() error = %v", err)
+	}
+	if len(p.NodePools()) != 0 {
+		t.Error("DeleteCluster() should remove the cluster's node pools")
+	}
+	if err := p.DeleteCluster(ctx, cluster); err != nil {
+		t.Errorf("DeleteCluster() of a deleted cluster error = %v, want nil", err)
+	}
+
+	if p.CallCount("CreateCluster") != 1 {
+		t.Errorf("CallCount(CreateCluster) = %d, want 1", p.CallCount("CreateCluster"))
+	}
+	if calls := p.Calls(); len(calls) != 7 || calls[0].Method != "CreateCluster" {
+		t.Errorf("Calls() = %v, want 7 calls starting with CreateCluster", calls)
+	}
+}
+
+func TestProvider_CopiesResources(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+
+	seeded := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod", Labels: map[string]string{"env": "prod"}},
+		Spec: api.ClusterSpec{
+			Tags:        map[string]string{"team": "platform"},
+			WorkerPools: []api.WorkerPoolSpec{{Name: "general"}},
+		},
+	}
+	p.SeedCluster(seeded)
+	seeded.Metadata.Labels["env"] = "staging"
+
+	got, err := p.GetCluster(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("GetCluster() error = %v", err)
+	}
+	got.Spec.Tags["team"] = "data"
+	got.Spec.WorkerPools[0].Name = "batch"
+
+	stored := p.Clusters()["cluster-1"]
+	if stored.Metadata.Labels["env"] != "prod" || stored.Spec.Tags["team"] != "platform" ||
+		stored.Spec.WorkerPools[0].Name != "general" {
+		t.Errorf("stored cluster = %+v, want it unaffected by changes to seeded and returned copies", stored)
+	}
+}
+
+func TestProvider_CreateNodePoolIdempotent(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+	p.SeedCluster(&api.Cluster{ID: "cluster-1"})
+
+	first, err := p.CreateNodePool(ctx, "cluster-1", api.WorkerPoolSpec{Name: "general", DesiredSize: 3})
+	if err != nil {
+		t.Fatalf("CreateNodePool() error = %v", err)
+	}
+
+	second, err := p.CreateNodePool(ctx, "cluster-1", api.WorkerPoolSpec{Name: "general", DesiredSize: 5})
+	if err != nil {
+		t.Fatalf("second CreateNodePool() error = %v", err)
+	}
+
+	if second.ID != first.ID {
+		t.Errorf("second CreateNodePool() ID = %s, want existing %s", second.ID, first.ID)
+	}
+
+	pools, err := p.ListNodePools(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("ListNodePools() error = %v", err)
+	}
+	if len(pools) != 1 {
+		t.Fatalf("ListNodePools() got %d pools, want 1", len(pools))
+	}
+	if pools[0].Spec.DesiredSize != 5 {
+		t.Errorf("DesiredSize = %d, want 5", pools[0].Spec.DesiredSize)
+	}
+	if n := p.CallCount("UpdateNodePool"); n != 1 {
+		t.Errorf("CallCount(UpdateNodePool) = %d, want 1", n)
+	}
+}
+
+func TestProvider_CreateNodePoolConcurrent(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+	p.SeedCluster(&api.Cluster{ID: "cluster-1"})
+
+	const creates = 50
+	ids := make([]string, creates)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			pool, err := p.CreateNodePool(ctx, "cluster-1", api.WorkerPoolSpec{Name: "general", DesiredSize: i})
+			if err != nil {
+				t.Errorf("CreateNodePool() error = %v", err)
+				return
+			}
+			ids[i] = pool.ID
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	pools, err := p.ListNodePools(ctx, "cluster-1")
+	if err != nil {
+		t.Fatalf("ListNodePools() error = %v", err)
+	}
+	if len(pools) != 1 {
+		t.Fatalf("concurrent CreateNodePool() left %d pools, want 1", len(pools))
+	}
+	for i, id := range ids {
+		if id != pools[0].ID {
+			t.Errorf("CreateNodePool() %d returned pool %s, want %s", i, id, pools[0].ID)
+		}
+	}
+}
+
+func TestProvider_Seed(t *testing.T) {
+	p := NewProvider("azure")
+	p.SeedCluster(&api.Cluster{ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "seeded"}})
+	seededAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pool := &api.NodePool{
+		ID:       "pool-1",
+		Metadata: api.ResourceMetadata{CreatedAt: seededAt, UpdatedAt: seededAt},
+		Spec:     api.WorkerPoolSpec{Name: "system"},
+	}
+	p.SeedNodePool("cluster-1", pool)
+	pool.Spec.Name = "changed"
+
+	if len(p.Calls()) != 0 {
+		t.Error("seeding should not record calls")
+	}
+
+	// Seeding copies the pool as given, timestamps included
+	if !pool.Metadata.UpdatedAt.Equal(seededAt) {
+		t.Errorf("SeedNodePool() changed the caller's pool UpdatedAt to %v", pool.Metadata.UpdatedAt)
+	}
+	seeded := p.NodePools()["pool-1"]
+	if seeded == nil || seeded.Spec.Name != "system" || !seeded.Metadata.UpdatedAt.Equal(seededAt) {
+		t.Errorf("NodePools() = %+v, want pool system updated at %v", seeded, seededAt)
+	}
+
+	got, err := p.GetCluster(context.Background(), "cluster-1")
+	if err != nil || got == nil {
+		t.Fatalf("GetCluster() = %v, %v, want seeded cluster", got, err)
+	}
+
+	missing, err := p.GetCluster(context.Background(), "cluster-2")
+	if err != nil || missing != nil {
+		t.Errorf("GetCluster() for unknown ID = %v, %v, want nil, nil", missing, err)
+	}
+}
+
+func TestProvider_FailOn(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider("aws")
+	p.SeedCluster(&api.Cluster{ID: "cluster-1"})
+
+	injected := errors.New("throttled")
+	p.FailOn("GetCluster", 2, injected)
+
+	if _, err := p.GetCluster(ctx, "cluster-1"); err != nil {
+		t.Errorf("first GetCluster() error = %v, want nil", err)
+	}
+	if _, err := p.GetCluster(ctx, "cluster-1"); !errors.Is(err, injected) {
+		t.Errorf("second GetCluster() error = %v, want %v", err, injected)
+	}
+	if _, err := p.GetCluster(ctx, "cluster-1"); err != nil {
+		t.Errorf("third GetCluster() error = %v, want nil", err)
+	}
+
+	p.FailOn("DeleteCluster", 0, injected)
+	for i := 0; i < 2; i++ {
+		if err := p.DeleteCluster(ctx, &api.Cluster{ID: "cluster-1"}); !errors.Is(err, injected) {
+			t.Errorf("DeleteCluster() error = %v, want %v", err, injected)
+		}
+	}
+	if len(p.Clusters()) != 1 {
+		t.Error("failed DeleteCluster() should leave the cluster in place")
+	}
+}
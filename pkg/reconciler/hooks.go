@@ -0,0 +1,97 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vjranagit/cluster-api/pkg/drift"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// Hooks run custom logic at points of the reconcile lifecycle, such as
+// pushing metrics or sending notifications. They are called synchronously,
+// so a slow hook slows reconciliation. Any hook may be nil. A hook that
+// panics is recovered and logged.
+type Hooks struct {
+	// OnCycleStart is called as a reconciliation cycle starts
+	OnCycleStart func(ctx context.Context)
+
+	// OnDriftDetected is called when a cluster differs from its desired
+	// state, before anything changes. Returning an error leaves the cluster
+	// as it is, gating the change externally; so does panicking.
+	OnDriftDetected func(ctx context.Context, report *drift.DriftReport) error
+
+	// OnApplied is called after a plan bringing a cluster to its desired
+	// state was applied
+	OnApplied func(ctx context.Context, plan *engine.Plan)
+
+	// OnError is called when reconciling fails
+	OnError func(ctx context.Context, err error)
+}
+
+// Option configures a Reconciler
+type Option func(*Reconciler)
+
+// WithHooks adds a set of hooks. Sets are called in the order they were
+// added.
+func WithHooks(hooks Hooks) Option {
+	return func(r *Reconciler) {
+		r.hooks = append(r.hooks, hooks)
+	}
+}
+
+func (r *Reconciler) cycleStarted(ctx context.Context) {
+	for _, hooks := range r.hooks {
+		if hooks.OnCycleStart != nil {
+			r.runHook(ctx, "OnCycleStart", func() { hooks.OnCycleStart(ctx) })
+		}
+	}
+}
+
+// driftDetected calls every OnDriftDetected hook, returning the first
+// refusal. Later hooks are not called once one refuses.
+func (r *Reconciler) driftDetected(ctx context.Context, report *drift.DriftReport) error {
+	for _, hooks := range r.hooks {
+		if hooks.OnDriftDetected == nil {
+			continue
+		}
+		var err error
+		if !r.runHook(ctx, "OnDriftDetected", func() { err = hooks.OnDriftDetected(ctx, report) }) {
+			err = errors.New("OnDriftDetected hook panicked")
+		}
+		if err != nil {
+			return fmt.Errorf("change refused by hook: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) applied(ctx context.Context, plan *engine.Plan) {
+	for _, hooks := range r.hooks {
+		if hooks.OnApplied != nil {
+			r.runHook(ctx, "OnApplied", func() { hooks.OnApplied(ctx, plan) })
+		}
+	}
+}
+
+func (r *Reconciler) failed(ctx context.Context, err error) {
+	for _, hooks := range r.hooks {
+		if hooks.OnError != nil {
+			r.runHook(ctx, "OnError", func() { hooks.OnError(ctx, err) })
+		}
+	}
+}
+
+// runHook calls a hook, recovering and logging a panic so that a faulty hook
+// cannot stop the reconciler. It reports whether the hook returned normally.
+func (r *Reconciler) runHook(ctx context.Context, name string, call func()) (ok bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			r.logger.ErrorContext(ctx, "reconciler hook panicked", "hook", name, "panic", recovered)
+			ok = false
+		}
+	}()
+	call()
+	return true
+}
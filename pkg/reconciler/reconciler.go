@@ -5,9 +5,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/drift"
 	"github.com/vjranagit/cluster-api/pkg/engine"
 )
 
@@ -16,15 +19,40 @@ type Reconciler struct {
 	engine   *engine.Engine
 	interval time.Duration
 	logger   *slog.Logger
+	now      func() time.Time
+	hooks    []Hooks
+
+	mu      sync.Mutex
+	pending map[string]*api.Cluster // Changes deferred until the next maintenance window
 }
 
 // NewReconciler creates a new reconciler
-func NewReconciler(eng *engine.Engine, interval time.Duration, logger *slog.Logger) *Reconciler {
-	return &Reconciler{
+func NewReconciler(eng *engine.Engine, interval time.Duration, logger *slog.Logger, opts ...Option) *Reconciler {
+	r := &Reconciler{
 		engine:   eng,
 		interval: interval,
 		logger:   logger,
+		now:      time.Now,
+		pending:  make(map[string]*api.Cluster),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Pending returns the IDs of clusters whose changes are deferred until the
+// next maintenance window
+func (r *Reconciler) Pending() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.pending))
+	for id := range r.pending {
+		ids = append(ids, id)
 	}
+	sort.Strings(ids)
+	return ids
 }
 
 // Run starts the reconciliation loop
@@ -35,18 +63,20 @@ func (r *Reconciler) Run(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			r.logger.Info("reconciler shutting down")
+			r.logger.InfoContext(ctx, "reconciler shutting down")
 			return ctx.Err()
 		case <-ticker.C:
 			if err := r.reconcile(ctx); err != nil {
-				r.logger.Error("reconciliation failed", "error", err)
+				r.logger.ErrorContext(ctx, "reconciliation failed", "error", err)
+				r.failed(ctx, err)
 			}
 		}
 	}
 }
 
 func (r *Reconciler) reconcile(ctx context.Context) error {
-	r.logger.Debug("starting reconciliation cycle")
+	r.logger.DebugContext(ctx, "starting reconciliation cycle")
+	r.cycleStarted(ctx)
 
 	// This would typically:
 	// 1. Load desired state from configuration
@@ -56,17 +86,27 @@ func (r *Reconciler) reconcile(ctx context.Context) error {
 	return nil
 }
 
-// ReconcileCluster reconciles a single cluster
+// ReconcileCluster reconciles a single cluster. Its log lines and the events
+// of provider calls share one correlation ID.
 func (r *Reconciler) ReconcileCluster(ctx context.Context, cluster *api.Cluster) error {
-	r.logger.Info("reconciling cluster",
+	ctx = engine.EnsureCorrelationID(ctx)
+	if err := r.reconcileCluster(ctx, cluster); err != nil {
+		r.failed(ctx, err)
+		return err
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *api.Cluster) error {
+	r.logger.InfoContext(ctx, "reconciling cluster",
 		"id", cluster.ID,
 		"name", cluster.Metadata.Name,
 		"provider", cluster.Spec.Provider,
 	)
 
-	provider := r.engine.GetProvider(cluster.Spec.Provider)
-	if provider == nil {
-		return fmt.Errorf("provider %s not found", cluster.Spec.Provider)
+	provider, err := r.engine.LoadRegionalProvider(ctx, cluster.Spec.Provider, cluster.Spec.Region)
+	if err != nil {
+		return err
 	}
 
 	// Get actual cluster state
@@ -75,28 +115,105 @@ func (r *Reconciler) ReconcileCluster(ctx context.Context, cluster *api.Cluster)
 		return fmt.Errorf("failed to get cluster: %w", err)
 	}
 
+	if actual != nil && !r.needsUpdate(cluster, actual) {
+		r.clearPending(cluster.ID)
+		return nil
+	}
+	if err := r.driftDetected(ctx, drift.NewReport(r.now(), clusterDrifts(cluster, actual))); err != nil {
+		return fmt.Errorf("cluster %s: %w", cluster.ID, err)
+	}
+
+	// Detect drift at any time but only change infrastructure inside the window
+	if !r.engine.MaintenanceWindow().InWindow(r.now()) {
+		r.logger.InfoContext(ctx, "drift found, deferring to next maintenance window", "id", cluster.ID)
+		r.mu.Lock()
+		r.pending[cluster.ID] = cluster
+		r.mu.Unlock()
+		return nil
+	}
+
+	// Like an apply, pass the provider the cluster's secrets, resolved now
+	secrets, err := cluster.Spec.ResolveSecrets()
+	if err != nil {
+		return fmt.Errorf("cluster %s: %w", cluster.ID, err)
+	}
+	ctx = engine.WithSecrets(ctx, secrets)
+
 	// If cluster doesn't exist, create it
 	if actual == nil {
-		r.logger.Info("cluster not found, creating", "id", cluster.ID)
+		r.logger.InfoContext(ctx, "cluster not found, creating", "id", cluster.ID)
 		_, err := provider.CreateCluster(ctx, cluster.Spec)
 		if err != nil {
 			return fmt.Errorf("failed to create cluster: %w", err)
 		}
+		r.clearPending(cluster.ID)
+		r.applied(ctx, clusterPlan(engine.ActionCreate, cluster))
 		return nil
 	}
 
-	// Update cluster if needed
-	if r.needsUpdate(cluster, actual) {
-		r.logger.Info("cluster needs update", "id", cluster.ID)
-		if err := provider.UpdateCluster(ctx, cluster); err != nil {
-			return fmt.Errorf("failed to update cluster: %w", err)
-		}
+	// Update cluster
+	r.logger.InfoContext(ctx, "cluster needs update", "id", cluster.ID)
+	if err := provider.UpdateCluster(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to update cluster: %w", err)
 	}
 
+	r.clearPending(cluster.ID)
+	r.applied(ctx, clusterPlan(engine.ActionUpdate, cluster))
 	return nil
 }
 
+func (r *Reconciler) clearPending(clusterID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.pending, clusterID)
+}
+
 func (r *Reconciler) needsUpdate(desired, actual *api.Cluster) bool {
 	// Compare versions, configurations, etc.
 	return desired.Spec.ControlPlane.Version != actual.Spec.ControlPlane.Version
 }
+
+// clusterDrifts describes how an actual cluster differs from the desired
+// one, like the drift detector does; a nil actual cluster was deleted
+func clusterDrifts(desired, actual *api.Cluster) []drift.ResourceDrift {
+	resource := api.ResourceID{
+		Provider: desired.Spec.Provider,
+		Kind:     "Cluster",
+		ID:       desired.ID,
+		Name:     desired.Metadata.Name,
+	}
+	if actual == nil {
+		return []drift.ResourceDrift{{
+			Resource:     resource,
+			DriftType:    drift.DriftResourceDeleted,
+			Field:        "cluster",
+			Expected:     "exists",
+			Actual:       "deleted",
+			Severity:     drift.SeverityCritical,
+			Remediatable: true,
+		}}
+	}
+	return []drift.ResourceDrift{{
+		Resource:     resource,
+		DriftType:    drift.DriftVersionSkew,
+		Field:        "controlPlane.version",
+		Expected:     desired.Spec.ControlPlane.Version,
+		Actual:       actual.Spec.ControlPlane.Version,
+		Severity:     drift.SeverityHigh,
+		Remediatable: true,
+	}}
+}
+
+// clusterPlan is the plan of a single action on a cluster
+func clusterPlan(action engine.ActionType, cluster *api.Cluster) *engine.Plan {
+	return &engine.Plan{Actions: []engine.Action{{
+		Type: action,
+		Resource: api.ResourceID{
+			Provider: cluster.Spec.Provider,
+			Kind:     "Cluster",
+			ID:       cluster.ID,
+			Name:     cluster.Metadata.Name,
+		},
+	}}}
+}
@@ -0,0 +1,157 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/drift"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+	"github.com/vjranagit/cluster-api/pkg/providers/fake"
+)
+
+func TestReconciler_MaintenanceWindow(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	desired := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+		},
+	}
+	actual := *desired
+	actual.Spec.ControlPlane.Version = "1.28"
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&actual)
+
+	window, err := engine.ParseMaintenanceWindow("Sat,Sun 02:00-06:00")
+	if err != nil {
+		t.Fatalf("ParseMaintenanceWindow() error = %v", err)
+	}
+
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+	eng.SetMaintenanceWindow(window)
+
+	r := NewReconciler(eng, time.Minute, logger)
+
+	// Monday noon: drift is detected but deferred
+	r.now = func() time.Time { return time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC) }
+	if err := r.ReconcileCluster(ctx, desired); err != nil {
+		t.Fatalf("ReconcileCluster() error = %v", err)
+	}
+	if n := provider.CallCount("UpdateCluster"); n != 0 {
+		t.Errorf("UpdateCluster called %d times outside window, want 0", n)
+	}
+	if pending := r.Pending(); len(pending) != 1 || pending[0] != "cluster-1" {
+		t.Errorf("Pending() = %v, want [cluster-1]", pending)
+	}
+
+	// Saturday 03:00: the deferred change is applied
+	r.now = func() time.Time { return time.Date(2024, 2, 3, 3, 0, 0, 0, time.UTC) }
+	if err := r.ReconcileCluster(ctx, desired); err != nil {
+		t.Fatalf("ReconcileCluster() error = %v", err)
+	}
+	if n := provider.CallCount("UpdateCluster"); n != 1 {
+		t.Errorf("UpdateCluster called %d times inside window, want 1", n)
+	}
+	if pending := r.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() = %v, want empty after apply", pending)
+	}
+}
+
+func TestReconciler_Hooks(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	desired := &api.Cluster{
+		ID:       "cluster-1",
+		Metadata: api.ResourceMetadata{Name: "prod"},
+		Spec: api.ClusterSpec{
+			Provider:     "aws",
+			ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+		},
+	}
+	actual := *desired
+	actual.Spec.ControlPlane.Version = "1.28"
+
+	provider := fake.NewProvider("aws")
+	provider.SeedCluster(&actual)
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+
+	var (
+		reports []*drift.DriftReport
+		plans   []*engine.Plan
+		errs    []error
+		refuse  error
+	)
+	r := NewReconciler(eng, time.Minute, logger,
+		WithHooks(Hooks{OnCycleStart: func(context.Context) { panic("metrics backend down") }}),
+		WithHooks(Hooks{
+			OnDriftDetected: func(ctx context.Context, report *drift.DriftReport) error {
+				reports = append(reports, report)
+				return refuse
+			},
+			OnApplied: func(ctx context.Context, plan *engine.Plan) { plans = append(plans, plan) },
+			OnError:   func(ctx context.Context, err error) { errs = append(errs, err) },
+		}),
+	)
+
+	// A panicking hook is recovered
+	if err := r.reconcile(ctx); err != nil {
+		t.Fatalf("reconcile() error = %v", err)
+	}
+
+	// A hook refusing the change leaves the cluster as it is
+	refuse = errors.New("change freeze")
+	err := r.ReconcileCluster(ctx, desired)
+	if !errors.Is(err, refuse) {
+		t.Fatalf("ReconcileCluster() error = %v, want the hook's refusal", err)
+	}
+	if n := provider.CallCount("UpdateCluster"); n != 0 {
+		t.Errorf("UpdateCluster called %d times after a refusal, want 0", n)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], refuse) {
+		t.Errorf("OnError got %v, want the refusal", errs)
+	}
+
+	refuse = nil
+	if err := r.ReconcileCluster(ctx, desired); err != nil {
+		t.Fatalf("ReconcileCluster() error = %v", err)
+	}
+	if len(reports) != 2 || reports[1].Summary.HighCount != 1 || reports[1].Drifts[0].DriftType != drift.DriftVersionSkew {
+		t.Errorf("OnDriftDetected got %v, want a version skew", reports)
+	}
+	if len(plans) != 1 || plans[0].Actions[0].Type != engine.ActionUpdate || plans[0].Actions[0].Resource.ID != "cluster-1" {
+		t.Errorf("OnApplied got %v, want an update of cluster-1", plans)
+	}
+}
+
+func TestReconciler_PanickingGate(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	provider := fake.NewProvider("aws")
+	eng := engine.NewEngine(nil, nil)
+	eng.RegisterProvider(provider)
+
+	r := NewReconciler(eng, time.Minute, logger, WithHooks(Hooks{
+		OnDriftDetected: func(context.Context, *drift.DriftReport) error { panic("bad gate") },
+	}))
+	cluster := &api.Cluster{ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}, Spec: api.ClusterSpec{Provider: "aws"}}
+	if err := r.ReconcileCluster(ctx, cluster); err == nil {
+		t.Error("ReconcileCluster() error = nil, want a panicking gate to refuse")
+	}
+	if n := provider.CallCount("CreateCluster"); n != 0 {
+		t.Errorf("CreateCluster called %d times, want 0", n)
+	}
+}
@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockFileName marks the snapshot directory as being written by a provctl
+// process
+const lockFileName = ".snapshot.lock"
+
+// lockStaleAfter is how old a lock file is before it is taken to be left by a
+// process that died holding it. Taking a snapshot takes well under this.
+const lockStaleAfter = 5 * time.Minute
+
+// lockRetryInterval is how often a held lock is checked again
+var lockRetryInterval = 10 * time.Millisecond
+
+// lock takes the snapshot directory's lock, waiting while another goroutine
+// or process holds it, and returns the function releasing it. Being a file,
+// the lock serializes every provctl process sharing the directory, such as
+// snapshot schedule and a manual snapshot create.
+func (m *Manager) lock(ctx context.Context) (func(), error) {
+	path := filepath.Join(m.snapshotDir, lockFileName)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock snapshot directory: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for another snapshot to finish: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
@@ -3,13 +3,20 @@ package snapshot
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/vjranagit/cluster-api/pkg/api"
 	"github.com/vjranagit/cluster-api/pkg/engine"
 )
@@ -18,6 +25,8 @@ import (
 type Manager struct {
 	snapshotDir string
 	state       engine.StateManager
+
+	removeFile func(name string) error // os.Remove, replaced in tests
 }
 
 // NewManager creates a new snapshot manager
@@ -29,6 +38,7 @@ func NewManager(snapshotDir string, state engine.StateManager) (*Manager, error)
 	return &Manager{
 		snapshotDir: snapshotDir,
 		state:       state,
+		removeFile:  os.Remove,
 	}, nil
 }
 
@@ -52,6 +62,13 @@ type SnapshotMetadata struct {
 	Tags          map[string]string
 }
 
+// Snapshot format versions. Snapshots of the legacy version may carry a
+// length checksum; every later version carries a SHA-256 one.
+const (
+	legacySnapshotVersion = "1.0"
+	snapshotVersion       = "1.1"
+)
+
 // TriggerReason describes why snapshot was created
 type TriggerReason string
 
@@ -62,30 +79,67 @@ const (
 	TriggerScheduled      TriggerReason = "scheduled"
 	TriggerPreApply       TriggerReason = "pre_apply"
 	TriggerDriftRemediate TriggerReason = "drift_remediate"
+	TriggerPreRestore     TriggerReason = "pre_restore"
 )
 
+// triggerReasons are the known trigger reasons
+var triggerReasons = []TriggerReason{
+	TriggerManual, TriggerPreUpgrade, TriggerPreDelete, TriggerScheduled,
+	TriggerPreApply, TriggerDriftRemediate, TriggerPreRestore,
+}
+
+// ParseTriggerReason parses the name of a trigger reason, e.g. "pre_upgrade"
+func ParseTriggerReason(s string) (TriggerReason, error) {
+	for _, reason := range triggerReasons {
+		if string(reason) == s {
+			return reason, nil
+		}
+	}
+	names := make([]string, len(triggerReasons))
+	for i, reason := range triggerReasons {
+		names[i] = string(reason)
+	}
+	return "", fmt.Errorf("unknown trigger reason %q (want one of %s)", s, strings.Join(names, ", "))
+}
+
 // CreateSnapshot creates a new snapshot of current state
 func (m *Manager) CreateSnapshot(ctx context.Context, description string, reason TriggerReason) (*Snapshot, error) {
+	return m.CreateTaggedSnapshot(ctx, description, reason, nil)
+}
+
+// CreateTaggedSnapshot creates a new snapshot of current state carrying
+// tags, by which retention policies can keep it
+func (m *Manager) CreateTaggedSnapshot(ctx context.Context, description string, reason TriggerReason, tags map[string]string) (*Snapshot, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	// Get current state
 	currentState, err := m.state.GetState(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current state: %w", err)
 	}
 
+	now := time.Now()
 	snapshot := &Snapshot{
-		ID:          generateSnapshotID(),
-		CreatedAt:   time.Now(),
+		ID:          generateSnapshotID(now),
+		CreatedAt:   now,
 		Description: description,
-		State:       currentState,
+		State:       redactState(currentState),
 		Metadata: SnapshotMetadata{
-			Version:       "1.0",
+			Version:       snapshotVersion,
 			CreatedBy:     "provctl",
 			TriggerReason: reason,
 			ClusterCount:  len(currentState.Clusters),
 			NodePoolCount: len(currentState.NodePools),
-			Tags:          make(map[string]string),
+			Tags:          make(map[string]string, len(tags)),
 		},
 	}
+	for key, value := range tags {
+		snapshot.Metadata.Tags[key] = value
+	}
 
 	// Calculate checksum for integrity verification
 	snapshot.Checksum = calculateChecksum(snapshot.State)
@@ -98,15 +152,22 @@ func (m *Manager) CreateSnapshot(ctx context.Context, description string, reason
 	return snapshot, nil
 }
 
-// RestoreSnapshot restores state from a snapshot
-func (m *Manager) RestoreSnapshot(ctx context.Context, snapshotID string, dryRun bool) (*RestoreResult, error) {
+// RestoreSnapshot restores state from a snapshot. When selectors are given,
+// only matching clusters and node pools are restored and the rest of the
+// current state is preserved.
+//
+// A real restore first backs up the current state, then writes the snapshot
+// and reads it back to verify it. If writing or verification fails after the
+// backup exists, the partial result is returned alongside the error so the
+// caller can report the backup ID.
+func (m *Manager) RestoreSnapshot(ctx context.Context, snapshotID string, dryRun bool, only ...ResourceSelector) (*RestoreResult, error) {
 	snapshot, err := m.LoadSnapshot(snapshotID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load snapshot: %w", err)
 	}
 
 	// Verify checksum
-	if calculateChecksum(snapshot.State) != snapshot.Checksum {
+	if ok, _ := snapshot.checksumMatches(); !ok {
 		return nil, fmt.Errorf("snapshot checksum mismatch - data may be corrupted")
 	}
 
@@ -114,6 +175,7 @@ func (m *Manager) RestoreSnapshot(ctx context.Context, snapshotID string, dryRun
 		SnapshotID:  snapshotID,
 		RestoredAt:  time.Now(),
 		DryRun:      dryRun,
+		Selective:   len(only) > 0,
 		Changes:     []RestoreChange{},
 	}
 
@@ -122,39 +184,211 @@ func (m *Manager) RestoreSnapshot(ctx context.Context, snapshotID string, dryRun
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current state: %w", err)
 	}
+	snapshot.State = unredactState(snapshot.State, currentState)
 
 	// Determine changes needed
-	result.Changes = m.calculateRestoreChanges(snapshot.State, currentState)
+	result.Changes = filterChanges(m.calculateRestoreChanges(snapshot.State, currentState), only)
+
+	// A selective restore writes the current state with only the chosen changes applied
+	restored := snapshot.State
+	if len(only) > 0 {
+		restored = applyChanges(currentState, snapshot.State, result.Changes)
+	}
+
+	if dryRun {
+		return result, nil // Dry run doesn't actually restore
+	}
+
+	// Back up the current state first. The backup is synced to disk before
+	// state is touched, so if the process dies mid-restore the user can roll
+	// back to it.
+	backup, err := m.CreateSnapshot(ctx, fmt.Sprintf("Pre-restore backup (restoring %s)", snapshotID), TriggerPreRestore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	result.BackupID = backup.ID
+
+	// Once the backup exists, finish the write and verification even if the
+	// caller is interrupted, rather than stopping halfway
+	ctx = context.WithoutCancel(ctx)
+
+	if err := m.state.SaveState(ctx, restored); err != nil {
+		return result, fmt.Errorf("failed to restore state (previous state saved as snapshot %s): %w", backup.ID, err)
+	}
+
+	if err := m.verifyRestore(ctx, restored, &result.Verification); err != nil {
+		return result, fmt.Errorf("%w (previous state saved as snapshot %s)", err, backup.ID)
+	}
+
+	result.Success = true
+	return result, nil
+}
 
-	if !dryRun {
-		// Create a backup of current state before restoring
-		backup, err := m.CreateSnapshot(ctx, "Pre-restore backup", TriggerManual)
+// verifyRestore re-reads state and compares its checksum with the state that
+// was written. On a mismatch it writes once more (rolling forward) before
+// giving up.
+func (m *Manager) verifyRestore(ctx context.Context, restored engine.State, verification *RestoreVerification) error {
+	verification.Expected = calculateChecksum(storedResources(restored))
+
+	for verification.Attempts = 1; ; verification.Attempts++ {
+		current, err := m.state.GetState(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create backup: %w", err)
+			return fmt.Errorf("failed to re-read state for verification: %w", err)
 		}
-		result.BackupID = backup.ID
 
-		// Apply restore
-		if err := m.state.SaveState(ctx, snapshot.State); err != nil {
-			return nil, fmt.Errorf("failed to restore state: %w", err)
+		verification.Actual = calculateChecksum(storedResources(current))
+		if verification.Actual == verification.Expected {
+			verification.Verified = true
+			return nil
+		}
+		if verification.Attempts == maxRestoreAttempts {
+			return fmt.Errorf("restore verification failed: stored state checksum %s does not match snapshot checksum %s",
+				verification.Actual, verification.Expected)
 		}
 
-		result.Success = true
-	} else {
-		result.Success = false // Dry run doesn't actually restore
+		if err := m.state.SaveState(ctx, restored); err != nil {
+			return fmt.Errorf("failed to rewrite state after verification mismatch: %w", err)
+		}
 	}
+}
 
-	return result, nil
+// redactState returns state with the sensitive fields of its clusters and
+// node pools redacted, as snapshots store it
+func redactState(state engine.State) engine.State {
+	state.Clusters = api.Redact(state.Clusters)
+	state.NodePools = api.Redact(state.NodePools)
+	return state
+}
+
+// unredactState fills the redacted fields of a snapshot's state in from the
+// current state, so a restore keeps the current values of sensitive fields
+// and the fields do not show up as changes. Resources that are no longer in
+// the current state get them back from the next apply.
+func unredactState(snapshot, current engine.State) engine.State {
+	snapshot.Clusters = api.Unredact(snapshot.Clusters, current.Clusters)
+	snapshot.NodePools = api.Unredact(snapshot.NodePools, current.NodePools)
+	return snapshot
+}
+
+// maxRestoreAttempts bounds how many times a restore writes state before
+// reporting a verification failure
+const maxRestoreAttempts = 2
+
+// storedResources returns the parts of state that state backends persist,
+// with nil maps normalized so that checksums compare equal
+func storedResources(state engine.State) engine.State {
+	stored := engine.State{
+		Clusters:  state.Clusters,
+		NodePools: state.NodePools,
+	}
+	if stored.Clusters == nil {
+		stored.Clusters = map[string]*api.Cluster{}
+	}
+	if stored.NodePools == nil {
+		stored.NodePools = map[string]*api.NodePool{}
+	}
+	return stored
 }
 
 // RestoreResult contains the results of a restore operation
 type RestoreResult struct {
-	SnapshotID string
-	BackupID   string
-	RestoredAt time.Time
-	DryRun     bool
-	Success    bool
-	Changes    []RestoreChange
+	SnapshotID   string
+	BackupID     string // Snapshot of the state before the restore
+	RestoredAt   time.Time
+	DryRun       bool
+	Selective    bool
+	Success      bool
+	Changes      []RestoreChange
+	Verification RestoreVerification
+}
+
+// RestoreVerification records the check that restored state was stored intact
+type RestoreVerification struct {
+	Verified bool
+	Expected string // Checksum of the state that was written
+	Actual   string // Checksum of the state read back
+	Attempts int    // Number of writes checked, including the roll-forward retry
+}
+
+// ResourceSelector matches a resource by kind and name or ID
+type ResourceSelector struct {
+	Kind string // "Cluster" or "NodePool"
+	Name string // Resource name or ID
+}
+
+// ParseResourceSelector parses a selector of the form kind=name, e.g. "cluster=prod"
+func ParseResourceSelector(s string) (ResourceSelector, error) {
+	kind, name, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return ResourceSelector{}, fmt.Errorf("invalid selector %q: expected kind=name", s)
+	}
+
+	switch strings.ToLower(kind) {
+	case "cluster":
+		return ResourceSelector{Kind: "Cluster", Name: name}, nil
+	case "nodepool":
+		return ResourceSelector{Kind: "NodePool", Name: name}, nil
+	default:
+		return ResourceSelector{}, fmt.Errorf("invalid selector %q: unknown kind %q (want cluster or nodepool)", s, kind)
+	}
+}
+
+// Matches reports whether the selector matches a resource
+func (rs ResourceSelector) Matches(id api.ResourceID) bool {
+	return rs.Kind == id.Kind && (rs.Name == id.Name || rs.Name == id.ID)
+}
+
+func filterChanges(changes []RestoreChange, only []ResourceSelector) []RestoreChange {
+	if len(only) == 0 {
+		return changes
+	}
+
+	var filtered []RestoreChange
+	for _, change := range changes {
+		for _, selector := range only {
+			if selector.Matches(change.Resource) {
+				filtered = append(filtered, change)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// applyChanges returns a copy of current with the given changes taken from snapshot
+func applyChanges(current, snapshot engine.State, changes []RestoreChange) engine.State {
+	restored := engine.State{
+		Clusters:  make(map[string]*api.Cluster, len(current.Clusters)),
+		NodePools: make(map[string]*api.NodePool, len(current.NodePools)),
+		Networks:  current.Networks,
+		Metadata:  current.Metadata,
+	}
+	for id, cluster := range current.Clusters {
+		restored.Clusters[id] = cluster
+	}
+	for id, pool := range current.NodePools {
+		restored.NodePools[id] = pool
+	}
+
+	for _, change := range changes {
+		id := change.Resource.ID
+		switch change.Resource.Kind {
+		case "Cluster":
+			if change.Action == ActionRemove {
+				delete(restored.Clusters, id)
+			} else {
+				restored.Clusters[id] = snapshot.Clusters[id]
+			}
+		case "NodePool":
+			if change.Action == ActionRemove {
+				delete(restored.NodePools, id)
+			} else {
+				restored.NodePools[id] = snapshot.NodePools[id]
+			}
+		}
+	}
+
+	return restored
 }
 
 // RestoreChange represents a change that will be made during restore
@@ -165,6 +399,25 @@ type RestoreChange struct {
 	After    interface{}
 }
 
+// FieldChanges returns the spec fields a modify changes, with paths relative
+// to the resource's spec. Adds and removes have none.
+func (c RestoreChange) FieldChanges() []api.FieldChange {
+	if c.Action != ActionModify {
+		return nil
+	}
+	switch before := c.Before.(type) {
+	case api.ClusterSpec:
+		if after, ok := c.After.(api.ClusterSpec); ok {
+			return before.Diff(after)
+		}
+	case api.WorkerPoolSpec:
+		if after, ok := c.After.(api.WorkerPoolSpec); ok {
+			return before.Diff(after)
+		}
+	}
+	return nil
+}
+
 // ChangeAction represents the type of change
 type ChangeAction string
 
@@ -293,6 +546,7 @@ func (m *Manager) ListSnapshots() ([]SnapshotInfo, error) {
 			TriggerReason: snapshot.Metadata.TriggerReason,
 			ClusterCount:  snapshot.Metadata.ClusterCount,
 			NodePoolCount: snapshot.Metadata.NodePoolCount,
+			Tags:          snapshot.Metadata.Tags,
 		}
 
 		fileInfo, _ := file.Info()
@@ -301,9 +555,12 @@ func (m *Manager) ListSnapshots() ([]SnapshotInfo, error) {
 		snapshots = append(snapshots, info)
 	}
 
-	// Sort by creation time (newest first)
+	// Sort by creation time (newest first), by ID for snapshots of the same time
 	sort.Slice(snapshots, func(i, j int) bool {
-		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+		if !snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+		}
+		return snapshots[i].ID > snapshots[j].ID
 	})
 
 	return snapshots, nil
@@ -317,6 +574,7 @@ type SnapshotInfo struct {
 	TriggerReason TriggerReason
 	ClusterCount  int
 	NodePoolCount int
+	Tags          map[string]string
 	SizeBytes     int64
 }
 
@@ -339,23 +597,35 @@ func (m *Manager) LoadSnapshot(snapshotID string) (*Snapshot, error) {
 // DeleteSnapshot deletes a snapshot
 func (m *Manager) DeleteSnapshot(snapshotID string) error {
 	path := filepath.Join(m.snapshotDir, snapshotID+".json")
-	if err := os.Remove(path); err != nil {
+	if err := m.removeFile(path); err != nil {
 		return fmt.Errorf("failed to delete snapshot: %w", err)
 	}
 	return nil
 }
 
-// PruneSnapshots removes old snapshots based on retention policy
+// DefaultPruneConcurrency is how many snapshots PruneSnapshots deletes at
+// once unless the retention policy sets Concurrency
+const DefaultPruneConcurrency = 8
+
+// PruneSnapshots removes old snapshots based on retention policy. Deletions
+// run concurrently, which matters on network filesystems, and a failed
+// deletion does not stop the others: the IDs deleted are returned, newest
+// first, together with the errors of every deletion that failed.
 func (m *Manager) PruneSnapshots(policy RetentionPolicy) ([]string, error) {
 	snapshots, err := m.ListSnapshots()
 	if err != nil {
 		return nil, err
 	}
 
-	var deleted []string
+	var expired []string
 	now := time.Now()
 
+	// Snapshots are listed newest first
+	prunable := 0
 	for _, snapshot := range snapshots {
+		if policy.keeps(snapshot) {
+			continue
+		}
 		shouldDelete := false
 
 		// Age-based retention
@@ -364,25 +634,97 @@ func (m *Manager) PruneSnapshots(policy RetentionPolicy) ([]string, error) {
 		}
 
 		// Count-based retention (keep only N most recent)
-		if policy.MaxCount > 0 && len(snapshots)-len(deleted) > policy.MaxCount {
+		if policy.MaxCount > 0 && prunable >= policy.MaxCount {
 			shouldDelete = true
 		}
+		prunable++
 
 		if shouldDelete {
-			if err := m.DeleteSnapshot(snapshot.ID); err != nil {
-				return deleted, err
-			}
-			deleted = append(deleted, snapshot.ID)
+			expired = append(expired, snapshot.ID)
 		}
 	}
 
-	return deleted, nil
+	return m.deleteSnapshots(expired, policy.Concurrency)
+}
+
+// deleteSnapshots deletes snapshots with at most concurrency deletions in
+// flight, zero taking DefaultPruneConcurrency. It returns the IDs deleted,
+// in the order given, and the joined errors of those that were not.
+func (m *Manager) deleteSnapshots(snapshotIDs []string, concurrency int) ([]string, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultPruneConcurrency
+	}
+
+	errs := make([]error, len(snapshotIDs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(snapshotIDs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := m.DeleteSnapshot(snapshotIDs[i]); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", snapshotIDs[i], err)
+				}
+			}
+		}()
+	}
+	for i := range snapshotIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var deleted []string
+	for i, id := range snapshotIDs {
+		if errs[i] == nil {
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, errors.Join(errs...)
 }
 
-// RetentionPolicy defines snapshot retention rules
+// RetentionPolicy defines snapshot retention rules. Snapshots carrying one
+// of KeepTags or taken for one of KeepReasons are never pruned, and do not
+// count towards MaxCount.
 type RetentionPolicy struct {
-	MaxAge   time.Duration
-	MaxCount int
+	MaxAge      time.Duration
+	MaxCount    int
+	KeepTags    map[string]string
+	KeepReasons []TriggerReason
+
+	// Reasons limits pruning to snapshots taken for these reasons, keeping
+	// the rest out of MaxCount; empty prunes snapshots of any reason
+	Reasons []TriggerReason
+
+	// Concurrency is how many snapshots are deleted at once; zero takes
+	// DefaultPruneConcurrency
+	Concurrency int
+}
+
+// keeps reports whether the policy keeps a snapshot whatever its age
+func (p RetentionPolicy) keeps(snapshot SnapshotInfo) bool {
+	if len(p.Reasons) > 0 && !hasReason(p.Reasons, snapshot.TriggerReason) {
+		return true
+	}
+	if hasReason(p.KeepReasons, snapshot.TriggerReason) {
+		return true
+	}
+	for key, value := range p.KeepTags {
+		if tag, ok := snapshot.Tags[key]; ok && tag == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasReason(reasons []TriggerReason, reason TriggerReason) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *Manager) saveSnapshot(snapshot *Snapshot) error {
@@ -392,48 +734,118 @@ func (m *Manager) saveSnapshot(snapshot *Snapshot) error {
 	}
 
 	path := filepath.Join(m.snapshotDir, snapshot.ID+".json")
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeFileSync(path, data); err != nil {
 		return fmt.Errorf("failed to write snapshot file: %w", err)
 	}
 
 	return nil
 }
 
-func generateSnapshotID() string {
-	return fmt.Sprintf("snapshot-%s", time.Now().Format("20060102-150405"))
+// writeFileSync writes data to a temporary file, syncs it and renames it into
+// place, so a crash leaves either the complete file or no file at all
+func writeFileSync(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Sync the directory so the rename itself is durable
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// generateSnapshotID returns an ID of a snapshot taken at t. The time has
+// microsecond precision, so IDs still sort in creation order, and a random
+// suffix keeps snapshots taken in the same microsecond, by concurrent runs
+// sharing the directory, from overwriting each other.
+func generateSnapshotID(t time.Time) string {
+	suffix := uuid.NewString()[:8]
+	return fmt.Sprintf("snapshot-%s-%06d-%s", t.Format("20060102-150405"), t.Nanosecond()/1000, suffix)
 }
 
+// calculateChecksum returns the SHA-256 of a state's JSON encoding, in hex
 func calculateChecksum(state engine.State) string {
-	// Simple checksum - in production, use proper hashing
+	data, _ := json.Marshal(state)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// legacyChecksum is the checksum of snapshots taken before checksums were
+// SHA-256: the length of the state's JSON encoding, in hex. It catches
+// truncation but not edits.
+func legacyChecksum(state engine.State) string {
 	data, _ := json.Marshal(state)
 	return fmt.Sprintf("%x", len(data))
 }
 
+// checksumMatches reports whether a snapshot's state matches its checksum,
+// and whether that checksum is a legacy one. Only snapshots of the legacy
+// format version are checked against a length checksum; in any other a
+// checksum that is not SHA-256 does not match.
+func (s *Snapshot) checksumMatches() (ok, legacy bool) {
+	if s.Metadata.Version == legacySnapshotVersion && len(s.Checksum) != sha256.Size*2 {
+		return legacyChecksum(s.State) == s.Checksum, true
+	}
+	return calculateChecksum(s.State) == s.Checksum, false
+}
+
 func clustersEqual(a, b *api.Cluster) bool {
-	// Deep comparison - simplified version
-	aJSON, _ := json.Marshal(a.Spec)
-	bJSON, _ := json.Marshal(b.Spec)
-	return string(aJSON) == string(bJSON)
+	return a.Spec.Equal(b.Spec)
 }
 
 func nodePoolsEqual(a, b *api.NodePool) bool {
-	// Deep comparison - simplified version
-	aJSON, _ := json.Marshal(a.Spec)
-	bJSON, _ := json.Marshal(b.Spec)
-	return string(aJSON) == string(bJSON)
+	return a.Spec.Equal(b.Spec)
 }
 
 // FormatRestoreResult generates a human-readable restore result
 func FormatRestoreResult(result *RestoreResult) string {
 	output := fmt.Sprintf("📸 Snapshot Restore %s\n\n", result.SnapshotID)
 
+	if result.Selective {
+		output += "Selective restore - only matching resources are affected\n"
+	}
+
 	if result.DryRun {
 		output += "⚠ DRY RUN - No changes were applied\n\n"
 	} else if result.Success {
 		output += "✓ Restore completed successfully\n"
-		output += fmt.Sprintf("Backup created: %s\n\n", result.BackupID)
+		output += fmt.Sprintf("Backup created: %s\n", result.BackupID)
+		output += fmt.Sprintf("Verified: stored state matches snapshot (checksum %s)\n\n", result.Verification.Actual)
 	} else {
-		output += "✗ Restore failed\n\n"
+		output += "✗ Restore failed\n"
+		if result.BackupID != "" {
+			output += fmt.Sprintf("Previous state saved as snapshot %s - restore it to roll back\n", result.BackupID)
+		}
+		if result.Verification.Attempts > 0 && !result.Verification.Verified {
+			output += fmt.Sprintf("Verification failed after %d attempt(s): stored checksum %s, expected %s\n",
+				result.Verification.Attempts, result.Verification.Actual, result.Verification.Expected)
+		}
+		output += "\n"
 	}
 
 	if len(result.Changes) == 0 {
@@ -472,6 +884,9 @@ func FormatRestoreResult(result *RestoreResult) string {
 		}
 
 		output += fmt.Sprintf("  %s %s/%s\n", icon, change.Resource.Kind, change.Resource.Name)
+		for _, field := range change.FieldChanges() {
+			output += "      " + field.String() + "\n"
+		}
 	}
 
 	return output
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Scheduler creates a snapshot on a fixed interval and prunes old scheduled
+// snapshots with a retention policy after each one
+type Scheduler struct {
+	manager   *Manager
+	interval  time.Duration
+	retention RetentionPolicy
+	logger    *slog.Logger
+}
+
+// NewScheduler creates a scheduler taking snapshots every interval. The
+// retention policy applies only to scheduled snapshots, so manual and
+// pre-change snapshots are never pruned by the schedule. A zero retention
+// policy keeps every snapshot.
+func NewScheduler(manager *Manager, interval time.Duration, retention RetentionPolicy, logger *slog.Logger) *Scheduler {
+	retention.Reasons = []TriggerReason{TriggerScheduled}
+	return &Scheduler{
+		manager:   manager,
+		interval:  interval,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Run takes snapshots until ctx is cancelled. Failures are logged and
+// retried on the next tick rather than stopping the schedule.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.interval <= 0 {
+		return fmt.Errorf("snapshot interval must be positive, got %s", s.interval)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("snapshot scheduler shutting down")
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.runOnce(ctx); err != nil {
+				s.logger.Error("scheduled snapshot failed", "error", err)
+			}
+		}
+	}
+}
+
+// runOnce takes one scheduled snapshot and applies the retention policy
+func (s *Scheduler) runOnce(ctx context.Context) error {
+	snapshot, err := s.manager.CreateSnapshot(ctx, "Scheduled snapshot", TriggerScheduled)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("scheduled snapshot created", "id", snapshot.ID)
+
+	if s.retention.MaxAge == 0 && s.retention.MaxCount == 0 {
+		return nil
+	}
+	deleted, err := s.manager.PruneSnapshots(s.retention)
+	if len(deleted) > 0 {
+		s.logger.Info("pruned snapshots", "count", len(deleted))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+	return nil
+}
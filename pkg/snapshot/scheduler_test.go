@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestScheduler_CreatesAndPrunes(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), &mockStateManager{state: engine.State{}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	// Older scheduled snapshots the retention policy should remove, and
+	// manual ones it must leave alone
+	for i := 1; i <= 3; i++ {
+		for _, reason := range []TriggerReason{TriggerScheduled, TriggerManual} {
+			old := &Snapshot{
+				ID:        fmt.Sprintf("snapshot-old-%s-%d", reason, i),
+				CreatedAt: time.Now().Add(-time.Duration(i) * time.Hour),
+				Metadata:  SnapshotMetadata{TriggerReason: reason},
+			}
+			if err := manager.saveSnapshot(old); err != nil {
+				t.Fatalf("saveSnapshot() error = %v", err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	scheduler := NewScheduler(manager, 10*time.Millisecond, RetentionPolicy{MaxCount: 2}, logger)
+	done := make(chan error, 1)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snapshots, err := manager.ListSnapshots()
+		if err != nil {
+			t.Fatalf("ListSnapshots() error = %v", err)
+		}
+		scheduled := 0
+		for _, snapshot := range snapshots {
+			if snapshot.TriggerReason == TriggerScheduled {
+				scheduled++
+			}
+		}
+		if scheduled <= 2 && len(snapshots) > 0 && !strings.HasPrefix(snapshots[0].ID, "snapshot-old") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scheduler did not create and prune snapshots, have %+v", snapshots)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after cancellation")
+	}
+
+	// The oldest scheduled snapshots go first
+	if _, err := manager.LoadSnapshot("snapshot-old-scheduled-3"); err == nil {
+		t.Error("oldest scheduled snapshot survived pruning")
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := manager.LoadSnapshot(fmt.Sprintf("snapshot-old-manual-%d", i)); err != nil {
+			t.Errorf("manual snapshot %d pruned by the schedule: %v", i, err)
+		}
+	}
+}
+
+func TestScheduler_InvalidInterval(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), &mockStateManager{})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := NewScheduler(manager, 0, RetentionPolicy{}, logger).Run(context.Background()); err == nil {
+		t.Error("Run() error = nil, want an error for a zero interval")
+	}
+}
@@ -2,8 +2,11 @@ package snapshot
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -149,6 +152,59 @@ func TestManager_RestoreSnapshot(t *testing.T) {
 	}
 }
 
+func TestManager_SnapshotRedactsSensitiveFields(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/workloads"
+	cluster := func(version string) *api.Cluster {
+		return &api.Cluster{
+			ID:       "cluster-1",
+			Metadata: api.ResourceMetadata{Name: "test-cluster"},
+			Spec: api.ClusterSpec{
+				ControlPlane: api.ControlPlaneSpec{
+					Version:  version,
+					Identity: &api.IdentitySpec{Type: "oidc", RoleARN: roleARN},
+				},
+			},
+		}
+	}
+
+	tempDir := t.TempDir()
+	state := &mockStateManager{state: engine.State{
+		Clusters: map[string]*api.Cluster{"cluster-1": cluster("1.28")},
+	}}
+	manager, err := NewManager(tempDir, state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	ctx := context.Background()
+
+	snapshot, err := manager.CreateSnapshot(ctx, "Test snapshot", TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, snapshot.ID+".json"))
+	if err != nil {
+		t.Fatalf("reading snapshot: %v", err)
+	}
+	if strings.Contains(string(data), roleARN) || !strings.Contains(string(data), `"roleArn": "***"`) {
+		t.Errorf("snapshot = %s, want the role ARN redacted", data)
+	}
+
+	// The restore only rolls back the version; the role ARN comes from the
+	// current state rather than the redacted snapshot
+	state.state = engine.State{Clusters: map[string]*api.Cluster{"cluster-1": cluster("1.29")}}
+	result, err := manager.RestoreSnapshot(ctx, snapshot.ID, false)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if len(result.Changes) != 1 || len(result.Changes[0].FieldChanges()) != 1 {
+		t.Errorf("RestoreSnapshot() changes = %+v, want only the version", result.Changes)
+	}
+	restored := state.state.Clusters["cluster-1"].Spec.ControlPlane
+	if restored.Version != "1.28" || restored.Identity.RoleARN != roleARN {
+		t.Errorf("restored control plane = %+v, want version 1.28 with the current role ARN", restored)
+	}
+}
+
 func TestManager_ListSnapshots(t *testing.T) {
 	tempDir := t.TempDir()
 	state := &mockStateManager{
@@ -170,7 +226,6 @@ func TestManager_ListSnapshots(t *testing.T) {
 		if err != nil {
 			t.Fatalf("CreateSnapshot() error = %v", err)
 		}
-		time.Sleep(time.Millisecond * 10) // Ensure different timestamps
 	}
 
 	snapshots, err := manager.ListSnapshots()
@@ -191,6 +246,103 @@ func TestManager_ListSnapshots(t *testing.T) {
 	}
 }
 
+func TestManager_CreateSnapshotUniqueIDs(t *testing.T) {
+	tempDir := t.TempDir()
+	state := &mockStateManager{
+		state: engine.State{
+			Clusters: map[string]*api.Cluster{},
+		},
+	}
+
+	// Two managers share the directory like two provctl runs would
+	var managers []*Manager
+	for i := 0; i < 2; i++ {
+		manager, err := NewManager(tempDir, state)
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+		managers = append(managers, manager)
+	}
+
+	const perManager = 50
+	ids := make(chan string, 2*perManager)
+	var wg sync.WaitGroup
+	for _, manager := range managers {
+		wg.Add(1)
+		go func(manager *Manager) {
+			defer wg.Done()
+			for i := 0; i < perManager; i++ {
+				snapshot, err := manager.CreateSnapshot(context.Background(), "Snapshot", TriggerManual)
+				if err != nil {
+					t.Errorf("CreateSnapshot() error = %v", err)
+					return
+				}
+				ids <- snapshot.ID
+			}
+		}(manager)
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		if seen[id] {
+			t.Errorf("snapshot ID %s generated twice", id)
+		}
+		seen[id] = true
+	}
+
+	snapshots, err := managers[0].ListSnapshots()
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 2*perManager {
+		t.Errorf("ListSnapshots() got %d snapshots, want %d", len(snapshots), 2*perManager)
+	}
+}
+
+func TestManager_CreateSnapshotWaitsForLock(t *testing.T) {
+	tempDir := t.TempDir()
+	state := &mockStateManager{state: engine.State{}}
+	holder, err := NewManager(tempDir, state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	// Another process, with its own manager, snapshotting the same directory
+	other, err := NewManager(tempDir, state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	unlock, err := holder.lock(context.Background())
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := other.CreateSnapshot(ctx, "Snapshot", TriggerManual); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateSnapshot() error = %v, want it to wait for the held lock", err)
+	}
+
+	unlock()
+	if _, err := other.CreateSnapshot(context.Background(), "Snapshot", TriggerManual); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v after the lock was released", err)
+	}
+
+	// A lock left by a process that died is taken over
+	path := filepath.Join(tempDir, lockFileName)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write lock file: %v", err)
+	}
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	if _, err := other.CreateSnapshot(context.Background(), "Snapshot", TriggerManual); err != nil {
+		t.Errorf("CreateSnapshot() error = %v with a stale lock", err)
+	}
+}
+
 func TestManager_PruneSnapshots(t *testing.T) {
 	tempDir := t.TempDir()
 	state := &mockStateManager{
@@ -207,11 +359,13 @@ func TestManager_PruneSnapshots(t *testing.T) {
 	ctx := context.Background()
 
 	// Create 5 snapshots
+	var created []string
 	for i := 0; i < 5; i++ {
-		_, err := manager.CreateSnapshot(ctx, "Snapshot", TriggerManual)
+		snapshot, err := manager.CreateSnapshot(ctx, "Snapshot", TriggerManual)
 		if err != nil {
 			t.Fatalf("CreateSnapshot() error = %v", err)
 		}
+		created = append(created, snapshot.ID)
 	}
 
 	// Prune to keep only 3
@@ -228,6 +382,12 @@ func TestManager_PruneSnapshots(t *testing.T) {
 	if len(deleted) != 2 {
 		t.Errorf("PruneSnapshots() deleted %d snapshots, want 2", len(deleted))
 	}
+	// The count keeps the most recent snapshots
+	for _, id := range deleted {
+		if id != created[0] && id != created[1] {
+			t.Errorf("PruneSnapshots() deleted %s, want only the two oldest %v", id, created[:2])
+		}
+	}
 
 	// Verify only 3 remain
 	snapshots, _ := manager.ListSnapshots()
@@ -235,3 +395,378 @@ func TestManager_PruneSnapshots(t *testing.T) {
 		t.Errorf("PruneSnapshots() left %d snapshots, want 3", len(snapshots))
 	}
 }
+
+func TestManager_PruneSnapshotsKeeps(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      RetentionPolicy
+		wantDeleted int
+	}{
+		{name: "age", policy: RetentionPolicy{MaxAge: time.Nanosecond}, wantDeleted: 3},
+		{name: "count", policy: RetentionPolicy{MaxCount: 1}, wantDeleted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &mockStateManager{state: engine.State{Clusters: map[string]*api.Cluster{}}}
+			manager, err := NewManager(t.TempDir(), state)
+			if err != nil {
+				t.Fatalf("NewManager() error = %v", err)
+			}
+
+			ctx := context.Background()
+			upgrade, err := manager.CreateSnapshot(ctx, "Before upgrade", TriggerPreUpgrade)
+			if err != nil {
+				t.Fatalf("CreateSnapshot() error = %v", err)
+			}
+			pinned, err := manager.CreateTaggedSnapshot(ctx, "Known good", TriggerManual, map[string]string{"keep": "true"})
+			if err != nil {
+				t.Fatalf("CreateTaggedSnapshot() error = %v", err)
+			}
+			for i := 0; i < 3; i++ {
+				if _, err := manager.CreateSnapshot(ctx, "Scheduled snapshot", TriggerScheduled); err != nil {
+					t.Fatalf("CreateSnapshot() error = %v", err)
+				}
+			}
+
+			policy := tt.policy
+			policy.KeepTags = map[string]string{"keep": "true"}
+			policy.KeepReasons = []TriggerReason{TriggerPreUpgrade}
+			deleted, err := manager.PruneSnapshots(policy)
+			if err != nil {
+				t.Fatalf("PruneSnapshots() error = %v", err)
+			}
+			if len(deleted) != tt.wantDeleted {
+				t.Errorf("PruneSnapshots() deleted %v, want %d snapshots", deleted, tt.wantDeleted)
+			}
+			for _, id := range deleted {
+				if id == upgrade.ID || id == pinned.ID {
+					t.Errorf("PruneSnapshots() deleted kept snapshot %s", id)
+				}
+			}
+
+			snapshots, err := manager.ListSnapshots()
+			if err != nil {
+				t.Fatalf("ListSnapshots() error = %v", err)
+			}
+			if len(snapshots) != 5-tt.wantDeleted {
+				t.Errorf("PruneSnapshots() left %d snapshots, want %d", len(snapshots), 5-tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestManager_PruneSnapshotsPartialFailure(t *testing.T) {
+	state := &mockStateManager{state: engine.State{Clusters: map[string]*api.Cluster{}}}
+	manager, err := NewManager(t.TempDir(), state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ctx := context.Background()
+	failing, err := manager.CreateSnapshot(ctx, "Undeletable", TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := manager.CreateSnapshot(ctx, "Scheduled snapshot", TriggerScheduled); err != nil {
+			t.Fatalf("CreateSnapshot() error = %v", err)
+		}
+	}
+
+	manager.removeFile = func(name string) error {
+		if filepath.Base(name) == failing.ID+".json" {
+			return os.ErrPermission
+		}
+		return os.Remove(name)
+	}
+
+	deleted, err := manager.PruneSnapshots(RetentionPolicy{MaxAge: time.Nanosecond, Concurrency: 2})
+	if err == nil {
+		t.Fatal("PruneSnapshots() error = nil, want the failed deletion")
+	}
+	if !strings.Contains(err.Error(), failing.ID) {
+		t.Errorf("PruneSnapshots() error = %v, want it to name %s", err, failing.ID)
+	}
+	if len(deleted) != 5 {
+		t.Errorf("PruneSnapshots() deleted %v, want the 5 deletable snapshots", deleted)
+	}
+	for _, id := range deleted {
+		if id == failing.ID {
+			t.Errorf("PruneSnapshots() reported %s deleted", id)
+		}
+	}
+
+	snapshots, err := manager.ListSnapshots()
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].ID != failing.ID {
+		t.Errorf("PruneSnapshots() left %d snapshots, want only %s", len(snapshots), failing.ID)
+	}
+}
+
+func TestParseTriggerReason(t *testing.T) {
+	if got, err := ParseTriggerReason("pre_upgrade"); err != nil || got != TriggerPreUpgrade {
+		t.Errorf("ParseTriggerReason(pre_upgrade) = %q, %v; want %q", got, err, TriggerPreUpgrade)
+	}
+	if _, err := ParseTriggerReason("pre-upgrade"); err == nil {
+		t.Error("ParseTriggerReason(pre-upgrade) error = nil, want an error")
+	}
+}
+
+func TestManager_RestoreSnapshotSelective(t *testing.T) {
+	tempDir := t.TempDir()
+	state := &mockStateManager{
+		state: engine.State{
+			Clusters: map[string]*api.Cluster{
+				"cluster-1": {
+					ID:       "cluster-1",
+					Metadata: api.ResourceMetadata{Name: "prod"},
+					Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: "1.28"}},
+				},
+				"cluster-2": {
+					ID:       "cluster-2",
+					Metadata: api.ResourceMetadata{Name: "staging"},
+					Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: "1.28"}},
+				},
+			},
+		},
+	}
+
+	manager, err := NewManager(tempDir, state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ctx := context.Background()
+	snapshot, err := manager.CreateSnapshot(ctx, "Before upgrade", TriggerPreUpgrade)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	// Upgrade both clusters after the snapshot
+	state.state = engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: "1.29"}},
+			},
+			"cluster-2": {
+				ID:       "cluster-2",
+				Metadata: api.ResourceMetadata{Name: "staging"},
+				Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: "1.29"}},
+			},
+		},
+	}
+
+	only := ResourceSelector{Kind: "Cluster", Name: "staging"}
+
+	// Dry run shows only the filtered change
+	result, err := manager.RestoreSnapshot(ctx, snapshot.ID, true, only)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Resource.Name != "staging" {
+		t.Errorf("RestoreSnapshot() dry run changes = %v, want only staging", result.Changes)
+	}
+
+	if _, err := manager.RestoreSnapshot(ctx, snapshot.ID, false, only); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	if got := state.state.Clusters["cluster-2"].Spec.ControlPlane.Version; got != "1.28" {
+		t.Errorf("staging version = %s, want restored 1.28", got)
+	}
+	if got := state.state.Clusters["cluster-1"].Spec.ControlPlane.Version; got != "1.29" {
+		t.Errorf("prod version = %s, want untouched 1.29", got)
+	}
+}
+
+// lossyStateManager silently loses the first dropWrites saves, as a backend
+// that acknowledges writes it did not persist would
+type lossyStateManager struct {
+	mockStateManager
+	dropWrites int
+	saves      int
+}
+
+func (m *lossyStateManager) SaveState(ctx context.Context, state engine.State) error {
+	m.saves++
+	if m.saves <= m.dropWrites {
+		return nil
+	}
+	return m.mockStateManager.SaveState(ctx, state)
+}
+
+func newLossyRestore(t *testing.T, dropWrites int) (*Manager, *lossyStateManager, string) {
+	t.Helper()
+	state := &lossyStateManager{dropWrites: dropWrites}
+	state.state = engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {
+				ID:       "cluster-1",
+				Metadata: api.ResourceMetadata{Name: "prod"},
+				Spec:     api.ClusterSpec{ControlPlane: api.ControlPlaneSpec{Version: "1.28"}},
+			},
+		},
+	}
+
+	manager, err := NewManager(t.TempDir(), state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	snapshot, err := manager.CreateSnapshot(context.Background(), "Before upgrade", TriggerPreUpgrade)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	state.state = engine.State{Clusters: map[string]*api.Cluster{}}
+	return manager, state, snapshot.ID
+}
+
+func TestManager_RestoreSnapshotVerifies(t *testing.T) {
+	manager, state, snapshotID := newLossyRestore(t, 0)
+
+	result, err := manager.RestoreSnapshot(context.Background(), snapshotID, false)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	verification := result.Verification
+	if !verification.Verified || verification.Attempts != 1 || verification.Actual != verification.Expected {
+		t.Errorf("Verification = %+v, want verified on the first attempt", verification)
+	}
+	if state.saves != 1 {
+		t.Errorf("SaveState called %d times, want 1", state.saves)
+	}
+
+	backup, err := manager.LoadSnapshot(result.BackupID)
+	if err != nil {
+		t.Fatalf("LoadSnapshot(backup) error = %v", err)
+	}
+	if backup.Metadata.TriggerReason != TriggerPreRestore {
+		t.Errorf("backup trigger = %s, want %s", backup.Metadata.TriggerReason, TriggerPreRestore)
+	}
+}
+
+func TestManager_RestoreSnapshotRollsForward(t *testing.T) {
+	manager, state, snapshotID := newLossyRestore(t, 1)
+
+	result, err := manager.RestoreSnapshot(context.Background(), snapshotID, false)
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if !result.Success || !result.Verification.Verified || result.Verification.Attempts != 2 {
+		t.Errorf("result = %+v, want success verified on the second attempt", result)
+	}
+	if _, ok := state.state.Clusters["cluster-1"]; !ok {
+		t.Error("cluster-1 not restored")
+	}
+}
+
+func TestManager_RestoreSnapshotVerificationFailure(t *testing.T) {
+	manager, state, snapshotID := newLossyRestore(t, maxRestoreAttempts)
+
+	result, err := manager.RestoreSnapshot(context.Background(), snapshotID, false)
+	if err == nil {
+		t.Fatal("RestoreSnapshot() succeeded, want verification error")
+	}
+	if result == nil {
+		t.Fatal("RestoreSnapshot() returned no result alongside the error")
+	}
+
+	if result.Success {
+		t.Error("result.Success = true, want false")
+	}
+	verification := result.Verification
+	if verification.Verified || verification.Attempts != maxRestoreAttempts || verification.Actual == verification.Expected {
+		t.Errorf("Verification = %+v, want unverified after %d attempts", verification, maxRestoreAttempts)
+	}
+	if state.saves != maxRestoreAttempts {
+		t.Errorf("SaveState called %d times, want %d", state.saves, maxRestoreAttempts)
+	}
+
+	// The error and the report both point at the backup to roll back to
+	if result.BackupID == "" || !strings.Contains(err.Error(), result.BackupID) {
+		t.Errorf("error %q does not name backup %q", err, result.BackupID)
+	}
+	if _, err := manager.LoadSnapshot(result.BackupID); err != nil {
+		t.Errorf("LoadSnapshot(backup) error = %v", err)
+	}
+	report := FormatRestoreResult(result)
+	for _, want := range []string{"Restore failed", result.BackupID, "Verification failed"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("FormatRestoreResult() missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestFormatRestoreResult_FieldDiff(t *testing.T) {
+	before := api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Version: "1.29"},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", DesiredSize: 5}},
+	}
+	after := api.ClusterSpec{
+		ControlPlane: api.ControlPlaneSpec{Version: "1.28"},
+		WorkerPools:  []api.WorkerPoolSpec{{Name: "general", DesiredSize: 3}},
+	}
+
+	result := &RestoreResult{
+		SnapshotID: "snap-1",
+		DryRun:     true,
+		Changes: []RestoreChange{
+			{Action: ActionModify, Resource: api.ResourceID{Kind: "Cluster", Name: "prod"}, Before: before, After: after},
+			{Action: ActionAdd, Resource: api.ResourceID{Kind: "Cluster", Name: "staging"}, After: after},
+			{
+				Action:   ActionModify,
+				Resource: api.ResourceID{Kind: "NodePool", Name: "gpu"},
+				Before:   api.WorkerPoolSpec{Name: "gpu", MaxSize: 4},
+				After:    api.WorkerPoolSpec{Name: "gpu", MaxSize: 2},
+			},
+		},
+	}
+
+	report := FormatRestoreResult(result)
+	want := `  ~ Cluster/prod
+      controlPlane.version: 1.29 → 1.28
+      workerPools.general.desiredSize: 5 → 3
+  + Cluster/staging
+  ~ NodePool/gpu
+      maxSize: 4 → 2
+`
+	if !strings.HasSuffix(report, want) {
+		t.Errorf("FormatRestoreResult() =\n%s\nwant it to end with\n%s", report, want)
+	}
+
+	if fields := result.Changes[1].FieldChanges(); fields != nil {
+		t.Errorf("FieldChanges() of an add = %v, want none", fields)
+	}
+}
+
+func TestParseResourceSelector(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ResourceSelector
+		wantErr bool
+	}{
+		{input: "cluster=prod", want: ResourceSelector{Kind: "Cluster", Name: "prod"}},
+		{input: "NodePool=gpu", want: ResourceSelector{Kind: "NodePool", Name: "gpu"}},
+		{input: "cluster=", wantErr: true},
+		{input: "prod", wantErr: true},
+		{input: "network=main", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseResourceSelector(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResourceSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseResourceSelector() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestManager_VerifySnapshots(t *testing.T) {
+	tempDir := t.TempDir()
+	state := &mockStateManager{
+		state: engine.State{
+			Clusters: map[string]*api.Cluster{
+				"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}},
+			},
+		},
+	}
+	manager, err := NewManager(tempDir, state)
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+
+	ctx := context.Background()
+	good, err := manager.CreateSnapshot(ctx, "good", TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	tampered, err := manager.CreateSnapshot(ctx, "tampered", TriggerManual)
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	// Rename the cluster without changing the file's length, which the old
+	// length checksum would not notice
+	path := filepath.Join(tempDir, tampered.ID+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), `"prod"`, `"dorp"`, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "snapshot-broken.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := manager.VerifySnapshots()
+	if err != nil {
+		t.Fatalf("VerifySnapshots() error = %v", err)
+	}
+	want := map[string]VerifyStatus{
+		good.ID:           VerifyOK,
+		tampered.ID:       VerifyCorrupt,
+		"snapshot-broken": VerifyUnreadable,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("VerifySnapshots() = %+v, want %d results", results, len(want))
+	}
+	for _, result := range results {
+		if result.Status != want[result.ID] {
+			t.Errorf("snapshot %s status = %s (%s), want %s", result.ID, result.Status, result.Detail, want[result.ID])
+		}
+	}
+
+	// A single snapshot can be verified by ID
+	results, err = manager.VerifySnapshots(good.ID)
+	if err != nil || len(results) != 1 || results[0].Status != VerifyOK {
+		t.Errorf("VerifySnapshots(%s) = %+v, %v, want it ok", good.ID, results, err)
+	}
+
+	if _, err := manager.RestoreSnapshot(ctx, tampered.ID, true); err == nil {
+		t.Error("RestoreSnapshot() of a tampered snapshot succeeded, want a checksum error")
+	}
+}
+
+func TestManager_VerifySnapshot_LegacyChecksum(t *testing.T) {
+	manager, err := NewManager(t.TempDir(), &mockStateManager{})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	state := engine.State{Clusters: map[string]*api.Cluster{"cluster-1": {ID: "cluster-1"}}}
+
+	tests := []struct {
+		name    string
+		version string
+		want    VerifyStatus
+	}{
+		{name: "snapshot-legacy", version: legacySnapshotVersion, want: VerifyLegacy},
+		// A current snapshot passed off as legacy by swapping in a length checksum
+		{name: "snapshot-downgraded", version: snapshotVersion, want: VerifyCorrupt},
+	}
+
+	for _, tt := range tests {
+		snapshot := &Snapshot{
+			ID:       tt.name,
+			State:    state,
+			Metadata: SnapshotMetadata{Version: tt.version},
+			Checksum: legacyChecksum(state),
+		}
+		if err := manager.saveSnapshot(snapshot); err != nil {
+			t.Fatalf("saveSnapshot() error = %v", err)
+		}
+		if result := manager.VerifySnapshot(tt.name); result.Status != tt.want {
+			t.Errorf("VerifySnapshot(%s) = %s (%s), want %s", tt.name, result.Status, result.Detail, tt.want)
+		}
+	}
+}
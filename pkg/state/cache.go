@@ -0,0 +1,63 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLiteRefreshCache keeps refreshed provider state in the state database
+// for a short time, so that back-to-back plans from separate provctl runs can
+// skip querying the providers again
+type SQLiteRefreshCache struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// RefreshCache returns the refresh cache sharing this state database
+func (s *SQLiteStateManager) RefreshCache() *SQLiteRefreshCache {
+	return &SQLiteRefreshCache{db: s.db, now: time.Now}
+}
+
+// Get returns the unexpired value stored under key
+func (c *SQLiteRefreshCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	err := c.db.QueryRowContext(ctx,
+		"SELECT value FROM refresh_cache WHERE key = ? AND expires_at > ?",
+		key, c.now().UnixNano(),
+	).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read refresh cache: %w", err)
+	}
+	return value, true, nil
+}
+
+// Put stores value under key for ttl. The provider the value was fetched
+// from is recorded so that Invalidate can drop it.
+func (c *SQLiteRefreshCache) Put(ctx context.Context, key, provider string, value []byte, ttl time.Duration) error {
+	if _, err := c.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO refresh_cache (key, provider, value, expires_at)
+		 VALUES (?, ?, ?, ?)`,
+		key, provider, value, c.now().Add(ttl).UnixNano(),
+	); err != nil {
+		return fmt.Errorf("failed to write refresh cache: %w", err)
+	}
+	return nil
+}
+
+// Invalidate drops every entry fetched from provider, along with any expired
+// entries
+func (c *SQLiteRefreshCache) Invalidate(ctx context.Context, provider string) error {
+	if _, err := c.db.ExecContext(ctx,
+		"DELETE FROM refresh_cache WHERE provider = ? OR expires_at <= ?",
+		provider, c.now().UnixNano(),
+	); err != nil {
+		return fmt.Errorf("failed to invalidate refresh cache: %w", err)
+	}
+	return nil
+}
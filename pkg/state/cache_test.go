@@ -0,0 +1,56 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteRefreshCache(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	now := time.Now()
+	cache := sm.RefreshCache()
+	cache.now = func() time.Time { return now }
+
+	get := func(key string) string {
+		t.Helper()
+		value, ok, err := cache.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if !ok {
+			return ""
+		}
+		return string(value)
+	}
+
+	for key, provider := range map[string]string{"aws-west": "aws", "aws-east": "aws", "azure-east": "azure"} {
+		if err := cache.Put(ctx, key, provider, []byte(key), time.Minute); err != nil {
+			t.Fatalf("Put(%s) error = %v", key, err)
+		}
+	}
+	if got := get("aws-west"); got != "aws-west" {
+		t.Errorf("Get(aws-west) = %q, want aws-west", got)
+	}
+	if got := get("gcp-west"); got != "" {
+		t.Errorf("Get(gcp-west) = %q, want a miss", got)
+	}
+
+	if err := cache.Invalidate(ctx, "aws"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if get("aws-west") != "" || get("aws-east") != "" {
+		t.Error("Invalidate(aws) left aws entries")
+	}
+	if got := get("azure-east"); got != "azure-east" {
+		t.Errorf("Get(azure-east) = %q after invalidating aws, want azure-east", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got := get("azure-east"); got != "" {
+		t.Errorf("Get(azure-east) = %q after its TTL, want a miss", got)
+	}
+}
@@ -0,0 +1,241 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+// eventTimeLayout stores timestamps in UTC at a fixed width, so that the
+// text ordering of the timestamp column is chronological
+const eventTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteEventStore implements EventStore on the events table of the state
+// database
+type SQLiteEventStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+var _ engine.EventStore = (*SQLiteEventStore)(nil)
+
+// Events returns the event store sharing this state database
+func (s *SQLiteStateManager) Events() *SQLiteEventStore {
+	return &SQLiteEventStore{db: s.db, now: time.Now}
+}
+
+// RecordEvent stores an event, assigning an ID and timestamp if unset
+func (e *SQLiteEventStore) RecordEvent(ctx context.Context, event api.Event) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = e.now()
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	_, err = e.db.ExecContext(ctx,
+		`INSERT INTO events (id, timestamp, type, resource_provider, resource_kind, resource_id, resource_name, actor, payload, correlation_id, plan_action)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID.String(), event.Timestamp.UTC().Format(eventTimeLayout), string(event.Type),
+		event.Resource.Provider, event.Resource.Kind, event.Resource.ID, event.Resource.Name,
+		event.Actor, string(payload), event.CorrelationID, event.PlanAction,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+	return nil
+}
+
+// GetEvents returns the events of one resource, oldest first
+func (e *SQLiteEventStore) GetEvents(ctx context.Context, resourceID api.ResourceID) ([]api.Event, error) {
+	return e.GetEventsByTimeRange(ctx, time.Time{}, time.Time{}, resourceID)
+}
+
+// GetEventsByTimeRange returns the events recorded at or after from and
+// before to, oldest first. A zero from or to leaves that end of the range
+// open, and a range whose from is after its to is an error. Events with the
+// same timestamp are ordered by ID, so a caller can page through a long
+// range by moving from past the last event it has seen.
+//
+// With resource filters only events matching one of them are returned.
+// Empty filter fields match any value, so ResourceID{Kind: "Cluster"}
+// selects the events of every cluster.
+func (e *SQLiteEventStore) GetEventsByTimeRange(ctx context.Context, from, to time.Time, resources ...api.ResourceID) ([]api.Event, error) {
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return nil, fmt.Errorf("invalid time range: %s is after %s",
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !from.IsZero() {
+		conditions = append(conditions, "timestamp >= ?")
+		args = append(args, from.UTC().Format(eventTimeLayout))
+	}
+	if !to.IsZero() {
+		conditions = append(conditions, "timestamp < ?")
+		args = append(args, to.UTC().Format(eventTimeLayout))
+	}
+
+	if condition, matchArgs := resourceCondition(resources); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, matchArgs...)
+	}
+
+	query := "SELECT " + eventColumns + " FROM events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY timestamp, id"
+
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []api.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// resourceCondition returns the condition selecting the events of any of
+// resources, and its arguments, or "" when one of them is an empty filter
+func resourceCondition(resources []api.ResourceID) (string, []interface{}) {
+	var matches []string
+	var args []interface{}
+	for _, resource := range resources {
+		var fields []string
+		var fieldArgs []interface{}
+		for _, field := range []struct{ column, value string }{
+			{"resource_provider", resource.Provider},
+			{"resource_kind", resource.Kind},
+			{"resource_id", resource.ID},
+			{"resource_name", resource.Name},
+		} {
+			if field.value != "" {
+				fields = append(fields, field.column+" = ?")
+				fieldArgs = append(fieldArgs, field.value)
+			}
+		}
+		if len(fields) == 0 {
+			// An empty filter matches every event
+			return "", nil
+		}
+		matches = append(matches, "("+strings.Join(fields, " AND ")+")")
+		args = append(args, fieldArgs...)
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(matches, " OR ") + ")", args
+}
+
+// streamBatchSize is how many events StreamEvents reads per query; tests
+// lower it to cross batch boundaries
+var streamBatchSize = 500
+
+// StreamEvents writes the events recorded at or after from to w as
+// newline-delimited JSON, oldest first, for shipping to a SIEM. A zero from
+// streams every event. Events are read in batches, each continuing after
+// the last event written, so memory use does not grow with the log.
+func (e *SQLiteEventStore) StreamEvents(ctx context.Context, from time.Time, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	// The cursor is the (timestamp, id) of the last event written; the
+	// first batch starts at from instead
+	var lastTimestamp, lastID string
+	for {
+		query := "SELECT " + eventColumns + " FROM events WHERE "
+		var args []interface{}
+		if lastID == "" {
+			query += "timestamp >= ?"
+			args = append(args, from.UTC().Format(eventTimeLayout))
+		} else {
+			query += "(timestamp > ? OR (timestamp = ? AND id > ?))"
+			args = append(args, lastTimestamp, lastTimestamp, lastID)
+		}
+		query += " ORDER BY timestamp, id LIMIT ?"
+		args = append(args, streamBatchSize)
+
+		rows, err := e.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to query events: %w", err)
+		}
+
+		count := 0
+		for rows.Next() {
+			event, err := scanEvent(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			if err := encoder.Encode(event); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to write event %s: %w", event.ID, err)
+			}
+			lastTimestamp, lastID = event.Timestamp.UTC().Format(eventTimeLayout), event.ID.String()
+			count++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read events: %w", err)
+		}
+
+		if count < streamBatchSize {
+			return nil
+		}
+	}
+}
+
+// eventColumns are the columns scanEvent reads, in order
+const eventColumns = "id, timestamp, type, resource_provider, resource_kind, resource_id, resource_name, actor, payload, correlation_id, plan_action"
+
+// scanEvent reads the event in the current row of a query selecting
+// eventColumns
+func scanEvent(rows *sql.Rows) (api.Event, error) {
+	var event api.Event
+	var id, eventType, payload string
+
+	if err := rows.Scan(&id, &event.Timestamp, &eventType, &event.Resource.Provider, &event.Resource.Kind,
+		&event.Resource.ID, &event.Resource.Name, &event.Actor, &payload, &event.CorrelationID, &event.PlanAction); err != nil {
+		return api.Event{}, fmt.Errorf("failed to scan event row: %w", err)
+	}
+
+	var err error
+	if event.ID, err = uuid.Parse(id); err != nil {
+		return api.Event{}, fmt.Errorf("invalid event id %q: %w", id, err)
+	}
+	event.Type = api.EventType(eventType)
+	if err := json.Unmarshal([]byte(payload), &event.Payload); err != nil {
+		return api.Event{}, fmt.Errorf("invalid payload of event %s: %w", id, err)
+	}
+	return event, nil
+}
+
+// ReplayEvents is not supported: events record what changed, not complete
+// resource state, so state is read from the state tables instead
+func (e *SQLiteEventStore) ReplayEvents(ctx context.Context, since *api.Event) (engine.State, error) {
+	return engine.State{}, fmt.Errorf("event replay is not supported by the SQLite event store")
+}
@@ -0,0 +1,233 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestSQLiteEventStore_GetEventsByTimeRange(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+	events := sm.Events()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	prod := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "prod"}
+	pool := api.ResourceID{Provider: "aws", Kind: "NodePool", ID: "np-1", Name: "general"}
+	recorded := []api.Event{
+		{Timestamp: base, Type: api.EventCreated, Resource: prod, Actor: "alice"},
+		{Timestamp: base.Add(time.Hour), Type: api.EventCreated, Resource: pool},
+		{Timestamp: base.Add(2 * time.Hour), Type: api.EventUpdated, Resource: prod,
+			Payload: map[string]interface{}{"version": "1.29"}},
+		// Recorded in another zone, still ordered by instant
+		{Timestamp: base.Add(3 * time.Hour).In(time.FixedZone("CEST", 2*60*60)), Type: api.EventDeleted, Resource: pool},
+	}
+	for _, event := range recorded {
+		if err := events.RecordEvent(ctx, event); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		from, to  time.Time
+		resources []api.ResourceID
+		want      []api.EventType
+	}{
+		{"open range", time.Time{}, time.Time{}, nil,
+			[]api.EventType{api.EventCreated, api.EventCreated, api.EventUpdated, api.EventDeleted}},
+		{"from inclusive, to exclusive", base.Add(time.Hour), base.Add(3 * time.Hour), nil,
+			[]api.EventType{api.EventCreated, api.EventUpdated}},
+		{"open start", time.Time{}, base.Add(time.Hour), nil, []api.EventType{api.EventCreated}},
+		{"open end", base.Add(3 * time.Hour), time.Time{}, nil, []api.EventType{api.EventDeleted}},
+		{"empty range", base.Add(time.Hour), base.Add(time.Hour), nil, nil},
+		{"no events in range", base.Add(-2 * time.Hour), base.Add(-time.Hour), nil, nil},
+		{"resource filter", time.Time{}, time.Time{}, []api.ResourceID{prod},
+			[]api.EventType{api.EventCreated, api.EventUpdated}},
+		{"kind filter", base, base.Add(4 * time.Hour), []api.ResourceID{{Kind: "NodePool"}},
+			[]api.EventType{api.EventCreated, api.EventDeleted}},
+		{"empty filter after another", base, base.Add(4 * time.Hour), []api.ResourceID{prod, {}},
+			[]api.EventType{api.EventCreated, api.EventCreated, api.EventUpdated, api.EventDeleted}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := events.GetEventsByTimeRange(ctx, tt.from, tt.to, tt.resources...)
+			if err != nil {
+				t.Fatalf("GetEventsByTimeRange() error = %v", err)
+			}
+			var types []api.EventType
+			for _, event := range got {
+				types = append(types, event.Type)
+			}
+			if len(types) != len(tt.want) {
+				t.Fatalf("GetEventsByTimeRange() = %v, want %v", types, tt.want)
+			}
+			for i := range types {
+				if types[i] != tt.want[i] {
+					t.Errorf("GetEventsByTimeRange() = %v, want %v", types, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	if _, err := events.GetEventsByTimeRange(ctx, base.Add(time.Hour), base); err == nil {
+		t.Error("GetEventsByTimeRange() error = nil, want an error for a reversed range")
+	}
+
+	got, err := events.GetEvents(ctx, prod)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Actor != "alice" || !got[0].Timestamp.Equal(base) {
+		t.Fatalf("GetEvents() = %+v, want prod's two events", got)
+	}
+	if payload, ok := got[1].Payload.(map[string]interface{}); !ok || payload["version"] != "1.29" {
+		t.Errorf("GetEvents() payload = %#v, want the recorded version", got[1].Payload)
+	}
+}
+
+func TestResourceCondition(t *testing.T) {
+	prod := api.ResourceID{Provider: "aws", Kind: "Cluster", Name: "prod"}
+
+	tests := []struct {
+		name      string
+		resources []api.ResourceID
+		want      string
+		wantArgs  int
+	}{
+		{"no filters", nil, "", 0},
+		{"one filter", []api.ResourceID{prod}, "((resource_provider = ? AND resource_kind = ? AND resource_name = ?))", 3},
+		{"two filters", []api.ResourceID{prod, {Kind: "NodePool"}},
+			"((resource_provider = ? AND resource_kind = ? AND resource_name = ?) OR (resource_kind = ?))", 4},
+		{"empty filter after another", []api.ResourceID{prod, {}}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args := resourceCondition(tt.resources)
+			if got != tt.want {
+				t.Errorf("resourceCondition() = %q, want %q", got, tt.want)
+			}
+			if len(args) != tt.wantArgs || strings.Count(got, "?") != len(args) {
+				t.Errorf("resourceCondition() args = %v, want %d, one per placeholder", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSQLiteEventStore_StreamEvents(t *testing.T) {
+	defer func(size int) { streamBatchSize = size }(streamBatchSize)
+	streamBatchSize = 3
+
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+	events := sm.Events()
+
+	// Recorded out of order, with several events sharing a timestamp so
+	// that a batch ends between them
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	offsets := []time.Duration{5, 1, 3, 3, 3, 3, 0, 4, 2}
+	prod := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "prod"}
+	for _, offset := range offsets {
+		event := api.Event{Timestamp: base.Add(offset * time.Minute), Type: api.EventUpdated, Resource: prod,
+			Payload: map[string]interface{}{"offset": float64(offset)}}
+		if err := events.RecordEvent(ctx, event); err != nil {
+			t.Fatalf("RecordEvent() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		want int
+	}{
+		{"every event", time.Time{}, len(offsets)},
+		{"from inclusive", base.Add(3 * time.Minute), 6},
+		{"after the last event", base.Add(time.Hour), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := events.StreamEvents(ctx, tt.from, &out); err != nil {
+				t.Fatalf("StreamEvents() error = %v", err)
+			}
+
+			var streamed []api.Event
+			seen := make(map[string]bool)
+			scanner := bufio.NewScanner(&out)
+			for scanner.Scan() {
+				var event api.Event
+				if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+					t.Fatalf("line %d is not a JSON event: %v: %s", len(streamed)+1, err, scanner.Text())
+				}
+				if seen[event.ID.String()] {
+					t.Errorf("event %s streamed twice", event.ID)
+				}
+				seen[event.ID.String()] = true
+				streamed = append(streamed, event)
+			}
+
+			if len(streamed) != tt.want {
+				t.Fatalf("StreamEvents() wrote %d events, want %d", len(streamed), tt.want)
+			}
+			for i, event := range streamed {
+				if event.Timestamp.Before(tt.from) {
+					t.Errorf("event at %v streamed, before %v", event.Timestamp, tt.from)
+				}
+				if i > 0 && event.Timestamp.Before(streamed[i-1].Timestamp) {
+					t.Errorf("event %d at %v streamed after one at %v", i, event.Timestamp, streamed[i-1].Timestamp)
+				}
+				if event.Resource != prod || event.Payload == nil {
+					t.Errorf("event %d = %+v, want prod's event with its payload", i, event)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteEventStore_CorrelationIDMigration(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+
+	// An events table from before correlation IDs
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE events (
+		id TEXT PRIMARY KEY, timestamp DATETIME NOT NULL, type TEXT NOT NULL,
+		resource_provider TEXT NOT NULL, resource_kind TEXT NOT NULL, resource_id TEXT NOT NULL,
+		resource_name TEXT NOT NULL, actor TEXT NOT NULL, payload TEXT NOT NULL
+	);
+	INSERT INTO events VALUES ('00000000-0000-0000-0000-000000000001', '2024-05-01T12:00:00.000000000Z',
+		'Created', 'aws', 'Cluster', 'c-1', 'prod', '', 'null');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+
+	events := newTestManager(t, path).Events()
+	prod := api.ResourceID{Provider: "aws", Kind: "Cluster", ID: "c-1", Name: "prod"}
+	if err := events.RecordEvent(ctx, api.Event{Type: api.EventUpdated, Resource: prod, CorrelationID: "apply-1"}); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+
+	got, err := events.GetEvents(ctx, prod)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(got) != 2 || got[0].CorrelationID != "" || got[1].CorrelationID != "apply-1" {
+		t.Errorf("GetEvents() = %+v, want the old event without and the new one with a correlation ID", got)
+	}
+}
@@ -0,0 +1,253 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+// DefaultLockTTL is how long a lock is honoured without being renewed before
+// it is considered stale and may be broken by another process
+const DefaultLockTTL = 15 * time.Minute
+
+// stateLockName is the lock row guarding the whole state
+const stateLockName = "state"
+
+// stateLockPoll is how often Lock retries a held state lock while waiting
+const stateLockPoll = 250 * time.Millisecond
+
+// lockWaitNotifyInterval is how often Lock reports that it is still waiting
+var lockWaitNotifyInterval = 10 * time.Second
+
+// lockHeartbeatInterval is how often a held state lock is renewed, so that an
+// apply outliving the lock TTL keeps it while a crashed process's lock still
+// goes stale
+var lockHeartbeatInterval = time.Minute
+
+// LockWaitFunc is told who holds the state lock and how long Lock has
+// waited for it so far
+type LockWaitFunc func(holder *LockError, waited time.Duration)
+
+// LockError is returned when a lock is held by another owner
+type LockError struct {
+	Name  string
+	Owner string
+	Since time.Time
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("%s is locked by %s since %s", e.Name, e.Owner, e.Since.Format(time.RFC3339))
+}
+
+// Lock acquires the advisory state lock and renews it until Unlock. If
+// another owner holds it, Lock fails at once unless a lock timeout is set, in
+// which case it waits for the lock until the timeout elapses or ctx is done.
+func (s *SQLiteStateManager) Lock(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	s.startHeartbeat(stateLockName)
+	return nil
+}
+
+func (s *SQLiteStateManager) lock(ctx context.Context) error {
+	err := s.acquire(ctx, stateLockName)
+
+	var lockErr *LockError
+	if s.lockTimeout <= 0 || !errors.As(err, &lockErr) {
+		return err
+	}
+
+	start := time.Now()
+	timeout := time.NewTimer(s.lockTimeout)
+	defer timeout.Stop()
+	poll := time.NewTicker(stateLockPoll)
+	defer poll.Stop()
+	notify := time.NewTicker(lockWaitNotifyInterval)
+	defer notify.Stop()
+
+	if s.lockWaitNotify != nil {
+		s.lockWaitNotify(lockErr, 0)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), lockErr)
+		case <-timeout.C:
+			return fmt.Errorf("timed out after %s waiting for the lock: %w", s.lockTimeout, lockErr)
+		case <-notify.C:
+			if s.lockWaitNotify != nil {
+				s.lockWaitNotify(lockErr, time.Since(start))
+			}
+			continue
+		case <-poll.C:
+		}
+
+		err := s.acquire(ctx, stateLockName)
+		if !errors.As(err, &lockErr) {
+			return err
+		}
+	}
+}
+
+// Unlock releases the state lock held by this manager
+func (s *SQLiteStateManager) Unlock(ctx context.Context) error {
+	s.stopHeartbeat()
+	return s.release(ctx, stateLockName)
+}
+
+// ForceUnlock releases the state lock regardless of its owner
+func (s *SQLiteStateManager) ForceUnlock(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM locks WHERE name = ?", stateLockName); err != nil {
+		return fmt.Errorf("failed to force unlock state: %w", err)
+	}
+	return nil
+}
+
+// LockInfo returns the current holder of the state lock, or nil if unlocked
+func (s *SQLiteStateManager) LockInfo(ctx context.Context) (*LockError, error) {
+	return s.holder(ctx, stateLockName)
+}
+
+func (s *SQLiteStateManager) acquire(ctx context.Context, name string) error {
+	now := time.Now()
+
+	// Break stale locks left behind by crashed processes
+	if _, err := s.db.ExecContext(ctx,
+		"DELETE FROM locks WHERE name = ? AND expires_at < ?",
+		name, now.UnixNano(),
+	); err != nil {
+		return fmt.Errorf("failed to clear stale lock: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO locks (name, owner, acquired_at, expires_at)
+		 VALUES (?, ?, ?, ?) ON CONFLICT(name) DO NOTHING`,
+		name, s.lockOwner, now.UnixNano(), now.Add(s.lockTTL).UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 1 {
+		return nil
+	}
+
+	holder, err := s.holder(ctx, name)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		// Released between our insert and lookup; try again
+		return s.acquire(ctx, name)
+	}
+	return holder
+}
+
+func (s *SQLiteStateManager) release(ctx context.Context, name string) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM locks WHERE name = ? AND owner = ?",
+		name, s.lockOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%s lock is not held by %s", name, s.lockOwner)
+	}
+	return nil
+}
+
+// startHeartbeat renews the lock every lockHeartbeatInterval until
+// stopHeartbeat, or until the lock is found broken or released
+func (s *SQLiteStateManager) startHeartbeat(name string) {
+	s.heartbeatMu.Lock()
+	defer s.heartbeatMu.Unlock()
+	if s.heartbeatStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.heartbeatStop, s.heartbeatDone = stop, done
+
+	ticker := time.NewTicker(lockHeartbeatInterval)
+	go func() {
+		defer close(done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if !s.renew(name) {
+				return
+			}
+		}
+	}()
+}
+
+// stopHeartbeat stops renewing the lock and waits for a renewal in flight
+func (s *SQLiteStateManager) stopHeartbeat() {
+	s.heartbeatMu.Lock()
+	stop, done := s.heartbeatStop, s.heartbeatDone
+	s.heartbeatStop, s.heartbeatDone = nil, nil
+	s.heartbeatMu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// renew extends the lock's expiry if this manager still holds it, and reports
+// whether it does
+func (s *SQLiteStateManager) renew(name string) bool {
+	result, err := s.db.Exec(
+		"UPDATE locks SET expires_at = ? WHERE name = ? AND owner = ?",
+		time.Now().Add(s.lockTTL).UnixNano(), name, s.lockOwner,
+	)
+	if err != nil {
+		// Keep trying; the lock only goes stale if renewals fail for a TTL
+		return true
+	}
+	rows, _ := result.RowsAffected()
+	return rows == 1
+}
+
+func (s *SQLiteStateManager) holder(ctx context.Context, name string) (*LockError, error) {
+	var owner string
+	var acquiredAt int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT owner, acquired_at FROM locks WHERE name = ?", name,
+	).Scan(&owner, &acquiredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock: %w", err)
+	}
+
+	return &LockError{Name: name, Owner: owner, Since: time.Unix(0, acquiredAt)}, nil
+}
+
+func defaultLockOwner() string {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return fmt.Sprintf("%s@%s (pid %d)", username, hostname, os.Getpid())
+}
@@ -0,0 +1,215 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, dbPath string, opts ...Option) *SQLiteStateManager {
+	t.Helper()
+
+	sm, err := NewSQLiteStateManager(dbPath, opts...)
+	if err != nil {
+		t.Fatalf("NewSQLiteStateManager() error = %v", err)
+	}
+	t.Cleanup(func() { sm.Close() })
+
+	return sm
+}
+
+func TestSQLiteStateManager_Lock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	first := newTestManager(t, dbPath, WithLockOwner("alice"))
+	second := newTestManager(t, dbPath, WithLockOwner("bob"))
+
+	if err := first.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	err := second.Lock(ctx)
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("Lock() error = %v, want LockError", err)
+	}
+	if lockErr.Owner != "alice" {
+		t.Errorf("LockError.Owner = %s, want alice", lockErr.Owner)
+	}
+	if !strings.Contains(err.Error(), "state is locked by alice since") {
+		t.Errorf("Lock() error message = %q", err.Error())
+	}
+
+	// Only the owner can release the lock
+	if err := second.Unlock(ctx); err == nil {
+		t.Error("Unlock() by non-owner should fail")
+	}
+
+	if err := first.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if err := second.Lock(ctx); err != nil {
+		t.Errorf("Lock() after release error = %v", err)
+	}
+}
+
+func TestSQLiteStateManager_LockWait(t *testing.T) {
+	defer func(interval time.Duration) { lockWaitNotifyInterval = interval }(lockWaitNotifyInterval)
+	lockWaitNotifyInterval = 100 * time.Millisecond
+
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var waits []time.Duration
+	notify := func(holder *LockError, waited time.Duration) {
+		if holder.Owner != "alice" {
+			t.Errorf("waiting for a lock held by %s, want alice", holder.Owner)
+		}
+		mu.Lock()
+		waits = append(waits, waited)
+		mu.Unlock()
+	}
+
+	holder := newTestManager(t, dbPath, WithLockOwner("alice"))
+	waiter := newTestManager(t, dbPath, WithLockOwner("bob"), WithLockTimeout(5*time.Second), WithLockWaitNotify(notify))
+
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	go func() {
+		time.Sleep(350 * time.Millisecond)
+		if err := holder.Unlock(ctx); err != nil {
+			t.Errorf("Unlock() error = %v", err)
+		}
+	}()
+
+	start := time.Now()
+	if err := waiter.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v, want the lock once it is released", err)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("Lock() returned after %v, before the lock was released", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(waits) < 2 || waits[0] != 0 || waits[len(waits)-1] <= waits[0] {
+		t.Errorf("notified after waiting %v, want at the start and periodically after", waits)
+	}
+}
+
+func TestSQLiteStateManager_LockTimeout(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	holder := newTestManager(t, dbPath, WithLockOwner("alice"))
+	waiter := newTestManager(t, dbPath, WithLockOwner("bob"), WithLockTimeout(300*time.Millisecond))
+
+	if err := holder.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	start := time.Now()
+	err := waiter.Lock(ctx)
+	elapsed := time.Since(start)
+
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) || lockErr.Owner != "alice" {
+		t.Fatalf("Lock() error = %v, want a LockError naming alice", err)
+	}
+	if !strings.Contains(err.Error(), "timed out after 300ms") {
+		t.Errorf("Lock() error message = %q, want the timeout", err.Error())
+	}
+	if elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Lock() gave up after %v, want about 300ms", elapsed)
+	}
+}
+
+func TestSQLiteStateManager_LockStale(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	crashed := newTestManager(t, dbPath, WithLockOwner("crashed"), WithLockTTL(10*time.Millisecond))
+	next := newTestManager(t, dbPath, WithLockOwner("next"))
+
+	if err := crashed.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if err := next.Lock(ctx); err != nil {
+		t.Errorf("Lock() should break stale lock, got error = %v", err)
+	}
+}
+
+func TestSQLiteStateManager_LockHeartbeat(t *testing.T) {
+	defer func(interval time.Duration) { lockHeartbeatInterval = interval }(lockHeartbeatInterval)
+	lockHeartbeatInterval = 10 * time.Millisecond
+
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	applying := newTestManager(t, dbPath, WithLockOwner("applying"), WithLockTTL(100*time.Millisecond))
+	next := newTestManager(t, dbPath, WithLockOwner("next"))
+
+	if err := applying.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	// A lock held past its TTL is renewed rather than broken
+	time.Sleep(300 * time.Millisecond)
+
+	err := next.Lock(ctx)
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) || lockErr.Owner != "applying" {
+		t.Fatalf("Lock() error = %v, want a LockError naming applying", err)
+	}
+
+	if err := applying.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if err := next.Lock(ctx); err != nil {
+		t.Fatalf("Lock() after release error = %v", err)
+	}
+
+}
+
+func TestSQLiteStateManager_ForceUnlock(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	ctx := context.Background()
+
+	stuck := newTestManager(t, dbPath, WithLockOwner("stuck"))
+	operator := newTestManager(t, dbPath, WithLockOwner("operator"))
+
+	if err := stuck.Lock(ctx); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	holder, err := operator.LockInfo(ctx)
+	if err != nil {
+		t.Fatalf("LockInfo() error = %v", err)
+	}
+	if holder == nil || holder.Owner != "stuck" {
+		t.Fatalf("LockInfo() = %v, want holder stuck", holder)
+	}
+
+	if err := operator.ForceUnlock(ctx); err != nil {
+		t.Fatalf("ForceUnlock() error = %v", err)
+	}
+
+	holder, err = operator.LockInfo(ctx)
+	if err != nil {
+		t.Fatalf("LockInfo() error = %v", err)
+	}
+	if holder != nil {
+		t.Errorf("LockInfo() after ForceUnlock = %v, want nil", holder)
+	}
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// orphanedCondition selects node pools linked to a cluster that is no
+// longer in state. Pools whose cluster is unknown, saved before pools were
+// linked, are never orphans.
+const orphanedCondition = "cluster_id != '' AND cluster_id NOT IN (SELECT id FROM clusters)"
+
+// FindOrphanedNodePools returns the node pools whose cluster is no longer in
+// state, sorted by ID. Nothing reconciles such pools; their cloud resources
+// may still exist.
+func (s *SQLiteStateManager) FindOrphanedNodePools(ctx context.Context) ([]*api.NodePool, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, cluster_id, metadata, spec, status FROM node_pools WHERE "+orphanedCondition+" ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned node pools: %w", err)
+	}
+	defer rows.Close()
+
+	var pools []*api.NodePool
+	for rows.Next() {
+		pool, err := scanNodePool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	return pools, rows.Err()
+}
+
+// DeleteOrphanedNodePools removes the node pools with the given IDs from
+// state, skipping any that are no longer orphaned, and returns how many it
+// removed. Cloud resources are not touched.
+func (s *SQLiteStateManager) DeleteOrphanedNodePools(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM node_pools WHERE id IN ("+placeholders+") AND "+orphanedCondition, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphaned node pools: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// scanNodePool reads a node pool row of id, cluster_id, metadata, spec and
+// status. The stored cluster ID fills in the pool's cluster ID property if
+// it lacks one, so the link survives being saved again.
+func scanNodePool(rows *sql.Rows) (*api.NodePool, error) {
+	var id, clusterID string
+	var metadataJSON, specJSON, statusJSON string
+
+	if err := rows.Scan(&id, &clusterID, &metadataJSON, &specJSON, &statusJSON); err != nil {
+		return nil, fmt.Errorf("failed to scan node pool row: %w", err)
+	}
+
+	pool := &api.NodePool{ID: id}
+	if err := json.Unmarshal([]byte(metadataJSON), &pool.Metadata); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(specJSON), &pool.Spec); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(statusJSON), &pool.Status); err != nil {
+		return nil, err
+	}
+
+	if pool.Status.Properties[api.PropertyClusterID] == "" {
+		pool.Status.SetProperty(api.PropertyClusterID, clusterID)
+	}
+	return pool, nil
+}
+
+// poolClusterID returns the ID of the cluster a node pool belongs to: the
+// one the provider recorded, else that of the cluster with the pool's
+// recorded cluster name among clusterIDs, keyed by name. It is empty when
+// neither is known.
+func poolClusterID(pool *api.NodePool, clusterIDs map[string]string) string {
+	if id := pool.Status.Properties[api.PropertyClusterID]; id != "" {
+		return id
+	}
+	if name := pool.Status.Properties[api.PropertyClusterName]; name != "" {
+		return clusterIDs[name]
+	}
+	return ""
+}
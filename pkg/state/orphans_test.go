@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestSQLiteStateManager_OrphanedNodePools(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	pool := func(id string, properties map[string]string) *api.NodePool {
+		return &api.NodePool{
+			ID:       id,
+			Metadata: api.ResourceMetadata{Name: id},
+			Status:   api.ResourceStatus{Properties: properties},
+		}
+	}
+	seed := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}},
+			"cluster-2": {ID: "cluster-2", Metadata: api.ResourceMetadata{Name: "staging"}},
+		},
+		NodePools: map[string]*api.NodePool{
+			"np-linked":   pool("np-linked", map[string]string{api.PropertyClusterID: "cluster-2"}),
+			"np-by-name":  pool("np-by-name", map[string]string{api.PropertyClusterName: "staging"}),
+			"np-kept":     pool("np-kept", map[string]string{api.PropertyClusterID: "cluster-1"}),
+			"np-unlinked": pool("np-unlinked", nil),
+		},
+	}
+	if err := sm.SaveState(ctx, seed); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	// Remove staging without its pools, as a broken cascade would
+	current, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	delete(current.Clusters, "cluster-2")
+	if err := sm.SaveState(ctx, current); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	orphans, err := sm.FindOrphanedNodePools(ctx)
+	if err != nil {
+		t.Fatalf("FindOrphanedNodePools() error = %v", err)
+	}
+	var ids []string
+	for _, orphan := range orphans {
+		ids = append(ids, orphan.ID)
+		if got := orphan.Status.Properties[api.PropertyClusterID]; got != "cluster-2" {
+			t.Errorf("orphan %s cluster ID = %q, want cluster-2", orphan.ID, got)
+		}
+	}
+	if len(ids) != 2 || ids[0] != "np-by-name" || ids[1] != "np-linked" {
+		t.Fatalf("FindOrphanedNodePools() = %v, want [np-by-name np-linked]", ids)
+	}
+
+	removed, err := sm.DeleteOrphanedNodePools(ctx, append(ids, "np-kept"))
+	if err != nil {
+		t.Fatalf("DeleteOrphanedNodePools() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("DeleteOrphanedNodePools() removed %d, want 2", removed)
+	}
+
+	got, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if len(got.NodePools) != 2 || got.NodePools["np-kept"] == nil || got.NodePools["np-unlinked"] == nil {
+		t.Errorf("node pools after pruning = %v, want np-kept and np-unlinked", got.NodePools)
+	}
+	if orphans, _ := sm.FindOrphanedNodePools(ctx); len(orphans) != 0 {
+		t.Errorf("FindOrphanedNodePools() after pruning = %v, want none", orphans)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	_ "modernc.org/sqlite"
 
@@ -13,22 +16,79 @@ import (
 	"github.com/vjranagit/cluster-api/pkg/engine"
 )
 
+// busyTimeout makes a connection wait this long for another writer, such as a
+// concurrent lock attempt, instead of failing with SQLITE_BUSY
+const busyTimeout = "_pragma=busy_timeout(5000)"
+
 // SQLiteStateManager implements StateManager using SQLite
 type SQLiteStateManager struct {
-	db     *sql.DB
-	dbPath string
+	db        *sql.DB
+	dbPath    string
+	lockOwner string
+	lockTTL   time.Duration
+
+	lockTimeout    time.Duration
+	lockWaitNotify LockWaitFunc
+
+	heartbeatMu   sync.Mutex
+	heartbeatStop chan struct{}
+	heartbeatDone chan struct{}
+}
+
+// Option configures a SQLiteStateManager
+type Option func(*SQLiteStateManager)
+
+// WithLockOwner sets the owner recorded when acquiring the state lock
+func WithLockOwner(owner string) Option {
+	return func(s *SQLiteStateManager) {
+		s.lockOwner = owner
+	}
+}
+
+// WithLockTTL sets how long a lock is held without being renewed before it is
+// considered stale. It should exceed the minute between renewals.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(s *SQLiteStateManager) {
+		s.lockTTL = ttl
+	}
+}
+
+// WithLockTimeout makes Lock wait up to timeout for a state lock held by
+// another owner instead of failing at once
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(s *SQLiteStateManager) {
+		s.lockTimeout = timeout
+	}
+}
+
+// WithLockWaitNotify sets a function Lock calls while it waits for the state
+// lock, when the wait starts and periodically after
+func WithLockWaitNotify(notify LockWaitFunc) Option {
+	return func(s *SQLiteStateManager) {
+		s.lockWaitNotify = notify
+	}
 }
 
 // NewSQLiteStateManager creates a new SQLite state manager
-func NewSQLiteStateManager(dbPath string) (*SQLiteStateManager, error) {
-	db, err := sql.Open("sqlite", dbPath)
+func NewSQLiteStateManager(dbPath string, opts ...Option) (*SQLiteStateManager, error) {
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+
+	db, err := sql.Open("sqlite", dbPath+separator+busyTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	sm := &SQLiteStateManager{
-		db:     db,
-		dbPath: dbPath,
+		db:        db,
+		dbPath:    dbPath,
+		lockOwner: defaultLockOwner(),
+		lockTTL:   DefaultLockTTL,
+	}
+	for _, opt := range opts {
+		opt(sm)
 	}
 
 	if err := sm.initialize(); err != nil {
@@ -38,6 +98,17 @@ func NewSQLiteStateManager(dbPath string) (*SQLiteStateManager, error) {
 	return sm, nil
 }
 
+// OpenSQLiteStateReadOnly opens an existing state database read-only,
+// without creating or migrating its schema, for inspecting a state file as
+// it is on disk
+func OpenSQLiteStateReadOnly(dbPath string) (*SQLiteStateManager, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&"+busyTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &SQLiteStateManager{db: db, dbPath: dbPath}, nil
+}
+
 func (s *SQLiteStateManager) initialize() error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS clusters (
@@ -69,17 +140,105 @@ func (s *SQLiteStateManager) initialize() error {
 		resource_id TEXT NOT NULL,
 		resource_name TEXT NOT NULL,
 		actor TEXT NOT NULL,
-		payload TEXT NOT NULL
+		payload TEXT NOT NULL,
+		correlation_id TEXT NOT NULL DEFAULT '',
+		plan_action TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS locks (
+		name TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		acquired_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS refresh_cache (
+		key TEXT PRIMARY KEY,
+		provider TEXT NOT NULL,
+		value BLOB NOT NULL,
+		expires_at INTEGER NOT NULL
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_events_resource ON events(resource_provider, resource_kind, resource_id);
 	CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp);
 	`
 
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	for _, migration := range columnMigrations {
+		if err := s.addColumn(migration.table, migration.column, migration.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnMigrations are columns added after a table was first released, which
+// databases created by older versions lack
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"events", "correlation_id", "TEXT NOT NULL DEFAULT ''"},
+	{"events", "plan_action", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// addColumn adds a column to a table of an existing database unless the
+// table already has it
+func (s *SQLiteStateManager) addColumn(table, column, definition string) error {
+	exists, err := s.hasColumn(table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
+// hasColumn reports whether a table has a column
+func (s *SQLiteStateManager) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// CheckSchema verifies that the database passes SQLite's integrity check and
+// has every migrated column, for diagnosing a damaged or outdated state file
+func (s *SQLiteStateManager) CheckSchema(ctx context.Context) error {
+	var result string
+	if err := s.db.QueryRowContext(ctx, "PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check database integrity: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database integrity check failed: %s", result)
+	}
+
+	for _, migration := range columnMigrations {
+		exists, err := s.hasColumn(migration.table, migration.column)
+		if err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", migration.table, err)
+		}
+		if !exists {
+			return fmt.Errorf("table %s is missing column %s", migration.table, migration.column)
+		}
+	}
+	return nil
+}
+
 // GetState retrieves current state
 func (s *SQLiteStateManager) GetState(ctx context.Context) (engine.State, error) {
 	state := engine.State{
@@ -119,38 +278,25 @@ func (s *SQLiteStateManager) GetState(ctx context.Context) (engine.State, error)
 	}
 
 	// Load node pools
-	poolRows, err := s.db.QueryContext(ctx, "SELECT id, metadata, spec, status FROM node_pools")
+	poolRows, err := s.db.QueryContext(ctx, "SELECT id, cluster_id, metadata, spec, status FROM node_pools")
 	if err != nil {
 		return state, fmt.Errorf("failed to query node pools: %w", err)
 	}
 	defer poolRows.Close()
 
 	for poolRows.Next() {
-		var id string
-		var metadataJSON, specJSON, statusJSON string
-
-		if err := poolRows.Scan(&id, &metadataJSON, &specJSON, &statusJSON); err != nil {
-			return state, fmt.Errorf("failed to scan node pool row: %w", err)
-		}
-
-		pool := &api.NodePool{ID: id}
-		if err := json.Unmarshal([]byte(metadataJSON), &pool.Metadata); err != nil {
-			return state, err
-		}
-		if err := json.Unmarshal([]byte(specJSON), &pool.Spec); err != nil {
-			return state, err
-		}
-		if err := json.Unmarshal([]byte(statusJSON), &pool.Status); err != nil {
+		pool, err := scanNodePool(poolRows)
+		if err != nil {
 			return state, err
 		}
-
-		state.NodePools[id] = pool
+		state.NodePools[pool.ID] = pool
 	}
 
 	return state, nil
 }
 
-// SaveState persists state
+// SaveState persists state. Resources are inserted or updated in place, and
+// stored resources absent from state are deleted.
 func (s *SQLiteStateManager) SaveState(ctx context.Context, state engine.State) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -159,59 +305,83 @@ func (s *SQLiteStateManager) SaveState(ctx context.Context, state engine.State)
 	defer tx.Rollback()
 
 	// Save clusters
+	clusterIDs := make([]string, 0, len(state.Clusters))
 	for _, cluster := range state.Clusters {
 		metadataJSON, _ := json.Marshal(cluster.Metadata)
 		specJSON, _ := json.Marshal(cluster.Spec)
 		statusJSON, _ := json.Marshal(cluster.Status)
 
 		_, err := tx.ExecContext(ctx,
-			`INSERT OR REPLACE INTO clusters (id, metadata, spec, status, updated_at)
-			 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+			`INSERT INTO clusters (id, metadata, spec, status, updated_at)
+			 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+			 ON CONFLICT (id) DO UPDATE SET metadata = excluded.metadata, spec = excluded.spec,
+			 status = excluded.status, updated_at = excluded.updated_at`,
 			cluster.ID, metadataJSON, specJSON, statusJSON,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to save cluster: %w", err)
 		}
+		clusterIDs = append(clusterIDs, cluster.ID)
 	}
 
-	// Save node pools
+	// Save node pools, linked to their cluster
+	idsByName := make(map[string]string, len(state.Clusters))
+	for id, cluster := range state.Clusters {
+		idsByName[cluster.Metadata.Name] = id
+	}
+	poolIDs := make([]string, 0, len(state.NodePools))
 	for _, pool := range state.NodePools {
 		metadataJSON, _ := json.Marshal(pool.Metadata)
 		specJSON, _ := json.Marshal(pool.Spec)
 		statusJSON, _ := json.Marshal(pool.Status)
 
 		_, err := tx.ExecContext(ctx,
-			`INSERT OR REPLACE INTO node_pools (id, metadata, spec, status, updated_at)
-			 VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`,
-			pool.ID, metadataJSON, specJSON, statusJSON,
+			`INSERT INTO node_pools (id, cluster_id, metadata, spec, status, updated_at)
+			 VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			 ON CONFLICT (id) DO UPDATE SET cluster_id = excluded.cluster_id, metadata = excluded.metadata,
+			 spec = excluded.spec, status = excluded.status, updated_at = excluded.updated_at`,
+			pool.ID, poolClusterID(pool, idsByName), metadataJSON, specJSON, statusJSON,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to save node pool: %w", err)
 		}
+		poolIDs = append(poolIDs, pool.ID)
 	}
 
-	return tx.Commit()
-}
+	if err := deleteRemoved(ctx, tx, "node_pools", poolIDs); err != nil {
+		return err
+	}
+	if err := deleteRemoved(ctx, tx, "clusters", clusterIDs); err != nil {
+		return err
+	}
 
-// BeginTransaction starts a state transaction
-func (s *SQLiteStateManager) BeginTransaction() engine.Transaction {
-	return &sqliteTransaction{db: s.db}
+	return tx.Commit()
 }
 
-// Lock acquires a lock on state
-func (s *SQLiteStateManager) Lock(ctx context.Context) error {
-	// SQLite handles locking automatically
+// deleteRemoved deletes the rows of table whose ID is not in keep
+func deleteRemoved(ctx context.Context, tx *sql.Tx, table string, keep []string) error {
+	query := "DELETE FROM " + table
+	args := make([]interface{}, len(keep))
+	if len(keep) > 0 {
+		query += " WHERE id NOT IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(keep)), ", ") + ")"
+		for i, id := range keep {
+			args[i] = id
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete removed %s: %w", table, err)
+	}
 	return nil
 }
 
-// Unlock releases the state lock
-func (s *SQLiteStateManager) Unlock(ctx context.Context) error {
-	// SQLite handles locking automatically
-	return nil
+// BeginTransaction starts a state transaction
+func (s *SQLiteStateManager) BeginTransaction() engine.Transaction {
+	return &sqliteTransaction{db: s.db}
 }
 
 // Close closes the database connection
 func (s *SQLiteStateManager) Close() error {
+	s.stopHeartbeat()
 	return s.db.Close()
 }
 
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/engine"
+)
+
+func TestSQLiteStateManager_SaveStateReplaces(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	initial := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}},
+			"cluster-2": {ID: "cluster-2", Metadata: api.ResourceMetadata{Name: "staging"}},
+		},
+	}
+	if err := sm.SaveState(ctx, initial); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	delete(initial.Clusters, "cluster-2")
+	if err := sm.SaveState(ctx, initial); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	got, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if len(got.Clusters) != 1 || got.Clusters["cluster-1"] == nil {
+		t.Errorf("GetState() clusters = %v, want only cluster-1", got.Clusters)
+	}
+}
+
+func TestSQLiteStateManager_SaveStateUpserts(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	clusterStatus := api.ResourceStatus{Properties: map[string]string{api.PropertyClusterID: "cluster-1"}}
+	saved := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod"}},
+		},
+		NodePools: map[string]*api.NodePool{
+			"pool-1": {ID: "pool-1", Metadata: api.ResourceMetadata{Name: "workers"}, Status: clusterStatus},
+			"pool-2": {ID: "pool-2", Metadata: api.ResourceMetadata{Name: "batch"}, Status: clusterStatus},
+		},
+	}
+	if err := sm.SaveState(ctx, saved); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	if _, err := sm.db.ExecContext(ctx, `UPDATE clusters SET created_at = '2020-01-01 00:00:00'`); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	saved.Clusters["cluster-1"].Metadata.Labels = map[string]string{"env": "prod"}
+	delete(saved.NodePools, "pool-2")
+	if err := sm.SaveState(ctx, saved); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	var createdAt string
+	if err := sm.db.QueryRowContext(ctx, `SELECT created_at FROM clusters WHERE id = 'cluster-1'`).Scan(&createdAt); err != nil {
+		t.Fatalf("QueryRowContext() error = %v", err)
+	}
+	if !strings.HasPrefix(createdAt, "2020-01-01") {
+		t.Errorf("created_at = %q, want the row updated in place", createdAt)
+	}
+
+	got, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	if got.Clusters["cluster-1"].Metadata.Labels["env"] != "prod" {
+		t.Errorf("GetState() cluster labels = %v, want the updated labels", got.Clusters["cluster-1"].Metadata.Labels)
+	}
+	if len(got.NodePools) != 1 || got.NodePools["pool-1"] == nil {
+		t.Errorf("GetState() node pools = %v, want only pool-1", got.NodePools)
+	}
+}
+
+func TestSQLiteStateManager_Timestamps(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	updated := created.Add(90 * time.Minute)
+	saved := engine.State{
+		Clusters: map[string]*api.Cluster{
+			"cluster-1": {ID: "cluster-1", Metadata: api.ResourceMetadata{Name: "prod", CreatedAt: created, UpdatedAt: updated}},
+		},
+	}
+	if err := sm.SaveState(ctx, saved); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+
+	got, err := sm.GetState(ctx)
+	if err != nil {
+		t.Fatalf("GetState() error = %v", err)
+	}
+	metadata := got.Clusters["cluster-1"].Metadata
+	if !metadata.CreatedAt.Equal(created) || !metadata.UpdatedAt.Equal(updated) {
+		t.Errorf("timestamps = %v, %v, want %v, %v", metadata.CreatedAt, metadata.UpdatedAt, created, updated)
+	}
+}
+
+func TestSQLiteStateManager_CheckSchema(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestManager(t, filepath.Join(t.TempDir(), "state.db"))
+
+	if err := sm.CheckSchema(ctx); err != nil {
+		t.Fatalf("CheckSchema() error = %v, want a freshly created schema to be current", err)
+	}
+
+	if _, err := sm.db.Exec("ALTER TABLE events DROP COLUMN correlation_id"); err != nil {
+		t.Fatalf("dropping column: %v", err)
+	}
+	if err := sm.CheckSchema(ctx); err == nil {
+		t.Error("CheckSchema() = nil, want an error for the missing correlation_id column")
+	}
+}
+
+func TestOpenSQLiteStateReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+	sm := newTestManager(t, path)
+	if _, err := sm.db.Exec("ALTER TABLE events DROP COLUMN plan_action"); err != nil {
+		t.Fatalf("dropping column: %v", err)
+	}
+
+	ro, err := OpenSQLiteStateReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenSQLiteStateReadOnly() error = %v", err)
+	}
+	defer ro.Close()
+
+	if err := ro.CheckSchema(ctx); err == nil {
+		t.Error("CheckSchema() = nil, want the read-only open to leave the outdated schema unmigrated")
+	}
+	if err := ro.SaveState(ctx, engine.State{}); err == nil {
+		t.Error("SaveState() = nil, want a read-only database to refuse writes")
+	}
+}
@@ -0,0 +1,154 @@
+package validation
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// knownFeatureGates are kube-apiserver feature gates of recent Kubernetes
+// releases. kube-apiserver refuses to start with a gate it does not know, so
+// gates close to one of these are reported as misspellings. The list is not
+// exhaustive; other gates only draw a warning.
+var knownFeatureGates = []string{
+	"APIResponseCompression",
+	"APIServerIdentity",
+	"APIServerTracing",
+	"AdmissionWebhookMatchConditions",
+	"AggregatedDiscoveryEndpoint",
+	"AllAlpha",
+	"AllBeta",
+	"AnonymousAuthConfigurableEndpoints",
+	"AnyVolumeDataSource",
+	"AuthorizeNodeWithSelectors",
+	"AuthorizeWithSelectors",
+	"CRDValidationRatcheting",
+	"CloudControllerManagerWebhook",
+	"ComponentSLIs",
+	"ConcurrentWatchObjectDecode",
+	"ConsistentListFromCache",
+	"CoordinatedLeaderElection",
+	"CustomResourceFieldSelectors",
+	"DynamicResourceAllocation",
+	"InPlacePodVerticalScaling",
+	"InformerResourceVersion",
+	"JobPodReplacementPolicy",
+	"KMSv1",
+	"KMSv2",
+	"KMSv2KDF",
+	"MutatingAdmissionPolicy",
+	"OpenAPIEnums",
+	"PodDisruptionConditions",
+	"ResilientWatchCacheInitialization",
+	"RetryGenerateName",
+	"SeparateCacheWatchRPC",
+	"ServiceAccountTokenJTI",
+	"ServiceAccountTokenNodeBinding",
+	"ServiceAccountTokenNodeBindingValidation",
+	"ServiceAccountTokenPodNodeInfo",
+	"SidecarContainers",
+	"StorageVersionAPI",
+	"StorageVersionHash",
+	"StrictCostEnforcementForVAP",
+	"StrictCostEnforcementForWebhooks",
+	"StructuredAuthenticationConfiguration",
+	"StructuredAuthorizationConfiguration",
+	"UnauthenticatedHTTP2DOSMitigation",
+	"UserNamespacesSupport",
+	"ValidatingAdmissionPolicy",
+	"VolumeAttributesClass",
+	"WatchCacheInitializationPostStartHook",
+	"WatchFromStorageWithoutResourceVersion",
+	"WatchList",
+}
+
+// apiServerArgPattern matches kube-apiserver flag names, such as
+// audit-log-maxage
+var apiServerArgPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// validateAPIServer checks feature gates and API server arguments: they are
+// only applied when provctl bootstraps the control plane itself, and gate
+// names are checked against knownFeatureGates
+func (v *Validator) validateAPIServer(spec api.ClusterSpec, result *Result) {
+	cp := spec.ControlPlane
+	if len(cp.FeatureGates) == 0 && len(cp.APIServerArgs) == 0 {
+		return
+	}
+
+	if cp.Type == api.ControlPlaneManaged {
+		result.addError("controlPlane", "feature gates and API server arguments need a self-managed control plane; managed control planes do not expose kube-apiserver flags")
+	}
+
+	for _, gate := range sortedKeys(cp.FeatureGates) {
+		field := "controlPlane.featureGates." + gate
+		if slices.Contains(knownFeatureGates, gate) {
+			continue
+		}
+		if suggestion := closestFeatureGate(gate); suggestion != "" {
+			result.addError(field, "unknown feature gate; did you mean %s?", suggestion)
+		} else {
+			result.addWarning(field, "not a known feature gate; kube-apiserver fails to start if Kubernetes %s does not have it", cp.Version)
+		}
+	}
+
+	for _, name := range sortedKeys(cp.APIServerArgs) {
+		field := "controlPlane.apiServerArgs." + name
+		switch {
+		case name == "feature-gates":
+			result.addError(field, "set feature gates in feature_gates instead")
+		case strings.HasPrefix(name, "-"):
+			result.addError(field, "flag names are given without leading dashes, as %s", strings.TrimLeft(name, "-"))
+		case !apiServerArgPattern.MatchString(name):
+			result.addError(field, "not a valid kube-apiserver flag name")
+		}
+	}
+}
+
+// closestFeatureGate returns the known feature gate a gate is probably a
+// misspelling of, differing only in case or by at most two edits, or ""
+func closestFeatureGate(gate string) string {
+	best, bestDistance := "", 3
+	for _, known := range knownFeatureGates {
+		if strings.EqualFold(known, gate) {
+			return known
+		}
+		if d := editDistance(strings.ToLower(known), strings.ToLower(gate)); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+// sortedKeys returns the keys of a map in order, for stable findings
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestValidator_APIServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		controlPlane api.ControlPlaneSpec
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{name: "none", controlPlane: api.ControlPlaneSpec{Type: api.ControlPlaneManaged}},
+		{
+			name: "known gates and valid arguments",
+			controlPlane: api.ControlPlaneSpec{
+				Type:          api.ControlPlaneSelfManaged,
+				FeatureGates:  map[string]bool{"WatchList": true, "KMSv1": false},
+				APIServerArgs: map[string]string{"audit-log-maxage": "30"},
+			},
+		},
+		{
+			name: "managed control plane",
+			controlPlane: api.ControlPlaneSpec{
+				Type:         api.ControlPlaneManaged,
+				FeatureGates: map[string]bool{"WatchList": true},
+			},
+			wantErrors: []string{"controlPlane"},
+		},
+		{
+			name: "misspelled gates",
+			controlPlane: api.ControlPlaneSpec{
+				Type:         api.ControlPlaneSelfManaged,
+				FeatureGates: map[string]bool{"watchlist": true, "SidecarContainer": true},
+			},
+			wantErrors: []string{"controlPlane.featureGates.SidecarContainer", "controlPlane.featureGates.watchlist"},
+		},
+		{
+			name: "unknown gate",
+			controlPlane: api.ControlPlaneSpec{
+				Type:         api.ControlPlaneSelfManaged,
+				Version:      "1.30",
+				FeatureGates: map[string]bool{"SomeFutureFeature": true},
+			},
+			wantWarnings: []string{"controlPlane.featureGates.SomeFutureFeature"},
+		},
+		{
+			name: "invalid arguments",
+			controlPlane: api.ControlPlaneSpec{
+				Type: api.ControlPlaneSelfManaged,
+				APIServerArgs: map[string]string{
+					"--audit-log-maxage": "30",
+					"feature-gates":      "WatchList=true",
+					"Max Requests":       "800",
+				},
+			},
+			wantErrors: []string{
+				"controlPlane.apiServerArgs.--audit-log-maxage",
+				"controlPlane.apiServerArgs.Max Requests",
+				"controlPlane.apiServerArgs.feature-gates",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator().Validate(api.ClusterSpec{Provider: "aws", ControlPlane: tt.controlPlane})
+			if got := issueFields(result.Errors); strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+			if got := issueFields(result.Warnings); strings.Join(got, ",") != strings.Join(tt.wantWarnings, ",") {
+				t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestClosestFeatureGate(t *testing.T) {
+	tests := []struct {
+		gate string
+		want string
+	}{
+		{"watchlist", "WatchList"},
+		{"SidecarContainer", "SidecarContainers"},
+		{"KMSv3", "KMSv1"},
+		{"SomeFutureFeature", ""},
+	}
+
+	for _, tt := range tests {
+		if got := closestFeatureGate(tt.gate); got != tt.want {
+			t.Errorf("closestFeatureGate(%q) = %q, want %q", tt.gate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// Guardrails are organization-wide limits that catch mistakes such as a
+// fat-fingered max_size. Node limits count each pool at its maximum size, the
+// most it can scale to. A zero limit is not enforced.
+type Guardrails struct {
+	MaxTotalNodes  int     `hcl:"max_total_nodes,optional"`  // Across all of a cluster's pools
+	MaxPoolNodes   int     `hcl:"max_pool_nodes,optional"`   // For any single pool
+	MaxMonthlyCost float64 `hcl:"max_monthly_cost,optional"` // Estimated cost of a cluster
+}
+
+// Enabled reports whether any limit is set
+func (g Guardrails) Enabled() bool {
+	return g.MaxTotalNodes > 0 || g.MaxPoolNodes > 0 || g.MaxMonthlyCost > 0
+}
+
+// Check returns the guardrail violations of a cluster as errors. monthlyCost
+// is the cluster's estimated monthly cost and is ignored unless MaxMonthlyCost
+// is set.
+func (g Guardrails) Check(spec api.ClusterSpec, monthlyCost float64) *Result {
+	result := &Result{}
+
+	total := 0
+	for _, pool := range spec.WorkerPools {
+		total += pool.MaxSize
+		if g.MaxPoolNodes > 0 && pool.MaxSize > g.MaxPoolNodes {
+			result.addError("workerPools."+pool.Name+".maxSize", "pool can scale to %d nodes, exceeds guardrail of %d nodes per pool",
+				pool.MaxSize, g.MaxPoolNodes)
+		}
+	}
+
+	if g.MaxTotalNodes > 0 && total > g.MaxTotalNodes {
+		result.addError("workerPools", "pools can scale to %d nodes in total, exceeds guardrail of %d nodes per cluster",
+			total, g.MaxTotalNodes)
+	}
+
+	if g.MaxMonthlyCost > 0 && monthlyCost > g.MaxMonthlyCost {
+		result.addError("cost", "estimated cost of $%.2f/month exceeds guardrail of $%.2f/month",
+			monthlyCost, g.MaxMonthlyCost)
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestGuardrails_Check(t *testing.T) {
+	spec := api.ClusterSpec{
+		WorkerPools: []api.WorkerPoolSpec{
+			{Name: "general", MinSize: 1, MaxSize: 10},
+			{Name: "batch", MinSize: 0, MaxSize: 30},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		guardrails Guardrails
+		cost       float64
+		wantFields []string
+	}{
+		{
+			name:       "no limits",
+			guardrails: Guardrails{},
+			cost:       1e6,
+		},
+		{
+			name:       "within every limit",
+			guardrails: Guardrails{MaxTotalNodes: 40, MaxPoolNodes: 30, MaxMonthlyCost: 5000},
+			cost:       5000,
+		},
+		{
+			name:       "pool over per-pool limit",
+			guardrails: Guardrails{MaxPoolNodes: 20},
+			wantFields: []string{"workerPools.batch.maxSize"},
+		},
+		{
+			name:       "pools summed over total limit",
+			guardrails: Guardrails{MaxTotalNodes: 35},
+			wantFields: []string{"workerPools"},
+		},
+		{
+			name:       "cost over limit",
+			guardrails: Guardrails{MaxMonthlyCost: 1000},
+			cost:       1000.01,
+			wantFields: []string{"cost"},
+		},
+		{
+			name:       "every limit exceeded",
+			guardrails: Guardrails{MaxTotalNodes: 20, MaxPoolNodes: 5, MaxMonthlyCost: 100},
+			cost:       200,
+			wantFields: []string{"workerPools.general.maxSize", "workerPools.batch.maxSize", "workerPools", "cost"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.guardrails.Check(spec, tt.cost)
+			if len(result.Warnings) != 0 {
+				t.Errorf("Check() warnings = %v, want none", result.Warnings)
+			}
+			if len(result.Errors) != len(tt.wantFields) {
+				t.Fatalf("Check() errors = %v, want fields %v", result.Errors, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if result.Errors[i].Field != field {
+					t.Errorf("error %d field = %s, want %s", i, result.Errors[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestGuardrails_Enabled(t *testing.T) {
+	if (Guardrails{}).Enabled() {
+		t.Error("zero Guardrails reported as enabled")
+	}
+	if !(Guardrails{MaxMonthlyCost: 1}).Enabled() {
+		t.Error("Guardrails with a cost limit reported as disabled")
+	}
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// DefaultClusterNamePattern is the cluster name rule of providers without an
+// override. EKS accepts up to 100 letters, digits, hyphens and underscores
+// starting with a letter or digit, and AKS up to 63 that also end with one.
+// The default is stricter than both so a name works on either provider:
+// lowercase, starting with a letter and short enough to leave room for the
+// suffixes providers add to node group and resource group names.
+const DefaultClusterNamePattern = `^[a-z][a-z0-9-]{2,38}$`
+
+var defaultClusterName = regexp.MustCompile(DefaultClusterNamePattern)
+
+// NamingRules override the cluster name rule per provider
+type NamingRules struct {
+	ClusterName map[string]string `hcl:"cluster_name,optional"` // Pattern by provider name
+}
+
+// compile compiles the configured patterns, reporting the first invalid one
+func (r NamingRules) compile() (map[string]*regexp.Regexp, error) {
+	providers := make([]string, 0, len(r.ClusterName))
+	for provider := range r.ClusterName {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	patterns := make(map[string]*regexp.Regexp, len(providers))
+	for _, provider := range providers {
+		pattern, err := regexp.Compile(r.ClusterName[provider])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster name pattern for %s: %w", provider, err)
+		}
+		patterns[provider] = pattern
+	}
+	return patterns, nil
+}
+
+// Check reports the first pattern that is not a valid regular expression
+func (r NamingRules) Check() error {
+	_, err := r.compile()
+	return err
+}
+
+// SetNamingRules replaces the default cluster name rule of the providers the
+// rules name
+func (v *Validator) SetNamingRules(rules NamingRules) error {
+	patterns, err := rules.compile()
+	if err != nil {
+		return err
+	}
+	v.namePatterns = patterns
+	return nil
+}
+
+// clusterNamePattern returns the cluster name rule of a provider
+func (v *Validator) clusterNamePattern(provider string) *regexp.Regexp {
+	if pattern, ok := v.namePatterns[provider]; ok {
+		return pattern
+	}
+	return defaultClusterName
+}
+
+// validateName checks the cluster name, which providers read from Config,
+// against the provider's naming rule. Specs without a name are not checked.
+func (v *Validator) validateName(spec api.ClusterSpec, result *Result) {
+	name, ok := spec.Config["name"].(string)
+	if !ok {
+		return
+	}
+	if pattern := v.clusterNamePattern(spec.Provider); !pattern.MatchString(name) {
+		result.addError("name", "cluster name %q does not match the naming rule %s", name, pattern)
+	}
+}
@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestValidator_ClusterName(t *testing.T) {
+	validator := NewValidator()
+	if err := validator.SetNamingRules(NamingRules{ClusterName: map[string]string{"azure": `^aks-[a-z0-9]{3,20}$`}}); err != nil {
+		t.Fatalf("SetNamingRules() error = %v", err)
+	}
+
+	tests := []struct {
+		provider string
+		name     string
+		valid    bool
+	}{
+		{provider: "aws", name: "prod-eks-1", valid: true},
+		{provider: "aws", name: "abc", valid: true},
+		{provider: "aws", name: "ab"},
+		{provider: "aws", name: "Prod"},
+		{provider: "aws", name: "1prod"},
+		{provider: "aws", name: "prod_eks"},
+		{provider: "aws", name: "a234567890123456789012345678901234567890"},
+		{provider: "gcp", name: "prod", valid: true},
+		{provider: "gcp", name: "-prod"},
+		{provider: "azure", name: "aks-prod1", valid: true},
+		{provider: "azure", name: "prod-aks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{Provider: tt.provider}.WithName(tt.name)
+			var want []string
+			if !tt.valid {
+				want = []string{"name"}
+			}
+			if got := issueFields(validator.Validate(spec).Errors); !reflect.DeepEqual(got, want) {
+				t.Errorf("Validate() error fields = %v, want %v", got, want)
+			}
+		})
+	}
+
+	// Specs without a name are left to the caller
+	if result := validator.Validate(api.ClusterSpec{Provider: "aws"}); result.HasErrors() {
+		t.Errorf("Validate() errors = %v, want none without a name", result.Errors)
+	}
+}
+
+func TestNamingRules_Check(t *testing.T) {
+	if err := (NamingRules{}).Check(); err != nil {
+		t.Errorf("Check() error = %v for no rules", err)
+	}
+	rules := NamingRules{ClusterName: map[string]string{"aws": `^[a-z]+$`, "azure": `^[a-z(+$`}}
+	if err := rules.Check(); err == nil {
+		t.Error("Check() succeeded, want an error for the invalid azure pattern")
+	}
+	if err := NewValidator().SetNamingRules(rules); err == nil {
+		t.Error("SetNamingRules() succeeded, want an error for the invalid azure pattern")
+	}
+}
@@ -0,0 +1,51 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+// secretNamePattern matches names valid both as Key Vault secret names and
+// as file names on the nodes
+var secretNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,126}$`)
+
+// secretControlPlanes is the control plane type each provider injects
+// secrets into when it creates it: AWS through the user data of EC2 control
+// plane instances, Azure through a Key Vault mounted into AKS. Other control
+// planes would silently go without the secrets.
+var secretControlPlanes = map[string]api.ControlPlaneType{
+	"aws":   api.ControlPlaneSelfManaged,
+	"azure": api.ControlPlaneManaged,
+}
+
+// validateSecrets checks that each secret reference has a usable name and
+// exactly one source, and that the cluster's provider can inject secrets
+// into its control plane. Whether the source holds a value is only known at
+// apply time, where it is resolved.
+func (v *Validator) validateSecrets(spec api.ClusterSpec, result *Result) {
+	if want, ok := secretControlPlanes[spec.Provider]; ok && len(spec.Secrets) > 0 && spec.ControlPlane.Type != want {
+		result.addError("secrets", "%s injects secrets only into %s control planes, not %q", spec.Provider, want, spec.ControlPlane.Type)
+	}
+
+	seen := make(map[string]bool, len(spec.Secrets))
+	for _, ref := range spec.Secrets {
+		field := "secrets." + ref.Name
+		if !secretNamePattern.MatchString(ref.Name) {
+			result.addError(field, "secret names start with a letter and contain only letters, digits and dashes, up to 127 characters")
+		}
+		if seen[ref.Name] {
+			result.addError(field, "duplicate secret")
+		}
+		seen[ref.Name] = true
+
+		switch {
+		case ref.Env == "" && ref.File == "":
+			result.addError(field, "set env or file to read the value from")
+		case ref.Env != "" && ref.File != "":
+			result.addError(field, "env and file are mutually exclusive")
+		case ref.Key != "" && ref.File == "":
+			result.addError(field+".key", "key selects an entry of a secrets file and needs file")
+		}
+	}
+}
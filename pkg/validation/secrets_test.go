@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestValidator_Secrets(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		cpType     api.ControlPlaneType
+		secrets    []api.SecretRef
+		wantErrors []string
+	}{
+		{
+			name:     "valid",
+			provider: "aws",
+			cpType:   api.ControlPlaneSelfManaged,
+			secrets: []api.SecretRef{
+				{Name: "registry-token", Env: "REGISTRY_TOKEN"},
+				{Name: "license", File: "secrets.json", Key: "license"},
+			},
+		},
+		{
+			name:     "invalid name",
+			provider: "aws",
+			cpType:   api.ControlPlaneSelfManaged,
+			secrets: []api.SecretRef{
+				{Name: "registry_token", Env: "REGISTRY_TOKEN"},
+			},
+			wantErrors: []string{"secrets.registry_token"},
+		},
+		{
+			name:     "duplicate",
+			provider: "aws",
+			cpType:   api.ControlPlaneSelfManaged,
+			secrets: []api.SecretRef{
+				{Name: "token", Env: "A"},
+				{Name: "token", Env: "B"},
+			},
+			wantErrors: []string{"secrets.token"},
+		},
+		{
+			name:     "sources",
+			provider: "aws",
+			cpType:   api.ControlPlaneSelfManaged,
+			secrets: []api.SecretRef{
+				{Name: "both", Env: "A", File: "a"},
+				{Name: "key", Env: "A", Key: "k"},
+				{Name: "none"},
+			},
+			wantErrors: []string{"secrets.both", "secrets.key.key", "secrets.none"},
+		},
+		{
+			name:     "AKS",
+			provider: "azure",
+			cpType:   api.ControlPlaneManaged,
+			secrets:  []api.SecretRef{{Name: "token", Env: "A"}},
+		},
+		{
+			name:       "EKS",
+			provider:   "aws",
+			cpType:     api.ControlPlaneManaged,
+			secrets:    []api.SecretRef{{Name: "token", Env: "A"}},
+			wantErrors: []string{"secrets"},
+		},
+		{
+			name:       "Azure VMs",
+			provider:   "azure",
+			cpType:     api.ControlPlaneSelfManaged,
+			secrets:    []api.SecretRef{{Name: "token", Env: "A"}},
+			wantErrors: []string{"secrets"},
+		},
+		{
+			name:       "no control plane type",
+			provider:   "aws",
+			secrets:    []api.SecretRef{{Name: "token", Env: "A"}},
+			wantErrors: []string{"secrets"},
+		},
+		{
+			name:     "EKS without secrets",
+			provider: "aws",
+			cpType:   api.ControlPlaneManaged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{Provider: tt.provider, ControlPlane: api.ControlPlaneSpec{Type: tt.cpType}, Secrets: tt.secrets}
+			result := NewValidator().Validate(spec)
+			if got := issueFields(result.Errors); strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
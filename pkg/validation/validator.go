@@ -0,0 +1,515 @@
+// Package validation provides pre-flight validation of cluster specifications
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+	"github.com/vjranagit/cluster-api/pkg/cost"
+)
+
+// userDataLimits holds the maximum raw user-data size accepted by each provider
+var userDataLimits = map[string]int{
+	"aws":   16 * 1024, // EC2 launch template user data
+	"azure": 64 * 1024, // VMSS custom data
+}
+
+// taintEffects are the effects Kubernetes accepts on a taint
+var taintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// maxSizeRatio is how far apart, in vCPU or memory, the instance types of a
+// diversified pool may be before they are considered not comparable
+const maxSizeRatio = 2.0
+
+// Validator checks cluster specifications before they are planned or applied
+type Validator struct {
+	strict       bool
+	pricing      *cost.Estimator           // Built-in instance sizes, which are looked up without blocking
+	requiredTags []string                  // Tag keys every cluster must carry
+	namePatterns map[string]*regexp.Regexp // Cluster name rule overrides by provider
+}
+
+// NewValidator creates a new validator
+func NewValidator() *Validator {
+	return &Validator{pricing: cost.NewEstimator()}
+}
+
+// SetStrict makes the validator report warnings as errors
+func (v *Validator) SetStrict(strict bool) {
+	v.strict = strict
+}
+
+// SetRequiredTags makes the validator report clusters missing any of the
+// given tag keys, or carrying them with an empty value
+func (v *Validator) SetRequiredTags(keys []string) {
+	v.requiredTags = keys
+}
+
+// Result contains the findings of a validation run
+type Result struct {
+	Errors   []Issue
+	Warnings []Issue
+}
+
+// Issue describes a single validation finding
+type Issue struct {
+	Field   string
+	Message string
+}
+
+func (i Issue) String() string {
+	return i.Field + ": " + i.Message
+}
+
+// HasErrors reports whether the result contains blocking errors
+func (r *Result) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// Err returns the blocking errors joined into a single error, or nil
+func (r *Result) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+
+	errs := make([]error, 0, len(r.Errors))
+	for _, issue := range r.Errors {
+		errs = append(errs, errors.New(issue.String()))
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Result) addError(field, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Result) addWarning(field, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks a cluster specification and returns all findings
+func (v *Validator) Validate(spec api.ClusterSpec) *Result {
+	result := &Result{}
+
+	v.validateName(spec, result)
+	v.validateNetwork(spec.Provider, spec.Network, result)
+	v.validateAPIServerAccess(spec.Provider, spec.Network, result)
+	v.validateLogging(spec, result)
+	v.validateAPIServer(spec, result)
+	v.validateTags(spec, result)
+	v.validateSecrets(spec, result)
+	if spec.SpotDefaults != nil {
+		v.validateSpotStrategy(spec.Provider, "spotDefaults.allocationStrategy", spec.SpotDefaults.AllocationStrategy, result)
+	}
+	for _, pool := range spec.WorkerPools {
+		v.validateSpot(spec, pool, result)
+		v.validateBootstrap(spec, pool, result)
+		v.validatePlacement(spec, pool, result)
+		v.validateWarmPool(spec, pool, result)
+		v.validateInstanceTypes(spec, pool, result)
+		v.validateInstanceWeights(spec, pool, result)
+		v.validateMaxPods(spec, pool, result)
+		v.validateTaints(pool, result)
+		v.validateNodeRegistration(spec, pool, result)
+	}
+	v.validateSchedulablePool(spec, result)
+	v.validatePriorities(spec, result)
+
+	if v.strict {
+		result.Errors = append(result.Errors, result.Warnings...)
+		result.Warnings = nil
+	}
+
+	return result
+}
+
+// validateTags checks that the cluster carries every required tag
+func (v *Validator) validateTags(spec api.ClusterSpec, result *Result) {
+	for _, key := range v.requiredTags {
+		if spec.Tags[key] == "" {
+			result.addError("tags."+key, "required tag %q is missing; set it on the cluster or in default_tags", key)
+		}
+	}
+}
+
+// validateNetwork checks that a cluster either creates its network from a
+// CIDR or is placed in existing subnets of an existing VPC or VNet
+func (v *Validator) validateNetwork(provider string, network api.NetworkSpec, result *Result) {
+	if network.ExistingNetworkID() == "" {
+		if len(network.ExistingSubnetIDs) > 0 {
+			result.addError("network.existingSubnetIds", "existing subnets require an existing VPC or VNet")
+		}
+		return
+	}
+
+	if network.VPCCIDR != "" {
+		result.addError("network.vpcCidr", "set either vpcCidr or an existing network, not both")
+	}
+	switch {
+	case network.ExistingVPCID != "" && network.ExistingVNetID != "":
+		result.addError("network", "existingVpcId and existingVnetId are mutually exclusive")
+	case provider == "aws" && network.ExistingVNetID != "":
+		result.addError("network.existingVnetId", "AWS clusters reference an existing VPC with existingVpcId")
+	case provider == "azure" && network.ExistingVPCID != "":
+		result.addError("network.existingVpcId", "Azure clusters reference an existing VNet with existingVnetId")
+	}
+	if len(network.Subnets) > 0 {
+		result.addError("network.subnets", "subnets are only created in a new network; list existing ones in existingSubnetIds")
+	}
+	if len(network.ExistingSubnetIDs) == 0 {
+		result.addError("network.existingSubnetIds", "an existing network requires the subnets to place the cluster in")
+	}
+}
+
+// validateAPIServerAccess checks that the API endpoint is reachable at all and
+// that its authorized ranges are valid CIDRs
+func (v *Validator) validateAPIServerAccess(provider string, network api.NetworkSpec, result *Result) {
+	access := network.APIServerAccess
+	if access == nil {
+		return
+	}
+
+	field := "network.apiServerAccess"
+	if !access.PublicAccess && !access.PrivateAccess {
+		result.addError(field, "public access is disabled but private access is not enabled, so the API server would be unreachable")
+	}
+	if network.PrivateCluster && access.PublicAccess {
+		result.addError(field+".publicAccess", "public access conflicts with privateCluster")
+	}
+
+	for _, cidr := range access.AuthorizedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			result.addError(field+".authorizedCidrs", "%q is not a valid CIDR", cidr)
+		}
+	}
+	switch {
+	case len(access.AuthorizedCIDRs) == 0:
+	case !access.PublicAccess:
+		result.addWarning(field+".authorizedCidrs", "authorized CIDRs only restrict the public endpoint, which is disabled")
+	case access.PrivateAccess && provider == "azure":
+		result.addWarning(field+".authorizedCidrs", "AKS ignores authorized IP ranges on private clusters")
+	}
+}
+
+// validateLogging checks control-plane log types against the provider's
+// capabilities. AKS diagnostic settings cannot be created without a Log
+// Analytics workspace to send logs to.
+func (v *Validator) validateLogging(spec api.ClusterSpec, result *Result) {
+	obs := spec.Observability
+	if !obs.LogsEnabled() {
+		return
+	}
+
+	field := "observability.logging"
+	if capabilities, ok := api.ProviderCapabilities(spec.Provider); ok {
+		for _, logType := range obs.LogTypes() {
+			if !slices.Contains(capabilities.LogTypes, logType) {
+				result.addError(field+".types", "%q is not a %s control-plane log type, expected one of %s",
+					logType, spec.Provider, strings.Join(capabilities.LogTypes, ", "))
+			}
+		}
+	}
+
+	if spec.Provider == "azure" && obs.LogDestination() == "" {
+		result.addWarning(field+".destination", "no Log Analytics workspace is set, so AKS control-plane logs are not collected")
+	}
+}
+
+// validateSpot checks a pool's spot allocation strategy. A strategy
+// inherited from SpotDefaults has been checked there already.
+func (v *Validator) validateSpot(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if pool.Spot == nil {
+		return
+	}
+	if pool.Spot.FallbackToOnDemand {
+		field := "workerPools." + pool.Name + ".spot.fallbackToOnDemand"
+		switch {
+		case !pool.Spot.Enabled:
+			result.addWarning(field, "has no effect unless spot is enabled")
+		case pool.MinSize == 0:
+			result.addWarning(field, "has no effect with a minimum size of 0: the minimum is what runs on on-demand capacity")
+		}
+	}
+	strategy := pool.Spot.AllocationStrategy
+	if spec.SpotDefaults != nil && strategy == spec.SpotDefaults.AllocationStrategy {
+		return
+	}
+	v.validateSpotStrategy(spec.Provider, "workerPools."+pool.Name+".spot.allocationStrategy", strategy, result)
+}
+
+// validateSpotStrategy checks a spot allocation strategy against the known
+// strategies and those the provider's scale groups accept
+func (v *Validator) validateSpotStrategy(provider, field, strategy string, result *Result) {
+	if strategy == "" {
+		return
+	}
+	if !slices.Contains(api.SpotAllocationStrategies, strategy) {
+		result.addError(field, "unknown spot allocation strategy %q, expected one of %s",
+			strategy, strings.Join(api.SpotAllocationStrategies, ", "))
+		return
+	}
+	if capabilities, ok := api.ProviderCapabilities(provider); ok && !slices.Contains(capabilities.SpotAllocationStrategies, strategy) {
+		result.addError(field, "%s does not support the %q spot allocation strategy, expected one of %s",
+			provider, strategy, strings.Join(capabilities.SpotAllocationStrategies, ", "))
+	}
+}
+
+// validateWarmPool rejects an enabled warm pool, and checks that its sizes
+// are consistent. No provider creates warm pools yet: AWS would need the
+// Auto Scaling API, which the AWS provider does not use, and Azure has none.
+func (v *Validator) validateWarmPool(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	warm := pool.WarmPool
+	if warm == nil || !warm.Enabled {
+		return
+	}
+
+	field := "workerPools." + pool.Name + ".warmPool"
+	result.addError(field, "warm pools are not supported on %s yet", spec.Provider)
+
+	if warm.MinSize < 0 {
+		result.addError(field+".minSize", "must not be negative")
+	}
+	if warm.MaxPreparedCapacity < 0 {
+		result.addError(field+".maxPreparedCapacity", "must not be negative")
+	}
+	if warm.MaxPreparedCapacity > 0 && warm.MaxPreparedCapacity < pool.MinSize {
+		result.addError(field+".maxPreparedCapacity", "%d is below the pool's minimum size of %d", warm.MaxPreparedCapacity, pool.MinSize)
+	}
+}
+
+// validateInstanceTypes checks that a pool diversified over several instance
+// types is a spot pool and that its types can run the same nodes: they must
+// share an architecture and should be of comparable size, as far as pricing
+// data knows them. Sizes of a weighted pool are compared per capacity unit.
+func (v *Validator) validateInstanceTypes(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if !pool.Diversified() {
+		return
+	}
+
+	field := "workerPools." + pool.Name + ".instanceTypes"
+	if pool.Spot == nil || !pool.Spot.Enabled {
+		result.addError(field, "instance type diversification is only supported on spot pools")
+	}
+
+	arch := cost.InstanceArch(spec.Provider, pool.InstanceType)
+	primary, known := v.pricing.InstanceType(context.Background(), spec.Provider, spec.Region, pool.InstanceType)
+	for _, instanceType := range pool.AllInstanceTypes()[1:] {
+		if other := cost.InstanceArch(spec.Provider, instanceType); other != arch {
+			result.addError(field, "%s is %s but %s is %s", instanceType, other, pool.InstanceType, arch)
+			continue
+		}
+
+		size, ok := v.pricing.InstanceType(context.Background(), spec.Provider, spec.Region, instanceType)
+		if !known || !ok {
+			continue
+		}
+		weight, primaryWeight := float64(pool.InstanceWeight(instanceType)), float64(pool.InstanceWeight(pool.InstanceType))
+		if mismatched(float64(size.VCPU)/weight, float64(primary.VCPU)/primaryWeight) || mismatched(size.MemoryGB/weight, primary.MemoryGB/primaryWeight) {
+			result.addWarning(field, "%s (%d vCPU, %.0f GB) is not comparable in size to %s (%d vCPU, %.0f GB)",
+				instanceType, size.VCPU, size.MemoryGB, pool.InstanceType, primary.VCPU, primary.MemoryGB)
+		}
+	}
+}
+
+// validateMaxPods checks a pool's pod limit against what its nodes can run.
+// On AWS the VPC CNI gives each pod an address of the instance's network
+// interfaces, so a limit above the instance types' capacity, as far as
+// pricing data knows it, leaves pods unschedulable. The limit reaches the
+// kubelet through a nodeadm NodeConfig, which only Amazon Linux 2023 EKS
+// AMIs read. Azure node pools are scale sets that do not apply one.
+func (v *Validator) validateMaxPods(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if pool.MaxPods == 0 {
+		return
+	}
+
+	field := "workerPools." + pool.Name + ".maxPods"
+	if pool.MaxPods < 0 {
+		result.addError(field, "must not be negative")
+		return
+	}
+
+	switch spec.Provider {
+	case "azure":
+		result.addError(field, "not supported on Azure: node pools are scale sets, which do not apply a pod limit")
+	case "aws":
+		if pool.ImageID != "" {
+			result.addWarning(field, "applied through a nodeadm NodeConfig, which only Amazon Linux 2023 EKS AMIs read; make sure image %s is one", pool.ImageID)
+		}
+		for _, instanceType := range pool.AllInstanceTypes() {
+			size, ok := v.pricing.InstanceType(context.Background(), spec.Provider, spec.Region, instanceType)
+			if !ok || size.MaxPods == 0 || pool.MaxPods <= size.MaxPods {
+				continue
+			}
+			result.addWarning(field, "%d exceeds the %d pods %s can address with the VPC CNI; enable prefix delegation or use a larger instance type",
+				pool.MaxPods, size.MaxPods, instanceType)
+		}
+	}
+}
+
+// validateTaints warns about taint effects Kubernetes does not know, which are
+// most likely typos: the node would reject the taint and register without it
+func (v *Validator) validateTaints(pool api.WorkerPoolSpec, result *Result) {
+	for i, taint := range pool.Taints {
+		if slices.Contains(taintEffects, taint.Effect) {
+			continue
+		}
+
+		field := fmt.Sprintf("workerPools.%s.taints[%d].effect", pool.Name, i)
+		suggestion := "must be one of " + strings.Join(taintEffects, ", ")
+		for _, effect := range taintEffects {
+			if strings.EqualFold(strings.ReplaceAll(taint.Effect, "_", ""), effect) {
+				suggestion = "did you mean " + effect + "?"
+			}
+		}
+		result.addWarning(field, "unknown taint effect %q on %s; %s", taint.Effect, taint.Key, suggestion)
+	}
+}
+
+// validateNodeRegistration warns when AWS nodes of a custom image may register
+// without the pool's labels and taints. They reach the kubelet through a
+// nodeadm NodeConfig, which only Amazon Linux 2023 EKS AMIs read.
+func (v *Validator) validateNodeRegistration(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if spec.Provider != "aws" || pool.ImageID == "" || (len(pool.Labels) == 0 && len(pool.Taints) == 0) {
+		return
+	}
+	result.addWarning("workerPools."+pool.Name+".imageId",
+		"labels and taints are applied through a nodeadm NodeConfig, which only Amazon Linux 2023 EKS AMIs read; make sure image %s is one", pool.ImageID)
+}
+
+// validateSchedulablePool warns when every worker pool repels pods without a
+// matching toleration. The spec cannot express tolerations, and system
+// workloads such as CoreDNS do not tolerate custom taints, so they would stay
+// pending.
+func (v *Validator) validateSchedulablePool(spec api.ClusterSpec, result *Result) {
+	if len(spec.WorkerPools) == 0 {
+		return
+	}
+	for _, pool := range spec.WorkerPools {
+		if !repelsPods(pool) {
+			return
+		}
+	}
+	result.addWarning("workerPools", "every worker pool is tainted NoSchedule or NoExecute, so pods without matching tolerations, including system workloads, cannot schedule; leave at least one pool untainted")
+}
+
+// validatePriorities warns when pools share a priority. Cluster-autoscaler's
+// priority expander picks among the node groups of one priority at random,
+// which is rarely what setting priorities intends. Pools left at 0 are
+// fallbacks and may tie.
+func (v *Validator) validatePriorities(spec api.ClusterSpec, result *Result) {
+	first := make(map[int]string)
+	for _, pool := range spec.WorkerPools {
+		if pool.Priority == 0 {
+			continue
+		}
+		if other, ok := first[pool.Priority]; ok {
+			result.addWarning("workerPools."+pool.Name+".priority",
+				"shares priority %d with pool %s; cluster-autoscaler chooses between them at random", pool.Priority, other)
+			continue
+		}
+		first[pool.Priority] = pool.Name
+	}
+}
+
+// repelsPods reports whether a pool carries a taint that keeps pods without a
+// matching toleration off its nodes
+func repelsPods(pool api.WorkerPoolSpec) bool {
+	for _, taint := range pool.Taints {
+		if taint.Effect == "NoSchedule" || taint.Effect == "NoExecute" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInstanceWeights checks that capacity weights are positive and name
+// instance types of the pool. Weights map onto the overrides of an Auto
+// Scaling mixed instances policy, which only spot pools on AWS get.
+func (v *Validator) validateInstanceWeights(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if !pool.Weighted() {
+		return
+	}
+
+	field := "workerPools." + pool.Name + ".instanceWeights"
+	switch {
+	case spec.Provider != "aws":
+		result.addError(field, "instance weights are not supported on %s", spec.Provider)
+	case pool.Spot == nil || !pool.Spot.Enabled:
+		result.addError(field, "instance weights are only supported on spot pools")
+	}
+
+	instanceTypes := make([]string, 0, len(pool.InstanceWeights))
+	for instanceType := range pool.InstanceWeights {
+		instanceTypes = append(instanceTypes, instanceType)
+	}
+	sort.Strings(instanceTypes)
+	for _, instanceType := range instanceTypes {
+		if weight := pool.InstanceWeights[instanceType]; weight <= 0 {
+			result.addError(field+"."+instanceType, "weight %d must be positive", weight)
+		}
+		if !slices.Contains(pool.AllInstanceTypes(), instanceType) {
+			result.addError(field+"."+instanceType, "%s is not one of the pool's instance types", instanceType)
+		}
+	}
+}
+
+// mismatched reports whether two sizes differ by more than maxSizeRatio
+func mismatched(a, b float64) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return a/b > maxSizeRatio || b/a > maxSizeRatio
+}
+
+func (v *Validator) validateBootstrap(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	if pool.UserData == "" {
+		return
+	}
+
+	field := "workerPools." + pool.Name + ".userData"
+
+	if limit, ok := userDataLimits[spec.Provider]; ok && len(pool.UserData) > limit {
+		result.addError(field, "user data is %d bytes, exceeds %s limit of %d bytes",
+			len(pool.UserData), spec.Provider, limit)
+	}
+
+	if spec.ControlPlane.Type == api.ControlPlaneManaged {
+		result.addWarning(field, "user data may be ignored or merged by the provider on managed node pools")
+	}
+}
+
+// validatePlacement warns when a pool meant to be highly available cannot be
+// spread evenly across the cluster's availability zones. Pools with a minimum
+// of fewer than two nodes are not expected to be highly available.
+func (v *Validator) validatePlacement(spec api.ClusterSpec, pool api.WorkerPoolSpec, result *Result) {
+	zones := len(spec.Network.AvailabilityZones)
+	if pool.MinSize < 2 || zones == 0 {
+		return
+	}
+
+	field := "workerPools." + pool.Name
+	if zones == 1 {
+		result.addWarning(field+".minSize", "pool has a minimum of %d nodes but the cluster has a single availability zone, so a zone outage takes down every node",
+			pool.MinSize)
+		return
+	}
+
+	size, sizeField := pool.DesiredSize, field+".desiredSize"
+	if size == 0 {
+		size, sizeField = pool.MinSize, field+".minSize"
+	}
+
+	switch {
+	case size < zones:
+		result.addWarning(sizeField, "%d nodes cannot cover the cluster's %d availability zones", size, zones)
+	case size%zones != 0:
+		result.addWarning(sizeField, "%d nodes cannot be spread evenly across the cluster's %d availability zones", size, zones)
+	}
+}
@@ -0,0 +1,795 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vjranagit/cluster-api/pkg/api"
+)
+
+func TestValidator_UserData(t *testing.T) {
+	validator := NewValidator()
+
+	tests := []struct {
+		name         string
+		provider     string
+		cpType       api.ControlPlaneType
+		userData     string
+		wantErrors   int
+		wantWarnings int
+	}{
+		{
+			name:         "no user data",
+			provider:     "aws",
+			cpType:       api.ControlPlaneSelfManaged,
+			wantErrors:   0,
+			wantWarnings: 0,
+		},
+		{
+			name:         "user data within EC2 limit",
+			provider:     "aws",
+			cpType:       api.ControlPlaneSelfManaged,
+			userData:     "#cloud-config\n",
+			wantErrors:   0,
+			wantWarnings: 0,
+		},
+		{
+			name:         "user data exceeds EC2 limit",
+			provider:     "aws",
+			cpType:       api.ControlPlaneSelfManaged,
+			userData:     strings.Repeat("x", 16*1024+1),
+			wantErrors:   1,
+			wantWarnings: 0,
+		},
+		{
+			name:         "same size fits Azure limit",
+			provider:     "azure",
+			cpType:       api.ControlPlaneSelfManaged,
+			userData:     strings.Repeat("x", 16*1024+1),
+			wantErrors:   0,
+			wantWarnings: 0,
+		},
+		{
+			name:         "user data on managed control plane",
+			provider:     "aws",
+			cpType:       api.ControlPlaneManaged,
+			userData:     "#cloud-config\n",
+			wantErrors:   0,
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				ControlPlane: api.ControlPlaneSpec{
+					Type: tt.cpType,
+				},
+				WorkerPools: []api.WorkerPoolSpec{
+					{
+						Name:         "general",
+						InstanceType: "t3.medium",
+						UserData:     tt.userData,
+					},
+				},
+			}
+
+			result := validator.Validate(spec)
+
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("Validate() got %d errors, want %d: %v", len(result.Errors), tt.wantErrors, result.Errors)
+			}
+
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() got %d warnings, want %d: %v", len(result.Warnings), tt.wantWarnings, result.Warnings)
+			}
+
+			if (result.Err() != nil) != (tt.wantErrors > 0) {
+				t.Errorf("Err() = %v, want error: %v", result.Err(), tt.wantErrors > 0)
+			}
+		})
+	}
+}
+
+func TestValidator_Placement(t *testing.T) {
+	tests := []struct {
+		name      string
+		zones     []string
+		minSize   int
+		desired   int
+		wantField string // empty means no warning
+	}{
+		{name: "single node pool", zones: []string{"a"}, minSize: 1},
+		{name: "no zones declared", minSize: 3},
+		{name: "HA pool in one zone", zones: []string{"a"}, minSize: 2, wantField: "workerPools.general.minSize"},
+		{name: "even spread", zones: []string{"a", "b", "c"}, minSize: 3, desired: 6},
+		{name: "uneven spread", zones: []string{"a", "b", "c"}, minSize: 3, desired: 4, wantField: "workerPools.general.desiredSize"},
+		{name: "fewer nodes than zones", zones: []string{"a", "b", "c"}, minSize: 2, desired: 2, wantField: "workerPools.general.desiredSize"},
+		{name: "min size checked without desired", zones: []string{"a", "b"}, minSize: 3, wantField: "workerPools.general.minSize"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: "aws",
+				Network:  api.NetworkSpec{AvailabilityZones: tt.zones},
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "general", MinSize: tt.minSize, MaxSize: 10, DesiredSize: tt.desired},
+				},
+			}
+
+			result := NewValidator().Validate(spec)
+			if len(result.Errors) != 0 {
+				t.Fatalf("Validate() errors = %v, want none", result.Errors)
+			}
+			if tt.wantField == "" {
+				if len(result.Warnings) != 0 {
+					t.Errorf("Validate() warnings = %v, want none", result.Warnings)
+				}
+				return
+			}
+			if len(result.Warnings) != 1 || result.Warnings[0].Field != tt.wantField {
+				t.Errorf("Validate() warnings = %v, want one for %s", result.Warnings, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidator_Network(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		network    api.NetworkSpec
+		wantErrors []string
+	}{
+		{name: "new network", provider: "aws", network: api.NetworkSpec{VPCCIDR: "10.0.0.0/16"}},
+		{
+			name:     "existing VPC",
+			provider: "aws",
+			network:  api.NetworkSpec{ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: []string{"subnet-1", "subnet-2"}},
+		},
+		{
+			name:       "CIDR and existing VPC",
+			provider:   "aws",
+			network:    api.NetworkSpec{VPCCIDR: "10.0.0.0/16", ExistingVPCID: "vpc-0abc", ExistingSubnetIDs: []string{"subnet-1"}},
+			wantErrors: []string{"network.vpcCidr"},
+		},
+		{
+			name:       "VNet on AWS",
+			provider:   "aws",
+			network:    api.NetworkSpec{ExistingVNetID: "/subscriptions/sub/vnet", ExistingSubnetIDs: []string{"subnet-1"}},
+			wantErrors: []string{"network.existingVnetId"},
+		},
+		{
+			name:       "existing VPC without subnets",
+			provider:   "aws",
+			network:    api.NetworkSpec{ExistingVPCID: "vpc-0abc", Subnets: []api.Subnet{{Name: "private"}}},
+			wantErrors: []string{"network.subnets", "network.existingSubnetIds"},
+		},
+		{
+			name:       "existing subnets without network",
+			provider:   "azure",
+			network:    api.NetworkSpec{VPCCIDR: "10.0.0.0/16", ExistingSubnetIDs: []string{"subnet-1"}},
+			wantErrors: []string{"network.existingSubnetIds"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator().Validate(api.ClusterSpec{Provider: tt.provider, Network: tt.network})
+			if got := issueFields(result.Errors); strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValidator_APIServerAccess(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		private      bool
+		access       *api.APIServerAccessSpec
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{name: "no access block"},
+		{name: "public with allowlist", access: &api.APIServerAccessSpec{PublicAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24", "198.51.100.7/32"}}},
+		{name: "private only", private: true, access: &api.APIServerAccessSpec{PrivateAccess: true}},
+		{
+			name:       "no access at all",
+			access:     &api.APIServerAccessSpec{},
+			wantErrors: []string{"network.apiServerAccess"},
+		},
+		{
+			name:       "invalid CIDR",
+			access:     &api.APIServerAccessSpec{PublicAccess: true, AuthorizedCIDRs: []string{"10.0.0.0/8", "10.0.0.300/32"}},
+			wantErrors: []string{"network.apiServerAccess.authorizedCidrs"},
+		},
+		{
+			name:       "public access on private cluster",
+			private:    true,
+			access:     &api.APIServerAccessSpec{PublicAccess: true, PrivateAccess: true},
+			wantErrors: []string{"network.apiServerAccess.publicAccess"},
+		},
+		{
+			name:         "allowlist on AKS private cluster",
+			provider:     "azure",
+			access:       &api.APIServerAccessSpec{PublicAccess: true, PrivateAccess: true, AuthorizedCIDRs: []string{"203.0.113.0/24"}},
+			wantWarnings: []string{"network.apiServerAccess.authorizedCidrs"},
+		},
+		{
+			name:         "allowlist without public endpoint",
+			access:       &api.APIServerAccessSpec{PrivateAccess: true, AuthorizedCIDRs: []string{"10.0.0.0/8"}},
+			wantWarnings: []string{"network.apiServerAccess.authorizedCidrs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := tt.provider
+			if provider == "" {
+				provider = "aws"
+			}
+			spec := api.ClusterSpec{
+				Provider: provider,
+				Network:  api.NetworkSpec{PrivateCluster: tt.private, APIServerAccess: tt.access},
+			}
+
+			result := NewValidator().Validate(spec)
+			if got := issueFields(result.Errors); strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+			if got := issueFields(result.Warnings); strings.Join(got, ",") != strings.Join(tt.wantWarnings, ",") {
+				t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidator_Logging(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		obs          *api.ObservabilitySpec
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{name: "no observability", provider: "aws"},
+		{name: "default types", provider: "aws", obs: &api.ObservabilitySpec{Logging: &api.LoggingSpec{}}},
+		{
+			name:     "supported types",
+			provider: "azure",
+			obs: &api.ObservabilitySpec{Logging: &api.LoggingSpec{
+				Destination: "/subscriptions/sub/resourceGroups/logs/providers/Microsoft.OperationalInsights/workspaces/clusters",
+				Types:       []string{api.LogTypeAudit, api.LogTypeClusterAutoscaler},
+			}},
+		},
+		{
+			name:       "unsupported type",
+			provider:   "aws",
+			obs:        &api.ObservabilitySpec{Logging: &api.LoggingSpec{Types: []string{api.LogTypeAudit, api.LogTypeClusterAutoscaler, "kubelet"}}},
+			wantErrors: []string{"observability.logging.types", "observability.logging.types"},
+		},
+		{name: "unknown provider", provider: "fake", obs: &api.ObservabilitySpec{Logging: &api.LoggingSpec{Types: []string{"kubelet"}}}},
+		{
+			name:         "AKS without workspace",
+			provider:     "azure",
+			obs:          &api.ObservabilitySpec{ControlPlaneLogs: true},
+			wantWarnings: []string{"observability.logging.destination"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidator().Validate(api.ClusterSpec{Provider: tt.provider, Observability: tt.obs})
+			if got := issueFields(result.Errors); strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+			if got := issueFields(result.Warnings); strings.Join(got, ",") != strings.Join(tt.wantWarnings, ",") {
+				t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidator_WarmPool(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		cpType     api.ControlPlaneType
+		warm       *api.WarmPoolConfig
+		wantErrors []string
+	}{
+		{name: "no warm pool", provider: "aws", cpType: api.ControlPlaneManaged},
+		{name: "disabled on managed", provider: "aws", cpType: api.ControlPlaneManaged, warm: &api.WarmPoolConfig{MinSize: 2}},
+		{
+			name:       "self-managed AWS",
+			provider:   "aws",
+			cpType:     api.ControlPlaneSelfManaged,
+			warm:       &api.WarmPoolConfig{Enabled: true, MinSize: 2, MaxPreparedCapacity: 15},
+			wantErrors: []string{"workerPools.general.warmPool"},
+		},
+		{
+			name:       "EKS control plane",
+			provider:   "aws",
+			cpType:     api.ControlPlaneManaged,
+			warm:       &api.WarmPoolConfig{Enabled: true, MinSize: 2},
+			wantErrors: []string{"workerPools.general.warmPool"},
+		},
+		{
+			name:       "azure",
+			provider:   "azure",
+			cpType:     api.ControlPlaneSelfManaged,
+			warm:       &api.WarmPoolConfig{Enabled: true},
+			wantErrors: []string{"workerPools.general.warmPool"},
+		},
+		{
+			name:       "inconsistent sizes",
+			provider:   "aws",
+			cpType:     api.ControlPlaneSelfManaged,
+			warm:       &api.WarmPoolConfig{Enabled: true, MinSize: -1, MaxPreparedCapacity: 1},
+			wantErrors: []string{"workerPools.general.warmPool", "workerPools.general.warmPool.minSize", "workerPools.general.warmPool.maxPreparedCapacity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider:     tt.provider,
+				ControlPlane: api.ControlPlaneSpec{Type: tt.cpType},
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "general", MinSize: 2, MaxSize: 10, WarmPool: tt.warm},
+				},
+			}
+
+			result := NewValidator().Validate(spec)
+			var got []string
+			for _, issue := range result.Errors {
+				got = append(got, issue.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValidator_SpotAllocationStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		defaults   string
+		pool       string
+		wantErrors []string
+	}{
+		{name: "none", provider: "aws"},
+		{name: "aws default", provider: "aws", defaults: api.SpotPriceCapacityOptimized, pool: api.SpotPriceCapacityOptimized},
+		{name: "azure default", provider: "azure", defaults: api.SpotCapacityOptimized, pool: api.SpotCapacityOptimized},
+		{name: "pool override", provider: "aws", defaults: api.SpotCapacityOptimized, pool: api.SpotLowestPrice},
+		{
+			name:       "unknown default reported once",
+			provider:   "aws",
+			defaults:   "cheapest",
+			pool:       "cheapest",
+			wantErrors: []string{"spotDefaults.allocationStrategy"},
+		},
+		{
+			name:       "unknown pool strategy",
+			provider:   "aws",
+			pool:       "diversified",
+			wantErrors: []string{"workerPools.batch.spot.allocationStrategy"},
+		},
+		{
+			name:       "unsupported on azure",
+			provider:   "azure",
+			defaults:   api.SpotPriceCapacityOptimized,
+			pool:       api.SpotPriceCapacityOptimized,
+			wantErrors: []string{"spotDefaults.allocationStrategy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "batch", MinSize: 2, MaxSize: 10, Spot: &api.SpotConfig{Enabled: true, AllocationStrategy: tt.pool}},
+				},
+			}
+			if tt.defaults != "" {
+				spec.SpotDefaults = &api.SpotDefaults{AllocationStrategy: tt.defaults}
+			}
+
+			result := NewValidator().Validate(spec)
+			if got := issueFields(result.Errors); !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestValidator_SpotFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		minSize    int
+		spot       api.SpotConfig
+		wantFields []string
+	}{
+		{name: "spot pool", minSize: 2, spot: api.SpotConfig{Enabled: true, FallbackToOnDemand: true}},
+		{
+			name:       "spot disabled",
+			minSize:    2,
+			spot:       api.SpotConfig{FallbackToOnDemand: true},
+			wantFields: []string{"workerPools.batch.spot.fallbackToOnDemand"},
+		},
+		{
+			name:       "no minimum",
+			spot:       api.SpotConfig{Enabled: true, FallbackToOnDemand: true},
+			wantFields: []string{"workerPools.batch.spot.fallbackToOnDemand"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spot := tt.spot
+			spec := api.ClusterSpec{
+				Provider:    "aws",
+				Region:      "us-west-2",
+				WorkerPools: []api.WorkerPoolSpec{{Name: "batch", MinSize: tt.minSize, MaxSize: 10, Spot: &spot}},
+			}
+
+			result := NewValidator().Validate(spec)
+			if result.HasErrors() {
+				t.Errorf("Validate() errors = %v, want none", result.Errors)
+			}
+			if got := issueFields(result.Warnings); !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidator_InstanceTypes(t *testing.T) {
+	spot := &api.SpotConfig{Enabled: true}
+
+	tests := []struct {
+		name         string
+		provider     string
+		primary      string
+		alternatives []string
+		spot         *api.SpotConfig
+		wantErrors   int
+		wantWarnings int
+	}{
+		{name: "single type", provider: "aws", primary: "m5.large"},
+		{name: "comparable types", provider: "aws", primary: "m5.large", alternatives: []string{"c5.large", "r5.large"}, spot: spot},
+		{name: "unpriced types are not compared", provider: "aws", primary: "m5.large", alternatives: []string{"m6i.4xlarge"}, spot: spot},
+		{name: "on-demand pool", provider: "aws", primary: "m5.large", alternatives: []string{"c5.large"}, wantErrors: 1},
+		{name: "mixed architectures", provider: "aws", primary: "m5.large", alternatives: []string{"m6g.large"}, spot: spot, wantErrors: 1},
+		{name: "mixed azure architectures", provider: "azure", primary: "Standard_D4s_v5", alternatives: []string{"Standard_D4ps_v5"}, spot: spot, wantErrors: 1},
+		{name: "mismatched sizes", provider: "aws", primary: "m5.large", alternatives: []string{"m5.2xlarge", "t3.xlarge"}, spot: spot, wantWarnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				Region:   "us-west-2",
+				WorkerPools: []api.WorkerPoolSpec{{
+					Name:          "batch",
+					InstanceType:  tt.primary,
+					InstanceTypes: tt.alternatives,
+					MaxSize:       10,
+					Spot:          tt.spot,
+				}},
+			}
+
+			result := NewValidator().Validate(spec)
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("Validate() errors = %v, want %d", result.Errors, tt.wantErrors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+			for _, issue := range append(result.Errors, result.Warnings...) {
+				if issue.Field != "workerPools.batch.instanceTypes" {
+					t.Errorf("issue field = %s, want workerPools.batch.instanceTypes", issue.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_InstanceWeights(t *testing.T) {
+	spot := &api.SpotConfig{Enabled: true}
+
+	tests := []struct {
+		name         string
+		provider     string
+		weights      map[string]int
+		spot         *api.SpotConfig
+		wantErrors   []string
+		wantWarnings int
+	}{
+		{name: "weights make sizes comparable", provider: "aws", weights: map[string]int{"m5.2xlarge": 4}, spot: spot},
+		{name: "unweighted sizes mismatch", provider: "aws", spot: spot, wantWarnings: 1},
+		{
+			name:         "non-positive weight",
+			provider:     "aws",
+			weights:      map[string]int{"m5.large": 0, "m5.2xlarge": -4},
+			spot:         spot,
+			wantErrors:   []string{"workerPools.batch.instanceWeights.m5.2xlarge", "workerPools.batch.instanceWeights.m5.large"},
+			wantWarnings: 1, // Invalid weights count as 1
+		},
+		{
+			name:       "unknown type",
+			provider:   "aws",
+			weights:    map[string]int{"m5.2xlarge": 4, "c5.xlarge": 2},
+			spot:       spot,
+			wantErrors: []string{"workerPools.batch.instanceWeights.c5.xlarge"},
+		},
+		{
+			name:       "on-demand pool",
+			provider:   "aws",
+			weights:    map[string]int{"m5.2xlarge": 4},
+			wantErrors: []string{"workerPools.batch.instanceTypes", "workerPools.batch.instanceWeights"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				Region:   "us-west-2",
+				WorkerPools: []api.WorkerPoolSpec{{
+					Name:            "batch",
+					InstanceType:    "m5.large",
+					InstanceTypes:   []string{"m5.2xlarge"},
+					InstanceWeights: tt.weights,
+					MaxSize:         10,
+					Spot:            tt.spot,
+				}},
+			}
+
+			result := NewValidator().Validate(spec)
+			var got []string
+			for _, issue := range result.Errors {
+				got = append(got, issue.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantErrors, ",") {
+				t.Errorf("Validate() errors = %v, want fields %v", result.Errors, tt.wantErrors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestValidator_MaxPods(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     string
+		instanceType string
+		imageID      string
+		maxPods      int
+		wantErrors   int
+		wantWarnings int
+	}{
+		{name: "default", provider: "aws", instanceType: "m5.large"},
+		{name: "within ENI capacity", provider: "aws", instanceType: "m5.large", maxPods: 29},
+		{name: "over ENI capacity", provider: "aws", instanceType: "m5.large", maxPods: 110, wantWarnings: 1},
+		{name: "unpriced type", provider: "aws", instanceType: "m6i.4xlarge", maxPods: 110},
+		{name: "negative", provider: "aws", instanceType: "m5.large", maxPods: -1, wantErrors: 1},
+		{name: "custom image", provider: "aws", instanceType: "m5.large", imageID: "ami-0123456789abcdef0", maxPods: 29, wantWarnings: 1},
+		{name: "custom image without limit", provider: "aws", instanceType: "m5.large", imageID: "ami-0123456789abcdef0"},
+		{name: "azure default", provider: "azure", instanceType: "Standard_D4s_v5"},
+		{name: "azure", provider: "azure", instanceType: "Standard_D4s_v5", maxPods: 110, wantErrors: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				Region:   "us-west-2",
+				WorkerPools: []api.WorkerPoolSpec{{
+					Name:         "general",
+					InstanceType: tt.instanceType,
+					MaxSize:      3,
+					ImageID:      tt.imageID,
+					MaxPods:      tt.maxPods,
+				}},
+			}
+
+			result := NewValidator().Validate(spec)
+			if len(result.Errors) != tt.wantErrors {
+				t.Errorf("Validate() errors = %v, want %d", result.Errors, tt.wantErrors)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("Validate() warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+			for _, issue := range append(result.Errors, result.Warnings...) {
+				if issue.Field != "workerPools.general.maxPods" {
+					t.Errorf("issue field = %s, want workerPools.general.maxPods", issue.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_NodeRegistration(t *testing.T) {
+	labels := map[string]string{"tier": "gpu"}
+	taints := []api.Taint{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}
+
+	tests := []struct {
+		name         string
+		provider     string
+		imageID      string
+		labels       map[string]string
+		taints       []api.Taint
+		wantWarnings int
+	}{
+		{name: "default image", provider: "aws", labels: labels, taints: taints},
+		{name: "custom image with labels", provider: "aws", imageID: "ami-0123456789abcdef0", labels: labels, wantWarnings: 1},
+		{name: "custom image with taints", provider: "aws", imageID: "ami-0123456789abcdef0", taints: taints, wantWarnings: 1},
+		{name: "custom image alone", provider: "aws", imageID: "ami-0123456789abcdef0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{
+				Provider: tt.provider,
+				Region:   "us-west-2",
+				WorkerPools: []api.WorkerPoolSpec{
+					{Name: "general", InstanceType: "m5.large", MaxSize: 3},
+					{Name: "gpu", InstanceType: "m5.large", MaxSize: 3, ImageID: tt.imageID, Labels: tt.labels, Taints: tt.taints},
+				},
+			}
+
+			result := NewValidator().Validate(spec)
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Fatalf("Validate() warnings = %v, want %d", result.Warnings, tt.wantWarnings)
+			}
+			for _, issue := range result.Warnings {
+				if issue.Field != "workerPools.gpu.imageId" {
+					t.Errorf("warning field = %s, want workerPools.gpu.imageId", issue.Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_Taints(t *testing.T) {
+	dedicated := func(effect string) []api.Taint {
+		return []api.Taint{{Key: "dedicated", Value: "gpu", Effect: effect}}
+	}
+
+	tests := []struct {
+		name       string
+		pools      []api.WorkerPoolSpec
+		wantFields []string
+	}{
+		{
+			name: "untainted default pool",
+			pools: []api.WorkerPoolSpec{
+				{Name: "general"},
+				{Name: "gpu", Taints: dedicated("NoSchedule")},
+			},
+		},
+		{
+			name: "all pools tainted",
+			pools: []api.WorkerPoolSpec{
+				{Name: "gpu", Taints: dedicated("NoSchedule")},
+				{Name: "batch", Taints: dedicated("NoExecute")},
+			},
+			wantFields: []string{"workerPools"},
+		},
+		{
+			name: "prefer no schedule still schedules",
+			pools: []api.WorkerPoolSpec{
+				{Name: "gpu", Taints: dedicated("NoSchedule")},
+				{Name: "spare", Taints: dedicated("PreferNoSchedule")},
+			},
+		},
+		{
+			name: "effect typo",
+			pools: []api.WorkerPoolSpec{
+				{Name: "general"},
+				{Name: "gpu", Taints: append(dedicated("NoSchedule"), api.Taint{Key: "spot", Effect: "noschedule"})},
+			},
+			wantFields: []string{"workerPools.gpu.taints[1].effect"},
+		},
+		{
+			name: "unknown effect on the only tainted pool",
+			pools: []api.WorkerPoolSpec{
+				{Name: "gpu", Taints: dedicated("Evict")},
+			},
+			wantFields: []string{"workerPools.gpu.taints[0].effect"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := api.ClusterSpec{Provider: "aws", Region: "us-west-2", WorkerPools: tt.pools}
+
+			result := NewValidator().Validate(spec)
+			if result.HasErrors() {
+				t.Errorf("Validate() errors = %v, want none", result.Errors)
+			}
+			if got := issueFields(result.Warnings); !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, tt.wantFields)
+			}
+		})
+	}
+
+	result := NewValidator().Validate(api.ClusterSpec{WorkerPools: []api.WorkerPoolSpec{
+		{Name: "gpu", Taints: dedicated("No_Schedule")},
+	}})
+	if len(result.Warnings) == 0 || !strings.Contains(result.Warnings[0].Message, "did you mean NoSchedule?") {
+		t.Errorf("Validate() warnings = %v, want a suggested effect", result.Warnings)
+	}
+}
+
+func TestValidator_RequiredTags(t *testing.T) {
+	spec := api.ClusterSpec{
+		Network: api.NetworkSpec{AvailabilityZones: []string{"a"}},
+		Tags:    map[string]string{"team": "platform", "owner": ""},
+	}
+
+	validator := NewValidator()
+	if result := validator.Validate(spec); result.HasErrors() {
+		t.Fatalf("Validate() errors = %v, want none without required tags", result.Errors)
+	}
+
+	validator.SetRequiredTags([]string{"team", "owner", "cost-center"})
+	got := issueFields(validator.Validate(spec).Errors)
+	want := []string{"tags.owner", "tags.cost-center"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() error fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidator_Strict(t *testing.T) {
+	spec := api.ClusterSpec{
+		Network:     api.NetworkSpec{AvailabilityZones: []string{"a"}},
+		WorkerPools: []api.WorkerPoolSpec{{Name: "general", MinSize: 2, MaxSize: 4}},
+	}
+
+	validator := NewValidator()
+	validator.SetStrict(true)
+	result := validator.Validate(spec)
+
+	if len(result.Warnings) != 0 || len(result.Errors) != 1 {
+		t.Fatalf("Validate() = %d errors, %d warnings, want the warning reported as an error",
+			len(result.Errors), len(result.Warnings))
+	}
+	if result.Err() == nil {
+		t.Error("Err() = nil, want an error in strict mode")
+	}
+}
+
+func issueFields(issues []Issue) []string {
+	var fields []string
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	return fields
+}
+
+func TestValidator_Priorities(t *testing.T) {
+	spec := api.ClusterSpec{Provider: "aws", Region: "us-west-2", WorkerPools: []api.WorkerPoolSpec{
+		{Name: "reserved", Priority: 50},
+		{Name: "spot", Priority: 20},
+		{Name: "spot-large", Priority: 20},
+		{Name: "fallback"},
+		{Name: "fallback-large"},
+	}}
+
+	result := NewValidator().Validate(spec)
+	if want := []string{"workerPools.spot-large.priority"}; !reflect.DeepEqual(issueFields(result.Warnings), want) {
+		t.Errorf("Validate() warnings = %v, want fields %v", result.Warnings, want)
+	}
+}